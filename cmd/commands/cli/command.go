@@ -22,7 +22,9 @@ import (
 	"fmt"
 	"io"
 	stdlog "log"
+	"net"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/chzyer/readline"
@@ -271,17 +273,19 @@ func (c *cliApp) serviceStop(id string) {
 }
 
 func (c *cliApp) serviceList() {
-	services, err := c.tequilapi.Services()
+	groups, err := c.tequilapi.ServicesByProvider()
 	if err != nil {
 		info("Failed to get a list of services: ", err)
 		return
 	}
 
-	for _, service := range services {
-		status(service.Status,
-			"ID: "+service.ID,
-			"ProviderID: "+service.Proposal.ProviderID,
-			"Type: "+service.Proposal.ServiceType)
+	for _, group := range groups {
+		info("Provider:", group.ProviderID)
+		for _, service := range group.Services {
+			status(service.Status,
+				"ID: "+service.ID,
+				"Type: "+service.Proposal.ServiceType)
+		}
 	}
 }
 
@@ -314,7 +318,12 @@ func (c *cliApp) serviceGet(id string) {
 func (c *cliApp) connect(argsString string) {
 	args := strings.Fields(argsString)
 
-	helpMsg := "Please type in the provider identity. connect <consumer-identity> <provider-identity> <service-type> [dns=auto|provider|system|1.1.1.1] [disable-kill-switch]"
+	helpMsg := "Please type in the provider identity. connect <consumer-identity> <provider-identity> <service-type> [dns=auto|provider|system|doh|dot|1.1.1.1|https://1.1.1.1/dns-query|tls://1.1.1.1] [disable-kill-switch] [include=10.0.0.0/8,example.com] [exclude=192.168.0.0/16,example.org]\n" +
+		"Or let the node choose the best provider. connect <consumer-identity> --auto [service-type=openvpn|wireguard] [country=DE] [node-type=residential] [filtered-dns] [max-price-minute=50000] [max-price-gb=7000000] [dns=auto|provider|system|doh|dot|1.1.1.1|https://1.1.1.1/dns-query|tls://1.1.1.1] [disable-kill-switch] [include=10.0.0.0/8,example.com] [exclude=192.168.0.0/16,example.org]"
+	if len(args) >= 2 && args[1] == "--auto" {
+		c.connectAuto(args[0], args[2:], helpMsg)
+		return
+	}
 	if len(args) < 3 {
 		info(helpMsg)
 		return
@@ -322,49 +331,69 @@ func (c *cliApp) connect(argsString string) {
 
 	consumerID, providerID, serviceType := args[0], args[1], args[2]
 
-	var disableKillSwitch bool
-	var dns connection.DNSOption
-	var err error
+	var connectOptions tequilapi_client.ConnectOptions
 	for _, arg := range args[3:] {
-		if strings.HasPrefix(arg, "dns=") {
-			kv := strings.Split(arg, "=")
-			dns, err = connection.NewDNSOption(kv[1])
-			if err != nil {
-				warn("Invalid value: ", err)
-				info(helpMsg)
-				return
-			}
-			continue
+		ok, err := parseConnectOption(arg, &connectOptions)
+		if err != nil {
+			warn("Invalid value: ", err)
+			info(helpMsg)
+			return
 		}
-		switch arg {
-		case "disable-kill-switch":
-			disableKillSwitch = true
-		default:
+		if !ok {
 			warn("Unexpected arg:", arg)
 			info(helpMsg)
 			return
 		}
 	}
 
-	connectOptions := tequilapi_client.ConnectOptions{
-		DNS:               dns,
-		DisableKillSwitch: disableKillSwitch,
+	consumerID, ok := c.resolveConsumerID(consumerID)
+	if !ok {
+		return
+	}
+
+	status("CONNECTING", "from:", consumerID, "to:", providerID)
+
+	accountantID := config.GetString(config.FlagAccountantID)
+	_, err := c.tequilapi.ConnectionCreate(consumerID, providerID, accountantID, serviceType, connectOptions)
+	if err != nil {
+		warn(err)
+		return
 	}
 
-	if consumerID == "new" {
-		id, err := c.tequilapi.NewIdentity(identityDefaultPassphrase)
+	c.currentConsumerID = consumerID
+
+	success("Connected.")
+}
+
+func (c *cliApp) connectAuto(consumerID string, args []string, helpMsg string) {
+	var filter tequilapi_client.QuickConnectFilter
+	var connectOptions tequilapi_client.ConnectOptions
+	for _, arg := range args {
+		ok, err := parseConnectOption(arg, &connectOptions)
+		if err == nil && !ok {
+			ok, err = parseQuickConnectFilter(arg, &filter)
+		}
 		if err != nil {
-			warn(err)
+			warn("Invalid value: ", err)
+			info(helpMsg)
+			return
+		}
+		if !ok {
+			warn("Unexpected arg:", arg)
+			info(helpMsg)
 			return
 		}
-		consumerID = id.Address
-		success("New identity created:", consumerID)
 	}
 
-	status("CONNECTING", "from:", consumerID, "to:", providerID)
+	consumerID, ok := c.resolveConsumerID(consumerID)
+	if !ok {
+		return
+	}
+
+	status("CONNECTING", "from:", consumerID, "to the best provider")
 
 	accountantID := config.GetString(config.FlagAccountantID)
-	_, err = c.tequilapi.ConnectionCreate(consumerID, providerID, accountantID, serviceType, connectOptions)
+	result, err := c.tequilapi.ConnectionCreateQuick(consumerID, accountantID, filter, connectOptions)
 	if err != nil {
 		warn(err)
 		return
@@ -372,7 +401,109 @@ func (c *cliApp) connect(argsString string) {
 
 	c.currentConsumerID = consumerID
 
-	success("Connected.")
+	for _, providerID := range result.FailedProviders {
+		warn("Failed to connect to provider:", providerID)
+	}
+	success("Connected to provider:", result.Proposal.ProviderID, "service:", result.Proposal.ServiceType)
+}
+
+func (c *cliApp) resolveConsumerID(consumerID string) (string, bool) {
+	if consumerID != "new" {
+		return consumerID, true
+	}
+
+	id, err := c.tequilapi.NewIdentity(identityDefaultPassphrase)
+	if err != nil {
+		warn(err)
+		return "", false
+	}
+	success("New identity created:", id.Address)
+	return id.Address, true
+}
+
+func parseConnectOption(arg string, options *tequilapi_client.ConnectOptions) (bool, error) {
+	if strings.HasPrefix(arg, "dns=") {
+		kv := strings.SplitN(arg, "=", 2)
+		dns, err := connection.NewDNSOption(kv[1])
+		if err != nil {
+			return false, err
+		}
+		options.DNS = dns
+		return true, nil
+	}
+	if arg == "disable-kill-switch" {
+		options.DisableKillSwitch = true
+		return true, nil
+	}
+	if strings.HasPrefix(arg, "include=") || strings.HasPrefix(arg, "exclude=") {
+		return true, parseSplitTunnelOption(arg, options)
+	}
+	return false, nil
+}
+
+// parseSplitTunnelOption parses comma separated networks and domains to be included in or excluded from the tunnel.
+func parseSplitTunnelOption(arg string, options *tequilapi_client.ConnectOptions) error {
+	if options.SplitTunnel == nil {
+		options.SplitTunnel = &tequilapi_client.SplitTunnelOptions{}
+	}
+	st := options.SplitTunnel
+
+	kv := strings.SplitN(arg, "=", 2)
+	for _, value := range strings.Split(kv[1], ",") {
+		_, _, cidrErr := net.ParseCIDR(value)
+		isRoute := cidrErr == nil || net.ParseIP(value) != nil
+		switch {
+		case kv[0] == "include" && isRoute:
+			st.IncludedRoutes = append(st.IncludedRoutes, value)
+		case kv[0] == "include":
+			st.IncludedDomains = append(st.IncludedDomains, value)
+		case isRoute:
+			st.ExcludedRoutes = append(st.ExcludedRoutes, value)
+		default:
+			st.ExcludedDomains = append(st.ExcludedDomains, value)
+		}
+	}
+
+	return connection.SplitTunnel{
+		IncludedRoutes:  st.IncludedRoutes,
+		ExcludedRoutes:  st.ExcludedRoutes,
+		IncludedDomains: st.IncludedDomains,
+		ExcludedDomains: st.ExcludedDomains,
+	}.Validate()
+}
+
+func parseQuickConnectFilter(arg string, filter *tequilapi_client.QuickConnectFilter) (bool, error) {
+	if arg == "filtered-dns" {
+		filter.FilteredDNS = true
+		return true, nil
+	}
+
+	kv := strings.SplitN(arg, "=", 2)
+	if len(kv) != 2 {
+		return false, nil
+	}
+
+	switch kv[0] {
+	case "service-type":
+		filter.ServiceType = kv[1]
+	case "country":
+		filter.LocationCountry = kv[1]
+	case "node-type":
+		filter.LocationType = kv[1]
+	case "max-price-minute", "max-price-gb":
+		price, err := strconv.ParseUint(kv[1], 10, 64)
+		if err != nil {
+			return false, err
+		}
+		if kv[0] == "max-price-minute" {
+			filter.UpperTimePriceBound = &price
+		} else {
+			filter.UpperGBPriceBound = &price
+		}
+	default:
+		return false, nil
+	}
+	return true, nil
 }
 
 func (c *cliApp) payout(argsString string) {
@@ -610,13 +741,21 @@ func newAutocompleter(tequilapi *tequilapi_client.Client, proposals []tequilapi_
 		readline.PcItem("dns=auto"),
 		readline.PcItem("dns=provider"),
 		readline.PcItem("dns=system"),
+		readline.PcItem("dns=doh"),
+		readline.PcItem("dns=dot"),
 		readline.PcItem("dns=1.1.1.1"),
+		readline.PcItem("include="),
+		readline.PcItem("exclude="),
 	}
 	return readline.NewPrefixCompleter(
 		readline.PcItem(
 			"connect",
 			readline.PcItemDynamic(
 				getIdentityOptionList(tequilapi),
+				readline.PcItem("--auto", append(connectOpts,
+					readline.PcItem("service-type=openvpn"),
+					readline.PcItem("service-type=wireguard"),
+				)...),
 				readline.PcItemDynamic(
 					getProposalOptionList(proposals),
 					readline.PcItem("noop", connectOpts...),
@@ -644,6 +783,10 @@ func newAutocompleter(tequilapi *tequilapi_client.Client, proposals []tequilapi_
 			readline.PcItem("get", readline.PcItemDynamic(getIdentityOptionList(tequilapi))),
 			readline.PcItem("new"),
 			readline.PcItem("unlock", readline.PcItemDynamic(getIdentityOptionList(tequilapi))),
+			readline.PcItem("lock", readline.PcItemDynamic(getIdentityOptionList(tequilapi))),
+			readline.PcItem("passphrase", readline.PcItemDynamic(getIdentityOptionList(tequilapi))),
+			readline.PcItem("export", readline.PcItemDynamic(getIdentityOptionList(tequilapi))),
+			readline.PcItem("import"),
 			readline.PcItem("register", readline.PcItemDynamic(getIdentityOptionList(tequilapi))),
 			readline.PcItem("topup", readline.PcItemDynamic(getIdentityOptionList(tequilapi))),
 		),
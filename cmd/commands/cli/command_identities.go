@@ -18,13 +18,16 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
 	"github.com/pkg/errors"
 )
 
@@ -36,6 +39,10 @@ func (c *cliApp) identities(argsString string) {
 		"  " + usageGetIdentity,
 		"  " + usageNewIdentity,
 		"  " + usageUnlockIdentity,
+		"  " + usageLockIdentity,
+		"  " + usageChangePassphrase,
+		"  " + usageExportIdentity,
+		"  " + usageImportIdentity,
 		"  " + usageRegisterIdentity,
 		"  " + usageTopupIdentity,
 		"  " + usageSettle,
@@ -59,6 +66,14 @@ func (c *cliApp) identities(argsString string) {
 		c.newIdentity(actionArgs)
 	case "unlock":
 		c.unlockIdentity(actionArgs)
+	case "lock":
+		c.lockIdentity(actionArgs)
+	case "passphrase":
+		c.changePassphrase(actionArgs)
+	case "export":
+		c.exportIdentity(actionArgs)
+	case "import":
+		c.importIdentity(actionArgs)
 	case "register":
 		c.registerIdentity(actionArgs)
 	case "topup":
@@ -154,6 +169,113 @@ func (c *cliApp) unlockIdentity(actionArgs []string) {
 	success(fmt.Sprintf("Identity %s unlocked.", address))
 }
 
+const usageLockIdentity = "lock <identity>"
+
+func (c *cliApp) lockIdentity(actionArgs []string) {
+	if len(actionArgs) != 1 {
+		info("Usage: " + usageLockIdentity)
+		return
+	}
+
+	address := actionArgs[0]
+	info("Locking", address)
+	err := c.tequilapi.Lock(address)
+	if err != nil {
+		warn(err)
+		return
+	}
+
+	success(fmt.Sprintf("Identity %s locked.", address))
+}
+
+const usageChangePassphrase = "passphrase <identity> <new passphrase> [passphrase]"
+
+func (c *cliApp) changePassphrase(actionArgs []string) {
+	if len(actionArgs) < 2 {
+		info("Usage: " + usageChangePassphrase)
+		return
+	}
+
+	address, newPassphrase := actionArgs[0], actionArgs[1]
+	var passphrase string
+	if len(actionArgs) >= 3 {
+		passphrase = actionArgs[2]
+	}
+
+	err := c.tequilapi.ChangePassphrase(address, passphrase, newPassphrase)
+	if err != nil {
+		warn(err)
+		return
+	}
+
+	success(fmt.Sprintf("Passphrase of identity %s changed.", address))
+}
+
+const usageExportIdentity = "export <identity> <file> <backup passphrase> [passphrase]"
+
+func (c *cliApp) exportIdentity(actionArgs []string) {
+	if len(actionArgs) < 3 || len(actionArgs) > 4 {
+		info("Usage: " + usageExportIdentity)
+		return
+	}
+
+	address, file, backupPassphrase := actionArgs[0], actionArgs[1], actionArgs[2]
+	passphrase := identityDefaultPassphrase
+	if len(actionArgs) == 4 {
+		passphrase = actionArgs[3]
+	}
+
+	backup, err := c.tequilapi.ExportIdentity(address, passphrase, backupPassphrase)
+	if err != nil {
+		warn(errors.Wrap(err, "could not export identity"))
+		return
+	}
+
+	data, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		warn(err)
+		return
+	}
+	if err := ioutil.WriteFile(file, data, 0600); err != nil {
+		warn(errors.Wrap(err, "could not write identity backup"))
+		return
+	}
+	success(fmt.Sprintf("Identity %s exported to %s", address, file))
+}
+
+const usageImportIdentity = "import <file> <backup passphrase> [new passphrase]"
+
+func (c *cliApp) importIdentity(actionArgs []string) {
+	if len(actionArgs) < 2 || len(actionArgs) > 3 {
+		info("Usage: " + usageImportIdentity)
+		return
+	}
+
+	file, backupPassphrase := actionArgs[0], actionArgs[1]
+	newPassphrase := backupPassphrase
+	if len(actionArgs) == 3 {
+		newPassphrase = actionArgs[2]
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		warn(errors.Wrap(err, "could not read identity backup"))
+		return
+	}
+	var backup contract.IdentityBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		warn(errors.Wrap(err, "could not parse identity backup"))
+		return
+	}
+
+	id, err := c.tequilapi.ImportIdentity(backup, backupPassphrase, newPassphrase)
+	if err != nil {
+		warn(errors.Wrap(err, "could not import identity"))
+		return
+	}
+	success("Identity imported:", id.Address)
+}
+
 const usageRegisterIdentity = "register <identity> [stake] [beneficiary]"
 
 func (c *cliApp) registerIdentity(actionArgs []string) {
@@ -110,11 +110,26 @@ func (sc *serviceCommand) Run(ctx *cli.Context) (err error) {
 		serviceTypes = strings.Split(arg, ",")
 	}
 
-	providerID := sc.unlockIdentity(parseIdentityFlags(ctx))
-	log.Info().Msgf("Unlocked identity: %v", providerID.Address)
+	identityOptions := parseIdentityFlags(ctx)
+	addresses := strings.Split(identityOptions.Identity, ",")
+	passphrases := strings.Split(identityOptions.Passphrase, ",")
+	for i, address := range addresses {
+		// Comma separated passphrases are matched to identities by position,
+		// otherwise the same passphrase unlocks every identity.
+		passphrase := identityOptions.Passphrase
+		if len(addresses) > 1 && len(passphrases) == len(addresses) {
+			passphrase = passphrases[i]
+		}
+
+		providerID := sc.unlockIdentity(service.OptionsIdentity{
+			Identity:   strings.TrimSpace(address),
+			Passphrase: passphrase,
+		})
+		log.Info().Msgf("Unlocked identity: %v", providerID.Address)
 
-	if err := sc.runServices(ctx, providerID.Address, serviceTypes); err != nil {
-		return err
+		if err := sc.runServices(ctx, providerID.Address, serviceTypes); err != nil {
+			return err
+		}
 	}
 
 	return <-sc.errorChannel
@@ -147,6 +162,11 @@ func (sc *serviceCommand) runServices(ctx *cli.Context, providerID string, servi
 
 func (sc *serviceCommand) runService(providerID, serviceType string, options service.Options) {
 	_, err := sc.tequilapi.ServiceStart(providerID, serviceType, options, sc.ap)
+	if err == client.ErrServiceAlreadyRunning {
+		// Services restored from the previous run are replaced by the node, so this one was started through the API.
+		log.Info().Msgf("Service %s is already running", serviceType)
+		return
+	}
 	if err != nil {
 		sc.errorChannel <- errors.Wrapf(err, "failed to run service %s", serviceType)
 	}
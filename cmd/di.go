@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"net"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/keystore"
@@ -33,6 +34,7 @@ import (
 	"github.com/mysteriumnetwork/node/config"
 	appconfig "github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/consumer/bandwidth"
+	"github.com/mysteriumnetwork/node/consumer/budget"
 	consumer_session "github.com/mysteriumnetwork/node/consumer/session"
 	"github.com/mysteriumnetwork/node/consumer/statistics"
 	"github.com/mysteriumnetwork/node/core/auth"
@@ -40,14 +42,19 @@ import (
 	"github.com/mysteriumnetwork/node/core/discovery"
 	"github.com/mysteriumnetwork/node/core/discovery/brokerdiscovery"
 	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/core/discovery/ranking"
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/core/location"
+	"github.com/mysteriumnetwork/node/core/metrics"
 	"github.com/mysteriumnetwork/node/core/node"
 	nodevent "github.com/mysteriumnetwork/node/core/node/event"
 	"github.com/mysteriumnetwork/node/core/policy"
 	"github.com/mysteriumnetwork/node/core/port"
 	"github.com/mysteriumnetwork/node/core/quality"
+	"github.com/mysteriumnetwork/node/core/quickconnect"
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/core/service/restore"
+	"github.com/mysteriumnetwork/node/core/service/schedule"
 	"github.com/mysteriumnetwork/node/core/state"
 	"github.com/mysteriumnetwork/node/core/storage/boltdb"
 	"github.com/mysteriumnetwork/node/core/storage/boltdb/migrations/history"
@@ -73,8 +80,12 @@ import (
 	service_noop "github.com/mysteriumnetwork/node/services/noop"
 	service_openvpn "github.com/mysteriumnetwork/node/services/openvpn"
 	"github.com/mysteriumnetwork/node/session"
+	"github.com/mysteriumnetwork/node/session/admission"
 	"github.com/mysteriumnetwork/node/session/connectivity"
+	sessionEvent "github.com/mysteriumnetwork/node/session/event"
+	session_history "github.com/mysteriumnetwork/node/session/history"
 	"github.com/mysteriumnetwork/node/session/pingpong"
+	"github.com/mysteriumnetwork/node/session/quota"
 	"github.com/mysteriumnetwork/node/tequilapi"
 	tequilapi_endpoints "github.com/mysteriumnetwork/node/tequilapi/endpoints"
 	"github.com/mysteriumnetwork/node/utils"
@@ -110,17 +121,24 @@ type Dependencies struct {
 	IdentityRegistry identity_registry.IdentityRegistry
 	IdentitySelector identity_selector.Handler
 
+	RegistrationStatusStorage *identity_registry.RegistrationStatusStorage
+
 	DiscoveryFactory   service.DiscoveryFactory
 	ProposalRepository proposal.Repository
+	ProposalScorer     ranking.Scorer
 	DiscoveryWorker    brokerdiscovery.Worker
 
 	QualityMetricsSender *quality.Sender
 	QualityClient        *quality.MysteriumMORQA
 
+	MetricsRegistry  *metrics.Registry
+	MetricsCollector *metrics.Collector
+
 	IPResolver       ip.Resolver
 	LocationResolver *location.Cache
 
-	PolicyOracle *policy.Oracle
+	PolicyOracle  *policy.Oracle
+	LocalPolicies *policy.LocalPolicies
 
 	StatisticsTracker                *statistics.SessionStatisticsTracker
 	StatisticsReporter               *statistics.SessionStatisticsReporter
@@ -131,10 +149,17 @@ type Dependencies struct {
 
 	ConnectionManager  connection.Manager
 	ConnectionRegistry *connection.Registry
+	BudgetTracker      *budget.Tracker
 
 	ServicesManager       *service.Manager
 	ServiceRegistry       *service.Registry
+	ServiceScheduler      *schedule.Scheduler
+	ServiceRestorer       *restore.Restorer
 	ServiceSessionStorage *session.EventBasedStorage
+	ServiceSessionHistory *session_history.Storage
+	QuotaEnforcer         *quota.Enforcer
+	DenyList              *policy.DenyList
+	SessionAdmission      *admission.Controller
 	ServiceFirewall       firewall.IncomingTrafficFirewall
 
 	NATPinger      traversal.NATPinger
@@ -162,6 +187,7 @@ type Dependencies struct {
 	ProviderInvoiceStorage   *pingpong.ProviderInvoiceStorage
 	ConsumerTotalsStorage    *pingpong.ConsumerTotalsStorage
 	AccountantPromiseStorage *pingpong.AccountantPromiseStorage
+	SettlementHistoryStorage *pingpong.SettlementHistoryStorage
 	ConsumerBalanceTracker   *pingpong.ConsumerBalanceTracker
 	AccountantPromiseSettler pingpong.AccountantPromiseSettler
 	AccountantCaller         *pingpong.AccountantCaller
@@ -223,8 +249,8 @@ func (di *Dependencies) Bootstrap(nodeOptions node.Options) error {
 	di.bootstrapNATComponents(nodeOptions)
 
 	di.PortPool = port.NewPool()
-	di.P2PListener = p2p.NewListener(di.BrokerConnector, di.NetworkDefinition.BrokerAddress, di.SignerFactory, identity.NewVerifierSigned(), di.IPResolver, di.NATPinger, di.PortPool)
-	di.P2PDialer = p2p.NewDialer(di.BrokerConnector, di.NetworkDefinition.BrokerAddress, di.SignerFactory, identity.NewVerifierSigned(), di.IPResolver, di.NATPinger, di.PortPool)
+	di.P2PListener = p2p.NewListener(di.BrokerConnector, di.NetworkDefinition.BrokerAddress, di.SignerFactory, identity.NewVerifierSigned(), di.IPResolver, di.NATPinger, di.PortPool, di.EventBus)
+	di.P2PDialer = p2p.NewDialer(di.BrokerConnector, di.NetworkDefinition.BrokerAddress, di.SignerFactory, identity.NewVerifierSigned(), di.IPResolver, di.NATPinger, di.PortPool, di.EventBus)
 	di.SessionConnectivityStatusStorage = connectivity.NewStatusStorage()
 
 	if err := di.bootstrapServices(nodeOptions, services.SharedConfiguredOptions()); err != nil {
@@ -247,6 +273,11 @@ func (di *Dependencies) Bootstrap(nodeOptions node.Options) error {
 	if err := di.Node.Start(); err != nil {
 		return err
 	}
+	if di.ServiceScheduler != nil {
+		if err := di.ServiceScheduler.Start(); err != nil {
+			log.Error().Err(err).Msg("Failed to start service scheduler")
+		}
+	}
 
 	appconfig.Current.EnableEventPublishing(di.EventBus)
 
@@ -323,6 +354,10 @@ func (di *Dependencies) Shutdown() (err error) {
 		}
 	}()
 
+	if di.ServiceScheduler != nil {
+		di.ServiceScheduler.Stop()
+	}
+
 	if di.ServicesManager != nil {
 		if err := di.ServicesManager.Kill(); err != nil {
 			errs = append(errs, err)
@@ -333,6 +368,14 @@ func (di *Dependencies) Shutdown() (err error) {
 		di.PolicyOracle.Stop()
 	}
 
+	if di.LocalPolicies != nil {
+		di.LocalPolicies.Stop()
+	}
+
+	if di.QuotaEnforcer != nil {
+		di.QuotaEnforcer.Stop()
+	}
+
 	if di.NATService != nil {
 		if err := di.NATService.Disable(); err != nil {
 			errs = append(errs, err)
@@ -385,6 +428,7 @@ func (di *Dependencies) bootstrapStorage(path string) error {
 	di.ProviderInvoiceStorage = pingpong.NewProviderInvoiceStorage(invoiceStorage)
 	di.ConsumerTotalsStorage = pingpong.NewConsumerTotalsStorage(di.Storage, di.EventBus)
 	di.AccountantPromiseStorage = pingpong.NewAccountantPromiseStorage(di.Storage)
+	di.SettlementHistoryStorage = pingpong.NewSettlementHistoryStorage(di.Storage)
 	return nil
 }
 
@@ -414,6 +458,18 @@ func (di *Dependencies) subscribeEventConsumers() error {
 	if err != nil {
 		return err
 	}
+	err = di.EventBus.SubscribeAsync(pingpong.AppTopicInvoicePaid, func(e pingpong.AppEventInvoicePaid) {
+		di.SessionStorage.UpdateTokensSpent(session.ID(e.SessionID), e.Invoice.AgreementTotal)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Provider session history (local storage)
+	err = di.EventBus.SubscribeAsync(sessionEvent.AppTopicSessionClosed, di.ServiceSessionHistory.ConsumeSessionClosedEvent)
+	if err != nil {
+		return err
+	}
 
 	// NAT events
 	err = di.EventBus.Subscribe(event.AppTopicTraversal, di.NATEventSender.ConsumeNATEvent)
@@ -447,6 +503,12 @@ func (di *Dependencies) subscribeEventConsumers() error {
 		return err
 	}
 
+	// Prometheus metrics
+	err = di.MetricsCollector.Subscribe(di.EventBus)
+	if err != nil {
+		return err
+	}
+
 	return di.handleHTTPClientConnections()
 }
 
@@ -456,6 +518,9 @@ func (di *Dependencies) bootstrapNodeComponents(nodeOptions node.Options, tequil
 		return dialogEstablisher.EstablishDialog(providerID, contact)
 	}
 
+	di.MetricsRegistry = metrics.NewRegistry()
+	di.MetricsCollector = metrics.NewCollector(di.MetricsRegistry)
+
 	di.StatisticsTracker = statistics.NewSessionStatisticsTracker(time.Now)
 	di.StatisticsReporter = statistics.NewSessionStatisticsReporter(
 		di.StatisticsTracker,
@@ -465,6 +530,8 @@ func (di *Dependencies) bootstrapNodeComponents(nodeOptions node.Options, tequil
 		time.Minute,
 	)
 	di.SessionStorage = consumer_session.NewSessionStorage(di.Storage, di.StatisticsTracker)
+	di.ServiceSessionHistory = session_history.NewStorage(di.Storage)
+	di.ProposalScorer = ranking.NewWeightedScorer(ranking.DefaultWeights(), di.QualityClient, di.SessionStorage)
 
 	di.Transactor = registry.NewTransactor(
 		di.HTTPClient,
@@ -506,7 +573,24 @@ func (di *Dependencies) bootstrapNodeComponents(nodeOptions node.Options, tequil
 		return errors.Wrap(err, "could not subscribe consumer balance tracker to relevant events")
 	}
 
+	di.BudgetTracker = budget.NewTracker(
+		di.Storage,
+		di.ConsumerTotalsStorage,
+		func(status string) error {
+			return di.ConnectionManager.DisconnectWithStatus(status)
+		},
+		di.EventBus,
+		identity.FromAddress(nodeOptions.Accountant.AccountantID),
+	)
+	if err := di.BudgetTracker.Subscribe(di.EventBus); err != nil {
+		return errors.Wrap(err, "could not subscribe budget tracker to relevant events")
+	}
+
 	di.ConnectionRegistry = connection.NewRegistry()
+	connectionConfig := connection.DefaultConfig()
+	if address := config.GetString(config.FlagDNSSecureAddress); address != "" {
+		connectionConfig.SecureDNS.ListenAddress = address
+	}
 	di.ConnectionManager = connection.NewManager(
 		dialogFactory,
 		pingpong.ExchangeFactoryFunc(
@@ -517,18 +601,20 @@ func (di *Dependencies) bootstrapNodeComponents(nodeOptions node.Options, tequil
 			nodeOptions.Transactor.RegistryAddress,
 			di.EventBus,
 			nodeOptions.Payments.ConsumerDataLeewayMegabytes,
+			di.BudgetTracker,
 		),
 		di.ConnectionRegistry.CreateConnection,
 		di.EventBus,
 		connectivity.NewStatusSender(),
 		di.IPResolver,
-		connection.DefaultConfig(),
+		connectionConfig,
 		connection.DefaultStatsReportInterval,
 		connection.NewValidator(
 			di.ConsumerBalanceTracker,
 			di.IdentityManager,
 		),
 		di.P2PDialer,
+		di.ProposalRepository,
 	)
 
 	di.LogCollector = logconfig.NewCollector(&logconfig.CurrentLogOptions)
@@ -559,20 +645,26 @@ func (di *Dependencies) bootstrapTequilapi(nodeOptions node.Options, listener ne
 	router := tequilapi.NewAPIRouter()
 	tequilapi_endpoints.AddRouteForStop(router, utils.SoftKiller(di.Shutdown))
 	tequilapi_endpoints.AddRoutesForAuthentication(router, di.Authenticator, di.JWTAuthenticator)
-	tequilapi_endpoints.AddRoutesForIdentities(router, di.IdentityManager, di.IdentitySelector, di.IdentityRegistry, di.ConsumerBalanceTracker, di.ChannelAddressCalculator, di.AccountantPromiseSettler)
-	tequilapi_endpoints.AddRoutesForConnection(router, di.ConnectionManager, di.StatisticsTracker, di.ProposalRepository, di.IdentityRegistry)
+	tequilapi_endpoints.AddRoutesForIdentities(router, di.IdentityManager, di.IdentitySelector, di.IdentityRegistry, di.RegistrationStatusStorage, di.ConsumerBalanceTracker, di.ChannelAddressCalculator, di.AccountantPromiseSettler)
+	tequilapi_endpoints.AddRoutesForConnection(router, di.ConnectionManager, di.StatisticsTracker, di.ProposalRepository, di.IdentityRegistry,
+		quickconnect.NewQuickConnector(di.ProposalRepository, di.ProposalScorer, di.ConnectionManager))
 	tequilapi_endpoints.AddRoutesForConnectionSessions(router, di.SessionStorage)
+	tequilapi_endpoints.AddRoutesForBudget(router, di.BudgetTracker)
 	tequilapi_endpoints.AddRoutesForConnectionLocation(router, di.ConnectionManager, di.IPResolver, di.LocationResolver, di.LocationResolver)
-	tequilapi_endpoints.AddRoutesForProposals(router, di.ProposalRepository, di.QualityClient)
-	tequilapi_endpoints.AddRoutesForService(router, di.ServicesManager, serviceTypesRequestParser)
-	tequilapi_endpoints.AddRoutesForServiceSessions(router, di.StateKeeper)
+	tequilapi_endpoints.AddRoutesForProposals(router, di.ProposalRepository, di.QualityClient, di.ProposalScorer)
+	tequilapi_endpoints.AddRoutesForService(router, di.ServicesManager, serviceTypesRequestParser, di.ServiceRestorer)
+	tequilapi_endpoints.AddRoutesForServiceSchedule(router, di.ServiceScheduler, serviceTypesRequestParser)
+	tequilapi_endpoints.AddRoutesForServiceSessions(router, di.StateKeeper, di.ServiceSessionHistory)
 	tequilapi_endpoints.AddRoutesForPayout(router, di.IdentityManager, di.SignerFactory, di.MysteriumAPI)
+	tequilapi_endpoints.AddRoutesForDenyList(router, di.DenyList)
+	tequilapi_endpoints.AddRoutesForLocalAccessPolicies(router, di.LocalPolicies)
 	tequilapi_endpoints.AddRoutesForAccessPolicies(di.HTTPClient, router, services.SharedConfiguredOptions().AccessPolicyAddress)
 	tequilapi_endpoints.AddRoutesForNAT(router, di.StateKeeper)
-	tequilapi_endpoints.AddRoutesForTransactor(router, di.Transactor, di.AccountantPromiseSettler)
+	tequilapi_endpoints.AddRoutesForTransactor(router, di.Transactor, di.AccountantPromiseSettler, di.SettlementHistoryStorage)
 	tequilapi_endpoints.AddRoutesForConfig(router)
 	tequilapi_endpoints.AddRoutesForFeedback(router, di.Reporter)
 	tequilapi_endpoints.AddRoutesForConnectivityStatus(router, di.SessionConnectivityStatusStorage)
+	tequilapi_endpoints.AddRoutesForMetrics(router, di.MetricsRegistry)
 	if err := tequilapi_endpoints.AddRoutesForSSE(router, di.StateKeeper, di.EventBus); err != nil {
 		return nil, err
 	}
@@ -600,6 +692,9 @@ func newSessionManagerFactory(
 	settler pingpong.AccountantPromiseSettler,
 	httpClient *requests.HTTPClient,
 	keystore *identity.Keystore,
+	quotaEnforcer session.QuotaEnforcer,
+	serviceState session.ServiceState,
+	sessionAdmission session.Admission,
 ) session.ManagerFactory {
 	return func(dialog communication.Dialog) *session.Manager {
 		paymentEngineFactory := pingpong.InvoiceFactoryCreator(
@@ -627,6 +722,9 @@ func newSessionManagerFactory(
 			serviceID,
 			eventbus,
 			nil,
+			quotaEnforcer,
+			serviceState,
+			sessionAdmission,
 			session.DefaultConfig(),
 		)
 	}
@@ -697,8 +795,8 @@ func (di *Dependencies) bootstrapNetworkComponents(options node.Options) (err er
 	bc := paymentClient.NewBlockchain(di.EtherClient, options.Payments.BCTimeout)
 	di.BCHelper = paymentClient.NewBlockchainWithRetries(bc, time.Millisecond*300, 3)
 
-	registryStorage := registry.NewRegistrationStatusStorage(di.Storage)
-	if di.IdentityRegistry, err = identity_registry.NewIdentityRegistryContract(di.EtherClient, common.HexToAddress(options.Transactor.RegistryAddress), common.HexToAddress(options.Accountant.AccountantID), registryStorage, di.EventBus); err != nil {
+	di.RegistrationStatusStorage = registry.NewRegistrationStatusStorage(di.Storage)
+	if di.IdentityRegistry, err = identity_registry.NewIdentityRegistryContract(di.EtherClient, common.HexToAddress(options.Transactor.RegistryAddress), common.HexToAddress(options.Accountant.AccountantID), di.RegistrationStatusStorage, di.EventBus); err != nil {
 		return err
 	}
 
@@ -720,7 +818,7 @@ func (di *Dependencies) bootstrapIdentityComponents(options node.Options) {
 	}
 
 	di.Keystore = identity.NewKeystoreFilesystem(options.Directories.Keystore, ks, keystore.DecryptKey)
-	di.IdentityManager = identity.NewIdentityManager(di.Keystore, di.EventBus)
+	di.IdentityManager = identity.NewIdentityManager(di.Keystore, di.EventBus, di.identityInUse)
 	di.SignerFactory = func(id identity.Identity) identity.Signer {
 		return identity.NewSigner(di.Keystore, id)
 	}
@@ -733,6 +831,26 @@ func (di *Dependencies) bootstrapIdentityComponents(options node.Options) {
 
 }
 
+// identityInUse returns an error if the identity is providing services or consuming a connection, so it can't be locked.
+// Managers are created after the identity manager, they are looked up on every call.
+func (di *Dependencies) identityInUse(address string) error {
+	if di.ServicesManager != nil {
+		for serviceID, instance := range di.ServicesManager.List() {
+			if strings.EqualFold(instance.Proposal().ProviderID, address) {
+				return fmt.Errorf("identity %s is running service %s", address, serviceID)
+			}
+		}
+	}
+
+	if di.ConnectionManager != nil {
+		status := di.ConnectionManager.Status()
+		if status.State != connection.NotConnected && strings.EqualFold(status.ConsumerID.Address, address) {
+			return fmt.Errorf("identity %s is used by the connection", address)
+		}
+	}
+	return nil
+}
+
 func (di *Dependencies) bootstrapQualityComponents(bindAddress string, options node.OptionsQuality) (err error) {
 	if _, err := firewall.AllowURLAccess(options.Address); err != nil {
 		return err
@@ -18,7 +18,9 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -30,6 +32,8 @@ import (
 	"github.com/mysteriumnetwork/node/core/policy"
 	"github.com/mysteriumnetwork/node/core/port"
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/core/service/restore"
+	"github.com/mysteriumnetwork/node/core/service/schedule"
 	"github.com/mysteriumnetwork/node/core/service/servicestate"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/identity/registry"
@@ -49,9 +53,11 @@ import (
 	"github.com/mysteriumnetwork/node/services/wireguard/resources"
 	wireguard_service "github.com/mysteriumnetwork/node/services/wireguard/service"
 	"github.com/mysteriumnetwork/node/session"
+	"github.com/mysteriumnetwork/node/session/admission"
 	"github.com/mysteriumnetwork/node/session/connectivity"
 	"github.com/mysteriumnetwork/node/session/pingpong"
 	pingpong_noop "github.com/mysteriumnetwork/node/session/pingpong/noop"
+	"github.com/mysteriumnetwork/node/session/quota"
 	"github.com/mysteriumnetwork/node/ui"
 	uinoop "github.com/mysteriumnetwork/node/ui/noop"
 	"github.com/rs/zerolog/log"
@@ -119,7 +125,10 @@ func (di *Dependencies) bootstrapServiceWireguard(nodeOptions node.Options) {
 				portMapper,
 				di.ServiceFirewall,
 			)
-			return svc, wireguard_service.GetProposal(loc), nil
+			proposal := wireguard_service.GetProposal(loc)
+			proposal.SetNATType(di.detectNATType())
+			proposal.SetFilteredDNS(wgOptions.DNSBlocklist.Enabled)
+			return svc, proposal, nil
 		},
 	)
 }
@@ -137,6 +146,8 @@ func (di *Dependencies) bootstrapServiceOpenvpn(nodeOptions node.Options) {
 
 		transportOptions := serviceOptions.(openvpn_service.Options)
 		proposal := openvpn_discovery.NewServiceProposalWithLocation(loc, transportOptions.Protocol)
+		proposal.SetNATType(di.detectNATType())
+		proposal.SetFilteredDNS(transportOptions.DNSBlocklist.Enabled)
 
 		var portPool port.ServicePortSupplier
 		var natPinger traversal.NATPinger
@@ -175,6 +186,24 @@ func (di *Dependencies) bootstrapServiceOpenvpn(nodeOptions node.Options) {
 	di.ServiceRegistry.Register(service_openvpn.ServiceType, createService)
 }
 
+// detectNATType checks whether provider is directly reachable by comparing its outbound and public IPs.
+func (di *Dependencies) detectNATType() string {
+	outIP, err := di.IPResolver.GetOutboundIPAsString()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get outbound IP, NAT type is unknown")
+		return ""
+	}
+	pubIP, err := di.IPResolver.GetPublicIP()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get public IP, NAT type is unknown")
+		return ""
+	}
+	if outIP == pubIP {
+		return market.NATTypeNone
+	}
+	return market.NATTypeBehindNAT
+}
+
 func (di *Dependencies) bootstrapServiceNoop(nodeOptions node.Options) {
 	di.ServiceRegistry.Register(
 		service_noop.ServiceType,
@@ -211,10 +240,19 @@ func (di *Dependencies) bootstrapAccountantPromiseSettler(nodeOptions node.Optio
 		return nil
 	}
 
+	strategy, err := pingpong.ParseSettlementStrategy(nodeOptions.Payments.SettlementStrategy)
+	if err != nil {
+		return err
+	}
+	if (strategy == pingpong.SettlementStrategyPeriodic || strategy == pingpong.SettlementStrategyFee) && nodeOptions.Payments.SettlementPeriod <= 0 {
+		return errors.Errorf("settlement period must be positive for the %v strategy", strategy)
+	}
+
 	di.AccountantPromiseSettler = pingpong.NewAccountantPromiseSettler(
 		di.EventBus,
 		di.Transactor,
 		di.AccountantPromiseStorage,
+		di.SettlementHistoryStorage,
 		di.BCHelper,
 		di.IdentityRegistry,
 		di.Keystore,
@@ -222,6 +260,9 @@ func (di *Dependencies) bootstrapAccountantPromiseSettler(nodeOptions node.Optio
 			AccountantAddress:    common.HexToAddress(nodeOptions.Accountant.AccountantID),
 			Threshold:            nodeOptions.Payments.AccountantPromiseSettlingThreshold,
 			MaxWaitForSettlement: nodeOptions.Payments.SettlementTimeout,
+			Strategy:             strategy,
+			Period:               nodeOptions.Payments.SettlementPeriod,
+			MaxFeePercent:        nodeOptions.Payments.SettlementMaxFeePercent,
 		},
 	)
 	return di.AccountantPromiseSettler.Subscribe()
@@ -241,9 +282,31 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options, ser
 	}
 	di.ServiceSessionStorage = storage
 
+	di.QuotaEnforcer = quota.NewEnforcer(quota.ConfiguredQuotas(), di.Storage, di.EventBus)
+	if err := di.QuotaEnforcer.Subscribe(); err != nil {
+		return errors.Wrap(err, "could not subscribe quota enforcer to node events")
+	}
+	go di.QuotaEnforcer.Start()
+
+	di.DenyList = policy.NewDenyList(di.Storage)
+	if err := di.DenyList.Load(); err != nil {
+		return err
+	}
+	di.SessionAdmission = admission.NewController(admission.ConfiguredLimits(), di.DenyList, di.ServiceSessionStorage)
+
 	di.PolicyOracle = policy.NewOracle(di.HTTPClient, servicesOptions.AccessPolicyAddress, servicesOptions.AccessPolicyFetchInterval)
 	go di.PolicyOracle.Start()
 
+	policyFile := servicesOptions.AccessPolicyFile
+	if policyFile == "" {
+		policyFile = filepath.Join(nodeOptions.Directories.Config, "access-policies.toml")
+	}
+	di.LocalPolicies = policy.NewLocalPolicies(policyFile)
+	if err := di.LocalPolicies.Load(); err != nil {
+		log.Error().Err(err).Msg("Failed to load local access policies, continuing without them")
+	}
+	go di.LocalPolicies.Start()
+
 	newDialogWaiter := func(providerID identity.Identity, serviceType string, policies *policy.Repository) (communication.DialogWaiter, error) {
 		return nats_dialog.NewDialogWaiter(
 			di.BrokerConnection,
@@ -278,6 +341,9 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options, ser
 			serviceID,
 			di.EventBus,
 			channel,
+			di.QuotaEnforcer,
+			di.ServicesManager.DrainState(service.ID(serviceID)),
+			di.SessionAdmission,
 			session.DefaultConfig(),
 		)
 	}
@@ -298,6 +364,9 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options, ser
 			di.AccountantPromiseSettler,
 			di.HTTPClient,
 			di.Keystore,
+			di.QuotaEnforcer,
+			di.ServicesManager.DrainState(service.ID(serviceID)),
+			di.SessionAdmission,
 		)
 
 		return session.NewDialogHandler(
@@ -314,12 +383,32 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options, ser
 		newDialogHandler,
 		di.DiscoveryFactory,
 		di.EventBus,
-		di.PolicyOracle,
+		policy.NewProvider(di.LocalPolicies, di.PolicyOracle),
 		di.P2PListener,
 		newP2PSessionHandler,
 		di.SessionConnectivityStatusStorage,
+		di.ServiceSessionStorage,
 	)
 
+	parseServiceOptions := func(serviceType string, options *json.RawMessage) (service.Options, error) {
+		parser, ok := serviceTypesRequestParser[serviceType]
+		if !ok {
+			return nil, service.ErrUnsupportedServiceType
+		}
+		return parser(options)
+	}
+	di.ServiceScheduler = schedule.NewScheduler(
+		di.ServicesManager,
+		schedule.NewConfigStorage(config.Current),
+		parseServiceOptions,
+		config.GetDuration(config.FlagDrainTimeout),
+	)
+
+	di.ServiceRestorer = restore.NewRestorer(di.ServicesManager, restore.NewStorage(di.Storage), parseServiceOptions, di.IdentityManager)
+	if err := di.ServiceRestorer.Subscribe(di.EventBus); err != nil {
+		return errors.Wrap(err, "could not subscribe service restorer to node events")
+	}
+
 	serviceCleaner := service.Cleaner{SessionStorage: di.ServiceSessionStorage}
 	if err := di.EventBus.Subscribe(servicestate.AppTopicServiceStatus, serviceCleaner.HandleServiceStatus); err != nil {
 		log.Error().Msg("Failed to subscribe service cleaner")
@@ -72,7 +72,7 @@ var (
 	// FlagIncomingFirewall enables incoming traffic filtering.
 	FlagIncomingFirewall = cli.BoolFlag{
 		Name:  "incoming-firewall",
-		Usage: "Enables incoming traffic filtering",
+		Usage: "Enables incoming traffic filtering, required to enforce DNS, IP network and port rules of access policies",
 		Value: false,
 	}
 )
@@ -57,6 +57,12 @@ var (
 		Usage: "IP address to bind to",
 		Value: "0.0.0.0",
 	}
+	// FlagDNSSecureAddress sets the address of the local stub resolver used for DNS-over-HTTPS and DNS-over-TLS.
+	FlagDNSSecureAddress = cli.StringFlag{
+		Name:  "dns.secure.address",
+		Usage: "IP address the local DNS-over-HTTPS and DNS-over-TLS stub resolver listens on port 53 of. Defaults to 127.0.0.153 (127.0.0.1 on macOS)",
+		Value: "",
+	}
 	// FlagFeedbackURL URL of Feedback API.
 	FlagFeedbackURL = cli.StringFlag{
 		Name:  "feedback.url",
@@ -72,7 +78,7 @@ var (
 	FlagFirewallProtectedNetworks = cli.StringFlag{
 		Name:  "firewall.protected.networks",
 		Usage: "List of comma separated (no spaces) subnets to be protected from access via VPN",
-		Value: "10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,127.0.0.0/8",
+		Value: "10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,127.0.0.0/8,fc00::/7,fe80::/10,::1/128",
 	}
 	// FlagKeystoreLightweight determines the scrypt memory complexity.
 	FlagKeystoreLightweight = cli.BoolFlag{
@@ -189,6 +195,7 @@ func RegisterFlagsNode(flags *[]cli.Flag) error {
 		&FlagDiscoveryType,
 		&FlagDiscoveryPingInterval,
 		&FlagDiscoveryFetchInterval,
+		&FlagDNSSecureAddress,
 		&FlagFeedbackURL,
 		&FlagFirewallKillSwitch,
 		&FlagFirewallProtectedNetworks,
@@ -225,6 +232,7 @@ func ParseFlagsNode(ctx *cli.Context) {
 	Current.ParseStringSliceFlag(ctx, FlagDiscoveryType)
 	Current.ParseDurationFlag(ctx, FlagDiscoveryPingInterval)
 	Current.ParseDurationFlag(ctx, FlagDiscoveryFetchInterval)
+	Current.ParseStringFlag(ctx, FlagDNSSecureAddress)
 	Current.ParseStringFlag(ctx, FlagFeedbackURL)
 	Current.ParseBoolFlag(ctx, FlagFirewallKillSwitch)
 	Current.ParseStringFlag(ctx, FlagFirewallProtectedNetworks)
@@ -48,6 +48,24 @@ var (
 		Value: time.Hour * 2,
 		Usage: "The duration we'll wait before timing out our wait for promise settle.",
 	}
+	// FlagPaymentsAccountantPromiseSettleStrategy represents the strategy deciding when the promises are settled.
+	FlagPaymentsAccountantPromiseSettleStrategy = cli.StringFlag{
+		Name:  "payments.accountant.promise.strategy",
+		Value: "threshold",
+		Usage: "The strategy of promise settling: threshold, periodic, fee or manual",
+	}
+	// FlagPaymentsAccountantPromiseSettlePeriod represents the time between settlements of the periodic strategy and between fee checks of the fee strategy.
+	FlagPaymentsAccountantPromiseSettlePeriod = cli.DurationFlag{
+		Name:  "payments.accountant.promise.period",
+		Value: time.Hour * 24,
+		Usage: "The duration between promise settlements when using the periodic strategy, or between settlement fee checks when using the fee strategy",
+	}
+	// FlagPaymentsAccountantPromiseSettleMaxFee represents the max settlement fee accepted by the fee strategy.
+	FlagPaymentsAccountantPromiseSettleMaxFee = cli.Float64Flag{
+		Name:  "payments.accountant.promise.max-fee",
+		Value: 5,
+		Usage: "The max settlement fee, in percents of the unsettled earnings, when using the fee strategy",
+	}
 	// FlagPaymentsMystSCAddress represents the myst smart contract address
 	FlagPaymentsMystSCAddress = cli.StringFlag{
 		Name:  "payments.mystscaddress",
@@ -94,6 +112,9 @@ func RegisterFlagsPayments(flags *[]cli.Flag) {
 		&FlagPaymentsBCTimeout,
 		&FlagPaymentsAccountantPromiseSettleThreshold,
 		&FlagPaymentsAccountantPromiseSettleTimeout,
+		&FlagPaymentsAccountantPromiseSettleStrategy,
+		&FlagPaymentsAccountantPromiseSettlePeriod,
+		&FlagPaymentsAccountantPromiseSettleMaxFee,
 		&FlagPaymentsMystSCAddress,
 		&FlagPaymentsConsumerPricePerMinuteUpperBound,
 		&FlagPaymentsConsumerPricePerMinuteLowerBound,
@@ -109,6 +130,9 @@ func ParseFlagsPayments(ctx *cli.Context) {
 	Current.ParseDurationFlag(ctx, FlagPaymentsBCTimeout)
 	Current.ParseFloat64Flag(ctx, FlagPaymentsAccountantPromiseSettleThreshold)
 	Current.ParseDurationFlag(ctx, FlagPaymentsAccountantPromiseSettleTimeout)
+	Current.ParseStringFlag(ctx, FlagPaymentsAccountantPromiseSettleStrategy)
+	Current.ParseDurationFlag(ctx, FlagPaymentsAccountantPromiseSettlePeriod)
+	Current.ParseFloat64Flag(ctx, FlagPaymentsAccountantPromiseSettleMaxFee)
 	Current.ParseStringFlag(ctx, FlagPaymentsMystSCAddress)
 	Current.ParseUInt64Flag(ctx, FlagPaymentsConsumerPricePerMinuteUpperBound)
 	Current.ParseUInt64Flag(ctx, FlagPaymentsConsumerPricePerMinuteLowerBound)
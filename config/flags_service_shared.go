@@ -29,6 +29,7 @@ type ServicesOptions struct {
 	AccessPolicyAddress       string
 	AccessPolicyList          []string
 	AccessPolicyFetchInterval time.Duration
+	AccessPolicyFile          string
 	ShaperEnabled             bool
 }
 
@@ -36,13 +37,13 @@ var (
 	// FlagIdentity keystore's identity.
 	FlagIdentity = cli.StringFlag{
 		Name:  "identity",
-		Usage: "Keystore's identity used to provide service. If not given identity will be created automatically",
+		Usage: "Keystore's identity used to provide service, several comma separated identities run services at once. If not given identity will be created automatically",
 		Value: "",
 	}
 	// FlagIdentityPassphrase passphrase to unlock the identity.
 	FlagIdentityPassphrase = cli.StringFlag{
 		Name:  "identity.passphrase",
-		Usage: "Used to unlock keystore's identity",
+		Usage: "Used to unlock keystore's identity, give comma separated passphrases in the same order as several identities to unlock each with its own one",
 		Value: "",
 	}
 	// FlagAgreedTermsConditions agree with terms & conditions.
@@ -62,6 +63,12 @@ var (
 		Usage: "Comma separated list that determines the access policies applied to provide service.",
 		Value: "",
 	}
+	// FlagAccessPolicyFile file of locally defined access policies.
+	FlagAccessPolicyFile = cli.StringFlag{
+		Name:  "access-policy.file",
+		Usage: "TOML or JSON file of locally defined access policies, reloaded on change. Defaults to access-policies.toml in config directory",
+		Value: "",
+	}
 	// FlagAccessPolicyFetchInterval policy list fetch interval.
 	FlagAccessPolicyFetchInterval = cli.DurationFlag{
 		Name:  "access-policy.fetch",
@@ -73,6 +80,95 @@ var (
 		Name:  "shaper.enabled",
 		Usage: "Limit service bandwidth",
 	}
+	// FlagShaperUplink limits service uplink bandwidth.
+	FlagShaperUplink = cli.IntFlag{
+		Name:  "shaper.uplink",
+		Usage: "Service uplink bandwidth limit in Kbps, 0 means unlimited",
+		Value: 5000,
+	}
+	// FlagShaperDownlink limits service downlink bandwidth.
+	FlagShaperDownlink = cli.IntFlag{
+		Name:  "shaper.downlink",
+		Usage: "Service downlink bandwidth limit in Kbps, 0 means unlimited",
+		Value: 5000,
+	}
+	// FlagShaperSessionUplink limits uplink bandwidth of every consumer session.
+	FlagShaperSessionUplink = cli.IntFlag{
+		Name:  "shaper.session.uplink",
+		Usage: "Uplink bandwidth limit of a single consumer session in Kbps, 0 means unlimited",
+		Value: 0,
+	}
+	// FlagShaperSessionDownlink limits downlink bandwidth of every consumer session.
+	FlagShaperSessionDownlink = cli.IntFlag{
+		Name:  "shaper.session.downlink",
+		Usage: "Downlink bandwidth limit of a single consumer session in Kbps, 0 means unlimited",
+		Value: 0,
+	}
+	// FlagQuotaSessionTraffic limits traffic of a single consumer session.
+	FlagQuotaSessionTraffic = cli.Uint64Flag{
+		Name:  "quota.session.traffic",
+		Usage: "Traffic quota of a single consumer session in MB, 0 means unlimited",
+		Value: 0,
+	}
+	// FlagQuotaSessionDuration limits duration of a single consumer session.
+	FlagQuotaSessionDuration = cli.DurationFlag{
+		Name:  "quota.session.duration",
+		Usage: `Time quota of a single consumer session { "30m", "2h" }, 0 means unlimited`,
+		Value: 0,
+	}
+	// FlagQuotaDailyTraffic limits daily traffic of a consumer.
+	FlagQuotaDailyTraffic = cli.Uint64Flag{
+		Name:  "quota.daily.traffic",
+		Usage: "Daily traffic quota of a consumer in MB, 0 means unlimited",
+		Value: 0,
+	}
+	// FlagQuotaDailyDuration limits daily session time of a consumer.
+	FlagQuotaDailyDuration = cli.DurationFlag{
+		Name:  "quota.daily.duration",
+		Usage: `Daily time quota of a consumer { "30m", "2h" }, 0 means unlimited`,
+		Value: 0,
+	}
+	// FlagSessionLimitConcurrent limits concurrent sessions of a consumer.
+	FlagSessionLimitConcurrent = cli.IntFlag{
+		Name:  "session.limit.concurrent",
+		Usage: "Number of concurrent sessions allowed for a single consumer, 0 means unlimited",
+		Value: 0,
+	}
+	// FlagSessionLimitAttempts limits session create attempts of a consumer.
+	FlagSessionLimitAttempts = cli.IntFlag{
+		Name:  "session.limit.attempts",
+		Usage: "Number of session create attempts per minute allowed for a single consumer, 0 means unlimited",
+		Value: 0,
+	}
+	// FlagDrainTimeout defines how long active sessions may last after the service starts draining.
+	FlagDrainTimeout = cli.DurationFlag{
+		Name:  "drain.timeout",
+		Usage: `Time given to active sessions of a draining or scheduled to stop service { "30s", "10m" }`,
+		Value: 5 * time.Minute,
+	}
+	// FlagDNSBlocklistEnabled enables blocking of listed domains on the provider DNS.
+	FlagDNSBlocklistEnabled = cli.BoolFlag{
+		Name:  "dns.blocklist.enabled",
+		Usage: "Block ad, tracker and malware domains on the provider DNS",
+	}
+	// FlagDNSBlocklistSources a comma-separated list of blocklist files or URLs.
+	FlagDNSBlocklistSources = cli.StringFlag{
+		Name:  "dns.blocklist.sources",
+		Usage: "Comma separated list of hosts-format or domain-list files, given as local paths or URLs",
+		Value: "",
+	}
+	// FlagDNSBlocklistResponse defines the answer to blocked DNS queries.
+	FlagDNSBlocklistResponse = cli.StringFlag{
+		Name:  "dns.blocklist.response",
+		Usage: `Answer to blocked DNS queries { "nxdomain", "zero_ip" }`,
+		Value: "nxdomain",
+	}
+	// FlagDNSBlocklistRefresh defines how often blocklist URLs are fetched again.
+	FlagDNSBlocklistRefresh = cli.IntFlag{
+		Name:  "dns.blocklist.refresh",
+		Usage: "Blocklist URLs refresh interval in minutes, 0 means never",
+		Value: 1440,
+	}
 )
 
 // RegisterFlagsServiceShared registers shared service CLI flags
@@ -84,7 +180,23 @@ func RegisterFlagsServiceShared(flags *[]cli.Flag) {
 		&FlagAccessPolicyAddress,
 		&FlagAccessPolicyList,
 		&FlagAccessPolicyFetchInterval,
+		&FlagAccessPolicyFile,
 		&FlagShaperEnabled,
+		&FlagShaperUplink,
+		&FlagShaperDownlink,
+		&FlagShaperSessionUplink,
+		&FlagShaperSessionDownlink,
+		&FlagQuotaSessionTraffic,
+		&FlagQuotaSessionDuration,
+		&FlagQuotaDailyTraffic,
+		&FlagQuotaDailyDuration,
+		&FlagSessionLimitConcurrent,
+		&FlagSessionLimitAttempts,
+		&FlagDrainTimeout,
+		&FlagDNSBlocklistEnabled,
+		&FlagDNSBlocklistSources,
+		&FlagDNSBlocklistResponse,
+		&FlagDNSBlocklistRefresh,
 	)
 }
 
@@ -96,5 +208,21 @@ func ParseFlagsServiceShared(ctx *cli.Context) {
 	Current.ParseStringFlag(ctx, FlagAccessPolicyAddress)
 	Current.ParseStringFlag(ctx, FlagAccessPolicyList)
 	Current.ParseDurationFlag(ctx, FlagAccessPolicyFetchInterval)
+	Current.ParseStringFlag(ctx, FlagAccessPolicyFile)
 	Current.ParseBoolFlag(ctx, FlagShaperEnabled)
+	Current.ParseIntFlag(ctx, FlagShaperUplink)
+	Current.ParseIntFlag(ctx, FlagShaperDownlink)
+	Current.ParseIntFlag(ctx, FlagShaperSessionUplink)
+	Current.ParseIntFlag(ctx, FlagShaperSessionDownlink)
+	Current.ParseUInt64Flag(ctx, FlagQuotaSessionTraffic)
+	Current.ParseDurationFlag(ctx, FlagQuotaSessionDuration)
+	Current.ParseUInt64Flag(ctx, FlagQuotaDailyTraffic)
+	Current.ParseDurationFlag(ctx, FlagQuotaDailyDuration)
+	Current.ParseIntFlag(ctx, FlagSessionLimitConcurrent)
+	Current.ParseIntFlag(ctx, FlagSessionLimitAttempts)
+	Current.ParseDurationFlag(ctx, FlagDrainTimeout)
+	Current.ParseBoolFlag(ctx, FlagDNSBlocklistEnabled)
+	Current.ParseStringFlag(ctx, FlagDNSBlocklistSources)
+	Current.ParseStringFlag(ctx, FlagDNSBlocklistResponse)
+	Current.ParseIntFlag(ctx, FlagDNSBlocklistRefresh)
 }
@@ -40,6 +40,12 @@ var (
 		Usage: "Subnet to be used by the wireguard service",
 		Value: "10.182.0.0/16",
 	}
+	// FlagWireguardListenSubnet6 IPv6 subnet to be used by the wireguard service.
+	FlagWireguardListenSubnet6 = cli.StringFlag{
+		Name:  "wireguard.allowed.subnet6",
+		Usage: "IPv6 subnet (e.g. ULA fd00:6d79:7374::/48) to be used by the wireguard service, IPv6 is not provided if empty",
+		Value: "",
+	}
 )
 
 // RegisterFlagsServiceWireguard function register Wireguard flags to flag list
@@ -48,6 +54,7 @@ func RegisterFlagsServiceWireguard(flags *[]cli.Flag) {
 		&FlagWireguardConnectDelay,
 		&FlagWireguardListenPorts,
 		&FlagWireguardListenSubnet,
+		&FlagWireguardListenSubnet6,
 	)
 }
 
@@ -56,4 +63,5 @@ func ParseFlagsServiceWireguard(ctx *cli.Context) {
 	Current.ParseIntFlag(ctx, FlagWireguardConnectDelay)
 	Current.ParseStringFlag(ctx, FlagWireguardListenPorts)
 	Current.ParseStringFlag(ctx, FlagWireguardListenSubnet)
+	Current.ParseStringFlag(ctx, FlagWireguardListenSubnet6)
 }
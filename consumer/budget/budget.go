@@ -0,0 +1,429 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package budget
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/pingpong"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	bucketName      = "consumer-budget"
+	capsKey         = "caps"
+	errBoltNotFound = "not found"
+)
+
+// Period represents the time span a spending cap applies to
+type Period string
+
+const (
+	// PeriodSession caps the spending of a single session
+	PeriodSession = Period("session")
+	// PeriodDay caps the spending of a calendar day in UTC
+	PeriodDay = Period("day")
+	// PeriodMonth caps the spending of a calendar month in UTC
+	PeriodMonth = Period("month")
+)
+
+// DefaultWarningThresholds are the percentages of a cap at which warnings are published, unless configured otherwise.
+var DefaultWarningThresholds = []int{80, 90}
+
+// Caps holds the spending caps, zero cap means no limit
+type Caps struct {
+	Session           uint64
+	Day               uint64
+	Month             uint64
+	WarningThresholds []int
+}
+
+// Validate checks if the caps are sane.
+func (c Caps) Validate() error {
+	for _, threshold := range c.WarningThresholds {
+		if threshold <= 0 || threshold >= 100 {
+			return errors.Errorf("warning threshold must be between 0 and 100 percent, got %d", threshold)
+		}
+	}
+	return nil
+}
+
+func (c Caps) limit(period Period) uint64 {
+	switch period {
+	case PeriodSession:
+		return c.Session
+	case PeriodDay:
+		return c.Day
+	case PeriodMonth:
+		return c.Month
+	}
+	return 0
+}
+
+// Spending holds the tokens spent by the consumer
+type Spending struct {
+	Session uint64
+	Day     uint64
+	DayOf   string
+	Month   uint64
+	MonthOf string
+}
+
+func (s Spending) spent(period Period) uint64 {
+	switch period {
+	case PeriodSession:
+		return s.Session
+	case PeriodDay:
+		return s.Day
+	case PeriodMonth:
+		return s.Month
+	}
+	return 0
+}
+
+// rollOver resets the day and month spending once they are over.
+func (s *Spending) rollOver(now time.Time) {
+	if day := now.Format("2006-01-02"); s.DayOf != day {
+		s.Day, s.DayOf = 0, day
+	}
+	if month := now.Format("2006-01"); s.MonthOf != month {
+		s.Month, s.MonthOf = 0, month
+	}
+}
+
+// AppTopicBudgetWarning is the topic to which warnings of spending approaching the cap are published
+const AppTopicBudgetWarning = "budget-warning"
+
+// AppEventBudgetWarning is published once the spending crosses the warning threshold of the cap
+type AppEventBudgetWarning struct {
+	ConsumerID identity.Identity
+	Period     Period
+	Threshold  int
+	Cap        uint64
+	Spent      uint64
+}
+
+// AppTopicBudgetExceeded is the topic to which reached spending caps are published
+const AppTopicBudgetExceeded = "budget-exceeded"
+
+// AppEventBudgetExceeded is published once the spending reaches the cap, the connection is closed after it
+type AppEventBudgetExceeded struct {
+	ConsumerID identity.Identity
+	Period     Period
+	Cap        uint64
+	Spent      uint64
+}
+
+type persistentStorage interface {
+	GetValue(bucket string, key interface{}, to interface{}) error
+	SetValue(bucket string, key interface{}, to interface{}) error
+}
+
+type totalsStorage interface {
+	Get(consumerAddress, accountantAddress identity.Identity) (uint64, error)
+}
+
+type activeSession struct {
+	consumerID identity.Identity
+	total      uint64
+	spent      uint64
+	// exceeded is set once AppEventBudgetExceeded is published for the session.
+	exceeded bool
+}
+
+// Tracker tracks tokens promised by the consumer and disconnects once the spending caps are reached
+type Tracker struct {
+	storage              persistentStorage
+	totals               totalsStorage
+	disconnectWithStatus func(status string) error
+	publisher            eventbus.Publisher
+	accountantID         identity.Identity
+	timeNow              func() time.Time
+
+	lock    sync.Mutex
+	caps    *Caps
+	session *activeSession
+}
+
+// NewTracker creates the consumer budget tracker, disconnectWithStatus is called to close the connection once a cap is reached
+func NewTracker(storage persistentStorage, totals totalsStorage, disconnectWithStatus func(status string) error, publisher eventbus.Publisher, accountantID identity.Identity) *Tracker {
+	return &Tracker{
+		storage:              storage,
+		totals:               totals,
+		disconnectWithStatus: disconnectWithStatus,
+		publisher:            publisher,
+		accountantID:         accountantID,
+		timeNow:              time.Now,
+	}
+}
+
+// Subscribe subscribes the tracker to session and promised total changes
+func (t *Tracker) Subscribe(bus eventbus.Subscriber) error {
+	if err := bus.SubscribeAsync(connection.AppTopicConsumerSession, t.handleSessionEvent); err != nil {
+		return errors.Wrap(err, "could not subscribe to session events")
+	}
+	return errors.Wrap(bus.SubscribeAsync(pingpong.AppTopicGrandTotalChanged, t.handleGrandTotalChanged), "could not subscribe to grand total events")
+}
+
+// Caps returns the configured spending caps
+func (t *Tracker) Caps() (Caps, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.getCaps()
+}
+
+// SetCaps stores the spending caps, they apply to the active session too
+func (t *Tracker) SetCaps(caps Caps) error {
+	if err := caps.Validate(); err != nil {
+		return err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if err := t.storage.SetValue(bucketName, capsKey, caps); err != nil {
+		return errors.Wrap(err, "could not store spending caps")
+	}
+	t.caps = &caps
+	return nil
+}
+
+// Spending returns the tokens spent by the given consumer
+func (t *Tracker) Spending(consumerID identity.Identity) (Spending, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.getSessionSpending(identity.FromAddress(consumerID.Address))
+}
+
+// Remaining returns the tokens the given consumer can spend until the first of the caps is reached, capped is false when no caps are set
+func (t *Tracker) Remaining(consumerID identity.Identity) (remaining uint64, capped bool, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	caps, err := t.getCaps()
+	if err != nil {
+		return 0, false, err
+	}
+	spending, err := t.getSessionSpending(identity.FromAddress(consumerID.Address))
+	if err != nil {
+		return 0, false, err
+	}
+	_, remaining, capped = caps.remaining(spending)
+	return remaining, capped, nil
+}
+
+// remaining returns the period whose cap is the closest to be reached and the tokens left until it.
+func (c Caps) remaining(spending Spending) (closest Period, remaining uint64, capped bool) {
+	for _, period := range []Period{PeriodSession, PeriodDay, PeriodMonth} {
+		limit, spent := c.limit(period), spending.spent(period)
+		if limit == 0 {
+			continue
+		}
+
+		var left uint64
+		if spent < limit {
+			left = limit - spent
+		}
+		if !capped || left < remaining {
+			closest, remaining = period, left
+		}
+		capped = true
+	}
+	return closest, remaining, capped
+}
+
+func (t *Tracker) handleSessionEvent(e connection.SessionEvent) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	switch e.Status {
+	case connection.SessionCreatedStatus:
+		consumerID := identity.FromAddress(e.SessionInfo.ConsumerID.Address)
+		total, err := t.totals.Get(consumerID, t.accountantID)
+		if err != nil && errors.Cause(err) != pingpong.ErrNotFound {
+			log.Error().Err(err).Msg("Could not get promised total, session spending will not be capped")
+			return
+		}
+		t.session = &activeSession{consumerID: consumerID, total: total}
+
+		spending, err := t.getSpending(consumerID)
+		if err != nil {
+			log.Error().Err(err).Msg("Could not get consumer spending")
+			return
+		}
+		t.enforce(consumerID, spending, 0)
+	case connection.SessionEndedStatus:
+		// Invoices over the remaining budget are rejected, which ends the session before the spending reaches the cap.
+		if e.Reason == connection.SessionBudgetExceededStatus && t.session != nil && !t.session.exceeded {
+			t.publishExceeded(t.session.consumerID)
+		}
+		t.session = nil
+	}
+}
+
+func (t *Tracker) handleGrandTotalChanged(e pingpong.AppEventGrandTotalChanged) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	consumerID := identity.FromAddress(e.ConsumerID.Address)
+	if t.session == nil || t.session.consumerID != consumerID || !strings.EqualFold(e.AccountantID.Address, t.accountantID.Address) {
+		return
+	}
+	// Totals going down are corrections recovered from the accountant, not spending.
+	if e.Current <= t.session.total {
+		t.session.total = e.Current
+		return
+	}
+
+	diff := e.Current - t.session.total
+	t.session.total = e.Current
+	t.session.spent += diff
+
+	spending, err := t.getSpending(consumerID)
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get consumer spending")
+		return
+	}
+	spending.Day += diff
+	spending.Month += diff
+	if err := t.storage.SetValue(bucketName, consumerID.Address, spending); err != nil {
+		log.Error().Err(err).Msg("Could not store consumer spending")
+	}
+
+	spending.Session = t.session.spent
+	t.enforce(consumerID, spending, diff)
+}
+
+// enforce publishes warnings for the crossed thresholds and disconnects once any of the caps is reached.
+func (t *Tracker) enforce(consumerID identity.Identity, spending Spending, diff uint64) {
+	caps, err := t.getCaps()
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get spending caps")
+		return
+	}
+	for _, period := range []Period{PeriodSession, PeriodDay, PeriodMonth} {
+		limit, spent := caps.limit(period), spending.spent(period)
+		if limit == 0 {
+			continue
+		}
+
+		if spent >= limit {
+			log.Warn().Msgf("Consumer %s reached %s spending cap: spent %d of %d", consumerID.Address, period, spent, limit)
+			if t.session != nil && t.session.consumerID == consumerID {
+				t.session.exceeded = true
+			}
+			go t.publisher.Publish(AppTopicBudgetExceeded, AppEventBudgetExceeded{
+				ConsumerID: consumerID,
+				Period:     period,
+				Cap:        limit,
+				Spent:      spent,
+			})
+			go t.disconnect()
+			return
+		}
+
+		for _, threshold := range caps.WarningThresholds {
+			warnAt := limit * uint64(threshold) / 100
+			if spent >= warnAt && spent-diff < warnAt {
+				go t.publisher.Publish(AppTopicBudgetWarning, AppEventBudgetWarning{
+					ConsumerID: consumerID,
+					Period:     period,
+					Threshold:  threshold,
+					Cap:        limit,
+					Spent:      spent,
+				})
+			}
+		}
+	}
+}
+
+// publishExceeded publishes the cap which is the closest to be reached by the consumer.
+func (t *Tracker) publishExceeded(consumerID identity.Identity) {
+	caps, err := t.getCaps()
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get spending caps")
+		return
+	}
+	spending, err := t.getSessionSpending(consumerID)
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get consumer spending")
+		return
+	}
+	period, _, capped := caps.remaining(spending)
+	if !capped {
+		return
+	}
+	go t.publisher.Publish(AppTopicBudgetExceeded, AppEventBudgetExceeded{
+		ConsumerID: consumerID,
+		Period:     period,
+		Cap:        caps.limit(period),
+		Spent:      spending.spent(period),
+	})
+}
+
+func (t *Tracker) disconnect() {
+	err := t.disconnectWithStatus(connection.SessionBudgetExceededStatus)
+	if err != nil && err != connection.ErrNoConnection {
+		log.Error().Err(err).Msg("Could not disconnect after reaching spending cap")
+	}
+}
+
+func (t *Tracker) getCaps() (Caps, error) {
+	if t.caps != nil {
+		return *t.caps, nil
+	}
+
+	caps := Caps{WarningThresholds: DefaultWarningThresholds}
+	err := t.storage.GetValue(bucketName, capsKey, &caps)
+	if err != nil && err.Error() != errBoltNotFound {
+		return Caps{}, errors.Wrap(err, "could not get spending caps")
+	}
+	t.caps = &caps
+	return caps, nil
+}
+
+// getSessionSpending returns the spending of the consumer including the active session, consumerID must be normalized.
+func (t *Tracker) getSessionSpending(consumerID identity.Identity) (Spending, error) {
+	spending, err := t.getSpending(consumerID)
+	if err != nil {
+		return Spending{}, err
+	}
+	if t.session != nil && t.session.consumerID == consumerID {
+		spending.Session = t.session.spent
+	}
+	return spending, nil
+}
+
+// getSpending returns the stored spending of the consumer, consumerID must be normalized.
+func (t *Tracker) getSpending(consumerID identity.Identity) (Spending, error) {
+	var spending Spending
+	err := t.storage.GetValue(bucketName, consumerID.Address, &spending)
+	if err != nil && err.Error() != errBoltNotFound {
+		return Spending{}, errors.Wrap(err, "could not get consumer spending")
+	}
+	spending.rollOver(t.timeNow().UTC())
+	return spending, nil
+}
@@ -0,0 +1,281 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package budget
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/pingpong"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	consumerID   = identity.FromAddress("0x000000000000000000000000000000000000000a")
+	accountantID = identity.FromAddress("0x00000000000000000000000000000000000000ac")
+)
+
+type mockStorage struct {
+	values map[interface{}][]byte
+}
+
+func (ms *mockStorage) GetValue(bucket string, key interface{}, to interface{}) error {
+	value, ok := ms.values[key]
+	if !ok {
+		return errors.New(errBoltNotFound)
+	}
+	return json.Unmarshal(value, to)
+}
+
+func (ms *mockStorage) SetValue(bucket string, key interface{}, to interface{}) error {
+	value, err := json.Marshal(to)
+	ms.values[key] = value
+	return err
+}
+
+type mockTotals struct {
+	total uint64
+}
+
+func (mt *mockTotals) Get(_, _ identity.Identity) (uint64, error) {
+	if mt.total == 0 {
+		return 0, pingpong.ErrNotFound
+	}
+	return mt.total, nil
+}
+
+type mockConnections struct {
+	lock     sync.Mutex
+	statuses []string
+}
+
+func (mc *mockConnections) DisconnectWithStatus(status string) error {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	mc.statuses = append(mc.statuses, status)
+	return nil
+}
+
+func (mc *mockConnections) disconnects() []string {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	return mc.statuses
+}
+
+type mockPublisher struct {
+	lock   sync.Mutex
+	events []interface{}
+}
+
+func (mp *mockPublisher) Publish(_ string, event interface{}) {
+	mp.lock.Lock()
+	defer mp.lock.Unlock()
+	mp.events = append(mp.events, event)
+}
+
+func (mp *mockPublisher) published() []interface{} {
+	mp.lock.Lock()
+	defer mp.lock.Unlock()
+	return mp.events
+}
+
+func newTestTracker(totals uint64) (*Tracker, *mockConnections, *mockPublisher) {
+	connections := &mockConnections{}
+	publisher := &mockPublisher{}
+	tracker := NewTracker(&mockStorage{values: map[interface{}][]byte{}}, &mockTotals{total: totals}, connections.DisconnectWithStatus, publisher, accountantID)
+	tracker.timeNow = func() time.Time {
+		return time.Date(2020, 6, 15, 12, 0, 0, 0, time.UTC)
+	}
+	return tracker, connections, publisher
+}
+
+func startSession(tracker *Tracker) {
+	tracker.handleSessionEvent(connection.SessionEvent{
+		Status:      connection.SessionCreatedStatus,
+		SessionInfo: connection.SessionInfo{ConsumerID: consumerID},
+	})
+}
+
+func promise(tracker *Tracker, total uint64) {
+	tracker.totals.(*mockTotals).total = total
+	tracker.handleGrandTotalChanged(pingpong.AppEventGrandTotalChanged{
+		Current:      total,
+		AccountantID: accountantID,
+		ConsumerID:   consumerID,
+	})
+}
+
+func TestTracker_CountsSpendingSinceSessionStart(t *testing.T) {
+	tracker, connections, _ := newTestTracker(1000)
+
+	promise(tracker, 1100)
+	spending, err := tracker.Spending(consumerID)
+	assert.NoError(t, err)
+	assert.Zero(t, spending.Day)
+
+	startSession(tracker)
+	promise(tracker, 1100)
+	promise(tracker, 1250)
+	// Other accountant's promises are not ours to count.
+	tracker.handleGrandTotalChanged(pingpong.AppEventGrandTotalChanged{Current: 5000, AccountantID: consumerID, ConsumerID: consumerID})
+
+	spending, err = tracker.Spending(consumerID)
+	assert.NoError(t, err)
+	assert.Equal(t, Spending{Session: 150, Day: 150, DayOf: "2020-06-15", Month: 150, MonthOf: "2020-06"}, spending)
+
+	tracker.handleSessionEvent(connection.SessionEvent{Status: connection.SessionEndedStatus})
+	startSession(tracker)
+	promise(tracker, 1300)
+
+	spending, err = tracker.Spending(consumerID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(50), spending.Session)
+	assert.Equal(t, uint64(200), spending.Day)
+	assert.Empty(t, connections.disconnects())
+
+	tracker.timeNow = func() time.Time {
+		return time.Date(2020, 6, 16, 0, 0, 1, 0, time.UTC)
+	}
+	spending, err = tracker.Spending(consumerID)
+	assert.NoError(t, err)
+	assert.Zero(t, spending.Day)
+	assert.Equal(t, uint64(200), spending.Month)
+}
+
+func TestTracker_WarnsAndDisconnectsOnCap(t *testing.T) {
+	tracker, connections, publisher := newTestTracker(0)
+	assert.NoError(t, tracker.SetCaps(Caps{Session: 1000, WarningThresholds: []int{50, 80}}))
+
+	startSession(tracker)
+	promise(tracker, 400)
+	promise(tracker, 600)
+	promise(tracker, 700)
+
+	assert.Eventually(t, func() bool { return len(publisher.published()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, AppEventBudgetWarning{ConsumerID: consumerID, Period: PeriodSession, Threshold: 50, Cap: 1000, Spent: 600}, publisher.published()[0])
+	assert.Empty(t, connections.disconnects())
+
+	promise(tracker, 1000)
+	assert.Eventually(t, func() bool { return len(publisher.published()) == 2 }, time.Second, time.Millisecond)
+	assert.Contains(t, publisher.published(), AppEventBudgetExceeded{ConsumerID: consumerID, Period: PeriodSession, Cap: 1000, Spent: 1000})
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{connection.SessionBudgetExceededStatus}, connections.disconnects())
+	}, time.Second, time.Millisecond)
+}
+
+func TestTracker_DisconnectsNewSessionWhenDailyCapReached(t *testing.T) {
+	tracker, connections, publisher := newTestTracker(0)
+	assert.NoError(t, tracker.SetCaps(Caps{Day: 100}))
+
+	startSession(tracker)
+	promise(tracker, 100)
+	tracker.handleSessionEvent(connection.SessionEvent{Status: connection.SessionEndedStatus})
+	startSession(tracker)
+
+	assert.Eventually(t, func() bool { return len(connections.disconnects()) == 2 }, time.Second, time.Millisecond)
+	assert.Contains(t, publisher.published(), AppEventBudgetExceeded{ConsumerID: consumerID, Period: PeriodDay, Cap: 100, Spent: 100})
+}
+
+func TestTracker_PublishesExceededWhenInvoiceOvershootsCap(t *testing.T) {
+	tracker, _, publisher := newTestTracker(0)
+	assert.NoError(t, tracker.SetCaps(Caps{Session: 1000, Day: 800}))
+
+	startSession(tracker)
+	promise(tracker, 700)
+	// Next invoice is rejected for exceeding the remaining budget and the connection is closed with the reason.
+	tracker.handleSessionEvent(connection.SessionEvent{Status: connection.SessionEndedStatus, Reason: connection.SessionBudgetExceededStatus})
+
+	assert.Eventually(t, func() bool { return len(publisher.published()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, AppEventBudgetExceeded{ConsumerID: consumerID, Period: PeriodDay, Cap: 800, Spent: 700}, publisher.published()[0])
+}
+
+func TestTracker_PublishesExceededOncePerSession(t *testing.T) {
+	tracker, _, publisher := newTestTracker(0)
+	assert.NoError(t, tracker.SetCaps(Caps{Session: 1000, WarningThresholds: []int{}}))
+
+	startSession(tracker)
+	promise(tracker, 1000)
+	tracker.handleSessionEvent(connection.SessionEvent{Status: connection.SessionEndedStatus, Reason: connection.SessionBudgetExceededStatus})
+
+	time.Sleep(10 * time.Millisecond)
+	assert.Len(t, publisher.published(), 1)
+}
+
+func TestTracker_Caps(t *testing.T) {
+	tracker, _, _ := newTestTracker(0)
+
+	caps, err := tracker.Caps()
+	assert.NoError(t, err)
+	assert.Equal(t, Caps{WarningThresholds: DefaultWarningThresholds}, caps)
+
+	assert.Error(t, tracker.SetCaps(Caps{WarningThresholds: []int{100}}))
+	assert.NoError(t, tracker.SetCaps(Caps{Month: 5000, WarningThresholds: []int{75}}))
+
+	tracker.caps = nil
+	caps, err = tracker.Caps()
+	assert.NoError(t, err)
+	assert.Equal(t, Caps{Month: 5000, WarningThresholds: []int{75}}, caps)
+}
+
+func TestTracker_NormalizesConsumerID(t *testing.T) {
+	tracker, _, _ := newTestTracker(0)
+	checksummed := identity.Identity{Address: "0x000000000000000000000000000000000000000A"}
+
+	tracker.handleSessionEvent(connection.SessionEvent{
+		Status:      connection.SessionCreatedStatus,
+		SessionInfo: connection.SessionInfo{ConsumerID: checksummed},
+	})
+	tracker.totals.(*mockTotals).total = 300
+	tracker.handleGrandTotalChanged(pingpong.AppEventGrandTotalChanged{
+		Current:      300,
+		AccountantID: accountantID,
+		ConsumerID:   checksummed,
+	})
+
+	spending, err := tracker.Spending(consumerID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(300), spending.Session)
+	assert.Equal(t, uint64(300), spending.Day)
+}
+
+func TestTracker_Remaining(t *testing.T) {
+	tracker, _, _ := newTestTracker(0)
+
+	_, capped, err := tracker.Remaining(consumerID)
+	assert.NoError(t, err)
+	assert.False(t, capped)
+
+	assert.NoError(t, tracker.SetCaps(Caps{Session: 1000, Day: 500}))
+	startSession(tracker)
+	promise(tracker, 200)
+
+	remaining, capped, err := tracker.Remaining(consumerID)
+	assert.NoError(t, err)
+	assert.True(t, capped)
+	assert.Equal(t, uint64(300), remaining)
+
+	promise(tracker, 600)
+	remaining, _, err = tracker.Remaining(consumerID)
+	assert.NoError(t, err)
+	assert.Zero(t, remaining)
+}
@@ -55,6 +55,7 @@ type History struct {
 	Status          string
 	Updated         time.Time
 	DataStats       connection.Statistics // is updated on disconnect event
+	TokensSpent     uint64                // is updated on every paid invoice
 }
 
 // GetDuration returns delta in seconds (TimeUpdated - TimeStarted)
@@ -18,9 +18,16 @@
 package session
 
 import (
+	"regexp"
 	"time"
 
+	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
 	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/discovery/ranking"
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/rs/zerolog/log"
 )
@@ -32,11 +39,12 @@ type StatsRetriever interface {
 	GetDataStats() connection.Statistics
 }
 
-// Storer allows us to get all sessions, save and update them
+// Storer allows us to get all sessions, query, save and update them
 type Storer interface {
 	Store(bucket string, object interface{}) error
 	Update(bucket string, object interface{}) error
 	GetAllFrom(bucket string, array interface{}) error
+	Select(bucket string, matchers ...q.Matcher) storm.Query
 }
 
 // Storage contains functions for storing, getting session objects
@@ -63,6 +71,88 @@ func (repo *Storage) GetAll() ([]History, error) {
 	return sessions, nil
 }
 
+// Filter narrows down the session history
+type Filter struct {
+	// From includes sessions started at or after the given time
+	From *time.Time
+	// To includes sessions started before the given time
+	To              *time.Time
+	ProviderID      string
+	ProviderCountry string
+	Status          string
+	Offset          int
+	Limit           int
+}
+
+func (f Filter) matchers() []q.Matcher {
+	var matchers []q.Matcher
+	if f.From != nil {
+		matchers = append(matchers, q.Gte("Started", *f.From))
+	}
+	if f.To != nil {
+		matchers = append(matchers, q.Lt("Started", *f.To))
+	}
+	if f.ProviderID != "" {
+		matchers = append(matchers, q.Eq("ProviderID", identity.FromAddress(f.ProviderID)))
+	}
+	if f.ProviderCountry != "" {
+		matchers = append(matchers, q.Re("ProviderCountry", "(?i)^"+regexp.QuoteMeta(f.ProviderCountry)+"$"))
+	}
+	if f.Status != "" {
+		matchers = append(matchers, q.Eq("Status", f.Status))
+	}
+	return matchers
+}
+
+// SessionOutcomes returns outcomes of all past sessions, they are used to rank proposals by success rate
+func (repo *Storage) SessionOutcomes() ([]ranking.SessionOutcome, error) {
+	sessions, err := repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	outcomes := make([]ranking.SessionOutcome, len(sessions))
+	for i, se := range sessions {
+		outcomes[i] = ranking.SessionOutcome{
+			ProposalID: market.ProposalID{ProviderID: se.ProviderID.Address, ServiceType: se.ServiceType},
+			Successful: se.Status == SessionStatusCompleted && se.DataStats.BytesReceived > 0,
+		}
+	}
+	return outcomes, nil
+}
+
+// List returns the newest first page of sessions matching the filter and the number of all matching sessions
+func (repo *Storage) List(filter Filter) ([]History, int, error) {
+	matchers := filter.matchers()
+
+	total, err := repo.storage.Select(sessionStorageBucketName, matchers...).Count(new(History))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := repo.storage.Select(sessionStorageBucketName, matchers...).OrderBy("Started").Reverse().Skip(filter.Offset)
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	sessions := []History{}
+	if err := query.Find(&sessions); err != nil && err != storage.ErrNotFound {
+		return nil, 0, err
+	}
+	return sessions, total, nil
+}
+
+// UpdateTokensSpent updates the tokens spent during the session with the total of the paid invoice
+func (repo *Storage) UpdateTokensSpent(sessionID session.ID, tokensSpent uint64) {
+	updatedSession := &History{
+		SessionID:   sessionID,
+		TokensSpent: tokensSpent,
+	}
+	err := repo.storage.Update(sessionStorageBucketName, updatedSession)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to update tokens spent of session %s", sessionID)
+	}
+}
+
 // ConsumeSessionEvent consumes the session state change events
 func (repo *Storage) ConsumeSessionEvent(sessionEvent connection.SessionEvent) {
 	switch sessionEvent.Status {
@@ -20,8 +20,13 @@ package session
 import (
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
 	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/discovery/ranking"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb/boltdbtest"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 	node_session "github.com/mysteriumnetwork/node/session"
@@ -114,6 +119,71 @@ func TestSessionStorageConsumeEventConnectedError(t *testing.T) {
 	assert.True(t, storer.SaveCalled)
 }
 
+func TestSessionStorageSessionOutcomes(t *testing.T) {
+	storer := &StubSessionStorer{Sessions: []History{
+		{ProviderID: providerID, ServiceType: serviceType, Status: SessionStatusCompleted, DataStats: connection.Statistics{BytesReceived: 100}},
+		{ProviderID: providerID, ServiceType: serviceType, Status: SessionStatusCompleted},
+		{ProviderID: providerID, ServiceType: serviceType, Status: SessionStatusNew, DataStats: connection.Statistics{BytesReceived: 100}},
+	}}
+	storage := NewSessionStorage(storer, stubRetriever)
+
+	outcomes, err := storage.SessionOutcomes()
+
+	assert.NoError(t, err)
+	proposalID := market.ProposalID{ProviderID: providerID.Address, ServiceType: serviceType}
+	assert.Equal(t, []ranking.SessionOutcome{
+		{ProposalID: proposalID, Successful: true},
+		{ProposalID: proposalID},
+		{ProposalID: proposalID},
+	}, outcomes)
+}
+
+func TestSessionStorageList(t *testing.T) {
+	day := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	storer := &StubSessionStorer{Sessions: []History{
+		{SessionID: "s1", ProviderID: providerID, ProviderCountry: "NL", Status: SessionStatusCompleted, Started: day},
+		{SessionID: "s2", ProviderID: identity.FromAddress("other"), ProviderCountry: "DE", Status: SessionStatusCompleted, Started: day.Add(time.Hour)},
+		{SessionID: "s3", ProviderID: providerID, ProviderCountry: "NL", Status: SessionStatusNew, Started: day.AddDate(0, 0, 1)},
+	}}
+	storage := NewSessionStorage(storer, stubRetriever)
+
+	tests := []struct {
+		filter   Filter
+		expected []node_session.ID
+		total    int
+	}{
+		{Filter{}, []node_session.ID{"s3", "s2", "s1"}, 3},
+		{Filter{ProviderID: "PROVIDERID"}, []node_session.ID{"s3", "s1"}, 2},
+		{Filter{ProviderCountry: "nl", Status: SessionStatusCompleted}, []node_session.ID{"s1"}, 1},
+		{Filter{From: timePtr(day.Add(time.Minute)), To: timePtr(day.AddDate(0, 0, 1))}, []node_session.ID{"s2"}, 1},
+		{Filter{Offset: 1, Limit: 1}, []node_session.ID{"s2"}, 3},
+		{Filter{Offset: 3}, []node_session.ID{}, 3},
+	}
+	for _, tt := range tests {
+		sessions, total, err := storage.List(tt.filter)
+		assert.NoError(t, err)
+		ids := make([]node_session.ID, 0)
+		for _, se := range sessions {
+			ids = append(ids, se.SessionID)
+		}
+		assert.Equal(t, tt.expected, ids, "%+v", tt.filter)
+		assert.Equal(t, tt.total, total, "%+v", tt.filter)
+	}
+}
+
+func TestSessionStorageUpdateTokensSpent(t *testing.T) {
+	storer := &StubSessionStorer{}
+	storage := NewSessionStorage(storer, stubRetriever)
+
+	storage.UpdateTokensSpent(sessionID, 150)
+
+	assert.Equal(t, &History{SessionID: sessionID, TokensSpent: 150}, storer.Updated)
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
 // StubSessionStorer allows us to get all sessions, save and update them
 type StubSessionStorer struct {
 	SaveError    error
@@ -122,6 +192,8 @@ type StubSessionStorer struct {
 	UpdateCalled bool
 	GetAllCalled bool
 	GetAllError  error
+	Sessions     []History
+	Updated      interface{}
 }
 
 func (sss *StubSessionStorer) Store(from string, object interface{}) error {
@@ -131,11 +203,19 @@ func (sss *StubSessionStorer) Store(from string, object interface{}) error {
 
 func (sss *StubSessionStorer) Update(from string, object interface{}) error {
 	sss.UpdateCalled = true
+	sss.Updated = object
 	return sss.UpdateError
 }
 
+func (sss *StubSessionStorer) Select(from string, matchers ...q.Matcher) storm.Query {
+	return boltdbtest.NewQueryStub(sss.Sessions, matchers...)
+}
+
 func (sss *StubSessionStorer) GetAllFrom(from string, array interface{}) error {
 	sss.GetAllCalled = true
+	if sss.Sessions != nil {
+		*array.(*[]History) = sss.Sessions
+	}
 	return sss.GetAllError
 }
 
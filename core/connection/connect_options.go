@@ -31,6 +31,10 @@ type ConnectParams struct {
 	DisableKillSwitch bool
 	// DNS servers to use
 	DNS DNSOption
+	// Reconnect policy applied when connection drops
+	Reconnect ReconnectPolicy
+	// SplitTunnel defines which traffic is routed through VPN
+	SplitTunnel SplitTunnel
 }
 
 // ConnectOptions represents the params we need to ensure a successful connection
@@ -43,4 +47,7 @@ type ConnectOptions struct {
 	SessionConfig   []byte
 	ProviderNATConn *net.UDPConn
 	ChannelConn     *net.UDPConn
+	Routes          Routes
+	// KillSwitch defines if traffic leaking outside of the tunnel should be blocked
+	KillSwitch bool
 }
@@ -20,6 +20,7 @@ package connection
 import (
 	"encoding/json"
 	"net"
+	"net/url"
 	"strings"
 
 	"github.com/mysteriumnetwork/node/dns"
@@ -38,17 +39,35 @@ const (
 	DNSOptionProvider = DNSOption("provider")
 	// DNSOptionSystem uses DNS servers from client's system configuration
 	DNSOptionSystem = DNSOption("system")
+	// DNSOptionDoH uses local stub resolver forwarding queries to public DNS-over-HTTPS resolvers through the tunnel
+	DNSOptionDoH = DNSOption("doh")
+	// DNSOptionDoT uses local stub resolver forwarding queries to public DNS-over-TLS resolvers through the tunnel
+	DNSOptionDoT = DNSOption("dot")
+)
+
+var (
+	defaultDoHUpstreams = []string{"https://1.1.1.1/dns-query", "https://8.8.8.8/dns-query"}
+	defaultDoTUpstreams = []string{"tls://1.1.1.1", "tls://8.8.8.8"}
 )
 
 // NewDNSOption creates and validates DNSOption
 func NewDNSOption(str string) (DNSOption, error) {
 	opt := DNSOption(str)
 	switch opt {
-	case DNSOptionAuto, DNSOptionProvider, DNSOptionSystem, "":
+	case DNSOptionAuto, DNSOptionProvider, DNSOptionSystem, DNSOptionDoH, DNSOptionDoT, "":
 		return opt, nil
 	}
-	// It may also be a set of IP addresses, e.g. 1.1.1.1,8.8.8.8
 	split := strings.Split(str, ",")
+	// It may be a set of DoH and DoT upstreams, e.g. https://1.1.1.1/dns-query,tls://8.8.8.8
+	if isSecureUpstream(split[0]) {
+		for _, s := range split {
+			if u, err := url.Parse(s); err != nil || !isSecureUpstream(s) || u.Hostname() == "" {
+				return "", errors.New("invalid DNS-over-HTTPS or DNS-over-TLS upstream provided as a DNS option: " + s)
+			}
+		}
+		return opt, nil
+	}
+	// It may also be a set of IP addresses, e.g. 1.1.1.1,8.8.8.8
 	for _, s := range split {
 		if ip := net.ParseIP(s); ip == nil {
 			return "", errors.New("invalid IP address provided as a DNS option: " + s)
@@ -77,9 +96,27 @@ func (o DNSOption) Exact() (servers []string, ok bool) {
 	case DNSOptionAuto, DNSOptionProvider, DNSOptionSystem:
 		return nil, false
 	}
+	if _, secure := o.SecureUpstreams(); secure {
+		return nil, false
+	}
 	return stringutil.Split(string(o), ','), true
 }
 
+// SecureUpstreams returns DNS-over-HTTPS and DNS-over-TLS upstreams, if the option requires a local stub resolver
+func (o DNSOption) SecureUpstreams() (upstreams []string, ok bool) {
+	switch o {
+	case DNSOptionDoH:
+		return defaultDoHUpstreams, true
+	case DNSOptionDoT:
+		return defaultDoTUpstreams, true
+	}
+	upstreams = stringutil.Split(string(o), ',')
+	if len(upstreams) == 0 || !isSecureUpstream(upstreams[0]) {
+		return nil, false
+	}
+	return upstreams, true
+}
+
 // ResolveIPs resolves DNS server IPs on the consumer side using self as the
 // consumer preference and `providerDNS` argument as received from the provider
 func (o *DNSOption) ResolveIPs(providerDNS string) ([]string, error) {
@@ -122,3 +159,7 @@ func selectProviderDNS(providerDNS string) ([]string, error) {
 	}
 	return servers, nil
 }
+
+func isSecureUpstream(str string) bool {
+	return strings.HasPrefix(str, "https://") || strings.HasPrefix(str, "tls://")
+}
@@ -35,10 +35,15 @@ func TestNewDNSOption(t *testing.T) {
 		{input: "system", expect: DNSOptionSystem},
 		{input: "1.1.1.1,9.9.9.9", expect: DNSOption("1.1.1.1,9.9.9.9")},
 		{input: "1.1.1.1", expect: DNSOption("1.1.1.1")},
+		{input: "doh", expect: DNSOptionDoH},
+		{input: "dot", expect: DNSOptionDoT},
+		{input: "https://dns.example.com/dns-query,tls://1.1.1.1:853", expect: DNSOption("https://dns.example.com/dns-query,tls://1.1.1.1:853")},
 		{input: "", expect: DNSOption("")},
 		{input: "AA", expectErr: true},
 		{input: "512.512.512.512", expectErr: true},
 		{input: "1.1.1.1,512.512.512.512", expectErr: true},
+		{input: "https://1.1.1.1/dns-query,8.8.8.8", expectErr: true},
+		{input: "tls://", expectErr: true},
 	}
 	for i, tt := range tests {
 		option, err := NewDNSOption(tt.input)
@@ -86,6 +91,8 @@ func TestDNSOption_Exact(t *testing.T) {
 		{option: DNSOption("1.1.1.1,9.9.9.9"), expectServers: []string{"1.1.1.1", "9.9.9.9"}, expectOK: true},
 		{option: DNSOption("9.9.9.9"), expectServers: []string{"9.9.9.9"}, expectOK: true},
 		{option: DNSOption(""), expectServers: nil, expectOK: true},
+		{option: DNSOptionDoH, expectOK: false},
+		{option: DNSOption("tls://1.1.1.1"), expectOK: false},
 	}
 	for _, tt := range tests {
 		servers, ok := tt.option.Exact()
@@ -93,3 +100,28 @@ func TestDNSOption_Exact(t *testing.T) {
 		assert.Equal(tt.expectServers, servers)
 	}
 }
+
+func TestDNSOption_SecureUpstreams(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		option          DNSOption
+		expectUpstreams []string
+		expectOK        bool
+	}{
+		{option: DNSOptionAuto, expectOK: false},
+		{option: DNSOption("1.1.1.1"), expectOK: false},
+		{option: DNSOption(""), expectOK: false},
+		{option: DNSOptionDoH, expectUpstreams: defaultDoHUpstreams, expectOK: true},
+		{option: DNSOptionDoT, expectUpstreams: defaultDoTUpstreams, expectOK: true},
+		{
+			option:          DNSOption("https://dns.example.com/dns-query,tls://1.1.1.1"),
+			expectUpstreams: []string{"https://dns.example.com/dns-query", "tls://1.1.1.1"},
+			expectOK:        true,
+		},
+	}
+	for _, tt := range tests {
+		upstreams, ok := tt.option.SecureUpstreams()
+		assert.Equal(tt.expectOK, ok)
+		assert.Equal(tt.expectUpstreams, upstreams)
+	}
+}
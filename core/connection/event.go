@@ -38,12 +38,18 @@ const (
 	SessionCreatedStatus = "Created"
 	// SessionEndedStatus represents a session end
 	SessionEndedStatus = "Ended"
+	// SessionQuotaExceededStatus represents a session ended by provider because of exceeded quota
+	SessionQuotaExceededStatus = "QuotaExceeded"
+	// SessionBudgetExceededStatus represents a session ended by consumer because of reached spending cap
+	SessionBudgetExceededStatus = "BudgetExceeded"
 )
 
 // SessionEvent represents a session related event
 type SessionEvent struct {
 	Status      string
 	SessionInfo SessionInfo
+	// Reason is set on the ended session event when the session was ended for a specific reason, e.g. SessionBudgetExceededStatus
+	Reason string
 }
 
 // SessionStatsEvent represents a session statistics event
@@ -60,4 +60,6 @@ type Manager interface {
 	Status() Status
 	// Disconnect closes established connection, reports error if no connection
 	Disconnect() error
+	// DisconnectWithStatus closes established connection with the given status as the reason of the ended session, reports error if no connection
+	DisconnectWithStatus(status string) error
 }
@@ -21,11 +21,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"runtime"
 	"sync"
 	"time"
 
 	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/core/ip"
+	"github.com/mysteriumnetwork/node/dns"
 	"github.com/mysteriumnetwork/node/eventbus"
 	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
@@ -53,6 +55,8 @@ var (
 	ErrInsufficientBalance = errors.New("insufficient balance")
 	// ErrUnlockRequired indicates that the consumer identity has not been unlocked yet
 	ErrUnlockRequired = errors.New("unlock required")
+	// ErrBudgetExceeded indicates that paying the invoice would exceed the consumer spending caps
+	ErrBudgetExceeded = errors.New("invoice exceeds the remaining budget")
 )
 
 // IPCheckConfig contains common params for connection ip check.
@@ -68,10 +72,18 @@ type KeepAliveConfig struct {
 	MaxSendErrCount int
 }
 
+// SecureDNSConfig contains options of the local stub resolver used for DNS-over-HTTPS and DNS-over-TLS.
+type SecureDNSConfig struct {
+	ListenAddress string
+	ListenPort    int
+	CacheSize     int
+}
+
 // Config contains common configuration options for connection manager.
 type Config struct {
 	IPCheck   IPCheckConfig
 	KeepAlive KeepAliveConfig
+	SecureDNS SecureDNSConfig
 }
 
 // DefaultConfig returns default params.
@@ -86,7 +98,21 @@ func DefaultConfig() Config {
 			SendTimeout:     5 * time.Second,
 			MaxSendErrCount: 5,
 		},
+		SecureDNS: SecureDNSConfig{
+			ListenAddress: defaultSecureDNSAddress(),
+			ListenPort:    53,
+			CacheSize:     1000,
+		},
+	}
+}
+
+// defaultSecureDNSAddress returns the loopback address for the stub resolver, port 53 of 127.0.0.1
+// is often taken by local resolvers, while darwin has no other loopback addresses unless aliased.
+func defaultSecureDNSAddress() string {
+	if runtime.GOOS == "darwin" {
+		return "127.0.0.1"
 	}
+	return "127.0.0.153"
 }
 
 // Creator creates new connection by given options and uses state channel to report state changes
@@ -139,6 +165,7 @@ type connectionManager struct {
 	statsReportInterval      time.Duration
 	validator                validator
 	p2pDialer                p2p.Dialer
+	proposalLookup           proposalLookup
 
 	// These are populated by Connect at runtime.
 	ctx                    context.Context
@@ -149,8 +176,16 @@ type connectionManager struct {
 	cleanup                []func() error
 	cleanupAfterDisconnect []func() error
 	cancel                 func()
+	request                connectRequest
+	requestLock            sync.Mutex
+	reconnecting           bool
+	reconnectingLock       sync.Mutex
+	removeTrafficBlock     func()
+	trafficBlockLock       sync.Mutex
 
 	discoLock sync.Mutex
+	// endReason is guarded by discoLock.
+	endReason string
 }
 
 // NewManager creates connection manager with given dependencies
@@ -165,6 +200,7 @@ func NewManager(
 	statsReportInterval time.Duration,
 	validator validator,
 	p2pDialer p2p.Dialer,
+	proposalLookup proposalLookup,
 ) *connectionManager {
 	return &connectionManager{
 		newDialog:                dialogCreator,
@@ -179,6 +215,7 @@ func NewManager(
 		statsReportInterval:      statsReportInterval,
 		validator:                validator,
 		p2pDialer:                p2pDialer,
+		proposalLookup:           proposalLookup,
 	}
 }
 
@@ -192,7 +229,13 @@ func (manager *connectionManager) Connect(consumerID, accountantID identity.Iden
 		return err
 	}
 
-	manager.ctx, manager.cancel = context.WithCancel(context.Background())
+	ctx := manager.newContext()
+	manager.setConnectRequest(connectRequest{
+		consumerID:   consumerID,
+		accountantID: accountantID,
+		proposal:     proposal,
+		params:       params,
+	})
 
 	manager.publishStateEvent(Connecting)
 	manager.setStatus(statusConnecting())
@@ -203,6 +246,20 @@ func (manager *connectionManager) Connect(consumerID, accountantID identity.Iden
 		}
 	}()
 
+	return manager.connect(ctx, consumerID, accountantID, proposal, params)
+}
+
+// newContext replaces the context of the connection, it is guarded by the same lock as Disconnect cancelling it.
+func (manager *connectionManager) newContext() context.Context {
+	manager.discoLock.Lock()
+	defer manager.discoLock.Unlock()
+
+	manager.ctx, manager.cancel = context.WithCancel(context.Background())
+	return manager.ctx
+}
+
+// connect creates a session with the provider of given proposal and establishes the connection.
+func (manager *connectionManager) connect(ctx context.Context, consumerID, accountantID identity.Identity, proposal market.ServiceProposal, params ConnectParams) (err error) {
 	providerID := identity.FromAddress(proposal.ProviderID)
 
 	channel := manager.createP2PChannel(consumerID, providerID, proposal)
@@ -241,7 +298,7 @@ func (manager *connectionManager) Connect(consumerID, accountantID identity.Iden
 
 	originalPublicIP := manager.getPublicIP()
 	// Try to establish connection with peer.
-	err = manager.startConnection(connection, consumerID, proposal, params, sessionDTO, channel)
+	err = manager.startConnection(ctx, connection, consumerID, proposal, params, sessionDTO, channel)
 	if err != nil {
 		if err == context.Canceled {
 			return ErrConnectionCancelled
@@ -253,19 +310,22 @@ func (manager *connectionManager) Connect(consumerID, accountantID identity.Iden
 		manager.discoLock.Unlock()
 		manager.publishStateEvent(StateConnectionFailed)
 
-		log.Info().Err(err).Msg("Cancelling connection initiation: ")
-		manager.Cancel()
+		// Failed reconnect attempts are cleaned up by the reconnect loop, keeping the traffic block in place.
+		if !manager.isReconnecting() {
+			log.Info().Err(err).Msg("Cancelling connection initiation: ")
+			manager.Cancel()
+		}
 		return err
 	}
 
-	go manager.keepAliveLoop(channel, sessionDTO.ID)
-	go manager.checkSessionIP(dialog, channel, consumerID, sessionDTO.ID, originalPublicIP)
+	go manager.keepAliveLoop(ctx, channel, sessionDTO.ID)
+	go manager.checkSessionIP(ctx, dialog, channel, consumerID, sessionDTO.ID, originalPublicIP)
 
 	return nil
 }
 
 // checkSessionIP checks if IP has changed after connection was established.
-func (manager *connectionManager) checkSessionIP(dialog communication.Dialog, channel p2p.Channel, consumerID identity.Identity, sessionID session.ID, originalPublicIP string) {
+func (manager *connectionManager) checkSessionIP(ctx context.Context, dialog communication.Dialog, channel p2p.Channel, consumerID identity.Identity, sessionID session.ID, originalPublicIP string) {
 	for i := 1; i <= manager.config.IPCheck.MaxAttempts; i++ {
 		// Skip check if not connected. This may happen when context was canceled via Disconnect.
 		if manager.Status().State != Connected {
@@ -284,6 +344,9 @@ func (manager *connectionManager) checkSessionIP(dialog communication.Dialog, ch
 		if i == manager.config.IPCheck.MaxAttempts {
 			manager.sendSessionStatus(dialog, channel, consumerID, sessionID, connectivity.StatusSessionIPNotChanged, nil)
 			manager.publishStateEvent(StateIPNotChanged)
+			if manager.getConnectRequest().params.Reconnect.Enabled {
+				manager.onConnectionLost(ctx)
+			}
 			return
 		}
 
@@ -395,6 +458,7 @@ func (manager *connectionManager) createP2PChannel(consumerID, providerID identi
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to establish p2p channel")
 	} else {
+		channel.Handle(p2p.TopicSessionStatus, manager.handleProviderSessionStatus)
 		manager.cleanupAfterDisconnect = append(manager.cleanupAfterDisconnect, func() error {
 			log.Trace().Msg("Cleaning: closing P2P communication channel")
 			defer log.Trace().Msg("Cleaning: P2P communication channel DONE")
@@ -405,6 +469,25 @@ func (manager *connectionManager) createP2PChannel(consumerID, providerID identi
 	return channel
 }
 
+// handleProviderSessionStatus handles session status notifications sent by provider.
+func (manager *connectionManager) handleProviderSessionStatus(c p2p.Context) error {
+	var ss pb.SessionStatus
+	if err := c.Request().UnmarshalProto(&ss); err != nil {
+		return err
+	}
+	log.Debug().Msgf("Received P2P session status message for %q: %s", p2p.TopicSessionStatus, ss.String())
+
+	if connectivity.StatusCode(ss.GetCode()) == connectivity.StatusSessionQuotaExceeded {
+		log.Warn().Msgf("Provider ended session %s: %s", ss.GetSessionID(), ss.GetMessage())
+		manager.eventPublisher.Publish(AppTopicConsumerSession, SessionEvent{
+			Status:      SessionQuotaExceededStatus,
+			SessionInfo: manager.getCurrentSession(),
+		})
+	}
+
+	return c.OK()
+}
+
 func (manager *connectionManager) createP2PSession(c Connection, p2pChannel p2p.ChannelSender, consumerID, accountantID identity.Identity, proposal market.ServiceProposal) (session.SessionDto, session.PaymentInfo, error) {
 	sessionCreateConfig, err := c.GetConfig()
 	if err != nil {
@@ -537,6 +620,7 @@ func (manager *connectionManager) saveSessionInfo(sessionInfo SessionInfo) {
 		manager.eventPublisher.Publish(AppTopicConsumerSession, SessionEvent{
 			Status:      SessionEndedStatus,
 			SessionInfo: manager.getCurrentSession(),
+			Reason:      manager.endReason,
 		})
 		manager.setCurrentSession(SessionInfo{})
 		return nil
@@ -544,6 +628,7 @@ func (manager *connectionManager) saveSessionInfo(sessionInfo SessionInfo) {
 }
 
 func (manager *connectionManager) startConnection(
+	ctx context.Context,
 	conn Connection,
 	consumerID identity.Identity,
 	proposal market.ServiceProposal,
@@ -551,13 +636,25 @@ func (manager *connectionManager) startConnection(
 	sessionDTO session.SessionDto,
 	channel p2p.Channel,
 ) (err error) {
+	routes, err := params.SplitTunnel.Resolve()
+	if err != nil {
+		return errors.Wrap(err, "could not resolve split tunnel routes")
+	}
+
+	dnsOption, err := manager.startSecureDNS(params.DNS)
+	if err != nil {
+		return errors.Wrap(err, "could not start secure DNS resolver")
+	}
+
 	connectOptions := ConnectOptions{
 		SessionID:     sessionDTO.ID,
 		SessionConfig: sessionDTO.Config,
-		DNS:           params.DNS,
+		DNS:           dnsOption,
 		ConsumerID:    consumerID,
 		ProviderID:    identity.FromAddress(proposal.ProviderID),
 		Proposal:      proposal,
+		Routes:        routes,
+		KillSwitch:    !params.DisableKillSwitch,
 	}
 
 	if channel != nil {
@@ -590,13 +687,19 @@ func (manager *connectionManager) startConnection(
 		return err
 	}
 
-	err = manager.waitForConnectedState(conn.State())
+	if !params.DisableKillSwitch {
+		if err = manager.allowBypassedRoutes(routes); err != nil {
+			return err
+		}
+	}
+
+	err = manager.waitForConnectedState(ctx, conn.State())
 	if err != nil {
 		return err
 	}
 
-	go manager.consumeConnectionStates(conn.State())
-	go manager.connectionWaiter(conn)
+	go manager.consumeConnectionStates(ctx, conn.State())
+	go manager.connectionWaiter(ctx, conn)
 	return nil
 }
 
@@ -622,6 +725,14 @@ func (manager *connectionManager) Cancel() {
 }
 
 func (manager *connectionManager) Disconnect() error {
+	return manager.disconnect("")
+}
+
+func (manager *connectionManager) DisconnectWithStatus(status string) error {
+	return manager.disconnect(status)
+}
+
+func (manager *connectionManager) disconnect(reason string) error {
 	manager.discoLock.Lock()
 	defer manager.discoLock.Unlock()
 
@@ -629,8 +740,12 @@ func (manager *connectionManager) Disconnect() error {
 		return ErrNoConnection
 	}
 
+	manager.endReason = reason
+	defer func() { manager.endReason = "" }()
+
 	manager.setStatus(statusDisconnecting())
 	manager.cleanConnection()
+	manager.cleanTrafficBlock()
 	manager.setStatus(statusNotConnected())
 	manager.publishStateEvent(NotConnected)
 
@@ -643,14 +758,18 @@ func (manager *connectionManager) payForService(payments PaymentIssuer) {
 	err := payments.Start()
 	if err != nil {
 		log.Error().Err(err).Msg("Payment error")
-		err = manager.Disconnect()
+		if errors.Cause(err) == ErrBudgetExceeded {
+			err = manager.DisconnectWithStatus(SessionBudgetExceededStatus)
+		} else {
+			err = manager.Disconnect()
+		}
 		if err != nil {
 			log.Error().Err(err).Msg("Could not disconnect gracefully")
 		}
 	}
 }
 
-func (manager *connectionManager) connectionWaiter(connection Connection) {
+func (manager *connectionManager) connectionWaiter(ctx context.Context, connection Connection) {
 	err := connection.Wait()
 	if err != nil {
 		log.Warn().Err(err).Msg("Connection exited with error")
@@ -658,10 +777,10 @@ func (manager *connectionManager) connectionWaiter(connection Connection) {
 		log.Info().Msg("Connection exited")
 	}
 
-	logDisconnectError(manager.Disconnect())
+	manager.onConnectionLost(ctx)
 }
 
-func (manager *connectionManager) waitForConnectedState(stateChannel <-chan State) error {
+func (manager *connectionManager) waitForConnectedState(ctx context.Context, stateChannel <-chan State) error {
 	log.Debug().Msg("waiting for connected state")
 	for {
 		select {
@@ -679,19 +798,19 @@ func (manager *connectionManager) waitForConnectedState(stateChannel <-chan Stat
 			default:
 				manager.onStateChanged(state)
 			}
-		case <-manager.ctx.Done():
-			return manager.ctx.Err()
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 }
 
-func (manager *connectionManager) consumeConnectionStates(stateChannel <-chan State) {
+func (manager *connectionManager) consumeConnectionStates(ctx context.Context, stateChannel <-chan State) {
 	for state := range stateChannel {
 		manager.onStateChanged(state)
 	}
 
 	log.Debug().Msg("State updater stopCalled")
-	logDisconnectError(manager.Disconnect())
+	manager.onConnectionLost(ctx)
 }
 
 func (manager *connectionManager) onStateChanged(state State) {
@@ -712,6 +831,14 @@ func (manager *connectionManager) setupTrafficBlock(disableKillSwitch bool) erro
 		return nil
 	}
 
+	manager.trafficBlockLock.Lock()
+	defer manager.trafficBlockLock.Unlock()
+
+	// Traffic block stays in place between reconnect attempts.
+	if manager.removeTrafficBlock != nil {
+		return nil
+	}
+
 	outboundIP, err := manager.ipResolver.GetOutboundIPAsString()
 	if err != nil {
 		return err
@@ -721,15 +848,68 @@ func (manager *connectionManager) setupTrafficBlock(disableKillSwitch bool) erro
 	if err != nil {
 		return err
 	}
+	manager.removeTrafficBlock = removeRule
+	return nil
+}
+
+// startSecureDNS starts a local stub resolver if DNS-over-HTTPS or DNS-over-TLS is requested,
+// the returned option points the connection to the stub resolver instead.
+func (manager *connectionManager) startSecureDNS(option DNSOption) (DNSOption, error) {
+	upstreams, ok := option.SecureUpstreams()
+	if !ok {
+		return option, nil
+	}
+
+	handler, err := dns.ResolveViaSecureUpstreams(upstreams)
+	if err != nil {
+		return "", err
+	}
+
+	cfg := manager.config.SecureDNS
+	if cfg.ListenAddress == "" {
+		return "", errors.New("secure DNS resolver address is not configured")
+	}
+	proxy := dns.NewProxy(cfg.ListenAddress, cfg.ListenPort, dns.CacheAnswers(handler, cfg.CacheSize))
+	if err := proxy.Run(); err != nil {
+		return "", err
+	}
 	manager.cleanup = append(manager.cleanup, func() error {
-		log.Trace().Msg("Cleaning: traffic block rule")
-		defer log.Trace().Msg("Cleaning: traffic block rule DONE")
-		removeRule()
-		return nil
+		log.Trace().Msg("Cleaning: stopping secure DNS resolver")
+		defer log.Trace().Msg("Cleaning: stopping secure DNS resolver DONE")
+		return proxy.Stop()
 	})
+	return DNSOption(cfg.ListenAddress), nil
+}
+
+// allowBypassedRoutes adds kill switch exceptions for the traffic which is split out of the tunnel.
+func (manager *connectionManager) allowBypassedRoutes(routes Routes) error {
+	for _, network := range routes.Bypassed() {
+		removeRule, err := firewall.AllowIPAccess(network.String())
+		if err != nil {
+			return errors.Wrapf(err, "could not allow access to %s", network.String())
+		}
+		manager.cleanup = append(manager.cleanup, func() error {
+			removeRule()
+			return nil
+		})
+	}
 	return nil
 }
 
+func (manager *connectionManager) cleanTrafficBlock() {
+	manager.trafficBlockLock.Lock()
+	defer manager.trafficBlockLock.Unlock()
+
+	if manager.removeTrafficBlock == nil {
+		return
+	}
+
+	log.Trace().Msg("Cleaning: traffic block rule")
+	defer log.Trace().Msg("Cleaning: traffic block rule DONE")
+	manager.removeTrafficBlock()
+	manager.removeTrafficBlock = nil
+}
+
 func (manager *connectionManager) publishStateEvent(state State) {
 	manager.eventPublisher.Publish(AppTopicConsumerConnectionState, StateEvent{
 		State:       state,
@@ -751,7 +931,7 @@ func (manager *connectionManager) getCurrentSession() SessionInfo {
 	return manager.sessionInfo
 }
 
-func (manager *connectionManager) keepAliveLoop(channel p2p.Channel, sessionID session.ID) {
+func (manager *connectionManager) keepAliveLoop(ctx context.Context, channel p2p.Channel, sessionID session.ID) {
 	// TODO: Remove this check once all provider migrates to p2p.
 	if channel == nil {
 		return
@@ -772,15 +952,15 @@ func (manager *connectionManager) keepAliveLoop(channel p2p.Channel, sessionID s
 	var errCount int
 	for {
 		select {
-		case <-manager.ctx.Done():
+		case <-ctx.Done():
 			return
 		case <-time.After(manager.config.KeepAlive.SendInterval):
 			if err := manager.sendKeepAlivePing(channel, sessionID); err != nil {
 				log.Err(err).Msgf("Failed to send p2p keepalive ping. SessionID=%s", sessionID)
 				errCount++
 				if errCount == manager.config.KeepAlive.MaxSendErrCount {
-					log.Error().Msgf("Max p2p keepalive err count reached, disconnecting. SessionID=%s", sessionID)
-					manager.Disconnect()
+					log.Error().Msgf("Max p2p keepalive err count reached, connection lost. SessionID=%s", sessionID)
+					manager.onConnectionLost(ctx)
 					return
 				}
 			} else {
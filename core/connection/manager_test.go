@@ -19,7 +19,6 @@ package connection
 
 import (
 	"context"
-	"errors"
 	"net"
 	"sync"
 	"testing"
@@ -27,6 +26,7 @@ import (
 
 	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/communication/nats"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
@@ -35,6 +35,7 @@ import (
 	"github.com/mysteriumnetwork/node/pb"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/mysteriumnetwork/node/session/connectivity"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
@@ -52,6 +53,7 @@ type testContext struct {
 	statusSender          *mockStatusSender
 	statsReportInterval   time.Duration
 	mockP2P               *mockP2PDialer
+	mockProposals         *mockProposalLookup
 	sync.RWMutex
 }
 
@@ -67,6 +69,12 @@ var (
 		ServiceType:       activeServiceType,
 		ServiceDefinition: &fakeServiceDefinition{},
 	}
+	failoverProposal = market.ServiceProposal{
+		ProviderID:        "fake-node-2",
+		ProviderContacts:  []market.Contact{activeProviderContact},
+		ServiceType:       activeServiceType,
+		ServiceDefinition: &fakeServiceDefinition{},
+	}
 	establishedSessionID = session.ID("session-100")
 	paymentInfo          session.PaymentInfo
 )
@@ -127,7 +135,8 @@ func (tc *testContext) SetupTest() {
 	brokerConn := nats.StartConnectionMock()
 	brokerConn.MockResponse("fake-node-1.p2p-config-exchange", []byte("123"))
 
-	tc.mockP2P = &mockP2PDialer{&mockP2PChannel{}}
+	tc.mockP2P = &mockP2PDialer{ch: &mockP2PChannel{}}
+	tc.mockProposals = &mockProposalLookup{}
 
 	tc.connManager = NewManager(
 		dialogCreator,
@@ -149,6 +158,7 @@ func (tc *testContext) SetupTest() {
 		tc.statsReportInterval,
 		&mockValidator{},
 		tc.mockP2P,
+		tc.mockProposals,
 	)
 }
 
@@ -156,6 +166,13 @@ func (tc *testContext) TestWhenNoConnectionIsMadeStatusIsNotConnected() {
 	assert.Exactly(tc.T(), statusNotConnected(), tc.connManager.Status())
 }
 
+func (tc *testContext) TestSecureDNSIsRefusedWhenNotConfigured() {
+	err := tc.connManager.Connect(consumerID, accountantID, activeProposal, ConnectParams{DNS: DNSOptionDoH})
+
+	assert.EqualError(tc.T(), err, "could not start secure DNS resolver: secure DNS resolver address is not configured")
+	assert.Equal(tc.T(), statusNotConnected(), tc.connManager.Status())
+}
+
 func (tc *testContext) TestOnConnectErrorStatusIsNotConnected() {
 	tc.fakeConnectionFactory.mockError = errors.New("fatal connection error")
 
@@ -218,6 +235,44 @@ func (tc *testContext) TestDisconnectReturnsErrorWhenNoConnectionExists() {
 	assert.Equal(tc.T(), ErrNoConnection, tc.connManager.Disconnect())
 }
 
+func (tc *testContext) TestDisconnectWithStatusSetsEndedSessionReason() {
+	assert.Equal(tc.T(), ErrNoConnection, tc.connManager.DisconnectWithStatus(SessionBudgetExceededStatus))
+
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, accountantID, activeProposal, ConnectParams{}))
+	tc.stubPublisher.Clear()
+	assert.NoError(tc.T(), tc.connManager.DisconnectWithStatus(SessionBudgetExceededStatus))
+	waitABit()
+	assert.Equal(tc.T(), statusNotConnected(), tc.connManager.Status())
+
+	var ended []SessionEvent
+	for _, v := range tc.stubPublisher.GetEventHistory() {
+		if v.calledWithTopic == AppTopicConsumerSession && v.calledWithData.(SessionEvent).Status == SessionEndedStatus {
+			ended = append(ended, v.calledWithData.(SessionEvent))
+		}
+	}
+	assert.Len(tc.T(), ended, 1)
+	assert.Equal(tc.T(), SessionBudgetExceededStatus, ended[0].Reason)
+}
+
+func (tc *testContext) TestInvoiceOverBudgetDisconnectsWithBudgetExceededReason() {
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, accountantID, activeProposal, ConnectParams{}))
+	waitABit()
+	tc.stubPublisher.Clear()
+
+	tc.MockPaymentIssuer.Fail(errors.Wrap(ErrBudgetExceeded, "invoice not valid"))
+	waitABit()
+	assert.Equal(tc.T(), statusNotConnected(), tc.connManager.Status())
+
+	var ended []SessionEvent
+	for _, v := range tc.stubPublisher.GetEventHistory() {
+		if v.calledWithTopic == AppTopicConsumerSession && v.calledWithData.(SessionEvent).Status == SessionEndedStatus {
+			ended = append(ended, v.calledWithData.(SessionEvent))
+		}
+	}
+	assert.Len(tc.T(), ended, 1)
+	assert.Equal(tc.T(), SessionBudgetExceededStatus, ended[0].Reason)
+}
+
 func (tc *testContext) TestReconnectingStatusIsReportedWhenOpenVpnGoesIntoReconnectingState() {
 	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, accountantID, activeProposal, ConnectParams{}))
 	tc.fakeConnectionFactory.mockConnection.reportState(reconnectingState)
@@ -473,6 +528,80 @@ func (tc *testContext) Test_ManagerNotifiesAboutSuccessfulConnection() {
 	assert.Equal(tc.T(), expectedStatusMsg, tc.mockP2P.ch.getSentMsg())
 }
 
+func (tc *testContext) Test_ManagerReconnectsWhenConnectionIsLost() {
+	tc.connManager.config.IPCheck.MaxAttempts = 0
+	tc.fakeConnectionFactory.mockConnection.onStopReportStates = []fakeState{}
+	params := ConnectParams{
+		Reconnect: ReconnectPolicy{Enabled: true, MaxAttempts: 1, InitialBackoff: time.Millisecond},
+	}
+
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, accountantID, activeProposal, params))
+	tc.stubPublisher.Clear()
+
+	tc.fakeConnectionFactory.mockConnection.reportState(processExited)
+	waitABit()
+
+	assert.Equal(tc.T(), statusConnected(establishedSessionID, activeProposal, consumerID), tc.connManager.Status())
+
+	var reconnectingPublished bool
+	for _, v := range tc.stubPublisher.GetEventHistory() {
+		if v.calledWithTopic == AppTopicConsumerConnectionState && v.calledWithData.(StateEvent).State == Reconnecting {
+			reconnectingPublished = true
+		}
+	}
+	assert.True(tc.T(), reconnectingPublished)
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+}
+
+func (tc *testContext) Test_ManagerDisconnectsWhenReconnectAttemptsAreExhausted() {
+	tc.connManager.config.IPCheck.MaxAttempts = 0
+	tc.fakeConnectionFactory.mockConnection.onStopReportStates = []fakeState{}
+	tc.mockProposals.proposals = []market.ServiceProposal{activeProposal, failoverProposal}
+	params := ConnectParams{
+		Reconnect: ReconnectPolicy{Enabled: true, MaxAttempts: 2, InitialBackoff: time.Millisecond, Failover: true},
+	}
+
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, accountantID, activeProposal, params))
+
+	tc.fakeConnectionFactory.mockConnection.onStartReturnError = errors.New("fatal connection error")
+	tc.fakeConnectionFactory.mockConnection.reportState(processExited)
+	waitABit()
+
+	assert.Equal(tc.T(), statusNotConnected(), tc.connManager.Status())
+	assert.Equal(tc.T(), activeServiceType, tc.mockProposals.getFilter().ServiceType)
+
+	// Initial connect and two attempts to the same provider, then failover to the other one.
+	failoverProviderID := identity.FromAddress(failoverProposal.ProviderID)
+	assert.Equal(tc.T(), []identity.Identity{activeProviderID, activeProviderID, activeProviderID, failoverProviderID}, tc.mockP2P.getDialed())
+}
+
+func (tc *testContext) Test_ManagerStaysDisconnectedWhenDisconnectedDuringReconnect() {
+	tc.connManager.config.IPCheck.MaxAttempts = 0
+	tc.fakeConnectionFactory.mockConnection.onStopReportStates = []fakeState{}
+	params := ConnectParams{
+		Reconnect: ReconnectPolicy{Enabled: true, MaxAttempts: 3, InitialBackoff: 50 * time.Millisecond},
+	}
+
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, accountantID, activeProposal, params))
+
+	tc.fakeConnectionFactory.mockConnection.reportState(processExited)
+	waitABit()
+	assert.Equal(tc.T(), statusReconnecting(), tc.connManager.Status())
+
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(tc.T(), statusNotConnected(), tc.connManager.Status())
+	assert.Len(tc.T(), tc.mockP2P.getDialed(), 1)
+}
+
+func TestDefaultConfig_SecureDNSListensOnLoopback(t *testing.T) {
+	ip := net.ParseIP(DefaultConfig().SecureDNS.ListenAddress)
+
+	assert.NotNil(t, ip)
+	assert.True(t, ip.IsLoopback())
+}
+
 func TestConnectionManagerSuite(t *testing.T) {
 	suite.Run(t, new(testContext))
 }
@@ -521,6 +650,16 @@ func (mpm *MockPaymentIssuer) Stop() {
 	mpm.Lock()
 	defer mpm.Unlock()
 	mpm.stopCalled = true
+	if mpm.MockError == nil {
+		close(mpm.stopChan)
+	}
+}
+
+// Fail makes the running payments exit with the given error.
+func (mpm *MockPaymentIssuer) Fail(err error) {
+	mpm.Lock()
+	defer mpm.Unlock()
+	mpm.MockError = err
 	close(mpm.stopChan)
 }
 
@@ -561,13 +700,26 @@ func (mpm *mockPaymentMethod) GetRate() market.PaymentRate {
 }
 
 type mockP2PDialer struct {
-	ch *mockP2PChannel
+	ch     *mockP2PChannel
+	dialed []identity.Identity
+	lock   sync.Mutex
 }
 
-func (m mockP2PDialer) Dial(ctx context.Context, consumerID identity.Identity, serviceType string, providerID identity.Identity) (p2p.Channel, error) {
+func (m *mockP2PDialer) Dial(ctx context.Context, consumerID identity.Identity, serviceType string, providerID identity.Identity) (p2p.Channel, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.dialed = append(m.dialed, providerID)
 	return m.ch, nil
 }
 
+func (m *mockP2PDialer) getDialed() []identity.Identity {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return append([]identity.Identity{}, m.dialed...)
+}
+
 type mockP2PChannel struct {
 	status connectivity.StatusMessage
 	lock   sync.Mutex
@@ -631,3 +783,24 @@ type mockValidator struct {
 func (mv *mockValidator) Validate(consumerID identity.Identity, proposal market.ServiceProposal) error {
 	return mv.errorToReturn
 }
+
+type mockProposalLookup struct {
+	proposals []market.ServiceProposal
+	filter    *proposal.Filter
+	lock      sync.Mutex
+}
+
+func (m *mockProposalLookup) Proposals(filter *proposal.Filter) ([]market.ServiceProposal, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.filter = filter
+	return m.proposals, nil
+}
+
+func (m *mockProposalLookup) getFilter() *proposal.Filter {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.filter
+}
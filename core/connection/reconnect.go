@@ -0,0 +1,239 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"context"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/rs/zerolog/log"
+)
+
+// ReconnectPolicy describes how a lost connection is recovered
+type ReconnectPolicy struct {
+	// Enabled turns on automatic reconnect, connection is torn down on failure otherwise
+	Enabled bool
+	// MaxAttempts is the number of attempts to reconnect to the same provider
+	MaxAttempts int
+	// InitialBackoff is the delay before the first attempt, it is doubled after every failed attempt
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts
+	MaxBackoff time.Duration
+	// Failover allows connecting to other proposals once attempts to the same provider are exhausted
+	Failover bool
+	// FailoverFilter selects proposals to fail over to, proposals of the same service type are used if not set
+	FailoverFilter *proposal.Filter
+	// MaxFailoverProposals limits the number of other proposals to try, all matching proposals are tried if zero
+	MaxFailoverProposals int
+}
+
+// DefaultReconnectPolicy returns enabled reconnect policy with default params.
+func DefaultReconnectPolicy() ReconnectPolicy {
+	return ReconnectPolicy{
+		Enabled:              true,
+		MaxAttempts:          3,
+		InitialBackoff:       2 * time.Second,
+		MaxBackoff:           30 * time.Second,
+		Failover:             true,
+		MaxFailoverProposals: 5,
+	}
+}
+
+type proposalLookup interface {
+	Proposals(filter *proposal.Filter) ([]market.ServiceProposal, error)
+}
+
+// connectRequest holds params of the last Connect call, they are reused for reconnecting.
+type connectRequest struct {
+	consumerID   identity.Identity
+	accountantID identity.Identity
+	proposal     market.ServiceProposal
+	params       ConnectParams
+}
+
+func (manager *connectionManager) setConnectRequest(request connectRequest) {
+	manager.requestLock.Lock()
+	defer manager.requestLock.Unlock()
+
+	manager.request = request
+}
+
+func (manager *connectionManager) getConnectRequest() connectRequest {
+	manager.requestLock.Lock()
+	defer manager.requestLock.Unlock()
+
+	return manager.request
+}
+
+func (manager *connectionManager) isReconnecting() bool {
+	manager.reconnectingLock.Lock()
+	defer manager.reconnectingLock.Unlock()
+
+	return manager.reconnecting
+}
+
+// onConnectionLost reconnects if it is allowed by the reconnect policy, disconnects otherwise.
+func (manager *connectionManager) onConnectionLost(ctx context.Context) {
+	// Context is cancelled when connection is torn down on purpose.
+	if ctx.Err() != nil {
+		return
+	}
+
+	if !manager.getConnectRequest().params.Reconnect.Enabled {
+		logDisconnectError(manager.Disconnect())
+		return
+	}
+
+	manager.reconnectingLock.Lock()
+	defer manager.reconnectingLock.Unlock()
+
+	if manager.reconnecting {
+		return
+	}
+	manager.reconnecting = true
+	go manager.reconnect(ctx)
+}
+
+func (manager *connectionManager) reconnect(ctx context.Context) {
+	defer func() {
+		manager.reconnectingLock.Lock()
+		manager.reconnecting = false
+		manager.reconnectingLock.Unlock()
+	}()
+
+	request := manager.getConnectRequest()
+	policy := request.params.Reconnect
+
+	ctx, ok := manager.resetConnection(ctx)
+	if !ok {
+		return
+	}
+
+	backoff := policy.InitialBackoff
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		log.Info().Msgf("Reconnecting to provider %s, attempt %d/%d", request.proposal.ProviderID, attempt, policy.MaxAttempts)
+		err := manager.connect(ctx, request.consumerID, request.accountantID, request.proposal, request.params)
+		if err == nil && ctx.Err() == nil {
+			return
+		}
+		log.Warn().Err(err).Msgf("Reconnect attempt %d/%d failed", attempt, policy.MaxAttempts)
+
+		if ctx, ok = manager.resetConnection(ctx); !ok {
+			return
+		}
+
+		backoff *= 2
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+
+	if policy.Failover {
+		for _, candidate := range manager.failoverProposals(request) {
+			if err := manager.validator.Validate(request.consumerID, candidate); err != nil {
+				log.Warn().Err(err).Msgf("Skipping failover to provider %s", candidate.ProviderID)
+				continue
+			}
+
+			log.Info().Msgf("Failing over to provider %s", candidate.ProviderID)
+			err := manager.connect(ctx, request.consumerID, request.accountantID, candidate, request.params)
+			if err == nil && ctx.Err() == nil {
+				request.proposal = candidate
+				manager.setConnectRequest(request)
+				return
+			}
+			log.Warn().Err(err).Msgf("Failover to provider %s failed", candidate.ProviderID)
+
+			if ctx, ok = manager.resetConnection(ctx); !ok {
+				return
+			}
+		}
+	}
+
+	log.Error().Msg("Could not restore lost connection, disconnecting")
+	logDisconnectError(manager.Disconnect())
+}
+
+// resetConnection releases resources of the lost connection and prepares for the next attempt.
+// Traffic block is kept in place so no traffic leaks while reconnecting.
+// Returns false if the connection was torn down in the meantime, resources of the interrupted attempt are released then.
+func (manager *connectionManager) resetConnection(ctx context.Context) (context.Context, bool) {
+	manager.discoLock.Lock()
+	defer manager.discoLock.Unlock()
+
+	// Context of the attempt is cancelled only by Disconnect.
+	if ctx.Err() != nil || manager.Status().State == NotConnected {
+		manager.cleanConnection()
+		manager.cleanAfterDisconnect()
+		manager.cleanTrafficBlock()
+		if manager.Status().State != NotConnected {
+			manager.setStatus(statusNotConnected())
+			manager.publishStateEvent(NotConnected)
+		}
+		return nil, false
+	}
+
+	manager.cleanConnection()
+	manager.cleanAfterDisconnect()
+
+	manager.ctx, manager.cancel = context.WithCancel(context.Background())
+	ctx = manager.ctx
+
+	manager.setStatus(statusReconnecting())
+	manager.publishStateEvent(Reconnecting)
+	return ctx, true
+}
+
+func (manager *connectionManager) failoverProposals(request connectRequest) []market.ServiceProposal {
+	policy := request.params.Reconnect
+
+	filter := policy.FailoverFilter
+	if filter == nil {
+		filter = &proposal.Filter{
+			ServiceType:        request.proposal.ServiceType,
+			ExcludeUnsupported: true,
+		}
+	}
+
+	proposals, err := manager.proposalLookup.Proposals(filter)
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not fetch proposals to fail over to")
+		return nil
+	}
+
+	candidates := make([]market.ServiceProposal, 0)
+	for _, p := range proposals {
+		if p.ProviderID == request.proposal.ProviderID {
+			continue
+		}
+		candidates = append(candidates, p)
+		if policy.MaxFailoverProposals > 0 && len(candidates) == policy.MaxFailoverProposals {
+			break
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+// lookupIP is used to resolve split tunnel domains, replaced in tests.
+var lookupIP = net.LookupIP
+
+// SplitTunnel defines which traffic is routed through the VPN tunnel.
+// When included routes or domains are set only the matching traffic goes through the tunnel,
+// otherwise all traffic except the excluded routes and domains is tunnelled.
+type SplitTunnel struct {
+	// IPv4 networks in CIDR notation or single IPv4 addresses
+	IncludedRoutes []string
+	ExcludedRoutes []string
+	// Domain names resolved to their IPv4 addresses when connecting
+	IncludedDomains []string
+	ExcludedDomains []string
+}
+
+// Routes holds the resolved split tunnel networks
+type Routes struct {
+	Included []net.IPNet
+	Excluded []net.IPNet
+}
+
+// Validate checks if routes and domains are well formed
+func (st SplitTunnel) Validate() error {
+	for _, routes := range [][]string{st.IncludedRoutes, st.ExcludedRoutes} {
+		for _, route := range routes {
+			if _, err := parseRoute(route); err != nil {
+				return err
+			}
+		}
+	}
+	for _, domains := range [][]string{st.IncludedDomains, st.ExcludedDomains} {
+		for _, domain := range domains {
+			if domain == "" || net.ParseIP(domain) != nil {
+				return errors.Errorf("invalid domain: %q", domain)
+			}
+		}
+	}
+	return nil
+}
+
+// Resolve parses routes and resolves domains to host routes
+func (st SplitTunnel) Resolve() (routes Routes, err error) {
+	if routes.Included, err = resolveRoutes(st.IncludedRoutes, st.IncludedDomains); err != nil {
+		return routes, err
+	}
+	if routes.Excluded, err = resolveRoutes(st.ExcludedRoutes, st.ExcludedDomains); err != nil {
+		return routes, err
+	}
+	return routes, nil
+}
+
+// Bypassed returns the networks which are allowed outside of the tunnel by the kill switch.
+// Only the excluded networks are, traffic not covered by the included networks stays blocked.
+func (r Routes) Bypassed() []net.IPNet {
+	return r.Excluded
+}
+
+func resolveRoutes(routes, domains []string) ([]net.IPNet, error) {
+	var networks []net.IPNet
+	for _, route := range routes {
+		network, err := parseRoute(route)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	for _, domain := range domains {
+		ips, err := lookupIP(domain)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not resolve domain %s", domain)
+		}
+		resolved := false
+		for _, ip := range ips {
+			if ip = ip.To4(); ip != nil {
+				networks = append(networks, net.IPNet{IP: ip, Mask: net.CIDRMask(32, 32)})
+				resolved = true
+			}
+		}
+		if !resolved {
+			return nil, errors.Errorf("domain %s has no IPv4 addresses", domain)
+		}
+	}
+	return networks, nil
+}
+
+func parseRoute(route string) (net.IPNet, error) {
+	if ip := net.ParseIP(route); ip != nil {
+		if ip = ip.To4(); ip == nil {
+			return net.IPNet{}, errors.Errorf("only IPv4 routes are supported: %s", route)
+		}
+		return net.IPNet{IP: ip, Mask: net.CIDRMask(32, 32)}, nil
+	}
+
+	_, network, err := net.ParseCIDR(route)
+	if err != nil {
+		return net.IPNet{}, errors.Errorf("invalid route: %q", route)
+	}
+	if network.IP.To4() == nil {
+		return net.IPNet{}, errors.Errorf("only IPv4 routes are supported: %s", route)
+	}
+	return net.IPNet{IP: network.IP.To4(), Mask: network.Mask}, nil
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func networks(cidrs ...string) []net.IPNet {
+	result := make([]net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, _ := net.ParseCIDR(cidr)
+		result = append(result, net.IPNet{IP: network.IP.To4(), Mask: network.Mask})
+	}
+	return result
+}
+
+func TestSplitTunnel_Validate(t *testing.T) {
+	assert.NoError(t, SplitTunnel{}.Validate())
+	assert.NoError(t, SplitTunnel{
+		IncludedRoutes:  []string{"10.0.0.0/8", "1.1.1.1"},
+		ExcludedRoutes:  []string{"10.1.0.0/16"},
+		IncludedDomains: []string{"example.com"},
+	}.Validate())
+
+	assert.EqualError(t, SplitTunnel{IncludedRoutes: []string{"10.0.0.0/33"}}.Validate(), `invalid route: "10.0.0.0/33"`)
+	assert.EqualError(t, SplitTunnel{ExcludedRoutes: []string{"::1"}}.Validate(), "only IPv4 routes are supported: ::1")
+	assert.EqualError(t, SplitTunnel{ExcludedRoutes: []string{"2001:db8::/32"}}.Validate(), "only IPv4 routes are supported: 2001:db8::/32")
+	assert.EqualError(t, SplitTunnel{ExcludedDomains: []string{""}}.Validate(), `invalid domain: ""`)
+	assert.EqualError(t, SplitTunnel{IncludedDomains: []string{"1.1.1.1"}}.Validate(), `invalid domain: "1.1.1.1"`)
+}
+
+func TestSplitTunnel_Resolve(t *testing.T) {
+	defer func(original func(string) ([]net.IP, error)) { lookupIP = original }(lookupIP)
+	lookupIP = func(host string) ([]net.IP, error) {
+		switch host {
+		case "example.com":
+			return []net.IP{net.ParseIP("93.184.216.34"), net.ParseIP("2606:2800:220:1::")}, nil
+		case "v6.example.com":
+			return []net.IP{net.ParseIP("2606:2800:220:1::")}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	routes, err := SplitTunnel{
+		ExcludedRoutes:  []string{"192.168.0.0/16", "1.1.1.1"},
+		ExcludedDomains: []string{"example.com"},
+	}.Resolve()
+	assert.NoError(t, err)
+	assert.Empty(t, routes.Included)
+	assert.Equal(t, networks("192.168.0.0/16", "1.1.1.1/32", "93.184.216.34/32"), routes.Excluded)
+
+	_, err = SplitTunnel{IncludedDomains: []string{"unknown.example.com"}}.Resolve()
+	assert.EqualError(t, err, "could not resolve domain unknown.example.com: no such host")
+
+	_, err = SplitTunnel{IncludedDomains: []string{"v6.example.com"}}.Resolve()
+	assert.EqualError(t, err, "domain v6.example.com has no IPv4 addresses")
+}
+
+func TestRoutes_Bypassed(t *testing.T) {
+	assert.Empty(t, Routes{}.Bypassed())
+	assert.Equal(t, networks("10.0.0.0/8"), Routes{Excluded: networks("10.0.0.0/8")}.Bypassed())
+
+	routes := Routes{Included: networks("0.0.0.0/1", "192.0.0.0/2"), Excluded: networks("10.0.0.0/8")}
+	assert.Equal(t, networks("10.0.0.0/8"), routes.Bypassed())
+
+	assert.Empty(t, Routes{Included: networks("10.0.0.0/8", "1.1.1.1/32")}.Bypassed())
+}
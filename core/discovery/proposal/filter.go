@@ -27,7 +27,11 @@ import (
 type Filter struct {
 	ProviderID          string
 	ServiceType         string
+	LocationCountry     string
+	LocationCity        string
 	LocationType        string
+	NATType             string
+	FilteredDNS         bool
 	AccessPolicyID      string
 	AccessPolicySource  string
 	UpperTimePriceBound *uint64
@@ -35,6 +39,11 @@ type Filter struct {
 	UpperGBPriceBound   *uint64
 	LowerGBPriceBound   *uint64
 	ExcludeUnsupported  bool
+
+	// QualityMin filters out proposals whose quality score is below the given value, disabled when zero.
+	QualityMin float64
+	// QualityScores holds known proposal quality scores, used together with QualityMin.
+	QualityScores map[market.ProposalID]float64
 }
 
 // Matches return flag if filter matches given proposal
@@ -51,9 +60,24 @@ func (filter *Filter) Matches(proposal market.ServiceProposal) bool {
 	if filter.ServiceType != "" {
 		conditions = append(conditions, reducer.Equal(reducer.ServiceType, filter.ServiceType))
 	}
+	if filter.LocationCountry != "" {
+		conditions = append(conditions, reducer.Equal(reducer.LocationCountry, filter.LocationCountry))
+	}
+	if filter.LocationCity != "" {
+		conditions = append(conditions, reducer.Equal(reducer.LocationCity, filter.LocationCity))
+	}
 	if filter.LocationType != "" {
 		conditions = append(conditions, reducer.Equal(reducer.LocationType, filter.LocationType))
 	}
+	if filter.NATType != "" {
+		conditions = append(conditions, reducer.Equal(reducer.NATType, filter.NATType))
+	}
+	if filter.FilteredDNS {
+		conditions = append(conditions, reducer.Equal(reducer.FilteredDNS, true))
+	}
+	if filter.QualityMin > 0 {
+		conditions = append(conditions, reducer.QualityMin(filter.QualityScores, filter.QualityMin))
+	}
 	if filter.AccessPolicyID != "" || filter.AccessPolicySource != "" {
 		conditions = append(conditions, reducer.AccessPolicy(filter.AccessPolicyID, filter.AccessPolicySource))
 	}
@@ -79,6 +103,7 @@ func (filter *Filter) ToAPIQuery() mysterium.ProposalsQuery {
 		ServiceType:        filter.ServiceType,
 		AccessPolicyID:     filter.AccessPolicyID,
 		AccessPolicySource: filter.AccessPolicySource,
+		NodeType:           filter.LocationType,
 	}
 	if filter.ServiceType == "" {
 		query.ServiceType = "all"
@@ -51,6 +51,7 @@ var (
 		ServiceType:       serviceTypeStreaming,
 		ServiceDefinition: mockService{Location: locationDatacenter},
 		AccessPolicies:    &[]market.AccessPolicy{accessRuleWhitelist},
+		NATType:           market.NATTypeBehindNAT,
 	}
 	proposalProvider1Noop = market.ServiceProposal{
 		ProviderID:        provider1,
@@ -62,6 +63,8 @@ var (
 		ServiceType:       serviceTypeStreaming,
 		ServiceDefinition: mockService{Location: locationResidential},
 		AccessPolicies:    &[]market.AccessPolicy{accessRuleWhitelist, accessRuleBlacklist},
+		NATType:           market.NATTypeNone,
+		FilteredDNS:       true,
 	}
 	proposalTimeExpensive = market.ServiceProposal{
 		PaymentMethod: &mockPaymentMethod{
@@ -193,6 +196,77 @@ func Test_ProposalFilter_FiltersByLocationType(t *testing.T) {
 	assert.True(t, filter.Matches(proposalProvider2Streaming))
 }
 
+func Test_ProposalFilter_FiltersByLocation(t *testing.T) {
+	filter := &Filter{
+		LocationCountry: "DE",
+	}
+	assert.False(t, filter.Matches(proposalEmpty))
+	assert.True(t, filter.Matches(proposalProvider1Streaming))
+	assert.False(t, filter.Matches(proposalProvider1Noop))
+	assert.False(t, filter.Matches(proposalProvider2Streaming))
+
+	filter = &Filter{
+		LocationCountry: "LT",
+		LocationCity:    "Vilnius",
+	}
+	assert.False(t, filter.Matches(proposalEmpty))
+	assert.False(t, filter.Matches(proposalProvider1Streaming))
+	assert.False(t, filter.Matches(proposalProvider1Noop))
+	assert.True(t, filter.Matches(proposalProvider2Streaming))
+
+	filter = &Filter{
+		LocationCountry: "LT",
+		LocationCity:    "Berlin",
+	}
+	assert.False(t, filter.Matches(proposalProvider1Streaming))
+	assert.False(t, filter.Matches(proposalProvider2Streaming))
+}
+
+func Test_ProposalFilter_FiltersByNATType(t *testing.T) {
+	filter := &Filter{
+		NATType: market.NATTypeNone,
+	}
+	assert.False(t, filter.Matches(proposalEmpty))
+	assert.False(t, filter.Matches(proposalProvider1Streaming))
+	assert.False(t, filter.Matches(proposalProvider1Noop))
+	assert.True(t, filter.Matches(proposalProvider2Streaming))
+}
+
+func Test_ProposalFilter_FiltersByFilteredDNS(t *testing.T) {
+	filter := &Filter{
+		FilteredDNS: true,
+	}
+	assert.False(t, filter.Matches(proposalEmpty))
+	assert.False(t, filter.Matches(proposalProvider1Streaming))
+	assert.True(t, filter.Matches(proposalProvider2Streaming))
+
+	filter = &Filter{}
+	assert.True(t, filter.Matches(proposalProvider1Streaming))
+	assert.True(t, filter.Matches(proposalProvider2Streaming))
+}
+
+func Test_ProposalFilter_FiltersByQuality(t *testing.T) {
+	scores := map[market.ProposalID]float64{
+		proposalProvider1Streaming.UniqueID(): 0.2,
+		proposalProvider2Streaming.UniqueID(): 0.8,
+	}
+
+	filter := &Filter{
+		QualityMin:    0.5,
+		QualityScores: scores,
+	}
+	assert.False(t, filter.Matches(proposalEmpty))
+	assert.False(t, filter.Matches(proposalProvider1Streaming))
+	assert.False(t, filter.Matches(proposalProvider1Noop))
+	assert.True(t, filter.Matches(proposalProvider2Streaming))
+
+	filter = &Filter{
+		QualityScores: scores,
+	}
+	assert.True(t, filter.Matches(proposalProvider1Noop))
+	assert.True(t, filter.Matches(proposalProvider1Streaming))
+}
+
 func Test_ProposalFilter_FiltersByAccessID(t *testing.T) {
 	filter := &Filter{
 		AccessPolicyID: "whitelist",
@@ -0,0 +1,144 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ranking
+
+import (
+	"sort"
+
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SortByScore sorts proposals by the total weighted score
+	SortByScore = "score"
+	// SortByPrice sorts proposals by their relative price
+	SortByPrice = "price"
+	// SortByQuality sorts proposals by the quality reported by quality oracle
+	SortByQuality = "quality"
+	// SortBySuccessRate sorts proposals by the success rate of consumer's past sessions
+	SortBySuccessRate = "success_rate"
+
+	// OrderAsc sorts from the lowest value to the highest
+	OrderAsc = "asc"
+	// OrderDesc sorts from the highest value to the lowest
+	OrderDesc = "desc"
+)
+
+// Score holds the proposal scores, all of them are in range from 0 to 1 where higher is better
+type Score struct {
+	Total       float64
+	Price       float64
+	Quality     float64
+	SuccessRate float64
+}
+
+// Scorer calculates scores of the given proposals
+type Scorer interface {
+	Score(proposals []market.ServiceProposal) map[market.ProposalID]Score
+}
+
+// Options defines how proposals are ordered and paginated
+type Options struct {
+	SortBy string
+	Order  string
+	Limit  int
+	Offset int
+}
+
+// Validate checks if options are valid
+func (o Options) Validate() error {
+	switch o.SortBy {
+	case "", SortByScore, SortByPrice, SortByQuality, SortBySuccessRate:
+	default:
+		return errors.Errorf("unsupported sort key: %s", o.SortBy)
+	}
+	switch o.Order {
+	case "", OrderAsc, OrderDesc:
+	default:
+		return errors.Errorf("unsupported sort order: %s", o.Order)
+	}
+	if o.Limit < 0 {
+		return errors.New("limit can not be negative")
+	}
+	if o.Offset < 0 {
+		return errors.New("offset can not be negative")
+	}
+	return nil
+}
+
+// Rank sorts proposals by the scores given by scorer and paginates the result.
+// Proposals are left in their original order if sort key is not set.
+func Rank(scorer Scorer, proposals []market.ServiceProposal, opts Options) []market.ServiceProposal {
+	if opts.SortBy != "" {
+		proposals = sortProposals(scorer.Score(proposals), proposals, opts.SortBy, opts.Order)
+	}
+	return Paginate(proposals, opts.Offset, opts.Limit)
+}
+
+// Paginate returns the requested page of proposals, limit of zero means no limit
+func Paginate(proposals []market.ServiceProposal, offset, limit int) []market.ServiceProposal {
+	if offset >= len(proposals) {
+		return []market.ServiceProposal{}
+	}
+	proposals = proposals[offset:]
+	if limit > 0 && limit < len(proposals) {
+		proposals = proposals[:limit]
+	}
+	return proposals
+}
+
+func sortProposals(scores map[market.ProposalID]Score, proposals []market.ServiceProposal, sortBy, order string) []market.ServiceProposal {
+	if order == "" {
+		order = defaultOrder(sortBy)
+	}
+
+	sorted := make([]market.ServiceProposal, len(proposals))
+	copy(sorted, proposals)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a := sortValue(scores[sorted[i].UniqueID()], sortBy)
+		b := sortValue(scores[sorted[j].UniqueID()], sortBy)
+		if order == OrderAsc {
+			return a < b
+		}
+		return a > b
+	})
+	return sorted
+}
+
+// defaultOrder puts the best proposals first: cheapest ones for price and highest scored ones otherwise.
+func defaultOrder(sortBy string) string {
+	if sortBy == SortByPrice {
+		return OrderAsc
+	}
+	return OrderDesc
+}
+
+func sortValue(score Score, sortBy string) float64 {
+	switch sortBy {
+	case SortByPrice:
+		// Price score is higher for cheaper proposals, invert it to sort by the relative price.
+		return 1 - score.Price
+	case SortByQuality:
+		return score.Quality
+	case SortBySuccessRate:
+		return score.SuccessRate
+	default:
+		return score.Total
+	}
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ranking
+
+import (
+	"testing"
+
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	proposal1 = market.ServiceProposal{ProviderID: "0x1", ServiceType: "wireguard"}
+	proposal2 = market.ServiceProposal{ProviderID: "0x2", ServiceType: "wireguard"}
+	proposal3 = market.ServiceProposal{ProviderID: "0x3", ServiceType: "openvpn"}
+)
+
+type staticScorer map[market.ProposalID]Score
+
+func (s staticScorer) Score(_ []market.ServiceProposal) map[market.ProposalID]Score {
+	return s
+}
+
+func providerIDs(proposals []market.ServiceProposal) []string {
+	ids := make([]string, 0, len(proposals))
+	for _, p := range proposals {
+		ids = append(ids, p.ProviderID)
+	}
+	return ids
+}
+
+func TestRank(t *testing.T) {
+	scorer := staticScorer{
+		proposal1.UniqueID(): {Total: 0.5, Price: 0.1, Quality: 0.9, SuccessRate: 0.2},
+		proposal2.UniqueID(): {Total: 0.9, Price: 0.6, Quality: 0.3, SuccessRate: 0.1},
+		proposal3.UniqueID(): {Total: 0.1, Price: 1.0, Quality: 0.5, SuccessRate: 0.7},
+	}
+	proposals := []market.ServiceProposal{proposal1, proposal2, proposal3}
+
+	tests := []struct {
+		opts     Options
+		expected []string
+	}{
+		{Options{}, []string{"0x1", "0x2", "0x3"}},
+		{Options{SortBy: SortByScore}, []string{"0x2", "0x1", "0x3"}},
+		{Options{SortBy: SortByScore, Order: OrderAsc}, []string{"0x3", "0x1", "0x2"}},
+		{Options{SortBy: SortByPrice}, []string{"0x3", "0x2", "0x1"}},
+		{Options{SortBy: SortByPrice, Order: OrderDesc}, []string{"0x1", "0x2", "0x3"}},
+		{Options{SortBy: SortByQuality}, []string{"0x1", "0x3", "0x2"}},
+		{Options{SortBy: SortBySuccessRate}, []string{"0x3", "0x1", "0x2"}},
+		{Options{SortBy: SortByScore, Limit: 2}, []string{"0x2", "0x1"}},
+		{Options{SortBy: SortByScore, Offset: 1, Limit: 1}, []string{"0x1"}},
+		{Options{Offset: 5}, []string{}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, providerIDs(Rank(scorer, proposals, tt.opts)), "%+v", tt.opts)
+	}
+
+	// Original slice must be left untouched.
+	assert.Equal(t, []string{"0x1", "0x2", "0x3"}, providerIDs(proposals))
+}
+
+func TestOptions_Validate(t *testing.T) {
+	assert.NoError(t, Options{}.Validate())
+	assert.NoError(t, Options{SortBy: SortBySuccessRate, Order: OrderAsc, Limit: 10, Offset: 20}.Validate())
+	assert.Error(t, Options{SortBy: "country"}.Validate())
+	assert.Error(t, Options{Order: "up"}.Validate())
+	assert.Error(t, Options{Limit: -1}.Validate())
+	assert.Error(t, Options{Offset: -1}.Validate())
+}
@@ -0,0 +1,202 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ranking
+
+import (
+	"math"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/quality"
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/rs/zerolog/log"
+)
+
+// unknownScore is used for proposals which have no quality or session history data.
+const unknownScore = 0.5
+
+// QualityFinder allows to fetch proposal quality data
+type QualityFinder interface {
+	ProposalsMetrics() []quality.ConnectMetric
+}
+
+// SessionOutcome describes the outcome of consumer's past session
+type SessionOutcome struct {
+	ProposalID market.ProposalID
+	// Successful marks a session which completed and transferred any data
+	Successful bool
+}
+
+// SessionHistory allows to fetch outcomes of consumer's past sessions
+type SessionHistory interface {
+	SessionOutcomes() ([]SessionOutcome, error)
+}
+
+// Weights defines how much each of the scores impacts the total score
+type Weights struct {
+	Price       float64
+	Quality     float64
+	SuccessRate float64
+}
+
+// DefaultWeights returns weights favouring the quality of proposals
+func DefaultWeights() Weights {
+	return Weights{
+		Price:       0.3,
+		Quality:     0.4,
+		SuccessRate: 0.3,
+	}
+}
+
+// WeightedScorer scores proposals by the weighted sum of price, quality and past session success rate
+type WeightedScorer struct {
+	weights       Weights
+	qualityFinder QualityFinder
+	history       SessionHistory
+}
+
+// NewWeightedScorer creates a new instance of WeightedScorer
+func NewWeightedScorer(weights Weights, qualityFinder QualityFinder, history SessionHistory) *WeightedScorer {
+	return &WeightedScorer{
+		weights:       weights,
+		qualityFinder: qualityFinder,
+		history:       history,
+	}
+}
+
+// Score calculates scores of the given proposals
+func (s *WeightedScorer) Score(proposals []market.ServiceProposal) map[market.ProposalID]Score {
+	priceScores := priceScores(proposals)
+	qualityScores := s.qualityScores()
+	successRates := s.successRates()
+
+	totalWeight := s.weights.Price + s.weights.Quality + s.weights.SuccessRate
+
+	scores := make(map[market.ProposalID]Score, len(proposals))
+	for _, p := range proposals {
+		id := p.UniqueID()
+		score := Score{
+			Price:       priceScores[id],
+			Quality:     unknownScore,
+			SuccessRate: unknownScore,
+		}
+		if q, ok := qualityScores[id]; ok {
+			score.Quality = q
+		}
+		if r, ok := successRates[id]; ok {
+			score.SuccessRate = r
+		}
+		if totalWeight > 0 {
+			score.Total = (score.Price*s.weights.Price +
+				score.Quality*s.weights.Quality +
+				score.SuccessRate*s.weights.SuccessRate) / totalWeight
+		}
+		scores[id] = score
+	}
+	return scores
+}
+
+func (s *WeightedScorer) qualityScores() map[market.ProposalID]float64 {
+	if s.qualityFinder == nil {
+		return nil
+	}
+	return quality.ProposalScores(s.qualityFinder.ProposalsMetrics())
+}
+
+// successRates calculates the share of consumer's past sessions which transferred any data.
+func (s *WeightedScorer) successRates() map[market.ProposalID]float64 {
+	if s.history == nil {
+		return nil
+	}
+	outcomes, err := s.history.SessionOutcomes()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get session history, ignoring success rates")
+		return nil
+	}
+
+	type counter struct{ total, successful int }
+	counters := make(map[market.ProposalID]*counter)
+	for _, outcome := range outcomes {
+		c, ok := counters[outcome.ProposalID]
+		if !ok {
+			c = &counter{}
+			counters[outcome.ProposalID] = c
+		}
+		c.total++
+		if outcome.Successful {
+			c.successful++
+		}
+	}
+
+	rates := make(map[market.ProposalID]float64, len(counters))
+	for id, c := range counters {
+		rates[id] = float64(c.successful) / float64(c.total)
+	}
+	return rates
+}
+
+// priceScores normalizes proposal prices per hour and per GiB among the given proposals,
+// the cheapest proposal gets the score of 1 and the most expensive one gets 0.
+func priceScores(proposals []market.ServiceProposal) map[market.ProposalID]float64 {
+	timePrices := make([]float64, len(proposals))
+	dataPrices := make([]float64, len(proposals))
+	for i, p := range proposals {
+		timePrices[i], dataPrices[i] = proposalPrices(p)
+	}
+	timeScores := normalizeCheapness(timePrices)
+	dataScores := normalizeCheapness(dataPrices)
+
+	scores := make(map[market.ProposalID]float64, len(proposals))
+	for i, p := range proposals {
+		scores[p.UniqueID()] = (timeScores[i] + dataScores[i]) / 2
+	}
+	return scores
+}
+
+func proposalPrices(p market.ServiceProposal) (perHour, perGiB float64) {
+	if p.PaymentMethod == nil {
+		return 0, 0
+	}
+	price := float64(p.PaymentMethod.GetPrice().Amount)
+	rate := p.PaymentMethod.GetRate()
+	if rate.PerTime > 0 {
+		perHour = price * float64(time.Hour) / float64(rate.PerTime)
+	}
+	if rate.PerByte > 0 {
+		perGiB = price * float64(datasize.GiB.Bytes()) / float64(rate.PerByte)
+	}
+	return perHour, perGiB
+}
+
+func normalizeCheapness(prices []float64) []float64 {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, p := range prices {
+		min = math.Min(min, p)
+		max = math.Max(max, p)
+	}
+
+	scores := make([]float64, len(prices))
+	for i, p := range prices {
+		if max == min {
+			scores[i] = 1
+			continue
+		}
+		scores[i] = (max - p) / (max - min)
+	}
+	return scores
+}
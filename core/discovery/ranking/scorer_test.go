@@ -0,0 +1,125 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ranking
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/quality"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/money"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPaymentMethod struct {
+	price money.Money
+	rate  market.PaymentRate
+}
+
+func (m *mockPaymentMethod) GetPrice() money.Money {
+	return m.price
+}
+
+func (m *mockPaymentMethod) GetType() string {
+	return "mock"
+}
+
+func (m *mockPaymentMethod) GetRate() market.PaymentRate {
+	return m.rate
+}
+
+type mockQualityFinder struct {
+	metrics []quality.ConnectMetric
+}
+
+func (m *mockQualityFinder) ProposalsMetrics() []quality.ConnectMetric {
+	return m.metrics
+}
+
+type mockSessionHistory struct {
+	outcomes []SessionOutcome
+	err      error
+}
+
+func (m *mockSessionHistory) SessionOutcomes() ([]SessionOutcome, error) {
+	return m.outcomes, m.err
+}
+
+func pricedProposal(providerID string, amount uint64) market.ServiceProposal {
+	return market.ServiceProposal{
+		ProviderID:  providerID,
+		ServiceType: "wireguard",
+		PaymentMethod: &mockPaymentMethod{
+			price: money.NewMoney(amount, money.CurrencyMyst),
+			rate:  market.PaymentRate{PerTime: time.Minute, PerByte: 1024},
+		},
+	}
+}
+
+func TestWeightedScorer_ScoresByPrice(t *testing.T) {
+	cheap := pricedProposal("0x1", 10)
+	medium := pricedProposal("0x2", 20)
+	expensive := pricedProposal("0x3", 30)
+
+	scorer := NewWeightedScorer(Weights{Price: 1}, nil, nil)
+	scores := scorer.Score([]market.ServiceProposal{cheap, medium, expensive})
+
+	assert.Equal(t, 1.0, scores[cheap.UniqueID()].Price)
+	assert.Equal(t, 0.5, scores[medium.UniqueID()].Price)
+	assert.Equal(t, 0.0, scores[expensive.UniqueID()].Price)
+	assert.Equal(t, 1.0, scores[cheap.UniqueID()].Total)
+	assert.Equal(t, 0.0, scores[expensive.UniqueID()].Total)
+}
+
+func TestWeightedScorer_ScoresByQualityAndSuccessRate(t *testing.T) {
+	p1 := pricedProposal("0x1", 10)
+	p2 := pricedProposal("0x2", 10)
+	p3 := pricedProposal("0x3", 10)
+
+	qualityFinder := &mockQualityFinder{metrics: []quality.ConnectMetric{
+		{
+			ProposalID:   quality.ProposalID{ProviderID: "0x1", ServiceType: "wireguard"},
+			ConnectCount: quality.ConnectCount{Success: 3, Fail: 1},
+		},
+	}}
+	p2ID := market.ProposalID{ProviderID: "0x2", ServiceType: "wireguard"}
+	history := &mockSessionHistory{outcomes: []SessionOutcome{
+		{ProposalID: p2ID, Successful: true},
+		{ProposalID: p2ID},
+		{ProposalID: p2ID},
+		{ProposalID: p2ID, Successful: true},
+	}}
+
+	scorer := NewWeightedScorer(Weights{Quality: 1, SuccessRate: 1}, qualityFinder, history)
+	scores := scorer.Score([]market.ServiceProposal{p1, p2, p3})
+
+	assert.Equal(t, Score{Total: 0.625, Price: 1, Quality: 0.75, SuccessRate: unknownScore}, scores[p1.UniqueID()])
+	assert.Equal(t, Score{Total: 0.5, Price: 1, Quality: unknownScore, SuccessRate: 0.5}, scores[p2.UniqueID()])
+	assert.Equal(t, Score{Total: 0.5, Price: 1, Quality: unknownScore, SuccessRate: unknownScore}, scores[p3.UniqueID()])
+}
+
+func TestWeightedScorer_IgnoresHistoryErrors(t *testing.T) {
+	p1 := pricedProposal("0x1", 10)
+
+	scorer := NewWeightedScorer(DefaultWeights(), &mockQualityFinder{}, &mockSessionHistory{err: errors.New("boom")})
+	scores := scorer.Score([]market.ServiceProposal{p1})
+
+	assert.Equal(t, unknownScore, scores[p1.UniqueID()].SuccessRate)
+}
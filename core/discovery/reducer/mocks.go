@@ -47,6 +47,7 @@ var (
 		ServiceType:       serviceTypeStreaming,
 		ServiceDefinition: mockService{Location: locationDatacenter},
 		AccessPolicies:    &[]market.AccessPolicy{accessRuleWhitelist},
+		NATType:           market.NATTypeBehindNAT,
 	}
 	proposalProvider1Noop = market.ServiceProposal{
 		ProviderID:        provider1,
@@ -58,6 +59,8 @@ var (
 		ServiceType:       serviceTypeStreaming,
 		ServiceDefinition: mockService{Location: locationResidential},
 		AccessPolicies:    &[]market.AccessPolicy{accessRuleWhitelist, accessRuleBlacklist},
+		NATType:           market.NATTypeNone,
+		FilteredDNS:       true,
 	}
 	proposalTimeExpensive = market.ServiceProposal{
 		PaymentMethod: &mockPaymentMethod{
@@ -58,6 +58,15 @@ func LocationCountry(proposal market.ServiceProposal) interface{} {
 	return service.GetLocation().Country
 }
 
+// LocationCity selects location city from proposal
+func LocationCity(proposal market.ServiceProposal) interface{} {
+	service := proposal.ServiceDefinition
+	if service == nil {
+		return nil
+	}
+	return service.GetLocation().City
+}
+
 // LocationType selects location type from proposal
 func LocationType(proposal market.ServiceProposal) interface{} {
 	service := proposal.ServiceDefinition
@@ -67,6 +76,16 @@ func LocationType(proposal market.ServiceProposal) interface{} {
 	return service.GetLocation().NodeType
 }
 
+// NATType selects provider NAT type from proposal
+func NATType(proposal market.ServiceProposal) interface{} {
+	return proposal.NATType
+}
+
+// FilteredDNS selects if provider DNS is filtered from proposal
+func FilteredDNS(proposal market.ServiceProposal) interface{} {
+	return proposal.FilteredDNS
+}
+
 // PriceMinute checks if the price per minute is below the given value
 func PriceMinute(lowerBound, upperBound uint64) func(market.ServiceProposal) bool {
 	return pricePerTime(lowerBound, upperBound, time.Minute)
@@ -132,6 +151,18 @@ func AccessPolicy(id, source string) func(market.ServiceProposal) bool {
 	}
 }
 
+// QualityMin returns a matcher for checking if proposal quality score is not below the given value.
+// Proposals without known quality score are not matched.
+func QualityMin(scores map[market.ProposalID]float64, min float64) func(market.ServiceProposal) bool {
+	return func(proposal market.ServiceProposal) bool {
+		score, ok := scores[proposal.UniqueID()]
+		if !ok {
+			return false
+		}
+		return score >= min
+	}
+}
+
 // Unsupported filters out unsupported proposals
 func Unsupported() func(market.ServiceProposal) bool {
 	return func(proposal market.ServiceProposal) bool {
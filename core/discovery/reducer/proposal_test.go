@@ -20,6 +20,7 @@ package reducer
 import (
 	"testing"
 
+	"github.com/mysteriumnetwork/node/market"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -59,6 +60,49 @@ func Test_Location_FiltersByNodeType(t *testing.T) {
 	assert.True(t, match(proposalProvider2Streaming))
 }
 
+func Test_Location_FiltersByCity(t *testing.T) {
+	match := EqualString(LocationCity, "Vilnius")
+
+	assert.False(t, match(proposalEmpty))
+	assert.False(t, match(proposalProvider1Streaming))
+	assert.False(t, match(proposalProvider1Noop))
+	assert.True(t, match(proposalProvider2Streaming))
+}
+
+func Test_NATType_FiltersByNATType(t *testing.T) {
+	match := EqualString(NATType, market.NATTypeNone)
+
+	assert.False(t, match(proposalEmpty))
+	assert.False(t, match(proposalProvider1Streaming))
+	assert.False(t, match(proposalProvider1Noop))
+	assert.True(t, match(proposalProvider2Streaming))
+}
+
+func Test_FilteredDNS_FiltersByFilteredDNS(t *testing.T) {
+	match := Equal(FilteredDNS, true)
+
+	assert.False(t, match(proposalEmpty))
+	assert.False(t, match(proposalProvider1Streaming))
+	assert.False(t, match(proposalProvider1Noop))
+	assert.True(t, match(proposalProvider2Streaming))
+}
+
+func Test_QualityMin_FiltersByScore(t *testing.T) {
+	scores := map[market.ProposalID]float64{
+		proposalProvider1Streaming.UniqueID(): 0.9,
+		proposalProvider2Streaming.UniqueID(): 0.3,
+	}
+	match := QualityMin(scores, 0.5)
+
+	assert.False(t, match(proposalEmpty))
+	assert.True(t, match(proposalProvider1Streaming))
+	assert.False(t, match(proposalProvider1Noop))
+	assert.False(t, match(proposalProvider2Streaming))
+
+	match = QualityMin(scores, 0.3)
+	assert.True(t, match(proposalProvider2Streaming))
+}
+
 func Test_AccessPolicy_FiltersByID(t *testing.T) {
 	match := AccessPolicy(accessRuleWhitelist.ID, "")
 
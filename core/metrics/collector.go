@@ -0,0 +1,169 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package metrics
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	stateEvent "github.com/mysteriumnetwork/node/core/state/event"
+	"github.com/mysteriumnetwork/node/eventbus"
+	natEvent "github.com/mysteriumnetwork/node/nat/event"
+	"github.com/mysteriumnetwork/node/p2p"
+	"github.com/mysteriumnetwork/node/session"
+	"github.com/mysteriumnetwork/node/session/pingpong"
+)
+
+// Collector keeps the node metrics up to date by consuming application events.
+type Collector struct {
+	providerSessionsActive   *Metric
+	providerBytesSent        *Metric
+	providerBytesReceived    *Metric
+	consumerBytesSent        *Metric
+	consumerBytesReceived    *Metric
+	natStatus                *Metric
+	natTraversalEvents       *Metric
+	identityBalance          *Metric
+	identityEarnings         *Metric
+	identityLifetimeEarnings *Metric
+	settlements              *Metric
+	settlementFailures       *Metric
+	p2pChannelErrors         *Metric
+
+	lock             sync.Mutex
+	providerSessions map[string]stateEvent.ServiceSession
+	consumerSession  session.ID
+	consumerStats    connection.Statistics
+}
+
+// NewCollector registers the node metrics in the given registry.
+func NewCollector(registry *Registry) *Collector {
+	return &Collector{
+		providerSessionsActive:   registry.NewGauge("myst_provider_sessions_active", "Number of active provider sessions.", "service_type"),
+		providerBytesSent:        registry.NewCounter("myst_provider_bytes_sent_total", "Bytes sent to consumers by the provider.", "service_type"),
+		providerBytesReceived:    registry.NewCounter("myst_provider_bytes_received_total", "Bytes received from consumers by the provider.", "service_type"),
+		consumerBytesSent:        registry.NewCounter("myst_consumer_bytes_sent_total", "Bytes sent by the consumer connection.", "service_type"),
+		consumerBytesReceived:    registry.NewCounter("myst_consumer_bytes_received_total", "Bytes received by the consumer connection.", "service_type"),
+		natStatus:                registry.NewGauge("myst_nat_status", "Current NAT traversal status, set to 1 for the active status.", "status"),
+		natTraversalEvents:       registry.NewCounter("myst_nat_traversal_events_total", "NAT traversal events by stage and result.", "stage", "successful"),
+		identityBalance:          registry.NewGauge("myst_identity_balance", "Identity balance in the smallest MYST units.", "identity"),
+		identityEarnings:         registry.NewGauge("myst_identity_earnings", "Unsettled identity earnings in the smallest MYST units.", "identity"),
+		identityLifetimeEarnings: registry.NewGauge("myst_identity_lifetime_earnings", "Lifetime identity earnings in the smallest MYST units.", "identity"),
+		settlements:              registry.NewCounter("myst_settlements_total", "Settlement attempts with the accountant.", "identity"),
+		settlementFailures:       registry.NewCounter("myst_settlement_failures_total", "Failed settlement attempts with the accountant.", "identity"),
+		p2pChannelErrors:         registry.NewCounter("myst_p2p_channel_errors_total", "Errors establishing p2p channels.", "side", "service_type"),
+
+		providerSessions: make(map[string]stateEvent.ServiceSession),
+	}
+}
+
+// Subscribe subscribes the collector to the events it builds the metrics from.
+func (c *Collector) Subscribe(bus eventbus.Subscriber) error {
+	err := bus.SubscribeAsync(stateEvent.AppTopicState, c.ConsumeStateEvent)
+	if err != nil {
+		return err
+	}
+	err = bus.SubscribeAsync(connection.AppTopicConsumerStatistics, c.ConsumeStatisticsEvent)
+	if err != nil {
+		return err
+	}
+	err = bus.SubscribeAsync(natEvent.AppTopicTraversal, c.ConsumeNATEvent)
+	if err != nil {
+		return err
+	}
+	err = bus.SubscribeAsync(pingpong.AppTopicSettlement, c.ConsumeSettlementEvent)
+	if err != nil {
+		return err
+	}
+	return bus.SubscribeAsync(p2p.AppTopicChannelError, c.ConsumeChannelErrorEvent)
+}
+
+// ConsumeStateEvent updates provider sessions, NAT status and identity metrics from the node state.
+func (c *Collector) ConsumeStateEvent(state stateEvent.State) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.providerSessionsActive.Reset()
+	sessions := make(map[string]stateEvent.ServiceSession, len(state.Sessions))
+	for _, s := range state.Sessions {
+		c.providerSessionsActive.Add(1, s.ServiceType)
+
+		previous := c.providerSessions[s.ID]
+		if s.BytesOut > previous.BytesOut {
+			c.providerBytesSent.Add(float64(s.BytesOut-previous.BytesOut), s.ServiceType)
+		}
+		if s.BytesIn > previous.BytesIn {
+			c.providerBytesReceived.Add(float64(s.BytesIn-previous.BytesIn), s.ServiceType)
+		}
+		sessions[s.ID] = s
+	}
+	c.providerSessions = sessions
+
+	c.natStatus.Reset()
+	if state.NATStatus.Status != "" {
+		c.natStatus.Set(1, state.NATStatus.Status)
+	}
+
+	c.identityBalance.Reset()
+	c.identityEarnings.Reset()
+	c.identityLifetimeEarnings.Reset()
+	for _, id := range state.Identities {
+		c.identityBalance.Set(float64(id.Balance), id.Address)
+		c.identityEarnings.Set(float64(id.Earnings), id.Address)
+		c.identityLifetimeEarnings.Set(float64(id.EarningsTotal), id.Address)
+	}
+}
+
+// ConsumeStatisticsEvent updates consumer traffic metrics from the connection statistics.
+func (c *Collector) ConsumeStatisticsEvent(e connection.SessionStatsEvent) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if e.SessionInfo.SessionID != c.consumerSession {
+		c.consumerSession = e.SessionInfo.SessionID
+		c.consumerStats = connection.Statistics{}
+	}
+
+	serviceType := e.SessionInfo.Proposal.ServiceType
+	if e.Stats.BytesSent > c.consumerStats.BytesSent {
+		c.consumerBytesSent.Add(float64(e.Stats.BytesSent-c.consumerStats.BytesSent), serviceType)
+	}
+	if e.Stats.BytesReceived > c.consumerStats.BytesReceived {
+		c.consumerBytesReceived.Add(float64(e.Stats.BytesReceived-c.consumerStats.BytesReceived), serviceType)
+	}
+	c.consumerStats = e.Stats
+}
+
+// ConsumeNATEvent counts NAT traversal events.
+func (c *Collector) ConsumeNATEvent(e natEvent.Event) {
+	c.natTraversalEvents.Inc(e.Stage, strconv.FormatBool(e.Successful))
+}
+
+// ConsumeSettlementEvent counts settlements and their failures.
+func (c *Collector) ConsumeSettlementEvent(e pingpong.AppEventSettlement) {
+	c.settlements.Inc(e.ProviderID.Address)
+	if e.Error != nil {
+		c.settlementFailures.Inc(e.ProviderID.Address)
+	}
+}
+
+// ConsumeChannelErrorEvent counts p2p channel errors.
+func (c *Collector) ConsumeChannelErrorEvent(e p2p.AppEventChannelError) {
+	c.p2pChannelErrors.Inc(e.Side, e.ServiceType)
+}
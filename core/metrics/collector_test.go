@@ -0,0 +1,106 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	stateEvent "github.com/mysteriumnetwork/node/core/state/event"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	natEvent "github.com/mysteriumnetwork/node/nat/event"
+	"github.com/mysteriumnetwork/node/p2p"
+	"github.com/mysteriumnetwork/node/session/pingpong"
+	"github.com/stretchr/testify/assert"
+)
+
+func output(t *testing.T, registry *Registry) string {
+	var buf bytes.Buffer
+	assert.NoError(t, registry.Write(&buf))
+	return buf.String()
+}
+
+func TestCollector_ConsumeStateEvent(t *testing.T) {
+	registry := NewRegistry()
+	collector := NewCollector(registry)
+
+	collector.ConsumeStateEvent(stateEvent.State{
+		NATStatus: stateEvent.NATStatus{Status: "successful"},
+		Sessions: []stateEvent.ServiceSession{
+			{ID: "1", ServiceType: "wireguard", BytesOut: 100, BytesIn: 10},
+			{ID: "2", ServiceType: "wireguard", BytesOut: 50, BytesIn: 5},
+		},
+		Identities: []stateEvent.Identity{
+			{Address: "0x1", Balance: 10, Earnings: 2, EarningsTotal: 7},
+		},
+	})
+	collector.ConsumeStateEvent(stateEvent.State{
+		NATStatus: stateEvent.NATStatus{Status: "failure"},
+		Sessions: []stateEvent.ServiceSession{
+			{ID: "2", ServiceType: "wireguard", BytesOut: 80, BytesIn: 6},
+			{ID: "3", ServiceType: "openvpn", BytesOut: 1, BytesIn: 1},
+		},
+	})
+
+	out := output(t, registry)
+	assert.Contains(t, out, `myst_provider_sessions_active{service_type="openvpn"} 1`+"\n")
+	assert.Contains(t, out, `myst_provider_sessions_active{service_type="wireguard"} 1`+"\n")
+	assert.Contains(t, out, `myst_provider_bytes_sent_total{service_type="wireguard"} 180`+"\n")
+	assert.Contains(t, out, `myst_provider_bytes_received_total{service_type="wireguard"} 16`+"\n")
+	assert.Contains(t, out, `myst_provider_bytes_sent_total{service_type="openvpn"} 1`+"\n")
+	assert.Contains(t, out, `myst_nat_status{status="failure"} 1`+"\n")
+	assert.NotContains(t, out, `myst_nat_status{status="successful"}`)
+	assert.NotContains(t, out, `myst_identity_balance{identity="0x1"}`)
+}
+
+func TestCollector_ConsumeStatisticsEvent(t *testing.T) {
+	registry := NewRegistry()
+	collector := NewCollector(registry)
+	info := connection.SessionInfo{SessionID: "1", Proposal: market.ServiceProposal{ServiceType: "wireguard"}}
+
+	collector.ConsumeStatisticsEvent(connection.SessionStatsEvent{Stats: connection.Statistics{BytesSent: 10, BytesReceived: 100}, SessionInfo: info})
+	collector.ConsumeStatisticsEvent(connection.SessionStatsEvent{Stats: connection.Statistics{BytesSent: 15, BytesReceived: 300}, SessionInfo: info})
+	info.SessionID = "2"
+	collector.ConsumeStatisticsEvent(connection.SessionStatsEvent{Stats: connection.Statistics{BytesSent: 5, BytesReceived: 50}, SessionInfo: info})
+
+	out := output(t, registry)
+	assert.Contains(t, out, `myst_consumer_bytes_sent_total{service_type="wireguard"} 20`+"\n")
+	assert.Contains(t, out, `myst_consumer_bytes_received_total{service_type="wireguard"} 350`+"\n")
+}
+
+func TestCollector_CountsEvents(t *testing.T) {
+	registry := NewRegistry()
+	collector := NewCollector(registry)
+	provider := identity.FromAddress("0x1")
+
+	collector.ConsumeNATEvent(natEvent.Event{Stage: "hole_punching", Successful: true})
+	collector.ConsumeNATEvent(natEvent.Event{Stage: "hole_punching", Successful: false})
+	collector.ConsumeSettlementEvent(pingpong.AppEventSettlement{ProviderID: provider})
+	collector.ConsumeSettlementEvent(pingpong.AppEventSettlement{ProviderID: provider, Error: errors.New("boom")})
+	collector.ConsumeChannelErrorEvent(p2p.AppEventChannelError{Side: p2p.SideConsumer, ServiceType: "wireguard"})
+
+	out := output(t, registry)
+	assert.Contains(t, out, `myst_nat_traversal_events_total{stage="hole_punching",successful="true"} 1`+"\n")
+	assert.Contains(t, out, `myst_nat_traversal_events_total{stage="hole_punching",successful="false"} 1`+"\n")
+	assert.Contains(t, out, `myst_settlements_total{identity="0x1"} 2`+"\n")
+	assert.Contains(t, out, `myst_settlement_failures_total{identity="0x1"} 1`+"\n")
+	assert.Contains(t, out, `myst_p2p_channel_errors_total{side="consumer",service_type="wireguard"} 1`+"\n")
+}
@@ -0,0 +1,211 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	typeCounter = "counter"
+	typeGauge   = "gauge"
+)
+
+// ContentType is the content type of Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Registry holds the metrics and writes them in Prometheus text exposition format.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []*Metric
+}
+
+// NewRegistry creates an empty metrics registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewCounter registers a new counter with the given label names.
+func (r *Registry) NewCounter(name, help string, labelNames ...string) *Metric {
+	return r.register(name, help, typeCounter, labelNames)
+}
+
+// NewGauge registers a new gauge with the given label names.
+func (r *Registry) NewGauge(name, help string, labelNames ...string) *Metric {
+	return r.register(name, help, typeGauge, labelNames)
+}
+
+func (r *Registry) register(name, help, metricType string, labelNames []string) *Metric {
+	m := &Metric{
+		registry:   r,
+		name:       name,
+		help:       help,
+		metricType: metricType,
+		labelNames: labelNames,
+		samples:    make(map[string]*sample),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+	return m
+}
+
+// Write writes all the registered metrics in Prometheus text exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var b strings.Builder
+	for _, m := range r.metrics {
+		m.write(&b)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Metric is a counter or a gauge with its samples per label values.
+type Metric struct {
+	registry   *Registry
+	name       string
+	help       string
+	metricType string
+	labelNames []string
+	samples    map[string]*sample
+}
+
+type sample struct {
+	labelValues []string
+	value       float64
+}
+
+// Inc increments the metric for the given label values by one.
+func (m *Metric) Inc(labelValues ...string) {
+	m.Add(1, labelValues...)
+}
+
+// Add adds the given value to the metric for the given label values.
+func (m *Metric) Add(value float64, labelValues ...string) {
+	m.registry.mu.Lock()
+	defer m.registry.mu.Unlock()
+
+	if s, ok := m.sample(labelValues); ok {
+		s.value += value
+	}
+}
+
+// Set sets the metric value for the given label values.
+func (m *Metric) Set(value float64, labelValues ...string) {
+	m.registry.mu.Lock()
+	defer m.registry.mu.Unlock()
+
+	if s, ok := m.sample(labelValues); ok {
+		s.value = value
+	}
+}
+
+// Reset removes all the samples of the metric, it is used for gauges which are recalculated from scratch.
+func (m *Metric) Reset() {
+	m.registry.mu.Lock()
+	defer m.registry.mu.Unlock()
+
+	m.samples = make(map[string]*sample)
+}
+
+// sample returns the sample for the given label values, values not matching the label names are skipped.
+func (m *Metric) sample(labelValues []string) (*sample, bool) {
+	if len(labelValues) != len(m.labelNames) {
+		log.Error().Msgf("Metric %s expects %d label values, got %d, skipping the sample", m.name, len(m.labelNames), len(labelValues))
+		return nil, false
+	}
+
+	key := strings.Join(labelValues, "\xff")
+	s, ok := m.samples[key]
+	if !ok {
+		s = &sample{labelValues: append([]string(nil), labelValues...)}
+		m.samples[key] = s
+	}
+	return s, true
+}
+
+func (m *Metric) write(b *strings.Builder) {
+	fmt.Fprintf(b, "# HELP %s %s\n", m.name, escapeHelp(m.help))
+	fmt.Fprintf(b, "# TYPE %s %s\n", m.name, m.metricType)
+
+	// Metrics without labels are always exposed to let the scraper know they exist.
+	if len(m.labelNames) == 0 && len(m.samples) == 0 {
+		fmt.Fprintf(b, "%s 0\n", m.name)
+		return
+	}
+
+	keys := make([]string, 0, len(m.samples))
+	for key := range m.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := m.samples[key]
+		b.WriteString(m.name)
+		if len(m.labelNames) > 0 {
+			b.WriteString("{")
+			for i, name := range m.labelNames {
+				if i > 0 {
+					b.WriteString(",")
+				}
+				fmt.Fprintf(b, "%s=\"%s\"", name, escapeLabelValue(s.labelValues[i]))
+			}
+			b.WriteString("}")
+		}
+		fmt.Fprintf(b, " %s\n", formatValue(s.value))
+	}
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return labelEscaper.Replace(value)
+}
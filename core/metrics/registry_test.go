@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounter("test_events_total", "Test events.", "type")
+	gauge := registry.NewGauge("test_value", "Test value.\nMultiline.")
+
+	counter.Inc("b")
+	counter.Add(2, "a")
+	counter.Inc("b")
+	counter.Inc(`quoted "value"`)
+	gauge.Set(1.5)
+
+	var buf bytes.Buffer
+	assert.NoError(t, registry.Write(&buf))
+	assert.Equal(t, `# HELP test_events_total Test events.
+# TYPE test_events_total counter
+test_events_total{type="a"} 2
+test_events_total{type="b"} 2
+test_events_total{type="quoted \"value\""} 1
+# HELP test_value Test value.\nMultiline.
+# TYPE test_value gauge
+test_value 1.5
+`, buf.String())
+}
+
+func TestRegistry_WriteEmptyMetrics(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewCounter("test_total", "Test.")
+	gauge := registry.NewGauge("test_labeled", "Labeled.", "label")
+	gauge.Set(1, "x")
+	gauge.Reset()
+
+	var buf bytes.Buffer
+	assert.NoError(t, registry.Write(&buf))
+	assert.Equal(t, `# HELP test_total Test.
+# TYPE test_total counter
+test_total 0
+# HELP test_labeled Labeled.
+# TYPE test_labeled gauge
+`, buf.String())
+}
+
+func TestMetric_SkipsSampleOnLabelMismatch(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounter("test_total", "Test.", "a", "b")
+
+	assert.NotPanics(t, func() { counter.Inc("only-one") })
+	counter.Inc("x", "y")
+
+	var buf bytes.Buffer
+	assert.NoError(t, registry.Write(&buf))
+	assert.Equal(t, `# HELP test_total Test.
+# TYPE test_total counter
+test_total{a="x",b="y"} 1
+`, buf.String())
+}
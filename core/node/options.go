@@ -134,6 +134,9 @@ func GetOptions() *Options {
 			BCTimeout:                          config.GetDuration(config.FlagPaymentsBCTimeout),
 			AccountantPromiseSettlingThreshold: config.GetFloat64(config.FlagPaymentsAccountantPromiseSettleThreshold),
 			SettlementTimeout:                  config.GetDuration(config.FlagPaymentsAccountantPromiseSettleTimeout),
+			SettlementStrategy:                 config.GetString(config.FlagPaymentsAccountantPromiseSettleStrategy),
+			SettlementPeriod:                   config.GetDuration(config.FlagPaymentsAccountantPromiseSettlePeriod),
+			SettlementMaxFeePercent:            config.GetFloat64(config.FlagPaymentsAccountantPromiseSettleMaxFee),
 			MystSCAddress:                      config.GetString(config.FlagPaymentsMystSCAddress),
 			ConsumerUpperGBPriceBound:          config.GetUInt64(config.FlagPaymentsConsumerPricePerGBUpperBound),
 			ConsumerLowerGBPriceBound:          config.GetUInt64(config.FlagPaymentsConsumerPricePerGBLowerBound),
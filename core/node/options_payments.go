@@ -25,6 +25,9 @@ type OptionsPayments struct {
 	BCTimeout                          time.Duration
 	AccountantPromiseSettlingThreshold float64
 	SettlementTimeout                  time.Duration
+	SettlementStrategy                 string
+	SettlementPeriod                   time.Duration
+	SettlementMaxFeePercent            float64
 	MystSCAddress                      string
 	ConsumerUpperGBPriceBound          uint64
 	ConsumerLowerGBPriceBound          uint64
@@ -0,0 +1,213 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package policy
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofrs/uuid"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/pkg/errors"
+)
+
+const denyListBucket = "policy-deny-list"
+
+const (
+	// DenyTypeIdentity denies the consumer identity ("0xd1faed693fec75389c3d1e59b863e4835ac6f5d1")
+	DenyTypeIdentity = market.AccessPolicyTypeIdentity
+	// DenyTypeIPRange denies consumers connecting from the IP range ("10.0.0.0/8") or a single IP ("10.0.0.1")
+	DenyTypeIPRange = "ip_range"
+)
+
+// ErrDenyRuleNotFound is returned when the deny rule does not exist.
+var ErrDenyRuleNotFound = errors.New("deny rule not found")
+
+// DenyRule is a single entry of the local deny list
+type DenyRule struct {
+	ID      string `storm:"id"`
+	Type    string
+	Value   string
+	Created time.Time
+}
+
+// DenyStorer allows us to store, list and delete the deny rules
+type DenyStorer interface {
+	Store(bucket string, object interface{}) error
+	GetAllFrom(bucket string, array interface{}) error
+	Delete(bucket string, object interface{}) error
+}
+
+// DenyList is the provider managed list of consumers which are refused the service
+type DenyList struct {
+	storage DenyStorer
+
+	lock       sync.RWMutex
+	rules      []DenyRule
+	identities map[string]bool
+	networks   []*net.IPNet
+}
+
+// NewDenyList creates an empty deny list, stored rules are read by Load
+func NewDenyList(storage DenyStorer) *DenyList {
+	return &DenyList{
+		storage:    storage,
+		identities: make(map[string]bool),
+	}
+}
+
+// Load reads the stored deny rules
+func (dl *DenyList) Load() error {
+	var rules []DenyRule
+	if err := dl.storage.GetAllFrom(denyListBucket, &rules); err != nil {
+		return errors.Wrap(err, "could not load deny list")
+	}
+
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.rules = rules
+	dl.rebuild()
+	return nil
+}
+
+// Rules returns all deny rules
+func (dl *DenyList) Rules() []DenyRule {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return append([]DenyRule{}, dl.rules...)
+}
+
+// Add validates and stores a new deny rule
+func (dl *DenyList) Add(ruleType, value string) (DenyRule, error) {
+	value, err := normalizeDenyValue(ruleType, value)
+	if err != nil {
+		return DenyRule{}, err
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return DenyRule{}, errors.Wrap(err, "could not generate deny rule ID")
+	}
+	rule := DenyRule{
+		ID:      id.String(),
+		Type:    ruleType,
+		Value:   value,
+		Created: time.Now().UTC(),
+	}
+
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	if err := dl.storage.Store(denyListBucket, &rule); err != nil {
+		return DenyRule{}, errors.Wrap(err, "could not store deny rule")
+	}
+	dl.rules = append(dl.rules, rule)
+	dl.rebuild()
+	return rule, nil
+}
+
+// Remove deletes the deny rule with the given ID
+func (dl *DenyList) Remove(id string) error {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	for i := range dl.rules {
+		if dl.rules[i].ID != id {
+			continue
+		}
+		if err := dl.storage.Delete(denyListBucket, &dl.rules[i]); err != nil {
+			return errors.Wrap(err, "could not delete deny rule")
+		}
+		dl.rules = append(dl.rules[:i], dl.rules[i+1:]...)
+		dl.rebuild()
+		return nil
+	}
+	return ErrDenyRuleNotFound
+}
+
+// IsIdentityDenied returns flag if given identity is on the deny list
+func (dl *DenyList) IsIdentityDenied(identity identity.Identity) bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.identities[identity.Address]
+}
+
+// IsIPDenied returns flag if given IP belongs to any denied range
+func (dl *DenyList) IsIPDenied(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	for _, network := range dl.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// rebuild refreshes lookup structures, must be called while holding the write lock
+func (dl *DenyList) rebuild() {
+	dl.identities = make(map[string]bool)
+	dl.networks = nil
+	for _, rule := range dl.rules {
+		switch rule.Type {
+		case DenyTypeIdentity:
+			dl.identities[rule.Value] = true
+		case DenyTypeIPRange:
+			if _, network, err := net.ParseCIDR(rule.Value); err == nil {
+				dl.networks = append(dl.networks, network)
+			}
+		}
+	}
+}
+
+// ValidateDenyRule checks if the deny rule of given type and value can be added
+func ValidateDenyRule(ruleType, value string) error {
+	_, err := normalizeDenyValue(ruleType, value)
+	return err
+}
+
+func normalizeDenyValue(ruleType, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch ruleType {
+	case DenyTypeIdentity:
+		if !common.IsHexAddress(value) {
+			return "", errors.Errorf("invalid identity %q", value)
+		}
+		return identity.FromAddress(value).Address, nil
+	case DenyTypeIPRange:
+		network, err := ParseNetwork(value)
+		if err != nil {
+			return "", errors.Errorf("invalid IP range %q", value)
+		}
+		return network.String(), nil
+	default:
+		return "", errors.Errorf("unknown deny rule type %q", ruleType)
+	}
+}
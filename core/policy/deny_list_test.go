@@ -0,0 +1,99 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package policy
+
+import (
+	"net"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDenyStorer struct {
+	rules []DenyRule
+}
+
+func (m *mockDenyStorer) Store(_ string, object interface{}) error {
+	m.rules = append(m.rules, *object.(*DenyRule))
+	return nil
+}
+
+func (m *mockDenyStorer) GetAllFrom(_ string, array interface{}) error {
+	*array.(*[]DenyRule) = append([]DenyRule(nil), m.rules...)
+	return nil
+}
+
+func (m *mockDenyStorer) Delete(_ string, object interface{}) error {
+	id := object.(*DenyRule).ID
+	for i := range m.rules {
+		if m.rules[i].ID == id {
+			m.rules = append(m.rules[:i], m.rules[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func TestDenyList_AddAndRemove(t *testing.T) {
+	storer := &mockDenyStorer{}
+	denyList := NewDenyList(storer)
+
+	identityRule, err := denyList.Add(DenyTypeIdentity, "0xD1FAED693FEC75389C3D1E59B863E4835AC6F5D1")
+	assert.NoError(t, err)
+	assert.Equal(t, "0xd1faed693fec75389c3d1e59b863e4835ac6f5d1", identityRule.Value)
+	rangeRule, err := denyList.Add(DenyTypeIPRange, "10.0.0.0/8")
+	assert.NoError(t, err)
+	ipRule, err := denyList.Add(DenyTypeIPRange, "192.168.1.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "192.168.1.1/32", ipRule.Value)
+	assert.Len(t, storer.rules, 3)
+
+	assert.True(t, denyList.IsIdentityDenied(identity.FromAddress("0xd1faed693fec75389c3d1e59b863e4835ac6f5d1")))
+	assert.False(t, denyList.IsIdentityDenied(identity.FromAddress("0x0000000000000000000000000000000000000001")))
+	assert.True(t, denyList.IsIPDenied(net.ParseIP("10.20.30.40")))
+	assert.True(t, denyList.IsIPDenied(net.ParseIP("192.168.1.1")))
+	assert.False(t, denyList.IsIPDenied(net.ParseIP("192.168.1.2")))
+	assert.False(t, denyList.IsIPDenied(nil))
+
+	assert.NoError(t, denyList.Remove(rangeRule.ID))
+	assert.False(t, denyList.IsIPDenied(net.ParseIP("10.20.30.40")))
+	assert.Equal(t, ErrDenyRuleNotFound, denyList.Remove(rangeRule.ID))
+	assert.Len(t, denyList.Rules(), 2)
+	assert.Len(t, storer.rules, 2)
+}
+
+func TestDenyList_Load(t *testing.T) {
+	storer := &mockDenyStorer{rules: []DenyRule{
+		{ID: "1", Type: DenyTypeIdentity, Value: "0xd1faed693fec75389c3d1e59b863e4835ac6f5d1"},
+		{ID: "2", Type: DenyTypeIPRange, Value: "2001:db8::/32"},
+	}}
+	denyList := NewDenyList(storer)
+
+	assert.NoError(t, denyList.Load())
+	assert.True(t, denyList.IsIdentityDenied(identity.FromAddress("0xd1faed693fec75389c3d1e59b863e4835ac6f5d1")))
+	assert.True(t, denyList.IsIPDenied(net.ParseIP("2001:db8::1")))
+}
+
+func TestValidateDenyRule(t *testing.T) {
+	assert.NoError(t, ValidateDenyRule(DenyTypeIdentity, "0xd1faed693fec75389c3d1e59b863e4835ac6f5d1"))
+	assert.NoError(t, ValidateDenyRule(DenyTypeIPRange, "2001:db8::1"))
+	assert.Error(t, ValidateDenyRule(DenyTypeIdentity, "consumer"))
+	assert.Error(t, ValidateDenyRule(DenyTypeIPRange, "10.0.0.0/33"))
+	assert.Error(t, ValidateDenyRule("dns_zone", "example.com"))
+}
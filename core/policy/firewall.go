@@ -0,0 +1,107 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package policy
+
+import (
+	"net"
+	"sync"
+
+	"github.com/mysteriumnetwork/node/firewall"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// IncomingTrafficBlocker blocks the traffic of a network leaving just the one allowed by traffic rules
+type IncomingTrafficBlocker interface {
+	BlockIncomingTraffic(network net.IPNet, rules firewall.TrafficRules) (firewall.IncomingRuleRemove, error)
+}
+
+// EnforceTrafficRules blocks the traffic of the network by the traffic rules of the repository and re-applies them once policies change.
+// Destinations are whitelisted by the provider DNS, so DNS rules are not enforced without it.
+func EnforceTrafficRules(blocker IncomingTrafficBlocker, network net.IPNet, repository *Repository, dnsAvailable bool) (firewall.IncomingRuleRemove, error) {
+	enforcer := &trafficRulesEnforcer{
+		blocker:      blocker,
+		network:      network,
+		repository:   repository,
+		dnsAvailable: dnsAvailable,
+	}
+	if err := enforcer.apply(); err != nil {
+		return nil, err
+	}
+
+	unsubscribe := repository.OnRulesChange(func() {
+		log.Info().Msgf("Access policies changed, updating traffic rules of %s", network.String())
+		if err := enforcer.apply(); err != nil {
+			log.Error().Err(err).Msgf("Failed to update traffic rules of %s", network.String())
+		}
+	})
+
+	return func() error {
+		unsubscribe()
+		return enforcer.release()
+	}, nil
+}
+
+type trafficRulesEnforcer struct {
+	blocker      IncomingTrafficBlocker
+	network      net.IPNet
+	repository   *Repository
+	dnsAvailable bool
+
+	lock   sync.Mutex
+	remove firewall.IncomingRuleRemove
+}
+
+func (e *trafficRulesEnforcer) apply() error {
+	rules := e.repository.TrafficRules()
+	rules.DNSWhitelist = rules.DNSWhitelist && e.dnsAvailable
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	// Rules of the network are kept in a chain named by it, so the previous ones have to be removed first.
+	if err := e.releaseLocked(); err != nil {
+		log.Warn().Err(err).Msg("Failed to disable previous traffic blocking")
+	}
+	if rules.IsEmpty() {
+		return nil
+	}
+
+	remove, err := e.blocker.BlockIncomingTraffic(e.network, rules)
+	if err != nil {
+		return errors.Wrap(err, "failed to enable traffic blocking")
+	}
+	e.remove = remove
+	return nil
+}
+
+func (e *trafficRulesEnforcer) release() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	return e.releaseLocked()
+}
+
+func (e *trafficRulesEnforcer) releaseLocked() error {
+	if e.remove == nil {
+		return nil
+	}
+	remove := e.remove
+	e.remove = nil
+	return remove()
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package policy
+
+import (
+	"net"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/firewall"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTrafficBlocker struct {
+	blocked []firewall.TrafficRules
+	removed int
+}
+
+func (b *mockTrafficBlocker) BlockIncomingTraffic(_ net.IPNet, rules firewall.TrafficRules) (firewall.IncomingRuleRemove, error) {
+	b.blocked = append(b.blocked, rules)
+	return func() error {
+		b.removed++
+		return nil
+	}, nil
+}
+
+func Test_EnforceTrafficRules_ReappliesRulesOnPolicyChange(t *testing.T) {
+	blocker := &mockTrafficBlocker{}
+	repo := createEmptyRepo()
+	smtpPolicy := market.AccessPolicy{ID: "smtp", Source: LocalSource}
+	repo.SetPolicyRules(smtpPolicy, market.AccessPolicyRuleSet{ID: "smtp"})
+	_, network, _ := net.ParseCIDR("10.182.0.0/24")
+
+	release, err := EnforceTrafficRules(blocker, *network, repo, true)
+	assert.NoError(t, err)
+	assert.Empty(t, blocker.blocked, "nothing to block without rules")
+
+	repo.SetPolicyRules(smtpPolicy, market.AccessPolicyRuleSet{
+		ID:    "smtp",
+		Allow: []market.AccessRule{{Type: market.AccessPolicyTypeBlockedPort, Value: "tcp/25"}},
+	})
+	assert.Equal(t, []firewall.TrafficRules{
+		{BlockedPorts: []firewall.PortRange{{Protocol: "tcp", From: 25, To: 25}}},
+	}, blocker.blocked)
+
+	repo.SetPolicyRules(smtpPolicy, market.AccessPolicyRuleSet{
+		ID:    "smtp",
+		Allow: []market.AccessRule{{Type: market.AccessPolicyTypeBlockedPort, Value: "25"}},
+	})
+	assert.Len(t, blocker.blocked, 2)
+	assert.Equal(t, 1, blocker.removed, "previous rules should be removed")
+
+	assert.NoError(t, release())
+	assert.Equal(t, 2, blocker.removed)
+
+	repo.SetPolicyRules(smtpPolicy, market.AccessPolicyRuleSet{ID: "smtp"})
+	assert.Len(t, blocker.blocked, 2, "released rules should not be reapplied")
+}
+
+func Test_EnforceTrafficRules_SkipsDNSWhitelistWithoutDNS(t *testing.T) {
+	blocker := &mockTrafficBlocker{}
+	repo := createEmptyRepo()
+	repo.SetPolicyRules(policyTwo, policyTwoRules)
+	_, network, _ := net.ParseCIDR("10.182.0.0/24")
+
+	_, err := EnforceTrafficRules(blocker, *network, repo, false)
+
+	assert.NoError(t, err)
+	assert.Empty(t, blocker.blocked)
+}
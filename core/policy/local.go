@@ -0,0 +1,477 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package policy
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// LocalSource is the source of access policies defined on the node itself
+const LocalSource = "local"
+
+// ErrLocalPolicyNotFound is returned when the local policy does not exist.
+var ErrLocalPolicyNotFound = errors.New("local access policy not found")
+
+type localRule struct {
+	Type  string `json:"type" toml:"type"`
+	Value string `json:"value" toml:"value"`
+}
+
+type localRuleSet struct {
+	ID          string      `json:"id" toml:"id"`
+	Title       string      `json:"title" toml:"title"`
+	Description string      `json:"description" toml:"description"`
+	Allow       []localRule `json:"allow" toml:"allow"`
+}
+
+type localPolicyFile struct {
+	Policies []localRuleSet `json:"policies" toml:"policies"`
+}
+
+type localSubscription struct {
+	policy      market.AccessPolicy
+	subscribers []*Repository
+}
+
+// LocalPolicies keeps access policy rule sets defined in a local TOML or JSON file and reloads them once the file changes
+type LocalPolicies struct {
+	path     string
+	interval time.Duration
+
+	lock          sync.RWMutex
+	ruleSets      []market.AccessPolicyRuleSet
+	modTime       time.Time
+	loadErr       error
+	subscriptions []localSubscription
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewLocalPolicies creates local policies of the given file, format is chosen by the file extension
+func NewLocalPolicies(path string) *LocalPolicies {
+	return &LocalPolicies{
+		path:     path,
+		interval: 10 * time.Second,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Load reads the policy file, missing file means there are no local policies.
+// Invalid file is not loaded again until it changes, the policies can't be saved or deleted until then.
+func (lp *LocalPolicies) Load() error {
+	info, err := os.Stat(lp.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "could not read local access policies")
+	}
+
+	ruleSets, err := lp.read()
+
+	lp.lock.Lock()
+	defer lp.lock.Unlock()
+
+	lp.modTime = info.ModTime()
+	lp.loadErr = err
+	if err != nil {
+		return err
+	}
+	lp.apply(ruleSets)
+	return nil
+}
+
+// Start reloads the policy file once it changes until Stop is called
+func (lp *LocalPolicies) Start() {
+	for {
+		select {
+		case <-lp.stop:
+			return
+		case <-time.After(lp.interval):
+			if lp.changed() {
+				log.Info().Msgf("Reloading local access policies from %s", lp.path)
+				if err := lp.Load(); err != nil {
+					log.Warn().Err(err).Msg("Failed to reload local access policies, keeping the previous ones")
+				}
+			}
+		}
+	}
+}
+
+// Stop ends watching the policy file
+func (lp *LocalPolicies) Stop() {
+	lp.stopOnce.Do(func() {
+		close(lp.stop)
+	})
+}
+
+// Has checks if the policy with given ID is defined locally
+func (lp *LocalPolicies) Has(policyID string) bool {
+	_, err := lp.RuleSet(policyID)
+	return err == nil
+}
+
+// Policy converts given local policy ID to the policy advertised in proposals
+func (lp *LocalPolicies) Policy(policyID string) market.AccessPolicy {
+	return market.AccessPolicy{
+		ID:     policyID,
+		Source: LocalSource,
+	}
+}
+
+// RuleSets returns all local rule sets
+func (lp *LocalPolicies) RuleSets() []market.AccessPolicyRuleSet {
+	lp.lock.RLock()
+	defer lp.lock.RUnlock()
+
+	return append([]market.AccessPolicyRuleSet{}, lp.ruleSets...)
+}
+
+// RuleSet returns the local rule set with given ID
+func (lp *LocalPolicies) RuleSet(policyID string) (market.AccessPolicyRuleSet, error) {
+	lp.lock.RLock()
+	defer lp.lock.RUnlock()
+
+	for _, ruleSet := range lp.ruleSets {
+		if ruleSet.ID == policyID {
+			return ruleSet, nil
+		}
+	}
+	return market.AccessPolicyRuleSet{}, ErrLocalPolicyNotFound
+}
+
+// Save adds or replaces the rule set and writes the policy file
+func (lp *LocalPolicies) Save(ruleSet market.AccessPolicyRuleSet) error {
+	if err := ValidateRuleSet(ruleSet); err != nil {
+		return err
+	}
+
+	lp.lock.Lock()
+	defer lp.lock.Unlock()
+
+	if lp.loadErr != nil {
+		return errors.Wrap(lp.loadErr, "fix the policy file before changing it")
+	}
+
+	ruleSets := make([]market.AccessPolicyRuleSet, 0, len(lp.ruleSets)+1)
+	replaced := false
+	for _, existing := range lp.ruleSets {
+		if existing.ID == ruleSet.ID {
+			existing = ruleSet
+			replaced = true
+		}
+		ruleSets = append(ruleSets, existing)
+	}
+	if !replaced {
+		ruleSets = append(ruleSets, ruleSet)
+	}
+	return lp.write(ruleSets)
+}
+
+// Delete removes the rule set and writes the policy file.
+// Services already using the policy keep its last rules until they are restarted.
+func (lp *LocalPolicies) Delete(policyID string) error {
+	lp.lock.Lock()
+	defer lp.lock.Unlock()
+
+	if lp.loadErr != nil {
+		return errors.Wrap(lp.loadErr, "fix the policy file before changing it")
+	}
+
+	for i, ruleSet := range lp.ruleSets {
+		if ruleSet.ID == policyID {
+			ruleSets := append(append([]market.AccessPolicyRuleSet{}, lp.ruleSets[:i]...), lp.ruleSets[i+1:]...)
+			return lp.write(ruleSets)
+		}
+	}
+	return ErrLocalPolicyNotFound
+}
+
+// SubscribePolicies adds given local policies to repository and keeps their rules up to date
+func (lp *LocalPolicies) SubscribePolicies(policies []market.AccessPolicy, repository *Repository) error {
+	lp.lock.Lock()
+	defer lp.lock.Unlock()
+
+	for _, policy := range policies {
+		ruleSet, ok := lp.find(policy.ID)
+		if !ok {
+			return errors.Errorf("unknown local policy: %s", policy.ID)
+		}
+		repository.SetPolicyRules(policy, ruleSet)
+	}
+	for _, policy := range policies {
+		lp.subscriptions = append(lp.subscriptions, localSubscription{
+			policy:      policy,
+			subscribers: []*Repository{repository},
+		})
+	}
+	return nil
+}
+
+// UnsubscribePolicies stops updating rules of the given repository, it is called once the service using it stops
+func (lp *LocalPolicies) UnsubscribePolicies(repository *Repository) {
+	lp.lock.Lock()
+	defer lp.lock.Unlock()
+
+	subscriptions := lp.subscriptions[:0]
+	for _, subscription := range lp.subscriptions {
+		subscription.subscribers = withoutSubscriber(subscription.subscribers, repository)
+		if len(subscription.subscribers) > 0 {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	lp.subscriptions = subscriptions
+}
+
+// apply replaces the rule sets and passes them to subscribers, must be called while holding the write lock
+func (lp *LocalPolicies) apply(ruleSets []market.AccessPolicyRuleSet) {
+	lp.ruleSets = ruleSets
+	for _, subscription := range lp.subscriptions {
+		ruleSet, ok := lp.find(subscription.policy.ID)
+		if !ok {
+			log.Warn().Msgf("Local access policy %s was removed, services using it keep the previous rules", subscription.policy.ID)
+			continue
+		}
+		for _, subscriber := range subscription.subscribers {
+			subscriber.SetPolicyRules(subscription.policy, ruleSet)
+		}
+	}
+}
+
+func (lp *LocalPolicies) find(policyID string) (market.AccessPolicyRuleSet, bool) {
+	for _, ruleSet := range lp.ruleSets {
+		if ruleSet.ID == policyID {
+			return ruleSet, true
+		}
+	}
+	return market.AccessPolicyRuleSet{}, false
+}
+
+func (lp *LocalPolicies) changed() bool {
+	info, err := os.Stat(lp.path)
+	if err != nil {
+		return false
+	}
+
+	lp.lock.RLock()
+	defer lp.lock.RUnlock()
+
+	return !info.ModTime().Equal(lp.modTime)
+}
+
+func (lp *LocalPolicies) isJSON() bool {
+	return strings.EqualFold(filepath.Ext(lp.path), ".json")
+}
+
+func (lp *LocalPolicies) read() ([]market.AccessPolicyRuleSet, error) {
+	data, err := ioutil.ReadFile(lp.path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read local access policies")
+	}
+
+	var file localPolicyFile
+	if lp.isJSON() {
+		err = json.Unmarshal(data, &file)
+	} else {
+		_, err = toml.Decode(string(data), &file)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse local access policies %s", lp.path)
+	}
+
+	ruleSets := make([]market.AccessPolicyRuleSet, 0, len(file.Policies))
+	ids := make(map[string]bool, len(file.Policies))
+	for _, policy := range file.Policies {
+		ruleSet := policy.toRuleSet()
+		if err := ValidateRuleSet(ruleSet); err != nil {
+			return nil, errors.Wrapf(err, "invalid local access policy %q", ruleSet.ID)
+		}
+		if ids[ruleSet.ID] {
+			return nil, errors.Errorf("duplicate local access policy %q", ruleSet.ID)
+		}
+		ids[ruleSet.ID] = true
+		ruleSets = append(ruleSets, ruleSet)
+	}
+	return ruleSets, nil
+}
+
+// write stores the rule sets and applies them, must be called while holding the write lock
+func (lp *LocalPolicies) write(ruleSets []market.AccessPolicyRuleSet) error {
+	file := localPolicyFile{Policies: make([]localRuleSet, 0, len(ruleSets))}
+	for _, ruleSet := range ruleSets {
+		file.Policies = append(file.Policies, fromRuleSet(ruleSet))
+	}
+
+	var data []byte
+	var err error
+	if lp.isJSON() {
+		data, err = json.MarshalIndent(file, "", "  ")
+	} else {
+		var out strings.Builder
+		err = toml.NewEncoder(&out).Encode(file)
+		data = []byte(out.String())
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not encode local access policies")
+	}
+
+	// Replace the file at once, so the watcher never reads a partially written one.
+	tmp := lp.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return errors.Wrap(err, "could not write local access policies")
+	}
+	if err := os.Rename(tmp, lp.path); err != nil {
+		return errors.Wrap(err, "could not write local access policies")
+	}
+
+	if info, err := os.Stat(lp.path); err == nil {
+		lp.modTime = info.ModTime()
+	}
+	lp.apply(ruleSets)
+	return nil
+}
+
+func (rs localRuleSet) toRuleSet() market.AccessPolicyRuleSet {
+	ruleSet := market.AccessPolicyRuleSet{
+		ID:          rs.ID,
+		Title:       rs.Title,
+		Description: rs.Description,
+		Allow:       make([]market.AccessRule, 0, len(rs.Allow)),
+	}
+	for _, rule := range rs.Allow {
+		ruleSet.Allow = append(ruleSet.Allow, market.AccessRule{Type: rule.Type, Value: rule.Value})
+	}
+	return ruleSet
+}
+
+func fromRuleSet(ruleSet market.AccessPolicyRuleSet) localRuleSet {
+	rs := localRuleSet{
+		ID:          ruleSet.ID,
+		Title:       ruleSet.Title,
+		Description: ruleSet.Description,
+		Allow:       make([]localRule, 0, len(ruleSet.Allow)),
+	}
+	for _, rule := range ruleSet.Allow {
+		rs.Allow = append(rs.Allow, localRule{Type: rule.Type, Value: rule.Value})
+	}
+	return rs
+}
+
+// ValidateRuleSet checks if the rule set can be used as a local access policy
+func ValidateRuleSet(ruleSet market.AccessPolicyRuleSet) error {
+	if strings.TrimSpace(ruleSet.ID) == "" {
+		return errors.New("policy ID is required")
+	}
+	if strings.ContainsAny(ruleSet.ID, ",/ ") {
+		return errors.Errorf("policy ID %q can not contain commas, slashes or spaces", ruleSet.ID)
+	}
+	for _, rule := range ruleSet.Allow {
+		if err := ValidateRule(rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateRule checks if the value is valid for the rule type
+func ValidateRule(rule market.AccessRule) error {
+	switch rule.Type {
+	case market.AccessPolicyTypeIdentity:
+		if !common.IsHexAddress(rule.Value) {
+			return errors.Errorf("invalid identity %q", rule.Value)
+		}
+	case market.AccessPolicyTypeDNSHostname, market.AccessPolicyTypeDNSZone:
+		if strings.TrimSpace(rule.Value) == "" {
+			return errors.Errorf("%s rule value is required", rule.Type)
+		}
+	case market.AccessPolicyTypeIPCIDR:
+		if _, err := ParseNetwork(rule.Value); err != nil {
+			return err
+		}
+	case market.AccessPolicyTypePort, market.AccessPolicyTypeBlockedPort:
+		if _, _, _, err := ParsePortRule(rule.Value); err != nil {
+			return err
+		}
+	default:
+		return errors.Errorf("unknown rule type %q", rule.Type)
+	}
+	return nil
+}
+
+// ParseNetwork parses network rule value, either a CIDR ("10.8.0.0/16") or a single address ("10.8.0.1")
+func ParseNetwork(value string) (net.IPNet, error) {
+	value = strings.TrimSpace(value)
+	if ip := net.ParseIP(value); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			return net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return net.IPNet{}, errors.Errorf("invalid network %q", value)
+	}
+	return *network, nil
+}
+
+// ParsePortRule parses port rule value, a port or port range optionally prefixed with the protocol ("tcp/25", "udp/8000-8100")
+func ParsePortRule(value string) (protocol string, from, to int, err error) {
+	if parts := strings.SplitN(value, "/", 2); len(parts) == 2 {
+		protocol = strings.ToLower(strings.TrimSpace(parts[0]))
+		if protocol != "tcp" && protocol != "udp" {
+			return "", 0, 0, errors.Errorf("unknown protocol %q, expected tcp or udp", parts[0])
+		}
+		value = parts[1]
+	}
+	from, to, err = ParsePortRange(value)
+	return protocol, from, to, err
+}
+
+// ParsePortRange parses port rule value, either a single port ("443") or a range ("8000-8100")
+func ParsePortRange(value string) (from, to int, err error) {
+	parts := strings.SplitN(value, "-", 2)
+	from, err = strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, errors.Errorf("invalid port %q", value)
+	}
+	to = from
+	if len(parts) == 2 {
+		to, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return 0, 0, errors.Errorf("invalid port %q", value)
+		}
+	}
+	if from < 1 || to > 65535 || from > to {
+		return 0, 0, errors.Errorf("invalid port range %q", value)
+	}
+	return from, to, nil
+}
@@ -0,0 +1,221 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+const localPoliciesTOML = `
+[[policies]]
+  id = "office"
+  title = "Office"
+  allow = [
+    { type = "identity", value = "0x0000000000000000000000000000000000000001" },
+    { type = "ip_cidr", value = "10.0.0.0/8" },
+  ]
+`
+
+func tempPolicyFile(t *testing.T, name string) (string, func()) {
+	dir, err := ioutil.TempDir("", "local-policies")
+	assert.NoError(t, err)
+	return filepath.Join(dir, name), func() { os.RemoveAll(dir) }
+}
+
+func TestLocalPolicies_LoadMissingFile(t *testing.T) {
+	path, cleanup := tempPolicyFile(t, "access-policies.toml")
+	defer cleanup()
+
+	local := NewLocalPolicies(path)
+	assert.NoError(t, local.Load())
+	assert.Empty(t, local.RuleSets())
+	assert.False(t, local.Has("office"))
+}
+
+func TestLocalPolicies_LoadTOML(t *testing.T) {
+	path, cleanup := tempPolicyFile(t, "access-policies.toml")
+	defer cleanup()
+	assert.NoError(t, ioutil.WriteFile(path, []byte(localPoliciesTOML), 0600))
+
+	local := NewLocalPolicies(path)
+	assert.NoError(t, local.Load())
+
+	ruleSet, err := local.RuleSet("office")
+	assert.NoError(t, err)
+	assert.Equal(t, market.AccessPolicyRuleSet{
+		ID:    "office",
+		Title: "Office",
+		Allow: []market.AccessRule{
+			{Type: market.AccessPolicyTypeIdentity, Value: "0x0000000000000000000000000000000000000001"},
+			{Type: market.AccessPolicyTypeIPCIDR, Value: "10.0.0.0/8"},
+		},
+	}, ruleSet)
+	assert.Equal(t, market.AccessPolicy{ID: "office", Source: LocalSource}, local.Policy("office"))
+}
+
+func TestLocalPolicies_LoadInvalidFile(t *testing.T) {
+	path, cleanup := tempPolicyFile(t, "access-policies.json")
+	defer cleanup()
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"policies": [{"id": "office", "allow": [{"type": "port", "value": "0"}]}]}`), 0600))
+
+	local := NewLocalPolicies(path)
+	assert.Error(t, local.Load())
+	assert.Empty(t, local.RuleSets())
+	assert.False(t, local.changed(), "invalid file should not be reloaded until it changes")
+	assert.Error(t, local.Save(market.AccessPolicyRuleSet{ID: "web"}), "invalid file should not be overwritten")
+}
+
+func TestLocalPolicies_LoadDuplicateIDs(t *testing.T) {
+	path, cleanup := tempPolicyFile(t, "access-policies.json")
+	defer cleanup()
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"policies": [{"id": "office"}, {"id": "office"}]}`), 0600))
+
+	err := NewLocalPolicies(path).Load()
+	assert.EqualError(t, err, `duplicate local access policy "office"`)
+}
+
+func TestLocalPolicies_SaveAndDelete(t *testing.T) {
+	for _, name := range []string{"access-policies.toml", "access-policies.json"} {
+		t.Run(name, func(t *testing.T) {
+			path, cleanup := tempPolicyFile(t, name)
+			defer cleanup()
+
+			local := NewLocalPolicies(path)
+			ruleSet := market.AccessPolicyRuleSet{
+				ID:    "web",
+				Allow: []market.AccessRule{{Type: market.AccessPolicyTypePort, Value: "80-443"}},
+			}
+			assert.NoError(t, local.Save(ruleSet))
+			assert.Error(t, local.Save(market.AccessPolicyRuleSet{ID: "bad id"}))
+
+			reloaded := NewLocalPolicies(path)
+			assert.NoError(t, reloaded.Load())
+			assert.Equal(t, []market.AccessPolicyRuleSet{ruleSet}, reloaded.RuleSets())
+
+			assert.NoError(t, local.Delete("web"))
+			assert.Equal(t, ErrLocalPolicyNotFound, local.Delete("web"))
+			assert.NoError(t, reloaded.Load())
+			assert.Empty(t, reloaded.RuleSets())
+		})
+	}
+}
+
+func TestLocalPolicies_UpdatesSubscribersOnReload(t *testing.T) {
+	path, cleanup := tempPolicyFile(t, "access-policies.toml")
+	defer cleanup()
+	assert.NoError(t, ioutil.WriteFile(path, []byte(localPoliciesTOML), 0600))
+
+	local := NewLocalPolicies(path)
+	local.interval = 10 * time.Millisecond
+	assert.NoError(t, local.Load())
+
+	repository := NewRepository()
+	assert.NoError(t, local.SubscribePolicies([]market.AccessPolicy{local.Policy("office")}, repository))
+	assert.Error(t, local.SubscribePolicies([]market.AccessPolicy{local.Policy("unknown")}, repository))
+	assert.True(t, repository.IsIdentityAllowed(identity.FromAddress("0x0000000000000000000000000000000000000001")))
+
+	go local.Start()
+	defer local.Stop()
+
+	updated := `
+[[policies]]
+  id = "office"
+  allow = [{ type = "identity", value = "0x0000000000000000000000000000000000000002" }]
+`
+	// Make sure the modification time differs even on file systems with coarse timestamps.
+	assert.NoError(t, ioutil.WriteFile(path, []byte(updated), 0600))
+	assert.NoError(t, os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+
+	assert.Eventually(t, func() bool {
+		return repository.IsIdentityAllowed(identity.FromAddress("0x0000000000000000000000000000000000000002"))
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.False(t, repository.IsIdentityAllowed(identity.FromAddress("0x0000000000000000000000000000000000000001")))
+}
+
+func TestLocalPolicies_UnsubscribePolicies(t *testing.T) {
+	path, cleanup := tempPolicyFile(t, "access-policies.toml")
+	defer cleanup()
+	assert.NoError(t, ioutil.WriteFile(path, []byte(localPoliciesTOML), 0600))
+
+	local := NewLocalPolicies(path)
+	assert.NoError(t, local.Load())
+
+	repository := NewRepository()
+	assert.NoError(t, local.SubscribePolicies([]market.AccessPolicy{local.Policy("office")}, repository))
+	other := NewRepository()
+	assert.NoError(t, local.SubscribePolicies([]market.AccessPolicy{local.Policy("office")}, other))
+
+	local.UnsubscribePolicies(repository)
+
+	assert.Len(t, local.subscriptions, 1)
+	assert.Equal(t, []*Repository{other}, local.subscriptions[0].subscribers)
+}
+
+func TestValidateRule(t *testing.T) {
+	valid := []market.AccessRule{
+		{Type: market.AccessPolicyTypeIdentity, Value: "0x0000000000000000000000000000000000000001"},
+		{Type: market.AccessPolicyTypeDNSHostname, Value: "example.com"},
+		{Type: market.AccessPolicyTypeDNSZone, Value: "example.com"},
+		{Type: market.AccessPolicyTypeIPCIDR, Value: "10.0.0.1"},
+		{Type: market.AccessPolicyTypeIPCIDR, Value: "10.0.0.0/8"},
+		{Type: market.AccessPolicyTypePort, Value: "443"},
+		{Type: market.AccessPolicyTypePort, Value: "8000-8100"},
+		{Type: market.AccessPolicyTypePort, Value: "tcp/25"},
+		{Type: market.AccessPolicyTypeBlockedPort, Value: "tcp/25"},
+	}
+	for _, rule := range valid {
+		assert.NoError(t, ValidateRule(rule), "%+v", rule)
+	}
+
+	invalid := []market.AccessRule{
+		{Type: market.AccessPolicyTypeIdentity, Value: "0x1"},
+		{Type: market.AccessPolicyTypeDNSHostname, Value: ""},
+		{Type: market.AccessPolicyTypeIPCIDR, Value: "10.0.0.0/33"},
+		{Type: market.AccessPolicyTypePort, Value: "65536"},
+		{Type: market.AccessPolicyTypePort, Value: "8100-8000"},
+		{Type: market.AccessPolicyTypePort, Value: "icmp/1"},
+		{Type: market.AccessPolicyTypeBlockedPort, Value: "smtp"},
+		{Type: "country", Value: "DE"},
+	}
+	for _, rule := range invalid {
+		assert.Error(t, ValidateRule(rule), "%+v", rule)
+	}
+}
+
+func TestParsePortRange(t *testing.T) {
+	from, to, err := ParsePortRange("443")
+	assert.NoError(t, err)
+	assert.Equal(t, 443, from)
+	assert.Equal(t, 443, to)
+
+	from, to, err = ParsePortRange("8000-8100")
+	assert.NoError(t, err)
+	assert.Equal(t, 8000, from)
+	assert.Equal(t, 8100, to)
+
+	_, _, err = ParsePortRange("http")
+	assert.Error(t, err)
+}
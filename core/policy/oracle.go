@@ -136,6 +136,31 @@ func (pr *Oracle) SubscribePolicies(policies []market.AccessPolicy, repository *
 	return nil
 }
 
+// UnsubscribePolicies stops syncing rules of the given repository, it is called once the service using it stops
+func (pr *Oracle) UnsubscribePolicies(repository *Repository) {
+	pr.fetchLock.Lock()
+	defer pr.fetchLock.Unlock()
+
+	subscriptionsNew := make([]policySubscription, 0, len(pr.fetchSubscriptions))
+	for _, subscription := range pr.fetchSubscriptions {
+		subscription.subscribers = withoutSubscriber(subscription.subscribers, repository)
+		if len(subscription.subscribers) > 0 {
+			subscriptionsNew = append(subscriptionsNew, subscription)
+		}
+	}
+	pr.fetchSubscriptions = subscriptionsNew
+}
+
+func withoutSubscriber(subscribers []*Repository, repository *Repository) []*Repository {
+	result := make([]*Repository, 0, len(subscribers))
+	for _, subscriber := range subscribers {
+		if subscriber != repository {
+			result = append(result, subscriber)
+		}
+	}
+	return result
+}
+
 func (pr *Oracle) fetchPolicyRules(subscription *policySubscription) error {
 	req, err := requests.NewGetRequest(subscription.policy.Source, "", nil)
 	if err != nil {
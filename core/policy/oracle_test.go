@@ -161,6 +161,23 @@ func Test_Oracle_SubscribePolicies_MultipleSubscribers(t *testing.T) {
 	assert.Equal(t, []market.AccessPolicyRuleSet{policyOneRulesUpdated}, repo2.Rules())
 }
 
+func Test_Oracle_UnsubscribePolicies(t *testing.T) {
+	server := mockPolicyServer()
+	defer server.Close()
+
+	oracle := createEmptyOracle(server.URL)
+
+	repo1 := NewRepository()
+	assert.NoError(t, oracle.SubscribePolicies(oracle.Policies([]string{"1", "2"}), repo1))
+	repo2 := NewRepository()
+	assert.NoError(t, oracle.SubscribePolicies(oracle.Policies([]string{"1"}), repo2))
+
+	oracle.UnsubscribePolicies(repo1)
+
+	assert.Len(t, oracle.fetchSubscriptions, 1)
+	assert.Equal(t, []*Repository{repo2}, oracle.fetchSubscriptions[0].subscribers)
+}
+
 func Test_Oracle_StartSyncsPolicies(t *testing.T) {
 	repo := NewRepository()
 	server := mockPolicyServer()
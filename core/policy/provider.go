@@ -0,0 +1,77 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package policy
+
+import (
+	"github.com/mysteriumnetwork/node/market"
+)
+
+// Provider serves locally defined access policies and fetches all the others from the oracle
+type Provider struct {
+	local  *LocalPolicies
+	oracle *Oracle
+}
+
+// NewProvider creates a new instance of access policy provider
+func NewProvider(local *LocalPolicies, oracle *Oracle) *Provider {
+	return &Provider{
+		local:  local,
+		oracle: oracle,
+	}
+}
+
+// Policies converts given values to list of valid policies, local policies take precedence over the oracle ones
+func (p *Provider) Policies(policyIDs []string) []market.AccessPolicy {
+	policies := make([]market.AccessPolicy, len(policyIDs))
+	for i, policyID := range policyIDs {
+		if p.local.Has(policyID) {
+			policies[i] = p.local.Policy(policyID)
+		} else {
+			policies[i] = p.oracle.Policy(policyID)
+		}
+	}
+	return policies
+}
+
+// SubscribePolicies adds given policies to repository and keeps their rules up to date
+func (p *Provider) SubscribePolicies(policies []market.AccessPolicy, repository *Repository) error {
+	var local, remote []market.AccessPolicy
+	for _, policy := range policies {
+		if policy.Source == LocalSource {
+			local = append(local, policy)
+		} else {
+			remote = append(remote, policy)
+		}
+	}
+
+	if len(local) > 0 {
+		if err := p.local.SubscribePolicies(local, repository); err != nil {
+			return err
+		}
+	}
+	if len(remote) > 0 {
+		return p.oracle.SubscribePolicies(remote, repository)
+	}
+	return nil
+}
+
+// UnsubscribePolicies stops keeping rules of the given repository up to date
+func (p *Provider) UnsubscribePolicies(repository *Repository) {
+	p.local.UnsubscribePolicies(repository)
+	p.oracle.UnsubscribePolicies(repository)
+}
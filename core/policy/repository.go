@@ -19,11 +19,14 @@ package policy
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"sync"
 
+	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
+	"github.com/rs/zerolog/log"
 )
 
 type listItem struct {
@@ -35,20 +38,24 @@ type listItem struct {
 type Repository struct {
 	lock  sync.RWMutex
 	items []listItem
+
+	listenersLock  sync.Mutex
+	listeners      map[int]func()
+	lastListenerID int
 }
 
 // NewRepository create instance of policy repository
 func NewRepository() *Repository {
 	return &Repository{
-		items: make([]listItem, 0),
+		items:     make([]listItem, 0),
+		listeners: make(map[int]func()),
 	}
 }
 
 // SetPolicyRules set policy and it's items to repository
 func (r *Repository) SetPolicyRules(policy market.AccessPolicy, policyRules market.AccessPolicyRuleSet) {
 	r.lock.Lock()
-	defer r.lock.Unlock()
-
+	changed := true
 	item, err := r.findItemFor(policy)
 	if err != nil {
 		r.items = append(r.items, listItem{
@@ -56,8 +63,44 @@ func (r *Repository) SetPolicyRules(policy market.AccessPolicy, policyRules mark
 			rules:  policyRules,
 		})
 	} else {
+		changed = !reflect.DeepEqual(item.rules, policyRules)
 		item.rules = policyRules
 	}
+	r.lock.Unlock()
+
+	if changed {
+		r.notifyListeners()
+	}
+}
+
+// OnRulesChange registers callback which is called once rules of any policy change, returned function unregisters it
+func (r *Repository) OnRulesChange(callback func()) (unsubscribe func()) {
+	r.listenersLock.Lock()
+	defer r.listenersLock.Unlock()
+
+	r.lastListenerID++
+	id := r.lastListenerID
+	r.listeners[id] = callback
+
+	return func() {
+		r.listenersLock.Lock()
+		defer r.listenersLock.Unlock()
+
+		delete(r.listeners, id)
+	}
+}
+
+func (r *Repository) notifyListeners() {
+	r.listenersLock.Lock()
+	listeners := make([]func(), 0, len(r.listeners))
+	for _, listener := range r.listeners {
+		listeners = append(listeners, listener)
+	}
+	r.listenersLock.Unlock()
+
+	for _, listener := range listeners {
+		listener()
+	}
 }
 
 // Policies list policies in repository
@@ -181,6 +224,46 @@ func (r *Repository) IsHostAllowed(host string) bool {
 	return isAllowedByDefault
 }
 
+// TrafficRules returns firewall rules allowing just the destinations and ports permitted by the policies
+func (r *Repository) TrafficRules() firewall.TrafficRules {
+	rules := firewall.TrafficRules{
+		DNSWhitelist: r.HasDNSRules(),
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, item := range r.items {
+		for _, rule := range item.rules.Allow {
+			switch rule.Type {
+			case market.AccessPolicyTypeIPCIDR:
+				network, err := ParseNetwork(rule.Value)
+				if err != nil {
+					log.Warn().Err(err).Msgf("Skipping invalid rule of policy %s", item.policy.ID)
+					continue
+				}
+				rules.Networks = append(rules.Networks, network)
+			case market.AccessPolicyTypePort:
+				protocol, from, to, err := ParsePortRule(rule.Value)
+				if err != nil {
+					log.Warn().Err(err).Msgf("Skipping invalid rule of policy %s", item.policy.ID)
+					continue
+				}
+				rules.Ports = append(rules.Ports, firewall.PortRange{Protocol: protocol, From: from, To: to})
+			case market.AccessPolicyTypeBlockedPort:
+				protocol, from, to, err := ParsePortRule(rule.Value)
+				if err != nil {
+					log.Warn().Err(err).Msgf("Skipping invalid rule of policy %s", item.policy.ID)
+					continue
+				}
+				rules.BlockedPorts = append(rules.BlockedPorts, firewall.PortRange{Protocol: protocol, From: from, To: to})
+			}
+		}
+	}
+
+	return rules
+}
+
 func (r *Repository) findItemFor(policy market.AccessPolicy) (*listItem, error) {
 	for i, item := range r.items {
 		if item.policy == policy {
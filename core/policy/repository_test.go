@@ -18,8 +18,10 @@
 package policy
 
 import (
+	"net"
 	"testing"
 
+	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/stretchr/testify/assert"
@@ -161,3 +163,65 @@ func createFullRepo() *Repository {
 	)
 	return repo
 }
+
+func Test_Repository_TrafficRules(t *testing.T) {
+	repo := createEmptyRepo()
+	assert.True(t, repo.TrafficRules().IsEmpty())
+
+	repo.SetPolicyRules(policyTwo, policyTwoRules)
+	assert.Equal(t, firewall.TrafficRules{DNSWhitelist: true}, repo.TrafficRules())
+
+	repo.SetPolicyRules(
+		market.AccessPolicy{ID: "3", Source: LocalSource},
+		market.AccessPolicyRuleSet{
+			ID: "3",
+			Allow: []market.AccessRule{
+				{Type: market.AccessPolicyTypeIPCIDR, Value: "10.0.0.0/8"},
+				{Type: market.AccessPolicyTypeIPCIDR, Value: "192.168.1.1"},
+				{Type: market.AccessPolicyTypePort, Value: "443"},
+				{Type: market.AccessPolicyTypePort, Value: "udp/8000-8100"},
+				{Type: market.AccessPolicyTypePort, Value: "invalid"},
+				{Type: market.AccessPolicyTypeBlockedPort, Value: "tcp/25"},
+			},
+		},
+	)
+	assert.Equal(
+		t,
+		firewall.TrafficRules{
+			DNSWhitelist: true,
+			Networks: []net.IPNet{
+				{IP: net.IP{10, 0, 0, 0}, Mask: net.CIDRMask(8, 32)},
+				{IP: net.IP{192, 168, 1, 1}, Mask: net.CIDRMask(32, 32)},
+			},
+			Ports: []firewall.PortRange{
+				{From: 443, To: 443},
+				{Protocol: "udp", From: 8000, To: 8100},
+			},
+			BlockedPorts: []firewall.PortRange{
+				{Protocol: "tcp", From: 25, To: 25},
+			},
+		},
+		repo.TrafficRules(),
+	)
+}
+
+func Test_Repository_OnRulesChange(t *testing.T) {
+	repo := createEmptyRepo()
+	changes := 0
+	unsubscribe := repo.OnRulesChange(func() {
+		changes++
+	})
+
+	repo.SetPolicyRules(policyOne, policyOneRulesUpdated)
+	assert.Equal(t, 1, changes)
+
+	repo.SetPolicyRules(policyOne, policyOneRulesUpdated)
+	assert.Equal(t, 1, changes, "unchanged rules should not be reported")
+
+	repo.SetPolicyRules(policyOne, policyOneRules)
+	assert.Equal(t, 2, changes)
+
+	unsubscribe()
+	repo.SetPolicyRules(policyOne, policyOneRulesUpdated)
+	assert.Equal(t, 2, changes)
+}
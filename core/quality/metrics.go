@@ -17,6 +17,8 @@
 
 package quality
 
+import "github.com/mysteriumnetwork/node/market"
+
 // ServiceMetricsResponse represents response from the quality oracle service
 type ServiceMetricsResponse struct {
 	Connects []ConnectMetric `json:"connects"`
@@ -40,3 +42,30 @@ type ConnectCount struct {
 	Fail    int `json:"fail" example:"50" format:"int64"`
 	Timeout int `json:"timeout" example:"10" format:"int64"`
 }
+
+// Total returns the total number of connection attempts
+func (c ConnectCount) Total() int {
+	return c.Success + c.Fail + c.Timeout
+}
+
+// Score returns the ratio of successful connection attempts, zero if there were no attempts
+func (c ConnectCount) Score() float64 {
+	total := c.Total()
+	if total == 0 {
+		return 0
+	}
+	return float64(c.Success) / float64(total)
+}
+
+// ProposalScores maps proposals to their quality scores, proposals without connection attempts are skipped
+func ProposalScores(metrics []ConnectMetric) map[market.ProposalID]float64 {
+	scores := make(map[market.ProposalID]float64, len(metrics))
+	for _, m := range metrics {
+		if m.ConnectCount.Total() == 0 {
+			continue
+		}
+		id := market.ProposalID{ProviderID: m.ProposalID.ProviderID, ServiceType: m.ProposalID.ServiceType}
+		scores[id] = m.ConnectCount.Score()
+	}
+	return scores
+}
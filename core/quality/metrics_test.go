@@ -0,0 +1,42 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package quality
+
+import (
+	"testing"
+
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectCount_Score(t *testing.T) {
+	assert.Equal(t, 0.0, ConnectCount{}.Score())
+	assert.Equal(t, 0.5, ConnectCount{Success: 2, Fail: 1, Timeout: 1}.Score())
+	assert.Equal(t, 1.0, ConnectCount{Success: 3}.Score())
+}
+
+func TestProposalScores(t *testing.T) {
+	scores := ProposalScores([]ConnectMetric{
+		{ProposalID: ProposalID{ProviderID: "0x1", ServiceType: "wireguard"}, ConnectCount: ConnectCount{Success: 1, Fail: 3}},
+		{ProposalID: ProposalID{ProviderID: "0x2", ServiceType: "openvpn"}, ConnectCount: ConnectCount{}},
+	})
+
+	assert.Equal(t, map[market.ProposalID]float64{
+		{ProviderID: "0x1", ServiceType: "wireguard"}: 0.25,
+	}, scores)
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package quickconnect
+
+import (
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/core/discovery/ranking"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultMaxCandidates is the number of top ranked proposals tried when not specified otherwise.
+const DefaultMaxCandidates = 5
+
+// ErrNoProposals is returned when there are no proposals matching the filter.
+var ErrNoProposals = errors.New("no proposals matching the filter")
+
+// Connector connects to the given proposal
+type Connector interface {
+	Connect(consumerID, accountantID identity.Identity, proposal market.ServiceProposal, params connection.ConnectParams) error
+}
+
+// Request describes what proposals to connect to
+type Request struct {
+	ConsumerID    identity.Identity
+	AccountantID  identity.Identity
+	Filter        *proposal.Filter
+	Params        connection.ConnectParams
+	MaxCandidates int
+}
+
+// Result holds the chosen proposal and the ones that failed before it
+type Result struct {
+	Proposal market.ServiceProposal
+	Failed   []market.ProposalID
+}
+
+// QuickConnector connects to the best ranked proposal, falling through to the next candidates on failure
+type QuickConnector struct {
+	repository proposal.Repository
+	scorer     ranking.Scorer
+	connector  Connector
+}
+
+// NewQuickConnector creates a new instance of QuickConnector
+func NewQuickConnector(repository proposal.Repository, scorer ranking.Scorer, connector Connector) *QuickConnector {
+	return &QuickConnector{
+		repository: repository,
+		scorer:     scorer,
+		connector:  connector,
+	}
+}
+
+// Connect picks the top ranked proposals matching the filter and connects to the first one which succeeds.
+func (qc *QuickConnector) Connect(req Request) (Result, error) {
+	result := Result{}
+
+	proposals, err := qc.repository.Proposals(req.Filter)
+	if err != nil {
+		return result, errors.Wrap(err, "could not get proposals")
+	}
+
+	maxCandidates := req.MaxCandidates
+	if maxCandidates <= 0 {
+		maxCandidates = DefaultMaxCandidates
+	}
+	candidates := ranking.Rank(qc.scorer, proposals, ranking.Options{
+		SortBy: ranking.SortByScore,
+		Limit:  maxCandidates,
+	})
+	if len(candidates) == 0 {
+		return result, ErrNoProposals
+	}
+
+	var lastErr error
+	for _, candidate := range candidates {
+		log.Info().Msgf("Quick connect trying provider %s (%s)", candidate.ProviderID, candidate.ServiceType)
+		lastErr = qc.connector.Connect(req.ConsumerID, req.AccountantID, candidate, req.Params)
+		if lastErr == nil {
+			result.Proposal = candidate
+			return result, nil
+		}
+		if isFatal(lastErr) {
+			return result, lastErr
+		}
+
+		log.Warn().Err(lastErr).Msgf("Quick connect failed to connect to provider %s", candidate.ProviderID)
+		result.Failed = append(result.Failed, candidate.UniqueID())
+	}
+	return result, errors.Wrapf(lastErr, "all %d candidates failed", len(candidates))
+}
+
+// isFatal checks if the error would not be resolved by connecting to another provider.
+func isFatal(err error) bool {
+	switch errors.Cause(err) {
+	case connection.ErrAlreadyExists,
+		connection.ErrConnectionCancelled,
+		connection.ErrInsufficientBalance,
+		connection.ErrUnlockRequired:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package quickconnect
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/core/discovery/ranking"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	proposal1 = market.ServiceProposal{ProviderID: "0x1", ServiceType: "wireguard"}
+	proposal2 = market.ServiceProposal{ProviderID: "0x2", ServiceType: "wireguard"}
+	proposal3 = market.ServiceProposal{ProviderID: "0x3", ServiceType: "wireguard"}
+
+	scores = staticScorer{
+		proposal1.UniqueID(): {Total: 0.2},
+		proposal2.UniqueID(): {Total: 0.9},
+		proposal3.UniqueID(): {Total: 0.5},
+	}
+)
+
+type staticScorer map[market.ProposalID]ranking.Score
+
+func (s staticScorer) Score(_ []market.ServiceProposal) map[market.ProposalID]ranking.Score {
+	return s
+}
+
+type mockRepository struct {
+	proposals      []market.ServiceProposal
+	recordedFilter *proposal.Filter
+}
+
+func (m *mockRepository) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	return nil, nil
+}
+
+func (m *mockRepository) Proposals(filter *proposal.Filter) ([]market.ServiceProposal, error) {
+	m.recordedFilter = filter
+	return m.proposals, nil
+}
+
+type mockConnector struct {
+	errors    map[string]error
+	attempted []string
+}
+
+func (m *mockConnector) Connect(_, _ identity.Identity, p market.ServiceProposal, _ connection.ConnectParams) error {
+	m.attempted = append(m.attempted, p.ProviderID)
+	return m.errors[p.ProviderID]
+}
+
+func TestQuickConnector_ConnectsToBestProposal(t *testing.T) {
+	repository := &mockRepository{proposals: []market.ServiceProposal{proposal1, proposal2, proposal3}}
+	connector := &mockConnector{}
+	filter := &proposal.Filter{LocationCountry: "DE"}
+
+	result, err := NewQuickConnector(repository, scores, connector).Connect(Request{Filter: filter})
+
+	assert.NoError(t, err)
+	assert.Equal(t, proposal2, result.Proposal)
+	assert.Empty(t, result.Failed)
+	assert.Equal(t, []string{"0x2"}, connector.attempted)
+	assert.Equal(t, filter, repository.recordedFilter)
+}
+
+func TestQuickConnector_FallsThroughOnFailure(t *testing.T) {
+	repository := &mockRepository{proposals: []market.ServiceProposal{proposal1, proposal2, proposal3}}
+	connector := &mockConnector{errors: map[string]error{"0x2": connection.ErrConnectionFailed}}
+
+	result, err := NewQuickConnector(repository, scores, connector).Connect(Request{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, proposal3, result.Proposal)
+	assert.Equal(t, []market.ProposalID{proposal2.UniqueID()}, result.Failed)
+	assert.Equal(t, []string{"0x2", "0x3"}, connector.attempted)
+}
+
+func TestQuickConnector_TriesLimitedNumberOfCandidates(t *testing.T) {
+	repository := &mockRepository{proposals: []market.ServiceProposal{proposal1, proposal2, proposal3}}
+	connector := &mockConnector{errors: map[string]error{
+		"0x1": errors.New("boom"),
+		"0x2": errors.New("boom"),
+		"0x3": errors.New("boom"),
+	}}
+
+	result, err := NewQuickConnector(repository, scores, connector).Connect(Request{MaxCandidates: 2})
+
+	assert.Error(t, err)
+	assert.Len(t, result.Failed, 2)
+	assert.Equal(t, []string{"0x2", "0x3"}, connector.attempted)
+}
+
+func TestQuickConnector_StopsOnFatalError(t *testing.T) {
+	repository := &mockRepository{proposals: []market.ServiceProposal{proposal1, proposal2, proposal3}}
+	connector := &mockConnector{errors: map[string]error{"0x2": connection.ErrInsufficientBalance}}
+
+	_, err := NewQuickConnector(repository, scores, connector).Connect(Request{})
+
+	assert.Equal(t, connection.ErrInsufficientBalance, err)
+	assert.Equal(t, []string{"0x2"}, connector.attempted)
+}
+
+func TestQuickConnector_ReturnsErrorWhenNoProposals(t *testing.T) {
+	connector := &mockConnector{}
+
+	_, err := NewQuickConnector(&mockRepository{}, scores, connector).Connect(Request{})
+
+	assert.Equal(t, ErrNoProposals, err)
+	assert.Empty(t, connector.attempted)
+}
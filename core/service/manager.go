@@ -19,6 +19,7 @@ package service
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/mysteriumnetwork/node/communication"
@@ -42,6 +43,9 @@ var (
 	ErrUnsupportedAccessPolicy = errors.New("unsupported access policy")
 )
 
+// drainCheckInterval defines how often active sessions of a draining service are checked.
+const drainCheckInterval = 5 * time.Second
+
 // Service interface represents pluggable Mysterium service
 type Service interface {
 	Serve(instance *Instance) error
@@ -65,6 +69,18 @@ type Discovery interface {
 	Wait()
 }
 
+// PolicyProvider provides access policies and keeps their rules up to date
+type PolicyProvider interface {
+	Policies(policyIDs []string) []market.AccessPolicy
+	SubscribePolicies(policies []market.AccessPolicy, repository *policy.Repository) error
+	UnsubscribePolicies(repository *policy.Repository)
+}
+
+// SessionLister lists active provider sessions
+type SessionLister interface {
+	GetAll() []session.Session
+}
+
 // WaitForNATHole blocks until NAT hole is punched towards consumer through local NAT or until hole punching failed
 type WaitForNATHole func() error
 
@@ -75,10 +91,11 @@ func NewManager(
 	dialogHandlerFactory DialogHandlerFactory,
 	discoveryFactory DiscoveryFactory,
 	eventPublisher Publisher,
-	policyOracle *policy.Oracle,
+	policyProvider PolicyProvider,
 	p2pListener p2p.Listener,
 	sessionManager func(proposal market.ServiceProposal, serviceID string, channel p2p.Channel) *session.Manager,
 	statusStorage connectivity.StatusStorage,
+	sessions SessionLister,
 ) *Manager {
 	return &Manager{
 		serviceRegistry:      serviceRegistry,
@@ -87,10 +104,12 @@ func NewManager(
 		dialogHandlerFactory: dialogHandlerFactory,
 		discoveryFactory:     discoveryFactory,
 		eventPublisher:       eventPublisher,
-		policyOracle:         policyOracle,
+		policyProvider:       policyProvider,
 		p2pManager:           p2pListener,
 		sessionManager:       sessionManager,
 		statusStorage:        statusStorage,
+		sessions:             sessions,
+		drainCheckInterval:   drainCheckInterval,
 	}
 }
 
@@ -104,11 +123,14 @@ type Manager struct {
 
 	discoveryFactory DiscoveryFactory
 	eventPublisher   Publisher
-	policyOracle     *policy.Oracle
+	policyProvider   PolicyProvider
 
 	p2pManager     p2p.Listener
 	sessionManager func(proposal market.ServiceProposal, serviceID string, channel p2p.Channel) *session.Manager
 	statusStorage  connectivity.StatusStorage
+
+	sessions           SessionLister
+	drainCheckInterval time.Duration
 }
 
 // Start starts an instance of the given service type if knows one in service registry.
@@ -122,9 +144,14 @@ func (manager *Manager) Start(providerID identity.Identity, serviceType string,
 
 	proposal.SetAccessPolicies(nil)
 	policyRules := policy.NewRepository()
+	defer func() {
+		if err != nil {
+			manager.policyProvider.UnsubscribePolicies(policyRules)
+		}
+	}()
 	if len(policyIDs) > 0 {
-		policies := manager.policyOracle.Policies(policyIDs)
-		if err = manager.policyOracle.SubscribePolicies(policies, policyRules); err != nil {
+		policies := manager.policyProvider.Policies(policyIDs)
+		if err = manager.policyProvider.SubscribePolicies(policies, policyRules); err != nil {
 			log.Warn().Err(err).Msg("Can't find given access policies")
 			return id, ErrUnsupportedAccessPolicy
 		}
@@ -149,9 +176,23 @@ func (manager *Manager) Start(providerID identity.Identity, serviceType string,
 		return id, err
 	}
 
+	discovery := manager.discoveryFactory()
+	instance := &Instance{
+		id:             id,
+		state:          servicestate.Starting,
+		options:        options,
+		service:        service,
+		proposal:       proposal,
+		policies:       policyRules,
+		policyProvider: manager.policyProvider,
+		dialogWaiter:   dialogWaiter,
+		discovery:      discovery,
+		eventPublisher: manager.eventPublisher,
+	}
+
 	channelHandlers := func(ch p2p.Channel) {
 		mng := manager.sessionManager(proposal, string(id), ch)
-		subscribeSessionCreate(mng, ch, service)
+		subscribeSessionCreate(mng, ch, service, instance)
 		subscribeSessionStatus(mng, ch, manager.statusStorage)
 		subscribeSessionAcknowledge(mng, ch)
 		subscribeSessionDestroy(mng, ch)
@@ -163,21 +204,8 @@ func (manager *Manager) Start(providerID identity.Identity, serviceType string,
 		return id, fmt.Errorf("could not subscribe to p2p channels: %w", err)
 	}
 
-	discovery := manager.discoveryFactory()
 	discovery.Start(providerID, proposal)
 
-	instance := &Instance{
-		id:             id,
-		state:          servicestate.Starting,
-		options:        options,
-		service:        service,
-		proposal:       proposal,
-		policies:       policyRules,
-		dialogWaiter:   dialogWaiter,
-		discovery:      discovery,
-		eventPublisher: manager.eventPublisher,
-	}
-
 	manager.servicePool.Add(instance)
 
 	go func() {
@@ -228,6 +256,76 @@ func (manager *Manager) Stop(id ID) error {
 	return nil
 }
 
+// Drain unregisters the service proposal and stops accepting new sessions.
+// The service is stopped once all active sessions end or the given timeout passes.
+func (manager *Manager) Drain(id ID, timeout time.Duration) error {
+	instance := manager.servicePool.Instance(id)
+	if instance == nil {
+		return ErrNoSuchInstance
+	}
+
+	if !instance.startDraining() {
+		return nil
+	}
+
+	log.Info().Msgf("Draining service %s, waiting up to %s for active sessions to end", id, timeout)
+	go manager.stopWhenDrained(id, timeout)
+	return nil
+}
+
+func (manager *Manager) stopWhenDrained(id ID, timeout time.Duration) {
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(manager.drainCheckInterval)
+	defer ticker.Stop()
+
+	for count := manager.activeSessions(id); count > 0; count = manager.activeSessions(id) {
+		select {
+		case <-deadline:
+			log.Info().Msgf("Drain timeout of service %s expired, stopping %d active sessions", id, count)
+			manager.stopDrained(id)
+			return
+		case <-ticker.C:
+		}
+	}
+
+	log.Info().Msgf("All sessions of service %s ended, stopping", id)
+	manager.stopDrained(id)
+}
+
+func (manager *Manager) stopDrained(id ID) {
+	err := manager.servicePool.Stop(id)
+	if err != nil && err != ErrNoSuchInstance {
+		log.Error().Err(err).Msgf("Failed to stop drained service %s", id)
+	}
+}
+
+func (manager *Manager) activeSessions(id ID) int {
+	count := 0
+	for _, s := range manager.sessions.GetAll() {
+		if s.ServiceID == string(id) {
+			count++
+		}
+	}
+	return count
+}
+
+// DrainState returns the state of service instance to be checked before starting new sessions.
+func (manager *Manager) DrainState(id ID) session.ServiceState {
+	return &instanceState{pool: manager.servicePool, id: id}
+}
+
+// instanceState looks up the service instance on every check, as it may not exist yet when sessions are set up.
+type instanceState struct {
+	pool *Pool
+	id   ID
+}
+
+// Draining returns true if the service instance is being drained.
+func (s *instanceState) Draining() bool {
+	instance := s.pool.Instance(s.id)
+	return instance != nil && instance.Draining()
+}
+
 // Service returns a service instance by requested id.
 func (manager *Manager) Service(id ID) *Instance {
 	return manager.servicePool.Instance(id)
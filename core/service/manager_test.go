@@ -19,6 +19,7 @@ package service
 
 import (
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 	"github.com/mysteriumnetwork/node/mocks"
 	"github.com/mysteriumnetwork/node/p2p"
 	"github.com/mysteriumnetwork/node/requests"
+	"github.com/mysteriumnetwork/node/session"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -54,7 +56,7 @@ func TestManager_StartRemovesServiceFromPoolIfServiceCrashes(t *testing.T) {
 		discoveryFactory,
 		mocks.NewEventBus(),
 		mockPolicyOracle,
-		&mockP2PListener{}, nil, nil,
+		&mockP2PListener{}, nil, nil, nil,
 	)
 	_, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{})
 	assert.Nil(t, err)
@@ -80,7 +82,7 @@ func TestManager_StartDoesNotCrashIfStoppedByUser(t *testing.T) {
 		discoveryFactory,
 		mocks.NewEventBus(),
 		mockPolicyOracle,
-		&mockP2PListener{}, nil, nil,
+		&mockP2PListener{}, nil, nil, nil,
 	)
 	id, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{})
 	assert.Nil(t, err)
@@ -108,7 +110,7 @@ func TestManager_StopSendsEvent_SucceedsAndPublishesEvent(t *testing.T) {
 		discoveryFactory,
 		eventBus,
 		mockPolicyOracle,
-		&mockP2PListener{}, nil, nil,
+		&mockP2PListener{}, nil, nil, nil,
 	)
 
 	id, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{})
@@ -152,3 +154,94 @@ type mockP2PListener struct {
 func (m mockP2PListener) Listen(providerID identity.Identity, serviceType string, channelHandler func(ch p2p.Channel)) error {
 	return nil
 }
+
+type mockSessionLister struct {
+	sessions []session.Session
+	lock     sync.Mutex
+}
+
+func (m *mockSessionLister) GetAll() []session.Session {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.sessions
+}
+
+func (m *mockSessionLister) set(sessions ...session.Session) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.sessions = sessions
+}
+
+func newDrainTestManager(discovery *mockDiscovery, sessions SessionLister) *Manager {
+	registry := NewRegistry()
+	mockCopy := *serviceMock
+	mockCopy.mockProcess = make(chan struct{})
+	registry.Register(serviceType, func(options Options) (Service, market.ServiceProposal, error) {
+		return &mockCopy, proposalMock, nil
+	})
+
+	manager := NewManager(
+		registry,
+		MockDialogWaiterFactory,
+		MockDialogHandlerFactory,
+		MockDiscoveryFactoryFunc(discovery),
+		mocks.NewEventBus(),
+		mockPolicyOracle,
+		&mockP2PListener{}, nil, nil, sessions,
+	)
+	manager.drainCheckInterval = 5 * time.Millisecond
+	return manager
+}
+
+func TestManager_DrainStopsServiceWhenSessionsEnd(t *testing.T) {
+	discovery := &mockDiscovery{}
+	sessions := &mockSessionLister{}
+	manager := newDrainTestManager(discovery, sessions)
+
+	id, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{})
+	assert.NoError(t, err)
+	sessions.set(session.Session{ID: "s1", ServiceID: string(id)}, session.Session{ID: "s2", ServiceID: "other"})
+
+	err = manager.Drain(id, time.Minute)
+	assert.NoError(t, err)
+
+	// Proposal is unregistered right away
+	discovery.Wait()
+	assert.Equal(t, servicestate.Draining, manager.Service(id).State())
+	assert.True(t, manager.DrainState(id).Draining())
+	assert.False(t, manager.DrainState("other").Draining())
+
+	// Draining twice is a no-op
+	assert.NoError(t, manager.Drain(id, time.Millisecond))
+
+	time.Sleep(20 * time.Millisecond)
+	assert.NotNil(t, manager.Service(id))
+
+	sessions.set(session.Session{ID: "s2", ServiceID: "other"})
+	assert.Eventually(t, func() bool {
+		return manager.Service(id) == nil
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestManager_DrainStopsServiceAfterTimeout(t *testing.T) {
+	discovery := &mockDiscovery{}
+	sessions := &mockSessionLister{}
+	manager := newDrainTestManager(discovery, sessions)
+
+	id, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{})
+	assert.NoError(t, err)
+	sessions.set(session.Session{ID: "s1", ServiceID: string(id)})
+
+	err = manager.Drain(id, 20*time.Millisecond)
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return manager.Service(id) == nil
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestManager_DrainFailsForUnknownService(t *testing.T) {
+	manager := newDrainTestManager(&mockDiscovery{}, &mockSessionLister{})
+
+	assert.Equal(t, ErrNoSuchInstance, manager.Drain("unknown", time.Second))
+}
@@ -17,6 +17,8 @@
 
 package service
 
+import "encoding/json"
+
 // OptionsIdentity describes identity which is required to start a service
 type OptionsIdentity struct {
 	Identity   string
@@ -25,3 +27,6 @@ type OptionsIdentity struct {
 
 // Options represents any type of options for pluggable service
 type Options interface{}
+
+// OptionsParser parses options of the given service type
+type OptionsParser func(serviceType string, options *json.RawMessage) (Options, error)
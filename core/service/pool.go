@@ -92,15 +92,16 @@ func (p *Pool) stop(id ID) error {
 	}
 
 	errStop := utils.ErrorCollection{}
-	if instance.discovery != nil {
-		instance.discovery.Stop()
-	}
+	instance.stopDiscovery()
 	if instance.dialogWaiter != nil {
 		errStop.Add(instance.dialogWaiter.Stop())
 	}
 	if instance.service != nil {
 		errStop.Add(instance.service.Stop())
 	}
+	if instance.policyProvider != nil {
+		instance.policyProvider.UnsubscribePolicies(instance.policies)
+	}
 
 	p.del(id)
 
@@ -164,11 +165,13 @@ type Instance struct {
 	service        RunnableService
 	proposal       market.ServiceProposal
 	policies       *policy.Repository
+	policyProvider PolicyProvider
 	dialogWaiter   communication.DialogWaiter
 	discovery      Discovery
 	eventPublisher Publisher
 
-	stateLock sync.RWMutex
+	stateLock     sync.RWMutex
+	discoveryOnce sync.Once
 }
 
 // Options returns options used to start service
@@ -193,9 +196,42 @@ func (i *Instance) State() servicestate.State {
 	return i.state
 }
 
+// Draining returns true if the service instance no longer accepts new sessions.
+func (i *Instance) Draining() bool {
+	return i.State() == servicestate.Draining
+}
+
+// startDraining unregisters the service proposal and marks the instance as draining.
+// Returns false if the instance was already draining or stopped.
+func (i *Instance) startDraining() bool {
+	i.stateLock.Lock()
+	if i.state == servicestate.Draining || i.state == servicestate.NotRunning {
+		i.stateLock.Unlock()
+		return false
+	}
+	i.state = servicestate.Draining
+	i.eventPublisher.Publish(servicestate.AppTopicServiceStatus, i.toEvent())
+	i.stateLock.Unlock()
+
+	i.stopDiscovery()
+	return true
+}
+
+func (i *Instance) stopDiscovery() {
+	i.discoveryOnce.Do(func() {
+		if i.discovery != nil {
+			i.discovery.Stop()
+		}
+	})
+}
+
 func (i *Instance) setState(newState servicestate.State) {
 	i.stateLock.Lock()
 	defer i.stateLock.Unlock()
+	// Draining instance can only be stopped.
+	if i.state == servicestate.Draining && newState != servicestate.NotRunning {
+		return
+	}
 	i.state = newState
 
 	i.eventPublisher.Publish(servicestate.AppTopicServiceStatus, i.toEvent())
@@ -22,6 +22,8 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/mysteriumnetwork/node/core/policy"
+	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/mocks"
 	"github.com/stretchr/testify/assert"
 )
@@ -101,3 +103,31 @@ func Test_Pool_StopAllDoesNotStopOneInstance(t *testing.T) {
 	err := pool.StopAll()
 	assert.EqualError(t, err, "Some instances did not stop: ErrorCollection(I dont want to stop)")
 }
+
+type mockPolicyProvider struct {
+	unsubscribed []*policy.Repository
+}
+
+func (m *mockPolicyProvider) Policies(_ []string) []market.AccessPolicy {
+	return nil
+}
+
+func (m *mockPolicyProvider) SubscribePolicies(_ []market.AccessPolicy, _ *policy.Repository) error {
+	return nil
+}
+
+func (m *mockPolicyProvider) UnsubscribePolicies(repository *policy.Repository) {
+	m.unsubscribed = append(m.unsubscribed, repository)
+}
+
+func Test_Pool_StopUnsubscribesPolicies(t *testing.T) {
+	policies := policy.NewRepository()
+	provider := &mockPolicyProvider{}
+	instance := &Instance{id: "test id", policies: policies, policyProvider: provider, eventPublisher: mocks.NewEventBus()}
+
+	pool := NewPool(mocks.NewEventBus())
+	pool.Add(instance)
+
+	assert.NoError(t, pool.Stop("test id"))
+	assert.Equal(t, []*policy.Repository{policies}, provider.unsubscribed)
+}
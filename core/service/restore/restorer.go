@@ -0,0 +1,193 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package restore
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/mysteriumnetwork/node/core/node/event"
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrAlreadyRunning is returned when the provider already runs a service of the requested type
+var ErrAlreadyRunning = errors.New("service already running")
+
+// ServiceManager starts, stops and lists the services
+type ServiceManager interface {
+	Start(providerID identity.Identity, serviceType string, policyIDs []string, options service.Options) (service.ID, error)
+	Stop(id service.ID) error
+	List() map[service.ID]*service.Instance
+}
+
+// UnlockChecker tells whether the identity is unlocked
+type UnlockChecker interface {
+	IsUnlocked(address string) bool
+}
+
+// Restorer starts the stored services of the provider once the node is started or its identity gets unlocked.
+// Services started explicitly through Start replace the restored ones, so the latest request wins.
+type Restorer struct {
+	manager      ServiceManager
+	storage      *Storage
+	parseOptions service.OptionsParser
+	identities   UnlockChecker
+
+	restored   map[string]bool
+	restoredID map[service.ID]bool
+	mu         sync.Mutex
+}
+
+// NewRestorer creates a new service restorer
+func NewRestorer(manager ServiceManager, storage *Storage, parseOptions service.OptionsParser, identities UnlockChecker) *Restorer {
+	return &Restorer{
+		manager:      manager,
+		storage:      storage,
+		parseOptions: parseOptions,
+		identities:   identities,
+		restored:     make(map[string]bool),
+		restoredID:   make(map[service.ID]bool),
+	}
+}
+
+// Subscribe subscribes the restorer to node start and identity unlock events
+func (r *Restorer) Subscribe(bus eventbus.Subscriber) error {
+	if err := bus.SubscribeAsync(event.AppTopicNode, r.handleNodeEvent); err != nil {
+		return err
+	}
+	return bus.SubscribeAsync(identity.AppTopicIdentityUnlock, r.handleUnlockEvent)
+}
+
+func (r *Restorer) handleNodeEvent(e event.Payload) {
+	if e.Status != event.StatusStarted {
+		return
+	}
+
+	definitions, err := r.storage.List()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load stored services")
+		return
+	}
+
+	providers := make(map[string]bool)
+	for _, definition := range definitions {
+		if !providers[definition.ProviderID] && r.identities.IsUnlocked(definition.ProviderID) {
+			providers[definition.ProviderID] = true
+			r.Restore(identity.FromAddress(definition.ProviderID))
+		}
+	}
+}
+
+func (r *Restorer) handleUnlockEvent(address string) {
+	r.Restore(identity.FromAddress(address))
+}
+
+// Restore starts the stored services of the given provider which are not running yet.
+// Services are restored once per node run, so stopping them later is not undone by unlocking the identity again.
+func (r *Restorer) Restore(providerID identity.Identity) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.restored[providerID.Address] {
+		return
+	}
+	r.restored[providerID.Address] = true
+
+	definitions, err := r.storage.List()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load stored services")
+		return
+	}
+
+	for _, definition := range definitions {
+		if definition.ProviderID != providerID.Address {
+			continue
+		}
+		if _, ok := r.running(definition); ok {
+			log.Info().Msgf("Stored %s service of %s is already running", definition.ServiceType, definition.ProviderID)
+			continue
+		}
+		r.restore(definition)
+	}
+}
+
+// Start starts the service and stores its definition, so it is restored after node restart.
+// A service restored from the previous run is replaced, while the one started through Start makes it fail with ErrAlreadyRunning.
+func (r *Restorer) Start(definition Definition, options service.Options) (service.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id, ok := r.running(definition); ok {
+		if !r.restoredID[id] {
+			return id, ErrAlreadyRunning
+		}
+		log.Info().Msgf("Replacing restored %s service of %s", definition.ServiceType, definition.ProviderID)
+		if err := r.manager.Stop(id); err != nil {
+			return id, err
+		}
+		delete(r.restoredID, id)
+	}
+
+	id, err := r.manager.Start(identity.FromAddress(definition.ProviderID), definition.ServiceType, definition.AccessPolicies, options)
+	if err != nil {
+		return id, err
+	}
+
+	if err := r.storage.Save(definition); err != nil {
+		log.Error().Err(err).Msgf("Failed to store %s service, it will not be restored after restart", definition.ServiceType)
+	}
+	return id, nil
+}
+
+// Forget deletes the stored service of the provider, so it is not restored after node restart
+func (r *Restorer) Forget(providerID, serviceType string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.storage.Delete(providerID, serviceType)
+}
+
+func (r *Restorer) restore(definition Definition) {
+	options, err := r.parseOptions(definition.ServiceType, definition.Options)
+	if err != nil {
+		log.Error().Err(err).Msgf("Invalid options of stored %s service of %s", definition.ServiceType, definition.ProviderID)
+		return
+	}
+
+	log.Info().Msgf("Restoring %s service of %s", definition.ServiceType, definition.ProviderID)
+	providerID := identity.FromAddress(definition.ProviderID)
+	id, err := r.manager.Start(providerID, definition.ServiceType, definition.AccessPolicies, options)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to restore %s service of %s", definition.ServiceType, definition.ProviderID)
+		return
+	}
+	r.restoredID[id] = true
+}
+
+func (r *Restorer) running(definition Definition) (service.ID, bool) {
+	for id, instance := range r.manager.List() {
+		proposal := instance.Proposal()
+		if identity.FromAddress(proposal.ProviderID).Address == definition.ProviderID && proposal.ServiceType == definition.ServiceType {
+			return id, true
+		}
+	}
+	return "", false
+}
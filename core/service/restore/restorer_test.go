@@ -0,0 +1,190 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package restore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/core/node/event"
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/core/service/servicestate"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+type startCall struct {
+	providerID  identity.Identity
+	serviceType string
+	policyIDs   []string
+	options     service.Options
+}
+
+type mockManager struct {
+	instances map[service.ID]*service.Instance
+	started   []startCall
+	stopped   []service.ID
+	err       error
+}
+
+func (m *mockManager) Start(providerID identity.Identity, serviceType string, policyIDs []string, options service.Options) (service.ID, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	m.started = append(m.started, startCall{providerID, serviceType, policyIDs, options})
+	id := service.ID(fmt.Sprintf("%d", len(m.started)))
+	proposal := market.ServiceProposal{ProviderID: providerID.Address, ServiceType: serviceType}
+	m.instances[id] = service.NewInstance(options, servicestate.Running, nil, proposal, nil, nil, nil)
+	return id, nil
+}
+
+func (m *mockManager) Stop(id service.ID) error {
+	m.stopped = append(m.stopped, id)
+	delete(m.instances, id)
+	return nil
+}
+
+func (m *mockManager) List() map[service.ID]*service.Instance {
+	return m.instances
+}
+
+func parseOptions(serviceType string, options *json.RawMessage) (service.Options, error) {
+	if serviceType == "unknown" {
+		return nil, service.ErrUnsupportedServiceType
+	}
+	if options == nil {
+		return "default", nil
+	}
+	return string(*options), nil
+}
+
+type mockUnlockChecker map[string]bool
+
+func (m mockUnlockChecker) IsUnlocked(address string) bool {
+	return m[address]
+}
+
+func newTestRestorer(manager *mockManager, definitions ...Definition) *Restorer {
+	storage := NewStorage(&mockStorer{})
+	for _, definition := range definitions {
+		storage.Save(definition)
+	}
+	return NewRestorer(manager, storage, parseOptions, mockUnlockChecker{"0x1": true})
+}
+
+func TestRestorer_StartsStoredServicesOfProvider(t *testing.T) {
+	manager := &mockManager{instances: make(map[service.ID]*service.Instance)}
+	restorer := newTestRestorer(manager,
+		NewDefinition("0x1", "wireguard", []string{"mysterium"}, rawOptions(`{"port":1}`)),
+		NewDefinition("0x1", "openvpn", nil, nil),
+		NewDefinition("0x2", "wireguard", nil, nil),
+	)
+
+	restorer.handleUnlockEvent("0x1")
+
+	assert.Equal(t, []startCall{
+		{identity.FromAddress("0x1"), "wireguard", []string{"mysterium"}, `{"port":1}`},
+		{identity.FromAddress("0x1"), "openvpn", nil, "default"},
+	}, manager.started)
+}
+
+func TestRestorer_SkipsRunningServices(t *testing.T) {
+	proposal := market.ServiceProposal{ProviderID: "0x1", ServiceType: "wireguard"}
+	manager := &mockManager{instances: map[service.ID]*service.Instance{
+		"running": service.NewInstance(nil, servicestate.Running, nil, proposal, nil, nil, nil),
+	}}
+	restorer := newTestRestorer(manager,
+		NewDefinition("0x1", "wireguard", nil, nil),
+		NewDefinition("0x1", "unknown", nil, nil),
+	)
+
+	restorer.Restore(identity.FromAddress("0x1"))
+
+	assert.Empty(t, manager.started)
+}
+
+func TestRestorer_RestoresOncePerProvider(t *testing.T) {
+	manager := &mockManager{instances: make(map[service.ID]*service.Instance)}
+	restorer := newTestRestorer(manager, NewDefinition("0x1", "wireguard", nil, nil))
+
+	restorer.Restore(identity.FromAddress("0x1"))
+	manager.instances = make(map[service.ID]*service.Instance)
+	restorer.Restore(identity.FromAddress("0x1"))
+
+	assert.Len(t, manager.started, 1)
+}
+
+func TestRestorer_ContinuesAfterFailedStart(t *testing.T) {
+	manager := &mockManager{instances: make(map[service.ID]*service.Instance), err: errors.New("boom")}
+	restorer := newTestRestorer(manager, NewDefinition("0x1", "wireguard", nil, nil))
+
+	restorer.Restore(identity.FromAddress("0x1"))
+
+	assert.Empty(t, manager.started)
+}
+
+func TestRestorer_RestoresUnlockedProvidersOnNodeStart(t *testing.T) {
+	manager := &mockManager{instances: make(map[service.ID]*service.Instance)}
+	restorer := newTestRestorer(manager,
+		NewDefinition("0x1", "wireguard", nil, nil),
+		NewDefinition("0x1", "openvpn", nil, nil),
+		NewDefinition("0x2", "wireguard", nil, nil),
+	)
+
+	restorer.handleNodeEvent(event.Payload{Status: event.StatusStopped})
+	assert.Empty(t, manager.started)
+
+	restorer.handleNodeEvent(event.Payload{Status: event.StatusStarted})
+	assert.Equal(t, []startCall{
+		{identity.FromAddress("0x1"), "wireguard", nil, "default"},
+		{identity.FromAddress("0x1"), "openvpn", nil, "default"},
+	}, manager.started)
+}
+
+func TestRestorer_StartReplacesRestoredService(t *testing.T) {
+	manager := &mockManager{instances: make(map[service.ID]*service.Instance)}
+	restorer := newTestRestorer(manager, NewDefinition("0x1", "wireguard", nil, rawOptions(`{"port":1}`)))
+	restorer.Restore(identity.FromAddress("0x1"))
+
+	id, err := restorer.Start(NewDefinition("0x1", "wireguard", nil, rawOptions(`{"port":2}`)), `{"port":2}`)
+
+	assert.NoError(t, err)
+	assert.Equal(t, service.ID("2"), id)
+	assert.Equal(t, []service.ID{"1"}, manager.stopped)
+	definitions, err := restorer.storage.List()
+	assert.NoError(t, err)
+	assert.Len(t, definitions, 1)
+	assert.JSONEq(t, `{"port":2}`, string(*definitions[0].Options))
+}
+
+func TestRestorer_StartRefusesServiceStartedExplicitly(t *testing.T) {
+	manager := &mockManager{instances: make(map[service.ID]*service.Instance)}
+	restorer := newTestRestorer(manager)
+
+	_, err := restorer.Start(NewDefinition("0x1", "wireguard", nil, nil), "default")
+	assert.NoError(t, err)
+	restorer.Restore(identity.FromAddress("0x1"))
+	_, err = restorer.Start(NewDefinition("0x1", "wireguard", nil, nil), "default")
+
+	assert.Equal(t, ErrAlreadyRunning, err)
+	assert.Len(t, manager.started, 1)
+	assert.Empty(t, manager.stopped)
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package restore
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mysteriumnetwork/node/identity"
+)
+
+const bucketName = "service-definitions"
+
+// Definition holds everything needed to start the service again
+type Definition struct {
+	Key            string `storm:"id"`
+	ProviderID     string
+	ServiceType    string
+	Options        *json.RawMessage
+	AccessPolicies []string
+	Created        time.Time
+}
+
+// NewDefinition creates a definition of the given provider service
+func NewDefinition(providerID, serviceType string, policyIDs []string, options *json.RawMessage) Definition {
+	return Definition{
+		Key:            definitionKey(providerID, serviceType),
+		ProviderID:     identity.FromAddress(providerID).Address,
+		ServiceType:    serviceType,
+		Options:        options,
+		AccessPolicies: policyIDs,
+		Created:        time.Now().UTC(),
+	}
+}
+
+// definitionKey allows a single service of the given type per provider, same as the service manager API does
+func definitionKey(providerID, serviceType string) string {
+	return fmt.Sprintf("%v.%v", identity.FromAddress(providerID).Address, serviceType)
+}
+
+// Storer allows us to store, list and delete the definitions
+type Storer interface {
+	Store(bucket string, object interface{}) error
+	GetAllFrom(bucket string, array interface{}) error
+	Delete(bucket string, object interface{}) error
+}
+
+// Storage persists definitions of the services started through the API
+type Storage struct {
+	storage Storer
+}
+
+// NewStorage creates the service definition storage
+func NewStorage(storage Storer) *Storage {
+	return &Storage{storage: storage}
+}
+
+// Save stores the definition, replacing the previous one of the same provider service
+func (s *Storage) Save(definition Definition) error {
+	return s.storage.Store(bucketName, &definition)
+}
+
+// List returns all stored definitions
+func (s *Storage) List() ([]Definition, error) {
+	var definitions []Definition
+	if err := s.storage.GetAllFrom(bucketName, &definitions); err != nil {
+		return nil, err
+	}
+	return definitions, nil
+}
+
+// Delete removes the definition of the given provider service, if there is one
+func (s *Storage) Delete(providerID, serviceType string) error {
+	definitions, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	key := definitionKey(providerID, serviceType)
+	for i := range definitions {
+		if definitions[i].Key == key {
+			return s.storage.Delete(bucketName, &definitions[i])
+		}
+	}
+	return nil
+}
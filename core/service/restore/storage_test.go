@@ -0,0 +1,93 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package restore
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockStorer struct {
+	definitions []Definition
+}
+
+func (m *mockStorer) Store(_ string, object interface{}) error {
+	definition := *object.(*Definition)
+	for i := range m.definitions {
+		if m.definitions[i].Key == definition.Key {
+			m.definitions[i] = definition
+			return nil
+		}
+	}
+	m.definitions = append(m.definitions, definition)
+	return nil
+}
+
+func (m *mockStorer) GetAllFrom(_ string, array interface{}) error {
+	*array.(*[]Definition) = append([]Definition(nil), m.definitions...)
+	return nil
+}
+
+func (m *mockStorer) Delete(_ string, object interface{}) error {
+	key := object.(*Definition).Key
+	for i := range m.definitions {
+		if m.definitions[i].Key == key {
+			m.definitions = append(m.definitions[:i], m.definitions[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func rawOptions(value string) *json.RawMessage {
+	msg := json.RawMessage(value)
+	return &msg
+}
+
+func TestStorage_SaveReplacesProviderService(t *testing.T) {
+	storage := NewStorage(&mockStorer{})
+
+	assert.NoError(t, storage.Save(NewDefinition("0xABC", "wireguard", nil, rawOptions(`{"port":1}`))))
+	assert.NoError(t, storage.Save(NewDefinition("0xabc", "openvpn", []string{"mysterium"}, nil)))
+	assert.NoError(t, storage.Save(NewDefinition("0xabc", "wireguard", nil, rawOptions(`{"port":2}`))))
+
+	definitions, err := storage.List()
+	assert.NoError(t, err)
+	assert.Len(t, definitions, 2)
+	assert.Equal(t, "0xabc", definitions[0].ProviderID)
+	assert.Equal(t, "wireguard", definitions[0].ServiceType)
+	assert.Equal(t, rawOptions(`{"port":2}`), definitions[0].Options)
+	assert.Equal(t, "openvpn", definitions[1].ServiceType)
+	assert.Equal(t, []string{"mysterium"}, definitions[1].AccessPolicies)
+}
+
+func TestStorage_Delete(t *testing.T) {
+	storage := NewStorage(&mockStorer{})
+	assert.NoError(t, storage.Save(NewDefinition("0xabc", "wireguard", nil, nil)))
+	assert.NoError(t, storage.Save(NewDefinition("0xabc", "openvpn", nil, nil)))
+
+	assert.NoError(t, storage.Delete("0xABC", "wireguard"))
+	assert.NoError(t, storage.Delete("0xabc", "noop"))
+
+	definitions, err := storage.List()
+	assert.NoError(t, err)
+	assert.Len(t, definitions, 1)
+	assert.Equal(t, "openvpn", definitions[0].ServiceType)
+}
@@ -0,0 +1,197 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package schedule
+
+import (
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// field describes the allowed value range of a single expression field.
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 7},
+}
+
+// Expression is a cron-like time expression made of five space separated fields:
+// minute, hour, day of month, month and day of week (0 or 7 is Sunday).
+// Every field accepts "*", single values, ranges ("1-5"), lists ("1,3,5") and steps ("*/15", "8-18/2").
+type Expression struct {
+	raw                           string
+	minute, hour, dom, month, dow uint64
+	domRestricted, dowRestricted  bool
+}
+
+// ParseExpression parses the given cron-like expression.
+func ParseExpression(raw string) (Expression, error) {
+	parts := strings.Fields(raw)
+	if len(parts) != len(fields) {
+		return Expression{}, errors.Errorf("expected %d fields in expression %q, got %d", len(fields), raw, len(parts))
+	}
+
+	sets := make([]uint64, len(fields))
+	for i, part := range parts {
+		set, err := parseField(part, fields[i])
+		if err != nil {
+			return Expression{}, errors.Wrapf(err, "invalid expression %q", raw)
+		}
+		sets[i] = set
+	}
+
+	// Sunday can be written both as 0 and 7.
+	dow := sets[4]
+	if dow&(1<<7) != 0 {
+		dow |= 1
+	}
+
+	return Expression{
+		raw:           raw,
+		minute:        sets[0],
+		hour:          sets[1],
+		dom:           sets[2],
+		month:         sets[3],
+		dow:           dow,
+		domRestricted: parts[2] != "*",
+		dowRestricted: parts[4] != "*",
+	}, nil
+}
+
+func parseField(value string, f field) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(value, ",") {
+		rangeValue, step := item, 1
+		i := strings.Index(item, "/")
+		if i >= 0 {
+			var err error
+			rangeValue = item[:i]
+			step, err = strconv.Atoi(item[i+1:])
+			if err != nil || step <= 0 {
+				return 0, errors.Errorf("invalid step in %s field %q", f.name, item)
+			}
+		}
+
+		from, to := f.min, f.max
+		if rangeValue != "*" {
+			bounds := strings.SplitN(rangeValue, "-", 2)
+			var err error
+			if from, err = parseValue(bounds[0], f); err != nil {
+				return 0, err
+			}
+			to = from
+			if len(bounds) == 1 && i >= 0 {
+				to = f.max
+			}
+			if len(bounds) == 2 {
+				if to, err = parseValue(bounds[1], f); err != nil {
+					return 0, err
+				}
+			}
+			if from > to {
+				return 0, errors.Errorf("invalid range in %s field %q", f.name, item)
+			}
+		}
+
+		for v := from; v <= to; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func parseValue(value string, f field) (int, error) {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.Errorf("invalid %s value %q", f.name, value)
+	}
+	if v < f.min || v > f.max {
+		return 0, errors.Errorf("%s value %d out of range %d-%d", f.name, v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Matches checks if the given time falls on the minute described by the expression.
+func (e Expression) Matches(t time.Time) bool {
+	if e.minute&(1<<uint(t.Minute())) == 0 || e.hour&(1<<uint(t.Hour())) == 0 {
+		return false
+	}
+	return e.dayMatches(t)
+}
+
+// Prev returns the latest minute not later than t which matches the expression.
+// Minutes not after oldest are not considered.
+func (e Expression) Prev(t, oldest time.Time) (time.Time, bool) {
+	t = t.Truncate(time.Minute)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	maxHour, maxMinute := t.Hour(), t.Minute()
+	for ; day.AddDate(0, 0, 1).After(oldest); day = day.AddDate(0, 0, -1) {
+		if e.dayMatches(day) {
+			for hour := highestBit(e.hour, maxHour); hour >= 0; hour = highestBit(e.hour, hour-1) {
+				limit := 59
+				if hour == maxHour {
+					limit = maxMinute
+				}
+				minute := highestBit(e.minute, limit)
+				if minute < 0 {
+					continue
+				}
+				match := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+				return match, match.After(oldest)
+			}
+		}
+		maxHour, maxMinute = 23, 59
+	}
+	return time.Time{}, false
+}
+
+func (e Expression) dayMatches(t time.Time) bool {
+	if e.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	domMatches := e.dom&(1<<uint(t.Day())) != 0
+	dowMatches := e.dow&(1<<uint(t.Weekday())) != 0
+	// As in cron, when both day fields are restricted it is enough for one of them to match.
+	if e.domRestricted && e.dowRestricted {
+		return domMatches || dowMatches
+	}
+	return domMatches && dowMatches
+}
+
+// highestBit returns the highest value of the set not greater than max, or -1 if there is none.
+func highestBit(set uint64, max int) int {
+	if max < 0 {
+		return -1
+	}
+	return bits.Len64(set&(1<<uint(max+1)-1)) - 1
+}
+
+// String returns the expression as it was given.
+func (e Expression) String() string {
+	return e.raw
+}
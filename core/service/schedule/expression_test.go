@@ -0,0 +1,109 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExpression_Matches(t *testing.T) {
+	// 2020-06-01 is a Monday
+	monday := func(hour, minute int) time.Time {
+		return time.Date(2020, 6, 1, hour, minute, 30, 0, time.UTC)
+	}
+
+	tests := []struct {
+		expression string
+		time       time.Time
+		matches    bool
+	}{
+		{"* * * * *", monday(13, 37), true},
+		{"0 9 * * *", monday(9, 0), true},
+		{"0 9 * * *", monday(9, 1), false},
+		{"*/15 * * * *", monday(10, 45), true},
+		{"*/15 * * * *", monday(10, 46), false},
+		{"5/20 * * * *", monday(10, 45), true},
+		{"0 8-18/2 * * *", monday(12, 0), true},
+		{"0 8-18/2 * * *", monday(13, 0), false},
+		{"0 9 * * 1-5", monday(9, 0), true},
+		{"0 9 * * 0,6", monday(9, 0), false},
+		{"0 9 * * 7", time.Date(2020, 6, 7, 9, 0, 0, 0, time.UTC), true},
+		{"0 9 1 6 *", monday(9, 0), true},
+		{"0 9 1 7 *", monday(9, 0), false},
+		// Either of restricted day fields is enough
+		{"0 9 15 * 1", monday(9, 0), true},
+		{"0 9 15 * 2", monday(9, 0), false},
+	}
+	for _, tt := range tests {
+		expression, err := ParseExpression(tt.expression)
+		assert.NoError(t, err, tt.expression)
+		assert.Equal(t, tt.matches, expression.Matches(tt.time), "%s at %s", tt.expression, tt.time)
+	}
+}
+
+func TestParseExpression_Invalid(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+	} {
+		_, err := ParseExpression(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestExpression_Prev(t *testing.T) {
+	// 2020-06-01 is a Monday
+	now := time.Date(2020, 6, 1, 10, 30, 45, 0, time.UTC)
+	oldest := now.Add(-31 * 24 * time.Hour)
+
+	tests := []struct {
+		expression string
+		prev       time.Time
+		found      bool
+	}{
+		{"* * * * *", time.Date(2020, 6, 1, 10, 30, 0, 0, time.UTC), true},
+		{"0 9 * * *", time.Date(2020, 6, 1, 9, 0, 0, 0, time.UTC), true},
+		{"45 10 * * *", time.Date(2020, 5, 31, 10, 45, 0, 0, time.UTC), true},
+		{"*/20 * * * *", time.Date(2020, 6, 1, 10, 20, 0, 0, time.UTC), true},
+		{"0 18 * * 1-5", time.Date(2020, 5, 29, 18, 0, 0, 0, time.UTC), true},
+		{"0 9 15 * 1", time.Date(2020, 6, 1, 9, 0, 0, 0, time.UTC), true},
+		{"0 0 1 5 *", time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC), false},
+		{"0 0 1 1 *", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		expression, err := ParseExpression(tt.expression)
+		assert.NoError(t, err, tt.expression)
+		prev, found := expression.Prev(now, oldest)
+		assert.Equal(t, tt.found, found, tt.expression)
+		if tt.found {
+			assert.Equal(t, tt.prev, prev, tt.expression)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package schedule
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrWindowNotFound is returned when the requested window does not exist.
+var ErrWindowNotFound = errors.New("schedule window not found")
+
+// ServiceManager starts and drains service instances.
+type ServiceManager interface {
+	Start(providerID identity.Identity, serviceType string, policyIDs []string, options service.Options) (service.ID, error)
+	Drain(id service.ID, timeout time.Duration) error
+	Service(id service.ID) *service.Instance
+}
+
+// Scheduler starts and stops services following the configured availability windows.
+type Scheduler struct {
+	manager      ServiceManager
+	storage      Storage
+	parseOptions service.OptionsParser
+	gracePeriod  time.Duration
+	interval     time.Duration
+	now          func() time.Time
+
+	windows []Window
+	mu      sync.Mutex
+
+	// applyMu serializes calls to the service manager, which are made without holding mu.
+	running map[string]service.ID
+	applyMu sync.Mutex
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewScheduler creates a new scheduler.
+func NewScheduler(manager ServiceManager, storage Storage, parseOptions service.OptionsParser, gracePeriod time.Duration) *Scheduler {
+	return &Scheduler{
+		manager:      manager,
+		storage:      storage,
+		parseOptions: parseOptions,
+		gracePeriod:  gracePeriod,
+		interval:     time.Minute,
+		now:          time.Now,
+		running:      make(map[string]service.ID),
+		stop:         make(chan struct{}),
+	}
+}
+
+// Start loads the stored windows and keeps services in line with them until stopped.
+func (s *Scheduler) Start() error {
+	windows, err := s.storage.Load()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.windows = windows
+	s.mu.Unlock()
+	s.reconcile()
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.reconcile()
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops following the schedule. Running services are left untouched.
+func (s *Scheduler) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+}
+
+// List returns all schedule windows.
+func (s *Scheduler) List() []Window {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	windows := make([]Window, len(s.windows))
+	copy(windows, s.windows)
+	return windows
+}
+
+// Window returns the schedule window by its ID.
+func (s *Scheduler) Window(id string) (Window, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(id)
+	if i < 0 {
+		return Window{}, ErrWindowNotFound
+	}
+	return s.windows[i], nil
+}
+
+// Add validates, stores and applies a new schedule window.
+func (s *Scheduler) Add(window Window) (Window, error) {
+	if err := window.Validate(); err != nil {
+		return Window{}, err
+	}
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return Window{}, err
+	}
+	window.ID = uid.String()
+
+	s.mu.Lock()
+	err = s.save(append(s.windows, window))
+	s.mu.Unlock()
+	if err != nil {
+		return Window{}, err
+	}
+
+	s.reconcile()
+	return window, nil
+}
+
+// Update replaces the schedule window. A service started for the old window is drained.
+func (s *Scheduler) Update(id string, window Window) (Window, error) {
+	if err := window.Validate(); err != nil {
+		return Window{}, err
+	}
+	window.ID = id
+
+	s.mu.Lock()
+	i := s.indexOf(id)
+	if i < 0 {
+		s.mu.Unlock()
+		return Window{}, ErrWindowNotFound
+	}
+	previous := s.windows[i]
+	windows := make([]Window, len(s.windows))
+	copy(windows, s.windows)
+	windows[i] = window
+	err := s.save(windows)
+	s.mu.Unlock()
+	if err != nil {
+		return Window{}, err
+	}
+
+	s.applyMu.Lock()
+	s.drain(previous)
+	s.applyMu.Unlock()
+	s.reconcile()
+	return window, nil
+}
+
+// Delete removes the schedule window. A service started for it is drained.
+func (s *Scheduler) Delete(id string) error {
+	s.mu.Lock()
+	i := s.indexOf(id)
+	if i < 0 {
+		s.mu.Unlock()
+		return ErrWindowNotFound
+	}
+	window := s.windows[i]
+	windows := make([]Window, 0, len(s.windows)-1)
+	windows = append(windows, s.windows[:i]...)
+	windows = append(windows, s.windows[i+1:]...)
+	err := s.save(windows)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	s.applyMu.Lock()
+	s.drain(window)
+	s.applyMu.Unlock()
+	return nil
+}
+
+func (s *Scheduler) indexOf(id string) int {
+	for i := range s.windows {
+		if s.windows[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Scheduler) save(windows []Window) error {
+	if err := s.storage.Save(windows); err != nil {
+		return errors.Wrap(err, "could not save schedule")
+	}
+	s.windows = windows
+	return nil
+}
+
+// reconcile starts services of active windows and drains services of inactive ones.
+// The service manager is called without holding the lock of the windows.
+func (s *Scheduler) reconcile() {
+	s.applyMu.Lock()
+	defer s.applyMu.Unlock()
+
+	s.mu.Lock()
+	windows := make([]Window, len(s.windows))
+	copy(windows, s.windows)
+	s.mu.Unlock()
+
+	now := s.now()
+	for _, window := range windows {
+		if id, ok := s.running[window.ID]; ok && s.manager.Service(id) == nil {
+			delete(s.running, window.ID)
+		}
+
+		active, err := window.Active(now)
+		if err != nil {
+			log.Error().Err(err).Msgf("Invalid schedule window %s", window.ID)
+			continue
+		}
+
+		_, running := s.running[window.ID]
+		switch {
+		case active && !running:
+			s.start(window)
+		case !active && running:
+			s.drain(window)
+		}
+	}
+}
+
+func (s *Scheduler) start(window Window) {
+	// Services fall back to the configured options when none are given.
+	var raw *json.RawMessage
+	if window.Options != nil {
+		data, err := json.Marshal(window.Options)
+		if err != nil {
+			log.Error().Err(err).Msgf("Invalid options of schedule window %s", window.ID)
+			return
+		}
+		msg := json.RawMessage(data)
+		raw = &msg
+	}
+
+	options, err := s.parseOptions(window.ServiceType, raw)
+	if err != nil {
+		log.Error().Err(err).Msgf("Invalid options of schedule window %s", window.ID)
+		return
+	}
+
+	log.Info().Msgf("Starting scheduled %s service of %s", window.ServiceType, window.ProviderID)
+	id, err := s.manager.Start(identity.FromAddress(window.ProviderID), window.ServiceType, window.AccessPolicies, options)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to start scheduled service of window %s, will retry", window.ID)
+		return
+	}
+	s.running[window.ID] = id
+}
+
+func (s *Scheduler) drain(window Window) {
+	id, ok := s.running[window.ID]
+	if !ok {
+		return
+	}
+	delete(s.running, window.ID)
+
+	gracePeriod, err := window.gracePeriod(s.gracePeriod)
+	if err != nil {
+		gracePeriod = s.gracePeriod
+	}
+
+	log.Info().Msgf("Stopping scheduled %s service of %s in %s", window.ServiceType, window.ProviderID, gracePeriod)
+	if err := s.manager.Drain(id, gracePeriod); err != nil && err != service.ErrNoSuchInstance {
+		log.Error().Err(err).Msgf("Failed to drain scheduled service %s", id)
+	}
+}
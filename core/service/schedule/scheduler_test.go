@@ -0,0 +1,230 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockManager struct {
+	instances map[service.ID]*service.Instance
+	started   []string
+	drained   map[service.ID]time.Duration
+	startErr  error
+	onStart   func()
+}
+
+func newMockManager() *mockManager {
+	return &mockManager{
+		instances: make(map[service.ID]*service.Instance),
+		drained:   make(map[service.ID]time.Duration),
+	}
+}
+
+func (m *mockManager) Start(providerID identity.Identity, serviceType string, _ []string, _ service.Options) (service.ID, error) {
+	if m.startErr != nil {
+		return "", m.startErr
+	}
+	if m.onStart != nil {
+		m.onStart()
+	}
+	id := service.ID(providerID.Address + "-" + serviceType)
+	m.started = append(m.started, string(id))
+	m.instances[id] = &service.Instance{}
+	return id, nil
+}
+
+func (m *mockManager) Drain(id service.ID, timeout time.Duration) error {
+	m.drained[id] = timeout
+	return nil
+}
+
+func (m *mockManager) Service(id service.ID) *service.Instance {
+	return m.instances[id]
+}
+
+type mockStorage struct {
+	windows []Window
+	saveErr error
+}
+
+func (m *mockStorage) Load() ([]Window, error) {
+	return m.windows, nil
+}
+
+func (m *mockStorage) Save(windows []Window) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	m.windows = windows
+	return nil
+}
+
+func parseOptions(_ string, options *json.RawMessage) (service.Options, error) {
+	return options, nil
+}
+
+var businessHours = Window{
+	ProviderID:  "0x1",
+	ServiceType: "wireguard",
+	Start:       "0 9 * * *",
+	Stop:        "0 18 * * *",
+}
+
+func newTestScheduler(manager *mockManager, storage *mockStorage, now time.Time) *Scheduler {
+	scheduler := NewScheduler(manager, storage, parseOptions, time.Minute)
+	scheduler.now = func() time.Time { return now }
+	return scheduler
+}
+
+func TestScheduler_StartsActiveWindowsOnStart(t *testing.T) {
+	manager := newMockManager()
+	window := businessHours
+	window.ID = "w1"
+	storage := &mockStorage{windows: []Window{window}}
+	scheduler := newTestScheduler(manager, storage, time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	assert.NoError(t, scheduler.Start())
+	defer scheduler.Stop()
+
+	assert.Equal(t, []string{"0x1-wireguard"}, manager.started)
+	assert.Equal(t, []Window{window}, scheduler.List())
+}
+
+func TestScheduler_DrainsServiceWhenWindowEnds(t *testing.T) {
+	manager := newMockManager()
+	window := businessHours
+	window.ID = "w1"
+	window.GracePeriod = "10m"
+	scheduler := newTestScheduler(manager, &mockStorage{windows: []Window{window}}, time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC))
+	assert.NoError(t, scheduler.Start())
+	defer scheduler.Stop()
+
+	scheduler.now = func() time.Time { return time.Date(2020, 6, 1, 18, 0, 0, 0, time.UTC) }
+	scheduler.reconcile()
+
+	assert.Equal(t, map[service.ID]time.Duration{"0x1-wireguard": 10 * time.Minute}, manager.drained)
+
+	// Nothing is drained twice
+	manager.drained = make(map[service.ID]time.Duration)
+	scheduler.reconcile()
+	assert.Empty(t, manager.drained)
+}
+
+func TestScheduler_RetriesFailedStart(t *testing.T) {
+	manager := newMockManager()
+	manager.startErr = errors.New("identity locked")
+	scheduler := newTestScheduler(manager, &mockStorage{}, time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC))
+	assert.NoError(t, scheduler.Start())
+	defer scheduler.Stop()
+
+	_, err := scheduler.Add(businessHours)
+	assert.NoError(t, err)
+	assert.Empty(t, manager.started)
+
+	manager.startErr = nil
+	scheduler.reconcile()
+	assert.Equal(t, []string{"0x1-wireguard"}, manager.started)
+}
+
+func TestScheduler_DoesNotHoldLockWhileStartingService(t *testing.T) {
+	manager := newMockManager()
+	scheduler := newTestScheduler(manager, &mockStorage{}, time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC))
+	manager.onStart = func() { scheduler.List() }
+	assert.NoError(t, scheduler.Start())
+	defer scheduler.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := scheduler.Add(businessHours)
+		assert.NoError(t, err)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("scheduler deadlocked while starting service")
+	}
+	assert.Equal(t, []string{"0x1-wireguard"}, manager.started)
+}
+
+func TestScheduler_CRUD(t *testing.T) {
+	manager := newMockManager()
+	storage := &mockStorage{}
+	scheduler := newTestScheduler(manager, storage, time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC))
+	assert.NoError(t, scheduler.Start())
+	defer scheduler.Stop()
+
+	added, err := scheduler.Add(businessHours)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, added.ID)
+	assert.Equal(t, []Window{added}, storage.windows)
+	assert.Equal(t, []string{"0x1-wireguard"}, manager.started)
+
+	found, err := scheduler.Window(added.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, added, found)
+
+	update := businessHours
+	update.GracePeriod = "30s"
+	updated, err := scheduler.Update(added.ID, update)
+	assert.NoError(t, err)
+	assert.Equal(t, added.ID, updated.ID)
+	assert.Equal(t, []Window{updated}, storage.windows)
+	// Service is restarted with the new settings
+	assert.Equal(t, time.Minute, manager.drained["0x1-wireguard"])
+	assert.Len(t, manager.started, 2)
+
+	assert.NoError(t, scheduler.Delete(added.ID))
+	assert.Empty(t, storage.windows)
+	assert.Equal(t, 30*time.Second, manager.drained["0x1-wireguard"])
+
+	_, err = scheduler.Window(added.ID)
+	assert.Equal(t, ErrWindowNotFound, err)
+	assert.Equal(t, ErrWindowNotFound, scheduler.Delete(added.ID))
+	_, err = scheduler.Update(added.ID, update)
+	assert.Equal(t, ErrWindowNotFound, err)
+}
+
+func TestScheduler_AddRejectsInvalidWindow(t *testing.T) {
+	storage := &mockStorage{}
+	scheduler := newTestScheduler(newMockManager(), storage, time.Now())
+
+	_, err := scheduler.Add(Window{ProviderID: "0x1", ServiceType: "wireguard", Start: "whenever", Stop: "0 18 * * *"})
+	assert.Error(t, err)
+	assert.Empty(t, storage.windows)
+}
+
+func TestScheduler_AddKeepsStateWhenSaveFails(t *testing.T) {
+	manager := newMockManager()
+	storage := &mockStorage{saveErr: errors.New("read-only")}
+	scheduler := newTestScheduler(manager, storage, time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	_, err := scheduler.Add(businessHours)
+	assert.Error(t, err)
+	assert.Empty(t, scheduler.List())
+	assert.Empty(t, manager.started)
+}
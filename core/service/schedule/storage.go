@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package schedule
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// ConfigKey is the user configuration key under which schedule windows are stored.
+const ConfigKey = "schedule.windows"
+
+// Storage loads and saves schedule windows.
+type Storage interface {
+	Load() ([]Window, error)
+	Save(windows []Window) error
+}
+
+type userConfig interface {
+	Get(key string) interface{}
+	SetUser(key string, value interface{})
+	SaveUserConfig() error
+}
+
+// ConfigStorage keeps schedule windows in the user configuration file.
+type ConfigStorage struct {
+	config userConfig
+}
+
+// NewConfigStorage creates a storage backed by the given configuration.
+func NewConfigStorage(config userConfig) *ConfigStorage {
+	return &ConfigStorage{config: config}
+}
+
+// Load returns windows stored in the configuration.
+func (cs *ConfigStorage) Load() ([]Window, error) {
+	value := cs.config.Get(ConfigKey)
+	if value == nil {
+		return nil, nil
+	}
+
+	// Values read from the config file are generic maps, so round-trip them through JSON.
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read schedule from config")
+	}
+	var windows []Window
+	if err := json.Unmarshal(data, &windows); err != nil {
+		return nil, errors.Wrap(err, "could not read schedule from config")
+	}
+	return windows, nil
+}
+
+// Save replaces windows stored in the configuration and persists the config file.
+func (cs *ConfigStorage) Save(windows []Window) error {
+	data, err := json.Marshal(windows)
+	if err != nil {
+		return errors.Wrap(err, "could not write schedule to config")
+	}
+	value := make([]interface{}, 0, len(windows))
+	if err := json.Unmarshal(data, &value); err != nil {
+		return errors.Wrap(err, "could not write schedule to config")
+	}
+
+	cs.config.SetUser(ConfigKey, value)
+	return cs.config.SaveUserConfig()
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package schedule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockConfig struct {
+	values map[string]interface{}
+	saved  bool
+}
+
+func (m *mockConfig) Get(key string) interface{} {
+	return m.values[key]
+}
+
+func (m *mockConfig) SetUser(key string, value interface{}) {
+	m.values[key] = value
+}
+
+func (m *mockConfig) SaveUserConfig() error {
+	m.saved = true
+	return nil
+}
+
+func TestConfigStorage_SaveAndLoad(t *testing.T) {
+	config := &mockConfig{values: make(map[string]interface{})}
+	storage := NewConfigStorage(config)
+
+	windows, err := storage.Load()
+	assert.NoError(t, err)
+	assert.Empty(t, windows)
+
+	window := Window{
+		ID:             "w1",
+		ProviderID:     "0x1",
+		ServiceType:    "openvpn",
+		Options:        map[string]interface{}{"port": 1194.0},
+		AccessPolicies: []string{"mysterium"},
+		Start:          "0 9 * * 1-5",
+		Stop:           "0 18 * * 1-5",
+		GracePeriod:    "10m",
+	}
+	assert.NoError(t, storage.Save([]Window{window}))
+	assert.True(t, config.saved)
+
+	windows, err = storage.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []Window{window}, windows)
+}
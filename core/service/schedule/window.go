@@ -0,0 +1,96 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package schedule
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// lookback limits how far into the past window boundaries are searched for.
+const lookback = 31 * 24 * time.Hour
+
+// Window describes when a service should be available to consumers.
+// The service is started on the minutes matching Start and drained on the minutes matching Stop.
+type Window struct {
+	ID             string                 `json:"id"`
+	ProviderID     string                 `json:"provider_id"`
+	ServiceType    string                 `json:"type"`
+	Options        map[string]interface{} `json:"options,omitempty"`
+	AccessPolicies []string               `json:"access_policies,omitempty"`
+	Start          string                 `json:"start"`
+	Stop           string                 `json:"stop"`
+	GracePeriod    string                 `json:"grace_period,omitempty"`
+}
+
+// Validate checks if the window is well formed.
+func (w Window) Validate() error {
+	if w.ProviderID == "" {
+		return errors.New("provider is required")
+	}
+	if w.ServiceType == "" {
+		return errors.New("service type is required")
+	}
+	if _, err := ParseExpression(w.Start); err != nil {
+		return errors.Wrap(err, "invalid start")
+	}
+	if _, err := ParseExpression(w.Stop); err != nil {
+		return errors.Wrap(err, "invalid stop")
+	}
+	if _, err := w.gracePeriod(0); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Active checks if the service should be running at the given time,
+// i.e. the last start of the window is more recent than the last stop.
+func (w Window) Active(now time.Time) (bool, error) {
+	start, err := ParseExpression(w.Start)
+	if err != nil {
+		return false, err
+	}
+	stop, err := ParseExpression(w.Stop)
+	if err != nil {
+		return false, err
+	}
+
+	oldest := now.Add(-lookback)
+	lastStart, started := start.Prev(now, oldest)
+	if !started {
+		return false, nil
+	}
+	lastStop, stopped := stop.Prev(now, oldest)
+	// Stop wins when both fall on the same minute.
+	return !stopped || lastStart.After(lastStop), nil
+}
+
+func (w Window) gracePeriod(fallback time.Duration) (time.Duration, error) {
+	if w.GracePeriod == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(w.GracePeriod)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid grace period %q", w.GracePeriod)
+	}
+	if d < 0 {
+		return 0, errors.Errorf("grace period %q can not be negative", w.GracePeriod)
+	}
+	return d, nil
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindow_Active(t *testing.T) {
+	// Business hours on working days
+	window := Window{Start: "0 9 * * 1-5", Stop: "0 18 * * 1-5"}
+
+	tests := []struct {
+		time   time.Time
+		active bool
+	}{
+		{time.Date(2020, 6, 1, 8, 59, 0, 0, time.UTC), false},
+		{time.Date(2020, 6, 1, 9, 0, 0, 0, time.UTC), true},
+		{time.Date(2020, 6, 1, 17, 59, 0, 0, time.UTC), true},
+		{time.Date(2020, 6, 1, 18, 0, 0, 0, time.UTC), false},
+		{time.Date(2020, 6, 6, 12, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		active, err := window.Active(tt.time)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.active, active, tt.time.String())
+	}
+}
+
+func TestWindow_ActiveAcrossMidnight(t *testing.T) {
+	window := Window{Start: "0 22 * * *", Stop: "0 6 * * *"}
+
+	active, err := window.Active(time.Date(2020, 6, 2, 3, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.True(t, active)
+
+	active, err = window.Active(time.Date(2020, 6, 2, 12, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.False(t, active)
+}
+
+func TestWindow_Validate(t *testing.T) {
+	valid := Window{ProviderID: "0x1", ServiceType: "wireguard", Start: "0 9 * * *", Stop: "0 18 * * *", GracePeriod: "10m"}
+	assert.NoError(t, valid.Validate())
+
+	invalid := []func(w *Window){
+		func(w *Window) { w.ProviderID = "" },
+		func(w *Window) { w.ServiceType = "" },
+		func(w *Window) { w.Start = "0 25 * * *" },
+		func(w *Window) { w.Stop = "" },
+		func(w *Window) { w.GracePeriod = "soon" },
+		func(w *Window) { w.GracePeriod = "-1m" },
+	}
+	for i, modify := range invalid {
+		w := valid
+		modify(&w)
+		assert.Error(t, w.Validate(), "case %d", i)
+	}
+}
@@ -38,4 +38,6 @@ const (
 	Starting = State("Starting")
 	// Running means that fully established service exists
 	Running = State("Running")
+	// Draining means that service is unregistered and waits for active sessions to end before stopping
+	Draining = State("Draining")
 )
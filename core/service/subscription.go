@@ -20,6 +20,7 @@ package service
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"time"
 
 	"github.com/mysteriumnetwork/node/identity"
@@ -30,8 +31,13 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func subscribeSessionCreate(mng *session.Manager, ch p2p.Channel, service Service) {
+func subscribeSessionCreate(mng *session.Manager, ch p2p.Channel, service Service, instance *Instance) {
 	ch.Handle(p2p.TopicSessionCreate, func(c p2p.Context) error {
+		// Refuse early, before service resources are allocated for the session.
+		if instance.Draining() {
+			return c.ErrorWithCode(session.ErrorCodeServiceDraining, session.ErrorServiceDraining)
+		}
+
 		var sr pb.SessionRequest
 		if err := c.Request().UnmarshalProto(&sr); err != nil {
 			return err
@@ -39,6 +45,10 @@ func subscribeSessionCreate(mng *session.Manager, ch p2p.Channel, service Servic
 		log.Debug().Msgf("Received P2P message for %q: %s", p2p.TopicSessionCreate, sr.String())
 
 		consumerID := identity.FromAddress(sr.GetConsumer().GetId())
+		if err := mng.Admit(consumerID, peerIP(ch)); err != nil {
+			return c.ErrorWithCode(session.ErrorCode(err), err)
+		}
+
 		consumerConfig := sr.GetConfig()
 		consumerInfo := session.ConsumerInfo{
 			IssuerID:       consumerID,
@@ -47,24 +57,31 @@ func subscribeSessionCreate(mng *session.Manager, ch p2p.Channel, service Servic
 		}
 
 		paymentVersion := string(session.PaymentVersionV3)
-		session, err := session.NewSession()
+		sessionInstance, err := session.NewSession()
 		if err != nil {
 			return fmt.Errorf("cannot create new session: %w", err)
 		}
 
-		config, err := service.ProvideConfig(string(session.ID), consumerConfig, ch.ServiceConn())
+		config, err := service.ProvideConfig(string(sessionInstance.ID), consumerConfig, ch.ServiceConn())
 		if err != nil {
-			return fmt.Errorf("cannot get provider config for session %s: %w", string(session.ID), err)
+			return fmt.Errorf("cannot get provider config for session %s: %w", string(sessionInstance.ID), err)
 		}
 
-		err = mng.Start(session, consumerID, consumerInfo, int(sr.GetProposalID()), config, nil)
+		err = mng.Start(sessionInstance, consumerID, consumerInfo, int(sr.GetProposalID()), config, nil)
 		if err != nil {
-			return fmt.Errorf("cannot start session %s: %w", string(session.ID), err)
+			if config.SessionDestroyCallback != nil {
+				config.SessionDestroyCallback()
+			}
+			// Concurrent sessions limit is checked again on start, consumer should see it the same way as on admission.
+			if code := session.ErrorCode(err); code != "" {
+				return c.ErrorWithCode(code, err)
+			}
+			return fmt.Errorf("cannot start session %s: %w", string(sessionInstance.ID), err)
 		}
 
 		if config.SessionDestroyCallback != nil {
 			go func() {
-				<-session.Done()
+				<-sessionInstance.Done()
 				config.SessionDestroyCallback()
 				ch.Close()
 			}()
@@ -72,11 +89,11 @@ func subscribeSessionCreate(mng *session.Manager, ch p2p.Channel, service Servic
 
 		data, err := json.Marshal(config.SessionServiceConfig)
 		if err != nil {
-			return fmt.Errorf("cannot pack session %s service config: %w", string(session.ID), err)
+			return fmt.Errorf("cannot pack session %s service config: %w", string(sessionInstance.ID), err)
 		}
 
 		pc := p2p.ProtoMessage(&pb.SessionResponse{
-			ID:          string(session.ID),
+			ID:          string(sessionInstance.ID),
 			PaymentInfo: paymentVersion,
 			Config:      data,
 		})
@@ -85,6 +102,19 @@ func subscribeSessionCreate(mng *session.Manager, ch p2p.Channel, service Servic
 	})
 }
 
+// peerIP returns the public IP of the consumer the channel is established with.
+func peerIP(ch p2p.Channel) net.IP {
+	conn := ch.ServiceConn()
+	if conn == nil {
+		return nil
+	}
+	addr, ok := conn.RemoteAddr().(*net.UDPAddr)
+	if !ok {
+		return nil
+	}
+	return addr.IP
+}
+
 func subscribeSessionStatus(mng *session.Manager, ch p2p.ChannelHandler, statusStorage connectivity.StatusStorage) {
 	ch.Handle(p2p.TopicSessionStatus, func(c p2p.Context) error {
 		var ss pb.SessionStatus
@@ -17,19 +17,65 @@
 
 package shaper
 
+import (
+	"net"
+
+	"github.com/mysteriumnetwork/node/config"
+)
+
 // Shaper shapes traffic on a network interface.
 type Shaper interface {
 	// Start applies shaping configuration on the specified interface and then continuously ensures it.
 	Start(interfaceName string) error
+	// AddSession applies per session limits to the traffic of a consumer with the given tunnel IP.
+	// Interface which was not started is considered to be a tunnel of a single session, service limits are shared by all such tunnels.
+	AddSession(interfaceName string, ip net.IP) error
+	// RemoveSession removes per session limits of a consumer with the given tunnel IP.
+	RemoveSession(interfaceName string, ip net.IP)
 	// Clear clears shaping rules.
 	Clear(interfaceName string)
+	// Stop stops following configuration changes.
+	Stop()
+}
+
+// Limits describes bandwidth limits in Kbps, zero value means unlimited.
+type Limits struct {
+	UplinkKbps   int `json:"uplink_kbps"`
+	DownlinkKbps int `json:"downlink_kbps"`
+}
+
+// Options describes traffic shaping of a service instance.
+type Options struct {
+	// Enabled turns shaping on.
+	Enabled bool `json:"enabled"`
+	// Service limits total bandwidth of the tunnel interface.
+	Service Limits `json:"service"`
+	// Session limits bandwidth of every consumer session.
+	Session Limits `json:"session"`
+}
+
+// ConfiguredOptions returns effective shaping options from application configuration.
+func ConfiguredOptions() Options {
+	return Options{
+		Enabled: config.GetBool(config.FlagShaperEnabled),
+		Service: Limits{
+			UplinkKbps:   config.GetInt(config.FlagShaperUplink),
+			DownlinkKbps: config.GetInt(config.FlagShaperDownlink),
+		},
+		Session: Limits{
+			UplinkKbps:   config.GetInt(config.FlagShaperSessionUplink),
+			DownlinkKbps: config.GetInt(config.FlagShaperSessionDownlink),
+		},
+	}
 }
 
 type eventListener interface {
 	SubscribeAsync(topic string, fn interface{}) error
+	Unsubscribe(topic string, fn interface{}) error
 }
 
 // New creates a traffic shaper (linux) or no-op.
-func New(listener eventListener) (shaper Shaper) {
-	return create(listener)
+// Options are the initial limits, they are updated once the related configuration changes at runtime.
+func New(listener eventListener, options Options) (shaper Shaper) {
+	return create(listener, options)
 }
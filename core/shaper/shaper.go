@@ -20,6 +20,8 @@
 package shaper
 
 import (
+	"net"
+
 	"github.com/mysteriumnetwork/node/config"
 	"github.com/rs/zerolog/log"
 )
@@ -28,7 +30,7 @@ import (
 type noopShaper struct {
 }
 
-func create(_ eventListener) *noopShaper {
+func create(_ eventListener, _ Options) *noopShaper {
 	return &noopShaper{}
 }
 
@@ -40,6 +42,19 @@ func (noopShaper) Start(_ string) error {
 	return nil
 }
 
+// AddSession noop
+func (noopShaper) AddSession(_ string, _ net.IP) error {
+	return nil
+}
+
+// RemoveSession noop
+func (noopShaper) RemoveSession(_ string, _ net.IP) {
+}
+
 // Clear noop
 func (noopShaper) Clear(_ string) {
 }
+
+// Stop noop
+func (noopShaper) Stop() {
+}
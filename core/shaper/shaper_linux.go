@@ -18,60 +18,439 @@
 package shaper
 
 import (
-	"github.com/mysteriumnetwork/go-wondershaper/wondershaper"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+
 	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/utils/cmdutil"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
 
-const limitKbps = 5000
+const (
+	// unlimitedRate is used for the parent class when the service downlink is not limited.
+	unlimitedRate = "10gbit"
+	// firstSessionID is the minor of the first session class, lower ones are reserved for the service classes.
+	firstSessionID = 0x10
+	// servicePrio is the priority of the service wide uplink filter, it is evaluated after the session filters.
+	servicePrio = 0xfff0
+	// sharedDownlinkPrio is the priority of the service wide downlink filter on a session tunnel, it is evaluated before the session filters.
+	sharedDownlinkPrio = 1
+)
+
+// lastPolicerIndex is used to allocate indexes of the police actions shared by session tunnels, tc actions are not bound to an interface.
+var lastPolicerIndex uint32 = 0x10000
+
+// Exec runs tc with the given args.
+var Exec = defaultExec
+
+func defaultExec(args ...string) error {
+	return cmdutil.SudoExec(append([]string{"tc"}, args...)...)
+}
 
 type linuxShaper struct {
-	ws          *wondershaper.Shaper
-	listener    eventListener
-	listenTopic string
+	listener      eventListener
+	subscribeOnce sync.Once
+	subscribeErr  error
+	handlers      map[string]func(interface{})
+
+	mu      sync.Mutex
+	options Options
+	// configured holds the globally configured options, options of the service follow them unless overridden.
+	configured Options
+	interfaces map[string]*shapedInterface
+
+	// uplinkPolicer and downlinkPolicer are indexes of the police actions enforcing service limits on all session tunnels together.
+	uplinkPolicer   uint32
+	downlinkPolicer uint32
 }
 
-func create(listener eventListener) *linuxShaper {
-	ws := wondershaper.New()
-	ws.Stdout = log.Logger
-	ws.Stderr = log.Logger
+// shapedInterface holds tc classes of sessions on the interface, keyed by the consumer tunnel IP.
+type shapedInterface struct {
+	sessions map[string]int
+	nextID   int
+	// sessionOnly marks a tunnel of a single session, service limits are shared with the other session tunnels.
+	sessionOnly bool
+}
+
+func newShapedInterface() *shapedInterface {
+	return &shapedInterface{
+		sessions: make(map[string]int),
+		nextID:   firstSessionID,
+	}
+}
+
+func create(listener eventListener, options Options) *linuxShaper {
 	return &linuxShaper{
-		ws:          ws,
-		listener:    listener,
-		listenTopic: config.AppTopicConfig(config.FlagShaperEnabled.Name),
+		listener:        listener,
+		handlers:        make(map[string]func(interface{})),
+		options:         options,
+		configured:      ConfiguredOptions(),
+		interfaces:      make(map[string]*shapedInterface),
+		uplinkPolicer:   atomic.AddUint32(&lastPolicerIndex, 1),
+		downlinkPolicer: atomic.AddUint32(&lastPolicerIndex, 1),
 	}
 }
 
 // Start applies shaping configuration on the specified interface and then continuously ensures it.
 func (s *linuxShaper) Start(interfaceName string) error {
-	applyLimits := func() error {
-		s.ws.Clear(interfaceName)
-
-		if config.GetBool(config.FlagShaperEnabled) {
-			err := s.ws.LimitDownlink(interfaceName, limitKbps)
-			if err != nil {
-				log.Error().Err(err).Msg("Could not limit download speed")
-				return err
+	if err := s.subscribe(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	iface, ok := s.interfaces[interfaceName]
+	if !ok {
+		iface = newShapedInterface()
+		s.interfaces[interfaceName] = iface
+	}
+	iface.sessionOnly = false
+	return s.apply(interfaceName)
+}
+
+// subscribe reapplies rules of all shaped interfaces once the related configuration changes.
+// Options overridden by the service are kept.
+func (s *linuxShaper) subscribe() error {
+	s.subscribeOnce.Do(func() {
+		updates := map[string]func(o *Options){
+			config.FlagShaperEnabled.Name: func(o *Options) {
+				o.Enabled = config.GetBool(config.FlagShaperEnabled)
+			},
+			config.FlagShaperUplink.Name: func(o *Options) {
+				o.Service.UplinkKbps = config.GetInt(config.FlagShaperUplink)
+			},
+			config.FlagShaperDownlink.Name: func(o *Options) {
+				o.Service.DownlinkKbps = config.GetInt(config.FlagShaperDownlink)
+			},
+			config.FlagShaperSessionUplink.Name: func(o *Options) {
+				o.Session.UplinkKbps = config.GetInt(config.FlagShaperSessionUplink)
+			},
+			config.FlagShaperSessionDownlink.Name: func(o *Options) {
+				o.Session.DownlinkKbps = config.GetInt(config.FlagShaperSessionDownlink)
+			},
+		}
+
+		for key, update := range updates {
+			topic := config.AppTopicConfig(key)
+			update := update
+			handler := func(_ interface{}) {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+
+				previous := s.configured
+				update(&s.configured)
+				s.options = followConfigured(s.options, previous, s.configured)
+				for interfaceName := range s.interfaces {
+					if err := s.apply(interfaceName); err != nil {
+						log.Error().Err(err).Msgf("Could not apply updated traffic shaping limits on %s", interfaceName)
+					}
+				}
 			}
-			err = s.ws.LimitUplink(interfaceName, limitKbps)
-			if err != nil {
-				log.Error().Err(err).Msg("Could not limit upload speed")
-				return err
+			if err := s.listener.SubscribeAsync(topic, handler); err != nil {
+				s.subscribeErr = errors.Wrap(err, "could not subscribe to topic: "+topic)
+				return
 			}
+
+			s.mu.Lock()
+			s.handlers[topic] = handler
+			s.mu.Unlock()
 		}
+	})
+	return s.subscribeErr
+}
+
+// Stop stops following configuration changes.
+func (s *linuxShaper) Stop() {
+	// Prevents subscribing after the shaper is stopped.
+	s.subscribeOnce.Do(func() {})
+
+	s.mu.Lock()
+	handlers := s.handlers
+	s.handlers = make(map[string]func(interface{}))
+	s.mu.Unlock()
+
+	for topic, handler := range handlers {
+		if err := s.listener.Unsubscribe(topic, handler); err != nil {
+			log.Warn().Err(err).Msg("Could not unsubscribe from topic: " + topic)
+		}
+	}
+}
+
+// followConfigured applies changes of the configured options to the options which were not overridden.
+func followConfigured(options, previous, configured Options) Options {
+	if options.Enabled == previous.Enabled {
+		options.Enabled = configured.Enabled
+	}
+	options.Service = followConfiguredLimits(options.Service, previous.Service, configured.Service)
+	options.Session = followConfiguredLimits(options.Session, previous.Session, configured.Session)
+	return options
+}
+
+func followConfiguredLimits(limits, previous, configured Limits) Limits {
+	if limits.UplinkKbps == previous.UplinkKbps {
+		limits.UplinkKbps = configured.UplinkKbps
+	}
+	if limits.DownlinkKbps == previous.DownlinkKbps {
+		limits.DownlinkKbps = configured.DownlinkKbps
+	}
+	return limits
+}
+
+// AddSession applies per session limits to the traffic of a consumer with the given tunnel IP.
+// Interface which was not started is considered to be a tunnel of a single session, service limits are shared by all such tunnels.
+func (s *linuxShaper) AddSession(interfaceName string, ip net.IP) error {
+	if err := s.subscribe(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	iface, ok := s.interfaces[interfaceName]
+	if !ok {
+		iface = newShapedInterface()
+		iface.sessionOnly = true
+		s.interfaces[interfaceName] = iface
+		if err := s.apply(interfaceName); err != nil {
+			return err
+		}
+	}
+	if _, ok := iface.sessions[ip.String()]; ok {
 		return nil
 	}
 
-	err := s.listener.SubscribeAsync(s.listenTopic, applyLimits)
-	if err != nil {
-		return errors.Wrap(err, "could not subscribe to topic: "+s.listenTopic)
+	id := iface.nextID
+	iface.nextID++
+	iface.sessions[ip.String()] = id
+
+	if !s.options.Enabled {
+		return nil
+	}
+	return s.addSessionRules(interfaceName, iface, ip.String(), id)
+}
+
+// RemoveSession removes per session limits of a consumer with the given tunnel IP.
+func (s *linuxShaper) RemoveSession(interfaceName string, ip net.IP) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	iface, ok := s.interfaces[interfaceName]
+	if !ok {
+		return
 	}
+	id, ok := iface.sessions[ip.String()]
+	if !ok {
+		return
+	}
+	delete(iface.sessions, ip.String())
 
-	return applyLimits()
+	if s.options.Enabled {
+		s.removeSessionRules(interfaceName, id)
+	}
 }
 
 // Clear clears shaping rules.
 func (s *linuxShaper) Clear(interfaceName string) {
-	s.ws.Clear(interfaceName)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	iface, ok := s.interfaces[interfaceName]
+	delete(s.interfaces, interfaceName)
+	clearRules(interfaceName)
+
+	if ok && iface.sessionOnly && !s.hasSessionTunnels() {
+		// Errors are expected when the policers were not created.
+		_ = Exec("actions", "del", "action", "police", "index", fmt.Sprint(s.uplinkPolicer))
+		_ = Exec("actions", "del", "action", "police", "index", fmt.Sprint(s.downlinkPolicer))
+	}
+}
+
+func (s *linuxShaper) hasSessionTunnels() bool {
+	for _, iface := range s.interfaces {
+		if iface.sessionOnly {
+			return true
+		}
+	}
+	return false
+}
+
+// apply rebuilds all shaping rules of the interface from the current options.
+// Downlink is shaped with htb classes on the egress of the tunnel interface,
+// uplink is policed on its ingress.
+// Session tunnels are policed by the actions shared among them, so service limits apply to all of them together.
+func (s *linuxShaper) apply(interfaceName string) error {
+	clearRules(interfaceName)
+
+	iface, ok := s.interfaces[interfaceName]
+	if !ok || !s.options.Enabled {
+		return nil
+	}
+
+	service, shared := s.options.Service, Limits{}
+	if iface.sessionOnly {
+		service, shared = Limits{}, s.options.Service
+	}
+	if service.DownlinkKbps > 0 || shared.DownlinkKbps > 0 || s.options.Session.DownlinkKbps > 0 {
+		rate := unlimitedRate
+		if service.DownlinkKbps > 0 {
+			rate = kbit(service.DownlinkKbps)
+		}
+		err := execAll(
+			[]string{"qdisc", "add", "dev", interfaceName, "root", "handle", "1:", "htb", "default", "2"},
+			[]string{"class", "add", "dev", interfaceName, "parent", "1:", "classid", "1:1", "htb", "rate", rate},
+			[]string{"class", "add", "dev", interfaceName, "parent", "1:1", "classid", "1:2", "htb", "rate", rate, "ceil", rate},
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Could not limit download speed")
+			return err
+		}
+	}
+	if shared.DownlinkKbps > 0 {
+		// Conforming packets continue to the session filters.
+		err := execAll(
+			[]string{"actions", "replace", "action", "police", "rate", kbit(shared.DownlinkKbps), "burst", burst(shared.DownlinkKbps),
+				"conform-exceed", "drop/continue", "index", fmt.Sprint(s.downlinkPolicer)},
+			[]string{"filter", "add", "dev", interfaceName, "parent", "1:", "protocol", "all", "prio", fmt.Sprint(sharedDownlinkPrio),
+				"u32", "match", "u32", "0", "0", "action", "police", "index", fmt.Sprint(s.downlinkPolicer)},
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Could not limit download speed")
+			return err
+		}
+	}
+
+	if service.UplinkKbps > 0 || shared.UplinkKbps > 0 || s.options.Session.UplinkKbps > 0 {
+		if err := Exec("qdisc", "add", "dev", interfaceName, "handle", "ffff:", "ingress"); err != nil {
+			log.Error().Err(err).Msg("Could not limit upload speed")
+			return err
+		}
+	}
+	if service.UplinkKbps > 0 {
+		err := Exec("filter", "add", "dev", interfaceName, "parent", "ffff:", "protocol", "all", "prio", fmt.Sprint(servicePrio),
+			"u32", "match", "u32", "0", "0",
+			"police", "rate", kbit(service.UplinkKbps), "burst", burst(service.UplinkKbps), "drop", "flowid", ":1")
+		if err != nil {
+			log.Error().Err(err).Msg("Could not limit upload speed")
+			return err
+		}
+	}
+	if shared.UplinkKbps > 0 {
+		err := execAll(
+			[]string{"actions", "replace", "action", "police", "rate", kbit(shared.UplinkKbps), "burst", burst(shared.UplinkKbps),
+				"conform-exceed", "drop/ok", "index", fmt.Sprint(s.uplinkPolicer)},
+			[]string{"filter", "add", "dev", interfaceName, "parent", "ffff:", "protocol", "all", "prio", fmt.Sprint(servicePrio),
+				"u32", "match", "u32", "0", "0", "flowid", ":1", "action", "police", "index", fmt.Sprint(s.uplinkPolicer)},
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Could not limit upload speed")
+			return err
+		}
+	}
+
+	for ip, id := range iface.sessions {
+		if err := s.addSessionRules(interfaceName, iface, ip, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *linuxShaper) addSessionRules(interfaceName string, iface *shapedInterface, ip string, id int) error {
+	session := s.options.Session
+	classID := fmt.Sprintf("1:%x", id)
+	prio := fmt.Sprint(id)
+
+	// Tunnel of a single session carries only its traffic, IPv6 included.
+	sessionIP := net.ParseIP(ip)
+	if iface.sessionOnly {
+		sessionIP = nil
+	}
+
+	if session.DownlinkKbps > 0 {
+		rate := kbit(session.DownlinkKbps)
+		protocol, match := selector(sessionIP, "dst")
+		err := execAll(
+			[]string{"class", "add", "dev", interfaceName, "parent", "1:1", "classid", classID, "htb", "rate", rate, "ceil", rate},
+			append(append([]string{"filter", "add", "dev", interfaceName, "parent", "1:", "protocol", protocol, "prio", prio,
+				"u32", "match"}, match...), "flowid", classID),
+		)
+		if err != nil {
+			return errors.Wrapf(err, "could not limit download speed of session %s", ip)
+		}
+	}
+
+	if session.UplinkKbps > 0 {
+		// Conforming packets continue to the service wide filter, so both limits are enforced.
+		protocol, match := selector(sessionIP, "src")
+		args := append([]string{"filter", "add", "dev", interfaceName, "parent", "ffff:", "protocol", protocol, "prio", prio,
+			"u32", "match"}, match...)
+		err := Exec(append(args,
+			"police", "rate", kbit(session.UplinkKbps), "burst", burst(session.UplinkKbps), "conform-exceed", "drop/continue",
+			"flowid", ":1")...)
+		if err != nil {
+			return errors.Wrapf(err, "could not limit upload speed of session %s", ip)
+		}
+	}
+	return nil
+}
+
+// selector returns the filter protocol and u32 match of packets from ("src") or to ("dst") the given IP, all packets are matched when IP is nil.
+func selector(ip net.IP, direction string) (protocol string, match []string) {
+	switch {
+	case ip == nil:
+		return "all", []string{"u32", "0", "0"}
+	case ip.To4() != nil:
+		return "ip", []string{"ip", direction, ip.String() + "/32"}
+	default:
+		return "ipv6", []string{"ip6", direction, ip.String() + "/128"}
+	}
+}
+
+func (s *linuxShaper) removeSessionRules(interfaceName string, id int) {
+	prio := fmt.Sprint(id)
+	if s.options.Session.DownlinkKbps > 0 {
+		logExecErr(Exec("filter", "del", "dev", interfaceName, "parent", "1:", "prio", prio))
+		logExecErr(Exec("class", "del", "dev", interfaceName, "classid", fmt.Sprintf("1:%x", id)))
+	}
+	if s.options.Session.UplinkKbps > 0 {
+		logExecErr(Exec("filter", "del", "dev", interfaceName, "parent", "ffff:", "prio", prio))
+	}
+}
+
+func clearRules(interfaceName string) {
+	// Errors are expected when there are no rules to remove.
+	_ = Exec("qdisc", "del", "dev", interfaceName, "root")
+	_ = Exec("qdisc", "del", "dev", interfaceName, "ingress")
+}
+
+func execAll(commands ...[]string) error {
+	for _, args := range commands {
+		if err := Exec(args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func logExecErr(err error) {
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not remove traffic shaping rule")
+	}
+}
+
+func kbit(kbps int) string {
+	return fmt.Sprintf("%dkbit", kbps)
+}
+
+// burst allows up to 100ms of traffic at the given rate, but not less than 10KB.
+func burst(kbps int) string {
+	kb := kbps / 80
+	if kb < 10 {
+		kb = 10
+	}
+	return fmt.Sprintf("%dk", kb)
 }
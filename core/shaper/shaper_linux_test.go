@@ -0,0 +1,271 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package shaper
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockListener struct {
+	topics       []string
+	handlers     map[string]func(interface{})
+	unsubscribed []string
+}
+
+func (l *mockListener) SubscribeAsync(topic string, fn interface{}) error {
+	l.topics = append(l.topics, topic)
+	if l.handlers == nil {
+		l.handlers = make(map[string]func(interface{}))
+	}
+	l.handlers[topic] = fn.(func(interface{}))
+	return nil
+}
+
+func (l *mockListener) Unsubscribe(topic string, _ interface{}) error {
+	l.unsubscribed = append(l.unsubscribed, topic)
+	return nil
+}
+
+type execRecorder struct {
+	commands []string
+}
+
+func (r *execRecorder) exec(args ...string) error {
+	r.commands = append(r.commands, strings.Join(args, " "))
+	return nil
+}
+
+func (r *execRecorder) reset() {
+	r.commands = nil
+}
+
+func mockExec() *execRecorder {
+	recorder := &execRecorder{}
+	Exec = recorder.exec
+	return recorder
+}
+
+func restoreExec() {
+	Exec = defaultExec
+}
+
+func Test_Start_AppliesServiceLimits(t *testing.T) {
+	recorder := mockExec()
+	defer restoreExec()
+	s := create(&mockListener{}, Options{
+		Enabled: true,
+		Service: Limits{UplinkKbps: 1000, DownlinkKbps: 2000},
+	})
+
+	err := s.Start("tun0")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"qdisc del dev tun0 root",
+		"qdisc del dev tun0 ingress",
+		"qdisc add dev tun0 root handle 1: htb default 2",
+		"class add dev tun0 parent 1: classid 1:1 htb rate 2000kbit",
+		"class add dev tun0 parent 1:1 classid 1:2 htb rate 2000kbit ceil 2000kbit",
+		"qdisc add dev tun0 handle ffff: ingress",
+		"filter add dev tun0 parent ffff: protocol all prio 65520 u32 match u32 0 0 police rate 1000kbit burst 12k drop flowid :1",
+	}, recorder.commands)
+}
+
+func Test_Start_SkipsRulesWhenDisabled(t *testing.T) {
+	recorder := mockExec()
+	defer restoreExec()
+	s := create(&mockListener{}, Options{
+		Service: Limits{UplinkKbps: 1000, DownlinkKbps: 2000},
+	})
+
+	err := s.Start("tun0")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"qdisc del dev tun0 root",
+		"qdisc del dev tun0 ingress",
+	}, recorder.commands)
+}
+
+func Test_AddSession_AppliesSessionLimits(t *testing.T) {
+	recorder := mockExec()
+	defer restoreExec()
+	s := create(&mockListener{}, Options{
+		Enabled: true,
+		Session: Limits{UplinkKbps: 500, DownlinkKbps: 800},
+	})
+	assert.NoError(t, s.Start("tun0"))
+	recorder.reset()
+
+	err := s.AddSession("tun0", net.ParseIP("10.8.0.2"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"class add dev tun0 parent 1:1 classid 1:10 htb rate 800kbit ceil 800kbit",
+		"filter add dev tun0 parent 1: protocol ip prio 16 u32 match ip dst 10.8.0.2/32 flowid 1:10",
+		"filter add dev tun0 parent ffff: protocol ip prio 16 u32 match ip src 10.8.0.2/32 police rate 500kbit burst 10k conform-exceed drop/continue flowid :1",
+	}, recorder.commands)
+
+	recorder.reset()
+	s.RemoveSession("tun0", net.ParseIP("10.8.0.2"))
+
+	assert.Equal(t, []string{
+		"filter del dev tun0 parent 1: prio 16",
+		"class del dev tun0 classid 1:10",
+		"filter del dev tun0 parent ffff: prio 16",
+	}, recorder.commands)
+}
+
+func Test_Start_SubscribesToConfigChanges(t *testing.T) {
+	mockExec()
+	defer restoreExec()
+	listener := &mockListener{}
+	s := create(listener, Options{})
+
+	assert.NoError(t, s.Start("tun0"))
+	assert.NoError(t, s.Start("tun1"))
+
+	assert.ElementsMatch(t, []string{
+		"config:shaper.enabled",
+		"config:shaper.uplink",
+		"config:shaper.downlink",
+		"config:shaper.session.uplink",
+		"config:shaper.session.downlink",
+	}, listener.topics)
+}
+
+func Test_Stop_UnsubscribesFromConfigChanges(t *testing.T) {
+	mockExec()
+	defer restoreExec()
+	listener := &mockListener{}
+	s := create(listener, Options{})
+	assert.NoError(t, s.Start("tun0"))
+
+	s.Stop()
+	assert.NoError(t, s.Start("tun0"))
+
+	assert.ElementsMatch(t, listener.topics, listener.unsubscribed)
+	assert.Len(t, listener.topics, 5)
+}
+
+func Test_ConfigChange_KeepsOverriddenOptions(t *testing.T) {
+	recorder := mockExec()
+	defer restoreExec()
+	config.Current.SetDefault(config.FlagShaperEnabled.Name, true)
+	defer config.Current.SetDefault(config.FlagShaperEnabled.Name, false)
+	listener := &mockListener{}
+	s := create(listener, Options{
+		Enabled: true,
+		Service: Limits{UplinkKbps: 1000},
+	})
+	assert.NoError(t, s.Start("tun0"))
+
+	config.Current.SetUser(config.FlagShaperUplink.Name, 3000)
+	config.Current.SetUser(config.FlagShaperSessionDownlink.Name, 800)
+	defer config.Current.RemoveUser(config.FlagShaperUplink.Name)
+	defer config.Current.RemoveUser(config.FlagShaperSessionDownlink.Name)
+	listener.handlers["config:shaper.uplink"](3000)
+	recorder.reset()
+	listener.handlers["config:shaper.session.downlink"](800)
+
+	assert.Equal(t, Options{
+		Enabled: true,
+		Service: Limits{UplinkKbps: 1000},
+		Session: Limits{DownlinkKbps: 800},
+	}, s.options)
+	assert.Contains(t, recorder.commands, "filter add dev tun0 parent ffff: protocol all prio 65520 u32 match u32 0 0 police rate 1000kbit burst 12k drop flowid :1")
+}
+
+func Test_AddSession_SharesServiceLimitsAmongSessionInterfaces(t *testing.T) {
+	recorder := mockExec()
+	defer restoreExec()
+	s := create(&mockListener{}, Options{
+		Enabled: true,
+		Service: Limits{UplinkKbps: 1000, DownlinkKbps: 2000},
+		Session: Limits{DownlinkKbps: 800},
+	})
+	s.uplinkPolicer, s.downlinkPolicer = 7, 8
+
+	assert.NoError(t, s.AddSession("wg0", net.ParseIP("10.182.0.2")))
+	assert.NoError(t, s.AddSession("wg1", net.ParseIP("10.182.0.6")))
+
+	assert.Equal(t, []string{
+		"qdisc del dev wg0 root",
+		"qdisc del dev wg0 ingress",
+		"qdisc add dev wg0 root handle 1: htb default 2",
+		"class add dev wg0 parent 1: classid 1:1 htb rate 10gbit",
+		"class add dev wg0 parent 1:1 classid 1:2 htb rate 10gbit ceil 10gbit",
+		"actions replace action police rate 2000kbit burst 25k conform-exceed drop/continue index 8",
+		"filter add dev wg0 parent 1: protocol all prio 1 u32 match u32 0 0 action police index 8",
+		"qdisc add dev wg0 handle ffff: ingress",
+		"actions replace action police rate 1000kbit burst 12k conform-exceed drop/ok index 7",
+		"filter add dev wg0 parent ffff: protocol all prio 65520 u32 match u32 0 0 flowid :1 action police index 7",
+		"class add dev wg0 parent 1:1 classid 1:10 htb rate 800kbit ceil 800kbit",
+		"filter add dev wg0 parent 1: protocol all prio 16 u32 match u32 0 0 flowid 1:10",
+		"qdisc del dev wg1 root",
+		"qdisc del dev wg1 ingress",
+		"qdisc add dev wg1 root handle 1: htb default 2",
+		"class add dev wg1 parent 1: classid 1:1 htb rate 10gbit",
+		"class add dev wg1 parent 1:1 classid 1:2 htb rate 10gbit ceil 10gbit",
+		"actions replace action police rate 2000kbit burst 25k conform-exceed drop/continue index 8",
+		"filter add dev wg1 parent 1: protocol all prio 1 u32 match u32 0 0 action police index 8",
+		"qdisc add dev wg1 handle ffff: ingress",
+		"actions replace action police rate 1000kbit burst 12k conform-exceed drop/ok index 7",
+		"filter add dev wg1 parent ffff: protocol all prio 65520 u32 match u32 0 0 flowid :1 action police index 7",
+		"class add dev wg1 parent 1:1 classid 1:10 htb rate 800kbit ceil 800kbit",
+		"filter add dev wg1 parent 1: protocol all prio 16 u32 match u32 0 0 flowid 1:10",
+	}, recorder.commands)
+
+	recorder.reset()
+	s.Clear("wg0")
+	s.Clear("wg1")
+
+	assert.Equal(t, []string{
+		"qdisc del dev wg0 root",
+		"qdisc del dev wg0 ingress",
+		"qdisc del dev wg1 root",
+		"qdisc del dev wg1 ingress",
+		"actions del action police index 7",
+		"actions del action police index 8",
+	}, recorder.commands)
+}
+
+func Test_AddSession_AppliesSessionLimitsOnIPv6(t *testing.T) {
+	recorder := mockExec()
+	defer restoreExec()
+	s := create(&mockListener{}, Options{
+		Enabled: true,
+		Session: Limits{UplinkKbps: 500, DownlinkKbps: 800},
+	})
+	assert.NoError(t, s.Start("tun0"))
+	recorder.reset()
+
+	err := s.AddSession("tun0", net.ParseIP("fd00::2"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"class add dev tun0 parent 1:1 classid 1:10 htb rate 800kbit ceil 800kbit",
+		"filter add dev tun0 parent 1: protocol ipv6 prio 16 u32 match ip6 dst fd00::2/128 flowid 1:10",
+		"filter add dev tun0 parent ffff: protocol ipv6 prio 16 u32 match ip6 src fd00::2/128 police rate 500kbit burst 10k conform-exceed drop/continue flowid :1",
+	}, recorder.commands)
+}
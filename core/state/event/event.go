@@ -114,4 +114,7 @@ type ServiceSession struct {
 	ServiceType string `json:"service_type"`
 	// example: 500000
 	TokensEarned uint64 `json:"tokens_earned"`
+	// number of DNS queries blocked by the provider blocklist
+	// example: 42
+	DNSBlocked uint64 `json:"dns_blocked"`
 }
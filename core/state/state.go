@@ -325,6 +325,7 @@ func (k *Keeper) updateSessionState(e interface{}) {
 			BytesOut:     sessions[i].DataTransferred.Up,
 			BytesIn:      sessions[i].DataTransferred.Down,
 			TokensEarned: sessions[i].TokensEarned,
+			DNSBlocked:   sessions[i].DNSBlocked,
 			ServiceID:    sessions[i].ServiceID,
 			ServiceType:  sessions[i].ServiceType,
 		}
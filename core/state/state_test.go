@@ -463,6 +463,7 @@ func Test_ConsumesEarningsChangeEvent(t *testing.T) {
 		IdentityProvider: &mocks.IdentityProvider{
 			Identities: []identity.Identity{
 				{Address: "0x000000000000000000000000000000000000000a"},
+				{Address: "0x000000000000000000000000000000000000000b"},
 			},
 		},
 		IdentityRegistry:          &mocks.IdentityRegistry{Status: registry.RegisteredProvider},
@@ -492,6 +493,8 @@ func Test_ConsumesEarningsChangeEvent(t *testing.T) {
 	}, 2*time.Second, 10*time.Millisecond)
 	assert.Equal(t, newSettlement.UnsettledBalance(), keeper.GetState().Identities[0].Earnings)
 	assert.Equal(t, newSettlement.LifetimeBalance(), keeper.GetState().Identities[0].EarningsTotal)
+	assert.Zero(t, keeper.GetState().Identities[1].Earnings)
+	assert.Zero(t, keeper.GetState().Identities[1].EarningsTotal)
 }
 
 func Test_ConsumesIdentityRegistrationEvent(t *testing.T) {
@@ -0,0 +1,207 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package boltdbtest
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
+)
+
+var errNotSupported = errors.New("not supported by the query stub")
+
+// QueryStub is an in-memory storm query of the given slice of structs, it allows to test bolt queries without the database
+type QueryStub struct {
+	items   reflect.Value
+	matcher q.Matcher
+	skip    int
+	limit   int
+	orderBy []string
+	reverse bool
+}
+
+// NewQueryStub returns the query of the structs in the given slice matching all the given matchers
+func NewQueryStub(items interface{}, matchers ...q.Matcher) *QueryStub {
+	return &QueryStub{
+		items:   reflect.ValueOf(items),
+		matcher: q.And(matchers...),
+		limit:   -1,
+	}
+}
+
+// Skip matching records by the given number
+func (qs *QueryStub) Skip(nb int) storm.Query {
+	qs.skip = nb
+	return qs
+}
+
+// Limit the results by the given number
+func (qs *QueryStub) Limit(nb int) storm.Query {
+	qs.limit = nb
+	return qs
+}
+
+// OrderBy orders by the given fields, in descending precedence, left-to-right
+func (qs *QueryStub) OrderBy(field ...string) storm.Query {
+	qs.orderBy = field
+	return qs
+}
+
+// Reverse the order of the results
+func (qs *QueryStub) Reverse() storm.Query {
+	qs.reverse = true
+	return qs
+}
+
+// Bucket is ignored by the stub
+func (qs *QueryStub) Bucket(string) storm.Query {
+	return qs
+}
+
+// Find a list of matching records
+func (qs *QueryStub) Find(to interface{}) error {
+	results, err := qs.results()
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return storm.ErrNotFound
+	}
+
+	list := reflect.MakeSlice(qs.items.Type(), 0, len(results))
+	for _, item := range results {
+		list = reflect.Append(list, item)
+	}
+	reflect.Indirect(reflect.ValueOf(to)).Set(list)
+	return nil
+}
+
+// First gets the first matching record
+func (qs *QueryStub) First(to interface{}) error {
+	results, err := qs.results()
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return storm.ErrNotFound
+	}
+
+	reflect.Indirect(reflect.ValueOf(to)).Set(results[0])
+	return nil
+}
+
+// Delete is not supported by the stub
+func (qs *QueryStub) Delete(interface{}) error {
+	return errNotSupported
+}
+
+// Count all the matching records
+func (qs *QueryStub) Count(interface{}) (int, error) {
+	results, err := qs.results()
+	return len(results), err
+}
+
+// Raw is not supported by the stub
+func (qs *QueryStub) Raw() ([][]byte, error) {
+	return nil, errNotSupported
+}
+
+// RawEach is not supported by the stub
+func (qs *QueryStub) RawEach(func([]byte, []byte) error) error {
+	return errNotSupported
+}
+
+// Each executes the given function for a copy of each matching record
+func (qs *QueryStub) Each(_ interface{}, fn func(interface{}) error) error {
+	results, err := qs.results()
+	if err != nil {
+		return err
+	}
+
+	for _, item := range results {
+		record := reflect.New(item.Type())
+		record.Elem().Set(item)
+		if err := fn(record.Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (qs *QueryStub) results() ([]reflect.Value, error) {
+	var results []reflect.Value
+	for i := 0; i < qs.items.Len(); i++ {
+		item := qs.items.Index(i)
+		ok, err := qs.matcher.Match(item.Interface())
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			results = append(results, item)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		for _, field := range qs.orderBy {
+			left, right := results[i].FieldByName(field), results[j].FieldByName(field)
+			if less(left, right) {
+				return true
+			}
+			if less(right, left) {
+				return false
+			}
+		}
+		return false
+	})
+	if qs.reverse {
+		for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+			results[i], results[j] = results[j], results[i]
+		}
+	}
+
+	if qs.skip >= len(results) {
+		return nil, nil
+	}
+	results = results[qs.skip:]
+	if qs.limit >= 0 && qs.limit < len(results) {
+		results = results[:qs.limit]
+	}
+	return results, nil
+}
+
+func less(left, right reflect.Value) bool {
+	if t, ok := left.Interface().(time.Time); ok {
+		return t.Before(right.Interface().(time.Time))
+	}
+
+	switch left.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return left.Int() < right.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return left.Uint() < right.Uint()
+	case reflect.Float32, reflect.Float64:
+		return left.Float() < right.Float()
+	case reflect.String:
+		return left.String() < right.String()
+	}
+	return false
+}
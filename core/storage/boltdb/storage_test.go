@@ -19,7 +19,10 @@ package boltdb
 
 import (
 	"testing"
+	"time"
 
+	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/mysteriumnetwork/node/core/storage/boltdb/boltdbtest"
@@ -113,3 +116,31 @@ func Test_StorageGetLast_ErrsOnEmpty(t *testing.T) {
 	err = storage.GetLast(bucket, &result)
 	assert.Equal(t, "not found", err.Error())
 }
+
+func Test_StorageSelect(t *testing.T) {
+	storage, close, err := createMockStorage(t)
+	assert.Nil(t, err)
+	defer close()
+
+	type timedType struct {
+		ID      int64 `storm:"id"`
+		Created time.Time
+	}
+	created := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	for id := int64(1); id <= 5; id++ {
+		err = storage.Store(bucket, &timedType{ID: id, Created: created.Add(time.Duration(5-id) * time.Hour)})
+		assert.Nil(t, err)
+	}
+
+	query := func() storm.Query {
+		return storage.Select(bucket, q.Gte("Created", created.Add(time.Hour)), q.Lt("Created", created.Add(4*time.Hour)))
+	}
+	count, err := query().Count(new(timedType))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, count)
+
+	var result []timedType
+	err = query().OrderBy("Created").Reverse().Skip(1).Limit(5).Find(&result)
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{3, 4}, []int64{result[0].ID, result[1].ID})
+}
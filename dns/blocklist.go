@@ -0,0 +1,272 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/utils/stringutil"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// BlocklistResponseNXDomain answers blocked queries with NXDOMAIN.
+	BlocklistResponseNXDomain = "nxdomain"
+	// BlocklistResponseZeroIP answers blocked queries with 0.0.0.0 or :: address.
+	BlocklistResponseZeroIP = "zero_ip"
+)
+
+const blocklistFetchTimeout = 30 * time.Second
+
+// BlocklistOptions describes domain blocking of a service instance.
+type BlocklistOptions struct {
+	// Enabled turns blocking on.
+	Enabled bool `json:"enabled"`
+	// Sources are hosts-format or domain-list files, given as local paths or http(s) URLs.
+	Sources []string `json:"sources"`
+	// Response is the answer to blocked queries, either "nxdomain" or "zero_ip".
+	Response string `json:"response"`
+	// RefreshMinutes defines how often URL sources are fetched again, zero disables refreshing.
+	RefreshMinutes int `json:"refresh_minutes"`
+}
+
+// Validate checks if blocklist options are valid.
+func (o BlocklistOptions) Validate() error {
+	if !o.Enabled {
+		return nil
+	}
+	if len(o.Sources) == 0 {
+		return errors.New("at least one blocklist source is required")
+	}
+	switch o.Response {
+	case "", BlocklistResponseNXDomain, BlocklistResponseZeroIP:
+	default:
+		return errors.Errorf("unsupported blocklist response: %s", o.Response)
+	}
+	if o.RefreshMinutes < 0 {
+		return errors.New("blocklist refresh interval can not be negative")
+	}
+	return nil
+}
+
+// ConfiguredBlocklistOptions returns effective blocklist options from application configuration.
+func ConfiguredBlocklistOptions() BlocklistOptions {
+	return BlocklistOptions{
+		Enabled:        config.GetBool(config.FlagDNSBlocklistEnabled),
+		Sources:        stringutil.Split(config.GetString(config.FlagDNSBlocklistSources), ','),
+		Response:       config.GetString(config.FlagDNSBlocklistResponse),
+		RefreshMinutes: config.GetInt(config.FlagDNSBlocklistRefresh),
+	}
+}
+
+// Blocklist keeps the set of blocked domains loaded from the configured sources.
+type Blocklist struct {
+	sources    []string
+	refresh    time.Duration
+	httpClient *http.Client
+
+	lock    sync.RWMutex
+	lists   map[string]map[string]struct{}
+	domains map[string]struct{}
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewBlocklist creates a blocklist of the given options, sources are not loaded until Load is called.
+func NewBlocklist(options BlocklistOptions) *Blocklist {
+	return &Blocklist{
+		sources:    options.Sources,
+		refresh:    time.Duration(options.RefreshMinutes) * time.Minute,
+		httpClient: &http.Client{Timeout: blocklistFetchTimeout},
+		lists:      make(map[string]map[string]struct{}),
+		domains:    make(map[string]struct{}),
+		stop:       make(chan struct{}),
+	}
+}
+
+// Load reads all the sources. Sources which fail to load keep their previously loaded domains.
+func (b *Blocklist) Load() error {
+	var failed int
+	for _, source := range b.sources {
+		domains, err := b.fetch(source)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Could not load DNS blocklist %s", source)
+			failed++
+			continue
+		}
+
+		b.lock.Lock()
+		b.lists[source] = domains
+		b.lock.Unlock()
+	}
+	b.merge()
+
+	if failed > 0 && failed == len(b.sources) {
+		return errors.New("could not load any of the DNS blocklists")
+	}
+	return nil
+}
+
+// Start periodically reloads the URL sources until Stop is called.
+func (b *Blocklist) Start() {
+	if b.refresh <= 0 || !b.hasRemoteSources() {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(b.refresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-b.stop:
+				return
+			case <-ticker.C:
+				if err := b.Load(); err != nil {
+					log.Warn().Err(err).Msg("Could not refresh DNS blocklists")
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops refreshing of the sources.
+func (b *Blocklist) Stop() {
+	b.stopOnce.Do(func() {
+		close(b.stop)
+	})
+}
+
+// Len returns the number of blocked domains.
+func (b *Blocklist) Len() int {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return len(b.domains)
+}
+
+// Contains checks if the given name or any of its parent domains is blocked.
+func (b *Blocklist) Contains(name string) bool {
+	name = normalizeDomain(name)
+
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	for name != "" {
+		if _, ok := b.domains[name]; ok {
+			return true
+		}
+		i := strings.IndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[i+1:]
+	}
+	return false
+}
+
+func (b *Blocklist) merge() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	domains := make(map[string]struct{})
+	for _, list := range b.lists {
+		for domain := range list {
+			domains[domain] = struct{}{}
+		}
+	}
+	b.domains = domains
+}
+
+func (b *Blocklist) hasRemoteSources() bool {
+	for _, source := range b.sources {
+		if isRemoteSource(source) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Blocklist) fetch(source string) (map[string]struct{}, error) {
+	if !isRemoteSource(source) {
+		file, err := os.Open(source)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not open blocklist file")
+		}
+		defer file.Close()
+
+		return parseBlocklist(file)
+	}
+
+	resp, err := b.httpClient.Get(source)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch blocklist")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("could not fetch blocklist, status: %s", resp.Status)
+	}
+	return parseBlocklist(resp.Body)
+}
+
+func isRemoteSource(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// parseBlocklist reads both hosts-format ("0.0.0.0 example.com") and plain domain-list files.
+func parseBlocklist(reader io.Reader) (map[string]struct{}, error) {
+	domains := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if net.ParseIP(fields[0]) != nil {
+			fields = fields[1:]
+		}
+
+		for _, field := range fields {
+			domain := normalizeDomain(field)
+			// Skips entries like "localhost" or "broadcasthost" which are not real domains.
+			if !strings.Contains(domain, ".") || net.ParseIP(domain) != nil {
+				continue
+			}
+			domains[domain] = struct{}{}
+		}
+	}
+	return domains, errors.Wrap(scanner.Err(), "could not read blocklist")
+}
+
+func normalizeDomain(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+}
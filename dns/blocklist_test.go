@@ -0,0 +1,106 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const hostsBlocklist = `# Ad servers
+127.0.0.1 localhost
+0.0.0.0 ads.example.com
+0.0.0.0 Tracker.Example.org. metrics.example.org # inline comment
+::1 ip6-localhost
+`
+
+const domainBlocklist = `
+malware.test
+# comment
+phishing.example.net
+`
+
+func Test_parseBlocklist(t *testing.T) {
+	domains, err := parseBlocklist(strings.NewReader(hostsBlocklist + domainBlocklist))
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{
+		"ads.example.com":      {},
+		"tracker.example.org":  {},
+		"metrics.example.org":  {},
+		"malware.test":         {},
+		"phishing.example.net": {},
+	}, domains)
+}
+
+func TestBlocklist_Contains(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blocklist")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "hosts")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(hostsBlocklist), 0600))
+
+	blocklist := NewBlocklist(BlocklistOptions{Sources: []string{path}})
+	assert.NoError(t, blocklist.Load())
+	assert.Equal(t, 3, blocklist.Len())
+
+	assert.True(t, blocklist.Contains("ads.example.com."))
+	assert.True(t, blocklist.Contains("cdn.ADS.example.com."))
+	assert.True(t, blocklist.Contains("tracker.example.org"))
+	assert.False(t, blocklist.Contains("example.com."))
+	assert.False(t, blocklist.Contains("badads.example.com."))
+	assert.False(t, blocklist.Contains("localhost."))
+}
+
+func TestBlocklist_LoadKeepsPreviousListOnFailure(t *testing.T) {
+	var failing int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, domainBlocklist)
+	}))
+	defer server.Close()
+
+	blocklist := NewBlocklist(BlocklistOptions{Sources: []string{server.URL}})
+	assert.NoError(t, blocklist.Load())
+	assert.True(t, blocklist.Contains("malware.test."))
+
+	atomic.StoreInt32(&failing, 1)
+	assert.Error(t, blocklist.Load())
+	assert.True(t, blocklist.Contains("malware.test."))
+}
+
+func TestBlocklistOptions_Validate(t *testing.T) {
+	assert.NoError(t, BlocklistOptions{}.Validate())
+	assert.NoError(t, BlocklistOptions{Enabled: true, Sources: []string{"hosts"}, Response: BlocklistResponseZeroIP}.Validate())
+	assert.Error(t, BlocklistOptions{Enabled: true}.Validate())
+	assert.Error(t, BlocklistOptions{Enabled: true, Sources: []string{"hosts"}, Response: "refused"}.Validate())
+	assert.Error(t, BlocklistOptions{Enabled: true, Sources: []string{"hosts"}, RefreshMinutes: -1}.Validate())
+}
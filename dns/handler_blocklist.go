@@ -0,0 +1,90 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"net"
+
+	"github.com/miekg/dns"
+	"github.com/rs/zerolog/log"
+)
+
+const blockedAnswerTTL = 300
+
+// BlockDomains creates a DNS handler which answers queries of blocklisted domains without resolving them.
+// onBlocked is called with the IP of the client whose query was blocked.
+func BlockDomains(resolver dns.Handler, blocklist *Blocklist, response string, onBlocked func(clientIP net.IP)) dns.Handler {
+	return &blocklistHandler{
+		resolver:  resolver,
+		blocklist: blocklist,
+		response:  response,
+		onBlocked: onBlocked,
+	}
+}
+
+type blocklistHandler struct {
+	resolver  dns.Handler
+	blocklist *Blocklist
+	response  string
+	onBlocked func(clientIP net.IP)
+}
+
+func (bh *blocklistHandler) ServeDNS(writer dns.ResponseWriter, req *dns.Msg) {
+	if len(req.Question) == 0 || !bh.blocklist.Contains(req.Question[0].Name) {
+		bh.resolver.ServeDNS(writer, req)
+		return
+	}
+
+	log.Debug().Msgf("Blocked DNS query: %s", req.Question[0].Name)
+	if err := writer.WriteMsg(bh.blockedResponse(req)); err != nil {
+		log.Warn().Err(err).Msg("Could not write blocked DNS response")
+	}
+
+	if bh.onBlocked != nil {
+		bh.onBlocked(remoteIP(writer.RemoteAddr()))
+	}
+}
+
+func (bh *blocklistHandler) blockedResponse(req *dns.Msg) *dns.Msg {
+	resp := &dns.Msg{}
+	if bh.response != BlocklistResponseZeroIP {
+		return resp.SetRcode(req, dns.RcodeNameError)
+	}
+
+	resp.SetReply(req)
+	for _, question := range req.Question {
+		header := dns.RR_Header{Name: question.Name, Rrtype: question.Qtype, Class: question.Qclass, Ttl: blockedAnswerTTL}
+		switch question.Qtype {
+		case dns.TypeA:
+			resp.Answer = append(resp.Answer, &dns.A{Hdr: header, A: net.IPv4zero})
+		case dns.TypeAAAA:
+			resp.Answer = append(resp.Answer, &dns.AAAA{Hdr: header, AAAA: net.IPv6zero})
+		}
+	}
+	return resp
+}
+
+func remoteIP(addr net.Addr) net.IP {
+	switch addr := addr.(type) {
+	case *net.UDPAddr:
+		return addr.IP
+	case *net.TCPAddr:
+		return addr.IP
+	}
+	return nil
+}
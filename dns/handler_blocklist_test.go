@@ -0,0 +1,85 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+type clientWriter struct {
+	recordingWriter
+	remote net.Addr
+}
+
+func (cw *clientWriter) RemoteAddr() net.Addr {
+	return cw.remote
+}
+
+func testBlocklist(t *testing.T) *Blocklist {
+	domains, err := parseBlocklist(strings.NewReader(domainBlocklist))
+	assert.NoError(t, err)
+
+	blocklist := NewBlocklist(BlocklistOptions{})
+	blocklist.lists["test"] = domains
+	blocklist.merge()
+	return blocklist
+}
+
+func Test_BlockDomains(t *testing.T) {
+	var blockedClients []net.IP
+	onBlocked := func(clientIP net.IP) {
+		blockedClients = append(blockedClients, clientIP)
+	}
+	resolver := &countingResolver{response: answer(60)}
+	handler := BlockDomains(resolver, testBlocklist(t), BlocklistResponseNXDomain, onBlocked)
+	writer := &clientWriter{remote: &net.UDPAddr{IP: net.ParseIP("10.182.0.2"), Port: 5353}}
+
+	handler.ServeDNS(writer, query(1, "example.com."))
+	assert.Equal(t, 1, resolver.calls)
+	assert.Equal(t, dns.RcodeSuccess, writer.responseMsg.Rcode)
+	assert.Empty(t, blockedClients)
+
+	handler.ServeDNS(writer, query(2, "www.malware.test."))
+	assert.Equal(t, 1, resolver.calls)
+	assert.Equal(t, dns.RcodeNameError, writer.responseMsg.Rcode)
+	assert.Equal(t, uint16(2), writer.responseMsg.Id)
+	assert.Equal(t, []net.IP{net.ParseIP("10.182.0.2")}, blockedClients)
+}
+
+func Test_BlockDomainsWithZeroIP(t *testing.T) {
+	resolver := &countingResolver{response: answer(60)}
+	handler := BlockDomains(resolver, testBlocklist(t), BlocklistResponseZeroIP, nil)
+	writer := &clientWriter{remote: &net.UDPAddr{IP: net.ParseIP("10.182.0.2"), Port: 5353}}
+
+	handler.ServeDNS(writer, query(1, "malware.test."))
+	assert.Equal(t, 0, resolver.calls)
+	assert.Equal(t, dns.RcodeSuccess, writer.responseMsg.Rcode)
+	assert.Len(t, writer.responseMsg.Answer, 1)
+	assert.Equal(t, net.IPv4zero, writer.responseMsg.Answer[0].(*dns.A).A)
+
+	req := &dns.Msg{}
+	req.SetQuestion("malware.test.", dns.TypeAAAA)
+	handler.ServeDNS(writer, req)
+	assert.Len(t, writer.responseMsg.Answer, 1)
+	assert.Equal(t, net.IPv6zero, writer.responseMsg.Answer[0].(*dns.AAAA).AAAA)
+}
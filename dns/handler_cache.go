@@ -0,0 +1,159 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// CacheAnswers creates a DNS handler caching successful responses of the resolver until their TTL expires.
+func CacheAnswers(resolver dns.Handler, size int) dns.Handler {
+	return &cacheHandler{
+		resolver: resolver,
+		size:     size,
+		entries:  make(map[cacheKey]cacheEntry),
+		now:      time.Now,
+	}
+}
+
+type cacheKey struct {
+	name   string
+	qtype  uint16
+	qclass uint16
+}
+
+type cacheEntry struct {
+	response *dns.Msg
+	stored   time.Time
+	expires  time.Time
+}
+
+type cacheHandler struct {
+	resolver dns.Handler
+	size     int
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[cacheKey]cacheEntry
+}
+
+func (ch *cacheHandler) ServeDNS(writer dns.ResponseWriter, req *dns.Msg) {
+	if len(req.Question) != 1 {
+		ch.resolver.ServeDNS(writer, req)
+		return
+	}
+
+	question := req.Question[0]
+	key := cacheKey{name: strings.ToLower(question.Name), qtype: question.Qtype, qclass: question.Qclass}
+	if resp, ok := ch.get(key, req); ok {
+		writer.WriteMsg(resp)
+		return
+	}
+
+	resolverWriter := &recordingWriter{writer: writer}
+	ch.resolver.ServeDNS(resolverWriter, req)
+	resp := resolverWriter.responseMsg
+	if resp == nil {
+		return
+	}
+
+	ch.put(key, resp)
+	writer.WriteMsg(resp)
+}
+
+// get returns a copy of the cached response with TTLs decreased by the time spent in cache.
+func (ch *cacheHandler) get(key cacheKey, req *dns.Msg) (*dns.Msg, bool) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	entry, ok := ch.entries[key]
+	if !ok {
+		return nil, false
+	}
+	now := ch.now()
+	if !now.Before(entry.expires) {
+		delete(ch.entries, key)
+		return nil, false
+	}
+
+	resp := entry.response.Copy()
+	resp.Id = req.Id
+	elapsed := uint32(now.Sub(entry.stored) / time.Second)
+	for _, records := range [][]dns.RR{resp.Answer, resp.Ns, resp.Extra} {
+		for _, record := range records {
+			if record.Header().Rrtype == dns.TypeOPT {
+				continue
+			}
+			record.Header().Ttl -= elapsed
+		}
+	}
+	return resp, true
+}
+
+func (ch *cacheHandler) put(key cacheKey, resp *dns.Msg) {
+	if resp.Rcode != dns.RcodeSuccess || resp.Truncated || len(resp.Answer) == 0 {
+		return
+	}
+	ttl := resp.Answer[0].Header().Ttl
+	for _, record := range resp.Answer {
+		if record.Header().Ttl < ttl {
+			ttl = record.Header().Ttl
+		}
+	}
+	if ttl == 0 {
+		return
+	}
+
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	now := ch.now()
+	if len(ch.entries) >= ch.size {
+		ch.evict(now)
+	}
+	if len(ch.entries) >= ch.size {
+		return
+	}
+	ch.entries[key] = cacheEntry{
+		response: resp.Copy(),
+		stored:   now,
+		expires:  now.Add(time.Duration(ttl) * time.Second),
+	}
+}
+
+// evict removes expired entries, or the one expiring soonest if none have expired.
+func (ch *cacheHandler) evict(now time.Time) {
+	var soonest *cacheKey
+	for key, entry := range ch.entries {
+		if !now.Before(entry.expires) {
+			delete(ch.entries, key)
+			continue
+		}
+		if soonest == nil || entry.expires.Before(ch.entries[*soonest].expires) {
+			k := key
+			soonest = &k
+		}
+	}
+	if len(ch.entries) >= ch.size && soonest != nil {
+		delete(ch.entries, *soonest)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingResolver struct {
+	calls    int
+	response func(req *dns.Msg) *dns.Msg
+}
+
+func (cr *countingResolver) ServeDNS(writer dns.ResponseWriter, req *dns.Msg) {
+	cr.calls++
+	writer.WriteMsg(cr.response(req))
+}
+
+func answer(ttl uint32) func(req *dns.Msg) *dns.Msg {
+	return func(req *dns.Msg) *dns.Msg {
+		resp := &dns.Msg{}
+		resp.SetReply(req)
+		resp.Answer = []dns.RR{&dns.A{
+			Hdr: dns.RR_Header{Name: req.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: ttl},
+			A:   net.ParseIP("1.2.3.4"),
+		}}
+		return resp
+	}
+}
+
+func query(id uint16, name string) *dns.Msg {
+	req := &dns.Msg{}
+	req.SetQuestion(name, dns.TypeA)
+	req.Id = id
+	return req
+}
+
+func Test_CacheAnswers(t *testing.T) {
+	resolver := &countingResolver{response: answer(60)}
+	handler := CacheAnswers(resolver, 10).(*cacheHandler)
+	now := time.Now()
+	handler.now = func() time.Time { return now }
+
+	writer := &recordingWriter{}
+	handler.ServeDNS(writer, query(1, "example.com."))
+	assert.Equal(t, 1, resolver.calls)
+	assert.Equal(t, uint16(1), writer.responseMsg.Id)
+
+	now = now.Add(20 * time.Second)
+	handler.ServeDNS(writer, query(2, "EXAMPLE.com."))
+	assert.Equal(t, 1, resolver.calls)
+	assert.Equal(t, uint16(2), writer.responseMsg.Id)
+	assert.Equal(t, uint32(40), writer.responseMsg.Answer[0].Header().Ttl)
+
+	now = now.Add(40 * time.Second)
+	handler.ServeDNS(writer, query(3, "example.com."))
+	assert.Equal(t, 2, resolver.calls)
+	assert.Equal(t, uint32(60), writer.responseMsg.Answer[0].Header().Ttl)
+}
+
+func Test_CacheAnswers_SkipsUncacheableResponses(t *testing.T) {
+	resolver := &countingResolver{response: answer(0)}
+	handler := CacheAnswers(resolver, 10)
+
+	handler.ServeDNS(&recordingWriter{}, query(1, "example.com."))
+	handler.ServeDNS(&recordingWriter{}, query(2, "example.com."))
+	assert.Equal(t, 2, resolver.calls)
+
+	resolver = &countingResolver{response: func(req *dns.Msg) *dns.Msg {
+		resp := &dns.Msg{}
+		resp.SetRcode(req, dns.RcodeNameError)
+		return resp
+	}}
+	handler = CacheAnswers(resolver, 10)
+
+	handler.ServeDNS(&recordingWriter{}, query(1, "example.com."))
+	handler.ServeDNS(&recordingWriter{}, query(2, "example.com."))
+	assert.Equal(t, 2, resolver.calls)
+}
+
+func Test_CacheAnswers_EvictsWhenFull(t *testing.T) {
+	resolver := &countingResolver{response: answer(60)}
+	handler := CacheAnswers(resolver, 2).(*cacheHandler)
+
+	handler.ServeDNS(&recordingWriter{}, query(1, "a.com."))
+	handler.ServeDNS(&recordingWriter{}, query(2, "b.com."))
+	handler.ServeDNS(&recordingWriter{}, query(3, "c.com."))
+	assert.Len(t, handler.entries, 2)
+	assert.Equal(t, 3, resolver.calls)
+}
@@ -0,0 +1,259 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	upstreamTimeout = 5 * time.Second
+	// bootstrapServer resolves upstream host names instead of the system resolver,
+	// which may be the handler itself or leak the names outside the tunnel.
+	bootstrapServer = "1.1.1.1:53"
+)
+
+// lookupHost resolves upstream host names, replaced in tests.
+var lookupHost = func(ctx context.Context, host string) ([]string, error) {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			dialer := &net.Dialer{Timeout: upstreamTimeout}
+			return dialer.DialContext(ctx, network, bootstrapServer)
+		},
+	}
+	return resolver.LookupHost(ctx, host)
+}
+
+// ResolveViaSecureUpstreams creates DNS handler forwarding queries to DNS-over-HTTPS ("https://" URLs)
+// or DNS-over-TLS ("tls://" URLs) upstreams. Upstream host names are resolved on the first query
+// with the bootstrap server, so that the name is not revealed before the tunnel is up
+// and the handler can be used as a system resolver itself.
+func ResolveViaSecureUpstreams(upstreams []string) (dns.Handler, error) {
+	if len(upstreams) == 0 {
+		return nil, errors.New("no DNS upstreams given")
+	}
+
+	handler := &secureHandler{}
+	for _, rawURL := range upstreams {
+		u, err := newSecureUpstream(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		handler.upstreams = append(handler.upstreams, u)
+	}
+	return handler, nil
+}
+
+type secureUpstream interface {
+	Exchange(req *dns.Msg) (*dns.Msg, error)
+	String() string
+}
+
+type secureHandler struct {
+	upstreams []secureUpstream
+}
+
+func (sh *secureHandler) ServeDNS(writer dns.ResponseWriter, req *dns.Msg) {
+	for _, upstream := range sh.upstreams {
+		resp, err := upstream.Exchange(req)
+		if err != nil {
+			log.Error().Err(err).Msg("Error proxying DNS query to " + upstream.String())
+			continue
+		}
+
+		writer.WriteMsg(resp)
+		return
+	}
+
+	resp := &dns.Msg{}
+	resp.SetRcode(req, dns.RcodeServerFailure)
+	writer.WriteMsg(resp)
+}
+
+func newSecureUpstream(rawURL string) (secureUpstream, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return nil, errors.Errorf("invalid DNS upstream: %s", rawURL)
+	}
+
+	switch u.Scheme {
+	case "https":
+		return newDoHUpstream(u)
+	case "tls":
+		return newDoTUpstream(u)
+	}
+	return nil, errors.Errorf("unsupported DNS upstream %s, expected https:// or tls:// URL", rawURL)
+}
+
+// upstreamAddress resolves upstream address once it is needed for the first time.
+type upstreamAddress struct {
+	host, port string
+
+	mu   sync.Mutex
+	addr string
+}
+
+func newUpstreamAddress(u *url.URL, defaultPort string) *upstreamAddress {
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+
+	ua := &upstreamAddress{host: u.Hostname(), port: port}
+	if net.ParseIP(ua.host) != nil {
+		ua.addr = net.JoinHostPort(ua.host, port)
+	}
+	return ua
+}
+
+func (ua *upstreamAddress) resolve(ctx context.Context) (string, error) {
+	ua.mu.Lock()
+	defer ua.mu.Unlock()
+
+	if ua.addr != "" {
+		return ua.addr, nil
+	}
+
+	addrs, err := lookupHost(ctx, ua.host)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not resolve DNS upstream %s", ua.host)
+	}
+	if len(addrs) == 0 {
+		return "", errors.Errorf("could not resolve DNS upstream %s", ua.host)
+	}
+	ua.addr = net.JoinHostPort(addrs[0], ua.port)
+	return ua.addr, nil
+}
+
+// dohUpstream exchanges DNS messages over HTTPS as defined in RFC 8484.
+type dohUpstream struct {
+	url    string
+	client *http.Client
+}
+
+func newDoHUpstream(u *url.URL) (*dohUpstream, error) {
+	addr := newUpstreamAddress(u, "443")
+
+	dialer := &net.Dialer{Timeout: upstreamTimeout}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			resolved, err := addr.resolve(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return dialer.DialContext(ctx, network, resolved)
+		},
+		TLSHandshakeTimeout: upstreamTimeout,
+		ForceAttemptHTTP2:   true,
+	}
+	return &dohUpstream{
+		url:    u.String(),
+		client: &http.Client{Transport: transport, Timeout: 2 * upstreamTimeout},
+	}, nil
+}
+
+func (u *dohUpstream) Exchange(req *dns.Msg) (*dns.Msg, error) {
+	// RFC 8484 recommends zero ID to make responses cache friendly.
+	query := req.Copy()
+	query.Id = 0
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not pack DNS query")
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, u.url, bytes.NewReader(packed))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/dns-message")
+	httpReq.Header.Set("Accept", "application/dns-message")
+
+	httpResp, err := u.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected response status: %s", httpResp.Status)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(httpResp.Body, dns.MaxMsgSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read DNS response")
+	}
+
+	resp := &dns.Msg{}
+	if err := resp.Unpack(body); err != nil {
+		return nil, errors.Wrap(err, "could not unpack DNS response")
+	}
+	resp.Id = req.Id
+	return resp, nil
+}
+
+func (u *dohUpstream) String() string {
+	return u.url
+}
+
+// dotUpstream exchanges DNS messages over TLS as defined in RFC 7858.
+type dotUpstream struct {
+	url    string
+	addr   *upstreamAddress
+	client *dns.Client
+}
+
+func newDoTUpstream(u *url.URL) (*dotUpstream, error) {
+	return &dotUpstream{
+		url:  u.String(),
+		addr: newUpstreamAddress(u, "853"),
+		client: &dns.Client{
+			Net:       "tcp-tls",
+			TLSConfig: &tls.Config{ServerName: u.Hostname()},
+			Timeout:   upstreamTimeout,
+		},
+	}, nil
+}
+
+func (u *dotUpstream) Exchange(req *dns.Msg) (*dns.Msg, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), upstreamTimeout)
+	defer cancel()
+
+	addr, err := u.addr.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, _, err := u.client.Exchange(req, addr)
+	return resp, err
+}
+
+func (u *dotUpstream) String() string {
+	return u.url
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ResolveViaSecureUpstreams_ValidatesUpstreams(t *testing.T) {
+	var lookups []string
+	defer func(original func(context.Context, string) ([]string, error)) { lookupHost = original }(lookupHost)
+	lookupHost = func(_ context.Context, host string) ([]string, error) {
+		lookups = append(lookups, host)
+		if host == "dns.example.com" {
+			return []string{"10.0.0.1"}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	_, err := ResolveViaSecureUpstreams(nil)
+	assert.EqualError(t, err, "no DNS upstreams given")
+
+	_, err = ResolveViaSecureUpstreams([]string{"udp://1.1.1.1"})
+	assert.EqualError(t, err, "unsupported DNS upstream udp://1.1.1.1, expected https:// or tls:// URL")
+
+	handler, err := ResolveViaSecureUpstreams([]string{"tls://dns.example.com", "tls://1.1.1.1", "tls://unknown.example.com"})
+	assert.NoError(t, err)
+	// Names are not resolved until the first query, which is sent through the tunnel.
+	assert.Empty(t, lookups)
+
+	upstreams := handler.(*secureHandler).upstreams
+	addr, err := upstreams[0].(*dotUpstream).addr.resolve(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1:853", addr)
+	assert.Equal(t, "dns.example.com", upstreams[0].(*dotUpstream).client.TLSConfig.ServerName)
+
+	addr, err = upstreams[1].(*dotUpstream).addr.resolve(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1.1.1:853", addr)
+
+	_, err = upstreams[2].(*dotUpstream).addr.resolve(context.Background())
+	assert.EqualError(t, err, "could not resolve DNS upstream unknown.example.com: no such host")
+	assert.Equal(t, []string{"dns.example.com", "unknown.example.com"}, lookups)
+}
+
+func Test_ResolveViaSecureUpstreams_ForwardsOverHTTPS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/dns-message", r.Header.Get("Content-Type"))
+
+		body, _ := ioutil.ReadAll(r.Body)
+		req := &dns.Msg{}
+		assert.NoError(t, req.Unpack(body))
+		assert.Equal(t, uint16(0), req.Id)
+
+		packed, _ := answer(60)(req).Pack()
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(packed)
+	}))
+	defer server.Close()
+
+	handler, err := ResolveViaSecureUpstreams([]string{server.URL + "/dns-query"})
+	assert.NoError(t, err)
+	trustServer(handler, server)
+
+	writer := &recordingWriter{}
+	handler.ServeDNS(writer, query(42, "example.com."))
+
+	assert.Equal(t, uint16(42), writer.responseMsg.Id)
+	assert.Equal(t, dns.RcodeSuccess, writer.responseMsg.Rcode)
+	assert.Equal(t, "1.2.3.4", writer.responseMsg.Answer[0].(*dns.A).A.String())
+}
+
+func Test_ResolveViaSecureUpstreams_FailsWhenUpstreamsFail(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	handler, err := ResolveViaSecureUpstreams([]string{server.URL + "/dns-query"})
+	assert.NoError(t, err)
+	trustServer(handler, server)
+
+	writer := &recordingWriter{}
+	handler.ServeDNS(writer, query(42, "example.com."))
+
+	assert.Equal(t, dns.RcodeServerFailure, writer.responseMsg.Rcode)
+}
+
+func trustServer(handler dns.Handler, server *httptest.Server) {
+	upstream := handler.(*secureHandler).upstreams[0].(*dohUpstream)
+	upstream.client.Transport.(*http.Transport).TLSClientConfig = &tls.Config{
+		RootCAs: server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs,
+	}
+}
@@ -179,7 +179,7 @@ func (tbn *trafficBlockerMock) Setup() error { return nil }
 
 func (tbn *trafficBlockerMock) Teardown() {}
 
-func (tbn *trafficBlockerMock) BlockIncomingTraffic(net.IPNet) (firewall.IncomingRuleRemove, error) {
+func (tbn *trafficBlockerMock) BlockIncomingTraffic(net.IPNet, firewall.TrafficRules) (firewall.IncomingRuleRemove, error) {
 	return nil, nil
 }
 
@@ -25,10 +25,34 @@ import (
 type IncomingTrafficFirewall interface {
 	Setup() error
 	Teardown()
-	BlockIncomingTraffic(network net.IPNet) (IncomingRuleRemove, error)
+	BlockIncomingTraffic(network net.IPNet, rules TrafficRules) (IncomingRuleRemove, error)
 	AllowURLAccess(rawURLs ...string) (IncomingRuleRemove, error)
 	AllowIPAccess(ip net.IP) (IncomingRuleRemove, error)
 }
 
 // IncomingRuleRemove type defines function for removal of created rule.
 type IncomingRuleRemove func() error
+
+// TrafficRules defines which traffic of the blocked network is still allowed to pass.
+type TrafficRules struct {
+	// DNSWhitelist allows destinations added by AllowIPAccess and AllowURLAccess.
+	DNSWhitelist bool
+	// Networks allows destinations in any of the given networks.
+	Networks []net.IPNet
+	// Ports allows just the given destination ports, any port is allowed if empty.
+	Ports []PortRange
+	// BlockedPorts rejects the given destination ports regardless of the other rules.
+	BlockedPorts []PortRange
+}
+
+// IsEmpty checks if there are no rules restricting the traffic.
+func (tr TrafficRules) IsEmpty() bool {
+	return !tr.DNSWhitelist && len(tr.Networks) == 0 && len(tr.Ports) == 0 && len(tr.BlockedPorts) == 0
+}
+
+// PortRange defines the range of destination ports, both TCP and UDP are matched if protocol is not set.
+type PortRange struct {
+	Protocol string
+	From     int
+	To       int
+}
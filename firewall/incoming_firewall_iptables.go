@@ -18,19 +18,26 @@
 package firewall
 
 import (
+	"fmt"
 	"net"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/mysteriumnetwork/node/firewall/ipset"
 	"github.com/mysteriumnetwork/node/firewall/iptables"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
 
 const (
 	incomingFirewallChain = "MYST_PROVIDER_FIREWALL"
 	incomingFirewallIpset = "myst-provider-dst-whitelist"
+
+	// Networks blocked with destination or port rules get their own chain and ipset named by the network.
+	incomingRulesChainPrefix = "MYST_PROVIDER_FW_"
+	incomingRulesIpsetPrefix = "myst-provider-fw-"
 )
 
 // incomingFirewallIptables allows incoming traffic blocking in IP granularity.
@@ -46,6 +53,7 @@ func (ibi *incomingFirewallIptables) Setup() error {
 		return err
 	}
 	ipset.Exec(ipset.OpDelete(incomingFirewallIpset))
+	ibi.cleanupStaleIpsets()
 
 	op := ipset.OpCreate(incomingFirewallIpset, ipset.SetTypeHashIP, 24*time.Hour, nil, 0)
 	if _, err := ipset.Exec(op); err != nil {
@@ -61,9 +69,15 @@ func (ibi *incomingFirewallIptables) Teardown() {
 	if errOutput, err := ipset.Exec(ipset.OpDelete(incomingFirewallIpset)); err != nil {
 		log.Warn().Err(err).Msgf("Error deleting ipset table. %s", strings.Join(errOutput, ""))
 	}
+	ibi.cleanupStaleIpsets()
 }
 
-func (ibi *incomingFirewallIptables) BlockIncomingTraffic(network net.IPNet) (IncomingRuleRemove, error) {
+func (ibi *incomingFirewallIptables) BlockIncomingTraffic(network net.IPNet, rules TrafficRules) (IncomingRuleRemove, error) {
+	if len(rules.Networks) > 0 || len(rules.Ports) > 0 || len(rules.BlockedPorts) > 0 {
+		return ibi.blockIncomingTrafficWithRules(network, rules)
+	}
+
+	// Only whitelisted destinations are allowed, shared chain is enough for that
 	remover, err := iptables.AddRuleWithRemoval(
 		iptables.AppendTo("FORWARD").RuleSpec("-s", network.String(), "-j", incomingFirewallChain),
 	)
@@ -76,6 +90,120 @@ func (ibi *incomingFirewallIptables) BlockIncomingTraffic(network net.IPNet) (In
 	}, nil
 }
 
+func (ibi *incomingFirewallIptables) blockIncomingTrafficWithRules(network net.IPNet, rules TrafficRules) (IncomingRuleRemove, error) {
+	ip := network.IP.Mask(network.Mask).To4()
+	if ip == nil {
+		return nil, errors.Errorf("traffic rules are supported for IPv4 networks only: %s", network.String())
+	}
+	ones, _ := network.Mask.Size()
+	chain := fmt.Sprintf("%s%x_%d", incomingRulesChainPrefix, []byte(ip), ones)
+	setName := fmt.Sprintf("%s%x-%d", incomingRulesIpsetPrefix, []byte(ip), ones)
+
+	cleanup := func() {
+		if _, err := iptables.Exec("-F", chain); err != nil {
+			log.Warn().Err(err).Msgf("Error flushing chain %s", chain)
+		}
+		if _, err := iptables.Exec("-X", chain); err != nil {
+			log.Warn().Err(err).Msgf("Error deleting chain %s", chain)
+		}
+		if len(rules.Networks) > 0 {
+			if _, err := ipset.Exec(ipset.OpDelete(setName)); err != nil {
+				log.Warn().Err(err).Msgf("Error deleting ipset table %s", setName)
+			}
+		}
+	}
+
+	if err := ibi.setupRulesChain(chain, setName, rules); err != nil {
+		cleanup()
+		return nil, err
+	}
+	remover, err := iptables.AddRuleWithRemoval(
+		iptables.AppendTo("FORWARD").RuleSpec("-s", network.String(), "-j", chain),
+	)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	return func() error {
+		remover()
+		cleanup()
+		return nil
+	}, nil
+}
+
+func (ibi *incomingFirewallIptables) setupRulesChain(chain, setName string, rules TrafficRules) error {
+	if _, err := iptables.Exec("-N", chain); err != nil {
+		return err
+	}
+
+	// Reject packets going to the blocked ports before anything is accepted
+	for _, port := range rules.BlockedPorts {
+		for _, protocol := range portProtocols(port) {
+			if _, err := iptables.Exec("-A", chain, "-p", protocol, "--dport", portSpec(port), "-j", "REJECT"); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Reject packets going to neither of the allowed networks nor the whitelisted destinations
+	var destinationSpec []string
+	if len(rules.Networks) > 0 {
+		if _, err := ipset.Exec(ipset.OpCreate(setName, ipset.SetTypeHashNet, 0, nil, 0)); err != nil {
+			return err
+		}
+		for _, network := range rules.Networks {
+			if network.IP.To4() == nil {
+				// Sessions with traffic rules get no IPv6 address, so the IPv6 network could not be reached anyway
+				log.Warn().Msgf("Skipping IPv6 network %s, it is not supported by the firewall", network.String())
+				continue
+			}
+			if _, err := ipset.Exec(ipset.OpNetAdd(setName, network, true)); err != nil {
+				return err
+			}
+		}
+		destinationSpec = append(destinationSpec, "-m", "set", "!", "--match-set", setName, "dst")
+	}
+	if rules.DNSWhitelist {
+		destinationSpec = append(destinationSpec, "-m", "set", "!", "--match-set", incomingFirewallIpset, "dst")
+	}
+	if len(destinationSpec) > 0 {
+		args := append(append([]string{"-A", chain}, destinationSpec...), "-j", "REJECT")
+		if _, err := iptables.Exec(args...); err != nil {
+			return err
+		}
+	}
+
+	if len(rules.Ports) == 0 {
+		_, err := iptables.Exec("-A", chain, "-j", "ACCEPT")
+		return err
+	}
+
+	// Accept packets going to the allowed ports, reject all the others
+	for _, port := range rules.Ports {
+		for _, protocol := range portProtocols(port) {
+			if _, err := iptables.Exec("-A", chain, "-p", protocol, "--dport", portSpec(port), "-j", "ACCEPT"); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := iptables.Exec("-A", chain, "-j", "REJECT")
+	return err
+}
+
+func portProtocols(port PortRange) []string {
+	if port.Protocol != "" {
+		return []string{port.Protocol}
+	}
+	return []string{"tcp", "udp"}
+}
+
+func portSpec(port PortRange) string {
+	if port.From == port.To {
+		return strconv.Itoa(port.From)
+	}
+	return fmt.Sprintf("%d:%d", port.From, port.To)
+}
+
 // AllowURLAccess adds URL based exception.
 func (ibi *incomingFirewallIptables) AllowURLAccess(rawURLs ...string) (IncomingRuleRemove, error) {
 	var ruleRemovers []func()
@@ -152,8 +280,8 @@ func (ibi *incomingFirewallIptables) cleanupStaleRules() error {
 		return err
 	}
 	for _, rule := range rules {
-		// detect if any references exist in FORWARD chain like -j MYST_PROVIDER_FIREWALL
-		if strings.HasSuffix(rule, incomingFirewallChain) {
+		// detect if any references exist in FORWARD chain like -j MYST_PROVIDER_FIREWALL or -j MYST_PROVIDER_FW_0a080000_24
+		if strings.HasSuffix(rule, incomingFirewallChain) || strings.Contains(rule, "-j "+incomingRulesChainPrefix) {
 			deleteRule := strings.Replace(rule, "-A", "-D", 1)
 			deleteRuleArgs := strings.Split(deleteRule, " ")
 			if _, err := iptables.Exec(deleteRuleArgs...); err != nil {
@@ -162,6 +290,24 @@ func (ibi *incomingFirewallIptables) cleanupStaleRules() error {
 		}
 	}
 
+	// Remove chains of the networks blocked with rules
+	chains, err := iptables.Exec("-S")
+	if err != nil {
+		return err
+	}
+	for _, chain := range chains {
+		if !strings.HasPrefix(chain, "-N "+incomingRulesChainPrefix) {
+			continue
+		}
+		chainName := strings.TrimPrefix(chain, "-N ")
+		if _, err := iptables.Exec("-F", chainName); err != nil {
+			return err
+		}
+		if _, err := iptables.Exec("-X", chainName); err != nil {
+			return err
+		}
+	}
+
 	// List chain rules
 	if _, err := iptables.Exec("-L", incomingFirewallChain); err != nil {
 		// error means no such chain - log error just in case and bail out
@@ -179,4 +325,19 @@ func (ibi *incomingFirewallIptables) cleanupStaleRules() error {
 	return err
 }
 
+func (ibi *incomingFirewallIptables) cleanupStaleIpsets() {
+	names, err := ipset.Exec(ipset.OpListNames())
+	if err != nil {
+		log.Info().Err(err).Msg("[setup] Got error while listing ipset tables. Probably nothing to worry about")
+		return
+	}
+	for _, name := range names {
+		if strings.HasPrefix(name, incomingRulesIpsetPrefix) {
+			if _, err := ipset.Exec(ipset.OpDelete(name)); err != nil {
+				log.Warn().Err(err).Msgf("Error deleting ipset table %s", name)
+			}
+		}
+	}
+}
+
 var _ IncomingTrafficFirewall = &incomingFirewallIptables{}
@@ -126,7 +126,7 @@ func Test_incomingFirewallIptables_BlockIncomingTraffic(t *testing.T) {
 	fw := &incomingFirewallIptables{}
 
 	_, network, _ := net.ParseCIDR("10.8.0.1/24")
-	removeRule, err := fw.BlockIncomingTraffic(*network)
+	removeRule, err := fw.BlockIncomingTraffic(*network, TrafficRules{DNSWhitelist: true})
 	assert.NoError(t, err)
 	assert.True(t, mockedIptables.VerifyCalledWithArgs("-A FORWARD -s 10.8.0.0/24 -j MYST_PROVIDER_FIREWALL"))
 
@@ -150,3 +150,126 @@ func Test_incomingFirewallIptables_AllowIPAccess(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, mockedIpset.VerifyCalledWithArgs("del myst-provider-dst-whitelist 1.2.3.4"))
 }
+
+func Test_incomingFirewallIptables_BlockIncomingTrafficWithRules(t *testing.T) {
+	mockedIpset := ipsetExecMock{
+		mocks: map[string]ipsetExecResult{},
+	}
+	ipset.Exec = mockedIpset.Exec
+
+	mockedIptables := iptablesExecMock{
+		mocks: map[string]iptablesExecResult{},
+	}
+	iptables.Exec = mockedIptables.Exec
+
+	fw := &incomingFirewallIptables{}
+
+	_, network, _ := net.ParseCIDR("10.8.0.1/24")
+	_, allowed, _ := net.ParseCIDR("192.168.0.0/16")
+	removeRule, err := fw.BlockIncomingTraffic(*network, TrafficRules{
+		DNSWhitelist: true,
+		Networks:     []net.IPNet{*allowed},
+		Ports: []PortRange{
+			{From: 443, To: 443},
+			{Protocol: "udp", From: 8000, To: 8100},
+		},
+	})
+	assert.NoError(t, err)
+	assert.True(t, mockedIpset.VerifyCalledWithArgs("create myst-provider-fw-0a080000-24 hash:net"))
+	assert.True(t, mockedIpset.VerifyCalledWithArgs("add myst-provider-fw-0a080000-24 192.168.0.0/16 --exist"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-N MYST_PROVIDER_FW_0a080000_24"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-A MYST_PROVIDER_FW_0a080000_24 -m set ! --match-set myst-provider-fw-0a080000-24 dst -m set ! --match-set myst-provider-dst-whitelist dst -j REJECT"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-A MYST_PROVIDER_FW_0a080000_24 -p tcp --dport 443 -j ACCEPT"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-A MYST_PROVIDER_FW_0a080000_24 -p udp --dport 443 -j ACCEPT"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-A MYST_PROVIDER_FW_0a080000_24 -p udp --dport 8000:8100 -j ACCEPT"))
+	assert.False(t, mockedIptables.VerifyCalledWithArgs("-A MYST_PROVIDER_FW_0a080000_24 -p tcp --dport 8000:8100 -j ACCEPT"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-A MYST_PROVIDER_FW_0a080000_24 -j REJECT"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-A FORWARD -s 10.8.0.0/24 -j MYST_PROVIDER_FW_0a080000_24"))
+
+	err = removeRule()
+	assert.NoError(t, err)
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-D FORWARD -s 10.8.0.0/24 -j MYST_PROVIDER_FW_0a080000_24"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-F MYST_PROVIDER_FW_0a080000_24"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-X MYST_PROVIDER_FW_0a080000_24"))
+	assert.True(t, mockedIpset.VerifyCalledWithArgs("destroy myst-provider-fw-0a080000-24"))
+}
+
+func Test_incomingFirewallIptables_BlockIncomingTrafficWithPortRulesOnly(t *testing.T) {
+	mockedIpset := ipsetExecMock{
+		mocks: map[string]ipsetExecResult{},
+	}
+	ipset.Exec = mockedIpset.Exec
+
+	mockedIptables := iptablesExecMock{
+		mocks: map[string]iptablesExecResult{},
+	}
+	iptables.Exec = mockedIptables.Exec
+
+	fw := &incomingFirewallIptables{}
+
+	_, network, _ := net.ParseCIDR("10.8.0.1/24")
+	_, err := fw.BlockIncomingTraffic(*network, TrafficRules{Ports: []PortRange{{Protocol: "tcp", From: 1, To: 24}}})
+	assert.NoError(t, err)
+	assert.False(t, mockedIpset.VerifyCalledWithArgs("create myst-provider-fw-0a080000-24 hash:net"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-A MYST_PROVIDER_FW_0a080000_24 -p tcp --dport 1:24 -j ACCEPT"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-A MYST_PROVIDER_FW_0a080000_24 -j REJECT"))
+}
+
+func Test_incomingFirewallIptables_BlockIncomingTrafficWithBlockedPorts(t *testing.T) {
+	mockedIpset := ipsetExecMock{
+		mocks: map[string]ipsetExecResult{},
+	}
+	ipset.Exec = mockedIpset.Exec
+
+	mockedIptables := iptablesExecMock{
+		mocks: map[string]iptablesExecResult{},
+	}
+	iptables.Exec = mockedIptables.Exec
+
+	fw := &incomingFirewallIptables{}
+
+	_, network, _ := net.ParseCIDR("10.8.0.1/24")
+	_, err := fw.BlockIncomingTraffic(*network, TrafficRules{BlockedPorts: []PortRange{{Protocol: "tcp", From: 25, To: 25}}})
+	assert.NoError(t, err)
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-A MYST_PROVIDER_FW_0a080000_24 -p tcp --dport 25 -j REJECT"))
+	assert.False(t, mockedIptables.VerifyCalledWithArgs("-A MYST_PROVIDER_FW_0a080000_24 -p udp --dport 25 -j REJECT"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-A MYST_PROVIDER_FW_0a080000_24 -j ACCEPT"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-A FORWARD -s 10.8.0.0/24 -j MYST_PROVIDER_FW_0a080000_24"))
+}
+
+func Test_incomingFirewallIptables_TeardownRemovesNetworkChains(t *testing.T) {
+	mockedIpset := ipsetExecMock{
+		mocks: map[string]ipsetExecResult{
+			"list -n": {
+				output: []string{"myst-provider-dst-whitelist", "myst-provider-fw-0a080000-24"},
+			},
+		},
+	}
+	ipset.Exec = mockedIpset.Exec
+
+	mockedIptables := iptablesExecMock{
+		mocks: map[string]iptablesExecResult{
+			"-S FORWARD": {
+				output: []string{
+					"-P FORWARD ACCEPT",
+					"-A FORWARD -s 10.8.0.0/24 -j MYST_PROVIDER_FW_0a080000_24",
+				},
+			},
+			"-S": {
+				output: []string{
+					"-P FORWARD ACCEPT",
+					"-N MYST_PROVIDER_FIREWALL",
+					"-N MYST_PROVIDER_FW_0a080000_24",
+				},
+			},
+		},
+	}
+	iptables.Exec = mockedIptables.Exec
+
+	fw := &incomingFirewallIptables{}
+	fw.Teardown()
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-D FORWARD -s 10.8.0.0/24 -j MYST_PROVIDER_FW_0a080000_24"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-F MYST_PROVIDER_FW_0a080000_24"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-X MYST_PROVIDER_FW_0a080000_24"))
+	assert.True(t, mockedIpset.VerifyCalledWithArgs("destroy myst-provider-fw-0a080000-24"))
+}
@@ -39,8 +39,11 @@ func (ifn *incomingFirewallNoop) Teardown() {
 }
 
 // BlockOutgoingTraffic just logs the call.
-func (ifn *incomingFirewallNoop) BlockIncomingTraffic(network net.IPNet) (IncomingRuleRemove, error) {
-	log.Info().Msg("Incoming traffic block requested")
+func (ifn *incomingFirewallNoop) BlockIncomingTraffic(network net.IPNet, rules TrafficRules) (IncomingRuleRemove, error) {
+	if !rules.IsEmpty() {
+		log.Warn().Msgf("Access policy traffic rules of %s are NOT enforced, run node with --incoming-firewall to enforce them", network.String())
+	}
+	log.Info().Msgf("Incoming traffic block requested, allowed: %+v", rules)
 	return func() error {
 		log.Info().Msg("Incoming traffic block removed")
 		return nil
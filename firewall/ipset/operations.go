@@ -29,6 +29,8 @@ type SetType string
 var (
 	// SetTypeHashIP set type uses a hash to store IP addresses where clashing is resolved by storing the clashing elements in an array and, as a last resort, by dynamically growing the hash.
 	SetTypeHashIP = SetType("hash:ip")
+	// SetTypeHashNet set type uses a hash to store different sized IP network addresses.
+	SetTypeHashNet = SetType("hash:net")
 )
 
 // OpVersion is an operation which prints version information.
@@ -66,6 +68,20 @@ func OpIPAdd(setName string, ip net.IP, ignoreExisting bool) []string {
 	return args
 }
 
+// OpNetAdd is an operation which adds network entry to the named set.
+func OpNetAdd(setName string, network net.IPNet, ignoreExisting bool) []string {
+	args := []string{"add", setName, network.String()}
+	if ignoreExisting {
+		args = append(args, "--exist")
+	}
+	return args
+}
+
+// OpListNames is an operation which lists names of all sets.
+func OpListNames() []string {
+	return []string{"list", "-n"}
+}
+
 // OpIPRemove is an operation which deletes IP entry from the named set.
 func OpIPRemove(setName string, ip net.IP) []string {
 	return []string{"del", setName, ip.String()}
@@ -29,8 +29,19 @@ import (
 // Exec actives given args
 var Exec = defaultExec
 
+// Exec6 actives given args for IPv6 packet filter
+var Exec6 = defaultExec6
+
 func defaultExec(args ...string) ([]string, error) {
-	args = append([]string{"sudo", "/sbin/iptables"}, args...)
+	return execOutput("/sbin/iptables", args...)
+}
+
+func defaultExec6(args ...string) ([]string, error) {
+	return execOutput("/sbin/ip6tables", args...)
+}
+
+func execOutput(executable string, args ...string) ([]string, error) {
+	args = append([]string{"sudo", executable}, args...)
 	output, err := cmdutil.ExecOutput(args...)
 	if err != nil {
 		return nil, errors.Wrap(err, "iptables cmd error")
@@ -46,11 +57,20 @@ func defaultExec(args ...string) ([]string, error) {
 
 // AddRuleWithRemoval activates given rule
 func AddRuleWithRemoval(rule Rule) (func(), error) {
-	if _, err := Exec(rule.ApplyArgs()...); err != nil {
+	return addRuleWithRemoval(Exec, rule)
+}
+
+// AddRuleWithRemoval6 activates given rule in IPv6 packet filter
+func AddRuleWithRemoval6(rule Rule) (func(), error) {
+	return addRuleWithRemoval(Exec6, rule)
+}
+
+func addRuleWithRemoval(exec func(args ...string) ([]string, error), rule Rule) (func(), error) {
+	if _, err := exec(rule.ApplyArgs()...); err != nil {
 		return nil, err
 	}
 	return func() {
-		_, err := Exec(rule.RemoveArgs()...)
+		_, err := exec(rule.RemoveArgs()...)
 		if err != nil {
 			log.Warn().Err(err).Msgf("Error executing rule: %v you might wanna do it yourself", rule.RemoveArgs())
 		}
@@ -38,6 +38,7 @@ type OutgoingTrafficFirewall interface {
 	BlockOutgoingTraffic(scope Scope, outboundIP string) (OutgoingRuleRemove, error)
 	AllowIPAccess(ip string) (OutgoingRuleRemove, error)
 	AllowURLAccess(rawURLs ...string) (OutgoingRuleRemove, error)
+	BlockIPv6Traffic() (OutgoingRuleRemove, error)
 }
 
 // Scope type represents scope of blocking consumer traffic.
@@ -65,3 +66,8 @@ func AllowIPAccess(ip string) (OutgoingRuleRemove, error) {
 func Reset() {
 	DefaultOutgoingFirewall.Teardown()
 }
+
+// BlockIPv6Traffic disallows any outgoing IPv6 traffic, used when tunnel does not provide IPv6.
+func BlockIPv6Traffic() (OutgoingRuleRemove, error) {
+	return DefaultOutgoingFirewall.BlockIPv6Traffic()
+}
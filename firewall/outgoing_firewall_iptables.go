@@ -28,6 +28,9 @@ import (
 
 const killswitchChain = "MYST_CONSUMER_KILL_SWITCH"
 
+// ipv6BlockRule rejects outgoing IPv6 traffic, it is marked with a comment to be found after the crash.
+var ipv6BlockRule = iptables.InsertAt("OUTPUT", 1).RuleSpec("!", "-o", "lo", "-m", "comment", "--comment", killswitchChain, "-j", "REJECT")
+
 type refCount struct {
 	count int
 	f     func()
@@ -47,6 +50,7 @@ func (obi *outgoingFirewallIptables) Setup() error {
 	if err := obi.cleanupStaleRules(); err != nil {
 		return err
 	}
+	obi.cleanupStaleIPv6Rules()
 	return obi.setupKillSwitchChain()
 }
 
@@ -55,6 +59,7 @@ func (obi *outgoingFirewallIptables) Teardown() {
 	if err := obi.cleanupStaleRules(); err != nil {
 		log.Warn().Err(err).Msg("Error cleaning up iptables rules, you might want to do it yourself")
 	}
+	obi.cleanupStaleIPv6Rules()
 }
 
 // BlockOutgoingTraffic effectively disallows any outgoing traffic from consumer node with specified scope.
@@ -72,6 +77,13 @@ func (obi *outgoingFirewallIptables) BlockOutgoingTraffic(scope Scope, outboundI
 	})
 }
 
+// BlockIPv6Traffic disallows any outgoing IPv6 traffic, except the loopback one.
+func (obi *outgoingFirewallIptables) BlockIPv6Traffic() (OutgoingRuleRemove, error) {
+	return obi.trackingReferenceCall("block-ipv6-traffic", func() (OutgoingRuleRemove, error) {
+		return iptables.AddRuleWithRemoval6(ipv6BlockRule)
+	})
+}
+
 // AllowIPAccess adds exception to blocked traffic for specified URL (host part is usually taken).
 func (obi *outgoingFirewallIptables) AllowIPAccess(ip string) (OutgoingRuleRemove, error) {
 	return obi.trackingReferenceCall("allow:"+ip, func() (rule OutgoingRuleRemove, e error) {
@@ -173,6 +185,21 @@ func (obi *outgoingFirewallIptables) cleanupStaleRules() error {
 	return err
 }
 
+func (obi *outgoingFirewallIptables) cleanupStaleIPv6Rules() {
+	rules, err := iptables.Exec6("-S", "OUTPUT")
+	if err != nil {
+		log.Info().Err(err).Msg("[setup] Got error while listing IPv6 rules. Probably nothing to worry about")
+		return
+	}
+	for _, rule := range rules {
+		if strings.Contains(rule, killswitchChain) {
+			if _, err := iptables.Exec6(ipv6BlockRule.RemoveArgs()...); err != nil {
+				log.Warn().Err(err).Msg("Error cleaning up ip6tables rules, you might want to do it yourself")
+			}
+		}
+	}
+}
+
 func (obi *outgoingFirewallIptables) trackingReferenceCall(ref string, actualCall func() (OutgoingRuleRemove, error)) (OutgoingRuleRemove, error) {
 	obi.lock.Lock()
 	defer obi.lock.Unlock()
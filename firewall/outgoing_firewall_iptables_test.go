@@ -123,6 +123,7 @@ func Test_outgoingFirewallIptables_SetupIsSuccessful(t *testing.T) {
 		},
 	}
 	iptables.Exec = mockedExec.Exec
+	iptables.Exec6 = mockedExec.Exec
 
 	fw := &outgoingFirewallIptables{
 		referenceTracker: make(map[string]refCount),
@@ -156,6 +157,7 @@ func Test_outgoingFirewallIptables_SetupIsSucessfulIfPreviousCleanupFailed(t *te
 		},
 	}
 	iptables.Exec = mockedExec.Exec
+	iptables.Exec6 = mockedExec.Exec
 
 	fw := &outgoingFirewallIptables{
 		referenceTracker: make(map[string]refCount),
@@ -192,6 +194,7 @@ func Test_outgoingFirewallIptables_ResetIsSuccessful(t *testing.T) {
 		},
 	}
 	iptables.Exec = mockedExec.Exec
+	iptables.Exec6 = mockedExec.Exec
 
 	fw := &outgoingFirewallIptables{
 		referenceTracker: make(map[string]refCount),
@@ -220,3 +223,21 @@ func Test_outgoingFirewallIptables_AddsAllowedIP(t *testing.T) {
 	assert.True(t, mockedExec.VerifyCalledWithArgs("-D", killswitchChain, "-d", "2.2.2.2", "-j", "ACCEPT"))
 
 }
+
+func Test_outgoingFirewallIptables_BlocksIPv6Traffic(t *testing.T) {
+	mockedExec := iptablesExecMock{
+		mocks: map[string]iptablesExecResult{},
+	}
+	iptables.Exec6 = mockedExec.Exec
+
+	fw := &outgoingFirewallIptables{
+		referenceTracker: make(map[string]refCount),
+	}
+
+	removeRuleFunc, err := fw.BlockIPv6Traffic()
+	assert.NoError(t, err)
+	assert.True(t, mockedExec.VerifyCalledWithArgs("-I", "OUTPUT", "1", "!", "-o", "lo", "-m", "comment", "--comment", killswitchChain, "-j", "REJECT"))
+
+	removeRuleFunc()
+	assert.True(t, mockedExec.VerifyCalledWithArgs("-D", "OUTPUT", "!", "-o", "lo", "-m", "comment", "--comment", killswitchChain, "-j", "REJECT"))
+}
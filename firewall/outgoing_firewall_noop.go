@@ -63,4 +63,12 @@ func (ofn *outgoingFirewallNoop) AllowURLAccess(rawURLs ...string) (OutgoingRule
 	}, nil
 }
 
+// BlockIPv6Traffic just logs the call.
+func (ofn *outgoingFirewallNoop) BlockIPv6Traffic() (OutgoingRuleRemove, error) {
+	log.Info().Msg("Outgoing IPv6 traffic block requested")
+	return func() {
+		log.Info().Msg("Outgoing IPv6 traffic block removed")
+	}, nil
+}
+
 var _ OutgoingTrafficFirewall = &outgoingFirewallNoop{}
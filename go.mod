@@ -40,7 +40,6 @@ require (
 	github.com/mysteriumnetwork/go-ci v0.0.0-20200121125840-b99aac3d815c
 	github.com/mysteriumnetwork/go-dvpn-web v0.0.36
 	github.com/mysteriumnetwork/go-openvpn v0.0.22
-	github.com/mysteriumnetwork/go-wondershaper v1.0.1
 	github.com/mysteriumnetwork/metrics v0.0.0-20191002053948-084a00d6c6b2
 	github.com/mysteriumnetwork/payments v0.0.11-0.20200325083835-89ffae399efa
 	github.com/nats-io/gnatsd v1.4.1 // indirect
@@ -27,7 +27,7 @@ import (
 func Test_UnlockAndSignAndVerify(t *testing.T) {
 	ks := NewKeystoreFilesystem("dir", NewMockKeystore(MockKeys), MockDecryptFunc)
 
-	manager := NewIdentityManager(ks, eventbus.New())
+	manager := NewIdentityManager(ks, eventbus.New(), nil)
 	err := manager.Unlock("0x53a835143c0ef3bbcbfa796d7eb738ca7dd28f68", "")
 	assert.NoError(t, err)
 
@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts"
 	ethKs "github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"golang.org/x/crypto/hkdf"
 )
 
@@ -39,8 +40,10 @@ type ks interface {
 	Find(a accounts.Account) (accounts.Account, error)
 	Unlock(a accounts.Account, passphrase string) error
 	Lock(addr common.Address) error
+	Update(a accounts.Account, passphrase, newPassphrase string) error
 	SignHash(a accounts.Account, hash []byte) ([]byte, error)
 	Export(a accounts.Account, passphrase, newPassphrase string) (keyJSON []byte, err error)
+	Import(keyJSON []byte, passphrase, newPassphrase string) (accounts.Account, error)
 }
 
 // NewKeystoreFilesystem create new keystore, which keeps keys in filesystem.
@@ -92,7 +95,31 @@ func (ks *Keystore) Unlock(a accounts.Account, passphrase string) error {
 	return nil
 }
 
-// Lock locks an account.
+// Export exports an account key encrypted with the new passphrase.
+func (ks *Keystore) Export(a accounts.Account, passphrase, newPassphrase string) ([]byte, error) {
+	return ks.ethKeystore.Export(a, passphrase, newPassphrase)
+}
+
+// Import stores a key encrypted with the new passphrase, the key must belong to the given address.
+func (ks *Keystore) Import(address common.Address, keyJSON []byte, passphrase, newPassphrase string) (accounts.Account, error) {
+	k, err := ks.keyDecryptFunc(keyJSON, passphrase)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	defer memguard.WipeBytes(k.PrivateKey.D.Bytes())
+
+	if crypto.PubkeyToAddress(k.PrivateKey.PublicKey) != address {
+		return accounts.Account{}, ErrAddressMismatch
+	}
+	return ks.ethKeystore.Import(keyJSON, passphrase, newPassphrase)
+}
+
+// ChangePassphrase re-encrypts an account key with the new passphrase.
+func (ks *Keystore) ChangePassphrase(a accounts.Account, passphrase, newPassphrase string) error {
+	return ks.ethKeystore.Update(a, passphrase, newPassphrase)
+}
+
+// Lock locks an account and wipes its derived key.
 func (ks *Keystore) Lock(addr common.Address) error {
 	defer ks.forgetDerivedKey(addr)
 	return ks.ethKeystore.Lock(addr)
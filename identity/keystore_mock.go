@@ -21,6 +21,7 @@ import (
 	"crypto/ecdsa"
 	crand "crypto/rand"
 	"encoding/hex"
+	"errors"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/accounts"
@@ -98,6 +99,28 @@ func (mk *mockKeystore) Export(a accounts.Account, passphrase, newPassphrase str
 	return nil, ethKs.ErrNoMatch
 }
 
+func (mk *mockKeystore) Import(keyJSON []byte, passphrase, newPassphrase string) (accounts.Account, error) {
+	mk.lock.Lock()
+	defer mk.lock.Unlock()
+
+	pk, err := crypto.HexToECDSA(common.Bytes2Hex(keyJSON))
+	if err != nil {
+		return accounts.Account{}, err
+	}
+
+	address := crypto.PubkeyToAddress(pk.PublicKey)
+	if _, ok := mk.keys[address]; ok {
+		return accounts.Account{}, errors.New("account already exists")
+	}
+	mk.keys[address] = MockKey{
+		Pass:  newPassphrase,
+		PkHex: common.Bytes2Hex(keyJSON),
+	}
+	return accounts.Account{
+		Address: address,
+	}, nil
+}
+
 func (mk *mockKeystore) NewAccount(passphrase string) (accounts.Account, error) {
 	mk.lock.Lock()
 	defer mk.lock.Unlock()
@@ -157,6 +180,22 @@ func (mk *mockKeystore) Lock(addr common.Address) error {
 	return nil
 }
 
+func (mk *mockKeystore) Update(a accounts.Account, passphrase, newPassphrase string) error {
+	mk.lock.Lock()
+	defer mk.lock.Unlock()
+
+	v, ok := mk.keys[a.Address]
+	if !ok {
+		return ethKs.ErrNoMatch
+	}
+	if v.Pass != passphrase {
+		return ethKs.ErrDecrypt
+	}
+	v.Pass = newPassphrase
+	mk.keys[a.Address] = v
+	return nil
+}
+
 func (mk *mockKeystore) Find(a accounts.Account) (accounts.Account, error) {
 	mk.lock.Lock()
 	defer mk.lock.Unlock()
@@ -21,6 +21,7 @@
 package identity
 
 import (
+	"strings"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/accounts"
@@ -34,14 +35,28 @@ import (
 // Identity events
 const (
 	AppTopicIdentityUnlock  = "identity-unlocked"
+	AppTopicIdentityLock    = "identity-locked"
 	AppTopicIdentityCreated = "identity-created"
 )
 
+var (
+	// ErrIdentityExists is returned when importing identity which is already in the keystore.
+	ErrIdentityExists = errors.New("identity already exists")
+	// ErrAddressMismatch is returned when imported key does not belong to the expected identity.
+	ErrAddressMismatch = errors.New("key does not belong to the identity")
+	// ErrIdentityInUse is returned when locking identity which is running services or a connection.
+	ErrIdentityInUse = errors.New("identity is in use")
+)
+
+// InUseChecker returns an error describing the usage of identity which has to stay unlocked, nil if it is not used.
+type InUseChecker func(address string) error
+
 type identityManager struct {
 	keystoreManager keystore
 	unlocked        map[string]bool // Currently unlocked addresses
 	unlockedMu      sync.RWMutex
 	eventBus        eventbus.EventBus
+	inUse           InUseChecker
 }
 
 // keystore allows actions with accounts (listing, creating, unlocking, signing)
@@ -50,15 +65,20 @@ type keystore interface {
 	NewAccount(passphrase string) (accounts.Account, error)
 	Find(a accounts.Account) (accounts.Account, error)
 	Unlock(a accounts.Account, passphrase string) error
+	Lock(addr common.Address) error
+	ChangePassphrase(a accounts.Account, passphrase, newPassphrase string) error
 	SignHash(a accounts.Account, hash []byte) ([]byte, error)
+	Export(a accounts.Account, passphrase, newPassphrase string) ([]byte, error)
+	Import(address common.Address, keyJSON []byte, passphrase, newPassphrase string) (accounts.Account, error)
 }
 
-// NewIdentityManager creates and returns new identityManager
-func NewIdentityManager(keystore keystore, eventBus eventbus.EventBus) *identityManager {
+// NewIdentityManager creates and returns new identityManager, identities are never considered in use if inUse is nil
+func NewIdentityManager(keystore keystore, eventBus eventbus.EventBus, inUse InUseChecker) *identityManager {
 	return &identityManager{
 		keystoreManager: keystore,
 		unlocked:        map[string]bool{},
 		eventBus:        eventBus,
+		inUse:           inUse,
 	}
 }
 
@@ -147,6 +167,83 @@ func (idm *identityManager) Unlock(address string, passphrase string) error {
 	return nil
 }
 
+// Lock locks the identity and forgets its cached keys, it has to be unlocked again before signing.
+// Identity which is in use is not locked, ErrIdentityInUse is returned instead.
+func (idm *identityManager) Lock(address string) error {
+	idm.unlockedMu.Lock()
+	defer idm.unlockedMu.Unlock()
+
+	account, err := idm.findAccount(address)
+	if err != nil {
+		return err
+	}
+
+	if idm.inUse != nil {
+		if err := idm.inUse(address); err != nil {
+			return errors.Wrap(ErrIdentityInUse, err.Error())
+		}
+	}
+
+	err = idm.keystoreManager.Lock(account.Address)
+	if err != nil {
+		return errors.Wrapf(err, "keystore failed to lock identity: %s", address)
+	}
+	for unlocked := range idm.unlocked {
+		if strings.EqualFold(unlocked, address) {
+			delete(idm.unlocked, unlocked)
+		}
+	}
+	log.Debug().Msgf("Forgot unlocked address: %s", address)
+
+	go idm.eventBus.Publish(AppTopicIdentityLock, address)
+
+	return nil
+}
+
+// ChangePassphrase re-encrypts the identity key with the new passphrase
+func (idm *identityManager) ChangePassphrase(address, passphrase, newPassphrase string) error {
+	account, err := idm.findAccount(address)
+	if err != nil {
+		return err
+	}
+
+	err = idm.keystoreManager.ChangePassphrase(account, passphrase, newPassphrase)
+	if err != nil {
+		return errors.Wrapf(err, "keystore failed to change passphrase of identity: %s", address)
+	}
+	return nil
+}
+
+// ExportIdentity returns keystore JSON of the identity encrypted with the new passphrase
+func (idm *identityManager) ExportIdentity(address, passphrase, newPassphrase string) ([]byte, error) {
+	account, err := idm.findAccount(address)
+	if err != nil {
+		return nil, err
+	}
+
+	keyJSON, err := idm.keystoreManager.Export(account, passphrase, newPassphrase)
+	if err != nil {
+		return nil, errors.Wrapf(err, "keystore failed to export identity: %s", address)
+	}
+	return keyJSON, nil
+}
+
+// ImportIdentity adds identity from keystore JSON encrypted with passphrase, it is stored encrypted with the new passphrase
+func (idm *identityManager) ImportIdentity(address string, keyJSON []byte, passphrase, newPassphrase string) (Identity, error) {
+	if idm.HasIdentity(address) {
+		return Identity{}, ErrIdentityExists
+	}
+
+	account, err := idm.keystoreManager.Import(common.HexToAddress(address), keyJSON, passphrase, newPassphrase)
+	if err != nil {
+		return Identity{}, errors.Wrapf(err, "keystore failed to import identity: %s", address)
+	}
+
+	identity := accountToIdentity(account)
+	idm.eventBus.Publish(AppTopicIdentityCreated, identity.Address)
+	return identity, nil
+}
+
 func (idm *identityManager) findAccount(address string) (accounts.Account, error) {
 	account, err := idm.keystoreManager.Find(addressToAccount(address))
 	if err != nil {
@@ -26,12 +26,13 @@ type idmFake struct {
 	newIdentity          Identity
 	unlockFails          bool
 	isUnlocked           bool
+	inUse                bool
 }
 
 // NewIdentityManagerFake creates fake identity manager for testing purposes
 // TODO each caller should use it's own mocked manager part instead of global one
 func NewIdentityManagerFake(existingIdentities []Identity, newIdentity Identity) *idmFake {
-	return &idmFake{"", "", existingIdentities, newIdentity, false, true}
+	return &idmFake{"", "", existingIdentities, newIdentity, false, true, false}
 }
 
 func (fakeIdm *idmFake) IsUnlocked(id string) bool {
@@ -42,6 +43,11 @@ func (fakeIdm *idmFake) MarkUnlockToFail() {
 	fakeIdm.unlockFails = true
 }
 
+// MarkInUse makes identities fail to lock as used by services or connections
+func (fakeIdm *idmFake) MarkInUse() {
+	fakeIdm.inUse = true
+}
+
 func (fakeIdm *idmFake) CreateNewIdentity(_ string) (Identity, error) {
 	return fakeIdm.newIdentity, nil
 }
@@ -60,6 +66,41 @@ func (fakeIdm *idmFake) HasIdentity(_ string) bool {
 	return true
 }
 
+func (fakeIdm *idmFake) ExportIdentity(address, _, _ string) ([]byte, error) {
+	if _, err := fakeIdm.GetIdentity(address); err != nil {
+		return nil, err
+	}
+	return []byte(`{"address":"` + address[2:] + `"}`), nil
+}
+
+func (fakeIdm *idmFake) ImportIdentity(address string, _ []byte, _, _ string) (Identity, error) {
+	if _, err := fakeIdm.GetIdentity(address); err == nil {
+		return Identity{}, ErrIdentityExists
+	}
+	return FromAddress(address), nil
+}
+
+func (fakeIdm *idmFake) Lock(address string) error {
+	if _, err := fakeIdm.GetIdentity(address); err != nil {
+		return err
+	}
+	if fakeIdm.inUse {
+		return errors.Wrap(ErrIdentityInUse, "identity "+address+" is running services")
+	}
+	fakeIdm.isUnlocked = false
+	return nil
+}
+
+func (fakeIdm *idmFake) ChangePassphrase(address, passphrase, _ string) error {
+	if _, err := fakeIdm.GetIdentity(address); err != nil {
+		return err
+	}
+	if fakeIdm.unlockFails {
+		return errors.New("passphrase change failed")
+	}
+	return nil
+}
+
 func (fakeIdm *idmFake) Unlock(address string, passphrase string) error {
 	fakeIdm.LastUnlockAddress = address
 	fakeIdm.LastUnlockPassphrase = passphrase
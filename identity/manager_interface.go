@@ -26,4 +26,8 @@ type Manager interface {
 	HasIdentity(address string) bool
 	Unlock(address string, passphrase string) error
 	IsUnlocked(address string) bool
+	Lock(address string) error
+	ChangePassphrase(address, passphrase, newPassphrase string) error
+	ExportIdentity(address, passphrase, newPassphrase string) ([]byte, error)
+	ImportIdentity(address string, keyJSON []byte, passphrase, newPassphrase string) (Identity, error)
 }
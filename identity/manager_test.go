@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/mysteriumnetwork/node/eventbus"
@@ -69,3 +70,77 @@ func Test_IdentityManager(t *testing.T) {
 		assert.False(t, idm.HasIdentity("0x000000000000000000000000000000000000000B"))
 	})
 }
+
+func Test_IdentityManager_ExportAndImport(t *testing.T) {
+	address := "0x53a835143c0ef3bbcbfa796d7eb738ca7dd28f68"
+	source := NewIdentityManager(NewKeystoreFilesystem("dir", NewMockKeystore(MockKeys), MockDecryptFunc), eventbus.New(), nil)
+	target := NewIdentityManager(NewKeystoreFilesystem("dir", NewMockKeystore(nil), MockDecryptFunc), eventbus.New(), nil)
+
+	_, err := source.ExportIdentity(address, "wrong", "backup")
+	assert.Error(t, err)
+	_, err = source.ExportIdentity("0x000000000000000000000000000000000000000B", "", "backup")
+	assert.Error(t, err)
+
+	keyJSON, err := source.ExportIdentity(address, "", "backup")
+	assert.NoError(t, err)
+
+	_, err = target.ImportIdentity("0x000000000000000000000000000000000000000B", keyJSON, "backup", "new")
+	assert.Equal(t, ErrAddressMismatch, errors.Cause(err))
+	assert.False(t, target.HasIdentity(address))
+
+	id, err := target.ImportIdentity(address, keyJSON, "backup", "new")
+	assert.NoError(t, err)
+	assert.Equal(t, FromAddress(address), id)
+	assert.True(t, target.HasIdentity(address))
+	assert.NoError(t, target.Unlock(address, "new"))
+
+	_, err = target.ImportIdentity(address, keyJSON, "backup", "new")
+	assert.Equal(t, ErrIdentityExists, err)
+}
+
+func Test_IdentityManager_ChangePassphraseAndLock(t *testing.T) {
+	address := "0x53a835143c0ef3bbcbfa796d7eb738ca7dd28f68"
+	ks := NewKeystoreFilesystem("dir", NewMockKeystore(MockKeys), MockDecryptFunc)
+	idm := NewIdentityManager(ks, eventbus.New(), nil)
+
+	assert.Error(t, idm.ChangePassphrase(address, "wrong", "new"))
+	assert.Error(t, idm.ChangePassphrase("0x000000000000000000000000000000000000000B", "", "new"))
+	assert.NoError(t, idm.ChangePassphrase(address, "", "new"))
+
+	assert.Error(t, idm.Unlock(address, ""))
+	assert.NoError(t, idm.Unlock(address, "new"))
+	assert.True(t, idm.IsUnlocked(address))
+	_, err := ks.Encrypt(common.HexToAddress(address), []byte("plaintext"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, idm.Lock(address))
+	assert.False(t, idm.IsUnlocked(address))
+	_, err = ks.Encrypt(common.HexToAddress(address), []byte("plaintext"))
+	assert.Error(t, err)
+	_, err = ks.SignHash(addressToAccount(address), []byte("hash"))
+	assert.Error(t, err)
+
+	assert.Error(t, idm.Lock("0x000000000000000000000000000000000000000B"))
+}
+
+func Test_IdentityManager_LockRefusesIdentityInUse(t *testing.T) {
+	address := "0x53a835143c0ef3bbcbfa796d7eb738ca7dd28f68"
+	ks := NewKeystoreFilesystem("dir", NewMockKeystore(MockKeys), MockDecryptFunc)
+	inUse := true
+	idm := NewIdentityManager(ks, eventbus.New(), func(string) error {
+		if inUse {
+			return errors.New("identity is running service 1")
+		}
+		return nil
+	})
+	assert.NoError(t, idm.Unlock(address, ""))
+
+	err := idm.Lock(address)
+	assert.Equal(t, ErrIdentityInUse, errors.Cause(err))
+	assert.EqualError(t, err, "identity is running service 1: identity is in use")
+	assert.True(t, idm.IsUnlocked(address))
+
+	inUse = false
+	assert.NoError(t, idm.Lock(address))
+	assert.False(t, idm.IsUnlocked(address))
+}
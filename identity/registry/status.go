@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/mysteriumnetwork/node/identity"
+	"github.com/pkg/errors"
 )
 
 // RegistrationStatus represents all the possible registration statuses
@@ -53,6 +54,16 @@ func (rs RegistrationStatus) String() string {
 	}[rs]
 }
 
+// ParseRegistrationStatus converts human readable notation back to registration status
+func ParseRegistrationStatus(value string) (RegistrationStatus, error) {
+	for rs := RegisteredConsumer; rs <= RegistrationError; rs++ {
+		if rs.String() == value {
+			return rs, nil
+		}
+	}
+	return Unregistered, errors.Errorf("unknown registration status %q", value)
+}
+
 // Registered returns flag if registration is in successful status
 func (rs RegistrationStatus) Registered() bool {
 	switch rs {
@@ -24,6 +24,12 @@ const (
 	AccessPolicyTypeDNSHostname = "dns_hostname"
 	// AccessPolicyTypeDNSZone Explicitly allow just specific DNS zone ("example.com" matches "example.com" and all of its subdomains)
 	AccessPolicyTypeDNSZone = "dns_zone"
+	// AccessPolicyTypeIPCIDR Explicitly allow just specific destination network ("10.8.0.0/16") or address ("10.8.0.1")
+	AccessPolicyTypeIPCIDR = "ip_cidr"
+	// AccessPolicyTypePort Explicitly allow just specific destination port ("443") or port range ("8000-8100"), optionally of one protocol ("tcp/443")
+	AccessPolicyTypePort = "port"
+	// AccessPolicyTypeBlockedPort Explicitly deny specific destination port ("25") or port range ("6881-6889"), optionally of one protocol ("tcp/25")
+	AccessPolicyTypeBlockedPort = "blocked_port"
 )
 
 // AccessPolicy represents the access controls for proposal
@@ -27,6 +27,13 @@ const (
 	proposalFormat = "service-proposal/v1"
 )
 
+const (
+	// NATTypeNone means that provider is directly reachable from the internet
+	NATTypeNone = "none"
+	// NATTypeBehindNAT means that provider is behind NAT and requires traversal to be reached
+	NATTypeBehindNAT = "behind_nat"
+)
+
 // ServiceProposal is top level structure which is presented to marketplace by service provider, and looked up by service consumer
 // service proposal can be marked as unsupported by deserializer, because of unknown service, payment method, or contact type
 type ServiceProposal struct {
@@ -57,6 +64,12 @@ type ServiceProposal struct {
 
 	// AccessPolicies represents the access controls for proposal
 	AccessPolicies *[]AccessPolicy `json:"access_policies,omitempty"`
+
+	// NATType describes how provider is reachable, empty if unknown
+	NATType string `json:"nat_type,omitempty"`
+
+	// FilteredDNS tells if provider DNS blocks ad, tracker and malware domains
+	FilteredDNS bool `json:"filtered_dns,omitempty"`
 }
 
 // UniqueID returns unique proposal composite ID
@@ -79,6 +92,8 @@ func (proposal *ServiceProposal) UnmarshalJSON(data []byte) error {
 		PaymentMethod     *json.RawMessage `json:"payment_method"`
 		ProviderContacts  *json.RawMessage `json:"provider_contacts"`
 		AccessPolicies    *[]AccessPolicy  `json:"access_policies,omitempty"`
+		NATType           string           `json:"nat_type,omitempty"`
+		FilteredDNS       bool             `json:"filtered_dns,omitempty"`
 	}
 	if err := json.Unmarshal(data, &jsonData); err != nil {
 		return err
@@ -106,6 +121,8 @@ func (proposal *ServiceProposal) UnmarshalJSON(data []byte) error {
 	proposal.ProviderContacts = unserializeContacts(jsonData.ProviderContacts)
 
 	proposal.AccessPolicies = jsonData.AccessPolicies
+	proposal.NATType = jsonData.NATType
+	proposal.FilteredDNS = jsonData.FilteredDNS
 	return nil
 }
 
@@ -123,6 +140,16 @@ func (proposal *ServiceProposal) SetAccessPolicies(ap *[]AccessPolicy) {
 	proposal.AccessPolicies = ap
 }
 
+// SetNATType updates service proposal with the given provider NAT type
+func (proposal *ServiceProposal) SetNATType(natType string) {
+	proposal.NATType = natType
+}
+
+// SetFilteredDNS updates service proposal with the given provider DNS filtering
+func (proposal *ServiceProposal) SetFilteredDNS(filtered bool) {
+	proposal.FilteredDNS = filtered
+}
+
 // IsSupported returns true if this service proposal can be used for connections by service consumer
 // can be used as a filter to filter out all proposals which are unsupported for any reason
 func (proposal *ServiceProposal) IsSupported() bool {
@@ -257,3 +257,41 @@ func Test_ServiceProposal_UnserializeAccessPolicy(t *testing.T) {
 	assert.Equal(t, expected, actual)
 	assert.True(t, actual.IsSupported())
 }
+
+func Test_ServiceProposal_UnserializeNATType(t *testing.T) {
+	jsonData := []byte(`{
+		"id": 1,
+		"format": "format/X",
+		"service_type": "mock_service",
+		"service_definition": null,
+		"payment_method_type": "mock_payment",
+		"payment_method": {},
+		"provider_id": "node",
+		"provider_contacts": [],
+		"nat_type": "behind_nat"
+	}`)
+
+	var actual ServiceProposal
+	err := json.Unmarshal(jsonData, &actual)
+	assert.NoError(t, err)
+	assert.Equal(t, NATTypeBehindNAT, actual.NATType)
+}
+
+func Test_ServiceProposal_UnserializeFilteredDNS(t *testing.T) {
+	jsonData := []byte(`{
+		"id": 1,
+		"format": "format/X",
+		"service_type": "mock_service",
+		"service_definition": null,
+		"payment_method_type": "mock_payment",
+		"payment_method": {},
+		"provider_id": "node",
+		"provider_contacts": [],
+		"filtered_dns": true
+	}`)
+
+	var actual ServiceProposal
+	err := json.Unmarshal(jsonData, &actual)
+	assert.NoError(t, err)
+	assert.True(t, actual.FilteredDNS)
+}
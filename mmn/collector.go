@@ -74,34 +74,9 @@ func (c *Collector) CollectEnvironmentInformation() error {
 	return nil
 }
 
-// SetIdentity sets node's identity
-func (c *Collector) SetIdentity(identity string) {
-	c.node.Identity = identity
-}
-
-// SetIsProvider marks node as a provider node
-func (c *Collector) SetIsProvider(isProvider bool) {
-	c.node.IsProvider = isProvider
-}
-
-// SetIsClient marks node as a client node
-func (c *Collector) SetIsClient(isClient bool) {
-	c.node.IsClient = isClient
-}
-
-// IsClient returns if the node is a client node
-func (c *Collector) IsClient() bool {
-	return c.node.IsClient
-}
-
-// IsProvider returns if the node is a provider node
-func (c *Collector) IsProvider() bool {
-	return c.node.IsProvider
-}
-
-// GetCollectedInformation returns collected information
-func (c *Collector) GetCollectedInformation() *NodeInformationDto {
-	return c.node
+// GetCollectedInformation returns a copy of collected information, without identity and node type set
+func (c *Collector) GetCollectedInformation() NodeInformationDto {
+	return *c.node
 }
 
 func hashMACAddress(mac string) string {
@@ -33,11 +33,18 @@ type MMN struct {
 	collector *Collector
 	client    *client
 	lock      sync.Mutex
+
+	// nodes keeps information reported for every identity, as node can provide and consume with several identities at once.
+	nodes map[string]*NodeInformationDto
 }
 
 // NewMMN creates new instance of MMN
 func NewMMN(collector *Collector, client *client) *MMN {
-	return &MMN{collector, client, sync.Mutex{}}
+	return &MMN{
+		collector: collector,
+		client:    client,
+		nodes:     make(map[string]*NodeInformationDto),
+	}
 }
 
 // Subscribe subscribes to node events and reports them to MMN
@@ -75,44 +82,61 @@ func (m *MMN) handleRegistration(identity string) {
 	}
 }
 
-func (m *MMN) handleClient(_ interface{}) {
-	if err := m.markClient(); err != nil {
+func (m *MMN) handleClient(e connection.SessionEvent) {
+	if err := m.markClient(e.SessionInfo.ConsumerID.Address); err != nil {
 		log.Error().Msgf("Failed to register to MMN as client: %v", err)
 	}
 }
 
-func (m *MMN) handleProvider(_ interface{}) {
-	if err := m.markProvider(); err != nil {
+func (m *MMN) handleProvider(e servicestate.AppEventServiceStatus) {
+	if err := m.markProvider(identity.FromAddress(e.ProviderID).Address); err != nil {
 		log.Error().Msgf("Failed to register to MMN as provider: %v", err)
 	}
 }
 
 func (m *MMN) register(identity string) error {
-	m.collector.SetIdentity(identity)
+	m.lock.Lock()
+	defer m.lock.Unlock()
 
-	return m.client.RegisterNode(m.collector.GetCollectedInformation())
+	return m.client.RegisterNode(m.node(identity))
 }
 
-func (m *MMN) markClient() error {
+func (m *MMN) markClient(identity string) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
+
+	node := m.node(identity)
 	// don't resend
-	if m.collector.IsClient() {
+	if node.IsClient {
 		return nil
 	}
-	m.collector.SetIsClient(true)
+	node.IsClient = true
 
-	return m.client.UpdateNodeType(m.collector.GetCollectedInformation())
+	return m.client.UpdateNodeType(node)
 }
 
-func (m *MMN) markProvider() error {
+func (m *MMN) markProvider(identity string) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
+
+	node := m.node(identity)
 	// don't resend
-	if m.collector.IsProvider() {
+	if node.IsProvider {
 		return nil
 	}
-	m.collector.SetIsProvider(true)
+	node.IsProvider = true
+
+	return m.client.UpdateNodeType(node)
+}
+
+// node returns information reported for the given identity, collected environment information is used for new identities.
+func (m *MMN) node(identity string) *NodeInformationDto {
+	if node, ok := m.nodes[identity]; ok {
+		return node
+	}
 
-	return m.client.UpdateNodeType(m.collector.GetCollectedInformation())
+	node := m.collector.GetCollectedInformation()
+	node.Identity = identity
+	m.nodes[identity] = &node
+	return &node
 }
@@ -35,6 +35,7 @@ import (
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/core/location"
 	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/mysteriumnetwork/node/core/quickconnect"
 	"github.com/mysteriumnetwork/node/eventbus"
 	"github.com/mysteriumnetwork/node/feedback"
 	"github.com/mysteriumnetwork/node/identity"
@@ -62,6 +63,7 @@ type MobileNode struct {
 	connectionRegistry           *connection.Registry
 	statisticsTracker            *statistics.SessionStatisticsTracker
 	proposalsManager             *proposalsManager
+	quickConnector               *quickconnect.QuickConnector
 	accountant                   identity.Identity
 	feedbackReporter             *feedback.Reporter
 	transactor                   *registry.Transactor
@@ -218,6 +220,7 @@ func NewNode(appPath string, options *MobileNodeOptions) (*MobileNode, error) {
 		eventBus:                     di.EventBus,
 		connectionRegistry:           di.ConnectionRegistry,
 		statisticsTracker:            di.StatisticsTracker,
+		quickConnector:               quickconnect.NewQuickConnector(di.ProposalRepository, di.ProposalScorer, di.ConnectionManager),
 		accountant:                   identity.FromAddress(nodeOptions.Accountant.AccountantID),
 		feedbackReporter:             di.Reporter,
 		transactor:                   di.Transactor,
@@ -230,6 +233,7 @@ func NewNode(appPath string, options *MobileNodeOptions) (*MobileNode, error) {
 			di.ProposalRepository,
 			di.MysteriumAPI,
 			di.QualityClient,
+			di.ProposalScorer,
 			&proposal.Filter{
 				UpperTimePriceBound: &nodeOptions.Payments.ConsumerUpperMinutePriceBound,
 				LowerTimePriceBound: &nodeOptions.Payments.ConsumerLowerMinutePriceBound,
@@ -381,27 +385,42 @@ func (mb *MobileNode) RegisterIdentityRegistrationChangeCallback(cb IdentityRegi
 }
 
 // ConnectRequest represents connect request.
+// When Auto is set, the best ranked provider matching ServiceType and CountryCode is chosen instead of ProviderID.
 type ConnectRequest struct {
 	IdentityAddress   string
 	ProviderID        string
 	ServiceType       string
 	DisableKillSwitch bool
+	Auto              bool
+	CountryCode       string
 }
 
 // ConnectResponse represents connect response with optional error code and message.
+// ProviderID and ServiceType describe the provider which the connection was established to.
 type ConnectResponse struct {
 	ErrorCode    string
 	ErrorMessage string
+	ProviderID   string
+	ServiceType  string
 }
 
 const (
 	connectErrInvalidProposal     = "InvalidProposal"
 	connectErrInsufficientBalance = "InsufficientBalance"
+	connectErrNoProposals         = "NoProposals"
 	connectErrUnknown             = "Unknown"
 )
 
 // Connect connects to given provider.
 func (mb *MobileNode) Connect(req *ConnectRequest) *ConnectResponse {
+	connectOptions := connection.ConnectParams{
+		DisableKillSwitch: req.DisableKillSwitch,
+		DNS:               connection.DNSOptionAuto,
+	}
+	if req.Auto {
+		return mb.connectAuto(req, connectOptions)
+	}
+
 	proposal, err := mb.proposalsManager.repository.Proposal(market.ProposalID{
 		ProviderID:  req.ProviderID,
 		ServiceType: req.ServiceType,
@@ -413,22 +432,51 @@ func (mb *MobileNode) Connect(req *ConnectRequest) *ConnectResponse {
 		}
 	}
 
-	connectOptions := connection.ConnectParams{
-		DisableKillSwitch: req.DisableKillSwitch,
-		DNS:               connection.DNSOptionAuto,
-	}
 	if err := mb.connectionManager.Connect(identity.FromAddress(req.IdentityAddress), mb.accountant, *proposal, connectOptions); err != nil {
-		if err == connection.ErrInsufficientBalance {
+		return toConnectErrorResponse(err)
+	}
+	return &ConnectResponse{
+		ProviderID:  proposal.ProviderID,
+		ServiceType: proposal.ServiceType,
+	}
+}
+
+func (mb *MobileNode) connectAuto(req *ConnectRequest, connectOptions connection.ConnectParams) *ConnectResponse {
+	filter := *mb.proposalsManager.filter
+	filter.ServiceType = req.ServiceType
+	filter.LocationCountry = req.CountryCode
+
+	result, err := mb.quickConnector.Connect(quickconnect.Request{
+		ConsumerID:   identity.FromAddress(req.IdentityAddress),
+		AccountantID: mb.accountant,
+		Filter:       &filter,
+		Params:       connectOptions,
+	})
+	if err != nil {
+		if err == quickconnect.ErrNoProposals {
 			return &ConnectResponse{
-				ErrorCode: connectErrInsufficientBalance,
+				ErrorCode:    connectErrNoProposals,
+				ErrorMessage: err.Error(),
 			}
 		}
+		return toConnectErrorResponse(err)
+	}
+	return &ConnectResponse{
+		ProviderID:  result.Proposal.ProviderID,
+		ServiceType: result.Proposal.ServiceType,
+	}
+}
+
+func toConnectErrorResponse(err error) *ConnectResponse {
+	if errors.Cause(err) == connection.ErrInsufficientBalance {
 		return &ConnectResponse{
-			ErrorCode:    connectErrUnknown,
-			ErrorMessage: err.Error(),
+			ErrorCode: connectErrInsufficientBalance,
 		}
 	}
-	return &ConnectResponse{}
+	return &ConnectResponse{
+		ErrorCode:    connectErrUnknown,
+		ErrorMessage: err.Error(),
+	}
 }
 
 // Disconnect disconnects or cancels current connection.
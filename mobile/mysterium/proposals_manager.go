@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 
 	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/core/discovery/ranking"
 	"github.com/mysteriumnetwork/node/core/quality"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/market/mysterium"
@@ -42,11 +43,21 @@ const (
 	proposalQualityLevelHigh    proposalQualityLevel = 3
 )
 
-// GetProposalsRequest represents proposals request.
+// GetProposalsRequest represents proposals request, proposals are sorted only if SortBy is set.
 type GetProposalsRequest struct {
 	ShowOpenvpnProposals   bool
 	ShowWireguardProposals bool
 	Refresh                bool
+	LocationCountry        string
+	LocationCity           string
+	LocationType           string
+	NATType                string
+	FilteredDNS            bool
+	QualityMin             float64
+	SortBy                 string
+	Order                  string
+	Limit                  int
+	Offset                 int
 }
 
 // GetProposalRequest represents proposal request.
@@ -83,12 +94,14 @@ func newProposalsManager(
 	repository proposal.Repository,
 	mysteriumAPI mysteriumAPI,
 	qualityFinder qualityFinder,
+	scorer ranking.Scorer,
 	filter *proposal.Filter,
 ) *proposalsManager {
 	return &proposalsManager{
 		repository:    repository,
 		mysteriumAPI:  mysteriumAPI,
 		qualityFinder: qualityFinder,
+		scorer:        scorer,
 		filter:        filter,
 	}
 }
@@ -98,15 +111,26 @@ type proposalsManager struct {
 	cache         []market.ServiceProposal
 	mysteriumAPI  mysteriumAPI
 	qualityFinder qualityFinder
+	scorer        ranking.Scorer
 	filter        *proposal.Filter
 }
 
 func (m *proposalsManager) getProposals(req *GetProposalsRequest) ([]byte, error) {
+	rankingOpts := ranking.Options{
+		SortBy: req.SortBy,
+		Order:  req.Order,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}
+	if err := rankingOpts.Validate(); err != nil {
+		return nil, err
+	}
+
 	// Get proposals from cache if exists.
 	if !req.Refresh {
 		cachedProposals := m.getFromCache()
 		if len(cachedProposals) > 0 {
-			return m.mapToProposalsResponse(cachedProposals)
+			return m.mapToProposalsResponse(m.rankProposals(req, rankingOpts, cachedProposals))
 		}
 	}
 
@@ -117,7 +141,33 @@ func (m *proposalsManager) getProposals(req *GetProposalsRequest) ([]byte, error
 	}
 	m.addToCache(apiProposals)
 
-	return m.mapToProposalsResponse(apiProposals)
+	return m.mapToProposalsResponse(m.rankProposals(req, rankingOpts, apiProposals))
+}
+
+func (m *proposalsManager) rankProposals(req *GetProposalsRequest, opts ranking.Options, proposals []market.ServiceProposal) []market.ServiceProposal {
+	return ranking.Rank(m.scorer, m.filterProposals(req, proposals), opts)
+}
+
+func (m *proposalsManager) filterProposals(req *GetProposalsRequest, proposals []market.ServiceProposal) []market.ServiceProposal {
+	filter := &proposal.Filter{
+		LocationCountry: req.LocationCountry,
+		LocationCity:    req.LocationCity,
+		LocationType:    req.LocationType,
+		NATType:         req.NATType,
+		FilteredDNS:     req.FilteredDNS,
+	}
+	if req.QualityMin > 0 {
+		filter.QualityMin = req.QualityMin
+		filter.QualityScores = quality.ProposalScores(m.qualityFinder.ProposalsMetrics())
+	}
+
+	var res []market.ServiceProposal
+	for _, p := range proposals {
+		if filter.Matches(p) {
+			res = append(res, p)
+		}
+	}
+	return res
 }
 
 func (m *proposalsManager) getProposal(req *GetProposalRequest) ([]byte, error) {
@@ -220,12 +270,11 @@ func (m *proposalsManager) addQualityData(proposals []*proposalDTO) {
 }
 
 func (m *proposalsManager) calculateMetricQualityLevel(counts quality.ConnectCount) proposalQualityLevel {
-	total := counts.Success + counts.Fail + counts.Timeout
-	if total == 0 {
+	if counts.Total() == 0 {
 		return proposalQualityLevelUnknown
 	}
 
-	qualityRatio := float64(counts.Success) / float64(total)
+	qualityRatio := counts.Score()
 	if qualityRatio >= qualityLevelHigh {
 		return proposalQualityLevelHigh
 	}
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/core/discovery/ranking"
 	"github.com/mysteriumnetwork/node/core/quality"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/market/mysterium"
@@ -57,6 +58,7 @@ func (s *proposalManagerTestSuite) SetupTest() {
 		s.repository,
 		s.mysteriumAPI,
 		s.qualityFinder,
+		&mockScorer{},
 		filter,
 	)
 }
@@ -106,6 +108,38 @@ func (s *proposalManagerTestSuite) TestGetProposalsFromAPIWhenNotFoundInCache()
 	assert.Equal(s.T(), "{\"proposals\":[{\"id\":0,\"providerId\":\"p1\",\"serviceType\":\"wireguard\",\"countryCode\":\"\",\"qualityLevel\":0}]}", string(bytes))
 }
 
+func (s *proposalManagerTestSuite) TestGetProposalsFiltersByQualityAndNAT() {
+	s.proposalsManager.cache = []market.ServiceProposal{
+		{ProviderID: "p1", ServiceType: "openvpn", NATType: market.NATTypeNone},
+		{ProviderID: "p2", ServiceType: "openvpn", NATType: market.NATTypeBehindNAT},
+		{ProviderID: "p3", ServiceType: "openvpn", NATType: market.NATTypeNone},
+	}
+	s.proposalsManager.qualityFinder = &mockQualityFinder{
+		metrics: []quality.ConnectMetric{
+			{
+				ProposalID:   quality.ProposalID{ProviderID: "p1", ServiceType: "openvpn"},
+				ConnectCount: quality.ConnectCount{Success: 9, Fail: 1},
+			},
+			{
+				ProposalID:   quality.ProposalID{ProviderID: "p2", ServiceType: "openvpn"},
+				ConnectCount: quality.ConnectCount{Success: 9, Fail: 1},
+			},
+			{
+				ProposalID:   quality.ProposalID{ProviderID: "p3", ServiceType: "openvpn"},
+				ConnectCount: quality.ConnectCount{Success: 1, Fail: 9},
+			},
+		},
+	}
+
+	bytes, err := s.proposalsManager.getProposals(&GetProposalsRequest{
+		NATType:    market.NATTypeNone,
+		QualityMin: 0.5,
+	})
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "{\"proposals\":[{\"id\":0,\"providerId\":\"p1\",\"serviceType\":\"openvpn\",\"countryCode\":\"\",\"qualityLevel\":3}]}", string(bytes))
+}
+
 func (s *proposalManagerTestSuite) TestGetSingleProposal() {
 	s.repository.data = []market.ServiceProposal{
 		{ProviderID: "p1", ServiceType: "wireguard"},
@@ -119,10 +153,44 @@ func (s *proposalManagerTestSuite) TestGetSingleProposal() {
 	assert.Equal(s.T(), "{\"proposal\":{\"id\":0,\"providerId\":\"p1\",\"serviceType\":\"wireguard\",\"countryCode\":\"\",\"qualityLevel\":0}}", string(bytes))
 }
 
+func (s *proposalManagerTestSuite) TestGetProposalsSortsByScore() {
+	s.proposalsManager.cache = []market.ServiceProposal{
+		{ProviderID: "p1", ServiceType: "openvpn"},
+		{ProviderID: "p2", ServiceType: "wireguard"},
+	}
+	s.proposalsManager.scorer = &mockScorer{
+		scores: map[market.ProposalID]ranking.Score{
+			{ProviderID: "p1", ServiceType: "openvpn"}:   {Total: 0.1},
+			{ProviderID: "p2", ServiceType: "wireguard"}: {Total: 0.7},
+		},
+	}
+
+	bytes, err := s.proposalsManager.getProposals(&GetProposalsRequest{SortBy: ranking.SortByScore, Limit: 1})
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "{\"proposals\":[{\"id\":0,\"providerId\":\"p2\",\"serviceType\":\"wireguard\",\"countryCode\":\"\",\"qualityLevel\":0}]}", string(bytes))
+
+	bytes, err = s.proposalsManager.getProposals(&GetProposalsRequest{Limit: 1})
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "{\"proposals\":[{\"id\":0,\"providerId\":\"p1\",\"serviceType\":\"openvpn\",\"countryCode\":\"\",\"qualityLevel\":0}]}", string(bytes))
+
+	_, err = s.proposalsManager.getProposals(&GetProposalsRequest{SortBy: "name"})
+	assert.Error(s.T(), err)
+}
+
 func TestProposalManagerSuite(t *testing.T) {
 	suite.Run(t, new(proposalManagerTestSuite))
 }
 
+type mockScorer struct {
+	scores map[market.ProposalID]ranking.Score
+}
+
+func (m *mockScorer) Score(_ []market.ServiceProposal) map[market.ProposalID]ranking.Score {
+	return m.scores
+}
+
 type mockRepository struct {
 	data []market.ServiceProposal
 }
@@ -304,6 +304,9 @@ func (w *wireguardDeviceImpl) applyConfig(devApi *device.Device, privateKey stri
 		// All traffic through this peer (unfortunately 0.0.0.0/0 didn't work as it was treated as ipv6)
 		AllowedIPs: []string{"0.0.0.0/1", "128.0.0.0/1"},
 	}
+	if config.Consumer.IPv6Address != nil {
+		peer.AllowedIPs = append(peer.AllowedIPs, "::/1", "8000::/1")
+	}
 	if err := devApi.IpcSetOperation(bufio.NewReader(strings.NewReader(peer.Encode()))); err != nil {
 		return err
 	}
@@ -316,6 +319,10 @@ func (w *wireguardDeviceImpl) newTunnDevice(wgTunnSetup WireguardTunnelSetup, co
 	wgTunnSetup.NewTunnel()
 	wgTunnSetup.SetSessionName("wg-tun-session")
 	wgTunnSetup.AddTunnelAddress(consumerIP.IP.String(), prefixLen)
+	if consumerIP6 := config.Consumer.IPv6Address; consumerIP6 != nil {
+		prefixLen6, _ := consumerIP6.Mask.Size()
+		wgTunnSetup.AddTunnelAddress(consumerIP6.IP.String(), prefixLen6)
+	}
 	wgTunnSetup.SetMTU(androidTunMtu)
 	wgTunnSetup.SetBlocking(true)
 
@@ -331,6 +338,9 @@ func (w *wireguardDeviceImpl) newTunnDevice(wgTunnSetup WireguardTunnelSetup, co
 	// Route all traffic through tunnel
 	wgTunnSetup.AddRoute("0.0.0.0", 1)
 	wgTunnSetup.AddRoute("128.0.0.0", 1)
+	// IPv6 traffic is routed through tunnel too, it is dropped there if provider does not provide IPv6
+	wgTunnSetup.AddRoute("::", 1)
+	wgTunnSetup.AddRoute("8000::", 1)
 
 	// Provider requests to delay consumer connection since it might be in a process of setting up NAT traversal for given consumer
 	if config.Consumer.ConnectDelay > 0 {
@@ -114,6 +114,7 @@ func newServiceConfig() wg.ServiceConfig {
 		},
 		Consumer: struct {
 			IPAddress    net.IPNet
+			IPv6Address  *net.IPNet
 			DNSIPs       string
 			ConnectDelay int
 		}{
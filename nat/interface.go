@@ -31,8 +31,10 @@ type NATService interface {
 // Options params to setup firewall/NAT rules.
 type Options struct {
 	VPNNetwork        net.IPNet
+	VPNNetwork6       *net.IPNet
 	ProviderExtIP     net.IP
 	EnableDNSRedirect bool
 	DNSIP             net.IP
+	DNSIP6            net.IP
 	DNSPort           int
 }
@@ -40,3 +40,20 @@ func protectedNetworks() (nets []*net.IPNet) {
 	}
 	return nets
 }
+
+// hostAddresses6 lists global IPv6 addresses of the provider host.
+var hostAddresses6 = func() (nets []*net.IPNet) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		log.Error().Err(err).Msg("Could not list host addresses")
+		return nil
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.To4() != nil || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+		nets = append(nets, &net.IPNet{IP: ipNet.IP, Mask: net.CIDRMask(8*net.IPv6len, 8*net.IPv6len)})
+	}
+	return nets
+}
@@ -29,12 +29,20 @@ import (
 )
 
 type serviceIPTables struct {
-	mu        sync.Mutex
-	rules     []iptables.Rule
-	ipForward serviceIPForward
+	mu         sync.Mutex
+	rules      []iptables.Rule
+	rules6     []iptables.Rule
+	ipForward  serviceIPForward
+	ipForward6 serviceIPForward
+	// forward6 is set once IPv6 forwarding is enabled by the first IPv6 enabled session.
+	forward6 bool
 }
 
+// rule6 is a rule applied with ip6tables.
+type rule6 iptables.Rule
+
 const (
+	chainInput       = "INPUT"
 	chainForward     = "FORWARD"
 	chainPreRouting  = "PREROUTING"
 	chainPostRouting = "POSTROUTING"
@@ -47,14 +55,14 @@ func (svc *serviceIPTables) Setup(opts Options) (appliedRules []interface{}, err
 	defer svc.mu.Unlock()
 
 	// Store applied rules so we can remove if setup exits prematurely (one of the latter rules fails to apply)
-	var applied []iptables.Rule
+	var applied []interface{}
 	defer func() {
 		if err == nil {
 			return
 		}
 		log.Warn().Msg("Error detected, clearing up rules that were already setup")
 		for _, rule := range applied {
-			if err := svc.removeRule(rule); err != nil {
+			if err := svc.remove(rule); err != nil {
 				log.Error().Err(err).Msg("Could not remove rule")
 			}
 		}
@@ -66,8 +74,18 @@ func (svc *serviceIPTables) Setup(opts Options) (appliedRules []interface{}, err
 		}
 		applied = append(applied, rule)
 	}
+
+	if opts.VPNNetwork6 != nil {
+		svc.enableIPForward6()
+		for _, rule := range makeIP6TablesRules(opts) {
+			if err := svc.applyRule6(rule); err != nil {
+				return nil, err
+			}
+			applied = append(applied, rule6(rule))
+		}
+	}
 	log.Info().Msg("Setting up NAT/Firewall rules... done")
-	return untypedIptRules(applied), nil
+	return applied, nil
 }
 
 // Del removes given NAT/Firewall rules that were previously set up.
@@ -77,9 +95,9 @@ func (svc *serviceIPTables) Del(rules []interface{}) (err error) {
 	defer svc.mu.Unlock()
 
 	errs := utils.ErrorCollection{}
-	for _, rule := range typedIptRules(rules) {
+	for _, rule := range rules {
 		log.Trace().Msgf("Deleting rule: %v", rule)
-		if err := svc.removeRule(rule); err != nil {
+		if err := svc.remove(rule); err != nil {
 			errs.Add(err)
 		}
 	}
@@ -100,7 +118,42 @@ func (svc *serviceIPTables) Enable() error {
 // Disable disables NAT service and deletes all rules.
 func (svc *serviceIPTables) Disable() error {
 	svc.ipForward.Disable()
-	return svc.Del(untypedIptRules(svc.rules))
+
+	svc.mu.Lock()
+	if svc.forward6 {
+		svc.ipForward6.Disable()
+		svc.forward6 = false
+	}
+	rules := untypedIptRules(svc.rules)
+	for _, rule := range svc.rules6 {
+		rules = append(rules, rule6(rule))
+	}
+	svc.mu.Unlock()
+
+	return svc.Del(rules)
+}
+
+// enableIPForward6 enables IPv6 forwarding when it is needed for the first time,
+// so that providers without IPv6 tunnels keep their system configuration intact.
+func (svc *serviceIPTables) enableIPForward6() {
+	if svc.forward6 {
+		return
+	}
+	if err := svc.ipForward6.Enable(); err != nil {
+		log.Warn().Err(err).Msg("Failed to enable IPv6 forwarding")
+	}
+	svc.forward6 = true
+}
+
+func (svc *serviceIPTables) remove(rule interface{}) error {
+	switch r := rule.(type) {
+	case iptables.Rule:
+		return svc.removeRule(r)
+	case rule6:
+		return svc.removeRule6(iptables.Rule(r))
+	default:
+		return errors.Errorf("unsupported rule type %T", rule)
+	}
 }
 
 func (svc *serviceIPTables) applyRule(rule iptables.Rule) error {
@@ -115,15 +168,35 @@ func (svc *serviceIPTables) removeRule(rule iptables.Rule) error {
 	if err := iptablesExec(rule.RemoveArgs()...); err != nil {
 		return err
 	}
-	for i := range svc.rules {
-		if svc.rules[i].Equals(rule) {
-			svc.rules = append(svc.rules[:i], svc.rules[i+1:]...)
-			break
-		}
+	svc.rules = withoutRule(svc.rules, rule)
+	return nil
+}
+
+func (svc *serviceIPTables) applyRule6(rule iptables.Rule) error {
+	if err := ip6tablesExec(rule.ApplyArgs()...); err != nil {
+		return err
+	}
+	svc.rules6 = append(svc.rules6, rule)
+	return nil
+}
+
+func (svc *serviceIPTables) removeRule6(rule iptables.Rule) error {
+	if err := ip6tablesExec(rule.RemoveArgs()...); err != nil {
+		return err
 	}
+	svc.rules6 = withoutRule(svc.rules6, rule)
 	return nil
 }
 
+func withoutRule(rules []iptables.Rule, rule iptables.Rule) []iptables.Rule {
+	for i := range rules {
+		if rules[i].Equals(rule) {
+			return append(rules[:i], rules[i+1:]...)
+		}
+	}
+	return rules
+}
+
 func makeIPTablesRules(opts Options) (rules []iptables.Rule) {
 	vpnNetwork := opts.VPNNetwork.String()
 
@@ -149,6 +222,9 @@ func makeIPTablesRules(opts Options) (rules []iptables.Rule) {
 
 	// Protect private networks rule
 	for _, ipNet := range protectedNetworks() {
+		if ipNet.IP.To4() == nil {
+			continue
+		}
 		rule := iptables.AppendTo(chainForward).RuleSpec(
 			"--source", vpnNetwork, "--destination", ipNet.String(),
 			"--jump", "DROP")
@@ -164,6 +240,52 @@ func makeIPTablesRules(opts Options) (rules []iptables.Rule) {
 	return rules
 }
 
+func makeIP6TablesRules(opts Options) (rules []iptables.Rule) {
+	vpnNetwork := opts.VPNNetwork6.String()
+
+	if opts.EnableDNSRedirect && opts.DNSIP6 != nil {
+		for _, protocol := range []string{"udp", "tcp"} {
+			rule := iptables.AppendTo(chainPreRouting).RuleSpec(
+				"--source", vpnNetwork, "--destination", opts.DNSIP6.String(), "--protocol", protocol, "--dport", strconv.Itoa(53),
+				"--jump", "REDIRECT",
+				"--to-ports", strconv.Itoa(opts.DNSPort),
+				"--table", "nat",
+			)
+			rules = append(rules, rule)
+		}
+	}
+
+	// Protect private networks rule
+	for _, ipNet := range protectedNetworks() {
+		if ipNet.IP.To4() != nil {
+			continue
+		}
+		rule := iptables.AppendTo(chainForward).RuleSpec(
+			"--source", vpnNetwork, "--destination", ipNet.String(),
+			"--jump", "DROP")
+		rules = append(rules, rule)
+	}
+
+	// Protect the provider host itself, its global addresses are reachable through the tunnel otherwise
+	for _, ipNet := range hostAddresses6() {
+		if opts.VPNNetwork6.Contains(ipNet.IP) {
+			continue
+		}
+		rule := iptables.AppendTo(chainInput).RuleSpec(
+			"--source", vpnNetwork, "--destination", ipNet.String(),
+			"--jump", "DROP")
+		rules = append(rules, rule)
+	}
+
+	// NAT66 forwarding rule, provider's IPv6 address is chosen by the outgoing interface
+	rule := iptables.AppendTo(chainPostRouting).RuleSpec("--source", vpnNetwork, "!", "--destination", vpnNetwork,
+		"--jump", "MASQUERADE",
+		"--table", "nat")
+	rules = append(rules, rule)
+
+	return rules
+}
+
 func iptablesExec(args ...string) error {
 	args = append([]string{"/sbin/iptables"}, args...)
 	if err := cmdutil.SudoExec(args...); err != nil {
@@ -172,18 +294,18 @@ func iptablesExec(args ...string) error {
 	return nil
 }
 
-func untypedIptRules(rules []iptables.Rule) []interface{} {
-	res := make([]interface{}, len(rules))
-	for i := range rules {
-		res[i] = rules[i]
+func ip6tablesExec(args ...string) error {
+	args = append([]string{"/sbin/ip6tables"}, args...)
+	if err := cmdutil.SudoExec(args...); err != nil {
+		return errors.Wrap(err, "error calling IP6Tables")
 	}
-	return res
+	return nil
 }
 
-func typedIptRules(rules []interface{}) []iptables.Rule {
-	res := make([]iptables.Rule, len(rules))
+func untypedIptRules(rules []iptables.Rule) []interface{} {
+	res := make([]interface{}, len(rules))
 	for i := range rules {
-		res[i] = rules[i].(iptables.Rule)
+		res[i] = rules[i]
 	}
 	return res
 }
@@ -0,0 +1,62 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nat
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_makeIP6TablesRules(t *testing.T) {
+	_, vpnNetwork, _ := net.ParseCIDR("fd00:6d79:7374:200::2/64")
+
+	config.Current.SetDefault(config.FlagFirewallProtectedNetworks.Name, "10.0.0.0/8,fc00::/7,::1/128")
+	defer config.Current.SetDefault(config.FlagFirewallProtectedNetworks.Name, "")
+	hostAddresses := hostAddresses6
+	hostAddresses6 = func() []*net.IPNet {
+		return []*net.IPNet{
+			{IP: net.ParseIP("2001:db8::10"), Mask: net.CIDRMask(128, 128)},
+			{IP: net.ParseIP("fd00:6d79:7374:200::1"), Mask: net.CIDRMask(128, 128)},
+		}
+	}
+	defer func() { hostAddresses6 = hostAddresses }()
+
+	rules := makeIP6TablesRules(Options{
+		VPNNetwork6:       vpnNetwork,
+		EnableDNSRedirect: true,
+		DNSIP6:            net.ParseIP("fd00:6d79:7374:200::1"),
+		DNSPort:           11253,
+	})
+
+	var args []string
+	for _, rule := range rules {
+		args = append(args, strings.Join(rule.ApplyArgs(), " "))
+	}
+	assert.Equal(t, []string{
+		"-A PREROUTING --source fd00:6d79:7374:200::/64 --destination fd00:6d79:7374:200::1 --protocol udp --dport 53 --jump REDIRECT --to-ports 11253 --table nat",
+		"-A PREROUTING --source fd00:6d79:7374:200::/64 --destination fd00:6d79:7374:200::1 --protocol tcp --dport 53 --jump REDIRECT --to-ports 11253 --table nat",
+		"-A FORWARD --source fd00:6d79:7374:200::/64 --destination fc00::/7 --jump DROP",
+		"-A FORWARD --source fd00:6d79:7374:200::/64 --destination ::1/128 --jump DROP",
+		"-A INPUT --source fd00:6d79:7374:200::/64 --destination 2001:db8::10/128 --jump DROP",
+		"-A POSTROUTING --source fd00:6d79:7374:200::/64 ! --destination fd00:6d79:7374:200::/64 --jump MASQUERADE --table nat",
+	}, args)
+}
@@ -109,6 +109,10 @@ func makePfctlRules(opts Options) (rules []string, err error) {
 	if len(networks) > 0 {
 		var targets []string
 		for _, network := range networks {
+			// Rule is for inet only, IPv6 networks would make it invalid
+			if network.IP.To4() == nil {
+				continue
+			}
 			targets = append(targets, network.String())
 		}
 		rule := fmt.Sprintf("no nat on %s inet from %s to { %s }",
@@ -41,6 +41,17 @@ var (
 	ErrSendTimeout = errors.New("p2p send timeout")
 )
 
+// PeerError represents public error returned by the peer handler.
+type PeerError struct {
+	// Code is set when the handler returned the error with a code, see Context.ErrorWithCode.
+	Code    string
+	Message string
+}
+
+func (e *PeerError) Error() string {
+	return "public peer error: " + e.Message
+}
+
 // ChannelSender is used to send messages.
 type ChannelSender interface {
 	// Send sends message to given topic. Peer listening to topic will receive message.
@@ -252,6 +263,7 @@ func (c *channel) handleRequest(msg *transportMsg) {
 	} else if ctx.publicError != nil {
 		log.Err(ctx.publicError).Msgf("Handler %q public error", msg.topic)
 		resMsg.statusCode = statusCodePublicErr
+		resMsg.errorCode = ctx.publicErrorCode
 		resMsg.data = []byte(ctx.publicError.Error())
 	} else {
 		resMsg.statusCode = statusCodeOK
@@ -315,7 +327,7 @@ func (c *channel) sendRequest(ctx context.Context, topic string, m *Message) (*M
 	case res := <-s.resCh:
 		if res.statusCode != statusCodeOK {
 			if res.statusCode == statusCodePublicErr {
-				return nil, fmt.Errorf("public peer error: %s", string(res.data))
+				return nil, &PeerError{Code: res.errorCode, Message: string(res.data)}
 			}
 			return nil, errors.New("internal peer error")
 		}
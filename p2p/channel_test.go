@@ -160,6 +160,15 @@ func TestChannelFullCommunicationFlow(t *testing.T) {
 		assert.EqualError(t, err, "public peer error: I don't like you")
 	})
 
+	t.Run("Test peer returns public error with code", func(t *testing.T) {
+		provider.Handle("get-error", func(c Context) error {
+			return c.ErrorWithCode("denied", errors.New("I don't like you"))
+		})
+
+		_, err := consumer.Send(context.Background(), "get-error", &Message{Data: []byte("hello")})
+		assert.Equal(t, &PeerError{Code: "denied", Message: "I don't like you"}, err)
+	})
+
 	t.Run("Test peer returns internal error", func(t *testing.T) {
 		provider.Handle("get-error", func(c Context) error {
 			return errors.New("I don't like you")
@@ -25,6 +25,9 @@ type Context interface {
 	// Error allows to return error which will be seen for peer.
 	Error(err error) error
 
+	// ErrorWithCode allows to return error which will be seen for peer together with the code to distinguish it by.
+	ErrorWithCode(code string, err error) error
+
 	// OkWithReply indicates that request was handled successfully and returns reply with given message.
 	OkWithReply(msg *Message) error
 
@@ -33,9 +36,10 @@ type Context interface {
 }
 
 type defaultContext struct {
-	req         *Message
-	res         *Message
-	publicError error
+	req             *Message
+	res             *Message
+	publicError     error
+	publicErrorCode string
 }
 
 func (d *defaultContext) Request() *Message {
@@ -47,6 +51,12 @@ func (d *defaultContext) Error(err error) error {
 	return nil
 }
 
+func (d *defaultContext) ErrorWithCode(code string, err error) error {
+	d.publicError = err
+	d.publicErrorCode = code
+	return nil
+}
+
 func (d *defaultContext) OkWithReply(msg *Message) error {
 	d.res = msg
 	return nil
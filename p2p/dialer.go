@@ -30,6 +30,7 @@ import (
 	"github.com/mysteriumnetwork/node/communication/nats"
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/core/port"
+	"github.com/mysteriumnetwork/node/eventbus"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/pb"
 
@@ -45,8 +46,9 @@ type Dialer interface {
 }
 
 // NewDialer creates new p2p communication dialer which is used on consumer side.
-func NewDialer(broker brokerConnector, address string, signer identity.SignerFactory, verifier identity.Verifier, ipResolver ip.Resolver, consumerPinger natConsumerPinger, portPool port.ServicePortSupplier) Dialer {
+func NewDialer(broker brokerConnector, address string, signer identity.SignerFactory, verifier identity.Verifier, ipResolver ip.Resolver, consumerPinger natConsumerPinger, portPool port.ServicePortSupplier, publisher eventbus.Publisher) Dialer {
 	return &dialer{
+		publisher:      publisher,
 		broker:         broker,
 		brokerAddress:  address,
 		ipResolver:     ipResolver,
@@ -59,6 +61,7 @@ func NewDialer(broker brokerConnector, address string, signer identity.SignerFac
 
 // dialer implements Dialer interface.
 type dialer struct {
+	publisher      eventbus.Publisher
 	portPool       port.ServicePortSupplier
 	broker         brokerConnector
 	consumerPinger natConsumerPinger
@@ -71,6 +74,14 @@ type dialer struct {
 // Dial exchanges p2p configuration via broker, performs NAT pinging if needed
 // and create p2p channel which is ready for communication.
 func (m *dialer) Dial(ctx context.Context, consumerID identity.Identity, serviceType string, providerID identity.Identity) (Channel, error) {
+	channel, err := m.dial(ctx, consumerID, serviceType, providerID)
+	if err != nil {
+		m.publisher.Publish(AppTopicChannelError, AppEventChannelError{Side: SideConsumer, ServiceType: serviceType, Error: err})
+	}
+	return channel, err
+}
+
+func (m *dialer) dial(ctx context.Context, consumerID identity.Identity, serviceType string, providerID identity.Identity) (Channel, error) {
 	brokerConn, err := m.broker.Connect(m.brokerAddress)
 	if err != nil {
 		return nil, fmt.Errorf("could not open broker conn: %w", err)
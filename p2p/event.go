@@ -0,0 +1,35 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+// AppTopicChannelError represents the topic of p2p channel establishment failures.
+const AppTopicChannelError = "p2p_channel_error"
+
+const (
+	// SideConsumer indicates the failure of the channel dialed by consumer.
+	SideConsumer = "consumer"
+	// SideProvider indicates the failure of the channel accepted by provider.
+	SideProvider = "provider"
+)
+
+// AppEventChannelError represents the p2p channel establishment failure.
+type AppEventChannelError struct {
+	Side        string
+	ServiceType string
+	Error       error
+}
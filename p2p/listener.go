@@ -26,6 +26,7 @@ import (
 	"github.com/mysteriumnetwork/node/communication/nats"
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/core/port"
+	"github.com/mysteriumnetwork/node/eventbus"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/pb"
 
@@ -42,8 +43,9 @@ type Listener interface {
 }
 
 // NewListener creates new p2p communication listener which is used on provider side.
-func NewListener(broker brokerConnector, address string, signer identity.SignerFactory, verifier identity.Verifier, ipResolver ip.Resolver, providerPinger natProviderPinger, portPool port.ServicePortSupplier) Listener {
+func NewListener(broker brokerConnector, address string, signer identity.SignerFactory, verifier identity.Verifier, ipResolver ip.Resolver, providerPinger natProviderPinger, portPool port.ServicePortSupplier, publisher eventbus.Publisher) Listener {
 	return &listener{
+		publisher:      publisher,
 		broker:         broker,
 		brokerAddress:  address,
 		pendingConfigs: map[PublicKey]*p2pConnectConfig{},
@@ -57,6 +59,7 @@ func NewListener(broker brokerConnector, address string, signer identity.SignerF
 
 // listener implements Listener interface.
 type listener struct {
+	publisher      eventbus.Publisher
 	portPool       port.ServicePortSupplier
 	broker         brokerConnector
 	providerPinger natProviderPinger
@@ -105,6 +108,7 @@ func (m *listener) Listen(providerID identity.Identity, serviceType string, chan
 	_, err = brokerConn.Subscribe(configExchangeSubject(providerID, serviceType), func(msg *nats_lib.Msg) {
 		if err := m.providerStartConfigExchange(brokerConn, providerID, msg, outboundIP); err != nil {
 			log.Err(err).Msg("Could not handle initial exchange")
+			m.publishError(serviceType, err)
 			return
 		}
 	})
@@ -113,6 +117,7 @@ func (m *listener) Listen(providerID identity.Identity, serviceType string, chan
 		config, err := m.providerAckConfigExchange(msg)
 		if err != nil {
 			log.Err(err).Msg("Could not handle exchange ack")
+			m.publishError(serviceType, err)
 			return
 		}
 
@@ -131,11 +136,13 @@ func (m *listener) Listen(providerID identity.Identity, serviceType string, chan
 			conn1, err = net.DialUDP("udp4", &net.UDPAddr{Port: config.localPorts[0]}, &net.UDPAddr{IP: net.ParseIP(config.peerPublicIP), Port: config.peerPorts[0]})
 			if err != nil {
 				log.Err(err).Msg("Could not create UDP conn for p2p channel")
+				m.publishError(serviceType, err)
 				return
 			}
 			conn2, err = net.DialUDP("udp4", &net.UDPAddr{Port: config.localPorts[1]}, &net.UDPAddr{IP: net.ParseIP(config.peerPublicIP), Port: config.peerPorts[1]})
 			if err != nil {
 				log.Err(err).Msg("Could not create UDP conn for service")
+				m.publishError(serviceType, err)
 				return
 			}
 		} else {
@@ -143,6 +150,7 @@ func (m *listener) Listen(providerID identity.Identity, serviceType string, chan
 			conns, err := m.providerPinger.PingConsumerPeer(config.pingIP(), config.localPorts, config.peerPorts, providerInitialTTL, requiredConnCount)
 			if err != nil {
 				log.Err(err).Msg("Could not ping peer")
+				m.publishError(serviceType, err)
 				return
 			}
 			conn1 = conns[0]
@@ -151,6 +159,7 @@ func (m *listener) Listen(providerID identity.Identity, serviceType string, chan
 		channel, err := newChannel(conn1, config.privateKey, config.peerPubKey)
 		if err != nil {
 			log.Err(err).Msg("Could not create channel")
+			m.publishError(serviceType, err)
 			return
 		}
 		channel.setServiceConn(conn2)
@@ -160,6 +169,7 @@ func (m *listener) Listen(providerID identity.Identity, serviceType string, chan
 		// Send handlers ready to consumer
 		if err := m.providerChannelHandlersReady(brokerConn, providerID, serviceType); err != nil {
 			log.Err(err).Msg("Could not handle channel handlers ready")
+			m.publishError(serviceType, err)
 			return
 		}
 	})
@@ -167,6 +177,10 @@ func (m *listener) Listen(providerID identity.Identity, serviceType string, chan
 	return err
 }
 
+func (m *listener) publishError(serviceType string, err error) {
+	m.publisher.Publish(AppTopicChannelError, AppEventChannelError{Side: SideProvider, ServiceType: serviceType, Error: err})
+}
+
 func (m *listener) providerStartConfigExchange(brokerConn nats.Connection, signerID identity.Identity, msg *nats_lib.Msg, outboundIP string) error {
 	pubKey, privateKey, err := GenerateKey()
 	if err != nil {
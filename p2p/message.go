@@ -79,6 +79,7 @@ const (
 	headerFieldRequestID = "Request-ID"
 	headerFieldTopic     = "Topic"
 	headerStatusCode     = "Status-Code"
+	headerErrorCode      = "Error-Code"
 
 	statusCodeOK          = 1
 	statusCodePublicErr   = 2
@@ -90,6 +91,7 @@ type transportMsg struct {
 	// Header fields.
 	id         uint64
 	statusCode uint64
+	errorCode  string
 	topic      string
 
 	// Data field.
@@ -112,6 +114,7 @@ func (m *transportMsg) readFrom(conn *textproto.Reader) error {
 		return fmt.Errorf("could not parse status code: %w", err)
 	}
 	m.statusCode = statusCode
+	m.errorCode = header.Get(headerErrorCode)
 	m.topic = header.Get(headerFieldTopic)
 
 	// Read data.
@@ -131,6 +134,9 @@ func (m *transportMsg) writeTo(conn *textproto.Writer) error {
 	header.WriteString(fmt.Sprintf("%s:%d\r\n", headerFieldRequestID, m.id))
 	header.WriteString(fmt.Sprintf("%s:%s\r\n", headerFieldTopic, m.topic))
 	header.WriteString(fmt.Sprintf("%s:%d\r\n", headerStatusCode, m.statusCode))
+	if m.errorCode != "" {
+		header.WriteString(fmt.Sprintf("%s:%s\r\n", headerErrorCode, m.errorCode))
+	}
 	header.WriteByte('\n')
 	w.Write(header.Bytes())
 	w.Write(m.data)
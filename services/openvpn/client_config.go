@@ -55,6 +55,20 @@ func (c *ClientConfig) SetProtocol(protocol string) {
 	}
 }
 
+// SetRoutes routes either all traffic or only the included networks through the tunnel,
+// excluded networks are routed through the default gateway.
+func (c *ClientConfig) SetRoutes(routes connection.Routes) {
+	if len(routes.Included) == 0 {
+		c.SetParam("redirect-gateway", "def1", "bypass-dhcp")
+	}
+	for _, network := range routes.Included {
+		c.SetParam("route", network.IP.String(), net.IP(network.Mask).String())
+	}
+	for _, network := range routes.Excluded {
+		c.SetParam("route", network.IP.String(), net.IP(network.Mask).String(), "net_gateway")
+	}
+}
+
 func defaultClientConfig(runtimeDir string, scriptSearchPath string) *ClientConfig {
 	clientConfig := ClientConfig{GenericConfig: config.NewConfig(runtimeDir, scriptSearchPath), VpnConfig: nil}
 
@@ -70,7 +84,6 @@ func defaultClientConfig(runtimeDir string, scriptSearchPath string) *ClientConf
 
 	clientConfig.SetParam("reneg-sec", "0")
 	clientConfig.SetParam("resolv-retry", "infinite")
-	clientConfig.SetParam("redirect-gateway", "def1", "bypass-dhcp")
 
 	return &clientConfig
 }
@@ -113,6 +126,7 @@ func NewClientConfigFromSession(vpnConfig VPNConfig, configDir string, runtimeDi
 	clientFileConfig.SetReconnectRetry(2)
 	clientFileConfig.SetClientMode(vpnConfig.RemoteIP, remotePort, localPort)
 	clientFileConfig.SetProtocol(vpnConfig.RemoteProtocol)
+	clientFileConfig.SetRoutes(options.Routes)
 	clientFileConfig.SetTLSCACertificate(vpnConfig.CACertificate)
 	clientFileConfig.SetTLSCrypt(vpnConfig.TLSPresharedKey)
 
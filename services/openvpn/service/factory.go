@@ -25,6 +25,7 @@ import (
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/core/node"
 	"github.com/mysteriumnetwork/node/core/port"
+	"github.com/mysteriumnetwork/node/core/shaper"
 	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/nat"
@@ -39,6 +40,7 @@ const statisticsReportingIntervalInSeconds = 1
 type eventBus interface {
 	Publish(topic string, data interface{})
 	SubscribeAsync(topic string, fn interface{}) error
+	Unsubscribe(topic string, fn interface{}) error
 }
 
 // NewManager creates new instance of Openvpn service
@@ -73,6 +75,7 @@ func NewManager(nodeOptions node.Options,
 	}
 
 	return &Manager{
+		clientMap:       clientMap,
 		nodeOptions:     nodeOptions,
 		serviceOptions:  serviceOptions,
 		natService:      natService,
@@ -81,7 +84,8 @@ func NewManager(nodeOptions node.Options,
 		natPinger:       natPinger,
 		natEventGetter:  natEventGetter,
 		ports:           portPool,
-		eventListener:   bus,
+		eventBus:        bus,
+		trafficShaper:   shaper.New(bus, serviceOptions.Shaper),
 		portMapper:      portMapper,
 		trafficFirewall: trafficFirewall,
 		country:         country,
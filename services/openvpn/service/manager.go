@@ -28,6 +28,7 @@ import (
 	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/mysteriumnetwork/node/core/policy"
 	"github.com/mysteriumnetwork/node/core/port"
 	"github.com/mysteriumnetwork/node/core/service"
 	"github.com/mysteriumnetwork/node/core/shaper"
@@ -40,6 +41,7 @@ import (
 	"github.com/mysteriumnetwork/node/nat/traversal"
 	openvpn_service "github.com/mysteriumnetwork/node/services/openvpn"
 	"github.com/mysteriumnetwork/node/session"
+	sessionEvent "github.com/mysteriumnetwork/node/session/event"
 	"github.com/mysteriumnetwork/node/utils/netutil"
 	"github.com/mysteriumnetwork/node/utils/stringutil"
 	"github.com/rs/zerolog/log"
@@ -60,8 +62,8 @@ type NATEventGetter interface {
 	LastEvent() *event.Event
 }
 
-type eventListener interface {
-	SubscribeAsync(topic string, fn interface{}) error
+type clientSessions interface {
+	GetClientSessions(clientID int) []session.ID
 }
 
 // Manager represents entrypoint for Openvpn service with top level components
@@ -72,7 +74,11 @@ type Manager struct {
 	natPinger       NATPinger
 	natEventGetter  NATEventGetter
 	dnsProxy        *dns.Proxy
-	eventListener   eventListener
+	blocklist       *dns.Blocklist
+	eventBus        eventBus
+	clientMap       clientSessions
+	clientTracker   *shaperMiddleware
+	trafficShaper   shaper.Shaper
 	portMapper      mapping.PortMapper
 	trafficFirewall firewall.IncomingTrafficFirewall
 	vpnNetwork      net.IPNet
@@ -97,20 +103,26 @@ func (m *Manager) Serve(instance *service.Instance) (err error) {
 		Mask: net.IPMask(net.ParseIP(m.serviceOptions.Netmask).To4()),
 	}
 
+	deviceName := func() string {
+		return m.openvpnProcess.DeviceName()
+	}
+	m.clientTracker = newShaperMiddleware(m.trafficShaper, deviceName)
+
 	var dnsPort = 11153
 	dnsHandler, err := dns.ResolveViaSystem()
 	if err == nil {
-		if instance.Policies().HasDNSRules() {
+		// DNS rules may be added by the policies reloaded later, so answers are whitelisted whenever there are any policies.
+		if len(instance.Policies().Policies()) > 0 {
 			dnsHandler = dns.WhitelistAnswers(dnsHandler, m.trafficFirewall, instance.Policies())
-			removeRule, err := m.trafficFirewall.BlockIncomingTraffic(m.vpnNetwork)
-			if err != nil {
-				return fmt.Errorf("failed to enable traffic blocking: %w", err)
+		}
+		if blocklistOpts := m.serviceOptions.DNSBlocklist; blocklistOpts.Enabled {
+			m.blocklist = dns.NewBlocklist(blocklistOpts)
+			if err := m.blocklist.Load(); err != nil {
+				log.Warn().Err(err).Msg("DNS blocklist is empty until the next refresh")
 			}
-			defer func() {
-				if err := removeRule(); err != nil {
-					log.Warn().Err(err).Msg("failed to disable traffic blocking")
-				}
-			}()
+			m.blocklist.Start()
+			defer m.blocklist.Stop()
+			dnsHandler = dns.BlockDomains(dnsHandler, m.blocklist, blocklistOpts.Response, m.publishDNSBlocked)
 		}
 
 		m.dnsProxy = dns.NewProxy("", dnsPort, dnsHandler)
@@ -124,6 +136,16 @@ func (m *Manager) Serve(instance *service.Instance) (err error) {
 		log.Warn().Err(err).Msg("Provider DNS will not be available")
 	}
 
+	removeRule, err := policy.EnforceTrafficRules(m.trafficFirewall, m.vpnNetwork, instance.Policies(), m.dnsOK)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := removeRule(); err != nil {
+			log.Warn().Err(err).Msg("failed to disable traffic blocking")
+		}
+	}()
+
 	servicePort, err := m.ports.Acquire()
 	if err != nil {
 		return fmt.Errorf("failed to acquire an unused port: %w", err)
@@ -153,7 +175,14 @@ func (m *Manager) Serve(instance *service.Instance) (err error) {
 
 	stateChannel := make(chan openvpn.State, 10)
 
-	protectedNetworks := stringutil.Split(config.GetString(config.FlagFirewallProtectedNetworks), ',')
+	var protectedNetworks []string
+	for _, network := range stringutil.Split(config.GetString(config.FlagFirewallProtectedNetworks), ',') {
+		// OpenVPN packet filter accepts IPv4 subnets only
+		if ip, _, err := net.ParseCIDR(network); err == nil && ip.To4() == nil {
+			continue
+		}
+		protectedNetworks = append(protectedNetworks, network)
+	}
 	var openvpnFilterAllow []string
 	if m.dnsOK {
 		openvpnFilterAllow = []string{m.dnsIP.String()}
@@ -171,10 +200,11 @@ func (m *Manager) Serve(instance *service.Instance) (err error) {
 	)
 
 	m.openvpnProcess = m.processLauncher.launch(launchOpts{
-		config:       vpnServerConfig,
-		filterAllow:  openvpnFilterAllow,
-		filterBlock:  protectedNetworks,
-		stateChannel: stateChannel,
+		config:           vpnServerConfig,
+		filterAllow:      openvpnFilterAllow,
+		filterBlock:      protectedNetworks,
+		stateChannel:     stateChannel,
+		shaperMiddleware: m.clientTracker,
 	})
 
 	// register service port to which NATProxy will forward connects attempts to
@@ -204,12 +234,11 @@ func (m *Manager) Serve(instance *service.Instance) (err error) {
 		return fmt.Errorf("failed to setup NAT/firewall rules: %w", err)
 	}
 
-	s := shaper.New(m.eventListener)
-	err = s.Start(m.openvpnProcess.DeviceName())
+	err = m.trafficShaper.Start(m.openvpnProcess.DeviceName())
 	if err != nil {
 		log.Error().Err(err).Msg("Could not start traffic shaper")
 	}
-	defer s.Clear(m.openvpnProcess.DeviceName())
+	defer m.trafficShaper.Clear(m.openvpnProcess.DeviceName())
 
 	log.Info().Msg("OpenVPN server waiting")
 	return m.openvpnProcess.Wait()
@@ -229,6 +258,9 @@ func (m *Manager) Stop() error {
 	if m.openvpnProcess != nil {
 		m.openvpnProcess.Stop()
 	}
+	if m.trafficShaper != nil {
+		m.trafficShaper.Stop()
+	}
 
 	if m.dnsProxy != nil {
 		if err := m.dnsProxy.Stop(); err != nil {
@@ -239,6 +271,18 @@ func (m *Manager) Stop() error {
 	return nil
 }
 
+// publishDNSBlocked counts the blocked DNS query for every session of the client.
+func (m *Manager) publishDNSBlocked(clientIP net.IP) {
+	clientID, ok := m.clientTracker.clientID(clientIP)
+	if !ok {
+		return
+	}
+
+	for _, sessionID := range m.clientMap.GetClientSessions(clientID) {
+		m.eventBus.Publish(sessionEvent.AppTopicDNSBlocked, sessionEvent.AppEventDNSBlocked{ID: string(sessionID)})
+	}
+}
+
 // ProvideConfig takes session creation config from end consumer and provides the service configuration to the end consumer
 func (m *Manager) ProvideConfig(_ string, sessionConfig json.RawMessage, conn *net.UDPConn) (*session.ConfigParams, error) {
 	if m.vpnServerPort == 0 {
@@ -18,8 +18,11 @@
 package service
 
 import (
+	"net"
 	"testing"
 
+	"github.com/mysteriumnetwork/node/session"
+	sessionEvent "github.com/mysteriumnetwork/node/session/event"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -28,3 +31,46 @@ func TestManager_StopNotPanic(t *testing.T) {
 	err := m.Stop()
 	assert.NoError(t, err)
 }
+
+func TestManager_PublishDNSBlockedForEverySessionOfClient(t *testing.T) {
+	bus := &mockEventBus{}
+	tracker := newShaperMiddleware(nil, nil)
+	tracker.clientIPs["7"] = net.ParseIP("10.8.0.2")
+	m := Manager{
+		eventBus:      bus,
+		clientTracker: tracker,
+		clientMap:     &mockClientSessions{sessions: map[int][]session.ID{7: {"s1", "s2"}}},
+	}
+
+	m.publishDNSBlocked(net.ParseIP("10.8.0.2"))
+	m.publishDNSBlocked(net.ParseIP("10.8.0.3"))
+
+	assert.Equal(t, []interface{}{
+		sessionEvent.AppEventDNSBlocked{ID: "s1"},
+		sessionEvent.AppEventDNSBlocked{ID: "s2"},
+	}, bus.published)
+}
+
+type mockEventBus struct {
+	published []interface{}
+}
+
+func (b *mockEventBus) Publish(_ string, data interface{}) {
+	b.published = append(b.published, data)
+}
+
+func (b *mockEventBus) SubscribeAsync(_ string, _ interface{}) error {
+	return nil
+}
+
+func (b *mockEventBus) Unsubscribe(_ string, _ interface{}) error {
+	return nil
+}
+
+type mockClientSessions struct {
+	sessions map[int][]session.ID
+}
+
+func (m *mockClientSessions) GetClientSessions(clientID int) []session.ID {
+	return m.sessions[clientID]
+}
@@ -22,24 +22,30 @@ import (
 
 	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/core/shaper"
+	"github.com/mysteriumnetwork/node/dns"
 	"github.com/rs/zerolog/log"
 )
 
 // Options describes options which are required to start Openvpn service
 type Options struct {
-	Protocol string `json:"protocol"`
-	Port     int    `json:"port"`
-	Subnet   string `json:"subnet"`
-	Netmask  string `json:"netmask"`
+	Protocol     string               `json:"protocol"`
+	Port         int                  `json:"port"`
+	Subnet       string               `json:"subnet"`
+	Netmask      string               `json:"netmask"`
+	Shaper       shaper.Options       `json:"shaper"`
+	DNSBlocklist dns.BlocklistOptions `json:"dns_blocklist"`
 }
 
 // GetOptions returns effective OpenVPN service options from application configuration.
 func GetOptions() Options {
 	return Options{
-		Protocol: config.GetString(config.FlagOpenvpnProtocol),
-		Port:     config.GetInt(config.FlagOpenvpnPort),
-		Subnet:   config.GetString(config.FlagOpenvpnSubnet),
-		Netmask:  config.GetString(config.FlagOpenvpnNetmask),
+		Protocol:     config.GetString(config.FlagOpenvpnProtocol),
+		Port:         config.GetInt(config.FlagOpenvpnPort),
+		Subnet:       config.GetString(config.FlagOpenvpnSubnet),
+		Netmask:      config.GetString(config.FlagOpenvpnNetmask),
+		Shaper:       shaper.ConfiguredOptions(),
+		DNSBlocklist: dns.ConfiguredBlocklistOptions(),
 	}
 }
 
@@ -55,5 +61,8 @@ func ParseJSONOptions(request *json.RawMessage) (service.Options, error) {
 		log.Warn().Err(err).Msg("Failed to parse options from request, using effective options")
 		return &Options{}, err
 	}
+	if err := requestOptions.DNSBlocklist.Validate(); err != nil {
+		return &Options{}, err
+	}
 	return requestOptions, nil
 }
@@ -0,0 +1,121 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mysteriumnetwork/go-openvpn/openvpn/management"
+	"github.com/mysteriumnetwork/node/core/shaper"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	clientAddressPrefix    = ">CLIENT:ADDRESS,"
+	clientDisconnectPrefix = ">CLIENT:DISCONNECT,"
+)
+
+// shaperMiddleware applies per session bandwidth limits to OpenVPN clients once they are assigned a tunnel IP.
+// It only observes management lines, so they are still handled by the other middlewares.
+// Tracked tunnel IPs are also used to find the clients sending DNS queries.
+type shaperMiddleware struct {
+	shaper     shaper.Shaper
+	deviceName func() string
+
+	clientIPs map[string]net.IP
+	lock      sync.Mutex
+}
+
+func newShaperMiddleware(s shaper.Shaper, deviceName func() string) *shaperMiddleware {
+	return &shaperMiddleware{
+		shaper:     s,
+		deviceName: deviceName,
+		clientIPs:  make(map[string]net.IP),
+	}
+}
+
+// Start is called when the management interface is ready.
+func (m *shaperMiddleware) Start(_ management.CommandWriter) error {
+	return nil
+}
+
+// Stop is called when the management interface is being closed.
+func (m *shaperMiddleware) Stop(_ management.CommandWriter) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for clientID, ip := range m.clientIPs {
+		m.shaper.RemoveSession(m.deviceName(), ip)
+		delete(m.clientIPs, clientID)
+	}
+	return nil
+}
+
+// clientID returns the ID of the client which was assigned the given tunnel IP.
+func (m *shaperMiddleware) clientID(ip net.IP) (int, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for clientID, clientIP := range m.clientIPs {
+		if clientIP.Equal(ip) {
+			id, err := strconv.Atoi(clientID)
+			return id, err == nil
+		}
+	}
+	return 0, false
+}
+
+// ConsumeLine tracks tunnel IPs of the clients, lines are never consumed.
+func (m *shaperMiddleware) ConsumeLine(line string) (bool, error) {
+	switch {
+	case strings.HasPrefix(line, clientAddressPrefix):
+		// >CLIENT:ADDRESS,{CID},{ADDR},{PRI}
+		fields := strings.Split(strings.TrimPrefix(line, clientAddressPrefix), ",")
+		if len(fields) < 2 {
+			return false, nil
+		}
+		ip := net.ParseIP(fields[1])
+		if ip == nil {
+			return false, nil
+		}
+
+		m.lock.Lock()
+		m.clientIPs[fields[0]] = ip
+		m.lock.Unlock()
+
+		if err := m.shaper.AddSession(m.deviceName(), ip); err != nil {
+			log.Error().Err(err).Msgf("Could not limit bandwidth of client %s", ip)
+		}
+	case strings.HasPrefix(line, clientDisconnectPrefix):
+		// >CLIENT:DISCONNECT,{CID}
+		clientID := strings.TrimPrefix(line, clientDisconnectPrefix)
+
+		m.lock.Lock()
+		ip, ok := m.clientIPs[clientID]
+		delete(m.clientIPs, clientID)
+		m.lock.Unlock()
+
+		if ok {
+			m.shaper.RemoveSession(m.deviceName(), ip)
+		}
+	}
+	return false, nil
+}
@@ -36,6 +36,7 @@ func SharedConfiguredOptions() config.ServicesOptions {
 		AccessPolicyAddress:       config.GetString(config.FlagAccessPolicyAddress),
 		AccessPolicyList:          policies,
 		AccessPolicyFetchInterval: config.GetDuration(config.FlagAccessPolicyFetchInterval),
+		AccessPolicyFile:          config.GetString(config.FlagAccessPolicyFile),
 		ShaperEnabled:             config.GetBool(config.FlagShaperEnabled),
 	}
 }
@@ -72,6 +72,7 @@ type Connection struct {
 	ipResolver          ip.Resolver
 	connectionEndpoint  wg.ConnectionEndpoint
 	removeAllowedIPRule func()
+	removeIPv6Block     func()
 	opts                Options
 	natPinger           traversal.NATProviderPinger
 	connEndpointFactory wg.EndpointFactory
@@ -120,6 +121,14 @@ func (c *Connection) Start(options connection.ConnectOptions) (err error) {
 
 	c.stateCh <- connection.Connecting
 
+	// IPv6 traffic would leak outside of the tunnel when provider does not provide IPv6.
+	if options.KillSwitch && config.Consumer.IPv6Address == nil {
+		c.removeIPv6Block, err = firewall.BlockIPv6Traffic()
+		if err != nil {
+			return errors.Wrap(err, "failed to block IPv6 traffic")
+		}
+	}
+
 	if options.ProviderNATConn != nil {
 		options.ProviderNATConn.Close()
 		config.LocalPort = options.ProviderNATConn.LocalAddr().(*net.UDPAddr).Port
@@ -137,9 +146,10 @@ func (c *Connection) Start(options connection.ConnectOptions) (err error) {
 
 	log.Info().Msg("Starting new connection")
 	conn, err := c.startConn(wg.ConsumerModeConfig{
-		PrivateKey: c.privateKey,
-		IPAddress:  config.Consumer.IPAddress,
-		ListenPort: config.LocalPort,
+		PrivateKey:  c.privateKey,
+		IPAddress:   config.Consumer.IPAddress,
+		IPv6Address: config.Consumer.IPv6Address,
+		ListenPort:  config.LocalPort,
 	})
 	if err != nil {
 		return errors.Wrap(err, "could not start new connection")
@@ -153,7 +163,8 @@ func (c *Connection) Start(options connection.ConnectOptions) (err error) {
 	}
 
 	log.Info().Msg("Configuring routes")
-	if err := conn.ConfigureRoutes(config.Provider.Endpoint.IP); err != nil {
+	routes := wg.Routes{Included: options.Routes.Included, Excluded: options.Routes.Excluded}
+	if err := conn.ConfigureRoutes(config.Provider.Endpoint.IP, routes); err != nil {
 		return errors.Wrap(err, "failed to configure routes for connection endpoint")
 	}
 
@@ -263,6 +274,10 @@ func (c *Connection) Stop() {
 			c.removeAllowedIPRule()
 		}
 
+		if c.removeIPv6Block != nil {
+			c.removeIPv6Block()
+		}
+
 		c.stateCh <- connection.NotConnected
 
 		close(c.stateCh)
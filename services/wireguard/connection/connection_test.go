@@ -57,6 +57,28 @@ func TestConnectionStartStop(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestConnectionConfiguresSplitTunnelRoutes(t *testing.T) {
+	conn := newConn(t)
+	_, included, _ := net.ParseCIDR("10.0.0.0/8")
+	_, excluded, _ := net.ParseCIDR("10.1.0.0/16")
+
+	sessionConfig, _ := json.Marshal(newServiceConfig())
+	err := conn.Start(connection.ConnectOptions{
+		DNS:           "1.2.3.4",
+		SessionConfig: sessionConfig,
+		Routes: connection.Routes{
+			Included: []net.IPNet{*included},
+			Excluded: []net.IPNet{*excluded},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, wg.Routes{
+		Included: []net.IPNet{*included},
+		Excluded: []net.IPNet{*excluded},
+	}, conn.connectionEndpoint.(*mockConnectionEndpoint).routes)
+}
+
 func TestConnectionStopAfterHandshakeError(t *testing.T) {
 	conn := newConn(t)
 	handshakeTimeoutErr := errors.New("handshake timeout")
@@ -117,6 +139,7 @@ func newServiceConfig() wg.ServiceConfig {
 		},
 		Consumer: struct {
 			IPAddress    net.IPNet
+			IPv6Address  *net.IPNet
 			DNSIPs       string
 			ConnectDelay int
 		}{
@@ -130,7 +153,9 @@ func newServiceConfig() wg.ServiceConfig {
 	}
 }
 
-type mockConnectionEndpoint struct{}
+type mockConnectionEndpoint struct {
+	routes wg.Routes
+}
 
 func (mce *mockConnectionEndpoint) StartConsumerMode(config wg.ConsumerModeConfig) error { return nil }
 func (mce *mockConnectionEndpoint) StartProviderMode(config wg.ProviderModeConfig) error { return nil }
@@ -139,7 +164,10 @@ func (mce *mockConnectionEndpoint) Stop() error
 func (mce *mockConnectionEndpoint) Config() (wg.ServiceConfig, error)                    { return wg.ServiceConfig{}, nil }
 func (mce *mockConnectionEndpoint) AddPeer(_ string, _ wg.Peer) error                    { return nil }
 func (mce *mockConnectionEndpoint) RemovePeer(_ string) error                            { return nil }
-func (mce *mockConnectionEndpoint) ConfigureRoutes(_ net.IP) error                       { return nil }
+func (mce *mockConnectionEndpoint) ConfigureRoutes(_ net.IP, routes wg.Routes) error {
+	mce.routes = routes
+	return nil
+}
 func (mce *mockConnectionEndpoint) PeerStats() (*wg.Stats, error) {
 	return &wg.Stats{LastHandshake: time.Now(), BytesSent: 10, BytesReceived: 11}, nil
 }
@@ -46,6 +46,7 @@ type connectionEndpoint struct {
 	iface             string
 	privateKey        string
 	ipAddr            net.IPNet
+	ipAddr6           *net.IPNet
 	endpoint          net.UDPAddr
 	resourceAllocator *resources.Allocator
 	wgClient          wgClient
@@ -64,11 +65,13 @@ func (ce *connectionEndpoint) StartConsumerMode(config wg.ConsumerModeConfig) er
 
 	ce.iface = iface
 	ce.ipAddr = config.IPAddress
+	ce.ipAddr6 = config.IPv6Address
 	ce.privateKey = config.PrivateKey
 
 	deviceConfig := wg.DeviceConfig{
 		IfaceName:  ce.iface,
 		Subnet:     ce.ipAddr,
+		Subnet6:    ce.ipAddr6,
 		ListenPort: config.ListenPort,
 		PrivateKey: ce.privateKey,
 	}
@@ -102,12 +105,16 @@ func (ce *connectionEndpoint) StartProviderMode(config wg.ProviderModeConfig) (e
 
 	ce.ipAddr = config.Network
 	ce.ipAddr.IP = netutil.FirstIP(ce.ipAddr)
+	if config.Network6 != nil {
+		ce.ipAddr6 = &net.IPNet{IP: netutil.FirstIP(*config.Network6), Mask: config.Network6.Mask}
+	}
 
 	ce.endpoint = net.UDPAddr{IP: net.ParseIP(config.PublicIP), Port: config.ListenPort}
 
 	deviceConfig := wg.DeviceConfig{
 		IfaceName:  ce.iface,
 		Subnet:     ce.ipAddr,
+		Subnet6:    ce.ipAddr6,
 		ListenPort: ce.endpoint.Port,
 		PrivateKey: ce.privateKey,
 	}
@@ -149,11 +156,18 @@ func (ce *connectionEndpoint) Config() (wg.ServiceConfig, error) {
 	config.Provider.Endpoint = ce.endpoint
 	config.Consumer.IPAddress = ce.ipAddr
 	config.Consumer.IPAddress.IP = ce.consumerIP(ce.ipAddr)
+	if ce.ipAddr6 != nil {
+		config.Consumer.IPv6Address = &net.IPNet{
+			IP:   resources.IPv6Address(*ce.ipAddr6, config.Consumer.IPAddress.IP),
+			Mask: ce.ipAddr6.Mask,
+		}
+	}
 	return config, nil
 }
 
-func (ce *connectionEndpoint) ConfigureRoutes(ip net.IP) error {
-	return ce.wgClient.ConfigureRoutes(ce.iface, ip)
+func (ce *connectionEndpoint) ConfigureRoutes(ip net.IP, routes wg.Routes) error {
+	routes.IPv6 = ce.ipAddr6 != nil
+	return ce.wgClient.ConfigureRoutes(ce.iface, ip, routes)
 }
 
 // Stop closes wireguard client and destroys wireguard network interface.
@@ -35,6 +35,8 @@ import (
 type client struct {
 	iface    string
 	wgClient *wgctrl.Client
+	// routes added by ConfigureRoutes, deleted on Close.
+	routes []net.IPNet
 }
 
 // NewWireguardClient creates new wireguard kernel space client.
@@ -55,7 +57,7 @@ func (c *client) ConfigureDevice(config wg.DeviceConfig) error {
 	}
 	deviceConfig.PrivateKey = &privateKey
 	deviceConfig.ListenPort = &port
-	if err := c.up(config.IfaceName, config.Subnet); err != nil {
+	if err := c.up(config.IfaceName, config.Subnet, config.Subnet6); err != nil {
 		return err
 	}
 	c.iface = config.IfaceName
@@ -129,7 +131,7 @@ func (c *client) DestroyDevice(name string) error {
 	return cmdutil.SudoExec("ip", "link", "del", "dev", name)
 }
 
-func (c *client) up(iface string, ipAddr net.IPNet) error {
+func (c *client) up(iface string, ipAddr net.IPNet, ipAddr6 *net.IPNet) error {
 	if d, err := c.wgClient.Device(iface); err != nil || d.Name != iface {
 		if err := cmdutil.SudoExec("ip", "link", "add", "dev", iface, "type", "wireguard"); err != nil {
 			return err
@@ -139,15 +141,56 @@ func (c *client) up(iface string, ipAddr net.IPNet) error {
 	if err := cmdutil.SudoExec("ip", "address", "replace", "dev", iface, ipAddr.String()); err != nil {
 		return err
 	}
+	if ipAddr6 != nil {
+		if err := cmdutil.SudoExec("ip", "-6", "address", "replace", "dev", iface, ipAddr6.String()); err != nil {
+			return err
+		}
+	}
 
 	return cmdutil.SudoExec("ip", "link", "set", "dev", iface, "up")
 }
 
-func (c *client) ConfigureRoutes(iface string, ip net.IP) error {
+func (c *client) ConfigureRoutes(iface string, ip net.IP, routes wg.Routes) error {
 	if err := excludeRoute(ip); err != nil {
 		return err
 	}
-	return addDefaultRoute(iface)
+	c.routes = append(c.routes, net.IPNet{IP: ip, Mask: net.CIDRMask(8*len(ip), 8*len(ip))})
+	for _, network := range routes.Excluded {
+		if err := excludeNetwork(network); err != nil {
+			return err
+		}
+		c.routes = append(c.routes, network)
+	}
+	if len(routes.Included) == 0 {
+		if err := addDefaultRoute(iface); err != nil {
+			return err
+		}
+		c.routes = append(c.routes, wg.DefaultRoutes...)
+		if routes.IPv6 {
+			if err := addDefaultRoute6(iface); err != nil {
+				return err
+			}
+			c.routes = append(c.routes, wg.DefaultRoutes6...)
+		}
+		return nil
+	}
+	for _, network := range routes.Included {
+		if err := addRoute(iface, network); err != nil {
+			return err
+		}
+		c.routes = append(c.routes, network)
+	}
+	return nil
+}
+
+// deleteRoutes deletes the routes added by ConfigureRoutes, the ones via gateway outlive the device otherwise.
+func (c *client) deleteRoutes() {
+	for _, network := range c.routes {
+		if err := deleteRoute(network); err != nil {
+			log.Warn().Err(err).Msgf("Failed to delete route %s", network.String())
+		}
+	}
+	c.routes = nil
 }
 
 func excludeRoute(ip net.IP) error {
@@ -159,6 +202,19 @@ func excludeRoute(ip net.IP) error {
 	return cmdutil.SudoExec("ip", "route", "replace", ip.String(), "via", gw.String())
 }
 
+func excludeNetwork(network net.IPNet) error {
+	gw, err := gateway.DiscoverGateway()
+	if err != nil {
+		return err
+	}
+
+	return cmdutil.SudoExec("ip", "route", "replace", network.String(), "via", gw.String())
+}
+
+func addRoute(iface string, network net.IPNet) error {
+	return cmdutil.SudoExec("ip", "route", "replace", network.String(), "dev", iface)
+}
+
 func addDefaultRoute(iface string) error {
 	if err := cmdutil.SudoExec("ip", "route", "replace", "0.0.0.0/1", "dev", iface); err != nil {
 		return err
@@ -166,6 +222,20 @@ func addDefaultRoute(iface string) error {
 	return cmdutil.SudoExec("ip", "route", "replace", "128.0.0.0/1", "dev", iface)
 }
 
+func addDefaultRoute6(iface string) error {
+	if err := cmdutil.SudoExec("ip", "-6", "route", "replace", "::/1", "dev", iface); err != nil {
+		return err
+	}
+	return cmdutil.SudoExec("ip", "-6", "route", "replace", "8000::/1", "dev", iface)
+}
+
+func deleteRoute(network net.IPNet) error {
+	if network.IP.To4() == nil {
+		return cmdutil.SudoExec("ip", "-6", "route", "del", network.String())
+	}
+	return cmdutil.SudoExec("ip", "route", "del", network.String())
+}
+
 func (c *client) Close() (err error) {
 	var errs []error
 	defer func() {
@@ -177,6 +247,7 @@ func (c *client) Close() (err error) {
 		}
 	}()
 
+	c.deleteRoutes()
 	if err := c.DestroyDevice(c.iface); err != nil {
 		errs = append(errs, err)
 	}
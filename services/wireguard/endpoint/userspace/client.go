@@ -25,6 +25,7 @@ import (
 
 	wg "github.com/mysteriumnetwork/node/services/wireguard"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 	"golang.zx2c4.com/wireguard/device"
 	"golang.zx2c4.com/wireguard/tun"
 )
@@ -32,6 +33,9 @@ import (
 type client struct {
 	tun    tun.Device
 	devAPI *device.Device
+	iface  string
+	// routes added by ConfigureRoutes, deleted on Close.
+	routes []net.IPNet
 }
 
 // NewWireguardClient creates new wireguard user space client.
@@ -43,6 +47,11 @@ func (c *client) ConfigureDevice(config wg.DeviceConfig) (err error) {
 	if c.tun, err = CreateTUN(config.IfaceName, config.Subnet); err != nil {
 		return errors.Wrap(err, "failed to create TUN device")
 	}
+	if config.Subnet6 != nil {
+		if err := assignIP6(config.IfaceName, *config.Subnet6); err != nil {
+			return errors.Wrap(err, "failed to assign IPv6 address")
+		}
+	}
 
 	c.devAPI = device.NewDevice(c.tun, device.NewLogger(device.LogLevelDebug, "[userspace-wg]"))
 	if err := c.setDeviceConfig(config.Encode()); err != nil {
@@ -69,15 +78,53 @@ func (c *client) RemovePeer(_ string, publicKey string) error {
 }
 
 func (c *client) Close() error {
+	c.deleteRoutes()
 	c.devAPI.Close() // c.devAPI.Close() closes c.tun too
 	return nil
 }
 
-func (c *client) ConfigureRoutes(iface string, ip net.IP) error {
+func (c *client) ConfigureRoutes(iface string, ip net.IP, routes wg.Routes) error {
+	c.iface = iface
 	if err := excludeRoute(ip); err != nil {
 		return err
 	}
-	return addDefaultRoute(iface)
+	c.routes = append(c.routes, net.IPNet{IP: ip, Mask: net.CIDRMask(8*len(ip), 8*len(ip))})
+	for _, network := range routes.Excluded {
+		if err := excludeNetwork(network); err != nil {
+			return err
+		}
+		c.routes = append(c.routes, network)
+	}
+	if len(routes.Included) == 0 {
+		if err := addDefaultRoute(iface); err != nil {
+			return err
+		}
+		c.routes = append(c.routes, wg.DefaultRoutes...)
+		if routes.IPv6 {
+			if err := addDefaultRoute6(iface); err != nil {
+				return err
+			}
+			c.routes = append(c.routes, wg.DefaultRoutes6...)
+		}
+		return nil
+	}
+	for _, network := range routes.Included {
+		if err := addRoute(iface, network); err != nil {
+			return err
+		}
+		c.routes = append(c.routes, network)
+	}
+	return nil
+}
+
+// deleteRoutes deletes the routes added by ConfigureRoutes, the ones via gateway outlive the device otherwise.
+func (c *client) deleteRoutes() {
+	for _, network := range c.routes {
+		if err := deleteRoute(c.iface, network); err != nil {
+			log.Warn().Err(err).Msgf("Failed to delete route %s", network.String())
+		}
+	}
+	c.routes = nil
 }
 
 func (c *client) PeerStats() (*wg.Stats, error) {
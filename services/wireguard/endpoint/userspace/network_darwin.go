@@ -19,6 +19,7 @@ package userspace
 
 import (
 	"net"
+	"strconv"
 
 	"github.com/jackpal/gateway"
 	"github.com/mysteriumnetwork/node/utils/cmdutil"
@@ -28,6 +29,11 @@ func assignIP(iface string, subnet net.IPNet) error {
 	return cmdutil.SudoExec("ifconfig", iface, subnet.String(), peerIP(subnet).String())
 }
 
+func assignIP6(iface string, subnet net.IPNet) error {
+	ones, _ := subnet.Mask.Size()
+	return cmdutil.SudoExec("ifconfig", iface, "inet6", subnet.IP.String(), "prefixlen", strconv.Itoa(ones), "alias")
+}
+
 func excludeRoute(ip net.IP) error {
 	gw, err := gateway.DiscoverGateway()
 	if err != nil {
@@ -37,6 +43,19 @@ func excludeRoute(ip net.IP) error {
 	return cmdutil.SudoExec("route", "add", "-host", ip.String(), gw.String())
 }
 
+func excludeNetwork(network net.IPNet) error {
+	gw, err := gateway.DiscoverGateway()
+	if err != nil {
+		return err
+	}
+
+	return cmdutil.SudoExec("route", "add", "-net", network.String(), gw.String())
+}
+
+func addRoute(iface string, network net.IPNet) error {
+	return cmdutil.SudoExec("route", "add", "-net", network.String(), "-interface", iface)
+}
+
 func addDefaultRoute(iface string) error {
 	if err := cmdutil.SudoExec("route", "add", "-net", "0.0.0.0/1", "-interface", iface); err != nil {
 		return err
@@ -45,6 +64,24 @@ func addDefaultRoute(iface string) error {
 	return cmdutil.SudoExec("route", "add", "-net", "128.0.0.0/1", "-interface", iface)
 }
 
+func addDefaultRoute6(iface string) error {
+	if err := cmdutil.SudoExec("route", "add", "-inet6", "-net", "::/1", "-interface", iface); err != nil {
+		return err
+	}
+
+	return cmdutil.SudoExec("route", "add", "-inet6", "-net", "8000::/1", "-interface", iface)
+}
+
+func deleteRoute(_ string, network net.IPNet) error {
+	if network.IP.To4() == nil {
+		return cmdutil.SudoExec("route", "delete", "-inet6", "-net", network.String())
+	}
+	if ones, bits := network.Mask.Size(); ones == bits {
+		return cmdutil.SudoExec("route", "delete", "-host", network.IP.String())
+	}
+	return cmdutil.SudoExec("route", "delete", "-net", network.String())
+}
+
 func peerIP(subnet net.IPNet) net.IP {
 	lastOctetID := len(subnet.IP) - 1
 	if subnet.IP[lastOctetID] == byte(1) {
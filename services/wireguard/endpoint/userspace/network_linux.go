@@ -31,6 +31,10 @@ func assignIP(iface string, subnet net.IPNet) error {
 	return cmdutil.SudoExec("ip", "link", "set", "dev", iface, "up")
 }
 
+func assignIP6(iface string, subnet net.IPNet) error {
+	return cmdutil.SudoExec("ip", "-6", "address", "replace", "dev", iface, subnet.String())
+}
+
 func excludeRoute(ip net.IP) error {
 	gw, err := gateway.DiscoverGateway()
 	if err != nil {
@@ -40,6 +44,19 @@ func excludeRoute(ip net.IP) error {
 	return cmdutil.SudoExec("route", "add", "-host", ip.String(), gw.String())
 }
 
+func excludeNetwork(network net.IPNet) error {
+	gw, err := gateway.DiscoverGateway()
+	if err != nil {
+		return err
+	}
+
+	return cmdutil.SudoExec("route", "add", "-net", network.String(), gw.String())
+}
+
+func addRoute(iface string, network net.IPNet) error {
+	return cmdutil.SudoExec("route", "add", "-net", network.String(), "-interface", iface)
+}
+
 func addDefaultRoute(iface string) error {
 	if err := cmdutil.SudoExec("route", "add", "-net", "0.0.0.0/1", "-interface", iface); err != nil {
 		return err
@@ -48,6 +65,21 @@ func addDefaultRoute(iface string) error {
 	return cmdutil.SudoExec("route", "add", "-net", "128.0.0.0/1", "-interface", iface)
 }
 
+func addDefaultRoute6(iface string) error {
+	if err := cmdutil.SudoExec("ip", "-6", "route", "replace", "::/1", "dev", iface); err != nil {
+		return err
+	}
+
+	return cmdutil.SudoExec("ip", "-6", "route", "replace", "8000::/1", "dev", iface)
+}
+
+func deleteRoute(_ string, network net.IPNet) error {
+	if network.IP.To4() == nil {
+		return cmdutil.SudoExec("ip", "-6", "route", "del", network.String())
+	}
+	return cmdutil.SudoExec("ip", "route", "del", network.String())
+}
+
 func destroyDevice(name string) error {
 	return cmdutil.SudoExec("ip", "link", "del", "dev", name)
 }
@@ -32,6 +32,11 @@ func assignIP(iface string, subnet net.IPNet) error {
 	return errors.Wrap(err, string(out))
 }
 
+func assignIP6(iface string, subnet net.IPNet) error {
+	out, err := exec.Command("powershell", "-Command", "netsh interface ipv6 add address interface=\""+iface+"\" address="+subnet.String()).CombinedOutput()
+	return errors.Wrap(err, string(out))
+}
+
 func renameInterface(name, newname string) error {
 	out, err := exec.Command("powershell", "-Command", "netsh interface set interface name=\""+name+"\" newname=\""+newname+"\"").CombinedOutput()
 	return errors.Wrap(err, string(out))
@@ -47,6 +52,26 @@ func excludeRoute(ip net.IP) error {
 	return errors.Wrap(err, string(out))
 }
 
+func excludeNetwork(network net.IPNet) error {
+	gw, err := gateway.DiscoverGateway()
+	if err != nil {
+		return err
+	}
+
+	out, err := exec.Command("powershell", "-Command", "route add "+network.String()+" "+gw.String()).CombinedOutput()
+	return errors.Wrap(err, string(out))
+}
+
+func addRoute(name string, network net.IPNet) error {
+	id, gw, err := interfaceInfo(name)
+	if err != nil {
+		return errors.Wrap(err, "failed to get info of interface: "+name)
+	}
+
+	out, err := exec.Command("powershell", "-Command", "route add "+network.String()+" "+gw+" if "+id).CombinedOutput()
+	return errors.Wrap(err, string(out))
+}
+
 func addDefaultRoute(name string) error {
 	id, gw, err := interfaceInfo(name)
 	if err != nil {
@@ -61,6 +86,36 @@ func addDefaultRoute(name string) error {
 	return errors.Wrap(err, string(out))
 }
 
+func addDefaultRoute6(name string) error {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return errors.Wrap(err, "failed to get interface "+name)
+	}
+
+	id := strconv.Itoa(iface.Index)
+	if out, err := exec.Command("powershell", "-Command", "netsh interface ipv6 add route ::/1 interface="+id).CombinedOutput(); err != nil {
+		return errors.Wrap(err, string(out))
+	}
+
+	out, err := exec.Command("powershell", "-Command", "netsh interface ipv6 add route 8000::/1 interface="+id).CombinedOutput()
+	return errors.Wrap(err, string(out))
+}
+
+func deleteRoute(name string, network net.IPNet) error {
+	if network.IP.To4() == nil {
+		iface, err := net.InterfaceByName(name)
+		if err != nil {
+			return errors.Wrap(err, "failed to get interface "+name)
+		}
+
+		out, err := exec.Command("powershell", "-Command", "netsh interface ipv6 delete route "+network.String()+" interface="+strconv.Itoa(iface.Index)).CombinedOutput()
+		return errors.Wrap(err, string(out))
+	}
+
+	out, err := exec.Command("powershell", "-Command", "route delete "+network.IP.String()+" mask "+net.IP(network.Mask).String()).CombinedOutput()
+	return errors.Wrap(err, string(out))
+}
+
 func destroyDevice(name string) error {
 	// Windows implementation is using single device that are reused for the future needs.
 	// Nothing to destroy here.
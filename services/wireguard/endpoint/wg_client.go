@@ -30,7 +30,7 @@ import (
 
 type wgClient interface {
 	ConfigureDevice(config wg.DeviceConfig) error
-	ConfigureRoutes(iface string, ip net.IP) error
+	ConfigureRoutes(iface string, ip net.IP, routes wg.Routes) error
 	DestroyDevice(name string) error
 	AddPeer(iface string, peer wg.Peer) error
 	RemovePeer(name string, publicKey string) error
@@ -0,0 +1,68 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package resources
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ipv6SessionPrefix is the prefix length of IPv6 network allocated for a single connection.
+	ipv6SessionPrefix = 64
+	// ipv6SubnetPrefix is the maximum prefix length of IPv6 subnet used by the service.
+	ipv6SubnetPrefix = 48
+)
+
+// ValidateIPv6Subnet checks if the given subnet can hold IPv6 networks of all the connections.
+func ValidateIPv6Subnet(subnet net.IPNet) error {
+	if subnet.IP.To4() != nil || len(subnet.IP) != net.IPv6len {
+		return errors.Errorf("%s is not an IPv6 subnet", subnet.String())
+	}
+	ones, bits := subnet.Mask.Size()
+	if bits != 8*net.IPv6len || ones > ipv6SubnetPrefix {
+		return errors.Errorf("IPv6 subnet %s is too small, at least /%d is required", subnet.String(), ipv6SubnetPrefix)
+	}
+	return nil
+}
+
+// IPv6Address returns the address of the IPv6 network paired with the given IPv4 address.
+// IPv4 address is embedded into the interface identifier, so addresses of both families are assigned together.
+func IPv6Address(network6 net.IPNet, ip4 net.IP) net.IP {
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, network6.IP.Mask(network6.Mask))
+	copy(ip[net.IPv6len-net.IPv4len:], ip4.To4())
+	return ip
+}
+
+// IPv6Net returns the /64 network paired with the given IPv4 network allocated from the same Allocator.
+// Pairing by the allocation index means that IPv6 networks are allocated and released together with IPv4 ones.
+func IPv6Net(subnet6 net.IPNet, ipv4Net net.IPNet) (net.IPNet, error) {
+	ip4 := ipv4Net.IP.To4()
+	if ip4 == nil {
+		return net.IPNet{}, errors.New("IPv4 network is required")
+	}
+
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, subnet6.IP.Mask(subnet6.Mask))
+	// IPv4 networks differ in the third octet on unix and in the fourth one on windows.
+	ip[6] = ip4[2]
+	ip[7] = ip4[3]
+	return net.IPNet{IP: ip, Mask: net.CIDRMask(ipv6SessionPrefix, 8*net.IPv6len)}, nil
+}
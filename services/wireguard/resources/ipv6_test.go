@@ -0,0 +1,57 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package resources
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateIPv6Subnet(t *testing.T) {
+	for subnet, valid := range map[string]bool{
+		"fd00:6d79:7374::/48": true,
+		"fd00:6d79::/32":      true,
+		"fd00:6d79:7374::/56": false,
+		"10.182.0.0/16":       false,
+	} {
+		_, ipnet, err := net.ParseCIDR(subnet)
+		assert.NoError(t, err)
+		assert.Equal(t, valid, ValidateIPv6Subnet(*ipnet) == nil, subnet)
+	}
+}
+
+func TestIPv6Net(t *testing.T) {
+	_, subnet6, _ := net.ParseCIDR("fd00:6d79:7374::/48")
+
+	ipnet, err := IPv6Net(*subnet6, calcIPNet(net.IPNet{IP: net.ParseIP("10.182.0.0")}, 5))
+	assert.NoError(t, err)
+	assert.Equal(t, "fd00:6d79:7374:500::/64", ipnet.String())
+
+	_, err = IPv6Net(*subnet6, *subnet6)
+	assert.Error(t, err)
+}
+
+func TestIPv6Address(t *testing.T) {
+	_, network6, _ := net.ParseCIDR("fd00:6d79:7374:500::1/64")
+
+	ip := IPv6Address(*network6, net.ParseIP("10.182.5.2"))
+	assert.Equal(t, "fd00:6d79:7374:500::ab6:502", ip.String())
+	assert.True(t, network6.Contains(ip))
+}
@@ -24,6 +24,8 @@ import (
 	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/core/port"
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/core/shaper"
+	"github.com/mysteriumnetwork/node/dns"
 	"github.com/mysteriumnetwork/node/services/wireguard/resources"
 	"github.com/rs/zerolog/log"
 )
@@ -33,6 +35,9 @@ type Options struct {
 	ConnectDelay int
 	Ports        *port.Range
 	Subnet       net.IPNet
+	Subnet6      *net.IPNet
+	Shaper       shaper.Options
+	DNSBlocklist dns.BlocklistOptions
 }
 
 // DefaultOptions is a wireguard service configuration that will be used if no options provided.
@@ -53,6 +58,15 @@ func GetOptions() Options {
 		ipnet = &DefaultOptions.Subnet
 	}
 
+	var subnet6 *net.IPNet
+	if value := config.GetString(config.FlagWireguardListenSubnet6); value != "" {
+		subnet6, err = parseSubnet6(value)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to parse IPv6 subnet option, IPv6 will not be provided")
+			subnet6 = nil
+		}
+	}
+
 	portRange, err := port.ParseRange(config.GetString(config.FlagWireguardListenPorts))
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to parse listen port range, using default value")
@@ -67,6 +81,9 @@ func GetOptions() Options {
 		ConnectDelay: config.GetInt(config.FlagWireguardConnectDelay),
 		Ports:        portRange,
 		Subnet:       *ipnet,
+		Subnet6:      subnet6,
+		Shaper:       shaper.ConfiguredOptions(),
+		DNSBlocklist: dns.ConfiguredBlocklistOptions(),
 	}
 }
 
@@ -85,22 +102,31 @@ func ParseJSONOptions(request *json.RawMessage) (service.Options, error) {
 // MarshalJSON implements json.Marshaler interface to provide human readable configuration.
 func (o Options) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
-		ConnectDelay int    `json:"connectDelay"`
-		Ports        string `json:"ports"`
-		Subnet       string `json:"subnet"`
+		ConnectDelay int                  `json:"connectDelay"`
+		Ports        string               `json:"ports"`
+		Subnet       string               `json:"subnet"`
+		Subnet6      string               `json:"subnet6,omitempty"`
+		Shaper       shaper.Options       `json:"shaper"`
+		DNSBlocklist dns.BlocklistOptions `json:"dns_blocklist"`
 	}{
 		ConnectDelay: o.ConnectDelay,
 		Ports:        o.Ports.String(),
 		Subnet:       o.Subnet.String(),
+		Subnet6:      subnet6String(o.Subnet6),
+		Shaper:       o.Shaper,
+		DNSBlocklist: o.DNSBlocklist,
 	})
 }
 
 // UnmarshalJSON implements json.Unmarshaler interface to receive human readable configuration.
 func (o *Options) UnmarshalJSON(data []byte) error {
 	var options struct {
-		ConnectDelay int    `json:"connectDelay"`
-		Ports        string `json:"ports"`
-		Subnet       string `json:"subnet"`
+		ConnectDelay int                   `json:"connectDelay"`
+		Ports        string                `json:"ports"`
+		Subnet       string                `json:"subnet"`
+		Subnet6      string                `json:"subnet6"`
+		Shaper       *shaper.Options       `json:"shaper"`
+		DNSBlocklist *dns.BlocklistOptions `json:"dns_blocklist"`
 	}
 
 	if err := json.Unmarshal(data, &options); err != nil {
@@ -124,6 +150,40 @@ func (o *Options) UnmarshalJSON(data []byte) error {
 		}
 		o.Subnet = *ipnet
 	}
+	if len(options.Subnet6) > 0 {
+		ipnet, err := parseSubnet6(options.Subnet6)
+		if err != nil {
+			return err
+		}
+		o.Subnet6 = ipnet
+	}
+	if options.Shaper != nil {
+		o.Shaper = *options.Shaper
+	}
+	if options.DNSBlocklist != nil {
+		if err := options.DNSBlocklist.Validate(); err != nil {
+			return err
+		}
+		o.DNSBlocklist = *options.DNSBlocklist
+	}
 
 	return nil
 }
+
+func parseSubnet6(value string) (*net.IPNet, error) {
+	_, ipnet, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := resources.ValidateIPv6Subnet(*ipnet); err != nil {
+		return nil, err
+	}
+	return ipnet, nil
+}
+
+func subnet6String(subnet6 *net.IPNet) string {
+	if subnet6 == nil {
+		return ""
+	}
+	return subnet6.String()
+}
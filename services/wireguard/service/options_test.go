@@ -25,6 +25,8 @@ import (
 
 	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/core/port"
+	"github.com/mysteriumnetwork/node/core/shaper"
+	"github.com/mysteriumnetwork/node/dns"
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli/v2"
 )
@@ -62,6 +64,50 @@ func Test_ParseJSONOptions_ValidRequest(t *testing.T) {
 	}, options)
 }
 
+func Test_ParseJSONOptions_ShaperOptions(t *testing.T) {
+	configureDefaults()
+	request := json.RawMessage(`{"shaper": {"enabled": true, "service": {"uplink_kbps": 10000, "downlink_kbps": 20000}, "session": {"downlink_kbps": 1000}}}`)
+	options, err := ParseJSONOptions(&request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, shaper.Options{
+		Enabled: true,
+		Service: shaper.Limits{UplinkKbps: 10000, DownlinkKbps: 20000},
+		Session: shaper.Limits{DownlinkKbps: 1000},
+	}, options.(Options).Shaper)
+}
+
+func Test_ParseJSONOptions_DNSBlocklistOptions(t *testing.T) {
+	configureDefaults()
+	request := json.RawMessage(`{"dns_blocklist": {"enabled": true, "sources": ["/etc/myst/hosts", "https://example.com/hosts"], "response": "zero_ip", "refresh_minutes": 60}}`)
+	options, err := ParseJSONOptions(&request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, dns.BlocklistOptions{
+		Enabled:        true,
+		Sources:        []string{"/etc/myst/hosts", "https://example.com/hosts"},
+		Response:       dns.BlocklistResponseZeroIP,
+		RefreshMinutes: 60,
+	}, options.(Options).DNSBlocklist)
+
+	request = json.RawMessage(`{"dns_blocklist": {"enabled": true}}`)
+	_, err = ParseJSONOptions(&request)
+	assert.Error(t, err)
+}
+
+func Test_ParseJSONOptions_Subnet6(t *testing.T) {
+	configureDefaults()
+	request := json.RawMessage(`{"subnet6": "fd00:6d79:7374::/48"}`)
+	options, err := ParseJSONOptions(&request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "fd00:6d79:7374::/48", options.(Options).Subnet6.String())
+
+	request = json.RawMessage(`{"subnet6": "10.10.0.0/16"}`)
+	_, err = ParseJSONOptions(&request)
+	assert.Error(t, err)
+}
+
 func configureDefaults() {
 	ctx := emptyContext()
 	config.ParseFlagsServiceWireguard(ctx)
@@ -27,6 +27,8 @@ import (
 	"github.com/mysteriumnetwork/node/core/policy"
 	"github.com/mysteriumnetwork/node/core/service"
 	"github.com/mysteriumnetwork/node/core/service/servicestate"
+	"github.com/mysteriumnetwork/node/core/shaper"
+	"github.com/mysteriumnetwork/node/eventbus"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/nat"
@@ -105,7 +107,7 @@ func (mce *mockConnectionEndpoint) Stop() error
 func (mce *mockConnectionEndpoint) Config() (wg.ServiceConfig, error)                    { return wg.ServiceConfig{}, nil }
 func (mce *mockConnectionEndpoint) AddPeer(_ string, _ wg.Peer) error                    { return nil }
 func (mce *mockConnectionEndpoint) RemovePeer(_ string) error                            { return nil }
-func (mce *mockConnectionEndpoint) ConfigureRoutes(_ net.IP) error                       { return nil }
+func (mce *mockConnectionEndpoint) ConfigureRoutes(_ net.IP, _ wg.Routes) error          { return nil }
 func (mce *mockConnectionEndpoint) PeerStats() (*wg.Stats, error) {
 	return &wg.Stats{LastHandshake: time.Now()}, nil
 }
@@ -115,6 +117,7 @@ func newManagerStub(pub, out, country string) *Manager {
 		done:       make(chan struct{}),
 		ipResolver: ip.NewResolverMock("1.2.3.4"),
 		natService: &serviceFake{},
+		shaper:     shaper.New(eventbus.New(), shaper.Options{}),
 		connEndpointFactory: func() (wg.ConnectionEndpoint, error) {
 			return connectionEndpointStub, nil
 		},
@@ -27,8 +27,10 @@ import (
 	"time"
 
 	"github.com/mysteriumnetwork/node/core/ip"
+	"github.com/mysteriumnetwork/node/core/policy"
 	"github.com/mysteriumnetwork/node/core/port"
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/core/shaper"
 	"github.com/mysteriumnetwork/node/dns"
 	"github.com/mysteriumnetwork/node/eventbus"
 	"github.com/mysteriumnetwork/node/firewall"
@@ -40,6 +42,7 @@ import (
 	"github.com/mysteriumnetwork/node/services/wireguard/endpoint"
 	"github.com/mysteriumnetwork/node/services/wireguard/resources"
 	"github.com/mysteriumnetwork/node/session"
+	sessionEvent "github.com/mysteriumnetwork/node/session/event"
 	"github.com/mysteriumnetwork/node/utils/netutil"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
@@ -64,7 +67,7 @@ func NewManager(
 	natService nat.NATService,
 	natPinger NATPinger,
 	natEventGetter NATEventGetter,
-	eventPublisher eventbus.Publisher,
+	eventBus eventbus.EventBus,
 	options Options,
 	portSupplier port.ServicePortSupplier,
 	portMapper mapping.PortMapper,
@@ -75,12 +78,14 @@ func NewManager(
 	return &Manager{
 		done:               make(chan struct{}),
 		resourcesAllocator: resourcesAllocator,
+		subnet6:            options.Subnet6,
 		ipResolver:         ipResolver,
 		natService:         natService,
 		natPinger:          natPinger,
 		natEventGetter:     natEventGetter,
 		natPingerPorts:     port.NewPool(),
-		publisher:          eventPublisher,
+		publisher:          eventBus,
+		shaper:             shaper.New(eventBus, options.Shaper),
 		portMapper:         portMapper,
 		trafficFirewall:    trafficFirewall,
 
@@ -90,6 +95,8 @@ func NewManager(
 		country:        country,
 		connectDelayMS: options.ConnectDelay,
 		sessionCleanup: map[string]func(){},
+		sessionIPs:     map[string]string{},
+		blocklistOpts:  options.DNSBlocklist,
 	}
 }
 
@@ -99,6 +106,7 @@ type Manager struct {
 	startStopMu sync.Mutex
 
 	resourcesAllocator *resources.Allocator
+	subnet6            *net.IPNet
 
 	natService      nat.NATService
 	natPinger       NATPinger
@@ -107,17 +115,22 @@ type Manager struct {
 	publisher       eventbus.Publisher
 	portMapper      mapping.PortMapper
 	trafficFirewall firewall.IncomingTrafficFirewall
+	shaper          shaper.Shaper
 
 	dnsOK    bool
 	dnsPort  int
 	dnsProxy *dns.Proxy
 
+	blocklistOpts dns.BlocklistOptions
+	blocklist     *dns.Blocklist
+
 	connEndpointFactory func() (wg.ConnectionEndpoint, error)
 
 	ipResolver ip.Resolver
 
 	serviceInstance  *service.Instance
 	sessionCleanup   map[string]func()
+	sessionIPs       map[string]string
 	sessionCleanupMu sync.Mutex
 
 	country        string
@@ -139,6 +152,16 @@ func (m *Manager) ProvideConfig(sessionID string, sessionConfig json.RawMessage,
 	if err != nil {
 		return nil, errors.Wrap(err, "could not allocate provider IP NET")
 	}
+	if m.subnet6 != nil && len(m.serviceInstance.Policies().Policies()) > 0 {
+		// Incoming firewall filters IPv4 traffic only, IPv6 would bypass the traffic rules the policies have or get once reloaded
+		log.Info().Msgf("IPv6 is not offered to session %s, access policies may restrict the traffic", sessionID)
+	} else if m.subnet6 != nil {
+		network6, err := resources.IPv6Net(*m.subnet6, providerConfig.Network)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not allocate provider IPv6 NET")
+		}
+		providerConfig.Network6 = &network6
+	}
 
 	var traversalParams traversal.Params
 	var releasePortMapping func()
@@ -193,23 +216,30 @@ func (m *Manager) ProvideConfig(sessionID string, sessionConfig json.RawMessage,
 		return nil, errors.Wrap(err, "could not add consumer peer")
 	}
 
-	var dnsIP net.IP
-	var releaseTrafficFirewall firewall.IncomingRuleRemove
-	if m.dnsOK {
-		if m.serviceInstance.Policies().HasDNSRules() {
-			releaseTrafficFirewall, err = m.trafficFirewall.BlockIncomingTraffic(providerConfig.Network)
-			if err != nil {
-				return nil, errors.Wrap(err, "failed to enable traffic blocking")
-			}
-		}
+	if err := m.shaper.AddSession(conn.InterfaceName(), config.Consumer.IPAddress.IP); err != nil {
+		log.Error().Err(err).Msg("Could not limit session bandwidth")
+	}
+
+	var dnsIP, dnsIP6 net.IP
+	releaseTrafficFirewall, err := policy.EnforceTrafficRules(m.trafficFirewall, providerConfig.Network, m.serviceInstance.Policies(), m.dnsOK)
+	if err != nil {
+		return nil, err
+	}
 
+	if m.dnsOK {
 		dnsIP = netutil.FirstIP(config.Consumer.IPAddress)
 		config.Consumer.DNSIPs = dnsIP.String()
+		if config.Consumer.IPv6Address != nil {
+			dnsIP6 = netutil.FirstIP(*config.Consumer.IPv6Address)
+			config.Consumer.DNSIPs += "," + dnsIP6.String()
+		}
 	}
 
 	natRules, err := m.natService.Setup(nat.Options{
 		VPNNetwork:        config.Consumer.IPAddress,
+		VPNNetwork6:       config.Consumer.IPv6Address,
 		DNSIP:             dnsIP,
+		DNSIP6:            dnsIP6,
 		ProviderExtIP:     net.ParseIP(m.outboundIP),
 		EnableDNSRedirect: m.dnsOK,
 		DNSPort:           m.dnsPort,
@@ -221,10 +251,17 @@ func (m *Manager) ProvideConfig(sessionID string, sessionConfig json.RawMessage,
 	statsPublisher := newStatsPublisher(m.publisher, time.Second)
 	go statsPublisher.start(sessionID, conn)
 
+	consumerIPs := []string{config.Consumer.IPAddress.IP.String()}
+	if config.Consumer.IPv6Address != nil {
+		consumerIPs = append(consumerIPs, config.Consumer.IPv6Address.IP.String())
+	}
 	destroy := func() {
 		log.Info().Msgf("Cleaning up session %s", sessionID)
 		m.sessionCleanupMu.Lock()
 		delete(m.sessionCleanup, sessionID)
+		for _, consumerIP := range consumerIPs {
+			delete(m.sessionIPs, consumerIP)
+		}
 		m.sessionCleanupMu.Unlock()
 
 		statsPublisher.stop()
@@ -234,10 +271,8 @@ func (m *Manager) ProvideConfig(sessionID string, sessionConfig json.RawMessage,
 			releasePortMapping()
 		}
 
-		if releaseTrafficFirewall != nil {
-			if err := releaseTrafficFirewall(); err != nil {
-				log.Warn().Err(err).Msg("failed to disable traffic blocking")
-			}
+		if err := releaseTrafficFirewall(); err != nil {
+			log.Warn().Err(err).Msg("failed to disable traffic blocking")
 		}
 
 		log.Trace().Msg("Deleting nat rules")
@@ -245,6 +280,9 @@ func (m *Manager) ProvideConfig(sessionID string, sessionConfig json.RawMessage,
 			log.Error().Err(err).Msg("Failed to delete NAT rules")
 		}
 
+		log.Trace().Msg("Deleting traffic shaping rules")
+		m.shaper.Clear(conn.InterfaceName())
+
 		log.Trace().Msg("Stopping connection endpoint")
 		if err := conn.Stop(); err != nil {
 			log.Error().Err(err).Msg("Failed to stop connection endpoint")
@@ -257,11 +295,25 @@ func (m *Manager) ProvideConfig(sessionID string, sessionConfig json.RawMessage,
 
 	m.sessionCleanupMu.Lock()
 	m.sessionCleanup[sessionID] = destroy
+	for _, consumerIP := range consumerIPs {
+		m.sessionIPs[consumerIP] = sessionID
+	}
 	m.sessionCleanupMu.Unlock()
 
 	return &session.ConfigParams{SessionServiceConfig: config, SessionDestroyCallback: destroy, TraversalParams: traversalParams}, nil
 }
 
+// publishDNSBlocked counts the blocked DNS query for the session of the consumer.
+func (m *Manager) publishDNSBlocked(consumerIP net.IP) {
+	m.sessionCleanupMu.Lock()
+	sessionID, ok := m.sessionIPs[consumerIP.String()]
+	m.sessionCleanupMu.Unlock()
+
+	if ok {
+		m.publisher.Publish(sessionEvent.AppTopicDNSBlocked, sessionEvent.AppEventDNSBlocked{ID: sessionID})
+	}
+}
+
 func (m *Manager) tryAddPortMapping(pubIP string, port int) (release func(), ok bool) {
 	if !m.behindNAT(pubIP) {
 		return nil, false
@@ -360,9 +412,18 @@ func (m *Manager) Serve(instance *service.Instance) error {
 	m.dnsOK = false
 	dnsHandler, err := dns.ResolveViaSystem()
 	if err == nil {
-		if m.serviceInstance.Policies().HasDNSRules() {
+		// DNS rules may be added by the policies reloaded later, so answers are whitelisted whenever there are any policies.
+		if len(m.serviceInstance.Policies().Policies()) > 0 {
 			dnsHandler = dns.WhitelistAnswers(dnsHandler, m.trafficFirewall, instance.Policies())
 		}
+		if m.blocklistOpts.Enabled {
+			m.blocklist = dns.NewBlocklist(m.blocklistOpts)
+			if err := m.blocklist.Load(); err != nil {
+				log.Warn().Err(err).Msg("DNS blocklist is empty until the next refresh")
+			}
+			m.blocklist.Start()
+			dnsHandler = dns.BlockDomains(dnsHandler, m.blocklist, m.blocklistOpts.Response, m.publishDNSBlocked)
+		}
 
 		m.dnsProxy = dns.NewProxy("", m.dnsPort, dnsHandler)
 		if err := m.dnsProxy.Run(); err != nil {
@@ -403,6 +464,10 @@ func (m *Manager) Stop() error {
 			log.Error().Err(err).Msg("Failed to stop DNS server")
 		}
 	}
+	if m.blocklist != nil {
+		m.blocklist.Stop()
+	}
+	m.shaper.Stop()
 
 	close(m.done)
 	log.Info().Msg("Wireguard: stopped")
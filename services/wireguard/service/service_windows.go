@@ -52,7 +52,7 @@ func NewManager(
 	natService nat.NATService,
 	natPinger NATPinger,
 	natEventGetter NATEventGetter,
-	eventPublisher eventbus.Publisher,
+	eventBus eventbus.EventBus,
 	options Options,
 	portSupplier port.ServicePortSupplier,
 	portMapper mapping.PortMapper,
@@ -57,22 +57,47 @@ type ConnectionEndpoint interface {
 	StartProviderMode(config ProviderModeConfig) error
 	AddPeer(iface string, peer Peer) error
 	PeerStats() (*Stats, error)
-	ConfigureRoutes(ip net.IP) error
+	ConfigureRoutes(ip net.IP, routes Routes) error
 	Config() (ServiceConfig, error)
 	InterfaceName() string
 	Stop() error
 }
 
+// Routes defines which networks are routed through the tunnel.
+// All traffic is routed through the tunnel when no networks are included.
+type Routes struct {
+	Included []net.IPNet
+	Excluded []net.IPNet
+	// IPv6 defines if IPv6 traffic is routed through the tunnel too.
+	IPv6 bool
+}
+
+var (
+	// DefaultRoutes cover IPv4 address space when all the traffic is routed through the tunnel,
+	// they take precedence over the system default route without replacing it.
+	DefaultRoutes = []net.IPNet{
+		{IP: net.IPv4(0, 0, 0, 0).To4(), Mask: net.CIDRMask(1, 32)},
+		{IP: net.IPv4(128, 0, 0, 0).To4(), Mask: net.CIDRMask(1, 32)},
+	}
+	// DefaultRoutes6 cover IPv6 address space the same way.
+	DefaultRoutes6 = []net.IPNet{
+		{IP: net.ParseIP("::"), Mask: net.CIDRMask(1, 128)},
+		{IP: net.ParseIP("8000::"), Mask: net.CIDRMask(1, 128)},
+	}
+)
+
 // ConsumerModeConfig is consumer endpoint startup configuration.
 type ConsumerModeConfig struct {
-	PrivateKey string
-	IPAddress  net.IPNet
-	ListenPort int
+	PrivateKey  string
+	IPAddress   net.IPNet
+	IPv6Address *net.IPNet
+	ListenPort  int
 }
 
 // ProviderModeConfig is provider endpoint startup configuration.
 type ProviderModeConfig struct {
 	Network    net.IPNet
+	Network6   *net.IPNet
 	ListenPort int
 	PublicIP   string
 }
@@ -98,6 +123,7 @@ type ServiceConfig struct {
 	}
 	Consumer struct {
 		IPAddress    net.IPNet
+		IPv6Address  *net.IPNet
 		DNSIPs       string
 		ConnectDelay int
 	}
@@ -111,10 +137,16 @@ func (s ServiceConfig) MarshalJSON() ([]byte, error) {
 	}
 	type consumer struct {
 		IPAddress    string `json:"ip_address"`
+		IPv6Address  string `json:"ipv6_address,omitempty"`
 		DNSIPs       string `json:"dns_ips"`
 		ConnectDelay int    `json:"connect_delay"`
 	}
 
+	var ipv6Address string
+	if s.Consumer.IPv6Address != nil {
+		ipv6Address = s.Consumer.IPv6Address.String()
+	}
+
 	return json.Marshal(&struct {
 		LocalPort  int      `json:"local_port"`
 		RemotePort int      `json:"remote_port"`
@@ -131,6 +163,7 @@ func (s ServiceConfig) MarshalJSON() ([]byte, error) {
 		},
 		Consumer: consumer{
 			IPAddress:    s.Consumer.IPAddress.String(),
+			IPv6Address:  ipv6Address,
 			ConnectDelay: s.Consumer.ConnectDelay,
 			DNSIPs:       s.Consumer.DNSIPs,
 		},
@@ -145,6 +178,7 @@ func (s *ServiceConfig) UnmarshalJSON(data []byte) error {
 	}
 	type consumer struct {
 		IPAddress    string `json:"ip_address"`
+		IPv6Address  string `json:"ipv6_address"`
 		DNSIPs       string `json:"dns_ips"`
 		ConnectDelay int    `json:"connect_delay"`
 	}
@@ -178,6 +212,14 @@ func (s *ServiceConfig) UnmarshalJSON(data []byte) error {
 	s.Consumer.IPAddress = *ipnet
 	s.Consumer.IPAddress.IP = ip
 	s.Consumer.ConnectDelay = config.Consumer.ConnectDelay
+	if config.Consumer.IPv6Address != "" {
+		ip6, ipnet6, err := net.ParseCIDR(config.Consumer.IPv6Address)
+		if err != nil {
+			return err
+		}
+		ipnet6.IP = ip6
+		s.Consumer.IPv6Address = ipnet6
+	}
 
 	return nil
 }
@@ -186,6 +228,7 @@ func (s *ServiceConfig) UnmarshalJSON(data []byte) error {
 type DeviceConfig struct {
 	IfaceName string
 	Subnet    net.IPNet
+	Subnet6   *net.IPNet
 
 	PrivateKey string
 	ListenPort int
@@ -257,6 +257,7 @@ func TestServiceConfig_MarshalJSON(t *testing.T) {
 		},
 		Consumer: struct {
 			IPAddress    net.IPNet
+			IPv6Address  *net.IPNet
 			DNSIPs       string
 			ConnectDelay int
 		}{
@@ -293,6 +294,7 @@ func TestServiceConfig_UnmarshalJSON(t *testing.T) {
 		},
 		Consumer: struct {
 			IPAddress    net.IPNet
+			IPv6Address  *net.IPNet
 			DNSIPs       string
 			ConnectDelay int
 		}{
@@ -311,3 +313,21 @@ func TestServiceConfig_UnmarshalJSON(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expecteConfig, actualConfig)
 }
+
+func TestServiceConfig_IPv6AddressJSON(t *testing.T) {
+	configJSON := json.RawMessage(`{"provider":{"public_key":"wg1","endpoint":"127.0.0.1:51001"},"consumer":{"ip_address":"10.182.0.2/24","ipv6_address":"fd00:6d79:7374::2/64","dns_ips":"10.182.0.1,fd00:6d79:7374::1"}}`)
+
+	var config ServiceConfig
+	err := json.Unmarshal(configJSON, &config)
+	assert.NoError(t, err)
+	assert.Equal(t, "fd00:6d79:7374::2/64", config.Consumer.IPv6Address.String())
+
+	marshaled, err := json.Marshal(config)
+	assert.NoError(t, err)
+	assert.Contains(t, string(marshaled), `"ipv6_address":"fd00:6d79:7374::2/64"`)
+
+	config.Consumer.IPv6Address = nil
+	marshaled, err = json.Marshal(config)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(marshaled), "ipv6_address")
+}
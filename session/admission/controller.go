@@ -0,0 +1,153 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package admission
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session"
+	"github.com/rs/zerolog/log"
+)
+
+const attemptsWindow = time.Minute
+
+// Config describes session limits of a single consumer, zero value means unlimited.
+type Config struct {
+	// MaxSessions is the number of concurrent sessions allowed.
+	MaxSessions int
+	// MaxAttemptsPerMinute is the number of session create attempts allowed during a minute.
+	MaxAttemptsPerMinute int
+}
+
+// ConfiguredLimits returns effective limits from application configuration.
+func ConfiguredLimits() Config {
+	return Config{
+		MaxSessions:          config.GetInt(config.FlagSessionLimitConcurrent),
+		MaxAttemptsPerMinute: config.GetInt(config.FlagSessionLimitAttempts),
+	}
+}
+
+// DenyList tells if the consumer is denied by the provider.
+type DenyList interface {
+	IsIdentityDenied(identity identity.Identity) bool
+	IsIPDenied(ip net.IP) bool
+}
+
+// SessionLister lists active provider sessions.
+type SessionLister interface {
+	GetAll() []session.Session
+}
+
+// Controller refuses new sessions of denied consumers and consumers exceeding session limits.
+type Controller struct {
+	config   Config
+	denyList DenyList
+	sessions SessionLister
+	timeNow  func() time.Time
+
+	lock     sync.Mutex
+	attempts map[string][]time.Time
+}
+
+// NewController creates a new instance of admission controller.
+func NewController(config Config, denyList DenyList, sessions SessionLister) *Controller {
+	return &Controller{
+		config:   config,
+		denyList: denyList,
+		sessions: sessions,
+		timeNow:  time.Now,
+		attempts: make(map[string][]time.Time),
+	}
+}
+
+// Admit checks if the consumer may create a new session, IP is nil when it is not known.
+func (c *Controller) Admit(consumerID identity.Identity, consumerIP net.IP) error {
+	if c.denyList.IsIdentityDenied(consumerID) {
+		log.Info().Msgf("Refusing session of denied consumer %s", consumerID.Address)
+		return session.ErrorConsumerDenied
+	}
+	if c.denyList.IsIPDenied(consumerIP) {
+		log.Info().Msgf("Refusing session of consumer %s from denied IP %s", consumerID.Address, consumerIP)
+		return session.ErrorConsumerIPDenied
+	}
+
+	if !c.recordAttempt(consumerID) {
+		log.Info().Msgf("Refusing session of consumer %s, too many attempts", consumerID.Address)
+		return session.ErrorTooManyAttempts
+	}
+
+	return c.AdmitSession(consumerID)
+}
+
+// AdmitSession checks if the consumer is within the concurrent sessions limit.
+// Session manager calls it again while holding the session creation lock, so concurrent requests can't exceed the limit.
+func (c *Controller) AdmitSession(consumerID identity.Identity) error {
+	if c.config.MaxSessions > 0 && c.activeSessions(consumerID) >= c.config.MaxSessions {
+		log.Info().Msgf("Refusing session of consumer %s, too many concurrent sessions", consumerID.Address)
+		return session.ErrorTooManySessions
+	}
+	return nil
+}
+
+// recordAttempt registers the session create attempt and returns false if attempts limit is exceeded.
+// Refused attempts are counted too, so consumers retrying in a loop stay refused.
+func (c *Controller) recordAttempt(consumerID identity.Identity) bool {
+	if c.config.MaxAttemptsPerMinute <= 0 {
+		return true
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.timeNow()
+	for id, attempts := range c.attempts {
+		attempts = recentAttempts(attempts, now)
+		if len(attempts) == 0 {
+			delete(c.attempts, id)
+		} else {
+			c.attempts[id] = attempts
+		}
+	}
+
+	attempts := append(c.attempts[consumerID.Address], now)
+	c.attempts[consumerID.Address] = attempts
+	return len(attempts) <= c.config.MaxAttemptsPerMinute
+}
+
+func (c *Controller) activeSessions(consumerID identity.Identity) int {
+	count := 0
+	for _, s := range c.sessions.GetAll() {
+		if s.ConsumerID == consumerID {
+			count++
+		}
+	}
+	return count
+}
+
+func recentAttempts(attempts []time.Time, now time.Time) []time.Time {
+	for i, attempt := range attempts {
+		if now.Sub(attempt) < attemptsWindow {
+			return attempts[i:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package admission
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session"
+	"github.com/stretchr/testify/assert"
+)
+
+var consumerID = identity.FromAddress("0x1")
+
+type mockDenyList struct {
+	identities map[identity.Identity]bool
+	network    *net.IPNet
+}
+
+func (m *mockDenyList) IsIdentityDenied(id identity.Identity) bool {
+	return m.identities[id]
+}
+
+func (m *mockDenyList) IsIPDenied(ip net.IP) bool {
+	return m.network != nil && ip != nil && m.network.Contains(ip)
+}
+
+type mockSessionLister struct {
+	sessions []session.Session
+}
+
+func (m *mockSessionLister) GetAll() []session.Session {
+	return m.sessions
+}
+
+func Test_Controller_RefusesDeniedConsumers(t *testing.T) {
+	_, network, _ := net.ParseCIDR("10.0.0.0/8")
+	denyList := &mockDenyList{identities: map[identity.Identity]bool{consumerID: true}, network: network}
+	controller := NewController(Config{}, denyList, &mockSessionLister{})
+
+	assert.Equal(t, session.ErrorConsumerDenied, controller.Admit(consumerID, nil))
+	assert.Equal(t, session.ErrorConsumerIPDenied, controller.Admit(identity.FromAddress("0x2"), net.ParseIP("10.1.2.3")))
+	assert.NoError(t, controller.Admit(identity.FromAddress("0x2"), net.ParseIP("192.168.1.1")))
+	assert.NoError(t, controller.Admit(identity.FromAddress("0x2"), nil))
+}
+
+func Test_Controller_LimitsConcurrentSessions(t *testing.T) {
+	sessions := &mockSessionLister{sessions: []session.Session{
+		{ID: "s1", ConsumerID: consumerID},
+		{ID: "s2", ConsumerID: identity.FromAddress("0x2")},
+	}}
+	controller := NewController(Config{MaxSessions: 2}, &mockDenyList{}, sessions)
+
+	assert.NoError(t, controller.Admit(consumerID, nil))
+
+	sessions.sessions = append(sessions.sessions, session.Session{ID: "s3", ConsumerID: consumerID})
+	assert.Equal(t, session.ErrorTooManySessions, controller.Admit(consumerID, nil))
+	assert.NoError(t, controller.Admit(identity.FromAddress("0x2"), nil))
+}
+
+func Test_Controller_LimitsAttemptsPerMinute(t *testing.T) {
+	now := time.Now()
+	controller := NewController(Config{MaxAttemptsPerMinute: 2}, &mockDenyList{}, &mockSessionLister{})
+	controller.timeNow = func() time.Time { return now }
+
+	assert.NoError(t, controller.Admit(consumerID, nil))
+	now = now.Add(30 * time.Second)
+	assert.NoError(t, controller.Admit(consumerID, nil))
+	assert.Equal(t, session.ErrorTooManyAttempts, controller.Admit(consumerID, nil))
+	assert.NoError(t, controller.Admit(identity.FromAddress("0x2"), nil))
+
+	// First attempt leaves the window, refused one is still counted.
+	now = now.Add(31 * time.Second)
+	assert.Equal(t, session.ErrorTooManyAttempts, controller.Admit(consumerID, nil))
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, controller.Admit(consumerID, nil))
+	assert.Len(t, controller.attempts, 1)
+}
@@ -41,6 +41,9 @@ const (
 
 	// StatusConnectionFailed indicates unknown session connection error.
 	StatusConnectionFailed StatusCode = 2003
+
+	// StatusSessionQuotaExceeded indicates that session was ended by provider because consumer exceeded its quota.
+	StatusSessionQuotaExceeded StatusCode = 2004
 )
 
 // StatusMessage is a contract for message broker.
@@ -19,6 +19,7 @@ package session
 
 import (
 	"encoding/json"
+	"net"
 
 	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/identity"
@@ -36,6 +37,7 @@ type createConsumer struct {
 
 // Starter starts the session.
 type Starter interface {
+	Admit(consumerID identity.Identity, consumerIP net.IP) error
 	Start(session *Session, consumerID identity.Identity, consumerInfo ConsumerInfo, proposalID int, config ServiceConfiguration, pingerParams *traversal.Params) error
 }
 
@@ -54,6 +56,10 @@ func (consumer *createConsumer) NewRequest() (requestPtr interface{}) {
 func (consumer *createConsumer) Consume(requestPtr interface{}) (response interface{}, err error) {
 	request := requestPtr.(*CreateRequest)
 
+	if err := consumer.sessionStarter.Admit(consumer.peerID, consumerIP(request.Config)); err != nil {
+		return createErrorResponse(err), nil
+	}
+
 	session, err := NewSession()
 	if err != nil {
 		return responseInternalError, errors.Wrap(err, "could not initialize new session")
@@ -71,6 +77,9 @@ func (consumer *createConsumer) Consume(requestPtr interface{}) (response interf
 
 	err = consumer.sessionStarter.Start(session, consumer.peerID, *request.ConsumerInfo, request.ProposalID, sessionConfigParams.SessionServiceConfig, &sessionConfigParams.TraversalParams)
 	if err != nil {
+		if sessionConfigParams.SessionDestroyCallback != nil {
+			sessionConfigParams.SessionDestroyCallback()
+		}
 		return createErrorResponse(err), nil
 	}
 
@@ -84,10 +93,30 @@ func (consumer *createConsumer) Consume(requestPtr interface{}) (response interf
 	return createResponse(*session, sessionConfigParams.SessionServiceConfig), nil
 }
 
+// consumerIP returns the public IP declared in consumer config, dialog carries no peer address itself.
+// Service consumer configs name the field differently ("IP", "Ip"), JSON keys are matched case insensitively.
+func consumerIP(config json.RawMessage) net.IP {
+	var consumerConfig struct {
+		IP string `json:"ip"`
+	}
+	if err := json.Unmarshal(config, &consumerConfig); err != nil {
+		return nil
+	}
+	return net.ParseIP(consumerConfig.IP)
+}
+
 func createErrorResponse(err error) CreateResponse {
 	switch err {
 	case ErrorInvalidProposal:
 		return responseInvalidProposal
+	case ErrorConsumerDenied:
+		return responseConsumerDenied
+	case ErrorConsumerIPDenied:
+		return responseConsumerIPDenied
+	case ErrorTooManySessions:
+		return responseTooManySessions
+	case ErrorTooManyAttempts:
+		return responseTooManyAttempts
 	default:
 		return responseInternalError
 	}
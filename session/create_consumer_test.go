@@ -109,6 +109,50 @@ func TestConsumer_ErrorFatal(t *testing.T) {
 	assert.Exactly(t, responseInternalError, sessionResponse)
 }
 
+func TestConsumer_ErrorConsumerDenied(t *testing.T) {
+	mockManager := &managerFake{
+		admitError: ErrorConsumerDenied,
+	}
+	consumer := createConsumer{
+		sessionStarter:         mockManager,
+		peerID:                 identity.FromAddress("peer-id"),
+		providerConfigProvider: mockConfigProvider{},
+	}
+
+	request := consumer.NewRequest().(*CreateRequest)
+	request.ConsumerInfo = &ConsumerInfo{
+		PaymentVersion: PaymentVersionV3,
+	}
+	sessionResponse, err := consumer.Consume(request)
+
+	assert.NoError(t, err)
+	assert.Exactly(t, responseConsumerDenied, sessionResponse)
+	assert.Equal(t, identity.FromAddress("peer-id"), mockManager.lastAdmittedID)
+	assert.Equal(t, identity.Identity{}, mockManager.lastConsumerID)
+}
+
+func TestConsumer_ErrorConsumerIPDenied(t *testing.T) {
+	mockManager := &managerFake{
+		admitError: ErrorConsumerIPDenied,
+	}
+	consumer := createConsumer{
+		sessionStarter:         mockManager,
+		peerID:                 identity.FromAddress("peer-id"),
+		providerConfigProvider: mockConfigProvider{},
+	}
+
+	request := consumer.NewRequest().(*CreateRequest)
+	request.Config = json.RawMessage(`{"PublicKey":"key","IP":"1.2.3.4"}`)
+	request.ConsumerInfo = &ConsumerInfo{
+		PaymentVersion: PaymentVersionV3,
+	}
+	sessionResponse, err := consumer.Consume(request)
+
+	assert.NoError(t, err)
+	assert.Exactly(t, responseConsumerIPDenied, sessionResponse)
+	assert.Equal(t, net.ParseIP("1.2.3.4"), mockManager.lastAdmittedIP)
+}
+
 func TestConsumer_UsesIssuerID(t *testing.T) {
 	mockManager := &managerFake{
 		fakeSession: Session{
@@ -147,10 +191,20 @@ type managerFake struct {
 	lastConsumerID identity.Identity
 	lastIssuerID   identity.Identity
 	lastProposalID int
+	lastAdmittedID identity.Identity
+	lastAdmittedIP net.IP
 	fakeSession    Session
+	admitError     error
 	returnError    error
 }
 
+// Admit records the admitted consumer and returns fake error
+func (manager *managerFake) Admit(consumerID identity.Identity, consumerIP net.IP) error {
+	manager.lastAdmittedID = consumerID
+	manager.lastAdmittedIP = consumerIP
+	return manager.admitError
+}
+
 // Start function creates and returns fake session
 func (manager *managerFake) Start(session *Session, consumerID identity.Identity, consumerInfo ConsumerInfo, proposalID int, config ServiceConfiguration, pingerParams *traversal.Params) error {
 	session.ID = manager.fakeSession.ID
@@ -30,6 +30,10 @@ var (
 	responseInvalidProposal    = CreateResponse{Success: false, Message: "Invalid Proposal"}
 	responseUnsupportedVersion = CreateResponse{Success: false, Message: "You are running and old version, please update your software"}
 	responseInternalError      = CreateResponse{Success: false, Message: "Internal Error"}
+	responseConsumerDenied     = CreateResponse{Success: false, Message: "Consumer Denied"}
+	responseConsumerIPDenied   = CreateResponse{Success: false, Message: "Consumer IP Denied"}
+	responseTooManySessions    = CreateResponse{Success: false, Message: "Too Many Sessions"}
+	responseTooManyAttempts    = CreateResponse{Success: false, Message: "Too Many Attempts"}
 )
 
 // CreateRequest structure represents message from service consumer to initiate session for given proposal id
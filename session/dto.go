@@ -48,6 +48,7 @@ type Session struct {
 	CreatedAt       time.Time
 	DataTransferred DataTransferred
 	TokensEarned    uint64
+	DNSBlocked      uint64
 	Last            bool
 	done            chan struct{}
 }
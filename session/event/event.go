@@ -18,6 +18,8 @@
 package event
 
 import (
+	"time"
+
 	"github.com/mysteriumnetwork/node/identity"
 )
 
@@ -28,6 +30,23 @@ const (
 	AppTopicDataTransferred = "Session data transferred"
 	// AppTopicSessionTokensEarned is a topic for publish events about tokens earned as a provider.
 	AppTopicSessionTokensEarned = "SessionTokensEarned"
+	// AppTopicDNSBlocked represents the topic of DNS queries blocked by the provider blocklist.
+	AppTopicDNSBlocked = "Session DNS query blocked"
+	// AppTopicSessionClosed represents the topic of finished provider sessions.
+	AppTopicSessionClosed = "Session closed"
+)
+
+const (
+	// ReasonConsumerRequest indicates the session was destroyed at the consumer request.
+	ReasonConsumerRequest = "consumer_request"
+	// ReasonPaymentError indicates the session was destroyed because of payment failure.
+	ReasonPaymentError = "payment_error"
+	// ReasonQuotaExceeded indicates the session was destroyed because consumer exceeded the quota.
+	ReasonQuotaExceeded = "quota_exceeded"
+	// ReasonServiceStopped indicates the session was closed together with its service.
+	ReasonServiceStopped = "service_stopped"
+	// ReasonConnectionLost indicates the session was destroyed after the consumer stopped answering keepalive pings.
+	ReasonConnectionLost = "connection_lost"
 )
 
 // AppEventDataTransferred represents the data transfer event
@@ -36,6 +55,25 @@ type AppEventDataTransferred struct {
 	Up, Down uint64
 }
 
+// AppEventDNSBlocked represents a DNS query of the session blocked by the provider
+type AppEventDNSBlocked struct {
+	ID string
+}
+
+// AppEventSessionClosed represents the finished provider session with its totals
+type AppEventSessionClosed struct {
+	ID            string
+	ConsumerID    identity.Identity
+	ServiceID     string
+	ServiceType   string
+	Started       time.Time
+	Ended         time.Time
+	BytesSent     uint64
+	BytesReceived uint64
+	TokensEarned  uint64
+	Reason        string
+}
+
 // AppEventSessionTokensEarned is an update on tokens earned during current session
 type AppEventSessionTokensEarned struct {
 	ProviderID identity.Identity
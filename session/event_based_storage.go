@@ -18,6 +18,8 @@
 package session
 
 import (
+	"time"
+
 	"github.com/mysteriumnetwork/node/session/event"
 )
 
@@ -31,6 +33,7 @@ type storage interface {
 	GetAll() []Session
 	UpdateDataTransfer(id ID, up, down uint64)
 	UpdateEarnings(id ID, total uint64)
+	IncrementDNSBlocked(id ID)
 	Find(id ID) (Session, bool)
 	FindBy(opts FindOpts) (ID, bool)
 	Remove(id ID)
@@ -80,6 +83,14 @@ func (ebs *EventBasedStorage) consumeTokensEarnedEvent(e event.AppEventSessionTo
 	})
 }
 
+func (ebs *EventBasedStorage) consumeDNSBlockedEvent(e event.AppEventDNSBlocked) {
+	ebs.storage.IncrementDNSBlocked(ID(e.ID))
+	go ebs.bus.Publish(event.AppTopicSession, event.Payload{
+		ID:     e.ID,
+		Action: event.Updated,
+	})
+}
+
 // UpdateDataTransfer updates the data transfer for a session
 func (ebs *EventBasedStorage) UpdateDataTransfer(id ID, up, down uint64) {
 	ebs.storage.UpdateDataTransfer(id, up, down)
@@ -105,6 +116,11 @@ func (ebs *EventBasedStorage) Remove(id ID) {
 
 // RemoveForService removes all the sessions for a service and publishes a delete event
 func (ebs *EventBasedStorage) RemoveForService(serviceID string) {
+	for _, session := range ebs.storage.GetAll() {
+		if session.ServiceID == serviceID {
+			go ebs.bus.Publish(event.AppTopicSessionClosed, newSessionClosedEvent(session, event.ReasonServiceStopped))
+		}
+	}
 	ebs.storage.RemoveForService(serviceID)
 	go ebs.bus.Publish(event.AppTopicSession, event.Payload{
 		ID:     "",
@@ -112,6 +128,21 @@ func (ebs *EventBasedStorage) RemoveForService(serviceID string) {
 	})
 }
 
+func newSessionClosedEvent(session Session, reason string) event.AppEventSessionClosed {
+	return event.AppEventSessionClosed{
+		ID:            string(session.ID),
+		ConsumerID:    session.ConsumerID,
+		ServiceID:     session.ServiceID,
+		ServiceType:   session.ServiceType,
+		Started:       session.CreatedAt,
+		Ended:         time.Now().UTC(),
+		BytesSent:     session.DataTransferred.Up,
+		BytesReceived: session.DataTransferred.Down,
+		TokensEarned:  session.TokensEarned,
+		Reason:        reason,
+	}
+}
+
 // Subscribe subscribes the ebs to relevant events
 func (ebs *EventBasedStorage) Subscribe() error {
 	if err := ebs.bus.SubscribeAsync(event.AppTopicDataTransferred, ebs.consumeDataTransferredEvent); err != nil {
@@ -120,5 +151,8 @@ func (ebs *EventBasedStorage) Subscribe() error {
 	if err := ebs.bus.SubscribeAsync(event.AppTopicSessionTokensEarned, ebs.consumeTokensEarnedEvent); err != nil {
 		return err
 	}
+	if err := ebs.bus.SubscribeAsync(event.AppTopicDNSBlocked, ebs.consumeDNSBlockedEvent); err != nil {
+		return err
+	}
 	return nil
 }
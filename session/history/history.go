@@ -0,0 +1,209 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package history
+
+import (
+	"sort"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/event"
+	"github.com/rs/zerolog/log"
+)
+
+const bucketName = "provider-session-history"
+
+// Record holds a finished provider session
+type Record struct {
+	SessionID     string `storm:"id"`
+	ConsumerID    identity.Identity
+	ServiceID     string
+	ServiceType   string
+	Started       time.Time
+	Ended         time.Time
+	BytesSent     uint64
+	BytesReceived uint64
+	TokensEarned  uint64
+	Reason        string
+}
+
+// Duration returns how long the session lasted
+func (r Record) Duration() time.Duration {
+	return r.Ended.Sub(r.Started)
+}
+
+// Filter narrows down the session history query
+type Filter struct {
+	// From includes sessions started at or after the given time
+	From *time.Time
+	// To includes sessions started before the given time
+	To          *time.Time
+	ConsumerID  string
+	ServiceType string
+	Offset      int
+	Limit       int
+}
+
+func (f Filter) matchers() []q.Matcher {
+	var matchers []q.Matcher
+	if f.From != nil {
+		matchers = append(matchers, q.Gte("Started", *f.From))
+	}
+	if f.To != nil {
+		matchers = append(matchers, q.Lt("Started", *f.To))
+	}
+	if f.ConsumerID != "" {
+		matchers = append(matchers, q.Eq("ConsumerID", identity.FromAddress(f.ConsumerID)))
+	}
+	if f.ServiceType != "" {
+		matchers = append(matchers, q.Eq("ServiceType", f.ServiceType))
+	}
+	return matchers
+}
+
+// Totals holds the aggregated values of sessions
+type Totals struct {
+	Sessions      int
+	Duration      time.Duration
+	BytesSent     uint64
+	BytesReceived uint64
+	TokensEarned  uint64
+}
+
+func (t *Totals) add(r Record) {
+	t.Sessions++
+	t.Duration += r.Duration()
+	t.BytesSent += r.BytesSent
+	t.BytesReceived += r.BytesReceived
+	t.TokensEarned += r.TokensEarned
+}
+
+// PeriodTotals holds the aggregated values of sessions started within the period
+type PeriodTotals struct {
+	// Period is the day formatted as 2006-01-02 or the month formatted as 2006-01
+	Period string
+	Totals
+}
+
+// Result holds the page of the session history matching the filter and the totals of all matching sessions
+type Result struct {
+	Records []Record
+	Total   int
+	Totals  Totals
+	Daily   []PeriodTotals
+	Monthly []PeriodTotals
+}
+
+// Storer allows us to store and query the history records
+type Storer interface {
+	Store(bucket string, object interface{}) error
+	Select(bucket string, matchers ...q.Matcher) storm.Query
+}
+
+// Storage persists finished provider sessions
+type Storage struct {
+	storage Storer
+}
+
+// NewStorage creates the provider session history storage
+func NewStorage(storage Storer) *Storage {
+	return &Storage{storage: storage}
+}
+
+// ConsumeSessionClosedEvent stores the finished session
+func (s *Storage) ConsumeSessionClosedEvent(e event.AppEventSessionClosed) {
+	// Records are ordered by the encoded start time, so all of them are kept in UTC.
+	record := Record{
+		SessionID:     e.ID,
+		ConsumerID:    e.ConsumerID,
+		ServiceID:     e.ServiceID,
+		ServiceType:   e.ServiceType,
+		Started:       e.Started.UTC(),
+		Ended:         e.Ended.UTC(),
+		BytesSent:     e.BytesSent,
+		BytesReceived: e.BytesReceived,
+		TokensEarned:  e.TokensEarned,
+		Reason:        e.Reason,
+	}
+	if err := s.storage.Store(bucketName, &record); err != nil {
+		log.Error().Err(err).Msgf("Failed to store session %s history", e.ID)
+	}
+}
+
+// Query returns the newest first sessions matching the filter together with their totals
+func (s *Storage) Query(filter Filter) (Result, error) {
+	matchers := filter.matchers()
+
+	var result Result
+	daily := newAggregator("2006-01-02")
+	monthly := newAggregator("2006-01")
+	err := s.storage.Select(bucketName, matchers...).Each(new(Record), func(record interface{}) error {
+		r := *record.(*Record)
+		result.Totals.add(r)
+		daily.add(r)
+		monthly.add(r)
+		return nil
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	result.Total = result.Totals.Sessions
+	result.Daily = daily.totals()
+	result.Monthly = monthly.totals()
+
+	query := s.storage.Select(bucketName, matchers...).OrderBy("Started").Reverse().Skip(filter.Offset)
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	result.Records = []Record{}
+	if err := query.Find(&result.Records); err != nil && err != storage.ErrNotFound {
+		return Result{}, err
+	}
+	return result, nil
+}
+
+type aggregator struct {
+	layout  string
+	periods map[string]*PeriodTotals
+}
+
+func newAggregator(layout string) *aggregator {
+	return &aggregator{layout: layout, periods: make(map[string]*PeriodTotals)}
+}
+
+func (a *aggregator) add(r Record) {
+	period := r.Started.UTC().Format(a.layout)
+	totals, ok := a.periods[period]
+	if !ok {
+		totals = &PeriodTotals{Period: period}
+		a.periods[period] = totals
+	}
+	totals.add(r)
+}
+
+func (a *aggregator) totals() []PeriodTotals {
+	res := make([]PeriodTotals, 0, len(a.periods))
+	for _, totals := range a.periods {
+		res = append(res, *totals)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Period > res[j].Period })
+	return res
+}
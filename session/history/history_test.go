@@ -0,0 +1,124 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb/boltdbtest"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/event"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockStorer struct {
+	records []Record
+}
+
+func (m *mockStorer) Store(_ string, object interface{}) error {
+	m.records = append(m.records, *object.(*Record))
+	return nil
+}
+
+func (m *mockStorer) Select(_ string, matchers ...q.Matcher) storm.Query {
+	return boltdbtest.NewQueryStub(m.records, matchers...)
+}
+
+func closedEvent(id, consumer, serviceType string, started time.Time, earned uint64) event.AppEventSessionClosed {
+	return event.AppEventSessionClosed{
+		ID:            id,
+		ConsumerID:    identity.FromAddress(consumer),
+		ServiceType:   serviceType,
+		Started:       started,
+		Ended:         started.Add(time.Minute),
+		BytesSent:     100,
+		BytesReceived: 10,
+		TokensEarned:  earned,
+		Reason:        event.ReasonConsumerRequest,
+	}
+}
+
+func TestStorage_ConsumeSessionClosedEvent(t *testing.T) {
+	storer := &mockStorer{}
+	started := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	NewStorage(storer).ConsumeSessionClosedEvent(closedEvent("s1", "0x1", "wireguard", started, 5))
+
+	assert.Equal(t, []Record{{
+		SessionID:     "s1",
+		ConsumerID:    identity.FromAddress("0x1"),
+		ServiceType:   "wireguard",
+		Started:       started,
+		Ended:         started.Add(time.Minute),
+		BytesSent:     100,
+		BytesReceived: 10,
+		TokensEarned:  5,
+		Reason:        event.ReasonConsumerRequest,
+	}}, storer.records)
+	assert.Equal(t, time.Minute, storer.records[0].Duration())
+}
+
+func TestStorage_Query(t *testing.T) {
+	storage := NewStorage(&mockStorer{})
+	day1 := time.Date(2020, 5, 31, 10, 0, 0, 0, time.UTC)
+	day2 := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	storage.ConsumeSessionClosedEvent(closedEvent("s1", "0x1", "wireguard", day1, 1))
+	storage.ConsumeSessionClosedEvent(closedEvent("s3", "0x1", "wireguard", day2.Add(time.Hour), 4))
+	storage.ConsumeSessionClosedEvent(closedEvent("s2", "0x2", "openvpn", day2, 2))
+
+	result, err := storage.Query(Filter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Total)
+	assert.Equal(t, []string{"s3", "s2", "s1"}, sessionIDs(result.Records))
+	assert.Equal(t, Totals{Sessions: 3, Duration: 3 * time.Minute, BytesSent: 300, BytesReceived: 30, TokensEarned: 7}, result.Totals)
+	assert.Equal(t, []PeriodTotals{
+		{Period: "2020-06-01", Totals: Totals{Sessions: 2, Duration: 2 * time.Minute, BytesSent: 200, BytesReceived: 20, TokensEarned: 6}},
+		{Period: "2020-05-31", Totals: Totals{Sessions: 1, Duration: time.Minute, BytesSent: 100, BytesReceived: 10, TokensEarned: 1}},
+	}, result.Daily)
+	assert.Equal(t, []string{"2020-06", "2020-05"}, []string{result.Monthly[0].Period, result.Monthly[1].Period})
+
+	result, err = storage.Query(Filter{ConsumerID: "0X1", ServiceType: "wireguard"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"s3", "s1"}, sessionIDs(result.Records))
+
+	from, to := day2, day2.Add(time.Hour)
+	result, err = storage.Query(Filter{From: &from, To: &to})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"s2"}, sessionIDs(result.Records))
+
+	result, err = storage.Query(Filter{Offset: 1, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Total)
+	assert.Equal(t, 7, int(result.Totals.TokensEarned))
+	assert.Equal(t, []string{"s2"}, sessionIDs(result.Records))
+
+	result, err = storage.Query(Filter{Offset: 5})
+	assert.NoError(t, err)
+	assert.Empty(t, result.Records)
+}
+
+func sessionIDs(records []Record) []string {
+	ids := make([]string, 0, len(records))
+	for _, r := range records {
+		ids = append(ids, r.SessionID)
+	}
+	return ids
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/mysteriumnetwork/node/nat/traversal"
 	"github.com/mysteriumnetwork/node/p2p"
 	"github.com/mysteriumnetwork/node/pb"
+	"github.com/mysteriumnetwork/node/session/connectivity"
 	sevent "github.com/mysteriumnetwork/node/session/event"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
@@ -42,8 +43,45 @@ var (
 	ErrorSessionNotExists = errors.New("session does not exists")
 	// ErrorWrongSessionOwner returned when consumer tries to destroy session that does not belongs to him
 	ErrorWrongSessionOwner = errors.New("wrong session owner")
+	// ErrorServiceDraining returned when consumer tries to start a session on a service which is being stopped
+	ErrorServiceDraining = errors.New("service is draining and does not accept new sessions")
+	// ErrorConsumerDenied returned when consumer identity is on the provider deny list
+	ErrorConsumerDenied = errors.New("consumer is denied by the provider")
+	// ErrorConsumerIPDenied returned when consumer IP is on the provider deny list
+	ErrorConsumerIPDenied = errors.New("consumer IP is denied by the provider")
+	// ErrorTooManySessions returned when consumer already has the maximum allowed number of active sessions
+	ErrorTooManySessions = errors.New("too many concurrent sessions")
+	// ErrorTooManyAttempts returned when consumer tries to create sessions too often
+	ErrorTooManyAttempts = errors.New("too many session create attempts, try again later")
 )
 
+// Error codes sent to the consumer over p2p, so refused session requests can be told apart.
+const (
+	ErrorCodeServiceDraining  = "service_draining"
+	ErrorCodeConsumerDenied   = "consumer_denied"
+	ErrorCodeConsumerIPDenied = "consumer_ip_denied"
+	ErrorCodeTooManySessions  = "too_many_sessions"
+	ErrorCodeTooManyAttempts  = "too_many_attempts"
+)
+
+// ErrorCode returns the code of the session refusal error, empty string if the error is not a refusal.
+func ErrorCode(err error) string {
+	switch errors.Cause(err) {
+	case ErrorServiceDraining:
+		return ErrorCodeServiceDraining
+	case ErrorConsumerDenied:
+		return ErrorCodeConsumerDenied
+	case ErrorConsumerIPDenied:
+		return ErrorCodeConsumerIPDenied
+	case ErrorTooManySessions:
+		return ErrorCodeTooManySessions
+	case ErrorTooManyAttempts:
+		return ErrorCodeTooManyAttempts
+	default:
+		return ""
+	}
+}
+
 // IDGenerator defines method for session id generation
 type IDGenerator func() (ID, error)
 
@@ -112,6 +150,22 @@ type NATEventGetter interface {
 	LastEvent() *event.Event
 }
 
+// QuotaEnforcer limits usage of the service by consumers.
+type QuotaEnforcer interface {
+	Track(sessionID string, consumerID identity.Identity, onExceeded func(reason string))
+}
+
+// ServiceState tells if the service still accepts new sessions.
+type ServiceState interface {
+	Draining() bool
+}
+
+// Admission decides if the consumer may create a new session.
+type Admission interface {
+	Admit(consumerID identity.Identity, consumerIP net.IP) error
+	AdmitSession(consumerID identity.Identity) error
+}
+
 // NewManager returns new session Manager
 func NewManager(
 	currentProposal market.ServiceProposal,
@@ -122,6 +176,9 @@ func NewManager(
 	serviceId string,
 	publisher publisher,
 	channel p2p.Channel,
+	quotaEnforcer QuotaEnforcer,
+	serviceState ServiceState,
+	admission Admission,
 	config Config,
 ) *Manager {
 	return &Manager{
@@ -133,6 +190,9 @@ func NewManager(
 		publisher:            publisher,
 		paymentEngineFactory: paymentEngineFactory,
 		channel:              channel,
+		quotaEnforcer:        quotaEnforcer,
+		serviceState:         serviceState,
+		admission:            admission,
 		config:               config,
 	}
 }
@@ -148,6 +208,9 @@ type Manager struct {
 	publisher            publisher
 	creationLock         sync.Mutex
 	channel              p2p.Channel
+	quotaEnforcer        QuotaEnforcer
+	serviceState         ServiceState
+	admission            Admission
 	config               Config
 }
 
@@ -162,6 +225,18 @@ func (manager *Manager) Start(session *Session, consumerID identity.Identity, co
 		return
 	}
 
+	if manager.serviceState != nil && manager.serviceState.Draining() {
+		err = ErrorServiceDraining
+		return
+	}
+
+	// Concurrent sessions are counted again under the creation lock, Admit only refuses early.
+	if manager.admission != nil {
+		if err = manager.admission.AdmitSession(consumerID); err != nil {
+			return
+		}
+	}
+
 	session.ServiceType = manager.currentProposal.ServiceType
 	session.ServiceID = manager.serviceId
 	session.ConsumerID = consumerID
@@ -185,7 +260,7 @@ func (manager *Manager) Start(session *Session, consumerID identity.Identity, co
 		err := engine.Start()
 		if err != nil {
 			log.Error().Err(err).Msg("Payment engine error")
-			destroyErr := manager.Destroy(consumerID, string(session.ID))
+			destroyErr := manager.destroy(consumerID, string(session.ID), sevent.ReasonPaymentError)
 			if destroyErr != nil {
 				log.Error().Err(err).Msg("Session cleanup failed")
 			}
@@ -198,9 +273,25 @@ func (manager *Manager) Start(session *Session, consumerID identity.Identity, co
 	}
 	go manager.keepAliveLoop(session, manager.channel)
 	manager.sessionStorage.Add(*session)
+
+	if manager.quotaEnforcer != nil {
+		sessionID := session.ID
+		manager.quotaEnforcer.Track(string(sessionID), consumerID, func(reason string) {
+			manager.destroyOverQuota(consumerID, sessionID, reason)
+		})
+	}
 	return nil
 }
 
+// Admit checks if the consumer may create a new session, IP is nil when it is not known.
+// It is called before any service resources are allocated for the session.
+func (manager *Manager) Admit(consumerID identity.Identity, consumerIP net.IP) error {
+	if manager.admission == nil {
+		return nil
+	}
+	return manager.admission.Admit(consumerID, consumerIP)
+}
+
 // Acknowledge marks the session as successfully established as far as the consumer is concerned.
 func (manager *Manager) Acknowledge(consumerID identity.Identity, sessionID string) error {
 	manager.creationLock.Lock()
@@ -225,6 +316,10 @@ func (manager *Manager) Acknowledge(consumerID identity.Identity, sessionID stri
 
 // Destroy destroys session by given sessionID
 func (manager *Manager) Destroy(consumerID identity.Identity, sessionID string) error {
+	return manager.destroy(consumerID, sessionID, sevent.ReasonConsumerRequest)
+}
+
+func (manager *Manager) destroy(consumerID identity.Identity, sessionID string, reason string) error {
 	manager.creationLock.Lock()
 	defer manager.creationLock.Unlock()
 
@@ -240,10 +335,34 @@ func (manager *Manager) Destroy(consumerID identity.Identity, sessionID string)
 
 	manager.sessionStorage.Remove(ID(sessionID))
 	close(session.done)
+	manager.publisher.Publish(sevent.AppTopicSessionClosed, newSessionClosedEvent(session, reason))
 
 	return nil
 }
 
+// destroyOverQuota notifies consumer that the quota is exceeded and destroys the session.
+func (manager *Manager) destroyOverQuota(consumerID identity.Identity, sessionID ID, reason string) {
+	if manager.channel != nil {
+		msg := &pb.SessionStatus{
+			ConsumerID: consumerID.Address,
+			SessionID:  string(sessionID),
+			Code:       uint32(connectivity.StatusSessionQuotaExceeded),
+			Message:    reason,
+		}
+		log.Debug().Msgf("Sending session status P2P message to %q: %s", p2p.TopicSessionStatus, msg.String())
+
+		ctx, cancel := context.WithTimeout(context.Background(), manager.config.KeepAlive.SendTimeout)
+		defer cancel()
+		if _, err := manager.channel.Send(ctx, p2p.TopicSessionStatus, p2p.ProtoMessage(msg)); err != nil {
+			log.Warn().Err(err).Msgf("Failed to notify consumer about exceeded quota. SessionID=%s", sessionID)
+		}
+	}
+
+	if err := manager.destroy(consumerID, string(sessionID), sevent.ReasonQuotaExceeded); err != nil {
+		log.Error().Err(err).Msgf("Failed to destroy session over quota. SessionID=%s", sessionID)
+	}
+}
+
 func (manager *Manager) keepAliveLoop(sess *Session, channel p2p.Channel) {
 	// TODO: Remove this check once all provider migrates to p2p.
 	if channel == nil {
@@ -274,6 +393,9 @@ func (manager *Manager) keepAliveLoop(sess *Session, channel p2p.Channel) {
 				if errCount == manager.config.KeepAlive.MaxSendErrCount {
 					log.Error().Msgf("Max p2p keepalive err count reached, closing p2p channel. SessionID=%s", sess.ID)
 					channel.Close()
+					if err := manager.destroy(sess.ConsumerID, string(sess.ID), sevent.ReasonConnectionLost); err != nil {
+						log.Error().Err(err).Msgf("Failed to destroy session after lost connection. SessionID=%s", sess.ID)
+					}
 					return
 				}
 			} else {
@@ -18,6 +18,10 @@
 package session
 
 import (
+	"context"
+	"errors"
+	"net"
+	"sync"
 	"testing"
 	"time"
 
@@ -26,6 +30,7 @@ import (
 	"github.com/mysteriumnetwork/node/mocks"
 	"github.com/mysteriumnetwork/node/nat/event"
 	"github.com/mysteriumnetwork/node/nat/traversal"
+	"github.com/mysteriumnetwork/node/p2p"
 	sessionEvent "github.com/mysteriumnetwork/node/session/event"
 	"github.com/stretchr/testify/assert"
 )
@@ -95,6 +100,70 @@ func TestManager_Start_RejectsUnknownProposal(t *testing.T) {
 	assert.Empty(t, session.CreatedAt)
 }
 
+type mockServiceState struct {
+	draining bool
+}
+
+func (m *mockServiceState) Draining() bool {
+	return m.draining
+}
+
+func TestManager_Start_RejectsWhenServiceDraining(t *testing.T) {
+	sessionStore := NewStorageMemory()
+
+	manager := newManager(currentProposal, sessionStore)
+	manager.serviceState = &mockServiceState{draining: true}
+
+	session, err := NewSession()
+	assert.NoError(t, err)
+	err = manager.Start(session, consumerID, ConsumerInfo{IssuerID: consumerID}, currentProposalID, nil, nil)
+	assert.Exactly(t, ErrorServiceDraining, err)
+	assert.Empty(t, sessionStore.GetAll())
+}
+
+type mockAdmission struct {
+	sessions   *StorageMemory
+	maxPerUser int
+}
+
+func (m *mockAdmission) Admit(consumerID identity.Identity, _ net.IP) error {
+	return nil
+}
+
+func (m *mockAdmission) AdmitSession(consumerID identity.Identity) error {
+	count := 0
+	for _, s := range m.sessions.GetAll() {
+		if s.ConsumerID == consumerID {
+			count++
+		}
+	}
+	if count >= m.maxPerUser {
+		return ErrorTooManySessions
+	}
+	return nil
+}
+
+func TestManager_Start_LimitsConcurrentSessionsAtomically(t *testing.T) {
+	sessionStore := NewStorageMemory()
+
+	manager := newManager(currentProposal, sessionStore)
+	manager.admission = &mockAdmission{sessions: sessionStore, maxPerUser: 1}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			session, err := NewSession()
+			assert.NoError(t, err)
+			manager.Start(session, consumerID, ConsumerInfo{IssuerID: consumerID}, currentProposalID, nil, nil)
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, sessionStore.GetAll(), 1)
+}
+
 type MockNatEventTracker struct {
 }
 
@@ -145,5 +214,78 @@ func TestManager_AcknowledgeSession_PublishesEvent(t *testing.T) {
 
 func newManager(proposal market.ServiceProposal, sessionStore *StorageMemory) *Manager {
 	return NewManager(proposal, sessionStore, mockPaymentEngineFactory, traversal.NewNoopPinger(),
-		&MockNatEventTracker{}, "test service id", mocks.NewEventBus(), nil, DefaultConfig())
+		&MockNatEventTracker{}, "test service id", mocks.NewEventBus(), nil, nil, nil, nil, DefaultConfig())
+}
+
+func TestManager_Destroy_PublishesSessionClosedEvent(t *testing.T) {
+	sessionStore := NewStorageMemory()
+
+	mp := mocks.NewEventBus()
+	manager := newManager(currentProposal, sessionStore)
+	manager.publisher = mp
+
+	session, err := NewSession()
+	assert.NoError(t, err)
+	err = manager.Start(session, consumerID, ConsumerInfo{IssuerID: consumerID}, currentProposalID, nil, nil)
+	assert.NoError(t, err)
+	sessionStore.UpdateDataTransfer(session.ID, 10, 20)
+	sessionStore.UpdateEarnings(session.ID, 30)
+
+	err = manager.Destroy(consumerID, string(session.ID))
+	assert.NoError(t, err)
+
+	closed, ok := mp.Pop().(sessionEvent.AppEventSessionClosed)
+	assert.True(t, ok)
+	assert.Equal(t, string(session.ID), closed.ID)
+	assert.Equal(t, consumerID, closed.ConsumerID)
+	assert.Equal(t, "test service id", closed.ServiceID)
+	assert.EqualValues(t, 10, closed.BytesSent)
+	assert.EqualValues(t, 20, closed.BytesReceived)
+	assert.EqualValues(t, 30, closed.TokensEarned)
+	assert.Equal(t, sessionEvent.ReasonConsumerRequest, closed.Reason)
+	assert.False(t, closed.Ended.Before(closed.Started))
+}
+
+type unreachableChannel struct {
+	p2p.Channel
+	closed chan struct{}
+}
+
+func (c *unreachableChannel) Handle(string, p2p.HandlerFunc) {}
+
+func (c *unreachableChannel) Send(context.Context, string, *p2p.Message) (*p2p.Message, error) {
+	return nil, errors.New("consumer unreachable")
+}
+
+func (c *unreachableChannel) Close() error {
+	close(c.closed)
+	return nil
+}
+
+func TestManager_KeepAliveLoss_DestroysSessionWithConnectionLostReason(t *testing.T) {
+	sessionStore := NewStorageMemory()
+	channel := &unreachableChannel{closed: make(chan struct{})}
+
+	config := DefaultConfig()
+	config.KeepAlive.SendInterval = time.Millisecond
+	config.KeepAlive.MaxSendErrCount = 2
+	mp := mocks.NewEventBus()
+	manager := NewManager(currentProposal, sessionStore, mockPaymentEngineFactory, traversal.NewNoopPinger(),
+		&MockNatEventTracker{}, "test service id", mp, channel, nil, nil, nil, config)
+
+	session, err := NewSession()
+	assert.NoError(t, err)
+	err = manager.Start(session, consumerID, ConsumerInfo{IssuerID: consumerID}, currentProposalID, nil, nil)
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, found := sessionStore.Find(session.ID)
+		return !found
+	}, 2*time.Second, 10*time.Millisecond)
+	<-channel.closed
+
+	closed, ok := mp.Pop().(sessionEvent.AppEventSessionClosed)
+	assert.True(t, ok)
+	assert.Equal(t, string(session.ID), closed.ID)
+	assert.Equal(t, sessionEvent.ReasonConnectionLost, closed.Reason)
 }
@@ -63,9 +63,37 @@ type promiseStorage interface {
 	Get(providerID, accountantID identity.Identity) (AccountantPromise, error)
 }
 
+type settlementHistoryStorage interface {
+	Store(entry SettlementHistoryEntry) error
+}
+
 type receivedPromise struct {
 	provider identity.Identity
 	promise  crypto.Promise
+	strategy SettlementStrategy
+}
+
+// SettlementStrategy decides when the provider earnings are settled.
+type SettlementStrategy string
+
+const (
+	// SettlementStrategyThreshold settles once the unsettled earnings cross the threshold of the channel balance.
+	SettlementStrategyThreshold = SettlementStrategy("threshold")
+	// SettlementStrategyPeriodic settles the unsettled earnings on a fixed schedule.
+	SettlementStrategyPeriodic = SettlementStrategy("periodic")
+	// SettlementStrategyFee checks the settlement fee on a fixed schedule and settles once it is below the configured share of the unsettled earnings.
+	SettlementStrategyFee = SettlementStrategy("fee")
+	// SettlementStrategyManual settles only when requested.
+	SettlementStrategyManual = SettlementStrategy("manual")
+)
+
+// ParseSettlementStrategy parses the settlement strategy from the given string.
+func ParseSettlementStrategy(s string) (SettlementStrategy, error) {
+	switch strategy := SettlementStrategy(s); strategy {
+	case SettlementStrategyThreshold, SettlementStrategyPeriodic, SettlementStrategyFee, SettlementStrategyManual:
+		return strategy, nil
+	}
+	return "", errors.Errorf("unknown settlement strategy %q", s)
 }
 
 // AccountantPromiseSettler is responsible for settling the accountant promises.
@@ -86,6 +114,7 @@ type accountantPromiseSettler struct {
 	ks                         ks
 	transactor                 transactor
 	promiseStorage             promiseStorage
+	history                    settlementHistoryStorage
 
 	currentState map[identity.Identity]SettlementState
 	settleQueue  chan receivedPromise
@@ -98,10 +127,19 @@ type AccountantPromiseSettlerConfig struct {
 	AccountantAddress    common.Address
 	Threshold            float64
 	MaxWaitForSettlement time.Duration
+	Strategy             SettlementStrategy
+	// Period is the time between settlements of the periodic strategy and between settlement fee checks of the fee strategy.
+	Period time.Duration
+	// MaxFeePercent is the largest settlement fee, in percents of the unsettled earnings, accepted by the fee strategy.
+	MaxFeePercent float64
 }
 
 // NewAccountantPromiseSettler creates a new instance of accountant promise settler.
-func NewAccountantPromiseSettler(eventBus eventbus.EventBus, transactor transactor, promiseStorage promiseStorage, providerChannelStatusProvider providerChannelStatusProvider, registrationStatusProvider registrationStatusProvider, ks ks, config AccountantPromiseSettlerConfig) *accountantPromiseSettler {
+func NewAccountantPromiseSettler(eventBus eventbus.EventBus, transactor transactor, promiseStorage promiseStorage, history settlementHistoryStorage, providerChannelStatusProvider providerChannelStatusProvider, registrationStatusProvider registrationStatusProvider, ks ks, config AccountantPromiseSettlerConfig) *accountantPromiseSettler {
+	if config.Strategy == "" {
+		config.Strategy = SettlementStrategyThreshold
+	}
+
 	return &accountantPromiseSettler{
 		eventBus:                   eventBus,
 		bc:                         providerChannelStatusProvider,
@@ -110,6 +148,7 @@ func NewAccountantPromiseSettler(eventBus eventbus.EventBus, transactor transact
 		config:                     config,
 		currentState:               make(map[identity.Identity]SettlementState),
 		promiseStorage:             promiseStorage,
+		history:                    history,
 
 		// defaulting to a queue of 5, in case we have a few active identities.
 		settleQueue: make(chan receivedPromise, 5),
@@ -120,10 +159,11 @@ func NewAccountantPromiseSettler(eventBus eventbus.EventBus, transactor transact
 
 // loadInitialState loads the initial state for the given identity. Inteded to be called on service start.
 func (aps *accountantPromiseSettler) loadInitialState(addr identity.Identity) error {
-	aps.lock.Lock()
-	defer aps.lock.Unlock()
+	aps.lock.RLock()
+	_, ok := aps.currentState[addr]
+	aps.lock.RUnlock()
 
-	if _, ok := aps.currentState[addr]; ok {
+	if ok {
 		log.Info().Msgf("State for %v already loaded, skipping", addr)
 		return nil
 	}
@@ -141,6 +181,7 @@ func (aps *accountantPromiseSettler) loadInitialState(addr identity.Identity) er
 	return aps.resyncState(addr)
 }
 
+// resyncState fetches the state of the provider from blockchain without holding the lock, so other providers are not blocked meanwhile.
 func (aps *accountantPromiseSettler) resyncState(id identity.Identity) error {
 	channel, err := aps.bc.GetProviderChannel(aps.config.AccountantAddress, id.ToCommonAddress())
 	if err != nil {
@@ -158,6 +199,9 @@ func (aps *accountantPromiseSettler) resyncState(id identity.Identity) error {
 		registered:  true,
 	}
 
+	aps.lock.Lock()
+	defer aps.lock.Unlock()
+
 	go aps.publishChangeEvent(id, aps.currentState[id], s)
 	aps.currentState[id] = s
 	log.Info().Msgf("Loaded state for provider %q: balance %v, available balance %v, unsettled balance %v", id, s.balance(), s.availableBalance(), s.UnsettledBalance())
@@ -223,9 +267,6 @@ func (aps *accountantPromiseSettler) handleNodeEvent(payload nodevent.Payload) {
 }
 
 func (aps *accountantPromiseSettler) handleRegistrationEvent(payload registry.AppEventIdentityRegistration) {
-	aps.lock.Lock()
-	defer aps.lock.Unlock()
-
 	if payload.Status != registry.RegisteredProvider {
 		log.Debug().Msgf("Ignoring event %v for provider %q", payload.Status.String(), payload.ID)
 		return
@@ -267,11 +308,86 @@ func (aps *accountantPromiseSettler) handleAccountantPromiseReceived(apep AppEve
 	aps.currentState[apep.ProviderID] = s
 	log.Info().Msgf("Accountant promise state updated for provider %q", id)
 
-	if s.needsSettling(aps.config.Threshold) {
+	if aps.shouldQueue(s) {
 		aps.settleQueue <- receivedPromise{
 			provider: apep.ProviderID,
 			promise:  apep.Promise,
+			strategy: aps.config.Strategy,
+		}
+	}
+}
+
+// shouldQueue checks if the strategy settles the state on receiving a promise.
+func (aps *accountantPromiseSettler) shouldQueue(s SettlementState) bool {
+	return aps.config.Strategy == SettlementStrategyThreshold && s.needsSettling(aps.config.Threshold)
+}
+
+// isWorthSettling checks if the settlement fee is low enough for the fee strategy.
+func (aps *accountantPromiseSettler) isWorthSettling(fee, unsettled uint64) bool {
+	return float64(fee) < aps.config.MaxFeePercent/100*float64(unsettled)
+}
+
+func (aps *accountantPromiseSettler) handleSettlementRequest(p receivedPromise) {
+	if err := aps.settle(p); err != nil {
+		log.Error().Err(err).Msgf("Could not settle promise for provider %v", p.provider)
+	}
+}
+
+func (aps *accountantPromiseSettler) settlePeriodically() {
+	log.Info().Msgf("Settling earnings by %v strategy every %v", aps.config.Strategy, aps.config.Period)
+	ticker := time.NewTicker(aps.config.Period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-aps.stop:
+			return
+		case <-ticker.C:
+			aps.settleUnsettled()
+		}
+	}
+}
+
+// settleUnsettled settles the earnings of every registered provider which has any unsettled.
+// The fee strategy fetches the settlement fee once and skips the providers it is too high for.
+func (aps *accountantPromiseSettler) settleUnsettled() {
+	aps.lock.RLock()
+	due := make(map[identity.Identity]uint64)
+	for id, s := range aps.currentState {
+		if s.registered && !s.settleInProgress && s.UnsettledBalance() > 0 {
+			due[id] = s.UnsettledBalance()
+		}
+	}
+	aps.lock.RUnlock()
+
+	if len(due) == 0 {
+		return
+	}
+
+	var fee uint64
+	if aps.config.Strategy == SettlementStrategyFee {
+		fees, err := aps.transactor.FetchSettleFees()
+		if err != nil {
+			log.Error().Err(err).Msg("Could not fetch settle fees")
+			return
 		}
+		fee = fees.Fee
+	}
+
+	accountantID := identity.FromAddress(aps.config.AccountantAddress.Hex())
+	for id, unsettled := range due {
+		if aps.config.Strategy == SettlementStrategyFee && !aps.isWorthSettling(fee, unsettled) {
+			log.Debug().Msgf("Settlement fee %v too high for provider %v earnings of %v, skipping", fee, id, unsettled)
+			aps.recordSettlement(receivedPromise{provider: id, strategy: aps.config.Strategy}, unsettled, SettlementOutcomeFeeTooHigh,
+				errors.Errorf("settlement fee %v exceeds %v%% of the earnings", fee, aps.config.MaxFeePercent))
+			continue
+		}
+
+		go func(id identity.Identity) {
+			if err := aps.forceSettle(id, accountantID, aps.config.Strategy); err != nil {
+				log.Error().Err(err).Msgf("Could not settle promise for provider %v", id)
+			}
+		}(id)
 	}
 }
 
@@ -286,7 +402,7 @@ func (aps *accountantPromiseSettler) listenForSettlementRequests() {
 		case <-aps.stop:
 			return
 		case p := <-aps.settleQueue:
-			go aps.settle(p)
+			go aps.handleSettlementRequest(p)
 		}
 	}
 }
@@ -304,6 +420,10 @@ var ErrNothingToSettle = errors.New("nothing to settle for the given provider")
 
 // ForceSettle forces the settlement for a provider
 func (aps *accountantPromiseSettler) ForceSettle(providerID, accountantID identity.Identity) error {
+	return aps.forceSettle(providerID, accountantID, SettlementStrategyManual)
+}
+
+func (aps *accountantPromiseSettler) forceSettle(providerID, accountantID identity.Identity, strategy SettlementStrategy) error {
 	promise, err := aps.promiseStorage.Get(providerID, accountantID)
 	if err == ErrNotFound {
 		return ErrNothingToSettle
@@ -321,18 +441,35 @@ func (aps *accountantPromiseSettler) ForceSettle(providerID, accountantID identi
 	return aps.settle(receivedPromise{
 		promise:  promise.Promise,
 		provider: providerID,
+		strategy: strategy,
 	})
 }
 
 // ErrSettleTimeout indicates that the settlement has timed out
 var ErrSettleTimeout = errors.New("settle timeout")
 
-func (aps *accountantPromiseSettler) settle(p receivedPromise) error {
-	if aps.isSettling(p.provider) {
-		return errors.New("provider already has settlement in progress")
+// ErrSettleInProgress indicates that the provider already has a settlement in progress
+var ErrSettleInProgress = errors.New("provider already has settlement in progress")
+
+func (aps *accountantPromiseSettler) settle(p receivedPromise) (err error) {
+	amount := aps.SettlementState(p.provider).UnsettledBalance()
+	if !aps.startSettling(p.provider) {
+		aps.recordSettlement(p, amount, SettlementOutcomeInProgress, ErrSettleInProgress)
+		return ErrSettleInProgress
 	}
 
-	aps.setSettling(p.provider, true)
+	defer func() {
+		outcome := SettlementOutcomeSettled
+		if err != nil {
+			outcome = SettlementOutcomeFailed
+		}
+		aps.recordSettlement(p, amount, outcome, err)
+		aps.eventBus.Publish(AppTopicSettlement, AppEventSettlement{
+			ProviderID:   p.provider,
+			AccountantID: identity.FromAddress(aps.config.AccountantAddress.Hex()),
+			Error:        err,
+		})
+	}()
 	log.Info().Msgf("Marked provider %v as requesting setlement", p.provider)
 	sink, cancel, err := aps.bc.SubscribeToPromiseSettledEvent(p.provider.ToCommonAddress(), aps.config.AccountantAddress)
 	if err != nil {
@@ -384,6 +521,24 @@ func (aps *accountantPromiseSettler) settle(p receivedPromise) error {
 	return <-errCh
 }
 
+func (aps *accountantPromiseSettler) recordSettlement(p receivedPromise, amount uint64, outcome SettlementOutcome, settleErr error) {
+	entry := SettlementHistoryEntry{
+		ProviderID:   p.provider,
+		AccountantID: identity.FromAddress(aps.config.AccountantAddress.Hex()),
+		Strategy:     p.strategy,
+		Amount:       amount,
+		Time:         time.Now().UTC(),
+		Outcome:      outcome,
+	}
+	if settleErr != nil {
+		entry.Error = settleErr.Error()
+	}
+
+	if err := aps.history.Store(entry); err != nil {
+		log.Error().Err(err).Msgf("Could not record settlement for provider %v", p.provider)
+	}
+}
+
 func (aps *accountantPromiseSettler) isSettling(id identity.Identity) bool {
 	aps.lock.RLock()
 	defer aps.lock.RUnlock()
@@ -395,6 +550,19 @@ func (aps *accountantPromiseSettler) isSettling(id identity.Identity) bool {
 	return v.settleInProgress
 }
 
+// startSettling marks the provider as settling, returns false if it already was.
+func (aps *accountantPromiseSettler) startSettling(id identity.Identity) bool {
+	aps.lock.Lock()
+	defer aps.lock.Unlock()
+	v := aps.currentState[id]
+	if v.settleInProgress {
+		return false
+	}
+	v.settleInProgress = true
+	aps.currentState[id] = v
+	return true
+}
+
 func (aps *accountantPromiseSettler) setSettling(id identity.Identity, settling bool) {
 	aps.lock.Lock()
 	defer aps.lock.Unlock()
@@ -405,6 +573,9 @@ func (aps *accountantPromiseSettler) setSettling(id identity.Identity, settling
 
 func (aps *accountantPromiseSettler) handleNodeStart() {
 	go aps.listenForSettlementRequests()
+	if aps.config.Strategy == SettlementStrategyPeriodic || aps.config.Strategy == SettlementStrategyFee {
+		go aps.settlePeriodically()
+	}
 
 	for _, v := range aps.ks.Accounts() {
 		addr := identity.FromAddress(v.Address.Hex())
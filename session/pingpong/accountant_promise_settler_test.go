@@ -22,6 +22,7 @@ import (
 	"io/ioutil"
 	"math/big"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -48,7 +49,7 @@ func TestPromiseSettler_resyncState_returns_errors(t *testing.T) {
 
 	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
 
-	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, channelStatusProvider, mrsp, ks, cfg)
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, &mockSettlementHistoryStorage{}, channelStatusProvider, mrsp, ks, cfg)
 	err = settler.resyncState(mockID)
 	assert.Equal(t, fmt.Sprintf("could not get provider channel for %v: %v", mockID, errMock.Error()), err.Error())
 
@@ -73,7 +74,7 @@ func TestPromiseSettler_resyncState_handles_no_promise(t *testing.T) {
 	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
 
 	id := identity.FromAddress("test")
-	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, channelStatusProvider, mrsp, ks, cfg)
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, &mockSettlementHistoryStorage{}, channelStatusProvider, mrsp, ks, cfg)
 	err = settler.resyncState(id)
 	assert.NoError(t, err)
 
@@ -102,7 +103,7 @@ func TestPromiseSettler_resyncState_takes_promise_into_account(t *testing.T) {
 
 	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
 
-	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, channelStatusProvider, mrsp, ks, cfg)
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, &mockSettlementHistoryStorage{}, channelStatusProvider, mrsp, ks, cfg)
 	err = settler.resyncState(mockID)
 	assert.NoError(t, err)
 
@@ -131,7 +132,7 @@ func TestPromiseSettler_loadInitialState(t *testing.T) {
 
 	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
 
-	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, channelStatusProvider, mrsp, ks, cfg)
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, &mockSettlementHistoryStorage{}, channelStatusProvider, mrsp, ks, cfg)
 
 	settler.currentState[mockID] = SettlementState{}
 
@@ -201,7 +202,7 @@ func TestPromiseSettler_handleServiceEvent(t *testing.T) {
 
 	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
 
-	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, channelStatusProvider, mrsp, ks, cfg)
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, &mockSettlementHistoryStorage{}, channelStatusProvider, mrsp, ks, cfg)
 
 	statusesWithNoChangeExpected := []string{string(servicestate.Starting), string(servicestate.NotRunning)}
 
@@ -243,7 +244,7 @@ func TestPromiseSettler_handleRegistrationEvent(t *testing.T) {
 
 	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
 
-	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, channelStatusProvider, mrsp, ks, cfg)
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, &mockSettlementHistoryStorage{}, channelStatusProvider, mrsp, ks, cfg)
 
 	statusesWithNoChangeExpected := []registry.RegistrationStatus{registry.RegisteredConsumer, registry.Unregistered, registry.InProgress, registry.Promoting, registry.RegistrationError}
 	for _, v := range statusesWithNoChangeExpected {
@@ -285,7 +286,7 @@ func TestPromiseSettler_handleAccountantPromiseReceived(t *testing.T) {
 	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
 
 	// no receive on unknown provider
-	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, channelStatusProvider, mrsp, ks, cfg)
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, &mockSettlementHistoryStorage{}, channelStatusProvider, mrsp, ks, cfg)
 	settler.handleAccountantPromiseReceived(AppEventAccountantPromise{
 		AccountantID: identity.FromAddress(cfg.AccountantAddress.Hex()),
 		ProviderID:   mockID,
@@ -336,6 +337,261 @@ func TestPromiseSettler_handleAccountantPromiseReceived(t *testing.T) {
 	assertNoReceive(t, settler.settleQueue)
 }
 
+func TestPromiseSettler_keepsStatePerProvider(t *testing.T) {
+	otherID := identity.FromAddress("0x2")
+	dir, err := ioutil.TempDir("", "TestPromiseSettler_keepsStatePerProvider")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, &mockAccountantPromiseGetter{}, &mockSettlementHistoryStorage{}, &mockProviderChannelStatusProvider{}, &mockRegistrationStatusProvider{}, ks, cfg)
+	settler.currentState[mockID] = SettlementState{
+		Channel:     client.ProviderChannel{Balance: big.NewInt(10000)},
+		LastPromise: crypto.Promise{Amount: 100},
+		registered:  true,
+	}
+	settler.currentState[otherID] = SettlementState{
+		Channel:     client.ProviderChannel{Balance: big.NewInt(10000)},
+		LastPromise: crypto.Promise{Amount: 500},
+		registered:  true,
+	}
+
+	settler.handleAccountantPromiseReceived(AppEventAccountantPromise{
+		AccountantID: identity.FromAddress(cfg.AccountantAddress.Hex()),
+		ProviderID:   otherID,
+		Promise:      crypto.Promise{Amount: 9000},
+	})
+
+	p := <-settler.settleQueue
+	assert.Equal(t, otherID, p.provider)
+	assert.Equal(t, uint64(10000-9000), settler.SettlementState(otherID).balance())
+	assert.Equal(t, uint64(10000-100), settler.SettlementState(mockID).balance())
+
+	settler.setSettling(otherID, true)
+	assert.True(t, settler.isSettling(otherID))
+	assert.False(t, settler.isSettling(mockID))
+}
+
+func TestPromiseSettler_strategies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestPromiseSettler_strategies")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
+	nearlyDepleted := SettlementState{
+		Channel:     client.ProviderChannel{Balance: big.NewInt(10000), Settled: big.NewInt(0)},
+		LastPromise: crypto.Promise{Amount: 9500},
+		registered:  true,
+	}
+	barelyUsed := SettlementState{
+		Channel:     client.ProviderChannel{Balance: big.NewInt(10000), Settled: big.NewInt(0)},
+		LastPromise: crypto.Promise{Amount: 1000},
+		registered:  true,
+	}
+
+	tests := []struct {
+		strategy SettlementStrategy
+		state    SettlementState
+		queued   bool
+	}{
+		{SettlementStrategyThreshold, nearlyDepleted, true},
+		{SettlementStrategyThreshold, barelyUsed, false},
+		{SettlementStrategyFee, nearlyDepleted, false},
+		{SettlementStrategyPeriodic, nearlyDepleted, false},
+		{SettlementStrategyManual, nearlyDepleted, false},
+	}
+	for _, tt := range tests {
+		config := cfg
+		config.Strategy = tt.strategy
+		settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, &mockAccountantPromiseGetter{}, &mockSettlementHistoryStorage{}, &mockProviderChannelStatusProvider{}, &mockRegistrationStatusProvider{}, ks, config)
+		assert.Equal(t, tt.queued, settler.shouldQueue(tt.state), "%v strategy", tt.strategy)
+	}
+
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, &mockAccountantPromiseGetter{}, &mockSettlementHistoryStorage{}, &mockProviderChannelStatusProvider{}, &mockRegistrationStatusProvider{}, ks, cfg)
+	assert.Equal(t, SettlementStrategyThreshold, settler.config.Strategy)
+
+	_, err = ParseSettlementStrategy("weekly")
+	assert.Error(t, err)
+	strategy, err := ParseSettlementStrategy("fee")
+	assert.NoError(t, err)
+	assert.Equal(t, SettlementStrategyFee, strategy)
+}
+
+func TestPromiseSettler_isWorthSettling(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestPromiseSettler_isWorthSettling")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
+	config := cfg
+	config.Strategy = SettlementStrategyFee
+	config.MaxFeePercent = 5
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, &mockAccountantPromiseGetter{}, &mockSettlementHistoryStorage{}, &mockProviderChannelStatusProvider{}, &mockRegistrationStatusProvider{}, ks, config)
+
+	assert.False(t, settler.isWorthSettling(50, 1000), "fee is exactly 5% of the unsettled earnings")
+	assert.True(t, settler.isWorthSettling(50, 2000))
+}
+
+func TestPromiseSettler_recordsSettlementHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestPromiseSettler_recordsSettlementHistory")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
+	sink := make(chan *bindings.AccountantImplementationPromiseSettled)
+	close(sink)
+	channelStatusProvider := &mockProviderChannelStatusProvider{
+		channelToReturn: mockProviderChannel,
+		sinkToReturn:    sink,
+		subCancel:       func() {},
+	}
+	history := &mockSettlementHistoryStorage{}
+	accountantID := identity.FromAddress(cfg.AccountantAddress.Hex())
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, &mockAccountantPromiseGetter{}, history, channelStatusProvider, &mockRegistrationStatusProvider{}, ks, cfg)
+	settler.currentState[mockID] = SettlementState{
+		Channel:     client.ProviderChannel{Balance: big.NewInt(10000), Settled: big.NewInt(100)},
+		LastPromise: crypto.Promise{Amount: 600},
+		registered:  true,
+	}
+
+	err = settler.ForceSettle(mockID, accountantID)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return !settler.isSettling(mockID) }, time.Second, time.Millisecond)
+
+	channelStatusProvider.subError = errMock
+	err = settler.forceSettle(mockID, accountantID, SettlementStrategyPeriodic)
+	assert.Equal(t, errMock, err)
+
+	settler.setSettling(mockID, true)
+	err = settler.ForceSettle(mockID, accountantID)
+	assert.Equal(t, ErrSettleInProgress, err)
+
+	entries := history.stored()
+	assert.Len(t, entries, 3)
+	assert.Equal(t, mockID, entries[0].ProviderID)
+	assert.Equal(t, accountantID, entries[0].AccountantID)
+	assert.Equal(t, SettlementStrategyManual, entries[0].Strategy)
+	assert.Equal(t, uint64(500), entries[0].Amount)
+	assert.Equal(t, SettlementOutcomeSettled, entries[0].Outcome)
+	assert.True(t, entries[0].Successful())
+	assert.Equal(t, SettlementStrategyPeriodic, entries[1].Strategy)
+	assert.Equal(t, SettlementOutcomeFailed, entries[1].Outcome)
+	assert.Equal(t, errMock.Error(), entries[1].Error)
+	assert.False(t, entries[1].Successful())
+	assert.Equal(t, SettlementOutcomeInProgress, entries[2].Outcome)
+	assert.False(t, entries[2].Successful())
+}
+
+func TestPromiseSettler_settleUnsettled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestPromiseSettler_settleUnsettled")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
+	otherID := identity.FromAddress("0x2")
+	history := &mockSettlementHistoryStorage{}
+	channelStatusProvider := &mockProviderChannelStatusProvider{subError: errMock}
+	config := cfg
+	config.Strategy = SettlementStrategyPeriodic
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, &mockAccountantPromiseGetter{}, history, channelStatusProvider, &mockRegistrationStatusProvider{}, ks, config)
+	settler.currentState[mockID] = SettlementState{
+		Channel:     client.ProviderChannel{Balance: big.NewInt(10000), Settled: big.NewInt(0)},
+		LastPromise: crypto.Promise{Amount: 600},
+		registered:  true,
+	}
+	settler.currentState[otherID] = SettlementState{
+		Channel:    client.ProviderChannel{Balance: big.NewInt(10000), Settled: big.NewInt(0)},
+		registered: true,
+	}
+
+	settler.settleUnsettled()
+
+	assert.Eventually(t, func() bool { return len(history.stored()) == 1 }, time.Second, time.Millisecond)
+	entry := history.stored()[0]
+	assert.Equal(t, mockID, entry.ProviderID)
+	assert.Equal(t, SettlementStrategyPeriodic, entry.Strategy)
+}
+
+func TestPromiseSettler_settleUnsettled_feeStrategy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestPromiseSettler_settleUnsettled_feeStrategy")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
+	otherID := identity.FromAddress("0x2")
+	history := &mockSettlementHistoryStorage{}
+	transactor := &mockTransactor{feesToReturn: registry.FeesResponse{Fee: 50}}
+	channelStatusProvider := &mockProviderChannelStatusProvider{subError: errMock}
+	config := cfg
+	config.Strategy = SettlementStrategyFee
+	config.MaxFeePercent = 5
+	settler := NewAccountantPromiseSettler(eventbus.New(), transactor, &mockAccountantPromiseGetter{}, history, channelStatusProvider, &mockRegistrationStatusProvider{}, ks, config)
+	settler.currentState[mockID] = SettlementState{
+		Channel:     client.ProviderChannel{Balance: big.NewInt(10000), Settled: big.NewInt(0)},
+		LastPromise: crypto.Promise{Amount: 2000},
+		registered:  true,
+	}
+	settler.currentState[otherID] = SettlementState{
+		Channel:     client.ProviderChannel{Balance: big.NewInt(10000), Settled: big.NewInt(0)},
+		LastPromise: crypto.Promise{Amount: 1000},
+		registered:  true,
+	}
+
+	settler.settleUnsettled()
+
+	assert.Equal(t, 1, transactor.feesFetched)
+	assert.Eventually(t, func() bool { return len(history.stored()) == 2 }, time.Second, time.Millisecond)
+	outcomes := make(map[identity.Identity]SettlementOutcome)
+	for _, entry := range history.stored() {
+		assert.Equal(t, SettlementStrategyFee, entry.Strategy)
+		outcomes[entry.ProviderID] = entry.Outcome
+	}
+	assert.Equal(t, SettlementOutcomeFailed, outcomes[mockID])
+	assert.Equal(t, SettlementOutcomeFeeTooHigh, outcomes[otherID])
+
+	transactor.feesError = errMock
+	settler.settleUnsettled()
+	assert.Equal(t, 2, transactor.feesFetched)
+	assert.Len(t, history.stored(), 2)
+}
+
+func TestPromiseSettler_resyncState_doesNotBlockOtherProviders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestPromiseSettler_resyncState_doesNotBlockOtherProviders")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	channelProvider := &mockProviderChannelStatusProvider{
+		channelToReturn: client.ProviderChannel{Balance: big.NewInt(10000)},
+		channelBlock:    make(chan struct{}),
+	}
+	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, &mockAccountantPromiseGetter{}, &mockSettlementHistoryStorage{}, channelProvider, &mockRegistrationStatusProvider{}, ks, cfg)
+	settler.currentState[mockID] = SettlementState{
+		Channel:    client.ProviderChannel{Balance: big.NewInt(5000)},
+		registered: true,
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- settler.resyncState(identity.FromAddress("0x2"))
+	}()
+
+	state := make(chan SettlementState)
+	go func() {
+		state <- settler.SettlementState(mockID)
+	}()
+	select {
+	case s := <-state:
+		assert.Equal(t, uint64(5000), s.balance())
+	case <-time.After(time.Second):
+		t.Fatal("settlement state is blocked by resync of other provider")
+	}
+
+	close(channelProvider.channelBlock)
+	assert.NoError(t, <-done)
+	assert.Equal(t, uint64(10000), settler.SettlementState(identity.FromAddress("0x2")).balance())
+}
+
 func assertNoReceive(t *testing.T, ch chan receivedPromise) {
 	// at this point, we should not receive an event on settled queue as we have no info on provider, let's check for that
 	select {
@@ -374,7 +630,7 @@ func TestPromiseSettler_handleNodeStart(t *testing.T) {
 		},
 	}
 
-	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, channelStatusProvider, mrsp, ks, cfg)
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, &mockSettlementHistoryStorage{}, channelStatusProvider, mrsp, ks, cfg)
 
 	settler.handleNodeStart()
 
@@ -453,6 +709,7 @@ type mockProviderChannelStatusProvider struct {
 	sinkToReturn       chan *bindings.AccountantImplementationPromiseSettled
 	subCancel          func()
 	subError           error
+	channelBlock       chan struct{}
 }
 
 func (mpcsp *mockProviderChannelStatusProvider) SubscribeToPromiseSettledEvent(providerID, accountantID common.Address) (sink chan *bindings.AccountantImplementationPromiseSettled, cancel func(), err error) {
@@ -460,6 +717,9 @@ func (mpcsp *mockProviderChannelStatusProvider) SubscribeToPromiseSettledEvent(p
 }
 
 func (mpcsp *mockProviderChannelStatusProvider) GetProviderChannel(accountantAddress common.Address, addressToCheck common.Address) (client.ProviderChannel, error) {
+	if mpcsp.channelBlock != nil {
+		<-mpcsp.channelBlock
+	}
 	return mpcsp.channelToReturn, mpcsp.channelReturnError
 }
 
@@ -508,12 +768,32 @@ type mockTransactor struct {
 	registerError error
 	feesToReturn  registry.FeesResponse
 	feesError     error
+	feesFetched   int
 }
 
 func (mt *mockTransactor) FetchSettleFees() (registry.FeesResponse, error) {
+	mt.feesFetched++
 	return mt.feesToReturn, mt.feesError
 }
 
 func (mt *mockTransactor) SettleAndRebalance(id string, promise crypto.Promise) error {
 	return nil
 }
+
+type mockSettlementHistoryStorage struct {
+	lock    sync.Mutex
+	entries []SettlementHistoryEntry
+}
+
+func (mshs *mockSettlementHistoryStorage) Store(entry SettlementHistoryEntry) error {
+	mshs.lock.Lock()
+	defer mshs.lock.Unlock()
+	mshs.entries = append(mshs.entries, entry)
+	return nil
+}
+
+func (mshs *mockSettlementHistoryStorage) stored() []SettlementHistoryEntry {
+	mshs.lock.Lock()
+	defer mshs.lock.Unlock()
+	return append([]SettlementHistoryEntry{}, mshs.entries...)
+}
@@ -115,6 +115,16 @@ func (ss SettlementState) needsSettling(threshold float64) bool {
 	return false
 }
 
+// AppTopicSettlement represents the settlement attempt topic
+const AppTopicSettlement = "settlement"
+
+// AppEventSettlement represents the result of the settlement attempt
+type AppEventSettlement struct {
+	ProviderID   identity.Identity
+	AccountantID identity.Identity
+	Error        error
+}
+
 // AppTopicInvoicePaid is a topic for publish events exchange message send to provider as a consumer.
 const AppTopicInvoicePaid = "invoice_paid"
 
@@ -184,7 +184,8 @@ func ExchangeFactoryFunc(
 	channelImplementation string,
 	registryAddress string,
 	eventBus eventbus.EventBus,
-	dataLeewayMegabytes uint64) func(paymentInfo session.PaymentInfo,
+	dataLeewayMegabytes uint64,
+	budget consumerBudget) func(paymentInfo session.PaymentInfo,
 	dialog communication.Dialog, channel p2p.Channel,
 	consumer, provider, accountant identity.Identity, proposal market.ServiceProposal, sessionID string) (connection.PaymentIssuer, error) {
 	return func(paymentInfo session.PaymentInfo,
@@ -216,6 +217,7 @@ func ExchangeFactoryFunc(
 			AccountantAddress:         accountant,
 			SessionID:                 sessionID,
 			DataLeeway:                datasize.MiB * datasize.BitSize(dataLeewayMegabytes),
+			Budget:                    budget,
 		}
 		return NewInvoicePayer(deps), nil
 	}
@@ -73,6 +73,10 @@ type channelAddressCalculator interface {
 	GetChannelAddress(id identity.Identity) (common.Address, error)
 }
 
+type consumerBudget interface {
+	Remaining(consumerID identity.Identity) (remaining uint64, capped bool, err error)
+}
+
 // InvoicePayer keeps track of exchange messages and sends them to the provider.
 type InvoicePayer struct {
 	stop           chan struct{}
@@ -100,6 +104,7 @@ type InvoicePayerDeps struct {
 	EventBus                  eventbus.EventBus
 	AccountantAddress         identity.Identity
 	DataLeeway                datasize.BitSize
+	Budget                    consumerBudget
 }
 
 // NewInvoicePayer returns a new instance of exchange message tracker.
@@ -183,9 +188,30 @@ func (ip *InvoicePayer) isInvoiceOK(invoice crypto.Invoice) error {
 		return ErrProviderOvercharge
 	}
 
+	if ip.deps.Budget == nil {
+		return nil
+	}
+	remaining, capped, err := ip.deps.Budget.Remaining(ip.deps.Identity)
+	if err != nil {
+		return errors.Wrap(err, "could not get remaining budget")
+	}
+	if diff := ip.invoiceDiff(invoice); capped && diff > remaining {
+		log.Warn().Msgf("Invoice of %v exceeds the remaining budget of %v", diff, remaining)
+		return connection.ErrBudgetExceeded
+	}
+
 	return nil
 }
 
+// invoiceDiff returns the amount the invoice adds on top of the already paid ones.
+func (ip *InvoicePayer) invoiceDiff(invoice crypto.Invoice) uint64 {
+	// This is a new agreement, the whole agreement total is to be paid
+	if ip.lastInvoice.AgreementID != invoice.AgreementID {
+		return invoice.AgreementTotal
+	}
+	return invoice.AgreementTotal - ip.lastInvoice.AgreementTotal
+}
+
 func estimateInvoiceTolerance(elapsed time.Duration, transferred dataTransferred) float64 {
 	if elapsed.Seconds() < 1 {
 		return 3
@@ -214,16 +240,12 @@ func estimateInvoiceTolerance(elapsed time.Duration, transferred dataTransferred
 }
 
 func (ip *InvoicePayer) calculateAmountToPromise(invoice crypto.Invoice) (toPromise uint64, diff uint64, err error) {
-	diff = invoice.AgreementTotal - ip.lastInvoice.AgreementTotal
 	totalPromised, err := ip.deps.ConsumerTotalsStorage.Get(ip.deps.Identity, ip.deps.AccountantAddress)
 	if err != nil {
 		return 0, 0, fmt.Errorf("could not get previous grand total: %w", err)
 	}
 
-	// This is a new agreement, we need to take in the agreement total and just add it to total promised
-	if ip.lastInvoice.AgreementID != invoice.AgreementID {
-		diff = invoice.AgreementTotal
-	}
+	diff = ip.invoiceDiff(invoice)
 
 	log.Debug().Msgf("Loaded previous state: already promised: %v", totalPromised)
 	log.Debug().Msgf("Incrementing promised amount by %v", diff)
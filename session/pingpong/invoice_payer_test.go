@@ -289,6 +289,7 @@ func TestInvoicePayer_isInvoiceOK(t *testing.T) {
 		peer        identity.Identity
 		timeTracker timeTracker
 		proposal    market.ServiceProposal
+		budget      consumerBudget
 	}
 	tests := []struct {
 		name    string
@@ -350,6 +351,52 @@ func TestInvoicePayer_isInvoiceOK(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "errors on invoice exceeding remaining budget",
+			fields: fields{
+				peer: identity.FromAddress("0x441Da57A51e42DAB7Daf55909Af93A9b00eEF23C"),
+				timeTracker: &mockTimeTracker{
+					timeToReturn: time.Minute,
+				},
+				proposal: market.ServiceProposal{
+					PaymentMethod: &mockPaymentMethod{
+						price: money.NewMoney(100000, money.CurrencyMyst),
+						rate:  market.PaymentRate{PerTime: time.Minute},
+					},
+				},
+				budget: &mockConsumerBudget{remaining: 99999, capped: true},
+			},
+			invoice: crypto.Invoice{
+				TransactorFee:  0,
+				AgreementID:    1,
+				AgreementTotal: 100000,
+				Provider:       "0x441Da57A51e42DAB7Daf55909Af93A9b00eEF23C",
+			},
+			wantErr: true,
+		},
+		{
+			name: "accepts invoice within remaining budget",
+			fields: fields{
+				peer: identity.FromAddress("0x441Da57A51e42DAB7Daf55909Af93A9b00eEF23C"),
+				timeTracker: &mockTimeTracker{
+					timeToReturn: time.Minute,
+				},
+				proposal: market.ServiceProposal{
+					PaymentMethod: &mockPaymentMethod{
+						price: money.NewMoney(100000, money.CurrencyMyst),
+						rate:  market.PaymentRate{PerTime: time.Minute},
+					},
+				},
+				budget: &mockConsumerBudget{remaining: 100000, capped: true},
+			},
+			invoice: crypto.Invoice{
+				TransactorFee:  0,
+				AgreementID:    1,
+				AgreementTotal: 100000,
+				Provider:       "0x441Da57A51e42DAB7Daf55909Af93A9b00eEF23C",
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -358,6 +405,7 @@ func TestInvoicePayer_isInvoiceOK(t *testing.T) {
 					TimeTracker: tt.fields.timeTracker,
 					Proposal:    tt.fields.proposal,
 					Peer:        tt.fields.peer,
+					Budget:      tt.fields.budget,
 				},
 			}
 			if err := emt.isInvoiceOK(tt.invoice); (err != nil) != tt.wantErr {
@@ -890,3 +938,12 @@ func Test_estimateInvoiceTolerance(t *testing.T) {
 		})
 	}
 }
+
+type mockConsumerBudget struct {
+	remaining uint64
+	capped    bool
+}
+
+func (mcb *mockConsumerBudget) Remaining(_ identity.Identity) (uint64, bool, error) {
+	return mcb.remaining, mcb.capped, nil
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pingpong
+
+import (
+	"sync"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
+	"github.com/gofrs/uuid"
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/pkg/errors"
+)
+
+const settlementHistoryBucketName = "settlement_history"
+
+// SettlementOutcome represents how the settlement attempt ended.
+type SettlementOutcome string
+
+const (
+	// SettlementOutcomeSettled means the earnings were settled.
+	SettlementOutcomeSettled = SettlementOutcome("settled")
+	// SettlementOutcomeFailed means the settlement was attempted and failed.
+	SettlementOutcomeFailed = SettlementOutcome("failed")
+	// SettlementOutcomeInProgress means the attempt was skipped as another settlement of the provider was in progress.
+	SettlementOutcomeInProgress = SettlementOutcome("in_progress")
+	// SettlementOutcomeFeeTooHigh means the attempt was skipped as the settlement fee was too high for the fee strategy.
+	SettlementOutcomeFeeTooHigh = SettlementOutcome("fee_too_high")
+)
+
+// SettlementHistoryEntry represents a single settlement attempt and its outcome
+type SettlementHistoryEntry struct {
+	ID           string `storm:"id"`
+	ProviderID   identity.Identity
+	AccountantID identity.Identity
+	Strategy     SettlementStrategy
+	Amount       uint64
+	Time         time.Time
+	Outcome      SettlementOutcome
+	Error        string
+}
+
+// Successful returns true if the settlement attempt succeeded.
+func (she SettlementHistoryEntry) Successful() bool {
+	return she.Outcome == SettlementOutcomeSettled
+}
+
+// SettlementHistoryFilter narrows down the settlement history query
+type SettlementHistoryFilter struct {
+	// From includes attempts made at or after the given time
+	From *time.Time
+	// To includes attempts made before the given time
+	To *time.Time
+	// ProviderID includes attempts of the given provider, all providers if empty
+	ProviderID identity.Identity
+	Offset     int
+	Limit      int
+}
+
+func (f SettlementHistoryFilter) matchers() []q.Matcher {
+	var matchers []q.Matcher
+	if f.From != nil {
+		matchers = append(matchers, q.Gte("Time", *f.From))
+	}
+	if f.To != nil {
+		matchers = append(matchers, q.Lt("Time", *f.To))
+	}
+	if f.ProviderID.Address != "" {
+		matchers = append(matchers, q.Eq("ProviderID", f.ProviderID))
+	}
+	return matchers
+}
+
+type settlementHistoryStorer interface {
+	Store(bucket string, data interface{}) error
+	Select(bucket string, matchers ...q.Matcher) storm.Query
+}
+
+// SettlementHistoryStorage stores the settlement attempts.
+type SettlementHistoryStorage struct {
+	lock sync.Mutex
+	bolt settlementHistoryStorer
+}
+
+// NewSettlementHistoryStorage returns a new instance of the settlement history storage.
+func NewSettlementHistoryStorage(bolt settlementHistoryStorer) *SettlementHistoryStorage {
+	return &SettlementHistoryStorage{
+		bolt: bolt,
+	}
+}
+
+// Store stores the given settlement attempt.
+func (shs *SettlementHistoryStorage) Store(entry SettlementHistoryEntry) error {
+	shs.lock.Lock()
+	defer shs.lock.Unlock()
+
+	if entry.ID == "" {
+		id, err := uuid.NewV4()
+		if err != nil {
+			return errors.Wrap(err, "could not generate settlement ID")
+		}
+		entry.ID = id.String()
+	}
+
+	return errors.Wrap(shs.bolt.Store(settlementHistoryBucketName, &entry), "could not store settlement")
+}
+
+// List returns the page of settlement attempts matching the filter, newest first, together with the count of all matching attempts.
+func (shs *SettlementHistoryStorage) List(filter SettlementHistoryFilter) (entries []SettlementHistoryEntry, total int, err error) {
+	shs.lock.Lock()
+	defer shs.lock.Unlock()
+
+	matchers := filter.matchers()
+
+	total, err = shs.bolt.Select(settlementHistoryBucketName, matchers...).Count(new(SettlementHistoryEntry))
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "could not count settlement history")
+	}
+
+	query := shs.bolt.Select(settlementHistoryBucketName, matchers...).OrderBy("Time").Reverse().Skip(filter.Offset)
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	entries = []SettlementHistoryEntry{}
+	if err := query.Find(&entries); err != nil && err != storage.ErrNotFound {
+		return nil, 0, errors.Wrap(err, "could not get settlement history")
+	}
+	return entries, total, nil
+}
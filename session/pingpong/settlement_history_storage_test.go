@@ -0,0 +1,87 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pingpong
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb/boltdbtest"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSettlementHistoryStorer struct {
+	entries []SettlementHistoryEntry
+}
+
+func (m *mockSettlementHistoryStorer) Store(_ string, data interface{}) error {
+	m.entries = append(m.entries, *data.(*SettlementHistoryEntry))
+	return nil
+}
+
+func (m *mockSettlementHistoryStorer) Select(_ string, matchers ...q.Matcher) storm.Query {
+	return boltdbtest.NewQueryStub(m.entries, matchers...)
+}
+
+func TestSettlementHistoryStorage(t *testing.T) {
+	provider := identity.FromAddress("0x1")
+	otherProvider := identity.FromAddress("0x2")
+	now := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	storage := NewSettlementHistoryStorage(&mockSettlementHistoryStorer{})
+
+	assert.NoError(t, storage.Store(SettlementHistoryEntry{ProviderID: provider, Amount: 1, Time: now, Outcome: SettlementOutcomeSettled}))
+	assert.NoError(t, storage.Store(SettlementHistoryEntry{ProviderID: otherProvider, Amount: 2, Time: now.Add(time.Minute), Outcome: SettlementOutcomeSettled}))
+	assert.NoError(t, storage.Store(SettlementHistoryEntry{ProviderID: provider, Amount: 3, Time: now.Add(time.Hour), Outcome: SettlementOutcomeFailed, Error: "settle timeout"}))
+
+	entries, total, err := storage.List(SettlementHistoryFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, uint64(3), entries[0].Amount)
+	assert.Equal(t, uint64(2), entries[1].Amount)
+	assert.Equal(t, uint64(1), entries[2].Amount)
+	assert.NotEmpty(t, entries[0].ID)
+	assert.NotEqual(t, entries[0].ID, entries[1].ID)
+
+	entries, total, err = storage.List(SettlementHistoryFilter{ProviderID: provider})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, entries, 2)
+	assert.False(t, entries[0].Successful())
+	assert.True(t, entries[1].Successful())
+
+	from, to := now.Add(time.Minute), now.Add(time.Hour)
+	entries, total, err = storage.List(SettlementHistoryFilter{From: &from, To: &to})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, uint64(2), entries[0].Amount)
+
+	entries, total, err = storage.List(SettlementHistoryFilter{Offset: 1, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, uint64(2), entries[0].Amount)
+
+	entries, total, err = storage.List(SettlementHistoryFilter{Offset: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Empty(t, entries)
+}
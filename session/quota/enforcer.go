@@ -0,0 +1,369 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package quota
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/event"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	usageBucketName = "provider_quota_usage"
+	errBoltNotFound = "not found"
+	bytesInMB       = 1024 * 1024
+)
+
+// Config describes quotas of a single consumer, zero value means unlimited.
+type Config struct {
+	// SessionTraffic is the amount of bytes allowed to transfer during a session.
+	SessionTraffic uint64
+	// SessionDuration is the maximum duration of a session.
+	SessionDuration time.Duration
+	// DailyTraffic is the amount of bytes allowed to transfer during a day.
+	DailyTraffic uint64
+	// DailyDuration is the total time of sessions allowed during a day.
+	DailyDuration time.Duration
+	// CheckInterval is the frequency of session duration checks.
+	CheckInterval time.Duration
+}
+
+// ConfiguredQuotas returns effective quotas from application configuration.
+func ConfiguredQuotas() Config {
+	return Config{
+		SessionTraffic:  config.GetUInt64(config.FlagQuotaSessionTraffic) * bytesInMB,
+		SessionDuration: config.GetDuration(config.FlagQuotaSessionDuration),
+		DailyTraffic:    config.GetUInt64(config.FlagQuotaDailyTraffic) * bytesInMB,
+		DailyDuration:   config.GetDuration(config.FlagQuotaDailyDuration),
+		CheckInterval:   10 * time.Second,
+	}
+}
+
+// Usage is a daily usage of provider services by a consumer.
+type Usage struct {
+	ConsumerID string
+	Date       string
+	Bytes      uint64
+	Duration   time.Duration
+}
+
+type persistentStorage interface {
+	GetValue(bucket string, key interface{}, to interface{}) error
+	SetValue(bucket string, key interface{}, to interface{}) error
+}
+
+type eventSubscriber interface {
+	SubscribeAsync(topic string, fn interface{}) error
+}
+
+// trackedSession holds session usage, parts of it which are already added to daily usage are marked as counted.
+type trackedSession struct {
+	consumerID   identity.Identity
+	startedAt    time.Time
+	bytes        uint64
+	countedBytes uint64
+	countedUntil time.Time
+	exceeded     bool
+	onExceeded   func(reason string)
+}
+
+// dailyUsage is a daily usage kept in memory, it is marked as dirty until saved to the storage.
+type dailyUsage struct {
+	Usage
+	dirty bool
+}
+
+// Enforcer keeps track of consumer usage and ends sessions once the quotas are exceeded.
+// Daily usage is kept in memory and saved to the storage on every check interval and once a session ends.
+type Enforcer struct {
+	config  Config
+	storage persistentStorage
+	bus     eventSubscriber
+	timeNow func() time.Time
+
+	lock     sync.Mutex
+	sessions map[string]*trackedSession
+	usage    map[string]*dailyUsage
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewEnforcer creates a new instance of quota enforcer.
+func NewEnforcer(config Config, storage persistentStorage, bus eventSubscriber) *Enforcer {
+	return &Enforcer{
+		config:   config,
+		storage:  storage,
+		bus:      bus,
+		timeNow:  time.Now,
+		sessions: make(map[string]*trackedSession),
+		usage:    make(map[string]*dailyUsage),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Subscribe subscribes the enforcer to session events.
+func (e *Enforcer) Subscribe() error {
+	if err := e.bus.SubscribeAsync(event.AppTopicDataTransferred, e.consumeDataTransferredEvent); err != nil {
+		return err
+	}
+	return e.bus.SubscribeAsync(event.AppTopicSession, e.consumeSessionEvent)
+}
+
+// Start periodically checks time quotas of the tracked sessions and saves daily usage, it blocks until stopped.
+func (e *Enforcer) Start() {
+	if e.config.SessionDuration == 0 && !e.dailyQuotas() {
+		return
+	}
+
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-time.After(e.config.CheckInterval):
+			e.checkAll()
+		}
+	}
+}
+
+// Stop stops the enforcer and saves daily usage.
+func (e *Enforcer) Stop() {
+	e.stopOnce.Do(func() {
+		close(e.stop)
+
+		e.lock.Lock()
+		defer e.lock.Unlock()
+		if err := e.save(); err != nil {
+			log.Error().Err(err).Msg("Could not save quota usage")
+		}
+	})
+}
+
+// Track starts tracking usage of the session, onExceeded is called once any of the quotas is exceeded.
+func (e *Enforcer) Track(sessionID string, consumerID identity.Identity, onExceeded func(reason string)) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	now := e.timeNow()
+	s := &trackedSession{
+		consumerID:   consumerID,
+		startedAt:    now,
+		countedUntil: now,
+		onExceeded:   onExceeded,
+	}
+	e.sessions[sessionID] = s
+	e.check(sessionID, s)
+}
+
+// GetUsage returns the usage of provider services by the consumer today.
+func (e *Enforcer) GetUsage(consumerID identity.Identity) (Usage, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	usage, err := e.getUsage(consumerID, e.timeNow())
+	if err != nil {
+		return Usage{}, err
+	}
+	return usage.Usage, nil
+}
+
+func (e *Enforcer) consumeDataTransferredEvent(ev event.AppEventDataTransferred) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	s, ok := e.sessions[ev.ID]
+	if !ok {
+		return
+	}
+	s.bytes = ev.Up + ev.Down
+	e.check(ev.ID, s)
+}
+
+func (e *Enforcer) consumeSessionEvent(ev event.Payload) {
+	if ev.Action != event.Removed {
+		return
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	s, ok := e.sessions[ev.ID]
+	if !ok {
+		return
+	}
+	if err := e.count(s); err != nil {
+		log.Error().Err(err).Msgf("Could not count quota usage of session %s", ev.ID)
+	}
+	delete(e.sessions, ev.ID)
+	if err := e.save(); err != nil {
+		log.Error().Err(err).Msg("Could not save quota usage")
+	}
+}
+
+func (e *Enforcer) checkAll() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for id, s := range e.sessions {
+		e.check(id, s)
+	}
+	if err := e.save(); err != nil {
+		log.Error().Err(err).Msg("Could not save quota usage")
+	}
+}
+
+// check adds session usage to the daily usage and calls onExceeded if any of the quotas is exceeded.
+func (e *Enforcer) check(sessionID string, s *trackedSession) {
+	if err := e.count(s); err != nil {
+		log.Error().Err(err).Msgf("Could not count quota usage of session %s", sessionID)
+	}
+	if s.exceeded {
+		return
+	}
+
+	reason, exceeded := e.exceeded(s)
+	if !exceeded {
+		return
+	}
+
+	log.Info().Msgf("Consumer %s exceeded quota of session %s: %s", s.consumerID.Address, sessionID, reason)
+	s.exceeded = true
+	go s.onExceeded(reason)
+}
+
+func (e *Enforcer) exceeded(s *trackedSession) (string, bool) {
+	now := e.timeNow()
+	if e.config.SessionTraffic > 0 && s.bytes >= e.config.SessionTraffic {
+		return fmt.Sprintf("session traffic quota of %d MB exceeded", e.config.SessionTraffic/bytesInMB), true
+	}
+	if e.config.SessionDuration > 0 && now.Sub(s.startedAt) >= e.config.SessionDuration {
+		return fmt.Sprintf("session time quota of %s exceeded", e.config.SessionDuration), true
+	}
+
+	if !e.dailyQuotas() {
+		return "", false
+	}
+	usage, err := e.getUsage(s.consumerID, now)
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get daily quota usage")
+		return "", false
+	}
+	if e.config.DailyTraffic > 0 && usage.Bytes >= e.config.DailyTraffic {
+		return fmt.Sprintf("daily traffic quota of %d MB exceeded", e.config.DailyTraffic/bytesInMB), true
+	}
+	if e.config.DailyDuration > 0 && usage.Duration >= e.config.DailyDuration {
+		return fmt.Sprintf("daily time quota of %s exceeded", e.config.DailyDuration), true
+	}
+	return "", false
+}
+
+func (e *Enforcer) dailyQuotas() bool {
+	return e.config.DailyTraffic > 0 || e.config.DailyDuration > 0
+}
+
+// count adds not yet counted session usage to the daily usage of the consumer.
+// Session time is split at midnight, traffic is counted to the day it is reported on.
+func (e *Enforcer) count(s *trackedSession) error {
+	if !e.dailyQuotas() {
+		return nil
+	}
+
+	now := e.timeNow()
+	for s.countedUntil.Before(now) {
+		until := nextDay(s.countedUntil)
+		if until.After(now) {
+			until = now
+		}
+
+		usage, err := e.getUsage(s.consumerID, s.countedUntil)
+		if err != nil {
+			return err
+		}
+		usage.Duration += until.Sub(s.countedUntil)
+		usage.dirty = true
+		s.countedUntil = until
+	}
+
+	if s.bytes > s.countedBytes {
+		usage, err := e.getUsage(s.consumerID, now)
+		if err != nil {
+			return err
+		}
+		usage.Bytes += s.bytes - s.countedBytes
+		usage.dirty = true
+		s.countedBytes = s.bytes
+	}
+	return nil
+}
+
+// save saves changed daily usage to the storage and forgets usage of the past days.
+func (e *Enforcer) save() error {
+	today := usageDate(e.timeNow())
+	for key, usage := range e.usage {
+		if usage.dirty {
+			if err := e.storage.SetValue(usageBucketName, key, usage.Usage); err != nil {
+				return errors.Wrap(err, "could not save quota usage")
+			}
+			usage.dirty = false
+		}
+		if usage.Date != today {
+			delete(e.usage, key)
+		}
+	}
+	return nil
+}
+
+// getUsage returns daily usage of the consumer, it is loaded from the storage if not kept in memory.
+func (e *Enforcer) getUsage(consumerID identity.Identity, t time.Time) (*dailyUsage, error) {
+	key := usageKey(consumerID, t)
+	if usage, ok := e.usage[key]; ok {
+		return usage, nil
+	}
+
+	usage := Usage{
+		ConsumerID: consumerID.Address,
+		Date:       usageDate(t),
+	}
+	err := e.storage.GetValue(usageBucketName, key, &usage)
+	if err != nil && err.Error() != errBoltNotFound {
+		return nil, errors.Wrap(err, "could not get quota usage")
+	}
+	usage.Date = usageDate(t)
+	e.usage[key] = &dailyUsage{Usage: usage}
+	return e.usage[key], nil
+}
+
+func usageDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// nextDay returns the beginning of the UTC day following the given time.
+func nextDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, time.UTC)
+}
+
+func usageKey(consumerID identity.Identity, t time.Time) string {
+	return consumerID.Address + ":" + usageDate(t)
+}
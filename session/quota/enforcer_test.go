@@ -0,0 +1,192 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package quota
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/event"
+	"github.com/stretchr/testify/assert"
+)
+
+var consumerID = identity.FromAddress("0x1")
+
+func Test_Enforcer_SessionTrafficExceeded(t *testing.T) {
+	enforcer := NewEnforcer(Config{SessionTraffic: 10 * bytesInMB}, newMockStorage(), &mockSubscriber{})
+	exceeded := newExceededRecorder()
+
+	enforcer.Track("session1", consumerID, exceeded.record)
+	enforcer.consumeDataTransferredEvent(event.AppEventDataTransferred{ID: "session1", Up: 5 * bytesInMB, Down: 4 * bytesInMB})
+	assert.Equal(t, "", exceeded.get())
+
+	enforcer.consumeDataTransferredEvent(event.AppEventDataTransferred{ID: "session1", Up: 5 * bytesInMB, Down: 5 * bytesInMB})
+	assert.Eventually(t, func() bool {
+		return exceeded.get() == "session traffic quota of 10 MB exceeded"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_Enforcer_SessionDurationExceeded(t *testing.T) {
+	now := time.Now()
+	enforcer := NewEnforcer(Config{SessionDuration: time.Hour}, newMockStorage(), &mockSubscriber{})
+	enforcer.timeNow = func() time.Time { return now }
+	exceeded := newExceededRecorder()
+
+	enforcer.Track("session1", consumerID, exceeded.record)
+	now = now.Add(time.Hour)
+	enforcer.checkAll()
+
+	assert.Eventually(t, func() bool {
+		return exceeded.get() == "session time quota of 1h0m0s exceeded"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_Enforcer_DailyTrafficIsSharedAcrossSessions(t *testing.T) {
+	storage := newMockStorage()
+	enforcer := NewEnforcer(Config{DailyTraffic: 10 * bytesInMB}, storage, &mockSubscriber{})
+	exceeded := newExceededRecorder()
+
+	enforcer.Track("session1", consumerID, exceeded.record)
+	enforcer.consumeDataTransferredEvent(event.AppEventDataTransferred{ID: "session1", Up: 3 * bytesInMB, Down: 3 * bytesInMB})
+	enforcer.consumeSessionEvent(event.Payload{ID: "session1", Action: event.Removed})
+
+	usage, err := enforcer.GetUsage(consumerID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6*bytesInMB), usage.Bytes)
+
+	enforcer.Track("session2", consumerID, exceeded.record)
+	enforcer.consumeDataTransferredEvent(event.AppEventDataTransferred{ID: "session2", Up: 2 * bytesInMB, Down: 2 * bytesInMB})
+
+	assert.Eventually(t, func() bool {
+		return exceeded.get() == "daily traffic quota of 10 MB exceeded"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_Enforcer_RefusesSessionWhenDailyQuotaIsUsed(t *testing.T) {
+	storage := newMockStorage()
+	now := time.Now()
+	storage.values[usageKey(consumerID, now)] = Usage{ConsumerID: consumerID.Address, Duration: 2 * time.Hour}
+	enforcer := NewEnforcer(Config{DailyDuration: 2 * time.Hour}, storage, &mockSubscriber{})
+	enforcer.timeNow = func() time.Time { return now }
+	exceeded := newExceededRecorder()
+
+	enforcer.Track("session1", consumerID, exceeded.record)
+
+	assert.Eventually(t, func() bool {
+		return exceeded.get() == "daily time quota of 2h0m0s exceeded"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_Enforcer_SavesDailyUsageOnCheck(t *testing.T) {
+	storage := newMockStorage()
+	now := time.Now()
+	enforcer := NewEnforcer(Config{DailyTraffic: 10 * bytesInMB}, storage, &mockSubscriber{})
+	enforcer.timeNow = func() time.Time { return now }
+
+	enforcer.Track("session1", consumerID, func(string) {})
+	enforcer.consumeDataTransferredEvent(event.AppEventDataTransferred{ID: "session1", Up: 1, Down: 1})
+	enforcer.consumeDataTransferredEvent(event.AppEventDataTransferred{ID: "session1", Up: 2, Down: 2})
+	assert.Equal(t, 0, storage.writes)
+
+	enforcer.checkAll()
+
+	assert.Equal(t, 1, storage.writes)
+	assert.Equal(t, uint64(4), storage.values[usageKey(consumerID, now)].Bytes)
+}
+
+func Test_Enforcer_SplitsDailyDurationAtMidnight(t *testing.T) {
+	storage := newMockStorage()
+	now := time.Date(2020, 6, 1, 23, 0, 0, 0, time.UTC)
+	enforcer := NewEnforcer(Config{DailyDuration: 10 * time.Hour}, storage, &mockSubscriber{})
+	enforcer.timeNow = func() time.Time { return now }
+
+	enforcer.Track("session1", consumerID, func(string) {})
+	now = now.Add(3 * time.Hour)
+	enforcer.consumeSessionEvent(event.Payload{ID: "session1", Action: event.Removed})
+
+	assert.Equal(t, time.Hour, storage.values[consumerID.Address+":2020-06-01"].Duration)
+	assert.Equal(t, 2*time.Hour, storage.values[consumerID.Address+":2020-06-02"].Duration)
+	usage, err := enforcer.GetUsage(consumerID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Hour, usage.Duration)
+}
+
+func Test_Enforcer_Subscribe(t *testing.T) {
+	subscriber := &mockSubscriber{}
+	enforcer := NewEnforcer(Config{}, newMockStorage(), subscriber)
+
+	assert.NoError(t, enforcer.Subscribe())
+	assert.Equal(t, []string{event.AppTopicDataTransferred, event.AppTopicSession}, subscriber.topics)
+}
+
+type exceededRecorder struct {
+	lock   sync.Mutex
+	reason string
+}
+
+func newExceededRecorder() *exceededRecorder {
+	return &exceededRecorder{}
+}
+
+func (r *exceededRecorder) record(reason string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.reason = reason
+}
+
+func (r *exceededRecorder) get() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.reason
+}
+
+type mockSubscriber struct {
+	topics []string
+}
+
+func (m *mockSubscriber) SubscribeAsync(topic string, _ interface{}) error {
+	m.topics = append(m.topics, topic)
+	return nil
+}
+
+type mockStorage struct {
+	values map[string]Usage
+	writes int
+}
+
+func newMockStorage() *mockStorage {
+	return &mockStorage{values: make(map[string]Usage)}
+}
+
+func (m *mockStorage) GetValue(_ string, key interface{}, to interface{}) error {
+	usage, ok := m.values[key.(string)]
+	if !ok {
+		return errors.New(errBoltNotFound)
+	}
+	*to.(*Usage) = usage
+	return nil
+}
+
+func (m *mockStorage) SetValue(_ string, key interface{}, to interface{}) error {
+	m.values[key.(string)] = to.(Usage)
+	m.writes++
+	return nil
+}
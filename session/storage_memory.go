@@ -99,6 +99,16 @@ func (storage *StorageMemory) UpdateEarnings(id ID, total uint64) {
 	}
 }
 
+// IncrementDNSBlocked increases the number of DNS queries blocked during the session.
+func (storage *StorageMemory) IncrementDNSBlocked(id ID) {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+	if session, found := storage.sessions[id]; found {
+		session.DNSBlocked++
+		storage.sessions[id] = session
+	}
+}
+
 // FindOpts provides fields to search sessions.
 type FindOpts struct {
 	Peer        *identity.Identity
@@ -81,6 +81,21 @@ func TestStorageMemory_UpdateEarnings(t *testing.T) {
 	assert.EqualValues(t, 420, session.TokensEarned)
 }
 
+func TestStorageMemory_IncrementDNSBlocked(t *testing.T) {
+	// given
+	storage := mockStorage(sessionExisting)
+
+	// when
+	storage.IncrementDNSBlocked(sessionExisting.ID)
+	storage.IncrementDNSBlocked(sessionExisting.ID)
+	storage.IncrementDNSBlocked(ID("unknown"))
+
+	// then
+	session, ok := storage.Find(sessionExisting.ID)
+	assert.True(t, ok)
+	assert.EqualValues(t, 2, session.DNSBlocked)
+}
+
 func TestStorageMemory_FindByPeer(t *testing.T) {
 	storage := mockStorage(sessionExisting)
 	id, ok := storage.FindBy(FindOpts{&sessionExisting.ConsumerID, ""})
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/identity/registry"
@@ -29,6 +30,9 @@ import (
 	"github.com/pkg/errors"
 )
 
+// ErrServiceAlreadyRunning is returned when the provider already runs the service of the requested type
+var ErrServiceAlreadyRunning = errors.New("service already running")
+
 // NewClient returns a new instance of Client
 func NewClient(ip string, port int) *Client {
 	return &Client{
@@ -85,6 +89,39 @@ func (client *Client) CurrentIdentity(identity, passphrase string) (id contract.
 	return id, err
 }
 
+// ExportIdentity exports identity keystore encrypted with the new passphrase
+func (client *Client) ExportIdentity(address, passphrase, newPassphrase string) (contract.IdentityBackup, error) {
+	path := fmt.Sprintf("identities/%s/export", address)
+	response, err := client.http.Put(path, contract.IdentityExportRequest{
+		Passphrase:    &passphrase,
+		NewPassphrase: &newPassphrase,
+	})
+	if err != nil {
+		return contract.IdentityBackup{}, err
+	}
+	defer response.Body.Close()
+
+	res := contract.IdentityBackup{}
+	err = parseResponseJSON(response, &res)
+	return res, err
+}
+
+// ImportIdentity imports identity from the backup encrypted with passphrase and stores it with the new passphrase
+func (client *Client) ImportIdentity(backup contract.IdentityBackup, passphrase, newPassphrase string) (id contract.IdentityRefDTO, err error) {
+	response, err := client.http.Post("identities/import", contract.IdentityImportRequest{
+		Backup:        &backup,
+		Passphrase:    &passphrase,
+		NewPassphrase: &newPassphrase,
+	})
+	if err != nil {
+		return
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &id)
+	return id, err
+}
+
 // Identity returns identity status with current balance
 func (client *Client) Identity(identityAddress string) (contract.IdentityDTO, error) {
 	path := fmt.Sprintf("identities/%s", identityAddress)
@@ -192,6 +229,29 @@ func (client *Client) ConnectionCreate(consumerID, providerID, accountantID, ser
 	return status, err
 }
 
+// ConnectionCreateQuick connects to the best ranked provider matching the filter
+func (client *Client) ConnectionCreateQuick(consumerID, accountantID string, filter QuickConnectFilter, options ConnectOptions) (status QuickStatusDTO, err error) {
+	payload := struct {
+		QuickConnectFilter
+		Identity     string         `json:"consumer_id"`
+		AccountantID string         `json:"accountant_id"`
+		Options      ConnectOptions `json:"connect_options"`
+	}{
+		QuickConnectFilter: filter,
+		Identity:           consumerID,
+		AccountantID:       accountantID,
+		Options:            options,
+	}
+	response, err := client.http.Put("connection/quick", payload)
+	if err != nil {
+		return QuickStatusDTO{}, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &status)
+	return status, err
+}
+
 // ConnectionDestroy terminates current connection
 func (client *Client) ConnectionDestroy() (err error) {
 	response, err := client.http.Delete("connection", nil)
@@ -327,6 +387,35 @@ func (client *Client) Unlock(identity, passphrase string) error {
 	return nil
 }
 
+// Lock locks the given identity, it has to be unlocked again before use
+func (client *Client) Lock(identity string) error {
+	path := fmt.Sprintf("identities/%s/lock", identity)
+
+	response, err := client.http.Put(path, nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
+// ChangePassphrase re-encrypts the given identity with the new passphrase
+func (client *Client) ChangePassphrase(identity, passphrase, newPassphrase string) error {
+	path := fmt.Sprintf("identities/%s/passphrase", identity)
+
+	response, err := client.http.Put(path, contract.IdentityPassphraseChangeRequest{
+		Passphrase:    &passphrase,
+		NewPassphrase: &newPassphrase,
+	})
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
 // Payout registers payout address for identity
 func (client *Client) Payout(identity, ethAddress string) error {
 	path := fmt.Sprintf("identities/%s/payout", identity)
@@ -396,6 +485,18 @@ func (client *Client) Services() (services ServiceListDTO, err error) {
 	return services, err
 }
 
+// ServicesByProvider returns all running services grouped by provider identity
+func (client *Client) ServicesByProvider() (groups ServiceGroupListDTO, err error) {
+	response, err := client.http.Get("services", url.Values{"group_by": []string{"provider"}})
+	if err != nil {
+		return groups, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &groups)
+	return groups, err
+}
+
 // Service returns a service information by the requested id
 func (client *Client) Service(id string) (service ServiceInfoDTO, err error) {
 	response, err := client.http.Get("services/"+id, url.Values{})
@@ -428,6 +529,30 @@ func (client *Client) ServiceStart(providerID, serviceType string, options inter
 	}
 
 	response, err := client.http.Post("services", payload)
+	if response != nil && response.StatusCode == http.StatusConflict {
+		response.Body.Close()
+		return service, ErrServiceAlreadyRunning
+	}
+	if err != nil {
+		return service, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &service)
+	return service, err
+}
+
+// ServiceDrain unregisters the service and stops it once active sessions end or the timeout expires.
+// Zero timeout means the node's configured default.
+func (client *Client) ServiceDrain(id string, timeout time.Duration) (service ServiceInfoDTO, err error) {
+	payload := struct {
+		Timeout string `json:"timeout,omitempty"`
+	}{}
+	if timeout > 0 {
+		payload.Timeout = timeout.String()
+	}
+
+	response, err := client.http.Post(fmt.Sprintf("services/%s/drain", id), payload)
 	if err != nil {
 		return service, err
 	}
@@ -88,6 +88,28 @@ func TestConnectionErrorIsReturnedByClientInsteadOfDoubleParsing(t *testing.T) {
 	assert.True(t, responseBody.Closed)
 }
 
+func Test_ServiceStart_ReturnsAlreadyRunningOnConflict(t *testing.T) {
+	responseBody := &trackingCloser{
+		Reader: strings.NewReader(`{"message": "Service already running"}`),
+	}
+
+	client := Client{
+		http: &httpClient{
+			http: onAnyRequestReturn(&http.Response{
+				Status:     "Conflict",
+				StatusCode: http.StatusConflict,
+				Body:       responseBody,
+			}),
+			baseURL: "http://test-api-whatever",
+			ua:      "test-agent",
+		},
+	}
+
+	_, err := client.ServiceStart("0x1", "wireguard", nil, AccessPoliciesRequest{})
+	assert.Equal(t, ErrServiceAlreadyRunning, err)
+	assert.True(t, responseBody.Closed)
+}
+
 func mockHTTPClient(t *testing.T, method, url string, statusCode int, response string) httpClientInterface {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, method, r.Method)
@@ -38,6 +38,23 @@ type StatusDTO struct {
 	Proposal   ProposalDTO `json:"proposal"`
 }
 
+// QuickStatusDTO holds connection status of the automatically chosen provider
+type QuickStatusDTO struct {
+	StatusDTO
+	FailedProviders []string `json:"failed_providers"`
+}
+
+// QuickConnectFilter describes proposals to choose from when connecting automatically
+type QuickConnectFilter struct {
+	ServiceType         string  `json:"service_type,omitempty"`
+	LocationCountry     string  `json:"location_country,omitempty"`
+	LocationType        string  `json:"location_type,omitempty"`
+	UpperTimePriceBound *uint64 `json:"upper_time_price_bound,omitempty"`
+	UpperGBPriceBound   *uint64 `json:"upper_gb_price_bound,omitempty"`
+	FilteredDNS         bool    `json:"filtered_dns,omitempty"`
+	MaxCandidates       int     `json:"max_candidates,omitempty"`
+}
+
 // StatisticsDTO holds statistics about connection
 type StatisticsDTO struct {
 	BytesSent     uint64 `json:"bytes_sent"`
@@ -118,11 +135,21 @@ type RegistrationDataDTO struct {
 type ConnectOptions struct {
 	DisableKillSwitch bool                 `json:"kill_switch"`
 	DNS               connection.DNSOption `json:"dns"`
+	SplitTunnel       *SplitTunnelOptions  `json:"split_tunnel,omitempty"`
+}
+
+// SplitTunnelOptions copied from tequilapi endpoint
+type SplitTunnelOptions struct {
+	IncludedRoutes  []string `json:"included_routes,omitempty"`
+	ExcludedRoutes  []string `json:"excluded_routes,omitempty"`
+	IncludedDomains []string `json:"included_domains,omitempty"`
+	ExcludedDomains []string `json:"excluded_domains,omitempty"`
 }
 
 // ConnectionSessionListDTO copied from tequilapi endpoint
 type ConnectionSessionListDTO struct {
 	Sessions []ConnectionSessionDTO `json:"sessions"`
+	Total    int                    `json:"total"`
 }
 
 // ConnectionSessionDTO copied from tequilapi endpoint
@@ -136,11 +163,21 @@ type ConnectionSessionDTO struct {
 	BytesReceived   uint64 `json:"bytes_received"`
 	Duration        uint64 `json:"duration"`
 	Status          string `json:"status"`
+	TokensSpent     uint64 `json:"tokens_spent"`
 }
 
 // ServiceListDTO represents a list of running services on the node
 type ServiceListDTO []ServiceInfoDTO
 
+// ServiceGroupListDTO represents a list of running services on the node grouped by provider identity
+type ServiceGroupListDTO []ServiceGroupDTO
+
+// ServiceGroupDTO represents running services of a single provider identity
+type ServiceGroupDTO struct {
+	ProviderID string           `json:"provider_id"`
+	Services   []ServiceInfoDTO `json:"services"`
+}
+
 // ServiceInfoDTO represents running service information
 type ServiceInfoDTO struct {
 	ID          string          `json:"id"`
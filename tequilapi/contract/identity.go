@@ -18,6 +18,8 @@
 package contract
 
 import (
+	"encoding/json"
+
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/tequilapi/validation"
 )
@@ -83,6 +85,106 @@ func ValidateIdentityRequest(req IdentityRequest) (errors *validation.FieldError
 	return
 }
 
+// IdentityPassphraseChangeRequest request used for changing identity passphrase.
+// swagger:model IdentityPassphraseChangeRequestDTO
+type IdentityPassphraseChangeRequest struct {
+	// current passphrase of identity
+	// required: true
+	Passphrase *string `json:"passphrase"`
+
+	// passphrase to re-encrypt the identity with
+	// required: true
+	NewPassphrase *string `json:"new_passphrase"`
+}
+
+// ValidateIdentityPassphraseChangeRequest validates request.
+func ValidateIdentityPassphraseChangeRequest(req IdentityPassphraseChangeRequest) (errors *validation.FieldErrorMap) {
+	errors = validation.NewErrorMap()
+	if req.Passphrase == nil {
+		errors.ForField("passphrase").AddError("required", "Field is required")
+	}
+	if req.NewPassphrase == nil {
+		errors.ForField("new_passphrase").AddError("required", "Field is required")
+	}
+	return
+}
+
+// IdentityExportRequest request used for identity export.
+// swagger:model IdentityExportRequestDTO
+type IdentityExportRequest struct {
+	// current passphrase of identity
+	// required: true
+	Passphrase *string `json:"passphrase"`
+
+	// passphrase to encrypt the exported keystore with
+	// required: true
+	NewPassphrase *string `json:"new_passphrase"`
+}
+
+// ValidateIdentityExportRequest validates request.
+func ValidateIdentityExportRequest(req IdentityExportRequest) (errors *validation.FieldErrorMap) {
+	errors = validation.NewErrorMap()
+	if req.Passphrase == nil {
+		errors.ForField("passphrase").AddError("required", "Field is required")
+	}
+	if req.NewPassphrase == nil || *req.NewPassphrase == "" {
+		errors.ForField("new_passphrase").AddError("required", "Field is required")
+	}
+	return
+}
+
+// IdentityBackup holds everything needed to restore the identity on another node.
+// swagger:model IdentityBackupDTO
+type IdentityBackup struct {
+	// identity in Ethereum address format
+	// required: true
+	// example: 0x0000000000000000000000000000000000000001
+	Address string `json:"id"`
+
+	// keystore JSON of the identity encrypted with the export passphrase
+	// required: true
+	Keystore json.RawMessage `json:"keystore"`
+
+	// example: RegisteredProvider
+	RegistrationStatus string `json:"registration_status"`
+
+	// example: 0x0000000000000000000000000000000000000002
+	ChannelAddress string `json:"channel_address"`
+}
+
+// IdentityImportRequest request used for identity import.
+// swagger:model IdentityImportRequestDTO
+type IdentityImportRequest struct {
+	// required: true
+	Backup *IdentityBackup `json:"backup"`
+
+	// passphrase the backup was exported with
+	// required: true
+	Passphrase *string `json:"passphrase"`
+
+	// passphrase to store the identity with, export passphrase is used if not set
+	NewPassphrase *string `json:"new_passphrase"`
+}
+
+// ValidateIdentityImportRequest validates request.
+func ValidateIdentityImportRequest(req IdentityImportRequest) (errors *validation.FieldErrorMap) {
+	errors = validation.NewErrorMap()
+	if req.Backup == nil {
+		errors.ForField("backup").AddError("required", "Field is required")
+	} else {
+		if req.Backup.Address == "" {
+			errors.ForField("backup.id").AddError("required", "Field is required")
+		}
+		if len(req.Backup.Keystore) == 0 {
+			errors.ForField("backup.keystore").AddError("required", "Field is required")
+		}
+	}
+	if req.Passphrase == nil {
+		errors.ForField("passphrase").AddError("required", "Field is required")
+	}
+	return
+}
+
 // IdentityRegistrationResponse represents registration status and needed data for registering of given identity
 // swagger:model RegistrationDataDTO
 type IdentityRegistrationResponse struct {
@@ -0,0 +1,246 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/core/policy"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+	"github.com/mysteriumnetwork/node/tequilapi/validation"
+)
+
+// swagger:model LocalAccessPolicyRequestDTO
+type localAccessPolicyRequest struct {
+	// example: Office
+	Title string `json:"title"`
+
+	// example: Office network only
+	Description string `json:"description"`
+
+	// rules of types "identity", "dns_hostname", "dns_zone", "ip_cidr", "port" and "blocked_port"
+	// required: true
+	Allow []accessRule `json:"allow"`
+}
+
+type localAccessPolicies interface {
+	RuleSets() []market.AccessPolicyRuleSet
+	RuleSet(policyID string) (market.AccessPolicyRuleSet, error)
+	Save(ruleSet market.AccessPolicyRuleSet) error
+	Delete(policyID string) error
+}
+
+type localAccessPoliciesEndpoint struct {
+	policies localAccessPolicies
+}
+
+// NewLocalAccessPoliciesEndpoint creates and returns local access policies endpoint
+func NewLocalAccessPoliciesEndpoint(policies localAccessPolicies) *localAccessPoliciesEndpoint {
+	return &localAccessPoliciesEndpoint{policies: policies}
+}
+
+// List provides locally defined access policies.
+// swagger:operation GET /access-policies/local AccessPolicies listLocalAccessPolicies
+// ---
+// summary: Returns local access policies
+// description: Returns access policies defined in the local policy file
+// responses:
+//   200:
+//     description: List of local access policies
+//     schema:
+//       "$ref": "#/definitions/AccessPolicies"
+func (endpoint *localAccessPoliciesEndpoint) List(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	ruleSets := endpoint.policies.RuleSets()
+
+	res := accessPolicyCollection{Entries: make([]accessPolicy, 0, len(ruleSets))}
+	for _, ruleSet := range ruleSets {
+		res.Entries = append(res.Entries, toAccessPolicyResponse(ruleSet))
+	}
+	utils.WriteAsJSON(res, resp)
+}
+
+// Get provides a single local access policy.
+// swagger:operation GET /access-policies/local/{id} AccessPolicies getLocalAccessPolicy
+// ---
+// summary: Returns local access policy
+// description: Returns local access policy by its ID
+// parameters:
+//   - name: id
+//     in: path
+//     description: Policy ID
+//     type: string
+//     required: true
+// responses:
+//   200:
+//     description: Local access policy
+//   404:
+//     description: Policy not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *localAccessPoliciesEndpoint) Get(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	ruleSet, err := endpoint.policies.RuleSet(params.ByName("id"))
+	if err == policy.ErrLocalPolicyNotFound {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	} else if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteAsJSON(toAccessPolicyResponse(ruleSet), resp)
+}
+
+// Put creates or replaces a local access policy.
+// swagger:operation PUT /access-policies/local/{id} AccessPolicies putLocalAccessPolicy
+// ---
+// summary: Creates or replaces local access policy
+// description: Writes the policy to the local policy file. Running services using the policy get the new rules.
+// parameters:
+//   - name: id
+//     in: path
+//     description: Policy ID
+//     type: string
+//     required: true
+//   - in: body
+//     name: body
+//     description: Policy rules
+//     schema:
+//       $ref: "#/definitions/LocalAccessPolicyRequestDTO"
+// responses:
+//   200:
+//     description: Stored local access policy
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *localAccessPoliciesEndpoint) Put(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	var pr localAccessPolicyRequest
+	decoder := json.NewDecoder(req.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&pr); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	ruleSet := market.AccessPolicyRuleSet{
+		ID:          params.ByName("id"),
+		Title:       pr.Title,
+		Description: pr.Description,
+		Allow:       make([]market.AccessRule, 0, len(pr.Allow)),
+	}
+	for _, rule := range pr.Allow {
+		ruleSet.Allow = append(ruleSet.Allow, market.AccessRule{Type: rule.Type, Value: rule.Value})
+	}
+
+	if errorMap := validateLocalAccessPolicy(ruleSet); errorMap.HasErrors() {
+		utils.SendValidationErrorMessage(resp, errorMap)
+		return
+	}
+
+	if err := endpoint.policies.Save(ruleSet); err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteAsJSON(toAccessPolicyResponse(ruleSet), resp)
+}
+
+// Delete removes a local access policy.
+// swagger:operation DELETE /access-policies/local/{id} AccessPolicies deleteLocalAccessPolicy
+// ---
+// summary: Removes local access policy
+// description: Removes the policy from the local policy file. Running services using the policy keep its last rules.
+// parameters:
+//   - name: id
+//     in: path
+//     description: Policy ID
+//     type: string
+//     required: true
+// responses:
+//   202:
+//     description: Policy removed
+//   404:
+//     description: Policy not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *localAccessPoliciesEndpoint) Delete(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	err := endpoint.policies.Delete(params.ByName("id"))
+	if err == policy.ErrLocalPolicyNotFound {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	} else if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp.WriteHeader(http.StatusAccepted)
+}
+
+func validateLocalAccessPolicy(ruleSet market.AccessPolicyRuleSet) *validation.FieldErrorMap {
+	errors := validation.NewErrorMap()
+	if err := policy.ValidateRuleSet(market.AccessPolicyRuleSet{ID: ruleSet.ID}); err != nil {
+		errors.ForField("id").AddError("invalid", err.Error())
+	}
+	if len(ruleSet.Allow) == 0 {
+		errors.ForField("allow").AddError("required", "Field is required")
+	}
+	for _, rule := range ruleSet.Allow {
+		if err := policy.ValidateRule(rule); err != nil {
+			errors.ForField("allow").AddError("invalid", err.Error())
+		}
+	}
+	return errors
+}
+
+func toAccessPolicyResponse(ruleSet market.AccessPolicyRuleSet) accessPolicy {
+	res := accessPolicy{
+		ID:          ruleSet.ID,
+		Title:       ruleSet.Title,
+		Description: ruleSet.Description,
+		Allow:       make([]accessRule, 0, len(ruleSet.Allow)),
+	}
+	for _, rule := range ruleSet.Allow {
+		res.Allow = append(res.Allow, accessRule{Type: rule.Type, Value: rule.Value})
+	}
+	return res
+}
+
+// AddRoutesForLocalAccessPolicies attaches local access policies endpoints to router
+func AddRoutesForLocalAccessPolicies(router *httprouter.Router, policies localAccessPolicies) {
+	endpoint := NewLocalAccessPoliciesEndpoint(policies)
+
+	router.GET("/access-policies/local", endpoint.List)
+	router.GET("/access-policies/local/:id", endpoint.Get)
+	router.PUT("/access-policies/local/:id", endpoint.Put)
+	router.DELETE("/access-policies/local/:id", endpoint.Delete)
+}
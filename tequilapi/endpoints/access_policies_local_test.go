@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/core/policy"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockLocalAccessPolicies struct {
+	ruleSets []market.AccessPolicyRuleSet
+}
+
+func (m *mockLocalAccessPolicies) RuleSets() []market.AccessPolicyRuleSet {
+	return m.ruleSets
+}
+
+func (m *mockLocalAccessPolicies) RuleSet(policyID string) (market.AccessPolicyRuleSet, error) {
+	for _, ruleSet := range m.ruleSets {
+		if ruleSet.ID == policyID {
+			return ruleSet, nil
+		}
+	}
+	return market.AccessPolicyRuleSet{}, policy.ErrLocalPolicyNotFound
+}
+
+func (m *mockLocalAccessPolicies) Save(ruleSet market.AccessPolicyRuleSet) error {
+	m.ruleSets = append(m.ruleSets, ruleSet)
+	return nil
+}
+
+func (m *mockLocalAccessPolicies) Delete(policyID string) error {
+	for i := range m.ruleSets {
+		if m.ruleSets[i].ID == policyID {
+			m.ruleSets = append(m.ruleSets[:i], m.ruleSets[i+1:]...)
+			return nil
+		}
+	}
+	return policy.ErrLocalPolicyNotFound
+}
+
+func Test_LocalAccessPoliciesEndpoints(t *testing.T) {
+	policies := &mockLocalAccessPolicies{}
+	router := httprouter.New()
+	AddRoutesForLocalAccessPolicies(router, policies)
+
+	req := httptest.NewRequest(http.MethodPut, "/access-policies/local/office", strings.NewReader(`{"title": "Office", "allow": [{"type": "ip_cidr", "value": "10.0.0.0/8"}]}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"id": "office", "title": "Office", "description": "", "allow": [{"type": "ip_cidr", "value": "10.0.0.0/8"}]}`, resp.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/access-policies/local", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"entries": [{"id": "office", "title": "Office", "description": "", "allow": [{"type": "ip_cidr", "value": "10.0.0.0/8"}]}]}`, resp.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/access-policies/local/office", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/access-policies/local/office", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Empty(t, policies.ruleSets)
+
+	req = httptest.NewRequest(http.MethodGet, "/access-policies/local/office", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/access-policies/local/office", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func Test_LocalAccessPoliciesEndpoints_ValidatesRules(t *testing.T) {
+	policies := &mockLocalAccessPolicies{}
+	router := httprouter.New()
+	AddRoutesForLocalAccessPolicies(router, policies)
+
+	req := httptest.NewRequest(http.MethodPut, "/access-policies/local/office", strings.NewReader(`{"allow": [{"type": "port", "value": "99999"}]}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+	req = httptest.NewRequest(http.MethodPut, "/access-policies/local/office", strings.NewReader(`{"allow": []}`))
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.Empty(t, policies.ruleSets)
+}
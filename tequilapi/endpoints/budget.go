@@ -0,0 +1,216 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/consumer/budget"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+	"github.com/mysteriumnetwork/node/tequilapi/validation"
+)
+
+// swagger:model BudgetCapsDTO
+type budgetCaps struct {
+	// maximum amount of tokens spent per session, 0 means no limit
+	// example: 1000000
+	Session uint64 `json:"session"`
+
+	// maximum amount of tokens spent per calendar day in UTC, 0 means no limit
+	// example: 5000000
+	Day uint64 `json:"day"`
+
+	// maximum amount of tokens spent per calendar month in UTC, 0 means no limit
+	// example: 50000000
+	Month uint64 `json:"month"`
+
+	// percentages of the cap at which warnings are published
+	// example: [80, 90]
+	WarningThresholds []int `json:"warning_thresholds"`
+}
+
+// swagger:model BudgetSpendingDTO
+type budgetSpending struct {
+	// tokens spent in the active session
+	// example: 120000
+	Session uint64 `json:"session"`
+
+	// tokens spent today
+	// example: 450000
+	Day uint64 `json:"day"`
+
+	// tokens spent this month
+	// example: 3200000
+	Month uint64 `json:"month"`
+}
+
+type budgetTracker interface {
+	Caps() (budget.Caps, error)
+	SetCaps(caps budget.Caps) error
+	Spending(consumerID identity.Identity) (budget.Spending, error)
+}
+
+type budgetEndpoint struct {
+	tracker budgetTracker
+}
+
+// NewBudgetEndpoint creates and returns consumer budget endpoint
+func NewBudgetEndpoint(tracker budgetTracker) *budgetEndpoint {
+	return &budgetEndpoint{tracker: tracker}
+}
+
+// Caps provides the consumer spending caps.
+// swagger:operation GET /budget Budget getBudgetCaps
+// ---
+// summary: Returns spending caps
+// description: Returns session, daily and monthly spending caps of the consumer
+// responses:
+//   200:
+//     description: Spending caps
+//     schema:
+//       "$ref": "#/definitions/BudgetCapsDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *budgetEndpoint) Caps(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	caps, err := endpoint.tracker.Caps()
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteAsJSON(budgetCaps{
+		Session:           caps.Session,
+		Day:               caps.Day,
+		Month:             caps.Month,
+		WarningThresholds: caps.WarningThresholds,
+	}, resp)
+}
+
+// SetCaps updates the consumer spending caps.
+// swagger:operation PUT /budget Budget setBudgetCaps
+// ---
+// summary: Sets spending caps
+// description: Sets session, daily and monthly spending caps of the consumer. Active session is disconnected once it reaches any of them.
+// parameters:
+//   - in: body
+//     name: body
+//     description: Spending caps
+//     schema:
+//       $ref: "#/definitions/BudgetCapsDTO"
+// responses:
+//   200:
+//     description: Stored spending caps
+//     schema:
+//       "$ref": "#/definitions/BudgetCapsDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *budgetEndpoint) SetCaps(resp http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var cr budgetCaps
+	decoder := json.NewDecoder(req.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cr); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	caps := budget.Caps{
+		Session:           cr.Session,
+		Day:               cr.Day,
+		Month:             cr.Month,
+		WarningThresholds: cr.WarningThresholds,
+	}
+	if caps.WarningThresholds == nil {
+		caps.WarningThresholds = budget.DefaultWarningThresholds
+	}
+	if err := caps.Validate(); err != nil {
+		errors := validation.NewErrorMap()
+		errors.ForField("warning_thresholds").AddError("invalid", err.Error())
+		utils.SendValidationErrorMessage(resp, errors)
+		return
+	}
+
+	if err := endpoint.tracker.SetCaps(caps); err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteAsJSON(budgetCaps{
+		Session:           caps.Session,
+		Day:               caps.Day,
+		Month:             caps.Month,
+		WarningThresholds: caps.WarningThresholds,
+	}, resp)
+}
+
+// Spending provides the tokens spent by the consumer.
+// swagger:operation GET /budget/spending/{id} Budget getBudgetSpending
+// ---
+// summary: Returns spending
+// description: Returns tokens spent by the consumer identity in the active session, today and this month
+// parameters:
+//   - name: id
+//     in: path
+//     description: Consumer identity
+//     type: string
+//     required: true
+// responses:
+//   200:
+//     description: Spending of the consumer
+//     schema:
+//       "$ref": "#/definitions/BudgetSpendingDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *budgetEndpoint) Spending(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	spending, err := endpoint.tracker.Spending(identity.FromAddress(params.ByName("id")))
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteAsJSON(budgetSpending{
+		Session: spending.Session,
+		Day:     spending.Day,
+		Month:   spending.Month,
+	}, resp)
+}
+
+// AddRoutesForBudget adds consumer budget routes to given router
+func AddRoutesForBudget(router *httprouter.Router, tracker budgetTracker) {
+	endpoint := NewBudgetEndpoint(tracker)
+
+	router.GET("/budget", endpoint.Caps)
+	router.PUT("/budget", endpoint.SetCaps)
+	router.GET("/budget/spending/:id", endpoint.Spending)
+}
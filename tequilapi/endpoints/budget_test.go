@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/consumer/budget"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBudgetTracker struct {
+	caps     budget.Caps
+	spending map[identity.Identity]budget.Spending
+}
+
+func (m *mockBudgetTracker) Caps() (budget.Caps, error) {
+	return m.caps, nil
+}
+
+func (m *mockBudgetTracker) SetCaps(caps budget.Caps) error {
+	m.caps = caps
+	return nil
+}
+
+func (m *mockBudgetTracker) Spending(consumerID identity.Identity) (budget.Spending, error) {
+	return m.spending[consumerID], nil
+}
+
+func Test_BudgetEndpoints(t *testing.T) {
+	tracker := &mockBudgetTracker{
+		caps: budget.Caps{WarningThresholds: budget.DefaultWarningThresholds},
+		spending: map[identity.Identity]budget.Spending{
+			identity.FromAddress("0x000000000000000000000000000000000000000a"): {Session: 10, Day: 20, Month: 30},
+		},
+	}
+	router := httprouter.New()
+	AddRoutesForBudget(router, tracker)
+
+	req := httptest.NewRequest(http.MethodGet, "/budget", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"session": 0, "day": 0, "month": 0, "warning_thresholds": [80, 90]}`, resp.Body.String())
+
+	req = httptest.NewRequest(http.MethodPut, "/budget", strings.NewReader(`{"session": 100, "day": 1000}`))
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"session": 100, "day": 1000, "month": 0, "warning_thresholds": [80, 90]}`, resp.Body.String())
+	assert.Equal(t, budget.Caps{Session: 100, Day: 1000, WarningThresholds: []int{80, 90}}, tracker.caps)
+
+	req = httptest.NewRequest(http.MethodPut, "/budget", strings.NewReader(`{"month": 5000, "warning_thresholds": [50]}`))
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, budget.Caps{Month: 5000, WarningThresholds: []int{50}}, tracker.caps)
+
+	req = httptest.NewRequest(http.MethodGet, "/budget/spending/0x000000000000000000000000000000000000000A", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"session": 10, "day": 20, "month": 30}`, resp.Body.String())
+}
+
+func Test_BudgetEndpoints_Validation(t *testing.T) {
+	tracker := &mockBudgetTracker{}
+	router := httprouter.New()
+	AddRoutesForBudget(router, tracker)
+
+	req := httptest.NewRequest(http.MethodPut, "/budget", strings.NewReader(`{"session": 100, "warning_thresholds": [120]}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+	req = httptest.NewRequest(http.MethodPut, "/budget", strings.NewReader(`{"weekly": 100}`))
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	assert.Equal(t, budget.Caps{}, tracker.caps)
+}
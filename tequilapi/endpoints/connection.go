@@ -26,6 +26,7 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/core/connection"
 	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/core/quickconnect"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/identity/registry"
 	"github.com/mysteriumnetwork/node/market"
@@ -50,8 +51,90 @@ type ConnectOptions struct {
 	// DNS to use
 	// required: false
 	// default: auto
-	// example: auto, provider, system, "1.1.1.1,8.8.8.8"
+	// example: auto, provider, system, doh, dot, "1.1.1.1,8.8.8.8", "https://1.1.1.1/dns-query,tls://8.8.8.8"
 	DNS connection.DNSOption `json:"dns"`
+	// automatic reconnect options
+	// required: false
+	Reconnect *ReconnectOptions `json:"reconnect,omitempty"`
+	// split tunnelling options
+	// required: false
+	SplitTunnel *SplitTunnelOptions `json:"split_tunnel,omitempty"`
+}
+
+// SplitTunnelOptions holds tequilapi split tunnelling options.
+// If anything is included only the included traffic is routed through VPN,
+// otherwise all traffic except the excluded one is routed through VPN.
+// swagger:model SplitTunnelOptionsDTO
+type SplitTunnelOptions struct {
+	// IPv4 networks in CIDR notation or single IPv4 addresses routed through VPN
+	// required: false
+	// example: ["10.0.0.0/8", "1.1.1.1"]
+	IncludedRoutes []string `json:"included_routes,omitempty"`
+	// IPv4 networks in CIDR notation or single IPv4 addresses routed outside of VPN
+	// required: false
+	// example: ["192.168.0.0/16"]
+	ExcludedRoutes []string `json:"excluded_routes,omitempty"`
+	// domains routed through VPN
+	// required: false
+	// example: ["example.com"]
+	IncludedDomains []string `json:"included_domains,omitempty"`
+	// domains routed outside of VPN
+	// required: false
+	// example: ["netflix.com"]
+	ExcludedDomains []string `json:"excluded_domains,omitempty"`
+}
+
+// ReconnectOptions holds tequilapi automatic reconnect options
+// swagger:model ReconnectOptionsDTO
+type ReconnectOptions struct {
+	// reconnect when connection drops
+	// required: true
+	// example: true
+	Enabled bool `json:"enabled"`
+	// number of attempts to reconnect to the same provider
+	// required: false
+	// default: 3
+	// example: 3
+	MaxAttempts int `json:"max_attempts,omitempty"`
+	// delay in seconds before the first attempt, it is doubled after every failed attempt
+	// required: false
+	// default: 2
+	// example: 2
+	BackoffSeconds int `json:"backoff_seconds,omitempty"`
+	// max delay in seconds between attempts
+	// required: false
+	// default: 30
+	// example: 30
+	MaxBackoffSeconds int `json:"max_backoff_seconds,omitempty"`
+	// connect to other proposals once attempts to the same provider are exhausted
+	// required: false
+	// example: true
+	Failover bool `json:"failover"`
+	// max number of other proposals to try
+	// required: false
+	// default: 5
+	// example: 5
+	MaxFailoverProposals int `json:"max_failover_proposals,omitempty"`
+	// filter of proposals to fail over to, proposals of the same service type are used if not set
+	// required: false
+	FailoverFilter *FailoverFilter `json:"failover_filter,omitempty"`
+}
+
+// FailoverFilter holds tequilapi filter of proposals to fail over to
+// swagger:model FailoverFilterDTO
+type FailoverFilter struct {
+	// example: wireguard
+	ServiceType string `json:"service_type,omitempty"`
+	// example: residential
+	LocationType string `json:"location_type,omitempty"`
+	// example: mysterium
+	AccessPolicyID string `json:"access_policy_id,omitempty"`
+	// example: https://trust-oracle.mysterium.network/api/v1/lists/mysterium
+	AccessPolicySource string `json:"access_policy_source,omitempty"`
+	// example: 50000
+	UpperTimePriceBound *uint64 `json:"upper_time_price_bound,omitempty"`
+	// example: 7000000
+	UpperGBPriceBound *uint64 `json:"upper_gb_price_bound,omitempty"`
 }
 
 // swagger:model ConnectionRequestDTO
@@ -82,6 +165,68 @@ type connectionRequest struct {
 	ConnectOptions ConnectOptions `json:"connect_options,omitempty"`
 }
 
+// swagger:model QuickConnectionRequestDTO
+type quickConnectionRequest struct {
+	// consumer identity
+	// required: true
+	// example: 0x0000000000000000000000000000000000000001
+	ConsumerID string `json:"consumer_id"`
+
+	// accountant identity
+	// required: true
+	// example: 0x0000000000000000000000000000000000000003
+	AccountantID string `json:"accountant_id"`
+
+	// service type. Possible values are "openvpn", "wireguard" and "noop", any service type is used if not set
+	// required: false
+	// example: wireguard
+	ServiceType string `json:"service_type,omitempty"`
+
+	// provider country code
+	// required: false
+	// example: DE
+	LocationCountry string `json:"location_country,omitempty"`
+
+	// provider node type
+	// required: false
+	// example: residential
+	LocationType string `json:"location_type,omitempty"`
+
+	// max price per minute
+	// required: false
+	// example: 50000
+	UpperTimePriceBound *uint64 `json:"upper_time_price_bound,omitempty"`
+
+	// max price per GiB
+	// required: false
+	// example: 7000000
+	UpperGBPriceBound *uint64 `json:"upper_gb_price_bound,omitempty"`
+
+	// only providers blocking ad, tracker and malware domains on their DNS
+	// required: false
+	// example: true
+	FilteredDNS bool `json:"filtered_dns,omitempty"`
+
+	// max number of top ranked proposals to try
+	// required: false
+	// default: 5
+	// example: 5
+	MaxCandidates int `json:"max_candidates,omitempty"`
+
+	// connect options
+	// required: false
+	ConnectOptions ConnectOptions `json:"connect_options,omitempty"`
+}
+
+// swagger:model QuickConnectionStatusDTO
+type quickConnectionResponse struct {
+	connectionResponse
+
+	// providers which were tried before the chosen one but failed to connect
+	// example: ["0x0000000000000000000000000000000000000002"]
+	FailedProviders []string `json:"failed_providers"`
+}
+
 // swagger:model ConnectionStatusDTO
 type connectionResponse struct {
 	// example: 0x00
@@ -136,6 +281,11 @@ type identityRegistry interface {
 	GetRegistrationStatus(identity.Identity) (registry.RegistrationStatus, error)
 }
 
+// QuickConnector connects to the best ranked proposal matching the request
+type QuickConnector interface {
+	Connect(req quickconnect.Request) (quickconnect.Result, error)
+}
+
 // ConnectionEndpoint struct represents /connection resource and it's subresources
 type ConnectionEndpoint struct {
 	manager           connection.Manager
@@ -143,15 +293,17 @@ type ConnectionEndpoint struct {
 	//TODO connection should use concrete proposal from connection params and avoid going to marketplace
 	proposalRepository proposal.Repository
 	identityRegistry   identityRegistry
+	quickConnector     QuickConnector
 }
 
 // NewConnectionEndpoint creates and returns connection endpoint
-func NewConnectionEndpoint(manager connection.Manager, statsKeeper SessionStatisticsTracker, proposalRepository proposal.Repository, identityRegistry identityRegistry) *ConnectionEndpoint {
+func NewConnectionEndpoint(manager connection.Manager, statsKeeper SessionStatisticsTracker, proposalRepository proposal.Repository, identityRegistry identityRegistry, quickConnector QuickConnector) *ConnectionEndpoint {
 	return &ConnectionEndpoint{
 		manager:            manager,
 		statisticsTracker:  statsKeeper,
 		proposalRepository: proposalRepository,
 		identityRegistry:   identityRegistry,
+		quickConnector:     quickConnector,
 	}
 }
 
@@ -217,22 +369,10 @@ func (ce *ConnectionEndpoint) Create(resp http.ResponseWriter, req *http.Request
 		return
 	}
 
-	status, err := ce.identityRegistry.GetRegistrationStatus(identity.FromAddress(cr.ConsumerID))
-	if err != nil {
-		log.Error().Err(err).Stack().Msg("could not check registration status")
-		utils.SendError(resp, err, http.StatusInternalServerError)
-		return
-	}
-
-	switch status {
-	case registry.Unregistered, registry.InProgress, registry.RegistrationError:
-		log.Warn().Msgf("identity %q is not registered, aborting...", cr.ConsumerID)
-		utils.SendError(resp, fmt.Errorf("identity %q is not registered. Please register the identity first", cr.ConsumerID), http.StatusExpectationFailed)
+	if !ce.checkRegistration(resp, cr.ConsumerID) {
 		return
 	}
 
-	log.Info().Msgf("identity %q is registered, continuing...", cr.ConsumerID)
-
 	errorMap := validateConnectionRequest(cr)
 	if errorMap.HasErrors() {
 		utils.SendValidationErrorMessage(resp, errorMap)
@@ -253,25 +393,132 @@ func (ce *ConnectionEndpoint) Create(resp http.ResponseWriter, req *http.Request
 		return
 	}
 
-	connectOptions := getConnectOptions(cr)
+	connectOptions := getConnectOptions(cr.ConnectOptions)
 	err = ce.manager.Connect(identity.FromAddress(cr.ConsumerID), identity.FromAddress(cr.AccountantID), *proposal, connectOptions)
 
 	if err != nil {
-		switch err {
-		case connection.ErrAlreadyExists:
-			utils.SendError(resp, err, http.StatusConflict)
-		case connection.ErrConnectionCancelled:
-			utils.SendError(resp, err, statusConnectCancelled)
-		default:
-			log.Error().Err(err).Msg("")
-			utils.SendError(resp, err, http.StatusInternalServerError)
-		}
+		sendConnectError(resp, err)
 		return
 	}
 	resp.WriteHeader(http.StatusCreated)
 	ce.Status(resp, req, params)
 }
 
+// QuickCreate starts new connection to the best ranked proposal
+// swagger:operation PUT /connection/quick Connection connectionQuickCreate
+// ---
+// summary: Starts new connection to automatically selected provider
+// description: Consumer opens connection to the best ranked provider matching the filter, next candidates are tried if connection fails
+// parameters:
+//   - in: body
+//     name: body
+//     description: Parameters in body (consumer_id, accountant_id and optional filter) required for creating new connection
+//     schema:
+//       $ref: "#/definitions/QuickConnectionRequestDTO"
+// responses:
+//   201:
+//     description: Connection started
+//     schema:
+//       "$ref": "#/definitions/QuickConnectionStatusDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   404:
+//     description: No proposals matching the filter
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   409:
+//     description: Conflict. Connection already exists
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   499:
+//     description: Connection was cancelled
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (ce *ConnectionEndpoint) QuickCreate(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	qcr := &quickConnectionRequest{}
+	if err := json.NewDecoder(req.Body).Decode(qcr); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	if !ce.checkRegistration(resp, qcr.ConsumerID) {
+		return
+	}
+
+	errorMap := validateQuickConnectionRequest(qcr)
+	if errorMap.HasErrors() {
+		utils.SendValidationErrorMessage(resp, errorMap)
+		return
+	}
+
+	result, err := ce.quickConnector.Connect(quickconnect.Request{
+		ConsumerID:    identity.FromAddress(qcr.ConsumerID),
+		AccountantID:  identity.FromAddress(qcr.AccountantID),
+		Filter:        toQuickConnectFilter(qcr),
+		Params:        getConnectOptions(qcr.ConnectOptions),
+		MaxCandidates: qcr.MaxCandidates,
+	})
+	if err != nil {
+		if err == quickconnect.ErrNoProposals {
+			utils.SendError(resp, err, http.StatusNotFound)
+			return
+		}
+		sendConnectError(resp, err)
+		return
+	}
+
+	res := quickConnectionResponse{
+		connectionResponse: toConnectionResponse(ce.manager.Status()),
+		FailedProviders:    []string{},
+	}
+	for _, id := range result.Failed {
+		res.FailedProviders = append(res.FailedProviders, id.ProviderID)
+	}
+	resp.WriteHeader(http.StatusCreated)
+	utils.WriteAsJSON(res, resp)
+}
+
+func (ce *ConnectionEndpoint) checkRegistration(resp http.ResponseWriter, consumerID string) bool {
+	status, err := ce.identityRegistry.GetRegistrationStatus(identity.FromAddress(consumerID))
+	if err != nil {
+		log.Error().Err(err).Stack().Msg("could not check registration status")
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return false
+	}
+
+	switch status {
+	case registry.Unregistered, registry.InProgress, registry.RegistrationError:
+		log.Warn().Msgf("identity %q is not registered, aborting...", consumerID)
+		utils.SendError(resp, fmt.Errorf("identity %q is not registered. Please register the identity first", consumerID), http.StatusExpectationFailed)
+		return false
+	}
+
+	log.Info().Msgf("identity %q is registered, continuing...", consumerID)
+	return true
+}
+
+func sendConnectError(resp http.ResponseWriter, err error) {
+	switch errors.Cause(err) {
+	case connection.ErrAlreadyExists:
+		utils.SendError(resp, err, http.StatusConflict)
+	case connection.ErrConnectionCancelled:
+		utils.SendError(resp, err, statusConnectCancelled)
+	default:
+		log.Error().Err(err).Msg("")
+		utils.SendError(resp, err, http.StatusInternalServerError)
+	}
+}
+
 // Kill stops connection
 // swagger:operation DELETE /connection Connection connectionCancel
 // ---
@@ -330,10 +577,12 @@ func (ce *ConnectionEndpoint) GetStatistics(writer http.ResponseWriter, request
 
 // AddRoutesForConnection adds connections routes to given router
 func AddRoutesForConnection(router *httprouter.Router, manager connection.Manager,
-	statsKeeper SessionStatisticsTracker, proposalRepository proposal.Repository, identityRegistry identityRegistry) {
-	connectionEndpoint := NewConnectionEndpoint(manager, statsKeeper, proposalRepository, identityRegistry)
+	statsKeeper SessionStatisticsTracker, proposalRepository proposal.Repository, identityRegistry identityRegistry,
+	quickConnector QuickConnector) {
+	connectionEndpoint := NewConnectionEndpoint(manager, statsKeeper, proposalRepository, identityRegistry, quickConnector)
 	router.GET("/connection", connectionEndpoint.Status)
 	router.PUT("/connection", connectionEndpoint.Create)
+	router.PUT("/connection/quick", connectionEndpoint.QuickCreate)
 	router.DELETE("/connection", connectionEndpoint.Kill)
 	router.GET("/connection/statistics", connectionEndpoint.GetStatistics)
 }
@@ -351,15 +600,90 @@ func toConnectionRequest(req *http.Request) (*connectionRequest, error) {
 	return &connectionRequest, nil
 }
 
-func getConnectOptions(cr *connectionRequest) connection.ConnectParams {
+func getConnectOptions(opts ConnectOptions) connection.ConnectParams {
 	dns := connection.DNSOptionAuto
-	if cr.ConnectOptions.DNS != "" {
-		dns = cr.ConnectOptions.DNS
+	if opts.DNS != "" {
+		dns = opts.DNS
 	}
 	return connection.ConnectParams{
-		DisableKillSwitch: cr.ConnectOptions.DisableKillSwitch,
+		DisableKillSwitch: opts.DisableKillSwitch,
 		DNS:               dns,
+		Reconnect:         getReconnectPolicy(opts.Reconnect),
+		SplitTunnel:       getSplitTunnel(opts.SplitTunnel),
+	}
+}
+
+func getSplitTunnel(opts *SplitTunnelOptions) connection.SplitTunnel {
+	if opts == nil {
+		return connection.SplitTunnel{}
+	}
+	return connection.SplitTunnel{
+		IncludedRoutes:  opts.IncludedRoutes,
+		ExcludedRoutes:  opts.ExcludedRoutes,
+		IncludedDomains: opts.IncludedDomains,
+		ExcludedDomains: opts.ExcludedDomains,
+	}
+}
+
+func toQuickConnectFilter(qcr *quickConnectionRequest) *proposal.Filter {
+	var lowerBound uint64
+	filter := &proposal.Filter{
+		ServiceType:        qcr.ServiceType,
+		LocationCountry:    qcr.LocationCountry,
+		LocationType:       qcr.LocationType,
+		FilteredDNS:        qcr.FilteredDNS,
+		ExcludeUnsupported: true,
+	}
+	if qcr.UpperTimePriceBound != nil {
+		filter.LowerTimePriceBound = &lowerBound
+		filter.UpperTimePriceBound = qcr.UpperTimePriceBound
+	}
+	if qcr.UpperGBPriceBound != nil {
+		filter.LowerGBPriceBound = &lowerBound
+		filter.UpperGBPriceBound = qcr.UpperGBPriceBound
+	}
+	return filter
+}
+
+func getReconnectPolicy(opts *ReconnectOptions) connection.ReconnectPolicy {
+	if opts == nil || !opts.Enabled {
+		return connection.ReconnectPolicy{}
+	}
+
+	policy := connection.DefaultReconnectPolicy()
+	policy.Failover = opts.Failover
+	if opts.MaxAttempts > 0 {
+		policy.MaxAttempts = opts.MaxAttempts
+	}
+	if opts.BackoffSeconds > 0 {
+		policy.InitialBackoff = time.Duration(opts.BackoffSeconds) * time.Second
+	}
+	if opts.MaxBackoffSeconds > 0 {
+		policy.MaxBackoff = time.Duration(opts.MaxBackoffSeconds) * time.Second
+	}
+	if opts.MaxFailoverProposals > 0 {
+		policy.MaxFailoverProposals = opts.MaxFailoverProposals
+	}
+
+	if f := opts.FailoverFilter; f != nil {
+		var lowerBound uint64
+		policy.FailoverFilter = &proposal.Filter{
+			ServiceType:        f.ServiceType,
+			LocationType:       f.LocationType,
+			AccessPolicyID:     f.AccessPolicyID,
+			AccessPolicySource: f.AccessPolicySource,
+			ExcludeUnsupported: true,
+		}
+		if f.UpperTimePriceBound != nil {
+			policy.FailoverFilter.LowerTimePriceBound = &lowerBound
+			policy.FailoverFilter.UpperTimePriceBound = f.UpperTimePriceBound
+		}
+		if f.UpperGBPriceBound != nil {
+			policy.FailoverFilter.LowerGBPriceBound = &lowerBound
+			policy.FailoverFilter.UpperGBPriceBound = f.UpperGBPriceBound
+		}
 	}
+	return policy
 }
 
 func validateConnectionRequest(cr *connectionRequest) *validation.FieldErrorMap {
@@ -373,9 +697,54 @@ func validateConnectionRequest(cr *connectionRequest) *validation.FieldErrorMap
 	if len(cr.AccountantID) == 0 {
 		errs.ForField("accountant_id").AddError("required", "Field is required")
 	}
+	validateReconnectOptions(errs, cr.ConnectOptions.Reconnect)
+	validateSplitTunnelOptions(errs, cr.ConnectOptions.SplitTunnel)
 	return errs
 }
 
+func validateQuickConnectionRequest(qcr *quickConnectionRequest) *validation.FieldErrorMap {
+	errs := validation.NewErrorMap()
+	if len(qcr.ConsumerID) == 0 {
+		errs.ForField("consumer_id").AddError("required", "Field is required")
+	}
+	if len(qcr.AccountantID) == 0 {
+		errs.ForField("accountant_id").AddError("required", "Field is required")
+	}
+	if qcr.MaxCandidates < 0 {
+		errs.ForField("max_candidates").AddError("invalid", "Value can not be negative")
+	}
+	validateReconnectOptions(errs, qcr.ConnectOptions.Reconnect)
+	validateSplitTunnelOptions(errs, qcr.ConnectOptions.SplitTunnel)
+	return errs
+}
+
+func validateReconnectOptions(errs *validation.FieldErrorMap, r *ReconnectOptions) {
+	if r == nil {
+		return
+	}
+	if r.MaxAttempts < 0 {
+		errs.ForField("max_attempts").AddError("invalid", "Value can not be negative")
+	}
+	if r.BackoffSeconds < 0 {
+		errs.ForField("backoff_seconds").AddError("invalid", "Value can not be negative")
+	}
+	if r.MaxBackoffSeconds < 0 {
+		errs.ForField("max_backoff_seconds").AddError("invalid", "Value can not be negative")
+	}
+	if r.MaxFailoverProposals < 0 {
+		errs.ForField("max_failover_proposals").AddError("invalid", "Value can not be negative")
+	}
+}
+
+func validateSplitTunnelOptions(errs *validation.FieldErrorMap, st *SplitTunnelOptions) {
+	if st == nil {
+		return
+	}
+	if err := getSplitTunnel(st).Validate(); err != nil {
+		errs.ForField("split_tunnel").AddError("invalid", err.Error())
+	}
+}
+
 func toConnectionResponse(status connection.Status) connectionResponse {
 	response := connectionResponse{
 		Status:     string(status.State),
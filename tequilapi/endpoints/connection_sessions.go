@@ -18,18 +18,32 @@
 package endpoints
 
 import (
+	"encoding/csv"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/consumer/session"
 	"github.com/mysteriumnetwork/node/tequilapi/utils"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	exportFormatJSON = "json"
+	exportFormatCSV  = "csv"
 )
 
 // connectionSessionsList defines session list representable as json
 // swagger:model ConnectionSessionListDTO
 type connectionSessionsList struct {
 	Sessions []connectionSession `json:"sessions"`
+
+	// number of sessions matching the filter
+	// example: 120
+	Total int `json:"total"`
 }
 
 // connectionSession represents the session object
@@ -62,10 +76,14 @@ type connectionSession struct {
 
 	// example: Completed
 	Status string `json:"status"`
+
+	// tokens spent during the session
+	// example: 500000
+	TokensSpent uint64 `json:"tokens_spent"`
 }
 
 type connectionSessionStorage interface {
-	GetAll() ([]session.History, error)
+	List(filter session.Filter) ([]session.History, int, error)
 }
 
 type connectionSessionsEndpoint struct {
@@ -82,31 +100,193 @@ func NewConnectionSessionsEndpoint(sessionStorage connectionSessionStorage) *con
 // swagger:operation GET /connection-sessions Connection connectionSessions
 // ---
 // summary: Returns sessions history
-// description: Returns list of sessions history
+// description: Returns list of sessions history, newest first
+// parameters:
+//   - in: query
+//     name: date_from
+//     description: Include sessions started on or after the date, formatted as 2006-01-02
+//     type: string
+//   - in: query
+//     name: date_to
+//     description: Include sessions started on or before the date, formatted as 2006-01-02
+//     type: string
+//   - in: query
+//     name: provider_id
+//     description: Provider identity to filter the sessions by
+//     type: string
+//   - in: query
+//     name: provider_country
+//     description: Provider country to filter the sessions by
+//     type: string
+//   - in: query
+//     name: status
+//     description: Session status to filter the sessions by
+//     type: string
+//   - in: query
+//     name: limit
+//     description: Maximum number of sessions to return
+//     type: integer
+//   - in: query
+//     name: offset
+//     description: Number of sessions to skip
+//     type: integer
 // responses:
 //   200:
 //     description: List of sessions
 //     schema:
 //       "$ref": "#/definitions/ConnectionSessionListDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
 //   500:
 //     description: Internal server error
 //     schema:
 //       "$ref": "#/definitions/ErrorMessageDTO"
 func (endpoint *connectionSessionsEndpoint) List(resp http.ResponseWriter, request *http.Request, params httprouter.Params) {
-	sessions, err := endpoint.sessionStorage.GetAll()
+	filter, err := parseConnectionSessionFilter(request)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	sessions, total, err := endpoint.sessionStorage.List(filter)
 	if err != nil {
 		utils.SendError(resp, err, http.StatusInternalServerError)
 		return
 	}
 
-	sessionsSerializable := connectionSessionsList{Sessions: mapConnectionSessions(sessions, connectionSessionToDto)}
+	sessionsSerializable := connectionSessionsList{
+		Sessions: mapConnectionSessions(sessions, connectionSessionToDto),
+		Total:    total,
+	}
 	utils.WriteAsJSON(sessionsSerializable, resp)
 }
 
+// swagger:operation GET /connection-sessions/export Connection exportConnectionSessions
+// ---
+// summary: Exports sessions history
+// description: Exports sessions history with tokens spent as a CSV or JSON file, accepts the same filters as the sessions list
+// produces:
+// - application/json
+// - text/csv
+// parameters:
+//   - in: query
+//     name: format
+//     description: Export format, "json" by default
+//     type: string
+//     enum: [csv, json]
+// responses:
+//   200:
+//     description: Sessions history file
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *connectionSessionsEndpoint) Export(resp http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	format := request.URL.Query().Get("format")
+	if format == "" {
+		format = exportFormatJSON
+	}
+	if format != exportFormatJSON && format != exportFormatCSV {
+		utils.SendErrorMessage(resp, fmt.Sprintf("unsupported format %q", format), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseConnectionSessionFilter(request)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	sessions, _, err := endpoint.sessionStorage.List(filter)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	dtos := mapConnectionSessions(sessions, connectionSessionToDto)
+	resp.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=connection-sessions.%s", format))
+	if format == exportFormatJSON {
+		utils.WriteAsJSON(dtos, resp)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	if err := writeConnectionSessionsCSV(resp, dtos); err != nil {
+		log.Error().Err(err).Msg("Failed to write sessions CSV")
+	}
+}
+
+var connectionSessionCSVHeader = []string{
+	"session_id",
+	"provider_id",
+	"service_type",
+	"provider_country",
+	"date_started",
+	"duration",
+	"bytes_sent",
+	"bytes_received",
+	"tokens_spent",
+	"status",
+}
+
+func writeConnectionSessionsCSV(resp http.ResponseWriter, sessions []connectionSession) error {
+	w := csv.NewWriter(resp)
+	if err := w.Write(connectionSessionCSVHeader); err != nil {
+		return err
+	}
+	for _, se := range sessions {
+		err := w.Write([]string{
+			se.SessionID,
+			se.ProviderID,
+			se.ServiceType,
+			se.ProviderCountry,
+			se.DateStarted,
+			strconv.FormatUint(se.Duration, 10),
+			strconv.FormatUint(se.BytesSent, 10),
+			strconv.FormatUint(se.BytesReceived, 10),
+			strconv.FormatUint(se.TokensSpent, 10),
+			se.Status,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func parseConnectionSessionFilter(request *http.Request) (session.Filter, error) {
+	query := request.URL.Query()
+	filter := session.Filter{
+		ProviderID:      query.Get("provider_id"),
+		ProviderCountry: query.Get("provider_country"),
+		Status:          query.Get("status"),
+	}
+
+	var err error
+	if filter.From, filter.To, err = parseDateRange(request); err != nil {
+		return filter, err
+	}
+	if filter.Limit, err = parseNonNegativeInt(query.Get("limit")); err != nil {
+		return filter, errors.Wrap(err, "invalid limit")
+	}
+	if filter.Offset, err = parseNonNegativeInt(query.Get("offset")); err != nil {
+		return filter, errors.Wrap(err, "invalid offset")
+	}
+	return filter, nil
+}
+
 // AddRoutesForConnectionSessions attaches connection sessions endpoints to router
 func AddRoutesForConnectionSessions(router *httprouter.Router, sessionStorage connectionSessionStorage) {
 	sessionsEndpoint := NewConnectionSessionsEndpoint(sessionStorage)
 	router.GET("/connection-sessions", sessionsEndpoint.List)
+	router.GET("/connection-sessions/export", sessionsEndpoint.Export)
 }
 
 func connectionSessionToDto(se session.History) connectionSession {
@@ -120,6 +300,7 @@ func connectionSessionToDto(se session.History) connectionSession {
 		BytesReceived:   se.DataStats.BytesReceived,
 		Duration:        se.GetDuration(),
 		Status:          se.Status,
+		TokensSpent:     se.TokensSpent,
 	}
 }
 
@@ -121,8 +121,69 @@ func Test_ConnectionSessionsEndpoint_ListBubblesError(t *testing.T) {
 type connectionSessionStorageMock struct {
 	sessionsToReturn []session.History
 	errToReturn      error
+	filter           session.Filter
 }
 
-func (ssm *connectionSessionStorageMock) GetAll() ([]session.History, error) {
-	return ssm.sessionsToReturn, ssm.errToReturn
+func (ssm *connectionSessionStorageMock) List(filter session.Filter) ([]session.History, int, error) {
+	ssm.filter = filter
+	return ssm.sessionsToReturn, len(ssm.sessionsToReturn), ssm.errToReturn
+}
+
+func Test_ConnectionSessionsEndpoint_ListParsesFilter(t *testing.T) {
+	ssm := &connectionSessionStorageMock{}
+
+	req := httptest.NewRequest(http.MethodGet, "/irrelevant?date_from=2020-06-01&date_to=2020-06-01&provider_id=0x1&provider_country=NL&status=Completed&limit=10&offset=20", nil)
+	resp := httptest.NewRecorder()
+	NewConnectionSessionsEndpoint(ssm).List(resp, req, nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	from := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2020, 6, 2, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, session.Filter{
+		From:            &from,
+		To:              &to,
+		ProviderID:      "0x1",
+		ProviderCountry: "NL",
+		Status:          "Completed",
+		Limit:           10,
+		Offset:          20,
+	}, ssm.filter)
+
+	req = httptest.NewRequest(http.MethodGet, "/irrelevant?date_from=01/06/2020", nil)
+	resp = httptest.NewRecorder()
+	NewConnectionSessionsEndpoint(ssm).List(resp, req, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func Test_ConnectionSessionsEndpoint_Export(t *testing.T) {
+	se := connectionSessionMock
+	se.Started = time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	se.Updated = se.Started.Add(time.Minute)
+	se.Status = session.SessionStatusCompleted
+	se.TokensSpent = 500
+	ssm := &connectionSessionStorageMock{sessionsToReturn: []session.History{se}}
+
+	req := httptest.NewRequest(http.MethodGet, "/irrelevant?format=csv", nil)
+	resp := httptest.NewRecorder()
+	NewConnectionSessionsEndpoint(ssm).Export(resp, req, nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=connection-sessions.csv", resp.Header().Get("Content-Disposition"))
+	assert.Equal(t, "session_id,provider_id,service_type,provider_country,date_started,duration,bytes_sent,bytes_received,tokens_spent,status\n"+
+		"SessionID,providerid,serviceType,ProviderCountry,2020-06-01T10:00:00Z,60,10,10,500,Completed\n", resp.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
+	resp = httptest.NewRecorder()
+	NewConnectionSessionsEndpoint(ssm).Export(resp, req, nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var exported []connectionSession
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &exported))
+	assert.Equal(t, []connectionSession{connectionSessionToDto(se)}, exported)
+
+	req = httptest.NewRequest(http.MethodGet, "/irrelevant?format=xml", nil)
+	resp = httptest.NewRecorder()
+	NewConnectionSessionsEndpoint(ssm).Export(resp, req, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
 }
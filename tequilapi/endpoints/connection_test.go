@@ -26,9 +26,12 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/core/quickconnect"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/identity/registry"
 	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/mocks"
 	"github.com/mysteriumnetwork/payments/crypto"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -43,6 +46,7 @@ type mockConnectionManager struct {
 	requestedProvider     identity.Identity
 	requestedAccountantID identity.Identity
 	requestedServiceType  string
+	requestedParams       connection.ConnectParams
 }
 
 func (cm *mockConnectionManager) Connect(consumerID, accountantID identity.Identity, proposal market.ServiceProposal, options connection.ConnectParams) error {
@@ -50,6 +54,7 @@ func (cm *mockConnectionManager) Connect(consumerID, accountantID identity.Ident
 	cm.requestedAccountantID = accountantID
 	cm.requestedProvider = identity.FromAddress(proposal.ProviderID)
 	cm.requestedServiceType = proposal.ServiceType
+	cm.requestedParams = options
 	return cm.onConnectReturn
 }
 
@@ -63,6 +68,10 @@ func (cm *mockConnectionManager) Disconnect() error {
 	return cm.onDisconnectReturn
 }
 
+func (cm *mockConnectionManager) DisconnectWithStatus(_ string) error {
+	return cm.Disconnect()
+}
+
 func (cm *mockConnectionManager) Wait() error {
 	return nil
 }
@@ -106,7 +115,7 @@ func TestAddRoutesForConnectionAddsRoutes(t *testing.T) {
 	}
 
 	mockedProposalProvider := mockRepositoryWithProposal("node1", "noop")
-	AddRoutesForConnection(router, &fakeManager, statsKeeper, mockedProposalProvider, mockIdentityRegistryInstance)
+	AddRoutesForConnection(router, &fakeManager, statsKeeper, mockedProposalProvider, mockIdentityRegistryInstance, nil)
 
 	tests := []struct {
 		method         string
@@ -158,7 +167,7 @@ func TestDisconnectingState(t *testing.T) {
 		SessionID: "",
 	}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, nil)
 	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
 
@@ -180,7 +189,7 @@ func TestNotConnectedStateIsReturnedWhenNoConnection(t *testing.T) {
 		SessionID: "",
 	}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, nil)
 	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
 
@@ -202,7 +211,7 @@ func TestStateConnectingIsReturnedWhenIsConnectionInProgress(t *testing.T) {
 		State: connection.Connecting,
 	}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, nil)
 	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
 
@@ -225,7 +234,7 @@ func TestConnectedStateAndSessionIdIsReturnedWhenIsConnected(t *testing.T) {
 		SessionID: "My-super-session",
 	}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, nil)
 	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
 
@@ -245,7 +254,7 @@ func TestConnectedStateAndSessionIdIsReturnedWhenIsConnected(t *testing.T) {
 func TestPutReturns400ErrorIfRequestBodyIsNotJSON(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, nil)
 	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader("a"))
 	resp := httptest.NewRecorder()
 
@@ -264,7 +273,7 @@ func TestPutReturns400ErrorIfRequestBodyIsNotJSON(t *testing.T) {
 func TestPutReturns422ErrorIfRequestBodyIsMissingFieldValues(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, nil)
 	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader("{}"))
 	resp := httptest.NewRecorder()
 
@@ -288,7 +297,7 @@ func TestPutWithValidBodyCreatesConnection(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
 	proposalProvider := mockRepositoryWithProposal("required-node", "openvpn")
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, proposalProvider, mockIdentityRegistryInstance)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, proposalProvider, mockIdentityRegistryInstance, nil)
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -310,6 +319,177 @@ func TestPutWithValidBodyCreatesConnection(t *testing.T) {
 	assert.Equal(t, "openvpn", fakeManager.requestedServiceType)
 }
 
+func TestPutWithSplitTunnelOptions(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+
+	proposalProvider := mockRepositoryWithProposal("required-node", "wireguard")
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, proposalProvider, mockIdentityRegistryInstance, nil)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumer_id" : "my-identity",
+				"provider_id" : "required-node",
+				"accountant_id" : "accountant",
+				"connect_options": {
+					"split_tunnel": {
+						"excluded_routes": ["192.168.0.0/16", "1.1.1.1"],
+						"excluded_domains": ["example.com"]
+					}
+				}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, connection.SplitTunnel{
+		ExcludedRoutes:  []string{"192.168.0.0/16", "1.1.1.1"},
+		ExcludedDomains: []string{"example.com"},
+	}, fakeManager.requestedParams.SplitTunnel)
+}
+
+func TestPutWithReconnectOptions(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+
+	proposalProvider := mockRepositoryWithProposal("required-node", "wireguard")
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, proposalProvider, mockIdentityRegistryInstance, nil)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumer_id" : "my-identity",
+				"provider_id" : "required-node",
+				"accountant_id" : "accountant",
+				"connect_options": {
+					"reconnect": {
+						"enabled": true,
+						"max_attempts": 5,
+						"backoff_seconds": 1,
+						"failover": true,
+						"failover_filter": {
+							"service_type": "openvpn",
+							"upper_gb_price_bound": 7000000
+						}
+					}
+				}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	lowerBound, upperBound := uint64(0), uint64(7000000)
+	assert.Equal(t, connection.ReconnectPolicy{
+		Enabled:        true,
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		Failover:       true,
+		FailoverFilter: &proposal.Filter{
+			ServiceType:        "openvpn",
+			LowerGBPriceBound:  &lowerBound,
+			UpperGBPriceBound:  &upperBound,
+			ExcludeUnsupported: true,
+		},
+		MaxFailoverProposals: 5,
+	}, fakeManager.requestedParams.Reconnect)
+}
+
+func TestPutWithoutReconnectOptionsDisablesReconnect(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+
+	proposalProvider := mockRepositoryWithProposal("required-node", "wireguard")
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, proposalProvider, mockIdentityRegistryInstance, nil)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumer_id" : "my-identity",
+				"provider_id" : "required-node",
+				"accountant_id" : "accountant",
+				"connect_options": {"reconnect": {"enabled": false, "max_attempts": 5}}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, connection.ReconnectPolicy{}, fakeManager.requestedParams.Reconnect)
+}
+
+func TestPutValidatesReconnectOptions(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, nil)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumer_id" : "my-identity",
+				"provider_id" : "required-node",
+				"accountant_id" : "accountant",
+				"connect_options": {
+					"reconnect": {
+						"enabled": true,
+						"max_attempts": -1,
+						"backoff_seconds": -1,
+						"max_backoff_seconds": -1,
+						"max_failover_proposals": -1
+					}
+				}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"message" : "validation_error",
+			"errors" : {
+				"max_attempts" : [ {"code" : "invalid" , "message" : "Value can not be negative" } ],
+				"backoff_seconds" : [ {"code" : "invalid" , "message" : "Value can not be negative" } ],
+				"max_backoff_seconds" : [ {"code" : "invalid" , "message" : "Value can not be negative" } ],
+				"max_failover_proposals" : [ {"code" : "invalid" , "message" : "Value can not be negative" } ]
+			}
+		}`, resp.Body.String())
+}
+
+func TestPutValidatesSplitTunnelOptions(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, nil)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumer_id" : "my-identity",
+				"provider_id" : "required-node",
+				"accountant_id" : "accountant",
+				"connect_options": {"split_tunnel": {"included_routes": ["10.0.0.0/33"]}}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"message" : "validation_error",
+			"errors" : {
+				"split_tunnel" : [ {"code" : "invalid" , "message" : "invalid route: \"10.0.0.0/33\"" } ]
+			}
+		}`, resp.Body.String())
+}
+
 func TestPutUnregisteredIdentityReturnsError(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
@@ -317,7 +497,7 @@ func TestPutUnregisteredIdentityReturnsError(t *testing.T) {
 	mir := *mockIdentityRegistryInstance
 	mir.RegistrationStatus = registry.Unregistered
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, proposalProvider, &mir)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, proposalProvider, &mir, nil)
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -346,7 +526,7 @@ func TestPutFailedRegistrationCheckReturnsError(t *testing.T) {
 	mir := *mockIdentityRegistryInstance
 	mir.RegistrationCheckError = errors.New("explosions everywhere")
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, proposalProvider, &mir)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, proposalProvider, &mir, nil)
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -372,7 +552,7 @@ func TestPutWithServiceTypeOverridesDefault(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
 	mystAPI := mockRepositoryWithProposal("required-node", "noop")
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, mystAPI, mockIdentityRegistryInstance)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, mystAPI, mockIdentityRegistryInstance, nil)
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -398,7 +578,7 @@ func TestPutWithServiceTypeOverridesDefault(t *testing.T) {
 func TestDeleteCallsDisconnect(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, nil)
 	req := httptest.NewRequest(http.MethodDelete, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
 
@@ -417,7 +597,7 @@ func TestGetStatisticsEndpointReturnsStatistics(t *testing.T) {
 	}
 
 	manager := mockConnectionManager{}
-	connEndpoint := NewConnectionEndpoint(&manager, statsKeeper, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	connEndpoint := NewConnectionEndpoint(&manager, statsKeeper, &mockProposalRepository{}, mockIdentityRegistryInstance, nil)
 
 	resp := httptest.NewRecorder()
 	connEndpoint.GetStatistics(resp, nil, nil)
@@ -439,7 +619,7 @@ func TestGetStatisticsEndpointReturnsStatisticsWhenSessionIsNotStarted(t *testin
 	}
 
 	manager := mockConnectionManager{}
-	connEndpoint := NewConnectionEndpoint(&manager, statsKeeper, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	connEndpoint := NewConnectionEndpoint(&manager, statsKeeper, &mockProposalRepository{}, mockIdentityRegistryInstance, nil)
 
 	resp := httptest.NewRecorder()
 	connEndpoint.GetStatistics(resp, nil, nil)
@@ -460,7 +640,7 @@ func TestEndpointReturnsConflictStatusIfConnectionAlreadyExists(t *testing.T) {
 	manager.onConnectReturn = connection.ErrAlreadyExists
 
 	mystAPI := mockRepositoryWithProposal("required-node", "openvpn")
-	connectionEndpoint := NewConnectionEndpoint(&manager, nil, mystAPI, mockIdentityRegistryInstance)
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, mystAPI, mockIdentityRegistryInstance, nil)
 
 	req := httptest.NewRequest(
 		http.MethodPut,
@@ -489,7 +669,7 @@ func TestDisconnectReturnsConflictStatusIfConnectionDoesNotExist(t *testing.T) {
 	manager := mockConnectionManager{}
 	manager.onDisconnectReturn = connection.ErrNoConnection
 
-	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, nil)
 
 	req := httptest.NewRequest(
 		http.MethodDelete,
@@ -515,7 +695,7 @@ func TestConnectReturnsConnectCancelledStatusWhenErrConnectionCancelledIsEncount
 	manager.onConnectReturn = connection.ErrConnectionCancelled
 
 	mockProposalProvider := mockRepositoryWithProposal("required-node", "openvpn")
-	connectionEndpoint := NewConnectionEndpoint(&manager, nil, mockProposalProvider, mockIdentityRegistryInstance)
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, mockProposalProvider, mockIdentityRegistryInstance, nil)
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -543,7 +723,7 @@ func TestConnectReturnsErrorIfNoProposals(t *testing.T) {
 	manager := mockConnectionManager{}
 	manager.onConnectReturn = connection.ErrConnectionCancelled
 
-	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, nil)
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -567,4 +747,129 @@ func TestConnectReturnsErrorIfNoProposals(t *testing.T) {
 	)
 }
 
+type mockQuickConnector struct {
+	result         quickconnect.Result
+	err            error
+	recordedFilter *proposal.Filter
+}
+
+func (m *mockQuickConnector) Connect(req quickconnect.Request) (quickconnect.Result, error) {
+	m.recordedFilter = req.Filter
+	return m.result, m.err
+}
+
+func TestQuickConnectReportsChosenProvider(t *testing.T) {
+	chosen := market.ServiceProposal{
+		ID:                1,
+		ServiceType:       "wireguard",
+		ServiceDefinition: TestServiceDefinition{},
+		ProviderID:        "0xChosen",
+		PaymentMethodType: mocks.DefaultPaymentMethodType,
+		PaymentMethod:     mocks.DefaultPaymentMethod(),
+	}
+	manager := mockConnectionManager{
+		onStatusReturn: connection.Status{
+			State:    connection.Connected,
+			Proposal: chosen,
+		},
+	}
+	quickConnector := &mockQuickConnector{
+		result: quickconnect.Result{
+			Proposal: chosen,
+			Failed:   []market.ProposalID{{ProviderID: "0xFailed", ServiceType: "wireguard"}},
+		},
+	}
+
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, quickConnector)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/connection/quick",
+		strings.NewReader(
+			`{
+				"consumer_id" : "my-identity",
+				"accountant_id" : "accountant",
+				"service_type" : "wireguard",
+				"location_country" : "DE",
+				"upper_time_price_bound" : 50000
+			}`))
+	resp := httptest.NewRecorder()
+
+	connectionEndpoint.QuickCreate(resp, req, nil)
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"status": "Connected",
+			"proposal": {
+				"id": 1,
+				"provider_id": "0xChosen",
+				"service_type": "wireguard",
+				"service_definition": {
+					"location_originate": {"asn": 123, "country": "Lithuania", "city": "Vilnius"}
+				},
+				"payment_method": {
+					"type": "BYTES_TRANSFERRED_WITH_TIME",
+					"price": {"amount": 50000, "currency": "MYST"},
+					"rate": {"per_seconds": 60, "per_bytes": 7669584}
+				}
+			},
+			"failed_providers": ["0xFailed"]
+		}`,
+		resp.Body.String(),
+	)
+
+	var lowerBound uint64
+	upperBound := uint64(50000)
+	assert.Equal(t, &proposal.Filter{
+		ServiceType:         "wireguard",
+		LocationCountry:     "DE",
+		LowerTimePriceBound: &lowerBound,
+		UpperTimePriceBound: &upperBound,
+		ExcludeUnsupported:  true,
+	}, quickConnector.recordedFilter)
+}
+
+func TestQuickConnectReturnsNotFoundWithoutProposals(t *testing.T) {
+	manager := mockConnectionManager{}
+	quickConnector := &mockQuickConnector{err: quickconnect.ErrNoProposals}
+
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, quickConnector)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/connection/quick",
+		strings.NewReader(`{"consumer_id" : "my-identity", "accountant_id" : "accountant"}`))
+	resp := httptest.NewRecorder()
+
+	connectionEndpoint.QuickCreate(resp, req, nil)
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestQuickConnectValidatesRequest(t *testing.T) {
+	manager := mockConnectionManager{}
+
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, &mockQuickConnector{})
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/connection/quick",
+		strings.NewReader(`{"consumer_id" : "my-identity", "max_candidates": -1}`))
+	resp := httptest.NewRecorder()
+
+	connectionEndpoint.QuickCreate(resp, req, nil)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"message": "validation_error",
+			"errors": {
+				"accountant_id": [{"code": "required", "message": "Field is required"}],
+				"max_candidates": [{"code": "invalid", "message": "Value can not be negative"}]
+			}
+		}`,
+		resp.Body.String(),
+	)
+}
+
 var mockIdentityRegistryInstance = &registry.FakeRegistry{RegistrationStatus: registry.RegisteredConsumer}
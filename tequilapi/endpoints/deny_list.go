@@ -0,0 +1,219 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/core/policy"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+	"github.com/mysteriumnetwork/node/tequilapi/validation"
+)
+
+// swagger:model DenyRuleRequestDTO
+type denyRuleRequest struct {
+	// rule type. Possible values are "identity" and "ip_range"
+	// required: true
+	// example: ip_range
+	Type string `json:"type"`
+
+	// consumer identity, IP range in CIDR notation or a single IP
+	// required: true
+	// example: 10.0.0.0/8
+	Value string `json:"value"`
+}
+
+// swagger:model DenyRuleDTO
+type denyRule struct {
+	// example: 6ba7b810-9dad-11d1-80b4-00c04fd430c8
+	ID string `json:"id"`
+
+	// example: ip_range
+	Type string `json:"type"`
+
+	// example: 10.0.0.0/8
+	Value string `json:"value"`
+
+	// example: 2020-06-01T10:00:00Z
+	CreatedAt string `json:"created_at"`
+}
+
+// swagger:model DenyListDTO
+type denyListResponse struct {
+	Rules []denyRule `json:"rules"`
+}
+
+type denyList interface {
+	Rules() []policy.DenyRule
+	Add(ruleType, value string) (policy.DenyRule, error)
+	Remove(id string) error
+}
+
+type denyListEndpoint struct {
+	denyList denyList
+}
+
+// NewDenyListEndpoint creates and returns deny list endpoint
+func NewDenyListEndpoint(denyList denyList) *denyListEndpoint {
+	return &denyListEndpoint{denyList: denyList}
+}
+
+// List provides the provider deny list.
+// swagger:operation GET /deny-list AccessPolicies listDenyRules
+// ---
+// summary: Returns deny list
+// description: Returns consumer identities and IP ranges which are refused new sessions
+// responses:
+//   200:
+//     description: List of deny rules
+//     schema:
+//       "$ref": "#/definitions/DenyListDTO"
+func (endpoint *denyListEndpoint) List(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	rules := endpoint.denyList.Rules()
+
+	res := denyListResponse{Rules: make([]denyRule, 0, len(rules))}
+	for _, rule := range rules {
+		res.Rules = append(res.Rules, toDenyRuleResponse(rule))
+	}
+	utils.WriteAsJSON(res, resp)
+}
+
+// Create adds a new deny rule.
+// swagger:operation POST /deny-list AccessPolicies createDenyRule
+// ---
+// summary: Adds deny rule
+// description: Adds consumer identity or IP range to the deny list. Active sessions are not affected.
+// parameters:
+//   - in: body
+//     name: body
+//     description: Rule to add
+//     schema:
+//       $ref: "#/definitions/DenyRuleRequestDTO"
+// responses:
+//   201:
+//     description: Created deny rule
+//     schema:
+//       "$ref": "#/definitions/DenyRuleDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *denyListEndpoint) Create(resp http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var rr denyRuleRequest
+	decoder := json.NewDecoder(req.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rr); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	if errorMap := validateDenyRuleRequest(rr); errorMap.HasErrors() {
+		utils.SendValidationErrorMessage(resp, errorMap)
+		return
+	}
+
+	rule, err := endpoint.denyList.Add(rr.Type, rr.Value)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp.WriteHeader(http.StatusCreated)
+	utils.WriteAsJSON(toDenyRuleResponse(rule), resp)
+}
+
+// Delete removes a deny rule.
+// swagger:operation DELETE /deny-list/{id} AccessPolicies deleteDenyRule
+// ---
+// summary: Removes deny rule
+// description: Removes deny rule by its ID
+// parameters:
+//   - name: id
+//     in: path
+//     description: Rule ID
+//     type: string
+//     required: true
+// responses:
+//   202:
+//     description: Rule removed
+//   404:
+//     description: Rule not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *denyListEndpoint) Delete(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	err := endpoint.denyList.Remove(params.ByName("id"))
+	if err == policy.ErrDenyRuleNotFound {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	} else if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp.WriteHeader(http.StatusAccepted)
+}
+
+func validateDenyRuleRequest(rr denyRuleRequest) *validation.FieldErrorMap {
+	errors := validation.NewErrorMap()
+	switch {
+	case rr.Type == "":
+		errors.ForField("type").AddError("required", "Field is required")
+	case rr.Type != policy.DenyTypeIdentity && rr.Type != policy.DenyTypeIPRange:
+		errors.ForField("type").AddError("invalid", "Invalid rule type")
+	case rr.Value == "":
+		errors.ForField("value").AddError("required", "Field is required")
+	default:
+		if err := policy.ValidateDenyRule(rr.Type, rr.Value); err != nil {
+			errors.ForField("value").AddError("invalid", err.Error())
+		}
+	}
+	return errors
+}
+
+func toDenyRuleResponse(rule policy.DenyRule) denyRule {
+	return denyRule{
+		ID:        rule.ID,
+		Type:      rule.Type,
+		Value:     rule.Value,
+		CreatedAt: rule.Created.Format(time.RFC3339),
+	}
+}
+
+// AddRoutesForDenyList adds deny list routes to given router
+func AddRoutesForDenyList(router *httprouter.Router, denyList denyList) {
+	endpoint := NewDenyListEndpoint(denyList)
+
+	router.GET("/deny-list", endpoint.List)
+	router.POST("/deny-list", endpoint.Create)
+	router.DELETE("/deny-list/:id", endpoint.Delete)
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/core/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDenyList struct {
+	rules []policy.DenyRule
+}
+
+func (m *mockDenyList) Rules() []policy.DenyRule {
+	return m.rules
+}
+
+func (m *mockDenyList) Add(ruleType, value string) (policy.DenyRule, error) {
+	rule := policy.DenyRule{ID: "new", Type: ruleType, Value: value, Created: time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)}
+	m.rules = append(m.rules, rule)
+	return rule, nil
+}
+
+func (m *mockDenyList) Remove(id string) error {
+	for i := range m.rules {
+		if m.rules[i].ID == id {
+			m.rules = append(m.rules[:i], m.rules[i+1:]...)
+			return nil
+		}
+	}
+	return policy.ErrDenyRuleNotFound
+}
+
+func Test_DenyListEndpoints(t *testing.T) {
+	denyList := &mockDenyList{}
+	router := httprouter.New()
+	AddRoutesForDenyList(router, denyList)
+
+	req := httptest.NewRequest(http.MethodPost, "/deny-list", strings.NewReader(`{"type": "ip_range", "value": "10.0.0.0/8"}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.JSONEq(t, `{"id": "new", "type": "ip_range", "value": "10.0.0.0/8", "created_at": "2020-06-01T10:00:00Z"}`, resp.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/deny-list", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"rules": [{"id": "new", "type": "ip_range", "value": "10.0.0.0/8", "created_at": "2020-06-01T10:00:00Z"}]}`, resp.Body.String())
+
+	req = httptest.NewRequest(http.MethodDelete, "/deny-list/new", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Empty(t, denyList.rules)
+
+	req = httptest.NewRequest(http.MethodDelete, "/deny-list/new", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func Test_DenyListCreate_ValidationErrors(t *testing.T) {
+	router := httprouter.New()
+	AddRoutesForDenyList(router, &mockDenyList{})
+
+	tests := []struct {
+		body         string
+		expectedJSON string
+	}{
+		{`{}`, `{"message": "validation_error", "errors": {"type": [{"code": "required", "message": "Field is required"}]}}`},
+		{`{"type": "dns_zone", "value": "example.com"}`, `{"message": "validation_error", "errors": {"type": [{"code": "invalid", "message": "Invalid rule type"}]}}`},
+		{`{"type": "identity"}`, `{"message": "validation_error", "errors": {"value": [{"code": "required", "message": "Field is required"}]}}`},
+		{`{"type": "identity", "value": "consumer"}`, `{"message": "validation_error", "errors": {"value": [{"code": "invalid", "message": "invalid identity \"consumer\""}]}}`},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/deny-list", strings.NewReader(test.body))
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.Code, test.body)
+		assert.JSONEq(t, test.expectedJSON, resp.Body.String(), test.body)
+	}
+}
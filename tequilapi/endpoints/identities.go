@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/identity/registry"
@@ -30,6 +31,7 @@ import (
 	"github.com/mysteriumnetwork/node/tequilapi/contract"
 	"github.com/mysteriumnetwork/node/tequilapi/utils"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 )
 
 type balanceProvider interface {
@@ -41,13 +43,18 @@ type earningsProvider interface {
 	SettlementState(id identity.Identity) pingpong.SettlementState
 }
 
+type registrationStatusStorage interface {
+	Store(status registry.StoredRegistrationStatus) error
+}
+
 type identitiesAPI struct {
-	idm               identity.Manager
-	selector          identity_selector.Handler
-	registry          registry.IdentityRegistry
-	channelCalculator *pingpong.ChannelAddressCalculator
-	balanceProvider   balanceProvider
-	earningsProvider  earningsProvider
+	idm                 identity.Manager
+	selector            identity_selector.Handler
+	registry            registry.IdentityRegistry
+	registrationStorage registrationStatusStorage
+	channelCalculator   *pingpong.ChannelAddressCalculator
+	balanceProvider     balanceProvider
+	earningsProvider    earningsProvider
 }
 
 // swagger:operation GET /identities Identity listIdentities
@@ -235,6 +242,114 @@ func (endpoint *identitiesAPI) Unlock(resp http.ResponseWriter, httpReq *http.Re
 	resp.WriteHeader(http.StatusAccepted)
 }
 
+// swagger:operation PUT /identities/{id}/lock Identity lockIdentity
+// ---
+// summary: Locks identity
+// description: Locks identity and wipes its decrypted keys from memory, it has to be unlocked again before use
+// parameters:
+// - in: path
+//   name: id
+//   description: Identity stored in keystore
+//   type: string
+//   required: true
+// responses:
+//   202:
+//     description: Identity locked
+//   404:
+//     description: Identity not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   409:
+//     description: Identity is running services or a connection
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *identitiesAPI) Lock(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	address := params.ByName("id")
+	id, err := endpoint.idm.GetIdentity(address)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	}
+
+	err = endpoint.idm.Lock(id.Address)
+	if errors.Cause(err) == identity.ErrIdentityInUse {
+		utils.SendError(resp, err, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+	resp.WriteHeader(http.StatusAccepted)
+}
+
+// swagger:operation PUT /identities/{id}/passphrase Identity changeIdentityPassphrase
+// ---
+// summary: Changes identity passphrase
+// description: Re-encrypts identity stored in keystore with a new passphrase
+// parameters:
+// - in: path
+//   name: id
+//   description: Identity stored in keystore
+//   type: string
+//   required: true
+// - in: body
+//   name: body
+//   description: Current and new passphrases of identity
+//   schema:
+//     $ref: "#/definitions/IdentityPassphraseChangeRequestDTO"
+// responses:
+//   202:
+//     description: Identity passphrase changed
+//   400:
+//     description: Body parsing error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   403:
+//     description: Forbidden
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   404:
+//     description: Identity not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+func (endpoint *identitiesAPI) ChangePassphrase(resp http.ResponseWriter, httpReq *http.Request, params httprouter.Params) {
+	address := params.ByName("id")
+	id, err := endpoint.idm.GetIdentity(address)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	}
+
+	var req contract.IdentityPassphraseChangeRequest
+	err = json.NewDecoder(httpReq.Body).Decode(&req)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	errorMap := contract.ValidateIdentityPassphraseChangeRequest(req)
+	if errorMap.HasErrors() {
+		utils.SendValidationErrorMessage(resp, errorMap)
+		return
+	}
+
+	err = endpoint.idm.ChangePassphrase(id.Address, *req.Passphrase, *req.NewPassphrase)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusForbidden)
+		return
+	}
+	resp.WriteHeader(http.StatusAccepted)
+}
+
 // swagger:operation GET /identities/{id} Identity getIdentity
 // ---
 // summary: Get identity
@@ -327,26 +442,212 @@ func (endpoint *identitiesAPI) RegistrationStatus(resp http.ResponseWriter, _ *h
 	utils.WriteAsJSON(registrationDataDTO, resp)
 }
 
+// swagger:operation PUT /identities/{id}/export Identity exportIdentity
+// ---
+// summary: Exports identity
+// description: Exports identity keystore encrypted with a new passphrase together with its registration status and channel address. Registration status is left empty if it can't be checked.
+// parameters:
+//   - in: path
+//     name: id
+//     description: hex address of identity
+//     type: string
+//     required: true
+//   - in: body
+//     name: body
+//     description: Current passphrase of identity and passphrase to encrypt the exported keystore with
+//     schema:
+//       $ref: "#/definitions/IdentityExportRequestDTO"
+// responses:
+//   200:
+//     description: Identity backup
+//     schema:
+//       "$ref": "#/definitions/IdentityBackupDTO"
+//   400:
+//     description: Bad Request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   403:
+//     description: Forbidden
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   404:
+//     description: Identity not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *identitiesAPI) Export(resp http.ResponseWriter, httpReq *http.Request, params httprouter.Params) {
+	address := params.ByName("id")
+	id, err := endpoint.idm.GetIdentity(address)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	}
+
+	var req contract.IdentityExportRequest
+	err = json.NewDecoder(httpReq.Body).Decode(&req)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	errorMap := contract.ValidateIdentityExportRequest(req)
+	if errorMap.HasErrors() {
+		utils.SendValidationErrorMessage(resp, errorMap)
+		return
+	}
+
+	keyJSON, err := endpoint.idm.ExportIdentity(id.Address, *req.Passphrase, *req.NewPassphrase)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusForbidden)
+		return
+	}
+
+	// Registration status is just a cache, the importing node checks it again if it is missing
+	var registrationStatus string
+	if regStatus, err := endpoint.registry.GetRegistrationStatus(id); err != nil {
+		log.Warn().Err(err).Msgf("Failed to check registration status of %s, exporting identity without it", id.Address)
+	} else {
+		registrationStatus = regStatus.String()
+	}
+
+	channelAddress, err := endpoint.channelCalculator.GetChannelAddress(id)
+	if err != nil {
+		utils.SendError(resp, fmt.Errorf("failed to calculate channel address %w", err), http.StatusInternalServerError)
+		return
+	}
+
+	backup := contract.IdentityBackup{
+		Address:            id.Address,
+		Keystore:           keyJSON,
+		RegistrationStatus: registrationStatus,
+		ChannelAddress:     channelAddress.Hex(),
+	}
+	utils.WriteAsJSON(backup, resp)
+}
+
+// swagger:operation POST /identities/import Identity importIdentity
+// ---
+// summary: Imports identity
+// description: Imports identity from the backup made by export, validates the keystore belongs to the identity and restores its registration status
+// parameters:
+//   - in: body
+//     name: body
+//     description: Identity backup and its passphrases
+//     schema:
+//       $ref: "#/definitions/IdentityImportRequestDTO"
+// responses:
+//   200:
+//     description: Identity imported
+//     schema:
+//       "$ref": "#/definitions/IdentityRefDTO"
+//   400:
+//     description: Bad Request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   403:
+//     description: Forbidden
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   409:
+//     description: Identity already exists
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *identitiesAPI) Import(resp http.ResponseWriter, httpReq *http.Request, _ httprouter.Params) {
+	var req contract.IdentityImportRequest
+	err := json.NewDecoder(httpReq.Body).Decode(&req)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	errorMap := contract.ValidateIdentityImportRequest(req)
+	if errorMap.HasErrors() {
+		utils.SendValidationErrorMessage(resp, errorMap)
+		return
+	}
+
+	newPassphrase := *req.Passphrase
+	if req.NewPassphrase != nil {
+		newPassphrase = *req.NewPassphrase
+	}
+
+	id, err := endpoint.idm.ImportIdentity(req.Backup.Address, req.Backup.Keystore, *req.Passphrase, newPassphrase)
+	switch errors.Cause(err) {
+	case nil:
+	case identity.ErrIdentityExists:
+		utils.SendError(resp, err, http.StatusConflict)
+		return
+	case identity.ErrAddressMismatch:
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	case keystore.ErrDecrypt:
+		utils.SendError(resp, err, http.StatusForbidden)
+		return
+	default:
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	endpoint.restoreBackupState(id, *req.Backup)
+	utils.WriteAsJSON(contract.NewIdentityDTO(id), resp)
+}
+
+// restoreBackupState restores the cached registration status, so it is not checked on blockchain again
+func (endpoint *identitiesAPI) restoreBackupState(id identity.Identity, backup contract.IdentityBackup) {
+	if status, err := registry.ParseRegistrationStatus(backup.RegistrationStatus); err == nil && status.Registered() {
+		storedStatus := registry.StoredRegistrationStatus{
+			Identity:           id,
+			RegistrationStatus: status,
+		}
+		if err := endpoint.registrationStorage.Store(storedStatus); err != nil {
+			log.Warn().Err(err).Msgf("Failed to restore registration status of identity %s", id.Address)
+		}
+	}
+
+	// Channel address is derived from the identity, it only differs if the backup was made with another accountant
+	if channelAddress, err := endpoint.channelCalculator.GetChannelAddress(id); err == nil && backup.ChannelAddress != "" && channelAddress.Hex() != backup.ChannelAddress {
+		log.Warn().Msgf("Channel address of identity %s changed from %s to %s", id.Address, backup.ChannelAddress, channelAddress.Hex())
+	}
+}
+
 // AddRoutesForIdentities creates /identities endpoint on tequilapi service
 func AddRoutesForIdentities(
 	router *httprouter.Router,
 	idm identity.Manager,
 	selector identity_selector.Handler,
 	registry registry.IdentityRegistry,
+	registrationStorage registrationStatusStorage,
 	balanceProvider balanceProvider,
 	channelAddressCalculator *pingpong.ChannelAddressCalculator,
 	earningsProvider earningsProvider,
 ) {
 	idmEnd := &identitiesAPI{
-		idm:               idm,
-		selector:          selector,
-		registry:          registry,
-		balanceProvider:   balanceProvider,
-		channelCalculator: channelAddressCalculator,
-		earningsProvider:  earningsProvider,
+		idm:                 idm,
+		selector:            selector,
+		registry:            registry,
+		registrationStorage: registrationStorage,
+		balanceProvider:     balanceProvider,
+		channelCalculator:   channelAddressCalculator,
+		earningsProvider:    earningsProvider,
 	}
 	router.GET("/identities", idmEnd.List)
 	router.POST("/identities", idmEnd.Create)
+	router.POST("/identities/import", idmEnd.Import)
 	router.PUT("/identities/:id", func(resp http.ResponseWriter, request *http.Request, params httprouter.Params) {
 		// TODO: remove this hack when we replace our router
 		switch params.ByName("id") {
@@ -359,5 +660,8 @@ func AddRoutesForIdentities(
 	router.GET("/identities/:id", idmEnd.Get)
 	router.GET("/identities/:id/status", idmEnd.Get)
 	router.PUT("/identities/:id/unlock", idmEnd.Unlock)
+	router.PUT("/identities/:id/lock", idmEnd.Lock)
+	router.PUT("/identities/:id/passphrase", idmEnd.ChangePassphrase)
 	router.GET("/identities/:id/registration", idmEnd.RegistrationStatus)
+	router.PUT("/identities/:id/export", idmEnd.Export)
 }
@@ -19,12 +19,17 @@ package endpoints
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/identity/registry"
+	"github.com/mysteriumnetwork/node/session/pingpong"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -245,3 +250,183 @@ func TestListIdentities(t *testing.T) {
 		resp.Body.String(),
 	)
 }
+
+type mockRegistrationStatusStorage struct {
+	stored []registry.StoredRegistrationStatus
+}
+
+func (m *mockRegistrationStatusStorage) Store(status registry.StoredRegistrationStatus) error {
+	m.stored = append(m.stored, status)
+	return nil
+}
+
+func TestExportIdentity(t *testing.T) {
+	endpoint := &identitiesAPI{
+		idm:               identity.NewIdentityManagerFake(existingIdentities, newIdentity),
+		registry:          &registry.FakeRegistry{RegistrationStatus: registry.RegisteredProvider},
+		channelCalculator: pingpong.NewChannelAddressCalculator("0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000003"),
+	}
+	params := httprouter.Params{{Key: "id", Value: "0x000000000000000000000000000000000000000a"}}
+
+	req := httptest.NewRequest(http.MethodPut, "/identities/0x000000000000000000000000000000000000000a/export", bytes.NewBufferString(`{"passphrase": "old"}`))
+	resp := httptest.NewRecorder()
+	endpoint.Export(resp, req, params)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+	req = httptest.NewRequest(http.MethodPut, "/identities/0x000000000000000000000000000000000000000a/export", bytes.NewBufferString(`{"passphrase": "old", "new_passphrase": "backup"}`))
+	resp = httptest.NewRecorder()
+	endpoint.Export(resp, req, params)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var backup contract.IdentityBackup
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &backup))
+	assert.Equal(t, "0x000000000000000000000000000000000000000a", backup.Address)
+	assert.JSONEq(t, `{"address": "000000000000000000000000000000000000000a"}`, string(backup.Keystore))
+	assert.Equal(t, "RegisteredProvider", backup.RegistrationStatus)
+	assert.NotEmpty(t, backup.ChannelAddress)
+}
+
+func TestExportIdentity_WithoutRegistrationStatus(t *testing.T) {
+	endpoint := &identitiesAPI{
+		idm:               identity.NewIdentityManagerFake(existingIdentities, newIdentity),
+		registry:          &registry.FakeRegistry{RegistrationCheckError: errors.New("bc unavailable")},
+		channelCalculator: pingpong.NewChannelAddressCalculator("0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000003"),
+	}
+	params := httprouter.Params{{Key: "id", Value: "0x000000000000000000000000000000000000000a"}}
+
+	req := httptest.NewRequest(http.MethodPut, "/identities/0x000000000000000000000000000000000000000a/export", bytes.NewBufferString(`{"passphrase": "old", "new_passphrase": "backup"}`))
+	resp := httptest.NewRecorder()
+	endpoint.Export(resp, req, params)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var backup contract.IdentityBackup
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &backup))
+	assert.Equal(t, "0x000000000000000000000000000000000000000a", backup.Address)
+	assert.Empty(t, backup.RegistrationStatus)
+}
+
+func TestAddRoutesForIdentities_ExportAndImport(t *testing.T) {
+	router := httprouter.New()
+	AddRoutesForIdentities(
+		router,
+		identity.NewIdentityManagerFake(existingIdentities, newIdentity),
+		nil,
+		&registry.FakeRegistry{RegistrationStatus: registry.RegisteredProvider},
+		&mockRegistrationStatusStorage{},
+		nil,
+		pingpong.NewChannelAddressCalculator("0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000003"),
+		nil,
+	)
+
+	req := httptest.NewRequest(http.MethodPut, "/identities/0x000000000000000000000000000000000000000a/export", bytes.NewBufferString(`{"passphrase": "old", "new_passphrase": "backup"}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/identities/import", bytes.NewBufferString(`{
+		"backup": {"id": "0x000000000000000000000000000000000000000b", "keystore": {"address": "000000000000000000000000000000000000000b"}},
+		"passphrase": "backup"
+	}`))
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestImportIdentity(t *testing.T) {
+	storage := &mockRegistrationStatusStorage{}
+	endpoint := &identitiesAPI{
+		idm:                 identity.NewIdentityManagerFake(existingIdentities, newIdentity),
+		registrationStorage: storage,
+		channelCalculator:   pingpong.NewChannelAddressCalculator("0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000003"),
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/identities/import", bytes.NewBufferString(`{
+		"backup": {"id": "0x000000000000000000000000000000000000000b", "keystore": {"address": "000000000000000000000000000000000000000b"}, "registration_status": "RegisteredProvider"},
+		"passphrase": "backup"
+	}`))
+	resp := httptest.NewRecorder()
+	endpoint.Import(resp, req, nil)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"id": "0x000000000000000000000000000000000000000b"}`, resp.Body.String())
+	assert.Equal(t, []registry.StoredRegistrationStatus{
+		{Identity: identity.FromAddress("0x000000000000000000000000000000000000000b"), RegistrationStatus: registry.RegisteredProvider},
+	}, storage.stored)
+
+	req = httptest.NewRequest(http.MethodPost, "/identities/import", bytes.NewBufferString(`{
+		"backup": {"id": "0x000000000000000000000000000000000000000a", "keystore": {"address": "000000000000000000000000000000000000000a"}},
+		"passphrase": "backup"
+	}`))
+	resp = httptest.NewRecorder()
+	endpoint.Import(resp, req, nil)
+	assert.Equal(t, http.StatusConflict, resp.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/identities/import", bytes.NewBufferString(`{"backup": {"id": "0x000000000000000000000000000000000000000c"}}`))
+	resp = httptest.NewRecorder()
+	endpoint.Import(resp, req, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.Len(t, storage.stored, 1)
+}
+
+func TestLockIdentity(t *testing.T) {
+	tests := []struct {
+		name         string
+		id           string
+		inUse        bool
+		expectedCode int
+	}{
+		{"locks idle identity", "0x000000000000000000000000000000000000000a", false, http.StatusAccepted},
+		{"identity not found", "0x0000000000000000000000000000000000000bad", false, http.StatusNotFound},
+		{"identity in use", "0x000000000000000000000000000000000000000a", true, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockIdm := identity.NewIdentityManagerFake(existingIdentities, newIdentity)
+			if tt.inUse {
+				mockIdm.MarkInUse()
+			}
+			endpoint := &identitiesAPI{
+				idm: mockIdm,
+			}
+			req, err := http.NewRequest(http.MethodPut, identityUrl, nil)
+			assert.NoError(t, err)
+			resp := httptest.NewRecorder()
+
+			endpoint.Lock(resp, req, httprouter.Params{{Key: "id", Value: tt.id}})
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+			assert.Equal(t, tt.expectedCode != http.StatusAccepted, mockIdm.IsUnlocked(tt.id))
+		})
+	}
+}
+
+func TestChangeIdentityPassphrase(t *testing.T) {
+	tests := []struct {
+		name         string
+		id           string
+		body         string
+		unlockFails  bool
+		expectedCode int
+	}{
+		{"changes passphrase", "0x000000000000000000000000000000000000000a", `{"passphrase": "old", "new_passphrase": "new"}`, false, http.StatusAccepted},
+		{"identity not found", "0x0000000000000000000000000000000000000bad", `{"passphrase": "old", "new_passphrase": "new"}`, false, http.StatusNotFound},
+		{"invalid body", "0x000000000000000000000000000000000000000a", `{`, false, http.StatusBadRequest},
+		{"missing new passphrase", "0x000000000000000000000000000000000000000a", `{"passphrase": "old"}`, false, http.StatusUnprocessableEntity},
+		{"wrong passphrase", "0x000000000000000000000000000000000000000a", `{"passphrase": "wrong", "new_passphrase": "new"}`, true, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockIdm := identity.NewIdentityManagerFake(existingIdentities, newIdentity)
+			if tt.unlockFails {
+				mockIdm.MarkUnlockToFail()
+			}
+			endpoint := &identitiesAPI{idm: mockIdm}
+			req, err := http.NewRequest(http.MethodPut, identityUrl, bytes.NewBufferString(tt.body))
+			assert.NoError(t, err)
+			resp := httptest.NewRecorder()
+
+			endpoint.ChangePassphrase(resp, req, httprouter.Params{{Key: "id", Value: tt.id}})
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+		})
+	}
+}
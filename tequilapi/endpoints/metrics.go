@@ -0,0 +1,66 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/core/metrics"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+	"github.com/rs/zerolog/log"
+)
+
+type metricsEndpoint struct {
+	registry *metrics.Registry
+}
+
+// Metrics exposes node metrics for Prometheus scraping
+// swagger:operation GET /metrics Metrics getMetrics
+// ---
+// summary: Returns node metrics
+// description: Returns node metrics in Prometheus text exposition format
+// produces:
+// - text/plain
+// responses:
+//   200:
+//     description: Node metrics
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (me *metricsEndpoint) Metrics(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	var buf bytes.Buffer
+	if err := me.registry.Write(&buf); err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp.Header().Set("Content-Type", metrics.ContentType)
+	resp.WriteHeader(http.StatusOK)
+	if _, err := buf.WriteTo(resp); err != nil {
+		log.Error().Err(err).Msg("Failed to write metrics")
+	}
+}
+
+// AddRoutesForMetrics attaches metrics endpoint to router
+func AddRoutesForMetrics(router *httprouter.Router, registry *metrics.Registry) {
+	endpoint := &metricsEndpoint{registry: registry}
+	router.GET("/metrics", endpoint.Metrics)
+}
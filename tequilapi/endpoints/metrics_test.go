@@ -0,0 +1,44 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/core/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsEndpoint_ReturnsMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	registry.NewCounter("test_total", "Test counter.", "label").Inc("value")
+
+	router := httprouter.New()
+	AddRoutesForMetrics(router, registry)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, metrics.ContentType, resp.Header().Get("Content-Type"))
+	assert.Equal(t, "# HELP test_total Test counter.\n# TYPE test_total counter\ntest_total{label=\"value\"} 1\n", resp.Body.String())
+}
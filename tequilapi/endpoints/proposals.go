@@ -23,6 +23,7 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/core/discovery/ranking"
 	"github.com/mysteriumnetwork/node/core/quality"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/money"
@@ -97,6 +98,10 @@ type proposalDTO struct {
 
 	// PaymentMethod
 	PaymentMethod paymentMethodRes `json:"payment_method"`
+
+	// provider DNS blocks ad, tracker and malware domains
+	// example: true
+	FilteredDNS bool `json:"filtered_dns,omitempty"`
 }
 
 func proposalToRes(p market.ServiceProposal) *proposalDTO {
@@ -116,6 +121,7 @@ func proposalToRes(p market.ServiceProposal) *proposalDTO {
 			},
 		},
 		AccessPolicies: p.AccessPolicies,
+		FilteredDNS:    p.FilteredDNS,
 		PaymentMethod: paymentMethodRes{
 			Type:  p.PaymentMethod.GetType(),
 			Price: p.PaymentMethod.GetPrice(),
@@ -135,13 +141,15 @@ type QualityFinder interface {
 type proposalsEndpoint struct {
 	proposalRepository proposal.Repository
 	qualityProvider    QualityFinder
+	scorer             ranking.Scorer
 }
 
 // NewProposalsEndpoint creates and returns proposal creation endpoint
-func NewProposalsEndpoint(proposalRepository proposal.Repository, qualityProvider QualityFinder) *proposalsEndpoint {
+func NewProposalsEndpoint(proposalRepository proposal.Repository, qualityProvider QualityFinder, scorer ranking.Scorer) *proposalsEndpoint {
 	return &proposalsEndpoint{
 		proposalRepository: proposalRepository,
 		qualityProvider:    qualityProvider,
+		scorer:             scorer,
 	}
 }
 
@@ -167,6 +175,46 @@ func NewProposalsEndpoint(proposalRepository proposal.Repository, qualityProvide
 //     description: the access policy source to filter the proposals by
 //     type: string
 //   - in: query
+//     name: location_country
+//     description: the provider country code to filter the proposals by
+//     type: string
+//   - in: query
+//     name: location_city
+//     description: the provider city to filter the proposals by
+//     type: string
+//   - in: query
+//     name: location_type
+//     description: the provider node type to filter the proposals by. Possible values are "residential", "hosting", "business", etc.
+//     type: string
+//   - in: query
+//     name: nat_type
+//     description: the provider NAT type to filter the proposals by. Possible values are "none" and "behind_nat"
+//     type: string
+//   - in: query
+//     name: filtered_dns
+//     description: if set to true, returns only proposals of providers blocking ad, tracker and malware domains on their DNS
+//     type: boolean
+//   - in: query
+//     name: quality_min
+//     description: the minimum quality score (from 0 to 1) of the proposals. Proposals without quality data are excluded.
+//     type: number
+//   - in: query
+//     name: sort_by
+//     description: sorts proposals by the given key. Possible values are "score", "price", "quality" and "success_rate". Proposals are not sorted by default.
+//     type: string
+//   - in: query
+//     name: order
+//     description: the sort order, "asc" or "desc". Defaults to "asc" for price and "desc" for other keys, so the best proposals come first.
+//     type: string
+//   - in: query
+//     name: limit
+//     description: the maximum number of proposals to return. Not limited by default.
+//     type: integer
+//   - in: query
+//     name: offset
+//     description: the number of proposals to skip
+//     type: integer
+//   - in: query
 //     name: fetch_connect_counts
 //     description: if set to true, fetches the connection success metrics for nodes. False by default.
 //     type: boolean
@@ -175,6 +223,10 @@ func NewProposalsEndpoint(proposalRepository proposal.Repository, qualityProvide
 //     description: List of proposals
 //     schema:
 //       "$ref": "#/definitions/ProposalsList"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
 //   500:
 //     description: Internal server error
 //     schema:
@@ -204,9 +256,26 @@ func (pe *proposalsEndpoint) List(resp http.ResponseWriter, req *http.Request, p
 		return
 	}
 
-	proposals, err := pe.proposalRepository.Proposals(&proposal.Filter{
+	qualityMin, err := parseQualityMin(req)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	rankingOpts, err := parseRankingOptions(req)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	filter := &proposal.Filter{
 		ProviderID:          req.URL.Query().Get("provider_id"),
 		ServiceType:         req.URL.Query().Get("service_type"),
+		LocationCountry:     req.URL.Query().Get("location_country"),
+		LocationCity:        req.URL.Query().Get("location_city"),
+		LocationType:        req.URL.Query().Get("location_type"),
+		NATType:             req.URL.Query().Get("nat_type"),
+		FilteredDNS:         req.URL.Query().Get("filtered_dns") == "true",
 		AccessPolicyID:      req.URL.Query().Get("access_policy_id"),
 		AccessPolicySource:  req.URL.Query().Get("access_policy_source"),
 		LowerGBPriceBound:   lowerGBPriceBound,
@@ -214,20 +283,31 @@ func (pe *proposalsEndpoint) List(resp http.ResponseWriter, req *http.Request, p
 		LowerTimePriceBound: lowerTimePriceBound,
 		UpperTimePriceBound: upperTimePriceBound,
 		ExcludeUnsupported:  true,
-	})
+	}
+
+	var metrics []quality.ConnectMetric
+	if qualityMin > 0 || fetchConnectCounts == "true" {
+		metrics = pe.qualityProvider.ProposalsMetrics()
+	}
+	if qualityMin > 0 {
+		filter.QualityMin = qualityMin
+		filter.QualityScores = quality.ProposalScores(metrics)
+	}
 
+	proposals, err := pe.proposalRepository.Proposals(filter)
 	if err != nil {
 		utils.SendError(resp, err, http.StatusInternalServerError)
 		return
 	}
 
+	proposals = ranking.Rank(pe.scorer, proposals, rankingOpts)
+
 	proposalsRes := proposalsRes{Proposals: []*proposalDTO{}}
 	for _, p := range proposals {
 		proposalsRes.Proposals = append(proposalsRes.Proposals, proposalToRes(p))
 	}
 
 	if fetchConnectCounts == "true" {
-		metrics := pe.qualityProvider.ProposalsMetrics()
 		addProposalMetrics(proposalsRes.Proposals, metrics)
 	}
 
@@ -243,9 +323,37 @@ func parsePriceBound(req *http.Request, key string) (*uint64, error) {
 	return &upperPriceBound, err
 }
 
+func parseQualityMin(req *http.Request) (float64, error) {
+	value := req.URL.Query().Get("quality_min")
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+func parseRankingOptions(req *http.Request) (ranking.Options, error) {
+	opts := ranking.Options{
+		SortBy: req.URL.Query().Get("sort_by"),
+		Order:  req.URL.Query().Get("order"),
+	}
+
+	var err error
+	if limit := req.URL.Query().Get("limit"); limit != "" {
+		if opts.Limit, err = strconv.Atoi(limit); err != nil {
+			return opts, err
+		}
+	}
+	if offset := req.URL.Query().Get("offset"); offset != "" {
+		if opts.Offset, err = strconv.Atoi(offset); err != nil {
+			return opts, err
+		}
+	}
+	return opts, opts.Validate()
+}
+
 // AddRoutesForProposals attaches proposals endpoints to router
-func AddRoutesForProposals(router *httprouter.Router, proposalRepository proposal.Repository, qualityProvider QualityFinder) {
-	pe := NewProposalsEndpoint(proposalRepository, qualityProvider)
+func AddRoutesForProposals(router *httprouter.Router, proposalRepository proposal.Repository, qualityProvider QualityFinder, scorer ranking.Scorer) {
+	pe := NewProposalsEndpoint(proposalRepository, qualityProvider, scorer)
 	router.GET("/proposals", pe.List)
 }
 
@@ -18,6 +18,7 @@
 package endpoints
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -25,6 +26,7 @@ import (
 	"testing"
 
 	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/core/discovery/ranking"
 	"github.com/mysteriumnetwork/node/core/quality"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/mocks"
@@ -80,7 +82,7 @@ func TestProposalsEndpointListByNodeId(t *testing.T) {
 	req.URL.RawQuery = query.Encode()
 
 	resp := httptest.NewRecorder()
-	handlerFunc := NewProposalsEndpoint(repository, &mockQualityProvider{}).List
+	handlerFunc := NewProposalsEndpoint(repository, &mockQualityProvider{}, &mockScorer{}).List
 	handlerFunc(resp, req, nil)
 
 	assert.JSONEq(
@@ -148,7 +150,7 @@ func TestProposalsEndpointAcceptsAccessPolicyParams(t *testing.T) {
 	req.URL.RawQuery = query.Encode()
 
 	resp := httptest.NewRecorder()
-	handlerFunc := NewProposalsEndpoint(repository, &mockQualityProvider{}).List
+	handlerFunc := NewProposalsEndpoint(repository, &mockQualityProvider{}, &mockScorer{}).List
 	handlerFunc(resp, req, nil)
 
 	assert.JSONEq(
@@ -192,6 +194,143 @@ func TestProposalsEndpointAcceptsAccessPolicyParams(t *testing.T) {
 	)
 }
 
+func TestProposalsEndpointAcceptsLocationQualityAndNATParams(t *testing.T) {
+	repository := &mockProposalRepository{
+		proposals: []market.ServiceProposal{serviceProposals[0]},
+	}
+
+	req, err := http.NewRequest(
+		http.MethodGet,
+		"/irrelevant",
+		nil,
+	)
+	assert.Nil(t, err)
+
+	query := req.URL.Query()
+	query.Set("location_country", "LT")
+	query.Set("location_city", "Vilnius")
+	query.Set("location_type", "residential")
+	query.Set("nat_type", market.NATTypeNone)
+	query.Set("quality_min", "0.4")
+	req.URL.RawQuery = query.Encode()
+
+	resp := httptest.NewRecorder()
+	handlerFunc := NewProposalsEndpoint(repository, &mockQualityProvider{}, &mockScorer{}).List
+	handlerFunc(resp, req, nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t,
+		&proposal.Filter{
+			LocationCountry:    "LT",
+			LocationCity:       "Vilnius",
+			LocationType:       "residential",
+			NATType:            market.NATTypeNone,
+			QualityMin:         0.4,
+			QualityScores:      map[market.ProposalID]float64{serviceProposals[0].UniqueID(): 0.5},
+			ExcludeUnsupported: true,
+		},
+		repository.recordedFilter,
+	)
+}
+
+func TestProposalsEndpointFiltersByFilteredDNS(t *testing.T) {
+	repository := &mockProposalRepository{}
+
+	req, err := http.NewRequest(
+		http.MethodGet,
+		"/irrelevant?filtered_dns=true",
+		nil,
+	)
+	assert.Nil(t, err)
+
+	resp := httptest.NewRecorder()
+	handlerFunc := NewProposalsEndpoint(repository, &mockQualityProvider{}, &mockScorer{}).List
+	handlerFunc(resp, req, nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t,
+		&proposal.Filter{
+			FilteredDNS:        true,
+			ExcludeUnsupported: true,
+		},
+		repository.recordedFilter,
+	)
+}
+
+func TestProposalsEndpointRejectsInvalidQualityMin(t *testing.T) {
+	repository := &mockProposalRepository{}
+
+	req, err := http.NewRequest(
+		http.MethodGet,
+		"/irrelevant?quality_min=high",
+		nil,
+	)
+	assert.Nil(t, err)
+
+	resp := httptest.NewRecorder()
+	handlerFunc := NewProposalsEndpoint(repository, &mockQualityProvider{}, &mockScorer{}).List
+	handlerFunc(resp, req, nil)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Nil(t, repository.recordedFilter)
+}
+
+func TestProposalsEndpointListSortsAndPaginates(t *testing.T) {
+	repository := &mockProposalRepository{
+		proposals: serviceProposals,
+	}
+	scorer := &mockScorer{
+		scores: map[market.ProposalID]ranking.Score{
+			serviceProposals[0].UniqueID(): {Total: 0.2},
+			serviceProposals[1].UniqueID(): {Total: 0.9},
+		},
+	}
+
+	req, err := http.NewRequest(
+		http.MethodGet,
+		"/irrelevant?sort_by=score&limit=1",
+		nil,
+	)
+	assert.Nil(t, err)
+
+	resp := httptest.NewRecorder()
+	handlerFunc := NewProposalsEndpoint(repository, &mockQualityProvider{}, scorer).List
+	handlerFunc(resp, req, nil)
+
+	parsed := proposalsRes{}
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &parsed))
+	assert.Len(t, parsed.Proposals, 1)
+	assert.Equal(t, "other_provider", parsed.Proposals[0].ProviderID)
+
+	req, err = http.NewRequest(
+		http.MethodGet,
+		"/irrelevant?sort_by=score&order=asc&offset=1",
+		nil,
+	)
+	assert.Nil(t, err)
+
+	resp = httptest.NewRecorder()
+	handlerFunc(resp, req, nil)
+
+	parsed = proposalsRes{}
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &parsed))
+	assert.Len(t, parsed.Proposals, 1)
+	assert.Equal(t, "other_provider", parsed.Proposals[0].ProviderID)
+}
+
+func TestProposalsEndpointListRejectsInvalidRankingParams(t *testing.T) {
+	for _, query := range []string{"sort_by=name", "order=random", "limit=-1", "offset=abc"} {
+		req, err := http.NewRequest(http.MethodGet, "/irrelevant?"+query, nil)
+		assert.Nil(t, err)
+
+		resp := httptest.NewRecorder()
+		handlerFunc := NewProposalsEndpoint(&mockProposalRepository{}, &mockQualityProvider{}, &mockScorer{}).List
+		handlerFunc(resp, req, nil)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code, query)
+	}
+}
+
 func TestProposalsEndpointList(t *testing.T) {
 	repository := &mockProposalRepository{
 		proposals: serviceProposals,
@@ -205,7 +344,7 @@ func TestProposalsEndpointList(t *testing.T) {
 	assert.Nil(t, err)
 
 	resp := httptest.NewRecorder()
-	handlerFunc := NewProposalsEndpoint(repository, &mockQualityProvider{}).List
+	handlerFunc := NewProposalsEndpoint(repository, &mockQualityProvider{}, &mockScorer{}).List
 	handlerFunc(resp, req, nil)
 
 	assert.JSONEq(
@@ -277,7 +416,7 @@ func TestProposalsEndpointListFetchConnectCounts(t *testing.T) {
 
 	resp := httptest.NewRecorder()
 
-	handlerFunc := NewProposalsEndpoint(repository, &mockQualityProvider{}).List
+	handlerFunc := NewProposalsEndpoint(repository, &mockQualityProvider{}, &mockScorer{}).List
 	handlerFunc(resp, req, nil)
 
 	assert.JSONEq(
@@ -343,6 +482,14 @@ func TestProposalsEndpointListFetchConnectCounts(t *testing.T) {
 	)
 }
 
+type mockScorer struct {
+	scores map[market.ProposalID]ranking.Score
+}
+
+func (m *mockScorer) Score(_ []market.ServiceProposal) map[market.ProposalID]ranking.Score {
+	return m.scores
+}
+
 type mockQualityProvider struct{}
 
 func (m *mockQualityProvider) ProposalsMetrics() []quality.ConnectMetric {
@@ -19,15 +19,23 @@ package endpoints
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/core/service/restore"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/services"
 	"github.com/mysteriumnetwork/node/tequilapi/utils"
 	"github.com/mysteriumnetwork/node/tequilapi/validation"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
 
@@ -51,6 +59,8 @@ type serviceRequest struct {
 	// access list which determines which identities will be able to receive the service
 	// required: false
 	AccessPolicies accessPoliciesRequest `json:"access_policies"`
+
+	rawOptions *json.RawMessage
 }
 
 // accessPolicy represents the access controls
@@ -59,9 +69,29 @@ type accessPoliciesRequest struct {
 	Ids []string `json:"ids"`
 }
 
+// swagger:model ServiceDrainRequestDTO
+type serviceDrainRequest struct {
+	// time given to active sessions before the service is stopped. Configured default is used if empty.
+	// required: false
+	// example: 10m
+	Timeout string `json:"timeout"`
+}
+
 // swagger:model ServiceListDTO
 type serviceList []serviceInfo
 
+// swagger:model ServiceGroupListDTO
+type serviceGroupList []serviceGroup
+
+// swagger:model ServiceGroupDTO
+type serviceGroup struct {
+	// provider identity
+	// example: 0x0000000000000000000000000000000000000002
+	ProviderID string `json:"provider_id"`
+
+	Services []serviceInfo `json:"services"`
+}
+
 // swagger:model ServiceInfoDTO
 type serviceInfo struct {
 	// example: 6ba7b810-9dad-11d1-80b4-00c04fd430c8
@@ -87,10 +117,17 @@ type serviceInfo struct {
 	AccessPolicies *[]market.AccessPolicy `json:"access_policies,omitempty"`
 }
 
+// ServiceRestorer starts services which are restored after node restart
+type ServiceRestorer interface {
+	Start(definition restore.Definition, options service.Options) (service.ID, error)
+	Forget(providerID, serviceType string) error
+}
+
 // ServiceEndpoint struct represents management of service resource and it's sub-resources
 type ServiceEndpoint struct {
 	serviceManager ServiceManager
 	optionsParser  map[string]ServiceOptionsParser
+	restorer       ServiceRestorer
 }
 
 // ServiceOptionsParser parses request to service specific options
@@ -104,10 +141,11 @@ var (
 )
 
 // NewServiceEndpoint creates and returns service endpoint
-func NewServiceEndpoint(serviceManager ServiceManager, optionsParser map[string]ServiceOptionsParser) *ServiceEndpoint {
+func NewServiceEndpoint(serviceManager ServiceManager, optionsParser map[string]ServiceOptionsParser, restorer ServiceRestorer) *ServiceEndpoint {
 	return &ServiceEndpoint{
 		serviceManager: serviceManager,
 		optionsParser:  optionsParser,
+		restorer:       restorer,
 	}
 }
 
@@ -116,16 +154,31 @@ func NewServiceEndpoint(serviceManager ServiceManager, optionsParser map[string]
 // ---
 // summary: List of services
 // description: ServiceList provides a list of running services on the node.
+// parameters:
+//   - in: query
+//     name: group_by
+//     description: Set to "provider" to get services grouped by provider identity as ServiceGroupListDTO
+//     type: string
 // responses:
 //   200:
 //     description: List of running services
 //     schema:
 //       "$ref": "#/definitions/ServiceListDTO"
-func (se *ServiceEndpoint) ServiceList(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+//   400:
+//     description: Bad Request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (se *ServiceEndpoint) ServiceList(resp http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	instances := se.serviceManager.List()
 
-	statusResponse := toServiceListResponse(instances)
-	utils.WriteAsJSON(statusResponse, resp)
+	switch groupBy := req.URL.Query().Get("group_by"); groupBy {
+	case "":
+		utils.WriteAsJSON(toServiceListResponse(instances), resp)
+	case "provider":
+		utils.WriteAsJSON(toServiceGroupListResponse(instances), resp)
+	default:
+		utils.SendErrorMessage(resp, fmt.Sprintf("Unsupported group_by value: %s", groupBy), http.StatusBadRequest)
+	}
 }
 
 // ServiceGet provides info for requested service on the node.
@@ -159,7 +212,7 @@ func (se *ServiceEndpoint) ServiceGet(resp http.ResponseWriter, _ *http.Request,
 // swagger:operation POST /services Service serviceStart
 // ---
 // summary: Starts service
-// description: Provider starts serving new service to consumers
+// description: Provider starts serving new service to consumers. Service is started again after node restart, until it is stopped.
 // parameters:
 //   - in: body
 //     name: body
@@ -200,14 +253,13 @@ func (se *ServiceEndpoint) ServiceStart(resp http.ResponseWriter, req *http.Requ
 		return
 	}
 
-	if se.isAlreadyRunning(sr) {
+	log.Info().Msgf("Service start options: %+v", sr)
+	definition := restore.NewDefinition(sr.ProviderID, sr.Type, sr.AccessPolicies.Ids, sr.rawOptions)
+	id, err := se.restorer.Start(definition, sr.Options)
+	if err == restore.ErrAlreadyRunning {
 		utils.SendErrorMessage(resp, "Service already running", http.StatusConflict)
 		return
-	}
-
-	log.Info().Msgf("Service start options: %+v", sr)
-	id, err := se.serviceManager.Start(identity.FromAddress(sr.ProviderID), sr.Type, sr.AccessPolicies.Ids, sr.Options)
-	if err == service.ErrorLocation {
+	} else if err == service.ErrorLocation {
 		utils.SendError(resp, err, http.StatusBadRequest)
 		return
 	} else if err != nil {
@@ -226,7 +278,7 @@ func (se *ServiceEndpoint) ServiceStart(resp http.ResponseWriter, req *http.Requ
 // swagger:operation DELETE /services/:id Service serviceStop
 // ---
 // summary: Stops service
-// description: Initiates service stop
+// description: Initiates service stop. Stopped service is not started again after node restart.
 // responses:
 //   202:
 //     description: Service Stop initiated
@@ -247,6 +299,11 @@ func (se *ServiceEndpoint) ServiceStop(resp http.ResponseWriter, _ *http.Request
 		return
 	}
 
+	proposal := instance.Proposal()
+	if err := se.restorer.Forget(proposal.ProviderID, proposal.ServiceType); err != nil {
+		log.Error().Err(err).Msgf("Failed to delete stored %s service", proposal.ServiceType)
+	}
+
 	if err := se.serviceManager.Stop(id); err != nil {
 		utils.SendError(resp, err, http.StatusInternalServerError)
 		return
@@ -255,24 +312,99 @@ func (se *ServiceEndpoint) ServiceStop(resp http.ResponseWriter, _ *http.Request
 	resp.WriteHeader(http.StatusAccepted)
 }
 
-func (se *ServiceEndpoint) isAlreadyRunning(sr serviceRequest) bool {
-	for _, instance := range se.serviceManager.List() {
-		proposal := instance.Proposal()
-		if proposal.ProviderID == sr.ProviderID && proposal.ServiceType == sr.Type {
-			return true
-		}
+// ServiceDrain starts draining service on the node.
+// swagger:operation POST /services/{id}/drain Service serviceDrain
+// ---
+// summary: Drains service
+// description: Unregisters service proposal and refuses new sessions. Service is stopped once active sessions end or the timeout expires.
+// parameters:
+//   - name: id
+//     in: path
+//     description: Service ID
+//     type: string
+//     required: true
+//   - in: body
+//     name: body
+//     description: Drain parameters
+//     schema:
+//       $ref: "#/definitions/ServiceDrainRequestDTO"
+// responses:
+//   202:
+//     description: Service drain initiated
+//     schema:
+//       "$ref": "#/definitions/ServiceInfoDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   404:
+//     description: No service exists
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (se *ServiceEndpoint) ServiceDrain(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	id := service.ID(params.ByName("id"))
+
+	instance := se.serviceManager.Service(id)
+	if instance == nil {
+		utils.SendErrorMessage(resp, "Service not found", http.StatusNotFound)
+		return
+	}
+
+	timeout, err := toDrainTimeout(req)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	err = se.serviceManager.Drain(id, timeout)
+	if err == service.ErrNoSuchInstance {
+		utils.SendErrorMessage(resp, "Service not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	// Headers are sent with the status code, so content type has to be set before it.
+	resp.Header().Set("Content-Type", "application/json; charset=utf-8")
+	resp.WriteHeader(http.StatusAccepted)
+	utils.WriteAsJSON(toServiceInfoResponse(id, instance), resp)
+}
+
+func toDrainTimeout(req *http.Request) (time.Duration, error) {
+	var dr serviceDrainRequest
+	decoder := json.NewDecoder(req.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&dr); err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	if dr.Timeout == "" {
+		return config.GetDuration(config.FlagDrainTimeout), nil
 	}
-	return false
+	timeout, err := time.ParseDuration(dr.Timeout)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid timeout")
+	}
+	if timeout < 0 {
+		return 0, errors.New("timeout can not be negative")
+	}
+	return timeout, nil
 }
 
 // AddRoutesForService adds service routes to given router
-func AddRoutesForService(router *httprouter.Router, serviceManager ServiceManager, optionsParser map[string]ServiceOptionsParser) {
-	serviceEndpoint := NewServiceEndpoint(serviceManager, optionsParser)
+func AddRoutesForService(router *httprouter.Router, serviceManager ServiceManager, optionsParser map[string]ServiceOptionsParser, restorer ServiceRestorer) {
+	serviceEndpoint := NewServiceEndpoint(serviceManager, optionsParser, restorer)
 
 	router.GET("/services", serviceEndpoint.ServiceList)
 	router.POST("/services", serviceEndpoint.ServiceStart)
 	router.GET("/services/:id", serviceEndpoint.ServiceGet)
 	router.DELETE("/services/:id", serviceEndpoint.ServiceStop)
+	router.POST("/services/:id/drain", serviceEndpoint.ServiceDrain)
 }
 
 func (se *ServiceEndpoint) toServiceRequest(req *http.Request) (serviceRequest, error) {
@@ -297,6 +429,7 @@ func (se *ServiceEndpoint) toServiceRequest(req *http.Request) (serviceRequest,
 		Type:           se.toServiceType(jsonData.Type),
 		Options:        se.toServiceOptions(jsonData.Type, jsonData.Options),
 		AccessPolicies: jsonData.AccessPolicies,
+		rawOptions:     jsonData.Options,
 	}
 	return sr, nil
 }
@@ -348,6 +481,27 @@ func toServiceListResponse(instances map[service.ID]*service.Instance) serviceLi
 	return res
 }
 
+func toServiceGroupListResponse(instances map[service.ID]*service.Instance) serviceGroupList {
+	groups := make(map[string][]serviceInfo)
+	for id, instance := range instances {
+		info := toServiceInfoResponse(id, instance)
+		providerID := strings.ToLower(info.ProviderID)
+		groups[providerID] = append(groups[providerID], info)
+	}
+
+	res := make(serviceGroupList, 0, len(groups))
+	for providerID, services := range groups {
+		sort.Slice(services, func(i, j int) bool {
+			return services[i].ID < services[j].ID
+		})
+		res = append(res, serviceGroup{ProviderID: providerID, Services: services})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ProviderID < res[j].ProviderID
+	})
+	return res
+}
+
 func validateServiceRequest(sr serviceRequest) *validation.FieldErrorMap {
 	errors := validation.NewErrorMap()
 	if len(sr.ProviderID) == 0 {
@@ -369,6 +523,7 @@ func validateServiceRequest(sr serviceRequest) *validation.FieldErrorMap {
 type ServiceManager interface {
 	Start(providerID identity.Identity, serviceType string, policies []string, options service.Options) (service.ID, error)
 	Stop(id service.ID) error
+	Drain(id service.ID, timeout time.Duration) error
 	Service(id service.ID) *service.Instance
 	Kill() error
 	List() map[service.ID]*service.Instance
@@ -0,0 +1,383 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/core/service/schedule"
+	"github.com/mysteriumnetwork/node/services"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+	"github.com/mysteriumnetwork/node/tequilapi/validation"
+)
+
+// swagger:model ServiceScheduleRequestDTO
+type serviceScheduleRequest struct {
+	// provider identity
+	// required: true
+	// example: 0x0000000000000000000000000000000000000002
+	ProviderID string `json:"provider_id"`
+
+	// service type. Possible values are "openvpn", "wireguard" and "noop"
+	// required: true
+	// example: wireguard
+	Type string `json:"type"`
+
+	// service options. Every service has a unique list of allowed options.
+	// required: false
+	// example: {"port": 1123, "protocol": "udp"}
+	Options map[string]interface{} `json:"options,omitempty"`
+
+	// access list which determines which identities will be able to receive the service
+	// required: false
+	AccessPolicies accessPoliciesRequest `json:"access_policies"`
+
+	// cron-like expression (minute hour day-of-month month day-of-week) of service start times
+	// required: true
+	// example: 0 9 * * 1-5
+	Start string `json:"start"`
+
+	// cron-like expression (minute hour day-of-month month day-of-week) of service stop times
+	// required: true
+	// example: 0 18 * * 1-5
+	Stop string `json:"stop"`
+
+	// time given to active sessions before the service is stopped. Configured default is used if empty.
+	// required: false
+	// example: 10m
+	GracePeriod string `json:"grace_period,omitempty"`
+}
+
+// swagger:model ServiceScheduleDTO
+type serviceSchedule struct {
+	// example: 6ba7b810-9dad-11d1-80b4-00c04fd430c8
+	ID string `json:"id"`
+
+	serviceScheduleRequest
+}
+
+// swagger:model ServiceScheduleListDTO
+type serviceScheduleList struct {
+	Schedules []serviceSchedule `json:"schedules"`
+}
+
+type serviceScheduler interface {
+	List() []schedule.Window
+	Window(id string) (schedule.Window, error)
+	Add(window schedule.Window) (schedule.Window, error)
+	Update(id string, window schedule.Window) (schedule.Window, error)
+	Delete(id string) error
+}
+
+type serviceScheduleEndpoint struct {
+	scheduler     serviceScheduler
+	optionsParser map[string]ServiceOptionsParser
+}
+
+// NewServiceScheduleEndpoint creates and returns service schedule endpoint
+func NewServiceScheduleEndpoint(scheduler serviceScheduler, optionsParser map[string]ServiceOptionsParser) *serviceScheduleEndpoint {
+	return &serviceScheduleEndpoint{
+		scheduler:     scheduler,
+		optionsParser: optionsParser,
+	}
+}
+
+// List provides a list of service availability windows.
+// swagger:operation GET /service-schedules Service listServiceSchedules
+// ---
+// summary: Returns service schedules
+// description: Returns windows during which services are started and registered for consumers
+// responses:
+//   200:
+//     description: List of service schedules
+//     schema:
+//       "$ref": "#/definitions/ServiceScheduleListDTO"
+func (endpoint *serviceScheduleEndpoint) List(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	windows := endpoint.scheduler.List()
+
+	res := serviceScheduleList{Schedules: make([]serviceSchedule, 0, len(windows))}
+	for _, window := range windows {
+		res.Schedules = append(res.Schedules, toServiceScheduleResponse(window))
+	}
+	utils.WriteAsJSON(res, resp)
+}
+
+// Get provides a single service availability window.
+// swagger:operation GET /service-schedules/{id} Service getServiceSchedule
+// ---
+// summary: Returns service schedule
+// description: Returns service schedule by its ID
+// parameters:
+//   - name: id
+//     in: path
+//     description: Schedule ID
+//     type: string
+//     required: true
+// responses:
+//   200:
+//     description: Service schedule
+//     schema:
+//       "$ref": "#/definitions/ServiceScheduleDTO"
+//   404:
+//     description: Schedule not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *serviceScheduleEndpoint) Get(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	window, err := endpoint.scheduler.Window(params.ByName("id"))
+	if err == schedule.ErrWindowNotFound {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	} else if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteAsJSON(toServiceScheduleResponse(window), resp)
+}
+
+// Create adds a new service availability window.
+// swagger:operation POST /service-schedules Service createServiceSchedule
+// ---
+// summary: Creates service schedule
+// description: Stores a new service schedule in config. Service is started right away if the window is active.
+// parameters:
+//   - in: body
+//     name: body
+//     description: Service and its availability window
+//     schema:
+//       $ref: "#/definitions/ServiceScheduleRequestDTO"
+// responses:
+//   201:
+//     description: Created service schedule
+//     schema:
+//       "$ref": "#/definitions/ServiceScheduleDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *serviceScheduleEndpoint) Create(resp http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	window, ok := endpoint.parseWindow(resp, req)
+	if !ok {
+		return
+	}
+
+	window, err := endpoint.scheduler.Add(window)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp.WriteHeader(http.StatusCreated)
+	utils.WriteAsJSON(toServiceScheduleResponse(window), resp)
+}
+
+// Update replaces a service availability window.
+// swagger:operation PUT /service-schedules/{id} Service updateServiceSchedule
+// ---
+// summary: Updates service schedule
+// description: Replaces service schedule in config. Service started for the old schedule is drained and started again if the window is active.
+// parameters:
+//   - name: id
+//     in: path
+//     description: Schedule ID
+//     type: string
+//     required: true
+//   - in: body
+//     name: body
+//     description: Service and its availability window
+//     schema:
+//       $ref: "#/definitions/ServiceScheduleRequestDTO"
+// responses:
+//   200:
+//     description: Updated service schedule
+//     schema:
+//       "$ref": "#/definitions/ServiceScheduleDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   404:
+//     description: Schedule not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *serviceScheduleEndpoint) Update(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	window, ok := endpoint.parseWindow(resp, req)
+	if !ok {
+		return
+	}
+
+	window, err := endpoint.scheduler.Update(params.ByName("id"), window)
+	if err == schedule.ErrWindowNotFound {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	} else if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteAsJSON(toServiceScheduleResponse(window), resp)
+}
+
+// Delete removes a service availability window.
+// swagger:operation DELETE /service-schedules/{id} Service deleteServiceSchedule
+// ---
+// summary: Deletes service schedule
+// description: Removes service schedule from config. Service started for it is drained.
+// parameters:
+//   - name: id
+//     in: path
+//     description: Schedule ID
+//     type: string
+//     required: true
+// responses:
+//   202:
+//     description: Service schedule deleted
+//   404:
+//     description: Schedule not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *serviceScheduleEndpoint) Delete(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	err := endpoint.scheduler.Delete(params.ByName("id"))
+	if err == schedule.ErrWindowNotFound {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	} else if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp.WriteHeader(http.StatusAccepted)
+}
+
+func (endpoint *serviceScheduleEndpoint) parseWindow(resp http.ResponseWriter, req *http.Request) (schedule.Window, bool) {
+	sr := serviceScheduleRequest{
+		AccessPolicies: accessPoliciesRequest{
+			Ids: services.SharedConfiguredOptions().AccessPolicyList,
+		},
+	}
+	decoder := json.NewDecoder(req.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&sr); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return schedule.Window{}, false
+	}
+
+	if errorMap := endpoint.validate(sr); errorMap.HasErrors() {
+		utils.SendValidationErrorMessage(resp, errorMap)
+		return schedule.Window{}, false
+	}
+
+	return schedule.Window{
+		ProviderID:     sr.ProviderID,
+		ServiceType:    sr.Type,
+		Options:        sr.Options,
+		AccessPolicies: sr.AccessPolicies.Ids,
+		Start:          sr.Start,
+		Stop:           sr.Stop,
+		GracePeriod:    sr.GracePeriod,
+	}, true
+}
+
+func (endpoint *serviceScheduleEndpoint) validate(sr serviceScheduleRequest) *validation.FieldErrorMap {
+	errors := validation.NewErrorMap()
+	if len(sr.ProviderID) == 0 {
+		errors.ForField("provider_id").AddError("required", "Field is required")
+	}
+	if sr.Type == "" {
+		errors.ForField("type").AddError("required", "Field is required")
+	} else if _, ok := endpoint.optionsParser[sr.Type]; !ok {
+		errors.ForField("type").AddError("invalid", "Invalid service type")
+	} else if !endpoint.validOptions(sr.Type, sr.Options) {
+		errors.ForField("options").AddError("invalid", "Invalid options")
+	}
+	if _, err := schedule.ParseExpression(sr.Start); err != nil {
+		errors.ForField("start").AddError("invalid", err.Error())
+	}
+	if _, err := schedule.ParseExpression(sr.Stop); err != nil {
+		errors.ForField("stop").AddError("invalid", err.Error())
+	}
+	if sr.GracePeriod != "" {
+		if d, err := time.ParseDuration(sr.GracePeriod); err != nil || d < 0 {
+			errors.ForField("grace_period").AddError("invalid", "Invalid duration")
+		}
+	}
+	return errors
+}
+
+func (endpoint *serviceScheduleEndpoint) validOptions(serviceType string, options map[string]interface{}) bool {
+	var raw *json.RawMessage
+	if options != nil {
+		data, err := json.Marshal(options)
+		if err != nil {
+			return false
+		}
+		msg := json.RawMessage(data)
+		raw = &msg
+	}
+
+	_, err := endpoint.optionsParser[serviceType](raw)
+	return err == nil
+}
+
+func toServiceScheduleResponse(window schedule.Window) serviceSchedule {
+	return serviceSchedule{
+		ID: window.ID,
+		serviceScheduleRequest: serviceScheduleRequest{
+			ProviderID:     window.ProviderID,
+			Type:           window.ServiceType,
+			Options:        window.Options,
+			AccessPolicies: accessPoliciesRequest{Ids: window.AccessPolicies},
+			Start:          window.Start,
+			Stop:           window.Stop,
+			GracePeriod:    window.GracePeriod,
+		},
+	}
+}
+
+// AddRoutesForServiceSchedule attaches service schedule endpoints to router
+func AddRoutesForServiceSchedule(router *httprouter.Router, scheduler serviceScheduler, optionsParser map[string]ServiceOptionsParser) {
+	endpoint := NewServiceScheduleEndpoint(scheduler, optionsParser)
+	router.GET("/service-schedules", endpoint.List)
+	router.POST("/service-schedules", endpoint.Create)
+	router.GET("/service-schedules/:id", endpoint.Get)
+	router.PUT("/service-schedules/:id", endpoint.Update)
+	router.DELETE("/service-schedules/:id", endpoint.Delete)
+}
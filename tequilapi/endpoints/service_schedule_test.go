@@ -0,0 +1,172 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/core/service/schedule"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockServiceScheduler struct {
+	windows []schedule.Window
+}
+
+func (m *mockServiceScheduler) List() []schedule.Window {
+	return m.windows
+}
+
+func (m *mockServiceScheduler) Window(id string) (schedule.Window, error) {
+	for _, w := range m.windows {
+		if w.ID == id {
+			return w, nil
+		}
+	}
+	return schedule.Window{}, schedule.ErrWindowNotFound
+}
+
+func (m *mockServiceScheduler) Add(window schedule.Window) (schedule.Window, error) {
+	window.ID = "new"
+	m.windows = append(m.windows, window)
+	return window, nil
+}
+
+func (m *mockServiceScheduler) Update(id string, window schedule.Window) (schedule.Window, error) {
+	for i := range m.windows {
+		if m.windows[i].ID == id {
+			window.ID = id
+			m.windows[i] = window
+			return window, nil
+		}
+	}
+	return schedule.Window{}, schedule.ErrWindowNotFound
+}
+
+func (m *mockServiceScheduler) Delete(id string) error {
+	for i := range m.windows {
+		if m.windows[i].ID == id {
+			m.windows = append(m.windows[:i], m.windows[i+1:]...)
+			return nil
+		}
+	}
+	return schedule.ErrWindowNotFound
+}
+
+func Test_ServiceScheduleEndpoints(t *testing.T) {
+	scheduler := &mockServiceScheduler{windows: []schedule.Window{{
+		ID:             "w1",
+		ProviderID:     "0x1",
+		ServiceType:    "testprotocol",
+		AccessPolicies: []string{"mysterium"},
+		Start:          "0 9 * * 1-5",
+		Stop:           "0 18 * * 1-5",
+	}}}
+	router := httprouter.New()
+	AddRoutesForServiceSchedule(router, scheduler, fakeOptionsParser)
+
+	tests := []struct {
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedJSON   string
+	}{
+		{
+			http.MethodGet, "/service-schedules", "", http.StatusOK,
+			`{"schedules": [{"id": "w1", "provider_id": "0x1", "type": "testprotocol", "access_policies": {"ids": ["mysterium"]}, "start": "0 9 * * 1-5", "stop": "0 18 * * 1-5"}]}`,
+		},
+		{
+			http.MethodGet, "/service-schedules/w1", "", http.StatusOK,
+			`{"id": "w1", "provider_id": "0x1", "type": "testprotocol", "access_policies": {"ids": ["mysterium"]}, "start": "0 9 * * 1-5", "stop": "0 18 * * 1-5"}`,
+		},
+		{
+			http.MethodGet, "/service-schedules/unknown", "", http.StatusNotFound, "",
+		},
+		{
+			http.MethodPost, "/service-schedules",
+			`{"provider_id": "0x2", "type": "testprotocol", "start": "0 22 * * *", "stop": "0 6 * * *", "grace_period": "10m"}`,
+			http.StatusCreated,
+			`{"id": "new", "provider_id": "0x2", "type": "testprotocol", "access_policies": {"ids": []}, "start": "0 22 * * *", "stop": "0 6 * * *", "grace_period": "10m"}`,
+		},
+		{
+			http.MethodPut, "/service-schedules/w1",
+			`{"provider_id": "0x1", "type": "testprotocol", "start": "0 8 * * *", "stop": "0 20 * * *"}`,
+			http.StatusOK,
+			`{"id": "w1", "provider_id": "0x1", "type": "testprotocol", "access_policies": {"ids": []}, "start": "0 8 * * *", "stop": "0 20 * * *"}`,
+		},
+		{
+			http.MethodPut, "/service-schedules/unknown",
+			`{"provider_id": "0x1", "type": "testprotocol", "start": "0 8 * * *", "stop": "0 20 * * *"}`,
+			http.StatusNotFound, "",
+		},
+		{
+			http.MethodDelete, "/service-schedules/w1", "", http.StatusAccepted, "",
+		},
+		{
+			http.MethodDelete, "/service-schedules/w1", "", http.StatusNotFound, "",
+		},
+	}
+
+	for _, test := range tests {
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, test.expectedStatus, resp.Code, "%s %s", test.method, test.path)
+		if test.expectedJSON != "" {
+			assert.JSONEq(t, test.expectedJSON, resp.Body.String(), "%s %s", test.method, test.path)
+		}
+	}
+	assert.Len(t, scheduler.windows, 1)
+}
+
+func Test_ServiceScheduleCreate_ValidatesRequest(t *testing.T) {
+	router := httprouter.New()
+	AddRoutesForServiceSchedule(router, &mockServiceScheduler{}, fakeOptionsParser)
+
+	tests := []struct {
+		body           string
+		expectedStatus int
+		invalidField   string
+	}{
+		{`{"provider_id": "0x1", "type": "testprotocol", "start": "0 9 * * *"`, http.StatusBadRequest, ""},
+		{`{"provider_id": "0x1", "type": "testprotocol", "start": "0 9 * * *", "stop": "0 18 * * *", "unknown": 1}`, http.StatusBadRequest, ""},
+		{`{"type": "testprotocol", "start": "0 9 * * *", "stop": "0 18 * * *"}`, http.StatusUnprocessableEntity, "provider_id"},
+		{`{"provider_id": "0x1", "type": "unknown", "start": "0 9 * * *", "stop": "0 18 * * *"}`, http.StatusUnprocessableEntity, "type"},
+		{`{"provider_id": "0x1", "type": "errorprotocol", "start": "0 9 * * *", "stop": "0 18 * * *"}`, http.StatusUnprocessableEntity, "options"},
+		{`{"provider_id": "0x1", "type": "testprotocol", "start": "9am", "stop": "0 18 * * *"}`, http.StatusUnprocessableEntity, "start"},
+		{`{"provider_id": "0x1", "type": "testprotocol", "start": "0 9 * * *", "stop": "0 24 * * *"}`, http.StatusUnprocessableEntity, "stop"},
+		{`{"provider_id": "0x1", "type": "testprotocol", "start": "0 9 * * *", "stop": "0 18 * * *", "grace_period": "a while"}`, http.StatusUnprocessableEntity, "grace_period"},
+	}
+
+	for _, test := range tests {
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/service-schedules", strings.NewReader(test.body))
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, test.expectedStatus, resp.Code, test.body)
+		if test.invalidField != "" {
+			assert.Contains(t, resp.Body.String(), `"`+test.invalidField+`"`, test.body)
+		}
+	}
+}
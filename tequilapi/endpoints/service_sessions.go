@@ -20,10 +20,14 @@ package endpoints
 import (
 	"net/http"
 	"sort"
+	"strconv"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	stateEvent "github.com/mysteriumnetwork/node/core/state/event"
+	"github.com/mysteriumnetwork/node/session/history"
 	"github.com/mysteriumnetwork/node/tequilapi/utils"
+	"github.com/pkg/errors"
 )
 
 // serviceSessionsList defines session list representable as json
@@ -32,18 +36,105 @@ type serviceSessionsList struct {
 	Sessions []stateEvent.ServiceSession `json:"sessions"`
 }
 
+// serviceSessionHistory defines finished sessions page with the totals of all matching sessions
+// swagger:model ServiceSessionHistoryDTO
+type serviceSessionHistory struct {
+	Sessions []serviceSessionRecord `json:"sessions"`
+
+	// number of sessions matching the filter
+	// example: 120
+	Total int `json:"total"`
+
+	Totals  sessionTotals         `json:"totals"`
+	Daily   []periodSessionTotals `json:"daily"`
+	Monthly []periodSessionTotals `json:"monthly"`
+}
+
+// serviceSessionRecord represents the finished provider session
+// swagger:model ServiceSessionRecordDTO
+type serviceSessionRecord struct {
+	// example: 4cfb0324-daf6-4ad8-448b-e61fe0a1f918
+	SessionID string `json:"session_id"`
+
+	// example: 0x0000000000000000000000000000000000000001
+	ConsumerID string `json:"consumer_id"`
+
+	// example: 6ba7b810-9dad-11d1-80b4-00c04fd430c8
+	ServiceID string `json:"service_id"`
+
+	// example: wireguard
+	ServiceType string `json:"service_type"`
+
+	// example: 2020-06-01T10:00:00Z
+	Started string `json:"started"`
+
+	// example: 2020-06-01T10:20:00Z
+	Ended string `json:"ended"`
+
+	// duration in seconds
+	// example: 1200
+	Duration uint64 `json:"duration"`
+
+	// example: 1024
+	BytesSent uint64 `json:"bytes_sent"`
+
+	// example: 1024
+	BytesReceived uint64 `json:"bytes_received"`
+
+	// example: 500000
+	TokensEarned uint64 `json:"tokens_earned"`
+
+	// example: consumer_request
+	TerminationReason string `json:"termination_reason"`
+}
+
+// sessionTotals represents the aggregated values of sessions
+// swagger:model SessionTotalsDTO
+type sessionTotals struct {
+	// example: 10
+	Sessions int `json:"sessions"`
+
+	// duration in seconds
+	// example: 12000
+	Duration uint64 `json:"duration"`
+
+	// example: 10240
+	BytesSent uint64 `json:"bytes_sent"`
+
+	// example: 10240
+	BytesReceived uint64 `json:"bytes_received"`
+
+	// example: 5000000
+	TokensEarned uint64 `json:"tokens_earned"`
+}
+
+// periodSessionTotals represents the aggregated values of sessions started within the day or month
+// swagger:model PeriodSessionTotalsDTO
+type periodSessionTotals struct {
+	// example: 2020-06-01
+	Period string `json:"period"`
+
+	sessionTotals
+}
+
 type stateStorage interface {
 	GetState() stateEvent.State
 }
 
+type sessionHistoryStorage interface {
+	Query(filter history.Filter) (history.Result, error)
+}
+
 type serviceSessionsEndpoint struct {
-	stateStorage stateStorage
+	stateStorage   stateStorage
+	historyStorage sessionHistoryStorage
 }
 
 // NewServiceSessionsEndpoint creates and returns sessions endpoint
-func NewServiceSessionsEndpoint(stateStorage stateStorage) *serviceSessionsEndpoint {
+func NewServiceSessionsEndpoint(stateStorage stateStorage, historyStorage sessionHistoryStorage) *serviceSessionsEndpoint {
 	return &serviceSessionsEndpoint{
-		stateStorage: stateStorage,
+		stateStorage:   stateStorage,
+		historyStorage: historyStorage,
 	}
 }
 
@@ -67,8 +158,171 @@ func (endpoint *serviceSessionsEndpoint) List(resp http.ResponseWriter, request
 	utils.WriteAsJSON(sessionsSerializable, resp)
 }
 
+// swagger:operation GET /service-sessions/history Service serviceSessionHistory
+// ---
+// summary: Returns finished sessions history
+// description: Returns finished provider sessions, newest first, with daily and monthly totals of all matching sessions
+// parameters:
+//   - in: query
+//     name: date_from
+//     description: Include sessions started on or after the date, formatted as 2006-01-02
+//     type: string
+//   - in: query
+//     name: date_to
+//     description: Include sessions started on or before the date, formatted as 2006-01-02
+//     type: string
+//   - in: query
+//     name: consumer_id
+//     description: Consumer identity to filter the sessions by
+//     type: string
+//   - in: query
+//     name: service_type
+//     description: Service type to filter the sessions by
+//     type: string
+//   - in: query
+//     name: limit
+//     description: Maximum number of sessions to return
+//     type: integer
+//   - in: query
+//     name: offset
+//     description: Number of sessions to skip
+//     type: integer
+// responses:
+//   200:
+//     description: Sessions history
+//     schema:
+//       "$ref": "#/definitions/ServiceSessionHistoryDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *serviceSessionsEndpoint) History(resp http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	filter, err := parseSessionHistoryFilter(request)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	result, err := endpoint.historyStorage.Query(filter)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteAsJSON(mapSessionHistory(result), resp)
+}
+
+func parseSessionHistoryFilter(request *http.Request) (history.Filter, error) {
+	query := request.URL.Query()
+	filter := history.Filter{
+		ConsumerID:  query.Get("consumer_id"),
+		ServiceType: query.Get("service_type"),
+	}
+
+	var err error
+	if filter.From, filter.To, err = parseDateRange(request); err != nil {
+		return filter, err
+	}
+	if filter.Limit, err = parseNonNegativeInt(query.Get("limit")); err != nil {
+		return filter, errors.Wrap(err, "invalid limit")
+	}
+	if filter.Offset, err = parseNonNegativeInt(query.Get("offset")); err != nil {
+		return filter, errors.Wrap(err, "invalid offset")
+	}
+	return filter, nil
+}
+
+// parseDateRange parses inclusive date_from and date_to query parameters into the [from, to) time range.
+func parseDateRange(request *http.Request) (from, to *time.Time, err error) {
+	if from, err = parseDate(request.URL.Query().Get("date_from")); err != nil {
+		return nil, nil, errors.Wrap(err, "invalid date_from")
+	}
+	if to, err = parseDate(request.URL.Query().Get("date_to")); err != nil {
+		return nil, nil, errors.Wrap(err, "invalid date_to")
+	}
+	if to != nil {
+		nextDay := to.AddDate(0, 0, 1)
+		to = &nextDay
+	}
+	return from, to, nil
+}
+
+func parseDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+func parseNonNegativeInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	res, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if res < 0 {
+		return 0, errors.New("must not be negative")
+	}
+	return res, nil
+}
+
+func mapSessionHistory(result history.Result) serviceSessionHistory {
+	res := serviceSessionHistory{
+		Sessions: make([]serviceSessionRecord, len(result.Records)),
+		Total:    result.Total,
+		Totals:   mapSessionTotals(result.Totals),
+		Daily:    mapPeriodSessionTotals(result.Daily),
+		Monthly:  mapPeriodSessionTotals(result.Monthly),
+	}
+	for i, r := range result.Records {
+		res.Sessions[i] = serviceSessionRecord{
+			SessionID:         r.SessionID,
+			ConsumerID:        r.ConsumerID.Address,
+			ServiceID:         r.ServiceID,
+			ServiceType:       r.ServiceType,
+			Started:           r.Started.Format(time.RFC3339),
+			Ended:             r.Ended.Format(time.RFC3339),
+			Duration:          uint64(r.Duration().Seconds()),
+			BytesSent:         r.BytesSent,
+			BytesReceived:     r.BytesReceived,
+			TokensEarned:      r.TokensEarned,
+			TerminationReason: r.Reason,
+		}
+	}
+	return res
+}
+
+func mapSessionTotals(totals history.Totals) sessionTotals {
+	return sessionTotals{
+		Sessions:      totals.Sessions,
+		Duration:      uint64(totals.Duration.Seconds()),
+		BytesSent:     totals.BytesSent,
+		BytesReceived: totals.BytesReceived,
+		TokensEarned:  totals.TokensEarned,
+	}
+}
+
+func mapPeriodSessionTotals(periods []history.PeriodTotals) []periodSessionTotals {
+	res := make([]periodSessionTotals, len(periods))
+	for i, p := range periods {
+		res[i] = periodSessionTotals{Period: p.Period, sessionTotals: mapSessionTotals(p.Totals)}
+	}
+	return res
+}
+
 // AddRoutesForServiceSessions attaches service sessions endpoints to router
-func AddRoutesForServiceSessions(router *httprouter.Router, stateStorage stateStorage) {
-	sessionsEndpoint := NewServiceSessionsEndpoint(stateStorage)
+func AddRoutesForServiceSessions(router *httprouter.Router, stateStorage stateStorage, historyStorage sessionHistoryStorage) {
+	sessionsEndpoint := NewServiceSessionsEndpoint(stateStorage, historyStorage)
 	router.GET("/service-sessions", sessionsEndpoint.List)
+	router.GET("/service-sessions/history", sessionsEndpoint.History)
 }
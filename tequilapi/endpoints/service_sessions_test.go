@@ -25,6 +25,8 @@ import (
 	"time"
 
 	stateEvent "github.com/mysteriumnetwork/node/core/state/event"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/history"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -60,7 +62,7 @@ func Test_ServiceSessionsEndpoint_List(t *testing.T) {
 	}
 
 	resp := httptest.NewRecorder()
-	handlerFunc := NewServiceSessionsEndpoint(ssm).List
+	handlerFunc := NewServiceSessionsEndpoint(ssm, &mockSessionHistoryStorage{}).List
 	handlerFunc(resp, req, nil)
 
 	parsedResponse := &serviceSessionsList{}
@@ -83,3 +85,74 @@ type stateProviderMock struct {
 func (spm *stateProviderMock) GetState() stateEvent.State {
 	return spm.stateToReturn
 }
+
+type mockSessionHistoryStorage struct {
+	result history.Result
+	filter history.Filter
+}
+
+func (m *mockSessionHistoryStorage) Query(filter history.Filter) (history.Result, error) {
+	m.filter = filter
+	return m.result, nil
+}
+
+func Test_ServiceSessionsEndpoint_History(t *testing.T) {
+	started := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	totals := history.Totals{Sessions: 1, Duration: time.Minute, BytesSent: 100, BytesReceived: 10, TokensEarned: 5}
+	storage := &mockSessionHistoryStorage{result: history.Result{
+		Records: []history.Record{{
+			SessionID:     "session1",
+			ConsumerID:    identity.FromAddress("0x1"),
+			ServiceID:     "service1",
+			ServiceType:   "wireguard",
+			Started:       started,
+			Ended:         started.Add(time.Minute),
+			BytesSent:     100,
+			BytesReceived: 10,
+			TokensEarned:  5,
+			Reason:        "consumer_request",
+		}},
+		Total:   3,
+		Totals:  totals,
+		Daily:   []history.PeriodTotals{{Period: "2020-06-01", Totals: totals}},
+		Monthly: []history.PeriodTotals{{Period: "2020-06", Totals: totals}},
+	}}
+
+	req := httptest.NewRequest(http.MethodGet, "/irrelevant?date_from=2020-06-01&date_to=2020-06-30&consumer_id=0x1&service_type=wireguard&limit=1&offset=2", nil)
+	resp := httptest.NewRecorder()
+	NewServiceSessionsEndpoint(&stateProviderMock{}, storage).History(resp, req, nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	from := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, history.Filter{From: &from, To: &to, ConsumerID: "0x1", ServiceType: "wireguard", Limit: 1, Offset: 2}, storage.filter)
+	assert.JSONEq(t, `{
+		"sessions": [{
+			"session_id": "session1",
+			"consumer_id": "0x1",
+			"service_id": "service1",
+			"service_type": "wireguard",
+			"started": "2020-06-01T10:00:00Z",
+			"ended": "2020-06-01T10:01:00Z",
+			"duration": 60,
+			"bytes_sent": 100,
+			"bytes_received": 10,
+			"tokens_earned": 5,
+			"termination_reason": "consumer_request"
+		}],
+		"total": 3,
+		"totals": {"sessions": 1, "duration": 60, "bytes_sent": 100, "bytes_received": 10, "tokens_earned": 5},
+		"daily": [{"period": "2020-06-01", "sessions": 1, "duration": 60, "bytes_sent": 100, "bytes_received": 10, "tokens_earned": 5}],
+		"monthly": [{"period": "2020-06", "sessions": 1, "duration": 60, "bytes_sent": 100, "bytes_received": 10, "tokens_earned": 5}]
+	}`, resp.Body.String())
+}
+
+func Test_ServiceSessionsEndpoint_HistoryValidatesFilter(t *testing.T) {
+	for _, query := range []string{"date_from=yesterday", "date_to=2020-13-01", "limit=-1", "offset=abc"} {
+		req := httptest.NewRequest(http.MethodGet, "/irrelevant?"+query, nil)
+		resp := httptest.NewRecorder()
+		NewServiceSessionsEndpoint(&stateProviderMock{}, &mockSessionHistoryStorage{}).History(resp, req, nil)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code, query)
+	}
+}
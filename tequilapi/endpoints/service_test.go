@@ -25,9 +25,11 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/core/service/restore"
 	"github.com/mysteriumnetwork/node/core/service/servicestate"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
@@ -87,7 +89,10 @@ type fancyServiceOptions struct {
 	Foo string `json:"foo"`
 }
 
-type mockServiceManager struct{}
+type mockServiceManager struct {
+	drainedID      service.ID
+	drainedTimeout time.Duration
+}
 
 func (sm *mockServiceManager) Start(providerID identity.Identity, serviceType string, policyIDs []string, options service.Options) (service.ID, error) {
 	if serviceType == serviceTypeWithAccessPolicy {
@@ -96,6 +101,11 @@ func (sm *mockServiceManager) Start(providerID identity.Identity, serviceType st
 	return mockServiceID, nil
 }
 func (sm *mockServiceManager) Stop(id service.ID) error { return nil }
+func (sm *mockServiceManager) Drain(id service.ID, timeout time.Duration) error {
+	sm.drainedID = id
+	sm.drainedTimeout = timeout
+	return nil
+}
 func (sm *mockServiceManager) Service(id service.ID) *service.Instance {
 	if id == "6ba7b810-9dad-11d1-80b4-00c04fd430c8" {
 		return mockServiceRunning
@@ -112,6 +122,32 @@ func (sm *mockServiceManager) List() map[service.ID]*service.Instance {
 }
 func (sm *mockServiceManager) Kill() error { return nil }
 
+type mockServiceRestorer struct {
+	manager   ServiceManager
+	saved     []restore.Definition
+	forgotten []string
+}
+
+func (mr *mockServiceRestorer) Start(definition restore.Definition, options service.Options) (service.ID, error) {
+	for id, instance := range mr.manager.List() {
+		proposal := instance.Proposal()
+		if strings.EqualFold(proposal.ProviderID, definition.ProviderID) && proposal.ServiceType == definition.ServiceType {
+			return id, restore.ErrAlreadyRunning
+		}
+	}
+	id, err := mr.manager.Start(identity.FromAddress(definition.ProviderID), definition.ServiceType, definition.AccessPolicies, options)
+	if err != nil {
+		return id, err
+	}
+	mr.saved = append(mr.saved, definition)
+	return id, nil
+}
+
+func (mr *mockServiceRestorer) Forget(providerID, serviceType string) error {
+	mr.forgotten = append(mr.forgotten, providerID+"."+serviceType)
+	return nil
+}
+
 var fakeOptionsParser = map[string]ServiceOptionsParser{
 	"testprotocol": func(opts *json.RawMessage) (service.Options, error) {
 		return nil, nil
@@ -126,7 +162,7 @@ var fakeOptionsParser = map[string]ServiceOptionsParser{
 
 func Test_AddRoutesForServiceAddsRoutes(t *testing.T) {
 	router := httprouter.New()
-	AddRoutesForService(router, &mockServiceManager{}, fakeOptionsParser)
+	AddRoutesForService(router, &mockServiceManager{}, fakeOptionsParser, &mockServiceRestorer{manager: &mockServiceManager{}})
 
 	tests := []struct {
 		method         string
@@ -167,6 +203,41 @@ func Test_AddRoutesForServiceAddsRoutes(t *testing.T) {
 				}
 			}]`,
 		},
+		{
+			http.MethodGet,
+			"/services?group_by=provider",
+			"",
+			http.StatusOK,
+			`[{
+				"provider_id": "0xproviderid",
+				"services": [{
+					"id": "11111111-9dad-11d1-80b4-00c04fd430c0",
+					"provider_id": "0xProviderId",
+					"type": "testprotocol",
+					"options": {"foo": "bar"},
+					"status": "NotRunning",
+					"proposal": {
+						"id": 1,
+						"provider_id": "0xProviderId",
+						"service_type": "testprotocol",
+						"service_definition": {
+							"location_originate": {"asn": 123, "country": "Lithuania", "city": "Vilnius"}
+						},
+						"payment_method": {
+							"type": "BYTES_TRANSFERRED_WITH_TIME",
+							"price": {
+								"amount":50000,
+								"currency":"MYST"
+							},
+							"rate":{
+								"per_seconds":60,
+								"per_bytes":7669584
+							}
+						}
+					}
+				}]
+			}]`,
+		},
 		{
 			http.MethodPost,
 			"/services",
@@ -256,7 +327,7 @@ func Test_AddRoutesForServiceAddsRoutes(t *testing.T) {
 }
 
 func Test_ServiceStartInvalidType(t *testing.T) {
-	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser)
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser, &mockServiceRestorer{manager: &mockServiceManager{}})
 
 	req := httptest.NewRequest(
 		http.MethodGet,
@@ -285,7 +356,7 @@ func Test_ServiceStartInvalidType(t *testing.T) {
 }
 
 func Test_ServiceStart_InvalidType(t *testing.T) {
-	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser)
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser, &mockServiceRestorer{manager: &mockServiceManager{}})
 
 	req := httptest.NewRequest(
 		http.MethodGet,
@@ -314,7 +385,7 @@ func Test_ServiceStart_InvalidType(t *testing.T) {
 }
 
 func Test_ServiceStart_InvalidOptions(t *testing.T) {
-	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser)
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser, &mockServiceRestorer{manager: &mockServiceManager{}})
 
 	req := httptest.NewRequest(
 		http.MethodGet,
@@ -343,7 +414,7 @@ func Test_ServiceStart_InvalidOptions(t *testing.T) {
 }
 
 func Test_ServiceStartAlreadyRunning(t *testing.T) {
-	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser)
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser, &mockServiceRestorer{manager: &mockServiceManager{}})
 
 	req := httptest.NewRequest(
 		http.MethodGet,
@@ -367,7 +438,7 @@ func Test_ServiceStartAlreadyRunning(t *testing.T) {
 }
 
 func Test_ServiceStatus_NotFoundIsReturnedWhenNotStarted(t *testing.T) {
-	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser)
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser, &mockServiceRestorer{manager: &mockServiceManager{}})
 
 	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
@@ -377,8 +448,63 @@ func Test_ServiceStatus_NotFoundIsReturnedWhenNotStarted(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, resp.Code)
 }
 
+func Test_ServiceDrain(t *testing.T) {
+	manager := &mockServiceManager{}
+	router := httprouter.New()
+	AddRoutesForService(router, manager, fakeOptionsParser, &mockServiceRestorer{manager: manager})
+
+	req := httptest.NewRequest(http.MethodPost, "/services/"+string(mockServiceID)+"/drain", strings.NewReader(`{"timeout": "15m"}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Equal(t, "application/json; charset=utf-8", resp.Header().Get("Content-Type"))
+	assert.Equal(t, mockServiceID, manager.drainedID)
+	assert.Equal(t, 15*time.Minute, manager.drainedTimeout)
+
+	parsedResponse := serviceInfo{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &parsedResponse))
+	assert.Equal(t, string(mockServiceID), parsedResponse.ID)
+}
+
+func Test_ServiceDrain_WithoutBody(t *testing.T) {
+	manager := &mockServiceManager{}
+	router := httprouter.New()
+	AddRoutesForService(router, manager, fakeOptionsParser, &mockServiceRestorer{manager: manager})
+
+	req := httptest.NewRequest(http.MethodPost, "/services/"+string(mockServiceID)+"/drain", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Equal(t, mockServiceID, manager.drainedID)
+}
+
+func Test_ServiceDrain_Errors(t *testing.T) {
+	router := httprouter.New()
+	AddRoutesForService(router, &mockServiceManager{}, fakeOptionsParser, &mockServiceRestorer{manager: &mockServiceManager{}})
+
+	tests := []struct {
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"/services/unknown/drain", "", http.StatusNotFound},
+		{"/services/" + string(mockServiceID) + "/drain", `{"timeout": "later"}`, http.StatusBadRequest},
+		{"/services/" + string(mockServiceID) + "/drain", `{"timeout": "-1m"}`, http.StatusBadRequest},
+		{"/services/" + string(mockServiceID) + "/drain", `{"deadline": "1m"}`, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, test.path, strings.NewReader(test.body))
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, test.expectedStatus, resp.Code, "%s %s", test.path, test.body)
+	}
+}
+
 func Test_ServiceGetReturnsServiceInfo(t *testing.T) {
-	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser)
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser, &mockServiceRestorer{manager: &mockServiceManager{}})
 
 	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
@@ -422,7 +548,7 @@ func Test_ServiceGetReturnsServiceInfo(t *testing.T) {
 	)
 }
 func Test_ServiceCreate_Returns400ErrorIfRequestBodyIsNotJSON(t *testing.T) {
-	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser)
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser, &mockServiceRestorer{manager: &mockServiceManager{}})
 
 	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader("a"))
 	resp := httptest.NewRecorder()
@@ -440,7 +566,7 @@ func Test_ServiceCreate_Returns400ErrorIfRequestBodyIsNotJSON(t *testing.T) {
 }
 
 func Test_ServiceCreate_Returns422ErrorIfRequestBodyIsMissingFieldValues(t *testing.T) {
-	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser)
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser, &mockServiceRestorer{manager: &mockServiceManager{}})
 
 	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader("{}"))
 	resp := httptest.NewRecorder()
@@ -461,7 +587,7 @@ func Test_ServiceCreate_Returns422ErrorIfRequestBodyIsMissingFieldValues(t *test
 }
 
 func Test_ServiceStart_WithAccessPolicy(t *testing.T) {
-	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser)
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser, &mockServiceRestorer{manager: &mockServiceManager{}})
 
 	req := httptest.NewRequest(
 		http.MethodGet,
@@ -530,7 +656,7 @@ func Test_ServiceStart_WithAccessPolicy(t *testing.T) {
 }
 
 func Test_ServiceStart_ReturnsBadRequest_WithUnknownParams(t *testing.T) {
-	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser)
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser, &mockServiceRestorer{manager: &mockServiceManager{}})
 
 	req := httptest.NewRequest(
 		http.MethodGet,
@@ -554,3 +680,79 @@ func Test_ServiceStart_ReturnsBadRequest_WithUnknownParams(t *testing.T) {
 		resp.Body.String(),
 	)
 }
+
+func Test_ServiceStart_StoresDefinition(t *testing.T) {
+	restorer := &mockServiceRestorer{manager: &mockServiceManager{}}
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser, restorer)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/irrelevant",
+		strings.NewReader(`{
+			"type": "testprotocol",
+			"provider_id": "0x9EDF75F870D87D2D1A69F0D950A99984AE955EE0",
+			"options": {"port": 1123},
+			"access_policies": {"ids": ["verified-traffic"]}
+		}`),
+	)
+	resp := httptest.NewRecorder()
+
+	serviceEndpoint.ServiceStart(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Len(t, restorer.saved, 1)
+	definition := restorer.saved[0]
+	assert.Equal(t, "0x9edf75f870d87d2d1a69f0d950a99984ae955ee0", definition.ProviderID)
+	assert.Equal(t, "testprotocol", definition.ServiceType)
+	assert.Equal(t, []string{"verified-traffic"}, definition.AccessPolicies)
+	assert.JSONEq(t, `{"port": 1123}`, string(*definition.Options))
+}
+
+func Test_ServiceStop_DeletesDefinition(t *testing.T) {
+	restorer := &mockServiceRestorer{manager: &mockServiceManager{}}
+	router := httprouter.New()
+	AddRoutesForService(router, &mockServiceManager{}, fakeOptionsParser, restorer)
+
+	req := httptest.NewRequest(http.MethodDelete, "/services/"+string(mockServiceID), nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Equal(t, []string{"0xProviderId.testprotocol"}, restorer.forgotten)
+}
+
+func Test_ServiceList_GroupsByProvider(t *testing.T) {
+	proposalOf := func(providerID string) market.ServiceProposal {
+		proposal := mockProposal
+		proposal.ProviderID = providerID
+		return proposal
+	}
+	instances := map[service.ID]*service.Instance{
+		"3": service.NewInstance(mockServiceOptions, servicestate.Running, nil, proposalOf("0x0000000000000000000000000000000000000002"), nil, nil, nil),
+		"1": service.NewInstance(mockServiceOptions, servicestate.Running, nil, proposalOf("0x0000000000000000000000000000000000000002"), nil, nil, nil),
+		"2": service.NewInstance(mockServiceOptions, servicestate.Running, nil, proposalOf("0x0000000000000000000000000000000000000001"), nil, nil, nil),
+	}
+
+	list := toServiceGroupListResponse(instances)
+
+	assert.Len(t, list, 2)
+	assert.Equal(t, "0x0000000000000000000000000000000000000001", list[0].ProviderID)
+	assert.Len(t, list[0].Services, 1)
+	assert.Equal(t, "2", list[0].Services[0].ID)
+	assert.Equal(t, "0x0000000000000000000000000000000000000002", list[1].ProviderID)
+	assert.Len(t, list[1].Services, 2)
+	assert.Equal(t, "1", list[1].Services[0].ID)
+	assert.Equal(t, "3", list[1].Services[1].ID)
+}
+
+func Test_ServiceList_Returns400ErrorIfGroupByIsUnsupported(t *testing.T) {
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser, &mockServiceRestorer{manager: &mockServiceManager{}})
+
+	req := httptest.NewRequest(http.MethodGet, "/services?group_by=type", nil)
+	resp := httptest.NewRecorder()
+
+	serviceEndpoint.ServiceList(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.JSONEq(t, `{"message": "Unsupported group_by value: type"}`, resp.Body.String())
+}
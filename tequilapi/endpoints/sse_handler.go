@@ -21,14 +21,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/discovery"
 	nodeEvent "github.com/mysteriumnetwork/node/core/node/event"
+	"github.com/mysteriumnetwork/node/core/service/servicestate"
 	stateEvent "github.com/mysteriumnetwork/node/core/state/event"
 	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/identity/registry"
+	"github.com/mysteriumnetwork/node/market"
+	natEvent "github.com/mysteriumnetwork/node/nat/event"
+	"github.com/mysteriumnetwork/node/session/pingpong"
 	"github.com/mysteriumnetwork/node/tequilapi/contract"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
@@ -49,14 +58,73 @@ const (
 	ServiceStatusEvent EventType = "service-status"
 	// StateChangeEvent represents the state change
 	StateChangeEvent EventType = "state-change"
+	// ConnectionStateEvent represents the consumer connection state change
+	ConnectionStateEvent EventType = "connection-state"
+	// SessionStatisticsEvent represents the consumer session statistics update
+	SessionStatisticsEvent EventType = "session-statistics"
+	// BalanceChangeEvent represents the identity balance change
+	BalanceChangeEvent EventType = "balance-change"
+	// SettlementEvent represents the settlement with accountant
+	SettlementEvent EventType = "settlement"
+	// ProposalAddedEvent represents the newly discovered proposal
+	ProposalAddedEvent EventType = "proposal-added"
+	// ProposalRemovedEvent represents the proposal which is no longer available
+	ProposalRemovedEvent EventType = "proposal-removed"
+	// RegistrationEvent represents the identity registration status change
+	RegistrationEvent EventType = "registration"
 )
 
+var eventTypes = []EventType{
+	NATEvent,
+	ServiceStatusEvent,
+	StateChangeEvent,
+	ConnectionStateEvent,
+	SessionStatisticsEvent,
+	BalanceChangeEvent,
+	SettlementEvent,
+	ProposalAddedEvent,
+	ProposalRemovedEvent,
+	RegistrationEvent,
+}
+
+// eventHistorySize is the number of last events kept for the replay to reconnecting clients.
+const eventHistorySize = 100
+
+// clientBufferSize is the number of messages buffered for a client, it fits the whole replay.
+// Clients which do not keep up are disconnected, they may reconnect with the Last-Event-ID to catch up.
+const clientBufferSize = eventHistorySize + 20
+
+// message is a marshaled event with its sequence number.
+type message struct {
+	id        uint64
+	eventType EventType
+	data      string
+}
+
+// client is a single sse subscriber.
+type client struct {
+	messages    chan message
+	types       map[EventType]struct{}
+	lastEventID uint64
+	initial     *message
+}
+
+func (c *client) wants(eventType EventType) bool {
+	if len(c.types) == 0 {
+		return true
+	}
+	_, ok := c.types[eventType]
+	return ok
+}
+
 // Handler represents an sse handler
 type Handler struct {
-	clients       map[chan string]struct{}
-	newClients    chan chan string
-	deadClients   chan chan string
-	messages      chan string
+	clients       map[*client]struct{}
+	newClients    chan *client
+	deadClients   chan *client
+	messages      chan message
+	history       []message
+	lastID        uint64
 	stopOnce      sync.Once
 	stopChan      chan struct{}
 	stateProvider stateProvider
@@ -69,10 +137,11 @@ type stateProvider interface {
 // NewSSEHandler returns a new instance of handler
 func NewSSEHandler(stateProvider stateProvider) *Handler {
 	return &Handler{
-		clients:       make(map[chan string]struct{}),
-		newClients:    make(chan (chan string)),
-		deadClients:   make(chan (chan string)),
-		messages:      make(chan string, 20),
+		clients:       make(map[*client]struct{}),
+		newClients:    make(chan *client),
+		deadClients:   make(chan *client),
+		messages:      make(chan message, 20),
+		history:       make([]message, 0, eventHistorySize),
 		stopChan:      make(chan struct{}),
 		stateProvider: stateProvider,
 	}
@@ -80,15 +149,52 @@ func NewSSEHandler(stateProvider stateProvider) *Handler {
 
 // Subscribe subscribes to the event bus.
 func (h *Handler) Subscribe(bus eventbus.Subscriber) error {
-	err := bus.Subscribe(nodeEvent.AppTopicNode, h.ConsumeNodeEvent)
-	if err != nil {
-		return err
+	subscriptions := map[string]interface{}{
+		nodeEvent.AppTopicNode:                     h.ConsumeNodeEvent,
+		stateEvent.AppTopicState:                   h.ConsumeStateEvent,
+		natEvent.AppTopicTraversal:                 h.ConsumeNATEvent,
+		servicestate.AppTopicServiceStatus:         h.ConsumeServiceStatusEvent,
+		connection.AppTopicConsumerConnectionState: h.ConsumeConnectionStateEvent,
+		connection.AppTopicConsumerStatistics:      h.ConsumeStatisticsEvent,
+		pingpong.AppTopicBalanceChanged:            h.ConsumeBalanceChangeEvent,
+		pingpong.AppTopicSettlement:                h.ConsumeSettlementEvent,
+		discovery.AppTopicProposalAdded:            h.ConsumeProposalAddedEvent,
+		discovery.AppTopicProposalRemoved:          h.ConsumeProposalRemovedEvent,
+		registry.AppTopicIdentityRegistration:      h.ConsumeRegistrationEvent,
+	}
+	for topic, fn := range subscriptions {
+		if err := bus.SubscribeAsync(topic, fn); err != nil {
+			return err
+		}
 	}
-	err = bus.Subscribe(stateEvent.AppTopicState, h.ConsumeStateEvent)
-	return err
+	return nil
 }
 
 // Sub subscribes a user to sse
+// swagger:operation GET /events/state Events subscribeEvents
+// ---
+// summary: Streams node events
+// description: Streams node events as server-sent events. Every event carries a monotonically increasing id,
+//   reconnecting clients may pass it in the Last-Event-ID header to receive the events they missed.
+//   The initial state is sent first, unless the missed events are replayed.
+// produces:
+// - text/event-stream
+// parameters:
+//   - in: query
+//     name: types
+//     description: Comma separated event types to stream, all types are streamed if omitted
+//     type: string
+//   - in: header
+//     name: Last-Event-ID
+//     description: Id of the last received event
+//     type: integer
+// responses:
+//   200:
+//     description: Event stream
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
 func (h *Handler) Sub(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
 	f, ok := resp.(http.Flusher)
 	if !ok {
@@ -101,65 +207,117 @@ func (h *Handler) Sub(resp http.ResponseWriter, req *http.Request, params httpro
 		return
 	}
 
+	c, err := h.newClient(req)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
 	resp.Header().Set("Content-Type", "text/event-stream")
 	resp.Header().Set("Cache-Control", "no-cache,no-transform")
 	resp.Header().Set("Connection", "keep-alive")
 
-	messageChan := make(chan string, 1)
-	err := h.sendInitialState(messageChan)
-	if err != nil {
-		resp.WriteHeader(http.StatusBadRequest)
-		resp.Header().Set("Content-type", "application/json; charset=utf-8")
-		writeErr := json.NewEncoder(resp).Encode(err)
-		if writeErr != nil {
-			http.Error(resp, "Http response write error", http.StatusInternalServerError)
-		}
+	select {
+	case h.newClients <- c:
+	case <-req.Context().Done():
+		return
+	case <-h.stopChan:
+		return
 	}
-
-	h.newClients <- messageChan
-
-	go func() {
-		<-req.Context().Done()
-		h.deadClients <- messageChan
+	defer func() {
+		select {
+		case h.deadClients <- c:
+		case <-h.stopChan:
+		}
 	}()
 
 	for {
 		select {
-		case msg, open := <-messageChan:
+		case msg, open := <-c.messages:
 			if !open {
 				return
 			}
 
-			_, err := fmt.Fprintf(resp, "data: %s\n\n", msg)
+			_, err := fmt.Fprint(resp, formatMessage(msg))
 			if err != nil {
 				log.Error().Err(err).Msg("")
 				return
 			}
 
 			f.Flush()
+		case <-req.Context().Done():
+			return
 		case <-h.stopChan:
 			return
 		}
 	}
 }
 
-func (h *Handler) sendInitialState(messageChan chan string) error {
-	res, err := json.Marshal(Event{
-		Type:    StateChangeEvent,
-		Payload: mapState(h.stateProvider.GetState()),
-	})
+func (h *Handler) newClient(req *http.Request) (*client, error) {
+	c := &client{
+		messages: make(chan message, clientBufferSize),
+		types:    make(map[EventType]struct{}),
+	}
+
+	for _, value := range req.URL.Query()["types"] {
+		for _, t := range strings.Split(value, ",") {
+			eventType := EventType(strings.TrimSpace(t))
+			if !isKnownEventType(eventType) {
+				return nil, fmt.Errorf("unknown event type %q", eventType)
+			}
+			c.types[eventType] = struct{}{}
+		}
+	}
+
+	if lastEventID := req.Header.Get("Last-Event-ID"); lastEventID != "" {
+		id, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid Last-Event-ID")
+		}
+		c.lastEventID = id
+	}
+
+	if c.wants(StateChangeEvent) {
+		msg, err := newMessage(Event{
+			Type:    StateChangeEvent,
+			Payload: mapState(h.stateProvider.GetState()),
+		})
+		if err != nil {
+			return nil, err
+		}
+		c.initial = &msg
+	}
+	return c, nil
+}
+
+func isKnownEventType(eventType EventType) bool {
+	for _, t := range eventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func newMessage(e Event) (message, error) {
+	marshaled, err := json.Marshal(e)
 	if err != nil {
-		return err
+		return message{}, err
 	}
+	return message{eventType: e.Type, data: string(marshaled)}, nil
+}
 
-	messageChan <- string(res)
-	return nil
+func formatMessage(msg message) string {
+	if msg.id == 0 {
+		return fmt.Sprintf("data: %s\n\n", msg.data)
+	}
+	return fmt.Sprintf("id: %d\ndata: %s\n\n", msg.id, msg.data)
 }
 
 func (h *Handler) serve() {
 	defer func() {
 		for k := range h.clients {
-			close(k)
+			close(k.messages)
 		}
 	}()
 
@@ -167,30 +325,100 @@ func (h *Handler) serve() {
 		select {
 		case <-h.stopChan:
 			return
-		case s := <-h.newClients:
-			h.clients[s] = struct{}{}
-		case s := <-h.deadClients:
-			delete(h.clients, s)
-			close(s)
+		case c := <-h.newClients:
+			h.register(c)
+		case c := <-h.deadClients:
+			h.unregister(c)
 		case msg := <-h.messages:
-			for s := range h.clients {
-				s <- msg
-			}
+			h.broadcast(msg)
+		}
+	}
+}
+
+// broadcast numbers the message, keeps it for the replay and sends it to the interested clients.
+func (h *Handler) broadcast(msg message) {
+	h.lastID++
+	msg.id = h.lastID
+	h.remember(msg)
+	for c := range h.clients {
+		if c.wants(msg.eventType) {
+			h.deliver(c, msg)
 		}
 	}
 }
 
+// deliver sends the message to the client without blocking, the client is dropped if its buffer is full.
+func (h *Handler) deliver(c *client, msg message) bool {
+	select {
+	case c.messages <- msg:
+		return true
+	default:
+		log.Warn().Msg("SSE client does not keep up with events, dropping it")
+		h.unregister(c)
+		return false
+	}
+}
+
+// unregister removes the client and closes its messages, it may be called for the already removed client.
+func (h *Handler) unregister(c *client) {
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	delete(h.clients, c)
+	close(c.messages)
+}
+
+// register adds the client and sends it either the missed events or the initial state.
+func (h *Handler) register(c *client) {
+	h.clients[c] = struct{}{}
+
+	if !h.canReplay(c.lastEventID) {
+		if c.initial != nil {
+			h.deliver(c, *c.initial)
+		}
+		return
+	}
+
+	for _, msg := range h.history {
+		if msg.id > c.lastEventID && c.wants(msg.eventType) && !h.deliver(c, msg) {
+			return
+		}
+	}
+}
+
+// canReplay checks if all the events after the given one are still kept in history.
+func (h *Handler) canReplay(lastEventID uint64) bool {
+	if lastEventID == 0 || lastEventID > h.lastID {
+		return false
+	}
+	if len(h.history) == 0 {
+		return lastEventID == h.lastID
+	}
+	return h.history[0].id <= lastEventID+1
+}
+
+func (h *Handler) remember(msg message) {
+	if len(h.history) == eventHistorySize {
+		copy(h.history, h.history[1:])
+		h.history = h.history[:len(h.history)-1]
+	}
+	h.history = append(h.history, msg)
+}
+
 func (h *Handler) stop() {
 	h.stopOnce.Do(func() { close(h.stopChan) })
 }
 
 func (h *Handler) send(e Event) {
-	marshaled, err := json.Marshal(e)
+	msg, err := newMessage(e)
 	if err != nil {
 		log.Error().Err(err).Msg("Could not marshal SSE message")
 		return
 	}
-	h.messages <- string(marshaled)
+	select {
+	case h.messages <- msg:
+	case <-h.stopChan:
+	}
 }
 
 // ConsumeNodeEvent consumes the node state event
@@ -260,3 +488,138 @@ func (h *Handler) ConsumeStateEvent(event stateEvent.State) {
 		Payload: mapState(event),
 	})
 }
+
+type natEventRes struct {
+	Stage      string `json:"stage"`
+	Successful bool   `json:"successful"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ConsumeNATEvent consumes the NAT traversal event
+func (h *Handler) ConsumeNATEvent(e natEvent.Event) {
+	res := natEventRes{
+		Stage:      e.Stage,
+		Successful: e.Successful,
+	}
+	if e.Error != nil {
+		res.Error = e.Error.Error()
+	}
+	h.send(Event{Type: NATEvent, Payload: res})
+}
+
+// ConsumeServiceStatusEvent consumes the service status event
+func (h *Handler) ConsumeServiceStatusEvent(e servicestate.AppEventServiceStatus) {
+	h.send(Event{Type: ServiceStatusEvent, Payload: e})
+}
+
+type connectionStateRes struct {
+	State      connection.State `json:"state"`
+	SessionID  string           `json:"session_id,omitempty"`
+	ConsumerID string           `json:"consumer_id,omitempty"`
+	Proposal   *proposalDTO     `json:"proposal,omitempty"`
+}
+
+// ConsumeConnectionStateEvent consumes the consumer connection state event
+func (h *Handler) ConsumeConnectionStateEvent(e connection.StateEvent) {
+	h.send(Event{
+		Type: ConnectionStateEvent,
+		Payload: connectionStateRes{
+			State:      e.State,
+			SessionID:  string(e.SessionInfo.SessionID),
+			ConsumerID: e.SessionInfo.ConsumerID.Address,
+			Proposal:   mapEventProposal(e.SessionInfo.Proposal),
+		},
+	})
+}
+
+type sessionStatisticsRes struct {
+	SessionID     string `json:"session_id"`
+	BytesSent     uint64 `json:"bytes_sent"`
+	BytesReceived uint64 `json:"bytes_received"`
+}
+
+// ConsumeStatisticsEvent consumes the consumer session statistics event
+func (h *Handler) ConsumeStatisticsEvent(e connection.SessionStatsEvent) {
+	h.send(Event{
+		Type: SessionStatisticsEvent,
+		Payload: sessionStatisticsRes{
+			SessionID:     string(e.SessionInfo.SessionID),
+			BytesSent:     e.Stats.BytesSent,
+			BytesReceived: e.Stats.BytesReceived,
+		},
+	})
+}
+
+type balanceChangeRes struct {
+	Identity string `json:"identity"`
+	Previous uint64 `json:"previous"`
+	Current  uint64 `json:"current"`
+}
+
+// ConsumeBalanceChangeEvent consumes the identity balance change event
+func (h *Handler) ConsumeBalanceChangeEvent(e pingpong.AppEventBalanceChanged) {
+	h.send(Event{
+		Type: BalanceChangeEvent,
+		Payload: balanceChangeRes{
+			Identity: e.Identity.Address,
+			Previous: e.Previous,
+			Current:  e.Current,
+		},
+	})
+}
+
+type settlementRes struct {
+	ProviderID   string `json:"provider_id"`
+	AccountantID string `json:"accountant_id"`
+	Error        string `json:"error,omitempty"`
+}
+
+// ConsumeSettlementEvent consumes the settlement event
+func (h *Handler) ConsumeSettlementEvent(e pingpong.AppEventSettlement) {
+	res := settlementRes{
+		ProviderID:   e.ProviderID.Address,
+		AccountantID: e.AccountantID.Address,
+	}
+	if e.Error != nil {
+		res.Error = e.Error.Error()
+	}
+	h.send(Event{Type: SettlementEvent, Payload: res})
+}
+
+// ConsumeProposalAddedEvent consumes the proposal added event
+func (h *Handler) ConsumeProposalAddedEvent(p market.ServiceProposal) {
+	if res := mapEventProposal(p); res != nil {
+		h.send(Event{Type: ProposalAddedEvent, Payload: res})
+	}
+}
+
+// ConsumeProposalRemovedEvent consumes the proposal removed event
+func (h *Handler) ConsumeProposalRemovedEvent(p market.ServiceProposal) {
+	if res := mapEventProposal(p); res != nil {
+		h.send(Event{Type: ProposalRemovedEvent, Payload: res})
+	}
+}
+
+type registrationRes struct {
+	Identity string `json:"identity"`
+	Status   string `json:"status"`
+}
+
+// ConsumeRegistrationEvent consumes the identity registration event
+func (h *Handler) ConsumeRegistrationEvent(e registry.AppEventIdentityRegistration) {
+	h.send(Event{
+		Type: RegistrationEvent,
+		Payload: registrationRes{
+			Identity: e.ID.Address,
+			Status:   e.Status.String(),
+		},
+	})
+}
+
+// mapEventProposal maps the proposal carried by event, incomplete proposals are skipped.
+func mapEventProposal(p market.ServiceProposal) *proposalDTO {
+	if p.ServiceDefinition == nil || p.PaymentMethod == nil || !p.IsSupported() {
+		return nil
+	}
+	return proposalToRes(p)
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -75,8 +76,8 @@ func TestHandler_ConsumeNodeEvent_Starts(t *testing.T) {
 	h.ConsumeNodeEvent(me)
 
 	// without starting, this would block forever
-	h.newClients <- make(chan string)
-	h.newClients <- make(chan string)
+	h.newClients <- &client{messages: make(chan message)}
+	h.newClients <- &client{messages: make(chan message)}
 
 	h.stop()
 }
@@ -154,6 +155,7 @@ func TestHandler_SendsInitialAndFollowingStates(t *testing.T) {
 	}
 	h.ConsumeStateEvent(changedState)
 
+	assert.Equal(t, "id: 1", <-results)
 	msg = <-results
 	assert.Regexp(t, "^data:\\s?{.*}$", msg)
 	msgJSON = strings.TrimPrefix(msg, "data: ")
@@ -191,6 +193,7 @@ func TestHandler_SendsInitialAndFollowingStates(t *testing.T) {
 	}
 	h.ConsumeStateEvent(changedState)
 
+	assert.Equal(t, "id: 2", <-results)
 	msg = <-results
 	assert.Regexp(t, "^data:\\s?{.*}$", msg)
 	msgJSON = strings.TrimPrefix(msg, "data: ")
@@ -228,3 +231,143 @@ func TestHandler_SendsInitialAndFollowingStates(t *testing.T) {
 
 	<-serveExit
 }
+
+func balanceMessage(t *testing.T, current uint64) message {
+	msg, err := newMessage(Event{
+		Type:    BalanceChangeEvent,
+		Payload: balanceChangeRes{Identity: "0x1", Current: current},
+	})
+	assert.NoError(t, err)
+	return msg
+}
+
+func natMessage(t *testing.T) message {
+	msg, err := newMessage(Event{Type: NATEvent, Payload: natEventRes{Stage: "hole_punching"}})
+	assert.NoError(t, err)
+	return msg
+}
+
+func receivedIDs(c *client) []uint64 {
+	var ids []uint64
+	for {
+		select {
+		case msg := <-c.messages:
+			ids = append(ids, msg.id)
+		default:
+			return ids
+		}
+	}
+}
+
+func newTestClient(t *testing.T, h *Handler, query, lastEventID string) *client {
+	req := httptest.NewRequest(http.MethodGet, "/events/state"+query, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	c, err := h.newClient(req)
+	assert.NoError(t, err)
+	c.messages = make(chan message, eventHistorySize+1)
+	return c
+}
+
+func TestHandler_FiltersEventTypes(t *testing.T) {
+	h := NewSSEHandler(&mockStateProvider{})
+	c := newTestClient(t, h, "?types=balance-change,registration", "")
+	h.register(c)
+
+	h.broadcast(natMessage(t))
+	h.broadcast(balanceMessage(t, 10))
+
+	assert.Equal(t, []uint64{2}, receivedIDs(c))
+}
+
+func TestHandler_ReplaysMissedEvents(t *testing.T) {
+	h := NewSSEHandler(&mockStateProvider{})
+	h.broadcast(balanceMessage(t, 1))
+	h.broadcast(natMessage(t))
+	h.broadcast(balanceMessage(t, 2))
+
+	c := newTestClient(t, h, "", "1")
+	h.register(c)
+	assert.Equal(t, []uint64{2, 3}, receivedIDs(c))
+
+	c = newTestClient(t, h, "?types=balance-change", "1")
+	h.register(c)
+	assert.Equal(t, []uint64{3}, receivedIDs(c))
+
+	c = newTestClient(t, h, "", "3")
+	h.register(c)
+	assert.Empty(t, receivedIDs(c))
+}
+
+func TestHandler_SendsInitialStateWhenReplayIsNotPossible(t *testing.T) {
+	h := NewSSEHandler(&mockStateProvider{})
+	for i := 0; i < eventHistorySize+5; i++ {
+		h.broadcast(balanceMessage(t, uint64(i)))
+	}
+
+	c := newTestClient(t, h, "", "2")
+	h.register(c)
+	initial := <-c.messages
+	assert.Equal(t, uint64(0), initial.id)
+	assert.Equal(t, StateChangeEvent, initial.eventType)
+	assert.Empty(t, receivedIDs(c))
+
+	c = newTestClient(t, h, "", "1000")
+	h.register(c)
+	assert.Equal(t, StateChangeEvent, (<-c.messages).eventType)
+
+	c = newTestClient(t, h, "?types=balance-change", "")
+	h.register(c)
+	assert.Empty(t, receivedIDs(c))
+}
+
+func TestHandler_DropsClientsWhichDoNotKeepUp(t *testing.T) {
+	h := NewSSEHandler(&mockStateProvider{})
+	slow := newTestClient(t, h, "?types=balance-change", "")
+	slow.messages = make(chan message, 1)
+	h.register(slow)
+	fast := newTestClient(t, h, "?types=balance-change", "")
+	h.register(fast)
+
+	h.broadcast(balanceMessage(t, 1))
+	h.broadcast(balanceMessage(t, 2))
+	h.broadcast(balanceMessage(t, 3))
+
+	assert.Equal(t, []uint64{1, 2, 3}, receivedIDs(fast))
+	assert.Equal(t, 1, len(h.clients))
+	msg, open := <-slow.messages
+	assert.True(t, open)
+	assert.Equal(t, uint64(1), msg.id)
+	_, open = <-slow.messages
+	assert.False(t, open)
+
+	replaying := newTestClient(t, h, "", "1")
+	replaying.messages = make(chan message, 1)
+	h.register(replaying)
+
+	assert.Equal(t, 1, len(h.clients))
+	h.unregister(replaying)
+}
+
+func TestHandler_RejectsInvalidRequests(t *testing.T) {
+	h := NewSSEHandler(&mockStateProvider{})
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/events/state?types=unknown", nil),
+		func() *http.Request {
+			req := httptest.NewRequest(http.MethodGet, "/events/state", nil)
+			req.Header.Set("Last-Event-ID", "abc")
+			return req
+		}(),
+	} {
+		resp := httptest.NewRecorder()
+		h.Sub(resp, req, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	}
+}
+
+func TestFormatMessage(t *testing.T) {
+	assert.Equal(t, "data: {}\n\n", formatMessage(message{data: "{}"}))
+	assert.Equal(t, "id: 7\ndata: {}\n\n", formatMessage(message{id: 7, data: "{}"}))
+}
@@ -20,6 +20,7 @@ package endpoints
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
@@ -27,6 +28,7 @@ import (
 
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/identity/registry"
+	"github.com/mysteriumnetwork/node/session/pingpong"
 	"github.com/mysteriumnetwork/node/tequilapi/utils"
 )
 
@@ -43,16 +45,23 @@ type promiseSettler interface {
 	ForceSettle(providerID, accountantID identity.Identity) error
 }
 
+// settlementHistory lists the settlement attempts
+type settlementHistory interface {
+	List(filter pingpong.SettlementHistoryFilter) ([]pingpong.SettlementHistoryEntry, int, error)
+}
+
 type transactorEndpoint struct {
-	transactor     Transactor
-	promiseSettler promiseSettler
+	transactor        Transactor
+	promiseSettler    promiseSettler
+	settlementHistory settlementHistory
 }
 
 // NewTransactorEndpoint creates and returns transactor endpoint
-func NewTransactorEndpoint(transactor Transactor, promiseSettler promiseSettler) *transactorEndpoint {
+func NewTransactorEndpoint(transactor Transactor, promiseSettler promiseSettler, settlementHistory settlementHistory) *transactorEndpoint {
 	return &transactorEndpoint{
-		transactor:     transactor,
-		promiseSettler: promiseSettler,
+		transactor:        transactor,
+		promiseSettler:    promiseSettler,
+		settlementHistory: settlementHistory,
 	}
 }
 
@@ -165,6 +174,138 @@ func (te *transactorEndpoint) SettleAsync(resp http.ResponseWriter, request *htt
 	resp.WriteHeader(http.StatusAccepted)
 }
 
+// SettlementHistoryEntry represents a single settlement attempt
+// swagger:model SettlementHistoryEntryDTO
+type SettlementHistoryEntry struct {
+	// example: 6ba7b810-9dad-11d1-80b4-00c04fd430c8
+	ID string `json:"id"`
+
+	// example: 0x0000000000000000000000000000000000000001
+	ProviderID string `json:"provider_id"`
+
+	// example: 0x0000000000000000000000000000000000000002
+	AccountantID string `json:"accountant_id"`
+
+	// what triggered the settlement. Possible values are "threshold", "periodic", "fee" and "manual"
+	// example: periodic
+	Strategy string `json:"strategy"`
+
+	// unsettled earnings at the time of the attempt
+	// example: 500000
+	Amount uint64 `json:"amount"`
+
+	// example: 2020-06-01T10:00:00Z
+	SettledAt string `json:"settled_at"`
+
+	// example: true
+	Success bool `json:"success"`
+
+	// how the attempt ended. Possible values are "settled", "failed", "in_progress" and "fee_too_high"
+	// example: settled
+	Outcome string `json:"outcome"`
+
+	// example: settle timeout
+	Error string `json:"error,omitempty"`
+}
+
+// SettlementHistory represents the list of settlement attempts
+// swagger:model SettlementHistoryDTO
+type SettlementHistory struct {
+	Settlements []SettlementHistoryEntry `json:"settlements"`
+
+	// count of all attempts matching the filter
+	// example: 120
+	Total int `json:"total"`
+}
+
+// swagger:operation GET /transactor/settle/history SettlementHistory
+// ---
+// summary: Returns settlement history
+// description: Returns settlement attempts and their outcomes, newest first
+// parameters:
+//   - in: query
+//     name: provider_id
+//     description: Provider identity to filter the settlements by
+//     type: string
+//   - in: query
+//     name: date_from
+//     description: Include attempts made on or after the date, formatted as 2006-01-02
+//     type: string
+//   - in: query
+//     name: date_to
+//     description: Include attempts made on or before the date, formatted as 2006-01-02
+//     type: string
+//   - in: query
+//     name: limit
+//     description: Maximum number of attempts to return
+//     type: integer
+//   - in: query
+//     name: offset
+//     description: Number of attempts to skip
+//     type: integer
+// responses:
+//   200:
+//     description: Settlement history
+//     schema:
+//       "$ref": "#/definitions/SettlementHistoryDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (te *transactorEndpoint) SettlementHistory(resp http.ResponseWriter, request *http.Request, _ httprouter.Params) {
+	filter, err := parseSettlementHistoryFilter(request)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	entries, total, err := te.settlementHistory.List(filter)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	res := SettlementHistory{Settlements: make([]SettlementHistoryEntry, 0, len(entries)), Total: total}
+	for _, entry := range entries {
+		res.Settlements = append(res.Settlements, SettlementHistoryEntry{
+			ID:           entry.ID,
+			ProviderID:   entry.ProviderID.Address,
+			AccountantID: entry.AccountantID.Address,
+			Strategy:     string(entry.Strategy),
+			Amount:       entry.Amount,
+			SettledAt:    entry.Time.Format(time.RFC3339),
+			Success:      entry.Successful(),
+			Outcome:      string(entry.Outcome),
+			Error:        entry.Error,
+		})
+	}
+	utils.WriteAsJSON(res, resp)
+}
+
+func parseSettlementHistoryFilter(request *http.Request) (pingpong.SettlementHistoryFilter, error) {
+	query := request.URL.Query()
+	var filter pingpong.SettlementHistoryFilter
+	if id := query.Get("provider_id"); id != "" {
+		filter.ProviderID = identity.FromAddress(id)
+	}
+
+	var err error
+	if filter.From, filter.To, err = parseDateRange(request); err != nil {
+		return filter, err
+	}
+	if filter.Limit, err = parseNonNegativeInt(query.Get("limit")); err != nil {
+		return filter, errors.Wrap(err, "invalid limit")
+	}
+	if filter.Offset, err = parseNonNegativeInt(query.Get("offset")); err != nil {
+		return filter, errors.Wrap(err, "invalid offset")
+	}
+	return filter, nil
+}
+
 func (te *transactorEndpoint) settle(request *http.Request, settler func(identity.Identity, identity.Identity) error) error {
 	req := SettleRequest{}
 
@@ -263,11 +404,12 @@ func (te *transactorEndpoint) RegisterIdentity(resp http.ResponseWriter, request
 }
 
 // AddRoutesForTransactor attaches Transactor endpoints to router
-func AddRoutesForTransactor(router *httprouter.Router, transactor Transactor, promiseSettler promiseSettler) {
-	te := NewTransactorEndpoint(transactor, promiseSettler)
+func AddRoutesForTransactor(router *httprouter.Router, transactor Transactor, promiseSettler promiseSettler, settlementHistory settlementHistory) {
+	te := NewTransactorEndpoint(transactor, promiseSettler, settlementHistory)
 	router.POST("/identities/:id/register", te.RegisterIdentity)
 	router.GET("/transactor/fees", te.TransactorFees)
 	router.POST("/transactor/topup", te.TopUp)
 	router.POST("/transactor/settle/sync", te.SettleSync)
 	router.POST("/transactor/settle/async", te.SettleAsync)
+	router.GET("/transactor/settle/history", te.SettlementHistory)
 }
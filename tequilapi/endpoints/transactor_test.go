@@ -24,6 +24,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/mocks"
@@ -32,6 +33,7 @@ import (
 
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/identity/registry"
+	"github.com/mysteriumnetwork/node/session/pingpong"
 )
 
 var identityRegData = `{
@@ -47,7 +49,7 @@ func Test_RegisterIdentity(t *testing.T) {
 	router := httprouter.New()
 
 	tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", fakeSignerFactory, mocks.NewEventBus())
-	AddRoutesForTransactor(router, tr, nil)
+	AddRoutesForTransactor(router, tr, nil, nil)
 
 	req, err := http.NewRequest(
 		http.MethodPost,
@@ -70,7 +72,7 @@ func Test_Get_TransactorFees(t *testing.T) {
 	router := httprouter.New()
 
 	tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "registryAddress", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "accountantID", fakeSignerFactory, mocks.NewEventBus())
-	AddRoutesForTransactor(router, tr, nil)
+	AddRoutesForTransactor(router, tr, nil, nil)
 
 	req, err := http.NewRequest(
 		http.MethodGet,
@@ -93,7 +95,7 @@ func Test_TopUp_OK(t *testing.T) {
 	router := httprouter.New()
 
 	tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", fakeSignerFactory, mocks.NewEventBus())
-	AddRoutesForTransactor(router, tr, nil)
+	AddRoutesForTransactor(router, tr, nil, nil)
 
 	topUpData := `{"identity": "0xbe180c8CA53F280C7BE8669596fF7939d933AA10"}`
 	req, err := http.NewRequest(
@@ -118,7 +120,7 @@ func Test_TopUp_BubblesErrors(t *testing.T) {
 	router := httprouter.New()
 
 	tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "0x599d43715DF3070f83355D9D90AE62c159E62A75", "0x599d43715DF3070f83355D9D90AE62c159E62A75", "0x599d43715DF3070f83355D9D90AE62c159E62A75", fakeSignerFactory, mocks.NewEventBus())
-	AddRoutesForTransactor(router, tr, nil)
+	AddRoutesForTransactor(router, tr, nil, nil)
 
 	topUpData := `{"identity": "0x599d43715DF3070f83355D9D90AE62c159E62A75"}`
 	req, err := http.NewRequest(
@@ -146,7 +148,7 @@ func Test_SettleAsync_OK(t *testing.T) {
 	router := httprouter.New()
 
 	tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", fakeSignerFactory, mocks.NewEventBus())
-	AddRoutesForTransactor(router, tr, &mockSettler{})
+	AddRoutesForTransactor(router, tr, &mockSettler{}, nil)
 
 	settleRequest := `{"accountant_id": "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "provider_id": "0xbe180c8CA53F280C7BE8669596fF7939d933AA10"}`
 	req, err := http.NewRequest(
@@ -170,7 +172,7 @@ func Test_SettleAsync_ReturnsError(t *testing.T) {
 	router := httprouter.New()
 
 	tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", fakeSignerFactory, mocks.NewEventBus())
-	AddRoutesForTransactor(router, tr, &mockSettler{errToReturn: errors.New("explosions everywhere")})
+	AddRoutesForTransactor(router, tr, &mockSettler{errToReturn: errors.New("explosions everywhere")}, nil)
 
 	settleRequest := `asdasdasd`
 	req, err := http.NewRequest(
@@ -194,7 +196,7 @@ func Test_SettleSync_OK(t *testing.T) {
 	router := httprouter.New()
 
 	tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", fakeSignerFactory, mocks.NewEventBus())
-	AddRoutesForTransactor(router, tr, &mockSettler{})
+	AddRoutesForTransactor(router, tr, &mockSettler{}, nil)
 
 	settleRequest := `{"accountant_id": "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "provider_id": "0xbe180c8CA53F280C7BE8669596fF7939d933AA10"}`
 	req, err := http.NewRequest(
@@ -218,7 +220,7 @@ func Test_SettleSync_ReturnsError(t *testing.T) {
 	router := httprouter.New()
 
 	tr := registry.NewTransactor(requests.NewHTTPClient(server.URL, requests.DefaultTimeout), server.URL, "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", fakeSignerFactory, mocks.NewEventBus())
-	AddRoutesForTransactor(router, tr, &mockSettler{errToReturn: errors.New("explosions everywhere")})
+	AddRoutesForTransactor(router, tr, &mockSettler{errToReturn: errors.New("explosions everywhere")}, nil)
 
 	settleRequest := `{"accountant_id": "0xbe180c8CA53F280C7BE8669596fF7939d933AA10", "provider_id": "0xbe180c8CA53F280C7BE8669596fF7939d933AA10"}`
 	req, err := http.NewRequest(
@@ -235,6 +237,68 @@ func Test_SettleSync_ReturnsError(t *testing.T) {
 	assert.JSONEq(t, `{"message":"settling failed: explosions everywhere"}`, resp.Body.String())
 }
 
+type mockSettlementHistory struct {
+	entries         []pingpong.SettlementHistoryEntry
+	requestedFilter pingpong.SettlementHistoryFilter
+}
+
+func (m *mockSettlementHistory) List(filter pingpong.SettlementHistoryFilter) ([]pingpong.SettlementHistoryEntry, int, error) {
+	m.requestedFilter = filter
+	return m.entries, len(m.entries), nil
+}
+
+func Test_SettlementHistory(t *testing.T) {
+	history := &mockSettlementHistory{entries: []pingpong.SettlementHistoryEntry{
+		{
+			ID:           "2",
+			ProviderID:   identity.FromAddress("0x1"),
+			AccountantID: identity.FromAddress("0x2"),
+			Strategy:     pingpong.SettlementStrategyManual,
+			Amount:       500,
+			Time:         time.Date(2020, 6, 1, 11, 0, 0, 0, time.UTC),
+			Outcome:      pingpong.SettlementOutcomeFailed,
+			Error:        "settle timeout",
+		},
+		{
+			ID:           "1",
+			ProviderID:   identity.FromAddress("0x1"),
+			AccountantID: identity.FromAddress("0x2"),
+			Strategy:     pingpong.SettlementStrategyPeriodic,
+			Amount:       300,
+			Time:         time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC),
+			Outcome:      pingpong.SettlementOutcomeSettled,
+		},
+	}}
+	router := httprouter.New()
+	AddRoutesForTransactor(router, nil, nil, history)
+
+	req := httptest.NewRequest(http.MethodGet, "/transactor/settle/history?provider_id=0x1&date_from=2020-06-01&date_to=2020-06-01&limit=2&offset=1", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	from := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2020, 6, 2, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, pingpong.SettlementHistoryFilter{ProviderID: identity.FromAddress("0x1"), From: &from, To: &to, Limit: 2, Offset: 1}, history.requestedFilter)
+	assert.JSONEq(t, `{"total": 2, "settlements": [
+		{"id": "2", "provider_id": "0x1", "accountant_id": "0x2", "strategy": "manual", "amount": 500, "settled_at": "2020-06-01T11:00:00Z", "success": false, "outcome": "failed", "error": "settle timeout"},
+		{"id": "1", "provider_id": "0x1", "accountant_id": "0x2", "strategy": "periodic", "amount": 300, "settled_at": "2020-06-01T10:00:00Z", "success": true, "outcome": "settled"}
+	]}`, resp.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/transactor/settle/history", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, pingpong.SettlementHistoryFilter{}, history.requestedFilter)
+
+	req = httptest.NewRequest(http.MethodGet, "/transactor/settle/history?limit=-1", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
 func newTestTransactorServer(mockStatus int, mockResponse string) *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(mockStatus)